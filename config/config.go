@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
@@ -27,6 +28,8 @@ type AppConfig struct {
 	Redis          Redis          // Redis cache configuration
 	Jwt            Jwt            // JWT token configuration
 	ServiceAccount ServiceAccount // Service account token settings
+	Email          Email          // SMTP settings for the emails sent to the users
+	Sms            Sms            // Webhook settings for the text messages sent to the users
 }
 
 // NewAppConfig creates a new AppConfig instance and loads all configuration
@@ -85,6 +88,8 @@ func (a *AppConfig) Load() {
 	a.LoadRedisConfig()
 	a.LoadJwtConfig()
 	a.LoadServiceAccountConfig()
+	a.LoadEmailConfig()
+	a.LoadSmsConfig()
 }
 
 // LoadServerConfig loads server-specific configuration from environment variables.
@@ -97,6 +102,13 @@ func (a *AppConfig) Load() {
 //   - ENABLE_REDIS: Enable Redis caching (default: false)
 //   - TOKEN_CACHE_TTL_IN_MINUTES: Token cache TTL in minutes (default: 1440)
 //   - AUTH_PROVIDER_REFETCH_INTERVAL_IN_MINUTES: Auth provider refresh interval (default: 1)
+//   - INTROSPECTION_CACHE_TTL_IN_SECONDS: Cache duration of active introspection results (default: 0, disabled)
+//   - ACCESS_TOKEN_MAX_SIZE_IN_BYTES: Size cap of self contained access tokens (default: 4096)
+//   - CONSENT_URL: Consent page of third party clients (default: http://localhost:4173/consent)
+//   - MFA_URL: Page asking for the second factor during the login (default: http://localhost:4173/mfa)
+//   - IDENTIFIER_URL: Page asking for the email address of the logins routed by email domain (default: http://localhost:4173/identifier)
+//   - PASSWORD_RESET_TTL_IN_MINUTES: Validity of the password reset links (default: 30)
+//   - PASSWORDLESS_CODE_TTL_IN_MINUTES: Validity of the emailed login codes and magic links (default: 10)
 func (a *AppConfig) LoadServerConfig() {
 	// load the default values
 	// then load from env variables
@@ -137,6 +149,63 @@ func (a *AppConfig) LoadServerConfig() {
 	} else {
 		a.Server.AuthProviderRefetchIntervalInMinutes = 1 // default to 1 minute
 	}
+	introspectionCacheTTL := os.Getenv("INTROSPECTION_CACHE_TTL_IN_SECONDS")
+	if introspectionCacheTTL != "" {
+		ttl, err := strconv.ParseInt(introspectionCacheTTL, 10, 64)
+		if err == nil {
+			a.Server.IntrospectionCacheTTLInSeconds = ttl
+		} else {
+			panic(fmt.Errorf("error converting introspection cache ttl to int: %w", err))
+		}
+	}
+	accessTokenMaxSize := os.Getenv("ACCESS_TOKEN_MAX_SIZE_IN_BYTES")
+	if accessTokenMaxSize != "" {
+		size, err := strconv.ParseInt(accessTokenMaxSize, 10, 64)
+		if err == nil {
+			a.Server.AccessTokenMaxSizeInBytes = size
+		} else {
+			panic(fmt.Errorf("error converting access token max size to int: %w", err))
+		}
+	} else {
+		a.Server.AccessTokenMaxSizeInBytes = 4096 // default to 4KB, well within the common header size limits
+	}
+	a.Server.ConsentUrl = "http://localhost:4173/consent" // consent page of the admin ui
+	consentUrl := os.Getenv("CONSENT_URL")
+	if consentUrl != "" {
+		a.Server.ConsentUrl = consentUrl
+	}
+	a.Server.MfaUrl = "http://localhost:4173/mfa" // mfa page of the admin ui
+	mfaUrl := os.Getenv("MFA_URL")
+	if mfaUrl != "" {
+		a.Server.MfaUrl = mfaUrl
+	}
+	a.Server.IdentifierUrl = "http://localhost:4173/identifier" // identifier page of the admin ui
+	identifierUrl := os.Getenv("IDENTIFIER_URL")
+	if identifierUrl != "" {
+		a.Server.IdentifierUrl = identifierUrl
+	}
+	passwordResetTTL := os.Getenv("PASSWORD_RESET_TTL_IN_MINUTES")
+	if passwordResetTTL != "" {
+		ttl, err := strconv.ParseInt(passwordResetTTL, 10, 64)
+		if err == nil {
+			a.Server.PasswordResetTTLInMinutes = ttl
+		} else {
+			panic(fmt.Errorf("error converting password reset ttl to int: %w", err))
+		}
+	} else {
+		a.Server.PasswordResetTTLInMinutes = 30 // default to 30 minutes
+	}
+	passwordlessCodeTTL := os.Getenv("PASSWORDLESS_CODE_TTL_IN_MINUTES")
+	if passwordlessCodeTTL != "" {
+		ttl, err := strconv.ParseInt(passwordlessCodeTTL, 10, 64)
+		if err == nil {
+			a.Server.PasswordlessCodeTTLInMinutes = ttl
+		} else {
+			panic(fmt.Errorf("error converting passwordless code ttl to int: %w", err))
+		}
+	} else {
+		a.Server.PasswordlessCodeTTLInMinutes = 10 // default to 10 minutes
+	}
 	log.Infow("Loaded Server Configurations",
 		"host", a.Server.Host,
 		"port", a.Server.Port,
@@ -265,19 +334,70 @@ func (a *AppConfig) LoadRedisConfig() {
 }
 
 // LoadJwtConfig loads JWT configuration from environment variables.
-// It sets up the JWT secret key used for token signing and verification.
+// It sets up the algorithm used for signing tokens, the signing key rotation
+// schedule, the legacy shared secret and the OpenID Connect issuer.
 //
 // Environment variables:
-//   - JWT_SECRET: JWT secret key (required)
+//   - JWT_ALGORITHM: Token signing algorithm - RS256, ES256, EdDSA or HS256
+//     (default: HS256 when JWT_SECRET is set, so upgraded deployments keep accepting their tokens, RS256 otherwise)
+//   - JWT_SECRET: JWT secret key (required only when JWT_ALGORITHM is HS256)
+//   - JWT_SIGNING_KEY: PEM encoded private key imported when the signing keyset is empty (optional)
+//   - JWT_KEY_ROTATION_INTERVAL_IN_HOURS: Signing key rotation interval, 0 disables rotation (default: 720)
+//   - JWT_KEY_OVERLAP_IN_HOURS: Duration for which rotated keys still verify tokens (default: 48)
+//   - JWT_ISSUER: Issuer identifier for ID tokens (default: http://<SERVER_HOST>:<SERVER_PORT>)
 //
-// Panics if JWT_SECRET is not provided.
+// Panics if the algorithm is not supported, the rotation settings are not integers
+// or JWT_SECRET is missing in HS256 mode.
 func (a *AppConfig) LoadJwtConfig() {
-	// load from env variables
+	// load the default values
+	// then load from env variables
 	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
+	a.Jwt.Algorithm = sdk.SigningAlgorithmRS256
+	if secret != "" {
+		// deployments from before the asymmetric keys only set the secret,
+		// switching them to RS256 would reject every token they issued
+		a.Jwt.Algorithm = sdk.SigningAlgorithmHS256
+	}
+	algorithm := os.Getenv("JWT_ALGORITHM")
+	if algorithm != "" {
+		a.Jwt.Algorithm = algorithm
+	}
+	switch a.Jwt.Algorithm {
+	case sdk.SigningAlgorithmRS256, sdk.SigningAlgorithmES256, sdk.SigningAlgorithmEdDSA, sdk.SigningAlgorithmHS256:
+	default:
+		panic(fmt.Errorf("unsupported JWT_ALGORITHM %s", a.Jwt.Algorithm))
+	}
+
+	if secret == "" && a.Jwt.Algorithm == sdk.SigningAlgorithmHS256 {
 		panic("JWT_SECRET is required")
 	}
 	a.Jwt.secret = sdk.MaskedBytes(secret)
+	a.Jwt.signingKey = sdk.MaskedBytes(os.Getenv("JWT_SIGNING_KEY"))
+
+	a.Jwt.KeyRotationIntervalInHours = 720 // default to 30 days
+	rotationInterval := os.Getenv("JWT_KEY_ROTATION_INTERVAL_IN_HOURS")
+	if rotationInterval != "" {
+		interval, err := strconv.ParseInt(rotationInterval, 10, 64)
+		if err != nil {
+			panic(fmt.Errorf("error converting jwt key rotation interval to int: %w", err))
+		}
+		a.Jwt.KeyRotationIntervalInHours = interval
+	}
+	a.Jwt.KeyOverlapInHours = 48 // default to 2 days
+	overlap := os.Getenv("JWT_KEY_OVERLAP_IN_HOURS")
+	if overlap != "" {
+		hours, err := strconv.ParseInt(overlap, 10, 64)
+		if err != nil {
+			panic(fmt.Errorf("error converting jwt key overlap to int: %w", err))
+		}
+		a.Jwt.KeyOverlapInHours = hours
+	}
+
+	a.Jwt.Issuer = fmt.Sprintf("http://%s:%s", a.Server.Host, a.Server.Port)
+	issuer := os.Getenv("JWT_ISSUER")
+	if issuer != "" {
+		a.Jwt.Issuer = strings.TrimSuffix(issuer, "/")
+	}
 }
 
 // LoadServiceAccountConfig loads service account configuration from environment variables.
@@ -302,3 +422,52 @@ func (a *AppConfig) LoadServiceAccountConfig() {
 		}
 	}
 }
+
+// LoadEmailConfig loads the SMTP configuration from environment variables.
+// Emails are logged instead of being sent when SMTP_HOST is not set.
+//
+// Environment variables:
+//   - SMTP_HOST: SMTP server host (optional)
+//   - SMTP_PORT: SMTP server port (default: 587)
+//   - SMTP_USERNAME: SMTP username (optional)
+//   - SMTP_PASSWORD: SMTP password (optional)
+//   - EMAIL_FROM: Sender address of the emails (default: no-reply@localhost)
+//
+// Panics if SMTP_PORT cannot be converted to integer.
+func (a *AppConfig) LoadEmailConfig() {
+	a.Email.SmtpHost = os.Getenv("SMTP_HOST")
+	a.Email.SmtpPort = 587
+	portStr := os.Getenv("SMTP_PORT")
+	if portStr != "" {
+		port, err := strconv.Atoi(portStr)
+		if err == nil {
+			a.Email.SmtpPort = port
+		} else {
+			panic(fmt.Errorf("error converting smtp port to int: %w", err))
+		}
+	}
+	a.Email.SmtpUsername = os.Getenv("SMTP_USERNAME")
+	password := os.Getenv("SMTP_PASSWORD")
+	if password != "" {
+		a.Email.SmtpPassword = sdk.MaskedBytes([]byte(password))
+	}
+	a.Email.From = "no-reply@localhost"
+	from := os.Getenv("EMAIL_FROM")
+	if from != "" {
+		a.Email.From = from
+	}
+}
+
+// LoadSmsConfig loads the sms webhook configuration from environment variables.
+// Text messages are logged instead of being sent when SMS_WEBHOOK_URL is not set.
+//
+// Environment variables:
+//   - SMS_WEBHOOK_URL: Url the messages are posted to as json (optional)
+//   - SMS_WEBHOOK_TOKEN: Bearer token sent to the webhook (optional)
+func (a *AppConfig) LoadSmsConfig() {
+	a.Sms.WebhookUrl = os.Getenv("SMS_WEBHOOK_URL")
+	token := os.Getenv("SMS_WEBHOOK_TOKEN")
+	if token != "" {
+		a.Sms.WebhookToken = sdk.MaskedBytes([]byte(token))
+	}
+}
@@ -92,6 +92,12 @@ func TestAppConfig_LoadServerConfig(t *testing.T) {
 				EnableRedis:                          false,
 				TokenCacheTTLInMinutes:               1440,
 				AuthProviderRefetchIntervalInMinutes: 1,
+				AccessTokenMaxSizeInBytes:            4096,
+				ConsentUrl:                           "http://localhost:4173/consent",
+				MfaUrl:                               "http://localhost:4173/mfa",
+				IdentifierUrl:                        "http://localhost:4173/identifier",
+				PasswordResetTTLInMinutes:            30,
+				PasswordlessCodeTTLInMinutes:         10,
 			},
 		},
 		{
@@ -106,6 +112,12 @@ func TestAppConfig_LoadServerConfig(t *testing.T) {
 				EnableRedis:                          false,
 				TokenCacheTTLInMinutes:               1440,
 				AuthProviderRefetchIntervalInMinutes: 1,
+				AccessTokenMaxSizeInBytes:            4096,
+				ConsentUrl:                           "http://localhost:4173/consent",
+				MfaUrl:                               "http://localhost:4173/mfa",
+				IdentifierUrl:                        "http://localhost:4173/identifier",
+				PasswordResetTTLInMinutes:            30,
+				PasswordlessCodeTTLInMinutes:         10,
 			},
 		},
 		{
@@ -119,6 +131,12 @@ func TestAppConfig_LoadServerConfig(t *testing.T) {
 				EnableRedis:                          true,
 				TokenCacheTTLInMinutes:               1440,
 				AuthProviderRefetchIntervalInMinutes: 1,
+				AccessTokenMaxSizeInBytes:            4096,
+				ConsentUrl:                           "http://localhost:4173/consent",
+				MfaUrl:                               "http://localhost:4173/mfa",
+				IdentifierUrl:                        "http://localhost:4173/identifier",
+				PasswordResetTTLInMinutes:            30,
+				PasswordlessCodeTTLInMinutes:         10,
 			},
 		},
 		{
@@ -133,6 +151,146 @@ func TestAppConfig_LoadServerConfig(t *testing.T) {
 				EnableRedis:                          false,
 				TokenCacheTTLInMinutes:               720,
 				AuthProviderRefetchIntervalInMinutes: 5,
+				AccessTokenMaxSizeInBytes:            4096,
+				ConsentUrl:                           "http://localhost:4173/consent",
+				MfaUrl:                               "http://localhost:4173/mfa",
+				IdentifierUrl:                        "http://localhost:4173/identifier",
+				PasswordResetTTLInMinutes:            30,
+				PasswordlessCodeTTLInMinutes:         10,
+			},
+		},
+		{
+			name: "Introspection cache enabled",
+			envVars: map[string]string{
+				"INTROSPECTION_CACHE_TTL_IN_SECONDS": "30",
+			},
+			expected: Server{
+				Host:                                 "localhost",
+				Port:                                 "3000",
+				EnableRedis:                          false,
+				TokenCacheTTLInMinutes:               1440,
+				AuthProviderRefetchIntervalInMinutes: 1,
+				AccessTokenMaxSizeInBytes:            4096,
+				ConsentUrl:                           "http://localhost:4173/consent",
+				MfaUrl:                               "http://localhost:4173/mfa",
+				IdentifierUrl:                        "http://localhost:4173/identifier",
+				PasswordResetTTLInMinutes:            30,
+				PasswordlessCodeTTLInMinutes:         10,
+				IntrospectionCacheTTLInSeconds:       30,
+			},
+		},
+		{
+			name: "Custom access token size cap",
+			envVars: map[string]string{
+				"ACCESS_TOKEN_MAX_SIZE_IN_BYTES": "8192",
+			},
+			expected: Server{
+				Host:                                 "localhost",
+				Port:                                 "3000",
+				EnableRedis:                          false,
+				TokenCacheTTLInMinutes:               1440,
+				AuthProviderRefetchIntervalInMinutes: 1,
+				AccessTokenMaxSizeInBytes:            8192,
+				ConsentUrl:                           "http://localhost:4173/consent",
+				MfaUrl:                               "http://localhost:4173/mfa",
+				IdentifierUrl:                        "http://localhost:4173/identifier",
+				PasswordResetTTLInMinutes:            30,
+				PasswordlessCodeTTLInMinutes:         10,
+			},
+		},
+		{
+			name: "Custom consent url",
+			envVars: map[string]string{
+				"CONSENT_URL": "https://iam.example.com/consent",
+			},
+			expected: Server{
+				Host:                                 "localhost",
+				Port:                                 "3000",
+				EnableRedis:                          false,
+				TokenCacheTTLInMinutes:               1440,
+				AuthProviderRefetchIntervalInMinutes: 1,
+				AccessTokenMaxSizeInBytes:            4096,
+				ConsentUrl:                           "https://iam.example.com/consent",
+				MfaUrl:                               "http://localhost:4173/mfa",
+				IdentifierUrl:                        "http://localhost:4173/identifier",
+				PasswordResetTTLInMinutes:            30,
+				PasswordlessCodeTTLInMinutes:         10,
+			},
+		},
+		{
+			name: "Custom mfa url",
+			envVars: map[string]string{
+				"MFA_URL": "https://iam.example.com/mfa",
+			},
+			expected: Server{
+				Host:                                 "localhost",
+				Port:                                 "3000",
+				EnableRedis:                          false,
+				TokenCacheTTLInMinutes:               1440,
+				AuthProviderRefetchIntervalInMinutes: 1,
+				AccessTokenMaxSizeInBytes:            4096,
+				ConsentUrl:                           "http://localhost:4173/consent",
+				MfaUrl:                               "https://iam.example.com/mfa",
+				IdentifierUrl:                        "http://localhost:4173/identifier",
+				PasswordResetTTLInMinutes:            30,
+				PasswordlessCodeTTLInMinutes:         10,
+			},
+		},
+		{
+			name: "Custom identifier url",
+			envVars: map[string]string{
+				"IDENTIFIER_URL": "https://iam.example.com/identifier",
+			},
+			expected: Server{
+				Host:                                 "localhost",
+				Port:                                 "3000",
+				EnableRedis:                          false,
+				TokenCacheTTLInMinutes:               1440,
+				AuthProviderRefetchIntervalInMinutes: 1,
+				AccessTokenMaxSizeInBytes:            4096,
+				ConsentUrl:                           "http://localhost:4173/consent",
+				MfaUrl:                               "http://localhost:4173/mfa",
+				IdentifierUrl:                        "https://iam.example.com/identifier",
+				PasswordResetTTLInMinutes:            30,
+				PasswordlessCodeTTLInMinutes:         10,
+			},
+		},
+		{
+			name: "Custom password reset ttl",
+			envVars: map[string]string{
+				"PASSWORD_RESET_TTL_IN_MINUTES": "15",
+			},
+			expected: Server{
+				Host:                                 "localhost",
+				Port:                                 "3000",
+				EnableRedis:                          false,
+				TokenCacheTTLInMinutes:               1440,
+				AuthProviderRefetchIntervalInMinutes: 1,
+				AccessTokenMaxSizeInBytes:            4096,
+				ConsentUrl:                           "http://localhost:4173/consent",
+				MfaUrl:                               "http://localhost:4173/mfa",
+				IdentifierUrl:                        "http://localhost:4173/identifier",
+				PasswordResetTTLInMinutes:            15,
+				PasswordlessCodeTTLInMinutes:         10,
+			},
+		},
+		{
+			name: "Custom passwordless code ttl",
+			envVars: map[string]string{
+				"PASSWORDLESS_CODE_TTL_IN_MINUTES": "5",
+			},
+			expected: Server{
+				Host:                                 "localhost",
+				Port:                                 "3000",
+				EnableRedis:                          false,
+				TokenCacheTTLInMinutes:               1440,
+				AuthProviderRefetchIntervalInMinutes: 1,
+				AccessTokenMaxSizeInBytes:            4096,
+				ConsentUrl:                           "http://localhost:4173/consent",
+				MfaUrl:                               "http://localhost:4173/mfa",
+				IdentifierUrl:                        "http://localhost:4173/identifier",
+				PasswordResetTTLInMinutes:            30,
+				PasswordlessCodeTTLInMinutes:         5,
 			},
 		},
 	}
@@ -455,10 +613,26 @@ func TestAppConfig_LoadJwtConfig(t *testing.T) {
 		})
 		assert.NotNil(t, config.Jwt.Secret())
 		assert.Equal(t, sdk.MaskedBytes("my-secret-key"), config.Jwt.Secret())
+		assert.Equal(t, "HS256", config.Jwt.Algorithm)
+	})
+
+	t.Run("JWT secret with an explicit asymmetric algorithm", func(t *testing.T) {
+		cleanEnv()
+		setEnvVars(map[string]string{
+			"JWT_SECRET":    "my-secret-key",
+			"JWT_ALGORITHM": "RS256",
+		})
+		defer cleanEnv()
+
+		config := &AppConfig{}
+		config.LoadJwtConfig()
+		assert.Equal(t, "RS256", config.Jwt.Algorithm)
 	})
 
-	t.Run("Missing JWT secret", func(t *testing.T) {
+	t.Run("Missing JWT secret in legacy mode", func(t *testing.T) {
 		cleanEnv()
+		err := os.Setenv("JWT_ALGORITHM", "HS256")
+		assert.NoError(t, err)
 		defer cleanEnv()
 
 		config := &AppConfig{}
@@ -466,6 +640,87 @@ func TestAppConfig_LoadJwtConfig(t *testing.T) {
 			config.LoadJwtConfig()
 		})
 	})
+
+	t.Run("Missing JWT secret with asymmetric signing", func(t *testing.T) {
+		cleanEnv()
+		defer cleanEnv()
+
+		config := &AppConfig{}
+		assert.NotPanics(t, func() {
+			config.LoadJwtConfig()
+		})
+		assert.Equal(t, "RS256", config.Jwt.Algorithm)
+		assert.Equal(t, int64(720), config.Jwt.KeyRotationIntervalInHours)
+		assert.Equal(t, int64(48), config.Jwt.KeyOverlapInHours)
+		assert.Empty(t, config.Jwt.Secret())
+	})
+
+	t.Run("Signing algorithm and rotation from env", func(t *testing.T) {
+		cleanEnv()
+		setEnvVars(map[string]string{
+			"JWT_ALGORITHM":                      "ES256",
+			"JWT_KEY_ROTATION_INTERVAL_IN_HOURS": "24",
+			"JWT_KEY_OVERLAP_IN_HOURS":           "2",
+		})
+		defer cleanEnv()
+
+		config := &AppConfig{}
+		config.LoadJwtConfig()
+		assert.Equal(t, "ES256", config.Jwt.Algorithm)
+		assert.Equal(t, int64(24), config.Jwt.KeyRotationIntervalInHours)
+		assert.Equal(t, int64(2), config.Jwt.KeyOverlapInHours)
+	})
+
+	t.Run("Unsupported signing algorithm", func(t *testing.T) {
+		cleanEnv()
+		err := os.Setenv("JWT_ALGORITHM", "none")
+		assert.NoError(t, err)
+		defer cleanEnv()
+
+		config := &AppConfig{}
+		assert.Panics(t, func() {
+			config.LoadJwtConfig()
+		})
+	})
+
+	t.Run("Invalid rotation interval", func(t *testing.T) {
+		cleanEnv()
+		err := os.Setenv("JWT_KEY_ROTATION_INTERVAL_IN_HOURS", "monthly")
+		assert.NoError(t, err)
+		defer cleanEnv()
+
+		config := &AppConfig{}
+		assert.Panics(t, func() {
+			config.LoadJwtConfig()
+		})
+	})
+
+	t.Run("Default issuer derived from server config", func(t *testing.T) {
+		cleanEnv()
+		err := os.Setenv("JWT_SECRET", "my-secret-key")
+		assert.NoError(t, err)
+		defer cleanEnv()
+
+		config := &AppConfig{Server: Server{Host: "localhost", Port: "3000"}}
+		config.LoadJwtConfig()
+		assert.Equal(t, "http://localhost:3000", config.Jwt.Issuer)
+		assert.Empty(t, config.Jwt.SigningKey())
+	})
+
+	t.Run("Issuer and signing key from env", func(t *testing.T) {
+		cleanEnv()
+		setEnvVars(map[string]string{
+			"JWT_SECRET":      "my-secret-key",
+			"JWT_ISSUER":      "https://iam.example.com/",
+			"JWT_SIGNING_KEY": "pem-key",
+		})
+		defer cleanEnv()
+
+		config := &AppConfig{}
+		config.LoadJwtConfig()
+		assert.Equal(t, "https://iam.example.com", config.Jwt.Issuer)
+		assert.Equal(t, sdk.MaskedBytes("pem-key"), config.Jwt.SigningKey())
+	})
 }
 
 func TestAppConfig_Load(t *testing.T) {
@@ -615,10 +870,15 @@ func cleanEnv() {
 	envVars := []string{
 		"SERVER_HOST", "SERVER_PORT", "ENABLE_REDIS",
 		"TOKEN_CACHE_TTL_IN_MINUTES", "AUTH_PROVIDER_REFETCH_INTERVAL_IN_MINUTES",
+		"INTROSPECTION_CACHE_TTL_IN_SECONDS", "ACCESS_TOKEN_MAX_SIZE_IN_BYTES", "CONSENT_URL", "MFA_URL", "IDENTIFIER_URL",
+		"PASSWORD_RESET_TTL_IN_MINUTES", "PASSWORDLESS_CODE_TTL_IN_MINUTES",
 		"DEPLOYMENT_ENVIRONMENT", "DEPLOYMENT_NAME",
 		"LOGGER_LEVEL", "DB_HOST", "ENCRYPTER_KEY",
 		"REDIS_HOST", "REDIS_DB", "REDIS_PASSWORD",
-		"JWT_SECRET",
+		"JWT_SECRET", "JWT_SIGNING_KEY", "JWT_ISSUER", "JWT_ALGORITHM",
+		"JWT_KEY_ROTATION_INTERVAL_IN_HOURS", "JWT_KEY_OVERLAP_IN_HOURS",
+		"SMTP_HOST", "SMTP_PORT", "SMTP_USERNAME", "SMTP_PASSWORD", "EMAIL_FROM",
+		"SMS_WEBHOOK_URL", "SMS_WEBHOOK_TOKEN",
 	}
 
 	for _, env := range envVars {
@@ -638,6 +898,82 @@ func setEnvVars(envVars map[string]string) {
 	}
 }
 
+func TestAppConfig_LoadEmailConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected Email
+	}{
+		{
+			name:    "Default values",
+			envVars: map[string]string{},
+			expected: Email{
+				SmtpPort: 587,
+				From:     "no-reply@localhost",
+			},
+		},
+		{
+			name: "Custom smtp server",
+			envVars: map[string]string{
+				"SMTP_HOST":     "smtp.example.com",
+				"SMTP_PORT":     "2525",
+				"SMTP_USERNAME": "mailer",
+				"SMTP_PASSWORD": "secret123",
+				"EMAIL_FROM":    "iam@example.com",
+			},
+			expected: Email{
+				SmtpHost:     "smtp.example.com",
+				SmtpPort:     2525,
+				SmtpUsername: "mailer",
+				SmtpPassword: sdk.MaskedBytes([]byte("secret123")),
+				From:         "iam@example.com",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleanEnv()
+			setEnvVars(tt.envVars)
+			defer cleanEnv()
+
+			config := &AppConfig{}
+			config.LoadEmailConfig()
+
+			assert.Equal(t, tt.expected, config.Email)
+		})
+	}
+}
+
+func TestAppConfig_LoadEmailConfig_InvalidPort(t *testing.T) {
+	cleanEnv()
+	err := os.Setenv("SMTP_PORT", "invalid")
+	assert.NoError(t, err)
+	defer cleanEnv()
+
+	config := &AppConfig{}
+
+	assert.Panics(t, func() {
+		config.LoadEmailConfig()
+	})
+}
+
+func TestAppConfig_LoadSmsConfig(t *testing.T) {
+	cleanEnv()
+	defer cleanEnv()
+
+	config := &AppConfig{}
+	config.LoadSmsConfig()
+	assert.Equal(t, Sms{}, config.Sms)
+
+	setEnvVars(map[string]string{
+		"SMS_WEBHOOK_URL":   "https://sms.example.com/send",
+		"SMS_WEBHOOK_TOKEN": "secret123",
+	})
+	config.LoadSmsConfig()
+	assert.Equal(t, Sms{WebhookUrl: "https://sms.example.com/send", WebhookToken: sdk.MaskedBytes([]byte("secret123"))}, config.Sms)
+}
+
 func TestAppConfig_LoadServiceAccountConfig(t *testing.T) {
 	tests := []struct {
 		name     string
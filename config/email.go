@@ -0,0 +1,14 @@
+package config
+
+import "github.com/melvinodsa/go-iam/sdk"
+
+// Email holds the SMTP settings used to send emails to the users, like password reset links.
+// Emails are only logged when no SMTP host is configured.
+// All fields are public and can be accessed directly.
+type Email struct {
+	SmtpHost     string          `json:"smtp_host"`     // SMTP server host, emails are logged instead of sent when empty
+	SmtpPort     int             `json:"smtp_port"`     // SMTP server port
+	SmtpUsername string          `json:"smtp_username"` // SMTP username (optional)
+	SmtpPassword sdk.MaskedBytes `json:"smtp_password"` // SMTP password (optional, stored as MaskedBytes for security)
+	From         string          `json:"from"`          // Sender address of the emails
+}
@@ -4,7 +4,12 @@ import "github.com/melvinodsa/go-iam/sdk"
 
 // Jwt holds JWT token configuration settings.
 type Jwt struct {
-	secret sdk.MaskedBytes // JWT secret key (private field, use Secret() method to access)
+	secret                     sdk.MaskedBytes // JWT secret key (private field, use Secret() method to access)
+	signingKey                 sdk.MaskedBytes // PEM encoded private key used to seed the signing keyset (private field, use SigningKey() method to access)
+	Issuer                     string          // Issuer identifier advertised in OpenID Connect discovery and ID tokens
+	Algorithm                  string          // Algorithm used for signing access tokens. HS256 enables the legacy shared secret mode
+	KeyRotationIntervalInHours int64           // Age after which the active signing key is rotated. 0 disables scheduled rotation
+	KeyOverlapInHours          int64           // Duration for which a rotated key still verifies tokens
 }
 
 // Secret returns the JWT secret key used for signing and verifying JWT tokens.
@@ -14,3 +19,11 @@ type Jwt struct {
 func (j Jwt) Secret() sdk.MaskedBytes {
 	return j.secret
 }
+
+// SigningKey returns the PEM encoded private key imported into the signing keyset
+// when the keyset is empty. An empty value means a new key is generated instead.
+//
+// Returns the key configured via JWT_SIGNING_KEY environment variable.
+func (j Jwt) SigningKey() sdk.MaskedBytes {
+	return j.signingKey
+}
@@ -8,6 +8,13 @@ type Server struct {
 	EnableRedis                          bool   // Whether Redis caching is enabled
 	TokenCacheTTLInMinutes               int64  // Token cache time-to-live in minutes
 	AuthProviderRefetchIntervalInMinutes int64  // Auth provider data refresh interval in minutes
+	IntrospectionCacheTTLInSeconds       int64  // Cache duration of active introspection results in seconds, 0 disables it
+	AccessTokenMaxSizeInBytes            int64  // Size cap of self contained access tokens, larger ones are issued as opaque tokens
+	ConsentUrl                           string // Page asking the users to consent to the scopes requested by third party clients
+	MfaUrl                               string // Page asking the users for their second factor during the login
+	IdentifierUrl                        string // Page asking the users for their email address to route their login to the auth provider of its domain
+	PasswordResetTTLInMinutes            int64  // Validity of the password reset links in minutes
+	PasswordlessCodeTTLInMinutes         int64  // Validity of the emailed login codes and magic links in minutes
 }
 
 // Deployment holds deployment environment configuration settings.
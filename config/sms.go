@@ -0,0 +1,11 @@
+package config
+
+import "github.com/melvinodsa/go-iam/sdk"
+
+// Sms holds the settings of the webhook relaying the text messages sent to the users, like login codes.
+// Messages are only logged when no webhook is configured.
+// All fields are public and can be accessed directly.
+type Sms struct {
+	WebhookUrl   string          `json:"webhook_url"`   // Url the messages are posted to, messages are logged instead of sent when empty
+	WebhookToken sdk.MaskedBytes `json:"webhook_token"` // Bearer token of the webhook (optional, stored as MaskedBytes for security)
+}
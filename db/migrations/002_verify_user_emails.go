@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/db"
+	"github.com/melvinodsa/go-iam/db/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func init() {
+	db.RegisterMigration(db.MigrationInfo{
+		Version:     "002",
+		Name:        "verify_user_emails",
+		Description: "Mark the email addresses of the users created before the verification was tracked as verified",
+		Up:          verifyUserEmailsUp,
+		Down:        verifyUserEmailsDown,
+	})
+}
+
+// verifyUserEmailsUp marks the existing addresses as verified, they were trusted for logins until now.
+// Leaving them unverified would refuse the logins with external auth providers the users already had.
+func verifyUserEmailsUp(ctx context.Context, dbConn db.DB) error {
+	userModel := models.GetUserModel()
+
+	log.Info("Starting user email verification migration...")
+
+	filter := bson.M{
+		userModel.EmailKey:         bson.M{"$nin": bson.A{"", nil}},
+		userModel.EmailVerifiedKey: bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			userModel.EmailVerifiedKey: true,
+			"updated_by":               "migration_002",
+		},
+	}
+	result, err := dbConn.UpdateMany(ctx, userModel, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to verify the user emails: %w", err)
+	}
+
+	log.Infof("Successfully verified the emails of %d users", result.ModifiedCount)
+	return nil
+}
+
+func verifyUserEmailsDown(ctx context.Context, dbConn db.DB) error {
+	userModel := models.GetUserModel()
+
+	log.Info("Rolling back user email verification migration...")
+
+	update := bson.M{
+		"$unset": bson.M{
+			userModel.EmailVerifiedKey: "",
+		},
+	}
+	result, err := dbConn.UpdateMany(ctx, userModel, bson.M{}, update)
+	if err != nil {
+		return fmt.Errorf("failed to roll back the user emails: %w", err)
+	}
+
+	log.Infof("Successfully rolled back the emails of %d users", result.ModifiedCount)
+	return nil
+}
@@ -0,0 +1,238 @@
+package migrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/db"
+	"github.com/melvinodsa/go-iam/db/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// ldapProviderType is the provider type of the LDAP auth providers
+	ldapProviderType = "LDAP"
+	// ldapGroupRoleMappingParam held the json object mapping the DNs of groups to role ids before the group roles of the auth providers
+	ldapGroupRoleMappingParam = "@LDAP/GROUP_ROLE_MAPPING"
+)
+
+func init() {
+	db.RegisterMigration(db.MigrationInfo{
+		Version:     "003",
+		Name:        "ldap_group_roles",
+		Description: "Move the group role mapping param of the LDAP auth providers to their group roles",
+		Up:          ldapGroupRolesUp,
+		Down:        ldapGroupRolesDown,
+	})
+}
+
+// ldapGroupRolesUp converts the group role mapping param of the LDAP auth providers into their group roles.
+// The sync used to manage every mapped role, so the mapped roles the users hold are recorded on the users as granted
+// through the groups of their identities. Otherwise leaving a group would no longer remove its role.
+func ldapGroupRolesUp(ctx context.Context, dbConn db.DB) error {
+	providerModel := models.GetAuthProviderModel()
+
+	log.Info("Starting LDAP group roles migration...")
+
+	filter := bson.M{
+		providerModel.ProviderKey:        ldapProviderType,
+		providerModel.ParamsKey + ".key": ldapGroupRoleMappingParam,
+	}
+	providers, err := findAuthProviders(ctx, dbConn, filter)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range providers {
+		groupRoles, err := groupRolesFromMapping(p)
+		if err != nil {
+			return err
+		}
+		err = recordIdentityGroupRoles(ctx, dbConn, p.Id, groupRoles)
+		if err != nil {
+			return err
+		}
+		update := bson.M{
+			"$set":  bson.M{"group_roles": groupRoles},
+			"$pull": bson.M{providerModel.ParamsKey: bson.M{"key": ldapGroupRoleMappingParam}},
+		}
+		_, err = dbConn.UpdateOne(ctx, providerModel, bson.M{providerModel.IdKey: p.Id}, update)
+		if err != nil {
+			return fmt.Errorf("failed to update the auth provider %s: %w", p.Id, err)
+		}
+	}
+
+	log.Infof("Successfully migrated the group role mapping of %d auth providers", len(providers))
+	return nil
+}
+
+func ldapGroupRolesDown(ctx context.Context, dbConn db.DB) error {
+	providerModel := models.GetAuthProviderModel()
+
+	log.Info("Rolling back LDAP group roles migration...")
+
+	filter := bson.M{
+		providerModel.ProviderKey: ldapProviderType,
+		"group_roles.0":           bson.M{"$exists": true},
+	}
+	providers, err := findAuthProviders(ctx, dbConn, filter)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range providers {
+		// the param maps a group to a single role, the last one of a group wins
+		mapping := map[string]string{}
+		for _, gr := range p.GroupRoles {
+			mapping[gr.Group] = gr.RoleId
+		}
+		value, err := json.Marshal(mapping)
+		if err != nil {
+			return fmt.Errorf("failed to encode the group role mapping of %s: %w", p.Id, err)
+		}
+		update := bson.M{
+			"$push":  bson.M{providerModel.ParamsKey: models.AuthProviderParam{Label: "Group role mapping", Key: ldapGroupRoleMappingParam, Value: string(value)}},
+			"$unset": bson.M{"group_roles": ""},
+		}
+		_, err = dbConn.UpdateOne(ctx, providerModel, bson.M{providerModel.IdKey: p.Id}, update)
+		if err != nil {
+			return fmt.Errorf("failed to roll back the auth provider %s: %w", p.Id, err)
+		}
+		err = forgetIdentityGroupRoles(ctx, dbConn, p.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Infof("Successfully rolled back the group roles of %d auth providers", len(providers))
+	return nil
+}
+
+func findAuthProviders(ctx context.Context, dbConn db.DB, filter bson.M) ([]models.AuthProvider, error) {
+	cursor, err := dbConn.Find(ctx, models.GetAuthProviderModel(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find auth providers: %w", err)
+	}
+	defer func() {
+		if err := cursor.Close(context.Background()); err != nil {
+			log.Errorf("failed to close cursor: %w", err)
+		}
+	}()
+	var providers []models.AuthProvider
+	if err := cursor.All(ctx, &providers); err != nil {
+		return nil, fmt.Errorf("failed to decode auth providers: %w", err)
+	}
+	return providers, nil
+}
+
+// groupRolesFromMapping reads the group role mapping param of the auth provider, sorted by group
+func groupRolesFromMapping(p models.AuthProvider) ([]models.AuthProviderGroupRole, error) {
+	mapping := map[string]string{}
+	for _, param := range p.Params {
+		if param.Key != ldapGroupRoleMappingParam {
+			continue
+		}
+		if err := json.Unmarshal([]byte(param.Value), &mapping); err != nil {
+			return nil, fmt.Errorf("failed to read the group role mapping of %s: %w", p.Id, err)
+		}
+	}
+	groupRoles := make([]models.AuthProviderGroupRole, 0, len(mapping))
+	for group, roleId := range mapping {
+		groupRoles = append(groupRoles, models.AuthProviderGroupRole{Group: group, RoleId: roleId})
+	}
+	sort.Slice(groupRoles, func(i, j int) bool { return groupRoles[i].Group < groupRoles[j].Group })
+	return groupRoles, nil
+}
+
+// heldGroupRoles returns the roles of the group roles the user holds
+func heldGroupRoles(groupRoles []models.AuthProviderGroupRole, roles map[string]models.UserRoles) []string {
+	held := []string{}
+	for _, gr := range groupRoles {
+		if _, ok := roles[gr.RoleId]; ok && !slices.Contains(held, gr.RoleId) {
+			held = append(held, gr.RoleId)
+		}
+	}
+	return held
+}
+
+// recordIdentityGroupRoles records the mapped roles the users of the identities of the auth provider hold as granted
+// through the groups of the identities
+func recordIdentityGroupRoles(ctx context.Context, dbConn db.DB, providerId string, groupRoles []models.AuthProviderGroupRole) error {
+	userModel := models.GetUserModel()
+	return forEachIdentityUser(ctx, dbConn, providerId, func(identity models.UserIdentity, user models.User) error {
+		held := heldGroupRoles(groupRoles, user.Roles)
+		if len(held) == 0 {
+			return nil
+		}
+		granted := bson.M{}
+		for _, roleId := range held {
+			granted[userModel.GroupRolesKey+"."+roleId] = identity.Id
+		}
+		_, err := dbConn.UpdateOne(ctx, userModel, bson.M{userModel.IdKey: user.Id}, bson.M{"$addToSet": granted})
+		if err != nil {
+			return fmt.Errorf("failed to update the user %s: %w", user.Id, err)
+		}
+		return nil
+	})
+}
+
+// forgetIdentityGroupRoles drops the identities of the auth provider from the roles their users are granted through groups
+func forgetIdentityGroupRoles(ctx context.Context, dbConn db.DB, providerId string) error {
+	userModel := models.GetUserModel()
+	return forEachIdentityUser(ctx, dbConn, providerId, func(identity models.UserIdentity, user models.User) error {
+		groupRoles := map[string][]string{}
+		for roleId, identityIds := range user.GroupRoles {
+			identityIds = slices.DeleteFunc(slices.Clone(identityIds), func(id string) bool { return id == identity.Id })
+			if len(identityIds) > 0 {
+				groupRoles[roleId] = identityIds
+			}
+		}
+		if maps.EqualFunc(groupRoles, user.GroupRoles, slices.Equal) {
+			return nil
+		}
+		_, err := dbConn.UpdateOne(ctx, userModel, bson.M{userModel.IdKey: user.Id}, bson.M{"$set": bson.M{userModel.GroupRolesKey: groupRoles}})
+		if err != nil {
+			return fmt.Errorf("failed to roll back the user %s: %w", user.Id, err)
+		}
+		return nil
+	})
+}
+
+// forEachIdentityUser calls fn with the identities of the auth provider and their users, skipping the users that
+// cannot be read
+func forEachIdentityUser(ctx context.Context, dbConn db.DB, providerId string, fn func(identity models.UserIdentity, user models.User) error) error {
+	identityModel := models.GetUserIdentityModel()
+	userModel := models.GetUserModel()
+
+	cursor, err := dbConn.Find(ctx, identityModel, bson.M{identityModel.AuthProviderIdKey: providerId})
+	if err != nil {
+		return fmt.Errorf("failed to find the identities of the auth provider %s: %w", providerId, err)
+	}
+	defer func() {
+		if err := cursor.Close(context.Background()); err != nil {
+			log.Errorf("failed to close cursor: %w", err)
+		}
+	}()
+	var identities []models.UserIdentity
+	if err := cursor.All(ctx, &identities); err != nil {
+		return fmt.Errorf("failed to decode the identities of the auth provider %s: %w", providerId, err)
+	}
+
+	for _, identity := range identities {
+		var user models.User
+		err := dbConn.FindOne(ctx, userModel, bson.M{userModel.IdKey: identity.UserId}).Decode(&user)
+		if err != nil {
+			log.Warnf("Skipping the identity %s, its user could not be read: %v", identity.Id, err)
+			continue
+		}
+		if err := fn(identity, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,12 +2,16 @@ package migrations
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/melvinodsa/go-iam/db/models"
 	"github.com/melvinodsa/go-iam/services/policy/system"
+	"github.com/melvinodsa/go-iam/utils/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // TestMigration_UpdateUserPolicies tests the user policies migration
@@ -183,3 +187,119 @@ func TestMigration_WithMocks(t *testing.T) {
 		assert.Contains(t, newPolicyData, accessPolicy.ID())
 	})
 }
+
+// TestMigration_VerifyUserEmails tests the user email verification migration
+func TestMigration_VerifyUserEmails(t *testing.T) {
+	ctx := context.Background()
+	userModel := models.GetUserModel()
+
+	t.Run("up verifies the existing email addresses", func(t *testing.T) {
+		mockDB := new(test.MockDB)
+		filter := bson.M{
+			"email":          bson.M{"$nin": bson.A{"", nil}},
+			"email_verified": bson.M{"$exists": false},
+		}
+		update := bson.M{"$set": bson.M{"email_verified": true, "updated_by": "migration_002"}}
+		mockDB.On("UpdateMany", ctx, userModel, filter, update, mock.Anything).Return(&mongo.UpdateResult{ModifiedCount: 2}, nil)
+
+		assert.NoError(t, verifyUserEmailsUp(ctx, mockDB))
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("down removes the verification status", func(t *testing.T) {
+		mockDB := new(test.MockDB)
+		update := bson.M{"$unset": bson.M{"email_verified": ""}}
+		mockDB.On("UpdateMany", ctx, userModel, bson.M{}, update, mock.Anything).Return(&mongo.UpdateResult{}, nil)
+
+		assert.NoError(t, verifyUserEmailsDown(ctx, mockDB))
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("up fails on database errors", func(t *testing.T) {
+		mockDB := new(test.MockDB)
+		mockDB.On("UpdateMany", ctx, userModel, mock.Anything, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{}, errors.New("db error"))
+
+		assert.ErrorContains(t, verifyUserEmailsUp(ctx, mockDB), "failed to verify the user emails")
+	})
+}
+
+// TestMigration_LdapGroupRoles tests the migration of the LDAP group role mapping param
+func TestMigration_LdapGroupRoles(t *testing.T) {
+	ctx := context.Background()
+	providerModel := models.GetAuthProviderModel()
+	identityModel := models.GetUserIdentityModel()
+	userModel := models.GetUserModel()
+	provider := models.AuthProvider{
+		Id:       "provider-1",
+		Provider: "LDAP",
+		Params: []models.AuthProviderParam{
+			{Key: "@LDAP/URL", Value: "ldaps://dc1.example.com"},
+			{Key: "@LDAP/GROUP_ROLE_MAPPING", Value: `{"cn=ops,dc=example,dc=com":"role-ops","cn=admins,dc=example,dc=com":"role-admin"}`},
+		},
+	}
+	groupRoles := []models.AuthProviderGroupRole{
+		{Group: "cn=admins,dc=example,dc=com", RoleId: "role-admin"},
+		{Group: "cn=ops,dc=example,dc=com", RoleId: "role-ops"},
+	}
+
+	t.Run("reads the mapping sorted by group", func(t *testing.T) {
+		result, err := groupRolesFromMapping(provider)
+		assert.NoError(t, err)
+		assert.Equal(t, groupRoles, result)
+
+		_, err = groupRolesFromMapping(models.AuthProvider{Params: []models.AuthProviderParam{{Key: "@LDAP/GROUP_ROLE_MAPPING", Value: `["role-ops"]`}}})
+		assert.Error(t, err)
+	})
+
+	t.Run("keeps the mapped roles the user holds", func(t *testing.T) {
+		roles := map[string]models.UserRoles{"role-ops": {Id: "role-ops"}, "role-manual": {Id: "role-manual"}}
+		assert.Equal(t, []string{"role-ops"}, heldGroupRoles(groupRoles, roles))
+		assert.Empty(t, heldGroupRoles(groupRoles, nil))
+	})
+
+	t.Run("up moves the mapping to the group roles", func(t *testing.T) {
+		mockDB := new(test.MockDB)
+		providers, _ := mongo.NewCursorFromDocuments([]interface{}{provider}, nil, nil)
+		identities, _ := mongo.NewCursorFromDocuments([]interface{}{models.UserIdentity{Id: "identity-1", UserId: "user-1", AuthProviderId: "provider-1"}}, nil, nil)
+		user := mongo.NewSingleResultFromDocument(models.User{Id: "user-1", Roles: map[string]models.UserRoles{"role-admin": {Id: "role-admin"}}}, nil, nil)
+		mockDB.On("Find", ctx, providerModel, bson.M{"provider": "LDAP", "params.key": "@LDAP/GROUP_ROLE_MAPPING"}, mock.Anything).Return(providers, nil)
+		mockDB.On("Find", ctx, identityModel, bson.M{"auth_provider_id": "provider-1"}, mock.Anything).Return(identities, nil)
+		mockDB.On("FindOne", ctx, userModel, bson.M{"id": "user-1"}, mock.Anything).Return(user)
+		mockDB.On("UpdateOne", ctx, userModel, bson.M{"id": "user-1"}, bson.M{"$addToSet": bson.M{"group_roles.role-admin": "identity-1"}}, mock.Anything).Return(&mongo.UpdateResult{ModifiedCount: 1}, nil)
+		update := bson.M{
+			"$set":  bson.M{"group_roles": groupRoles},
+			"$pull": bson.M{"params": bson.M{"key": "@LDAP/GROUP_ROLE_MAPPING"}},
+		}
+		mockDB.On("UpdateOne", ctx, providerModel, bson.M{"id": "provider-1"}, update, mock.Anything).Return(&mongo.UpdateResult{ModifiedCount: 1}, nil)
+
+		assert.NoError(t, ldapGroupRolesUp(ctx, mockDB))
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("up fails on database errors", func(t *testing.T) {
+		mockDB := new(test.MockDB)
+		mockDB.On("Find", ctx, providerModel, mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+		assert.ErrorContains(t, ldapGroupRolesUp(ctx, mockDB), "failed to find auth providers")
+	})
+
+	t.Run("down drops the identities from the group roles of the users", func(t *testing.T) {
+		mockDB := new(test.MockDB)
+		migrated := models.AuthProvider{Id: "provider-1", Provider: "LDAP", GroupRoles: groupRoles}
+		providers, _ := mongo.NewCursorFromDocuments([]interface{}{migrated}, nil, nil)
+		identities, _ := mongo.NewCursorFromDocuments([]interface{}{models.UserIdentity{Id: "identity-1", UserId: "user-1", AuthProviderId: "provider-1"}}, nil, nil)
+		user := mongo.NewSingleResultFromDocument(models.User{Id: "user-1", GroupRoles: map[string][]string{
+			"role-admin": {"identity-1"},
+			"role-ops":   {"identity-1", "identity-2"},
+		}}, nil, nil)
+		mockDB.On("Find", ctx, providerModel, bson.M{"provider": "LDAP", "group_roles.0": bson.M{"$exists": true}}, mock.Anything).Return(providers, nil)
+		mockDB.On("UpdateOne", ctx, providerModel, bson.M{"id": "provider-1"}, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{ModifiedCount: 1}, nil)
+		mockDB.On("Find", ctx, identityModel, bson.M{"auth_provider_id": "provider-1"}, mock.Anything).Return(identities, nil)
+		mockDB.On("FindOne", ctx, userModel, bson.M{"id": "user-1"}, mock.Anything).Return(user)
+		update := bson.M{"$set": bson.M{"group_roles": map[string][]string{"role-ops": {"identity-2"}}}}
+		mockDB.On("UpdateOne", ctx, userModel, bson.M{"id": "user-1"}, update, mock.Anything).Return(&mongo.UpdateResult{ModifiedCount: 1}, nil)
+
+		assert.NoError(t, ldapGroupRolesDown(ctx, mockDB))
+		mockDB.AssertExpectations(t)
+	})
+}
@@ -10,17 +10,36 @@ type AuthProviderType string
 // Auth providers handle external authentication services like Google, GitHub, etc.
 // Each provider belongs to a project and can be configured with custom parameters.
 type AuthProvider struct {
-	Id        string              `bson:"id"`         // Unique identifier for the auth provider
-	Name      string              `bson:"name"`       // Human-readable name of the auth provider
-	Icon      string              `bson:"icon"`       // Icon URL or identifier for UI display
-	Provider  AuthProviderType    `bson:"provider"`   // Type of authentication provider
-	Params    []AuthProviderParam `bson:"params"`     // Configuration parameters for the provider
-	ProjectId string              `bson:"project_id"` // ID of the project this provider belongs to
-	Enabled   bool                `bson:"enabled"`    // Whether the provider is currently active
-	CreatedAt *time.Time          `bson:"created_at"` // Timestamp when the provider was created
-	UpdatedAt *time.Time          `bson:"updated_at"` // Timestamp when the provider was last updated
-	CreatedBy string              `bson:"created_by"` // User who created the provider
-	UpdatedBy string              `bson:"updated_by"` // User who last updated the provider
+	Id                   string                   `bson:"id"`                     // Unique identifier for the auth provider
+	Name                 string                   `bson:"name"`                   // Human-readable name of the auth provider
+	Icon                 string                   `bson:"icon"`                   // Icon URL or identifier for UI display
+	Provider             AuthProviderType         `bson:"provider"`               // Type of authentication provider
+	Params               []AuthProviderParam      `bson:"params"`                 // Configuration parameters for the provider
+	ProjectId            string                   `bson:"project_id"`             // ID of the project this provider belongs to
+	Enabled              bool                     `bson:"enabled"`                // Whether the provider is currently active
+	RequireVerifiedEmail bool                     `bson:"require_verified_email"` // Whether logins with unverified email addresses are refused
+	Provisioning         AuthProviderProvisioning `bson:"provisioning"`           // Rules for the users created on their first login
+	GroupRoles           []AuthProviderGroupRole  `bson:"group_roles"`            // Roles the members of the groups at the provider get
+	CreatedAt            *time.Time               `bson:"created_at"`             // Timestamp when the provider was created
+	UpdatedAt            *time.Time               `bson:"updated_at"`             // Timestamp when the provider was last updated
+	CreatedBy            string                   `bson:"created_by"`             // User who created the provider
+	UpdatedBy            string                   `bson:"updated_by"`             // User who last updated the provider
+}
+
+// AuthProviderProvisioning holds the rules for creating the users on their first login with an auth provider.
+type AuthProviderProvisioning struct {
+	Disabled        bool                  `bson:"disabled"`         // Whether unknown users are refused instead of created
+	AllowedDomains  []string              `bson:"allowed_domains"`  // Email domains users are created for, any when empty
+	BlockedDomains  []string              `bson:"blocked_domains"`  // Email domains users are never created for
+	DefaultRoles    []string              `bson:"default_roles"`    // Roles assigned to the created users
+	DefaultPolicies map[string]UserPolicy `bson:"default_policies"` // Policies assigned to the created users
+	RequireApproval bool                  `bson:"require_approval"` // Whether the created users wait for an admin to enable them
+}
+
+// AuthProviderGroupRole maps a group at an auth provider to the role its members get.
+type AuthProviderGroupRole struct {
+	Group  string `bson:"group"`   // Id, name or DN of the group at the provider
+	RoleId string `bson:"role_id"` // Id of the role the members of the group get
 }
 
 // AuthProviderParam represents a configuration parameter for an authentication provider.
@@ -6,23 +6,30 @@ import "time"
 // Clients are applications that can authenticate users and access protected resources.
 // Each client belongs to a project and can have various configuration options.
 type Client struct {
-	Id                    string     `bson:"id"`                       // Unique identifier for the client
-	Name                  string     `bson:"name"`                     // Human-readable name of the client
-	Description           string     `bson:"description"`              // Detailed description of the client's purpose
-	Secret                string     `bson:"secret"`                   // Client secret for authentication
-	Tags                  []string   `bson:"tags"`                     // Tags for categorizing and filtering clients
-	RedirectURLs          []string   `bson:"redirect_urls"`            // Allowed redirect URLs for OAuth2 flows
-	DefaultAuthProviderId string     `bson:"default_auth_provider_id"` // Default authentication provider for this client
-	GoIamClient           bool       `bson:"go_iam_client"`            // Indicates if this is a Go-IAM system client
-	ProjectId             string     `bson:"project_id"`               // ID of the project this client belongs to
-	ServiceAccountEmail   string     `bson:"service_account_email"`    // Email for service account authentication
-	Scopes                []string   `bson:"scopes"`                   // OAuth2 scopes this client can request
-	Enabled               bool       `bson:"enabled"`                  // Whether the client is currently active
-	LinkedUserId          string     `bson:"linked_user_id"`           // User ID for service account clients
-	CreatedAt             *time.Time `bson:"created_at"`               // Timestamp when the client was created
-	CreatedBy             string     `bson:"created_by"`               // User who created the client
-	UpdatedAt             *time.Time `bson:"updated_at"`               // Timestamp when the client was last updated
-	UpdatedBy             string     `bson:"updated_by"`               // User who last updated the client
+	Id                     string            `bson:"id"`                        // Unique identifier for the client
+	Name                   string            `bson:"name"`                      // Human-readable name of the client
+	Description            string            `bson:"description"`               // Detailed description of the client's purpose
+	Secret                 string            `bson:"secret"`                    // Client secret for authentication
+	Tags                   []string          `bson:"tags"`                      // Tags for categorizing and filtering clients
+	RedirectURLs           []string          `bson:"redirect_urls"`             // Allowed redirect URLs for OAuth2 flows
+	PostLogoutRedirectURLs []string          `bson:"post_logout_redirect_urls"` // Allowed redirect URLs after logout
+	DefaultAuthProviderId  string            `bson:"default_auth_provider_id"`  // Default authentication provider for this client
+	GoIamClient            bool              `bson:"go_iam_client"`             // Indicates if this is a Go-IAM system client
+	ProjectId              string            `bson:"project_id"`                // ID of the project this client belongs to
+	ServiceAccountEmail    string            `bson:"service_account_email"`     // Email for service account authentication
+	Scopes                 []string          `bson:"scopes"`                    // OAuth2 scopes this client can request
+	Enabled                bool              `bson:"enabled"`                   // Whether the client is currently active
+	LinkedUserId           string            `bson:"linked_user_id"`            // User ID for service account clients
+	Public                 bool              `bson:"public"`                    // Whether the client cannot keep its secret and uses PKCE instead
+	PkceRequired           bool              `bson:"pkce_required"`             // Whether logins of the client have to use PKCE
+	PkceAllowPlain         bool              `bson:"pkce_allow_plain"`          // Whether the plain PKCE method is accepted
+	AccessTokenFormat      string            `bson:"access_token_format"`       // Format of the access tokens issued to the client
+	AccessTokenClaims      map[string]string `bson:"access_token_claims"`       // Claims mapping of the self contained access tokens
+	ThirdParty             bool              `bson:"third_party"`               // Whether the users have to consent to the client's scopes
+	CreatedAt              *time.Time        `bson:"created_at"`                // Timestamp when the client was created
+	CreatedBy              string            `bson:"created_by"`                // User who created the client
+	UpdatedAt              *time.Time        `bson:"updated_at"`                // Timestamp when the client was last updated
+	UpdatedBy              string            `bson:"updated_by"`                // User who last updated the client
 }
 
 // ClientModel provides database access patterns and field mappings for Client entities.
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Consent represents the scopes a user has allowed a third party client to access.
+type Consent struct {
+	Id        string     `bson:"id"`         // Unique identifier for the consent
+	UserId    string     `bson:"user_id"`    // User who granted the consent
+	ClientId  string     `bson:"client_id"`  // Client the consent was granted to
+	ProjectId string     `bson:"project_id"` // Project the client belongs to
+	Scopes    []string   `bson:"scopes"`     // Scopes the client is allowed to access
+	CreatedAt *time.Time `bson:"created_at"` // Timestamp when the consent was first granted
+	UpdatedAt *time.Time `bson:"updated_at"` // Timestamp when the consent was last extended
+}
+
+// ConsentModel provides database access patterns and field mappings for Consent entities.
+type ConsentModel struct {
+	iam                 // Embedded struct providing DbName() method
+	IdKey        string // BSON field key for consent ID
+	UserIdKey    string // BSON field key for user ID
+	ClientIdKey  string // BSON field key for client ID
+	ScopesKey    string // BSON field key for the granted scopes
+	UpdatedAtKey string // BSON field key for updated timestamp
+}
+
+// Name returns the MongoDB collection name for consents.
+// This implements the DbCollection interface.
+func (c ConsentModel) Name() string {
+	return "consents"
+}
+
+// GetConsentModel returns a properly initialized ConsentModel with all field mappings.
+func GetConsentModel() ConsentModel {
+	return ConsentModel{
+		IdKey:        "id",
+		UserIdKey:    "user_id",
+		ClientIdKey:  "client_id",
+		ScopesKey:    "scopes",
+		UpdatedAtKey: "updated_at",
+	}
+}
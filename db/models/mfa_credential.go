@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// MfaCredential represents the TOTP second factor of a user in the database.
+// The secret is encrypted and only hashes of the recovery codes are stored.
+type MfaCredential struct {
+	Id                 string     `bson:"id"`                   // Unique identifier for the credential
+	UserId             string     `bson:"user_id"`              // User the credential belongs to
+	ProjectId          string     `bson:"project_id"`           // Project of the user
+	Secret             string     `bson:"secret"`               // Encrypted TOTP secret
+	RecoveryCodeHashes []string   `bson:"recovery_code_hashes"` // sha256 hashes of the unused recovery codes
+	LastUsedStep       int64      `bson:"last_used_step"`       // Time step of the last accepted code
+	EnabledAt          *time.Time `bson:"enabled_at"`           // Timestamp when the enrollment was confirmed
+	CreatedAt          *time.Time `bson:"created_at"`           // Timestamp when the enrollment was started
+	UpdatedAt          *time.Time `bson:"updated_at"`           // Timestamp when the credential was last updated
+}
+
+// MfaCredentialModel provides database access patterns and field mappings for MfaCredential entities.
+type MfaCredentialModel struct {
+	iam                          // Embedded struct providing DbName() method
+	IdKey                 string // BSON field key for credential ID
+	UserIdKey             string // BSON field key for user ID
+	SecretKey             string // BSON field key for the encrypted secret
+	RecoveryCodeHashesKey string // BSON field key for recovery code hashes
+	LastUsedStepKey       string // BSON field key for the last accepted time step
+	EnabledAtKey          string // BSON field key for enabled timestamp
+	UpdatedAtKey          string // BSON field key for updated timestamp
+}
+
+// Name returns the MongoDB collection name for mfa credentials.
+// This implements the DbCollection interface.
+func (m MfaCredentialModel) Name() string {
+	return "mfa_credentials"
+}
+
+// GetMfaCredentialModel returns a properly initialized MfaCredentialModel with all field mappings.
+func GetMfaCredentialModel() MfaCredentialModel {
+	return MfaCredentialModel{
+		IdKey:                 "id",
+		UserIdKey:             "user_id",
+		SecretKey:             "secret",
+		RecoveryCodeHashesKey: "recovery_code_hashes",
+		LastUsedStepKey:       "last_used_step",
+		EnabledAtKey:          "enabled_at",
+		UpdatedAtKey:          "updated_at",
+	}
+}
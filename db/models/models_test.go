@@ -17,6 +17,7 @@ func TestUserModel(t *testing.T) {
 		assert.Equal(t, "id", m.IdKey)
 		assert.Equal(t, "name", m.NameKey)
 		assert.Equal(t, "email", m.EmailKey)
+		assert.Equal(t, "email_verified", m.EmailVerifiedKey)
 		assert.Equal(t, "project_id", m.ProjectIDKey)
 	})
 }
@@ -138,6 +139,98 @@ func TestRoleMapModel(t *testing.T) {
 	})
 }
 
+func TestSigningKeyModel(t *testing.T) {
+	t.Run("Name returns correct collection name", func(t *testing.T) {
+		m := GetSigningKeyModel()
+		assert.Equal(t, "signing_keys", m.Name())
+	})
+
+	t.Run("GetSigningKeyModel returns correct field keys", func(t *testing.T) {
+		m := GetSigningKeyModel()
+		assert.Equal(t, "id", m.IdKey)
+		assert.Equal(t, "status", m.StatusKey)
+		assert.Equal(t, "created_at", m.CreatedAtKey)
+		assert.Equal(t, "rotated_at", m.RotatedAtKey)
+		assert.Equal(t, "expires_at", m.ExpiresAtKey)
+	})
+}
+
+func TestRefreshTokenModel(t *testing.T) {
+	t.Run("Name returns correct collection name", func(t *testing.T) {
+		m := GetRefreshTokenModel()
+		assert.Equal(t, "refresh_tokens", m.Name())
+	})
+
+	t.Run("GetRefreshTokenModel returns correct field keys", func(t *testing.T) {
+		m := GetRefreshTokenModel()
+		assert.Equal(t, "id", m.IdKey)
+		assert.Equal(t, "family_id", m.FamilyIdKey)
+		assert.Equal(t, "token_hash", m.TokenHashKey)
+		assert.Equal(t, "used_at", m.UsedAtKey)
+	})
+}
+
+func TestConsentModel(t *testing.T) {
+	t.Run("Name returns correct collection name", func(t *testing.T) {
+		m := GetConsentModel()
+		assert.Equal(t, "consents", m.Name())
+	})
+
+	t.Run("GetConsentModel returns correct field keys", func(t *testing.T) {
+		m := GetConsentModel()
+		assert.Equal(t, "id", m.IdKey)
+		assert.Equal(t, "user_id", m.UserIdKey)
+		assert.Equal(t, "client_id", m.ClientIdKey)
+	})
+}
+
+func TestPasswordCredentialModel(t *testing.T) {
+	t.Run("Name returns correct collection name", func(t *testing.T) {
+		m := GetPasswordCredentialModel()
+		assert.Equal(t, "password_credentials", m.Name())
+	})
+
+	t.Run("GetPasswordCredentialModel returns correct field keys", func(t *testing.T) {
+		m := GetPasswordCredentialModel()
+		assert.Equal(t, "id", m.IdKey)
+		assert.Equal(t, "project_id", m.ProjectIdKey)
+		assert.Equal(t, "email", m.EmailKey)
+		assert.Equal(t, "password_hash", m.PasswordHashKey)
+	})
+}
+
+func TestPasskeyModel(t *testing.T) {
+	t.Run("Name returns correct collection name", func(t *testing.T) {
+		m := GetPasskeyModel()
+		assert.Equal(t, "passkeys", m.Name())
+	})
+
+	t.Run("GetPasskeyModel returns correct field keys", func(t *testing.T) {
+		m := GetPasskeyModel()
+		assert.Equal(t, "id", m.IdKey)
+		assert.Equal(t, "user_id", m.UserIdKey)
+		assert.Equal(t, "credential_id", m.CredentialIdKey)
+		assert.Equal(t, "sign_count", m.SignCountKey)
+	})
+}
+
+func TestUserIdentityModel(t *testing.T) {
+	t.Run("Name returns correct collection name", func(t *testing.T) {
+		m := GetUserIdentityModel()
+		assert.Equal(t, "user_identities", m.Name())
+	})
+
+	t.Run("GetUserIdentityModel returns correct field keys", func(t *testing.T) {
+		m := GetUserIdentityModel()
+		assert.Equal(t, "id", m.IdKey)
+		assert.Equal(t, "user_id", m.UserIdKey)
+		assert.Equal(t, "auth_provider_id", m.AuthProviderIdKey)
+		assert.Equal(t, "subject", m.SubjectKey)
+		assert.Equal(t, "last_login_at", m.LastLoginAtKey)
+		assert.Equal(t, "unlinked_at", m.UnlinkedAtKey)
+	})
+}
+
 func TestAllModelsDbName(t *testing.T) {
 	t.Run("All models return correct database name", func(t *testing.T) {
 		models := []interface{ DbName() string }{
@@ -149,6 +242,12 @@ func TestAllModelsDbName(t *testing.T) {
 			GetClientModel(),
 			GetAuthProviderModel(),
 			GetMigrationModel(),
+			GetSigningKeyModel(),
+			GetRefreshTokenModel(),
+			GetConsentModel(),
+			GetPasswordCredentialModel(),
+			GetPasskeyModel(),
+			GetUserIdentityModel(),
 		}
 
 		for _, model := range models {
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Passkey represents a WebAuthn credential of a user in the database.
+// Only the public key of the credential is stored, so it is not encrypted.
+type Passkey struct {
+	Id           string     `bson:"id"`            // Unique identifier for the passkey
+	UserId       string     `bson:"user_id"`       // User the passkey belongs to
+	ProjectId    string     `bson:"project_id"`    // Project of the user
+	CredentialId string     `bson:"credential_id"` // Base64url credential id given by the authenticator
+	PublicKey    []byte     `bson:"public_key"`    // COSE encoded public key of the credential
+	SignCount    uint32     `bson:"sign_count"`    // Signature counter of the authenticator
+	Transports   []string   `bson:"transports"`    // Transports of the authenticator
+	Name         string     `bson:"name"`          // Friendly name given by the user
+	CreatedAt    *time.Time `bson:"created_at"`    // Timestamp when the passkey was registered
+	LastUsedAt   *time.Time `bson:"last_used_at"`  // Timestamp when the passkey was last used
+}
+
+// PasskeyModel provides database access patterns and field mappings for Passkey entities.
+type PasskeyModel struct {
+	iam                    // Embedded struct providing DbName() method
+	IdKey           string // BSON field key for passkey ID
+	UserIdKey       string // BSON field key for user ID
+	ProjectIdKey    string // BSON field key for project ID
+	CredentialIdKey string // BSON field key for credential ID
+	SignCountKey    string // BSON field key for the signature counter
+	CreatedAtKey    string // BSON field key for creation timestamp
+	LastUsedAtKey   string // BSON field key for last used timestamp
+}
+
+// Name returns the MongoDB collection name for passkeys.
+// This implements the DbCollection interface.
+func (m PasskeyModel) Name() string {
+	return "passkeys"
+}
+
+// GetPasskeyModel returns a properly initialized PasskeyModel with all field mappings.
+func GetPasskeyModel() PasskeyModel {
+	return PasskeyModel{
+		IdKey:           "id",
+		UserIdKey:       "user_id",
+		ProjectIdKey:    "project_id",
+		CredentialIdKey: "credential_id",
+		SignCountKey:    "sign_count",
+		CreatedAtKey:    "created_at",
+		LastUsedAtKey:   "last_used_at",
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// PasswordCredential represents the password of a user of a project in the database.
+// Passwords are kept out of the users collection and only their bcrypt hash is stored.
+type PasswordCredential struct {
+	Id             string     `bson:"id"`               // Unique identifier for the credential
+	ProjectId      string     `bson:"project_id"`       // Project the credential belongs to
+	AuthProviderId string     `bson:"auth_provider_id"` // Password auth provider the user signed up with
+	Email          string     `bson:"email"`            // Email address of the user in lower case
+	Name           string     `bson:"name"`             // Name given by the user at signup
+	PasswordHash   string     `bson:"password_hash"`    // bcrypt hash of the password
+	VerifiedAt     *time.Time `bson:"verified_at"`      // Timestamp when the email address was verified
+	CreatedAt      *time.Time `bson:"created_at"`       // Timestamp when the credential was created
+	UpdatedAt      *time.Time `bson:"updated_at"`       // Timestamp when the password was last changed
+}
+
+// PasswordCredentialModel provides database access patterns and field mappings for PasswordCredential entities.
+type PasswordCredentialModel struct {
+	iam                    // Embedded struct providing DbName() method
+	IdKey           string // BSON field key for credential ID
+	ProjectIdKey    string // BSON field key for project ID
+	EmailKey        string // BSON field key for email
+	PasswordHashKey string // BSON field key for password hash
+	VerifiedAtKey   string // BSON field key for verified timestamp
+	UpdatedAtKey    string // BSON field key for updated timestamp
+}
+
+// Name returns the MongoDB collection name for password credentials.
+// This implements the DbCollection interface.
+func (p PasswordCredentialModel) Name() string {
+	return "password_credentials"
+}
+
+// GetPasswordCredentialModel returns a properly initialized PasswordCredentialModel with all field mappings.
+func GetPasswordCredentialModel() PasswordCredentialModel {
+	return PasswordCredentialModel{
+		IdKey:           "id",
+		ProjectIdKey:    "project_id",
+		EmailKey:        "email",
+		PasswordHashKey: "password_hash",
+		VerifiedAtKey:   "verified_at",
+		UpdatedAtKey:    "updated_at",
+	}
+}
@@ -6,14 +6,59 @@ import "time"
 // Projects are organizational units that contain users, clients, roles, and resources.
 // They provide isolation and multi-tenancy in the IAM system.
 type Project struct {
-	Id          string     `bson:"id"`          // Unique identifier for the project
-	Name        string     `bson:"name"`        // Human-readable name of the project
-	Tags        []string   `bson:"tags"`        // Tags for categorizing and filtering projects
-	Description string     `bson:"description"` // Detailed description of the project's purpose
-	CreatedAt   *time.Time `bson:"created_at"`  // Timestamp when the project was created
-	CreatedBy   string     `bson:"created_by"`  // User who created the project
-	UpdatedAt   *time.Time `bson:"updated_at"`  // Timestamp when the project was last updated
-	UpdatedBy   string     `bson:"updated_by"`  // User who last updated the project
+	Id             string          `bson:"id"`              // Unique identifier for the project
+	Name           string          `bson:"name"`            // Human-readable name of the project
+	Tags           []string        `bson:"tags"`            // Tags for categorizing and filtering projects
+	Description    string          `bson:"description"`     // Detailed description of the project's purpose
+	Scopes         []ProjectScope  `bson:"scopes"`          // Scopes defined for the clients of the project
+	PasswordPolicy *PasswordPolicy `bson:"password_policy"` // Rules for the passwords of the users of the project
+	MfaPolicy      *MfaPolicy      `bson:"mfa_policy"`      // Which users of the project have to use a second factor
+	WebAuthn       *WebAuthnConfig `bson:"webauthn"`        // Relying party of the passkeys of the users of the project
+	LoginRouting   *LoginRouting   `bson:"login_routing"`   // Auth providers the logins of the users are routed to by email domain
+	CreatedAt      *time.Time      `bson:"created_at"`      // Timestamp when the project was created
+	CreatedBy      string          `bson:"created_by"`      // User who created the project
+	UpdatedAt      *time.Time      `bson:"updated_at"`      // Timestamp when the project was last updated
+	UpdatedBy      string          `bson:"updated_by"`      // User who last updated the project
+}
+
+// ProjectScope is a scope defined for the clients of a project along with its description.
+type ProjectScope struct {
+	Name        string `bson:"name"`        // Name of the scope
+	Description string `bson:"description"` // Description of the access granted by the scope
+}
+
+// PasswordPolicy holds the rules for the passwords of the users of a project.
+type PasswordPolicy struct {
+	MinLength        int  `bson:"min_length"`        // Minimum number of characters
+	RequireUppercase bool `bson:"require_uppercase"` // Whether an uppercase letter is required
+	RequireLowercase bool `bson:"require_lowercase"` // Whether a lowercase letter is required
+	RequireNumber    bool `bson:"require_number"`    // Whether a digit is required
+	RequireSymbol    bool `bson:"require_symbol"`    // Whether a symbol is required
+}
+
+// MfaPolicy decides which users of a project have to use a second factor.
+type MfaPolicy struct {
+	Mode    string   `bson:"mode"`     // One of optional, required or roles
+	RoleIds []string `bson:"role_ids"` // Roles requiring a second factor in the roles mode
+}
+
+// WebAuthnConfig is the relying party the passkeys of the users of a project are bound to.
+type WebAuthnConfig struct {
+	RpId    string   `bson:"rp_id"`   // Domain of the relying party
+	RpName  string   `bson:"rp_name"` // Name shown by the authenticators
+	Origins []string `bson:"origins"` // Origins allowed to run the ceremonies
+}
+
+// LoginRouting routes the logins of the users of a project to auth providers by the domain of their email.
+type LoginRouting struct {
+	Domains  []LoginDomainRoute `bson:"domains"`  // Auth providers of the email domains
+	Fallback []string           `bson:"fallback"` // Auth providers of the other domains
+}
+
+// LoginDomainRoute routes the logins of the users of an email domain to an auth provider.
+type LoginDomainRoute struct {
+	Domain         string `bson:"domain"`           // Email domain
+	AuthProviderId string `bson:"auth_provider_id"` // Auth provider of the domain
 }
 
 // ProjectModel provides database access patterns and field mappings for Project entities.
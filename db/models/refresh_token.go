@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// RefreshToken represents a go-iam issued refresh token in the database.
+// The raw token is never stored, only its hash. The session it grants access to
+// is kept encrypted since it can carry the auth provider tokens.
+type RefreshToken struct {
+	Id        string     `bson:"id"`         // Unique identifier for the refresh token
+	FamilyId  string     `bson:"family_id"`  // Identifier shared by all the rotations of a login
+	ClientId  string     `bson:"client_id"`  // Client the token was issued to
+	UserId    string     `bson:"user_id"`    // User the token was issued for
+	TokenHash string     `bson:"token_hash"` // SHA-256 hash of the raw token
+	AuthToken string     `bson:"auth_token"` // Encrypted session details
+	ExpiresAt *time.Time `bson:"expires_at"` // Expiry of the refresh token
+	CreatedAt *time.Time `bson:"created_at"` // Timestamp when the token was issued
+	UsedAt    *time.Time `bson:"used_at"`    // Timestamp when the token was rotated
+	RevokedAt *time.Time `bson:"revoked_at"` // Timestamp when the token was revoked
+}
+
+// RefreshTokenModel provides database access patterns and field mappings for RefreshToken entities.
+type RefreshTokenModel struct {
+	iam                 // Embedded struct providing DbName() method
+	IdKey        string // BSON field key for refresh token ID
+	FamilyIdKey  string // BSON field key for token family ID
+	UserIdKey    string // BSON field key for user ID
+	ClientIdKey  string // BSON field key for client ID
+	TokenHashKey string // BSON field key for token hash
+	UsedAtKey    string // BSON field key for used timestamp
+	RevokedAtKey string // BSON field key for revoked timestamp
+}
+
+// Name returns the MongoDB collection name for refresh tokens.
+// This implements the DbCollection interface.
+func (r RefreshTokenModel) Name() string {
+	return "refresh_tokens"
+}
+
+// GetRefreshTokenModel returns a properly initialized RefreshTokenModel with all field mappings.
+func GetRefreshTokenModel() RefreshTokenModel {
+	return RefreshTokenModel{
+		IdKey:        "id",
+		FamilyIdKey:  "family_id",
+		UserIdKey:    "user_id",
+		ClientIdKey:  "client_id",
+		TokenHashKey: "token_hash",
+		UsedAtKey:    "used_at",
+		RevokedAtKey: "revoked_at",
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// SigningKey represents a token signing key in the Go IAM keyset.
+// The private key is stored PEM encoded and encrypted with the encrypter key.
+type SigningKey struct {
+	Id         string     `bson:"id"`          // Key identifier used as the kid header
+	Algorithm  string     `bson:"algorithm"`   // Signing algorithm of the key
+	Status     string     `bson:"status"`      // Lifecycle state - active, rotated or retired
+	PrivateKey string     `bson:"private_key"` // Encrypted PEM encoded private key
+	CreatedAt  *time.Time `bson:"created_at"`  // Timestamp when the key was generated
+	RotatedAt  *time.Time `bson:"rotated_at"`  // Timestamp when the key stopped signing tokens
+	ExpiresAt  *time.Time `bson:"expires_at"`  // End of the verification overlap window
+	RetiredAt  *time.Time `bson:"retired_at"`  // Timestamp when the key was retired
+}
+
+// SigningKeyModel provides database access patterns and field mappings for SigningKey entities.
+// It embeds the iam struct to inherit the database name and implements collection operations.
+type SigningKeyModel struct {
+	iam                 // Embedded struct providing DbName() method
+	IdKey        string // BSON field key for key ID
+	StatusKey    string // BSON field key for key status
+	CreatedAtKey string // BSON field key for the creation timestamp
+	RotatedAtKey string // BSON field key for the rotation timestamp
+	ExpiresAtKey string // BSON field key for the end of the overlap window
+}
+
+// Name returns the MongoDB collection name for signing keys.
+// This implements the DbCollection interface.
+func (s SigningKeyModel) Name() string {
+	return "signing_keys"
+}
+
+// GetSigningKeyModel returns a properly initialized SigningKeyModel with all field mappings.
+//
+// Returns a SigningKeyModel instance with all BSON field keys mapped to their respective field names.
+func GetSigningKeyModel() SigningKeyModel {
+	return SigningKeyModel{
+		IdKey:        "id",
+		StatusKey:    "status",
+		CreatedAtKey: "created_at",
+		RotatedAtKey: "rotated_at",
+		ExpiresAtKey: "expires_at",
+	}
+}
@@ -6,22 +6,25 @@ import "time"
 // Users are the primary subjects of authentication and authorization,
 // with assigned roles, resources, and policies that determine their access rights.
 type User struct {
-	Id             string                  `bson:"id"`                         // Unique identifier for the user
-	ProjectId      string                  `bson:"project_id"`                 // ID of the project this user belongs to
-	Name           string                  `bson:"name"`                       // Display name of the user
-	Email          string                  `bson:"email"`                      // Email address of the user
-	Phone          string                  `bson:"phone"`                      // Phone number of the user
-	Enabled        bool                    `bson:"enabled"`                    // Whether the user account is active
-	ProfilePic     string                  `bson:"profile_pic"`                // URL or path to the user's profile picture
-	Expiry         *time.Time              `bson:"expiry"`                     // Optional expiration date for the user account
-	Roles          map[string]UserRoles    `bson:"roles"`                      // Roles assigned to the user
-	Resources      map[string]UserResource `bson:"resources"`                  // Resources the user has access to
-	Policies       map[string]UserPolicy   `bson:"policies"`                   // Policies applied to the user
-	LinkedClientId string                  `bson:"linked_client_id,omitempty"` // Client ID for service account users
-	CreatedAt      *time.Time              `bson:"created_at"`                 // Timestamp when the user was created
-	CreatedBy      string                  `bson:"created_by"`                 // User who created this user
-	UpdatedAt      *time.Time              `bson:"updated_at"`                 // Timestamp when the user was last updated
-	UpdatedBy      string                  `bson:"updated_by"`                 // User who last updated this user
+	Id              string                  `bson:"id"`                         // Unique identifier for the user
+	ProjectId       string                  `bson:"project_id"`                 // ID of the project this user belongs to
+	Name            string                  `bson:"name"`                       // Display name of the user
+	Email           string                  `bson:"email"`                      // Email address of the user
+	EmailVerified   bool                    `bson:"email_verified"`             // Whether the ownership of the email address was verified
+	Phone           string                  `bson:"phone"`                      // Phone number of the user
+	Enabled         bool                    `bson:"enabled"`                    // Whether the user account is active
+	PendingApproval bool                    `bson:"pending_approval"`           // Whether the user waits for an admin to enable them
+	ProfilePic      string                  `bson:"profile_pic"`                // URL or path to the user's profile picture
+	Expiry          *time.Time              `bson:"expiry"`                     // Optional expiration date for the user account
+	Roles           map[string]UserRoles    `bson:"roles"`                      // Roles assigned to the user
+	Resources       map[string]UserResource `bson:"resources"`                  // Resources the user has access to
+	Policies        map[string]UserPolicy   `bson:"policies"`                   // Policies applied to the user
+	GroupRoles      map[string][]string     `bson:"group_roles"`                // Ids of the identities whose groups at the auth provider grant each role
+	LinkedClientId  string                  `bson:"linked_client_id,omitempty"` // Client ID for service account users
+	CreatedAt       *time.Time              `bson:"created_at"`                 // Timestamp when the user was created
+	CreatedBy       string                  `bson:"created_by"`                 // User who created this user
+	UpdatedAt       *time.Time              `bson:"updated_at"`                 // Timestamp when the user was last updated
+	UpdatedBy       string                  `bson:"updated_by"`                 // User who last updated this user
 }
 
 // UserPolicy represents a policy assignment to a user with dynamic value mapping.
@@ -64,18 +67,20 @@ type UserRoles struct {
 // UserModel provides database access patterns and field mappings for User entities.
 // It embeds the iam struct to inherit the database name and implements collection operations.
 type UserModel struct {
-	iam                 // Embedded struct providing DbName() method
-	IdKey        string // BSON field key for user ID
-	NameKey      string // BSON field key for user name
-	EmailKey     string // BSON field key for user email
-	PhoneKey     string // BSON field key for user phone
-	EnabledKey   string // BSON field key for enabled status
-	RolesIdKey   string // BSON field key for user roles
-	PoliciesKey  string // BSON field key for user policies
-	ResourcesKey string // BSON field key for user resources
-	IsEnabledKey string // BSON field key for enabled status (alternative)
-	ProjectIDKey string // BSON field key for project ID
-	ExpiryKey    string // BSON field key for account expiry
+	iam                     // Embedded struct providing DbName() method
+	IdKey            string // BSON field key for user ID
+	NameKey          string // BSON field key for user name
+	EmailKey         string // BSON field key for user email
+	EmailVerifiedKey string // BSON field key for the email verification status
+	PhoneKey         string // BSON field key for user phone
+	EnabledKey       string // BSON field key for enabled status
+	RolesIdKey       string // BSON field key for user roles
+	PoliciesKey      string // BSON field key for user policies
+	ResourcesKey     string // BSON field key for user resources
+	GroupRolesKey    string // BSON field key for the roles granted through groups
+	IsEnabledKey     string // BSON field key for enabled status (alternative)
+	ProjectIDKey     string // BSON field key for project ID
+	ExpiryKey        string // BSON field key for account expiry
 }
 
 // Name returns the MongoDB collection name for users.
@@ -90,16 +95,18 @@ func (u UserModel) Name() string {
 // Returns a UserModel instance with all BSON field keys mapped to their respective field names.
 func GetUserModel() UserModel {
 	return UserModel{
-		IdKey:        "id",
-		NameKey:      "name",
-		EmailKey:     "email",
-		PhoneKey:     "phone",
-		EnabledKey:   "enabled",
-		RolesIdKey:   "roles",
-		ResourcesKey: "resources",
-		PoliciesKey:  "policies",
-		IsEnabledKey: "is_enabled",
-		ProjectIDKey: "project_id",
-		ExpiryKey:    "expiry",
+		IdKey:            "id",
+		NameKey:          "name",
+		EmailKey:         "email",
+		EmailVerifiedKey: "email_verified",
+		PhoneKey:         "phone",
+		EnabledKey:       "enabled",
+		RolesIdKey:       "roles",
+		ResourcesKey:     "resources",
+		PoliciesKey:      "policies",
+		GroupRolesKey:    "group_roles",
+		IsEnabledKey:     "is_enabled",
+		ProjectIDKey:     "project_id",
+		ExpiryKey:        "expiry",
 	}
 }
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// UserIdentity represents an identity of a user at an auth provider in the database.
+type UserIdentity struct {
+	Id             string     `bson:"id"`               // Unique identifier for the linked identity
+	UserId         string     `bson:"user_id"`          // User the identity is linked to
+	ProjectId      string     `bson:"project_id"`       // Project of the user
+	AuthProviderId string     `bson:"auth_provider_id"` // Auth provider the identity belongs to
+	Subject        string     `bson:"subject"`          // Stable id of the user at the auth provider
+	Email          string     `bson:"email"`            // Email address reported at the last login
+	EmailVerified  bool       `bson:"email_verified"`   // Whether the email address was reported as verified
+	LastLoginAt    *time.Time `bson:"last_login_at"`    // Timestamp of the last login with the identity
+	CreatedAt      *time.Time `bson:"created_at"`       // Timestamp when the identity was linked
+	UnlinkedAt     *time.Time `bson:"unlinked_at"`      // Timestamp when the user unlinked the identity
+}
+
+// UserIdentityModel provides database access patterns and field mappings for UserIdentity entities.
+type UserIdentityModel struct {
+	iam                      // Embedded struct providing DbName() method
+	IdKey             string // BSON field key for identity ID
+	UserIdKey         string // BSON field key for user ID
+	ProjectIdKey      string // BSON field key for project ID
+	AuthProviderIdKey string // BSON field key for auth provider ID
+	SubjectKey        string // BSON field key for the subject
+	EmailKey          string // BSON field key for the email address
+	EmailVerifiedKey  string // BSON field key for the email verified flag
+	LastLoginAtKey    string // BSON field key for last login timestamp
+	CreatedAtKey      string // BSON field key for creation timestamp
+	UnlinkedAtKey     string // BSON field key for unlink timestamp
+}
+
+// Name returns the MongoDB collection name for user identities.
+// This implements the DbCollection interface.
+func (m UserIdentityModel) Name() string {
+	return "user_identities"
+}
+
+// GetUserIdentityModel returns a properly initialized UserIdentityModel with all field mappings.
+func GetUserIdentityModel() UserIdentityModel {
+	return UserIdentityModel{
+		IdKey:             "id",
+		UserIdKey:         "user_id",
+		ProjectIdKey:      "project_id",
+		AuthProviderIdKey: "auth_provider_id",
+		SubjectKey:        "subject",
+		EmailKey:          "email",
+		EmailVerifiedKey:  "email_verified",
+		LastLoginAtKey:    "last_login_at",
+		CreatedAtKey:      "created_at",
+		UnlinkedAtKey:     "unlinked_at",
+	}
+}
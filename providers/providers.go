@@ -5,7 +5,9 @@
 package providers
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
@@ -42,11 +44,12 @@ type Provider struct {
 // The function performs the following initialization steps:
 // 1. Establishes database connection and runs migrations
 // 2. Configures caching (Redis or mock based on configuration)
-// 3. Initializes encryption and JWT services
+// 3. Initializes encryption and JWT services with the persisted signing keyset
 // 4. Creates all business logic services with proper dependencies
 // 5. Sets up authentication and project middlewares
 // 6. Configures event subscriptions for cross-service communication
-// 7. Creates default project if it doesn't exist
+// 7. Starts the background rotation of the JWT signing keys
+// 8. Creates default project if it doesn't exist
 //
 // Parameters:
 //   - cnf: Application configuration containing all service settings
@@ -71,9 +74,18 @@ func InjectDefaultProviders(cnf config.AppConfig) (*Provider, error) {
 		return nil, fmt.Errorf("error creating encrypter: %w", err)
 	}
 
-	jwtSvc := jwt.NewService(cnf.Jwt.Secret())
+	jwtSvc, err := jwt.NewServiceWithStore(context.Background(), jwt.NewStore(enc, d), jwt.Options{
+		Algorithm:        cnf.Jwt.Algorithm,
+		Secret:           cnf.Jwt.Secret(),
+		SeedKey:          cnf.Jwt.SigningKey(),
+		RotationInterval: time.Duration(cnf.Jwt.KeyRotationIntervalInHours) * time.Hour,
+		OverlapWindow:    time.Duration(cnf.Jwt.KeyOverlapInHours) * time.Hour,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating jwt service: %w", err)
+	}
 
-	svcs := NewServices(d, cS, enc, jwtSvc, cnf.Server.TokenCacheTTLInMinutes, cnf.Server.AuthProviderRefetchIntervalInMinutes)
+	svcs := NewServices(d, cS, enc, jwtSvc, cnf)
 	pm := projects.NewMiddlewares(svcs.Projects)
 	am, err := auth.NewMiddlewares(svcs.Auth, svcs.Clients)
 	if err != nil {
@@ -106,6 +118,9 @@ func InjectDefaultProviders(cnf config.AppConfig) (*Provider, error) {
 	svcs.Resources.Subscribe(goiamuniverse.EventResourceDeleted, system.NewRemoveDeletedResourceFromRole(svcs.Role))
 	svcs.Resources.Subscribe(goiamuniverse.EventResourceDeleted, system.NewRemoveDeletedResourceFromUser(svcs.User))
 
+	// keeping the jwt signing keys in sync and rotating them when due
+	go jwtSvc.RunKeyRotation(context.Background())
+
 	// creating default project if it doesn't exist
 	err = CheckAndAddDefaultProject(svcs.Projects)
 	if err != nil {
@@ -106,7 +106,9 @@ func TestNewServices(t *testing.T) {
 		mockEncrypt := &testservices.MockEncryptService{}
 		mockJWT := &testservices.MockJWTService{}
 
-		services := NewServices(mockDB, mockCache, mockEncrypt, mockJWT, 60, 30)
+		services := NewServices(mockDB, mockCache, mockEncrypt, mockJWT, config.AppConfig{
+			Server: config.Server{TokenCacheTTLInMinutes: 60, AuthProviderRefetchIntervalInMinutes: 30},
+		})
 
 		assert.NotNil(t, services)
 		assert.NotNil(t, services.Projects)
@@ -1,19 +1,32 @@
 package providers
 
 import (
+	"time"
+
+	"github.com/melvinodsa/go-iam/config"
 	"github.com/melvinodsa/go-iam/db"
 	"github.com/melvinodsa/go-iam/services/auth"
 	"github.com/melvinodsa/go-iam/services/auth/syncuser"
 	"github.com/melvinodsa/go-iam/services/authprovider"
 	"github.com/melvinodsa/go-iam/services/cache"
 	"github.com/melvinodsa/go-iam/services/client"
+	"github.com/melvinodsa/go-iam/services/consent"
+	"github.com/melvinodsa/go-iam/services/email"
 	"github.com/melvinodsa/go-iam/services/encrypt"
+	"github.com/melvinodsa/go-iam/services/identity"
 	"github.com/melvinodsa/go-iam/services/jwt"
+	"github.com/melvinodsa/go-iam/services/ldap"
+	"github.com/melvinodsa/go-iam/services/mfa"
+	"github.com/melvinodsa/go-iam/services/passkey"
+	"github.com/melvinodsa/go-iam/services/password"
+	"github.com/melvinodsa/go-iam/services/passwordless"
 	"github.com/melvinodsa/go-iam/services/policy"
 	"github.com/melvinodsa/go-iam/services/policy/system"
 	"github.com/melvinodsa/go-iam/services/project"
+	"github.com/melvinodsa/go-iam/services/refreshtoken"
 	"github.com/melvinodsa/go-iam/services/resource"
 	"github.com/melvinodsa/go-iam/services/role"
+	"github.com/melvinodsa/go-iam/services/sms"
 	"github.com/melvinodsa/go-iam/services/user"
 	"github.com/melvinodsa/go-iam/utils/goiamuniverse"
 )
@@ -31,6 +44,12 @@ type Service struct {
 	User          user.Service         // User management and authorization service
 	Role          role.Service         // Role-based access control service
 	Policy        policy.Service       // Policy management service
+	Jwt           jwt.Service          // Token signing and signing key management service
+	Consents      consent.Service      // Consents granted by the users to third party clients
+	Passwords     password.Service     // Credentials of the password auth provider
+	Mfa           mfa.Service          // TOTP second factor of the users
+	Passkeys      passkey.Service      // Passkeys of the users for the passkey login and as a second factor
+	Identities    identity.Service     // Identities of the users at the auth providers, linked to their accounts
 }
 
 // NewServices creates and configures all business logic services with their dependencies.
@@ -48,12 +67,11 @@ type Service struct {
 //   - cache: Cache service for performance optimization
 //   - enc: Encryption service for sensitive data
 //   - jwtSvc: JWT service for token operations
-//   - tokenTTL: Token time-to-live in minutes
-//   - refetchTTL: Auth provider refetch interval in minutes
+//   - cnf: Application configuration providing token TTLs and the issuer
 //
 // Returns:
 //   - *Service: Configured service container with all dependencies wired
-func NewServices(db db.DB, cache cache.Service, enc encrypt.Service, jwtSvc jwt.Service, tokenTTL int64, refetchTTL int64) *Service {
+func NewServices(db db.DB, cache cache.Service, enc encrypt.Service, jwtSvc jwt.Service, cnf config.AppConfig) *Service {
 	pstr := project.NewStore(db)
 	psvc := project.NewService(pstr)
 	cstr := client.NewStore(db)
@@ -74,10 +92,58 @@ func NewServices(db db.DB, cache cache.Service, enc encrypt.Service, jwtSvc jwt.
 	// adding default policies to a user when gets created
 	userSvc.Subscribe(goiamuniverse.EventUserCreated, system.NewDefaultPoliciesOnUser(userSvc))
 
+	// without an smtp server the emails are only logged
+	emailSvc := email.NewLogService()
+	if len(cnf.Email.SmtpHost) > 0 {
+		emailSvc = email.NewService(cnf.Email.SmtpHost, cnf.Email.SmtpPort, cnf.Email.SmtpUsername, string(cnf.Email.SmtpPassword), cnf.Email.From)
+	}
+	// without an sms webhook the login codes sent by sms are only logged
+	smsSender := sms.NewLogSender()
+	if len(cnf.Sms.WebhookUrl) > 0 {
+		smsSender = sms.NewWebhookSender(cnf.Sms.WebhookUrl, string(cnf.Sms.WebhookToken))
+	}
+	refreshStr := refreshtoken.NewStore(enc, db)
+	refreshSvc := refreshtoken.NewService(refreshStr, time.Hour*24*time.Duration(cnf.ServiceAccount.RefreshTokenTTLInDays))
+	identitySvc := identity.NewService(identity.NewStore(db), userSvc)
+	// a revocation is kept until every access token issued before it has expired
+	revocationTTL := time.Minute * time.Duration(max(cnf.Server.TokenCacheTTLInMinutes, cnf.ServiceAccount.AccessTokenTTLInMinutes))
+	passwordSvc := password.NewService(password.NewStore(db), psvc, cache, emailSvc, refreshSvc, identitySvc, time.Minute*time.Duration(cnf.Server.PasswordResetTTLInMinutes), revocationTTL)
+	passwordlessSvc := passwordless.NewService(cache, emailSvc, smsSender, time.Minute*time.Duration(cnf.Server.PasswordlessCodeTTLInMinutes))
+	passkeySvc := passkey.NewService(passkey.NewStore(db), cache, psvc)
+	ldapSvc := ldap.NewService(cache)
+
 	apStr := authprovider.NewStore(enc, db)
-	apSvc := authprovider.NewService(apStr, psvc)
+	apSvc := authprovider.NewService(apStr, psvc, passwordSvc, passwordlessSvc, passkeySvc, ldapSvc)
 	csvc := client.NewService(cstr, psvc, apSvc, userSvc)
-	authSvc := auth.NewService(apSvc, csvc, cache, jwtSvc, enc, userSvc, tokenTTL, refetchTTL)
+	consentSvc := consent.NewService(consent.NewStore(db))
+	mfaSvc := mfa.NewService(mfa.NewStore(enc, db), psvc, cache)
+	authSvc := auth.NewService(auth.Deps{
+		AuthProviders: apSvc,
+		Clients:       csvc,
+		Cache:         cache,
+		Jwt:           jwtSvc,
+		Encrypt:       enc,
+		Users:         userSvc,
+		RefreshTokens: refreshSvc,
+		Consents:      consentSvc,
+		Passwords:     passwordSvc,
+		Passwordless:  passwordlessSvc,
+		Mfa:           mfaSvc,
+		Passkeys:      passkeySvc,
+		Ldap:          ldapSvc,
+		Identities:    identitySvc,
+		Projects:      psvc,
+	}, auth.Options{
+		TokenTTL:         cnf.Server.TokenCacheTTLInMinutes,
+		RefetchTTL:       cnf.Server.AuthProviderRefetchIntervalInMinutes,
+		AccessTokenTTL:   cnf.ServiceAccount.AccessTokenTTLInMinutes,
+		IntrospectionTTL: cnf.Server.IntrospectionCacheTTLInSeconds,
+		MaxTokenSize:     cnf.Server.AccessTokenMaxSizeInBytes,
+		Issuer:           cnf.Jwt.Issuer,
+		ConsentUrl:       cnf.Server.ConsentUrl,
+		MfaUrl:           cnf.Server.MfaUrl,
+		IdentifierUrl:    cnf.Server.IdentifierUrl,
+	})
 	authSyncSvc := syncuser.NewService(authSvc)
 	polstr := policy.NewStore()
 	polSvc := policy.NewService(polstr)
@@ -92,5 +158,11 @@ func NewServices(db db.DB, cache cache.Service, enc encrypt.Service, jwtSvc jwt.
 		Role:          roleSvc,
 		Policy:        polSvc,
 		AuthSync:      authSyncSvc,
+		Jwt:           jwtSvc,
+		Consents:      consentSvc,
+		Passwords:     passwordSvc,
+		Mfa:           mfaSvc,
+		Passkeys:      passkeySvc,
+		Identities:    identitySvc,
 	}
 }
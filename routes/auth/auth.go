@@ -2,6 +2,7 @@ package auth
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -38,7 +39,13 @@ func LoginRoute(router fiber.Router, basePath string) {
 			{
 				Name:        "auth_provider",
 				In:          "query",
-				Description: "The authentication provider",
+				Description: "The authentication provider. When empty, the login routing of the project picks it by the domain of the login_hint or asks the user for their email, and the client's default provider is used without a routing",
+				Required:    false,
+			},
+			{
+				Name:        "login_hint",
+				In:          "query",
+				Description: "Email address of the user, routes the login to the auth provider of its domain",
 				Required:    false,
 			},
 			{
@@ -56,13 +63,31 @@ func LoginRoute(router fiber.Router, basePath string) {
 			{
 				Name:        "code_challenge_method",
 				In:          "query",
-				Description: "Code challenge method for PKCE. This required for public clients. For security reasons, only S256 is supported.",
+				Description: "Code challenge method for PKCE, S256 or plain. Defaults to plain, which has to be allowed for the client",
 				Required:    false,
 			},
 			{
 				Name:        "code_challenge",
 				In:          "query",
-				Description: "Code challenge for PKCE. This required for public clients",
+				Description: "Code challenge for PKCE. Required for public clients and for clients marked as PKCE required",
+				Required:    false,
+			},
+			{
+				Name:        "nonce",
+				In:          "query",
+				Description: "OpenID Connect nonce. It is returned as is in the id token for replay protection",
+				Required:    false,
+			},
+			{
+				Name:        "scope",
+				In:          "query",
+				Description: "Space delimited scopes requested for the tokens. Defaults to all the scopes allowed for the client",
+				Required:    false,
+			},
+			{
+				Name:        "acr_values",
+				In:          "query",
+				Description: "Space delimited authentication context classes requested. Pass urn:go-iam:acr:mfa to have the user complete a second factor",
 				Required:    false,
 			},
 		},
@@ -76,16 +101,25 @@ func Login(c *fiber.Ctx) error {
 	log.Debug("received login request")
 	pr := providers.GetProviders(c)
 
-	codeChallengeMethod := c.Query("code_challenge_method", "")
-	// Might have to revisit this when the standards change.
-	if len(codeChallengeMethod) != 0 && strings.Compare(codeChallengeMethod, "S256") != 0 {
-		log.Debugw("invalid code challenge", "code_challenge_method", codeChallengeMethod)
-		return sdk.AuthProviderBadRequest("invalid code challenge. Only S256 is supported", c)
-	}
-	url, err := pr.S.Auth.GetLoginUrl(c.Context(), c.Query("client_id", ""), c.Query("auth_provider", ""), c.Query("state", ""), c.Query("redirect_url", ""), c.Query("code_challenge_method", ""), c.Query("code_challenge", ""))
+	url, err := pr.S.Auth.GetLoginUrl(c.Context(), sdk.AuthLoginParams{
+		ClientId:            c.Query("client_id", ""),
+		AuthProviderId:      c.Query("auth_provider", ""),
+		State:               c.Query("state", ""),
+		RedirectUrl:         c.Query("redirect_url", ""),
+		CodeChallengeMethod: c.Query("code_challenge_method", ""),
+		CodeChallenge:       c.Query("code_challenge", ""),
+		Nonce:               c.Query("nonce", ""),
+		Scope:               c.Query("scope", ""),
+		AcrValues:           c.Query("acr_values", ""),
+		LoginHint:           c.Query("login_hint", ""),
+	})
 	if err != nil {
 		message := fmt.Errorf("failed to get login url. %w", err).Error()
 		log.Errorw("failed to get login url", "error", message)
+		if errors.Is(err, sdk.ErrPkceRequired) || errors.Is(err, sdk.ErrInvalidCodeChallenge) ||
+			errors.Is(err, sdk.ErrInvalidScope) || errors.Is(err, sdk.ErrAuthProviderNotFound) {
+			return sdk.AuthProviderBadRequest(message, c)
+		}
 		return sdk.AuthProviderInternalServerError(message, c)
 	}
 
@@ -172,7 +206,7 @@ func VerifyRoute(router fiber.Router, basePath string) {
 		Path:        path,
 		Method:      http.MethodGet,
 		Name:        "Verify",
-		Description: "Verify the authentication code",
+		Description: "Verify the authentication code. Deprecated, use the authorization_code grant of /auth/v1/token",
 		Tags:        routeTags,
 		RequestBody: nil,
 		Response: &docs.ApiResponse{
@@ -186,10 +220,16 @@ func VerifyRoute(router fiber.Router, basePath string) {
 				Description: "The authentication code",
 				Required:    true,
 			},
+			{
+				Name:        "code_verifier",
+				In:          "query",
+				Description: "The PKCE code verifier",
+				Required:    false,
+			},
 			{
 				Name:        "code_challenge",
 				In:          "query",
-				Description: "The code verifier",
+				Description: "Legacy name of code_verifier",
 				Required:    false,
 			},
 			{
@@ -201,8 +241,9 @@ func VerifyRoute(router fiber.Router, basePath string) {
 		},
 		UnAuthenticated:      true,
 		ProjectIDNotRequired: true,
+		Deprecated:           true,
 	})
-	router.Get(routePath, Verify)
+	router.Get(routePath, deprecated(Verify))
 }
 
 func Verify(c *fiber.Ctx) error {
@@ -210,11 +251,11 @@ func Verify(c *fiber.Ctx) error {
 	pr := providers.GetProviders(c)
 	code := c.Query("code")
 	var clientId, clientSecret string
-	// get code verifier from query params
-	codeChallenge := c.Query("code_challenge")
+	// get code verifier from query params. code_challenge is its legacy name
+	codeVerifier := c.Query("code_verifier", c.Query("code_challenge"))
 	clientId = c.Query("client_id")
 
-	if len(codeChallenge) == 0 || len(clientId) == 0 {
+	if len(codeVerifier) == 0 || len(clientId) == 0 {
 		// get client id and secret from authorization header with basic auth
 		clId, clSec, ok := getClientDetails(c)
 		if !ok {
@@ -223,7 +264,7 @@ func Verify(c *fiber.Ctx) error {
 		clientId = clId
 		clientSecret = clSec
 	}
-	resp, err := pr.S.Auth.ClientCallback(c.Context(), code, codeChallenge, clientId, clientSecret)
+	resp, err := pr.S.Auth.ClientCallback(c.Context(), code, codeVerifier, clientId, clientSecret)
 	if err != nil {
 		message := fmt.Errorf("failed to get callback. %w", err).Error()
 		return sdk.AuthProviderInternalServerError(message, c)
@@ -244,7 +285,7 @@ func ClientCredentialsRoute(router fiber.Router, basePath string) {
 		Path:        path,
 		Method:      http.MethodPost,
 		Name:        "Client",
-		Description: "Authenticate using client credentials for service accounts",
+		Description: "Authenticate using client credentials for service accounts. Deprecated, use the client_credentials grant of /auth/v1/token",
 		Tags:        routeTags,
 		RequestBody: &docs.ApiRequestBody{
 			Description: "Client credentials",
@@ -256,8 +297,9 @@ func ClientCredentialsRoute(router fiber.Router, basePath string) {
 		},
 		UnAuthenticated:      true,
 		ProjectIDNotRequired: true,
+		Deprecated:           true,
 	})
-	router.Post(routePath, ClientCredentials)
+	router.Post(routePath, deprecated(ClientCredentials))
 }
 
 func ClientCredentials(c *fiber.Ctx) error {
@@ -314,6 +356,84 @@ func ClientCredentials(c *fiber.Ctx) error {
 	})
 }
 
+// RefreshTokenRoute registers the refresh token route
+func RefreshTokenRoute(router fiber.Router, basePath string) {
+	routePath := "/refresh"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Refresh Token",
+		Description: "Exchange a refresh token for a new access token. The refresh token is rotated on every use and presenting a used one revokes the whole session. Confidential clients can authenticate with basic auth instead of the client secret in the body. Deprecated, use the refresh_token grant of /auth/v1/token",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "Refresh token along with the client it was issued to",
+			Content:     new(sdk.RefreshTokenRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Token refreshed successfully",
+			Content:     new(sdk.RefreshTokenResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+		Deprecated:           true,
+	})
+	router.Post(routePath, deprecated(RefreshToken))
+}
+
+func RefreshToken(c *fiber.Ctx) error {
+	log.Debug("received refresh token request")
+
+	payload := new(sdk.RefreshTokenRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.RefreshTokenResponse{
+			Success: false,
+			Message: fmt.Sprintf("invalid request body: %v", err),
+		})
+	}
+	if clId, clSec, ok := getClientDetails(c); ok {
+		payload.ClientId = clId
+		payload.ClientSecret = clSec
+	}
+
+	if payload.RefreshToken == "" || payload.ClientId == "" {
+		return c.Status(http.StatusBadRequest).JSON(sdk.RefreshTokenResponse{
+			Success: false,
+			Message: "refresh_token and client_id are required",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	resp, err := pr.S.Auth.RefreshToken(c.Context(), payload.RefreshToken, payload.ClientId, payload.ClientSecret)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, sdk.ErrRefreshTokenInvalid) {
+			status = http.StatusBadRequest
+		}
+		log.Errorw("refresh token request failed",
+			"client_id", payload.ClientId,
+			"error", err.Error())
+		return c.Status(status).JSON(sdk.RefreshTokenResponse{
+			Success: false,
+			Message: fmt.Sprintf("failed to refresh the token: %v", err),
+		})
+	}
+
+	log.Debugw("refresh token request successful",
+		"client_id", payload.ClientId)
+
+	return c.Status(http.StatusOK).JSON(sdk.RefreshTokenResponse{
+		Success: true,
+		Message: "Token refreshed successfully",
+		Data: &sdk.ClientCredentialsDataResponse{
+			AccessToken:  resp.AccessToken,
+			RefreshToken: resp.RefreshToken,
+			TokenType:    resp.TokenType,
+			ExpiresIn:    resp.ExpiresIn,
+		},
+	})
+}
+
 func getClientDetails(c *fiber.Ctx) (string, string, bool) {
 	headers := c.GetReqHeaders()
 	authHeaders := headers["Authorization"]
@@ -3,6 +3,7 @@ package auth
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
@@ -49,7 +50,9 @@ func TestLogin(t *testing.T) {
 		// auth mock
 
 		mockAuthSvc := services.MockAuthService{}
-		mockAuthSvc.On("GetLoginUrl", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("test-auth", nil).Once()
+		mockAuthSvc.On("GetLoginUrl", mock.Anything, mock.MatchedBy(func(params sdk.AuthLoginParams) bool {
+			return params.ClientId == "10001" && params.Nonce == "n-0S6_WzA2Mj" && params.AcrValues == sdk.AcrMultiFactor
+		})).Return("test-auth", nil).Once()
 
 		svcs.Auth = &mockAuthSvc
 
@@ -59,7 +62,7 @@ func TestLogin(t *testing.T) {
 
 		RegisterRoutes(app, "/auth")
 
-		req, _ := http.NewRequest("GET", "/auth/v1/login?client_id=10001", nil)
+		req, _ := http.NewRequest("GET", "/auth/v1/login?client_id=10001&nonce=n-0S6_WzA2Mj&acr_values=urn:go-iam:acr:mfa", nil)
 		res, err := app.Test(req, -1)
 		assert.Equalf(t, 307, res.StatusCode, "Expected status code 307")
 		assert.Nil(t, err)
@@ -81,7 +84,7 @@ func TestLogin(t *testing.T) {
 		// auth mock
 
 		mockAuthSvc := services.MockAuthService{}
-		mockAuthSvc.On("GetLoginUrl", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("test-auth", nil).Once()
+		mockAuthSvc.On("GetLoginUrl", mock.Anything, mock.Anything).Return("test-auth", nil).Once()
 
 		svcs.Auth = &mockAuthSvc
 
@@ -116,6 +119,12 @@ func TestLogin(t *testing.T) {
 			t.Errorf("error getting services: %s", err)
 			return
 		}
+		// auth mock
+
+		mockAuthSvc := services.MockAuthService{}
+		mockAuthSvc.On("GetLoginUrl", mock.Anything, mock.Anything).Return("", fmt.Errorf("error validating the code challenge %w", sdk.ErrInvalidCodeChallenge)).Once()
+
+		svcs.Auth = &mockAuthSvc
 
 		prv := server.SetupTestServer(app, cnf, svcs, cs, d)
 
@@ -145,7 +154,7 @@ func TestLogin(t *testing.T) {
 		// auth mock
 
 		mockAuthSvc := services.MockAuthService{}
-		mockAuthSvc.On("GetLoginUrl", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("some error")).Once()
+		mockAuthSvc.On("GetLoginUrl", mock.Anything, mock.Anything).Return("", errors.New("some error")).Once()
 
 		svcs.Auth = &mockAuthSvc
 
@@ -551,6 +560,20 @@ func TestVerify(t *testing.T) {
 
 		assert.Equalf(t, 400, res.StatusCode, "Expected status code 400")
 	})
+
+	t.Run("code verifier takes precedence over the legacy code challenge param", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		mockAuthSvc.On("ClientCallback", mock.Anything, "1234", "the-verifier", "10001", "").Return(&sdk.AuthVerifyCodeResponse{
+			AccessToken: "test-token",
+		}, nil).Once()
+		app := setupOidcTestApp(t, mockAuthSvc)
+
+		req, _ := http.NewRequest("GET", "/auth/v1/verify?code=1234&code_verifier=the-verifier&code_challenge=legacy&client_id=10001", nil)
+		res, err := app.Test(req, -1)
+		assert.Nil(t, err)
+		assert.Equalf(t, 200, res.StatusCode, "Expected status code 200")
+		mockAuthSvc.AssertExpectations(t)
+	})
 }
 
 func TestClientCredentials(t *testing.T) {
@@ -795,3 +818,117 @@ func TestClientCredentials(t *testing.T) {
 		assert.NotNil(t, resp)
 	})
 }
+
+func TestRefreshToken(t *testing.T) {
+	err := os.Setenv("JWT_SECRET", "abcd")
+	require.NoError(t, err)
+	cnf := config.NewAppConfig()
+
+	tests := []struct {
+		name           string
+		body           string
+		basicAuth      string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "success with client secret in body",
+			body: `{"refresh_token": "rt", "client_id": "test", "client_secret": "secret"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("RefreshToken", mock.Anything, "rt", "test", "secret").Return(&sdk.AuthVerifyCodeResponse{
+					AccessToken:  "new_access_token",
+					RefreshToken: "new_refresh_token",
+					TokenType:    "Bearer",
+					ExpiresIn:    3600,
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:      "success with basic auth",
+			body:      `{"refresh_token": "rt"}`,
+			basicAuth: "dGVzdDpzZWNyZXQ=", // test:secret
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("RefreshToken", mock.Anything, "rt", "test", "secret").Return(&sdk.AuthVerifyCodeResponse{
+					AccessToken:  "new_access_token",
+					RefreshToken: "new_refresh_token",
+					TokenType:    "Bearer",
+					ExpiresIn:    3600,
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing refresh token",
+			body:           `{"client_id": "test"}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid body",
+			body:           `{`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid refresh token",
+			body: `{"refresh_token": "rt", "client_id": "test"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("RefreshToken", mock.Anything, "rt", "test", "").Return(nil, sdk.ErrRefreshTokenInvalid).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "reused refresh token",
+			body: `{"refresh_token": "rt", "client_id": "test"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("RefreshToken", mock.Anything, "rt", "test", "").Return(nil, sdk.ErrRefreshTokenReused).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{
+				ReadBufferSize: 8192,
+			})
+
+			d := test.SetupMockDB()
+			cs := cache.NewMockService()
+			svcs, err := server.GetServices(*cnf, cs, d)
+			require.NoError(t, err)
+
+			mockAuthSvc := services.MockAuthService{}
+			tt.setupMocks(&mockAuthSvc)
+			svcs.Auth = &mockAuthSvc
+
+			prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+			app.Use(providers.Handle(prv))
+			RegisterRoutes(app, "/auth")
+
+			req, _ := http.NewRequest("POST", "/auth/v1/refresh", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.basicAuth != "" {
+				req.Header.Set("Authorization", "Basic "+tt.basicAuth)
+			}
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.RefreshTokenResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			if tt.expectedStatus == http.StatusOK {
+				require.NotNil(t, resp.Data)
+				assert.Equal(t, "new_access_token", resp.Data.AccessToken)
+				assert.Equal(t, "new_refresh_token", resp.Data.RefreshToken)
+				assert.Equal(t, "Bearer", resp.Data.TokenType)
+				assert.Equal(t, int64(3600), resp.Data.ExpiresIn)
+			} else {
+				assert.False(t, resp.Success)
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// ConsentRoute registers the route that describes a pending consent to the consent screen
+func ConsentRoute(router fiber.Router, basePath string) {
+	routePath := "/consent"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodGet,
+		Name:        "Get Consent",
+		Description: "Get the client, user and scopes of a pending consent so that the consent screen can ask the user",
+		Tags:        routeTags,
+		Response: &docs.ApiResponse{
+			Description: "Consent fetched successfully",
+			Content:     new(sdk.ConsentPromptResponse),
+		},
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "consent_challenge",
+				In:          "query",
+				Description: "The consent challenge passed to the consent screen",
+				Required:    true,
+			},
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Get(routePath, Consent)
+}
+
+func Consent(c *fiber.Ctx) error {
+	log.Debug("received get consent request")
+	challenge := c.Query("consent_challenge")
+	if len(challenge) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(sdk.ConsentPromptResponse{
+			Success: false,
+			Message: "consent_challenge is required",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	prompt, err := pr.S.Auth.GetConsentPrompt(c.Context(), challenge)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, sdk.ErrInvalidConsentChallenge) {
+			status = http.StatusBadRequest
+		}
+		message := fmt.Errorf("failed to get the consent. %w", err).Error()
+		log.Errorw("failed to get the consent", "error", message)
+		return c.Status(status).JSON(sdk.ConsentPromptResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("consent fetched successfully")
+
+	return c.Status(http.StatusOK).JSON(sdk.ConsentPromptResponse{
+		Success: true,
+		Message: "Consent fetched successfully",
+		Data:    prompt,
+	})
+}
+
+// DecideConsentRoute registers the route that records the answer of the user to a pending consent
+func DecideConsentRoute(router fiber.Router, basePath string) {
+	routePath := "/consent"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Decide Consent",
+		Description: "Approve or deny a pending consent. Approving remembers the consent and returns the client redirect url with the auth code. Denying returns the client redirect url with the access_denied error",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The consent challenge and the answer of the user",
+			Content:     new(sdk.ConsentDecision),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Redirect URL generated successfully",
+			Content:     new(sdk.AuthRedirectResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, DecideConsent)
+}
+
+func DecideConsent(c *fiber.Ctx) error {
+	log.Debug("received consent decision request")
+	payload := new(sdk.ConsentDecision)
+	if err := c.BodyParser(payload); err != nil {
+		return sdk.AuthProviderBadRequest(fmt.Sprintf("invalid request body: %v", err), c)
+	}
+	if len(payload.ConsentChallenge) == 0 {
+		return sdk.AuthProviderBadRequest("consent_challenge is required", c)
+	}
+
+	pr := providers.GetProviders(c)
+	resp, err := pr.S.Auth.DecideConsent(c.Context(), *payload)
+	if err != nil {
+		message := fmt.Errorf("failed to record the consent. %w", err).Error()
+		log.Errorw("failed to record the consent", "error", message)
+		if errors.Is(err, sdk.ErrInvalidConsentChallenge) {
+			return sdk.AuthProviderBadRequest(message, c)
+		}
+		return sdk.AuthProviderInternalServerError(message, c)
+	}
+	log.Debugw("consent recorded successfully", "approved", payload.Approve)
+
+	return c.Status(http.StatusOK).JSON(sdk.AuthRedirectResponse{
+		RedirectUrl: resp.RedirectUrl,
+	})
+}
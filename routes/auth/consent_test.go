@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsent(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:  "success",
+			query: "?consent_challenge=challenge-1",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("GetConsentPrompt", mock.Anything, "challenge-1").Return(&sdk.ConsentPrompt{
+					ConsentChallenge: "challenge-1",
+					ClientName:       "Orders App",
+					Scopes:           []string{"openid", "orders:read"},
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing challenge",
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "expired challenge",
+			query: "?consent_challenge=challenge-1",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("GetConsentPrompt", mock.Anything, "challenge-1").Return(nil, fmt.Errorf("%w: key not found", sdk.ErrInvalidConsentChallenge)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "service error",
+			query: "?consent_challenge=challenge-1",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("GetConsentPrompt", mock.Anything, "challenge-1").Return(nil, errors.New("error fetching client details")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("GET", "/auth/v1/consent"+tt.query, nil)
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.ConsentPromptResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "Orders App", resp.Data.ClientName)
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDecideConsent(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+		expectedUrl    string
+	}{
+		{
+			name: "approved",
+			body: `{"consent_challenge": "challenge-1", "approve": true}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("DecideConsent", mock.Anything, sdk.ConsentDecision{ConsentChallenge: "challenge-1", Approve: true}).
+					Return(&sdk.AuthRedirectResponse{RedirectUrl: "http://callback.com?code=abc"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedUrl:    "http://callback.com?code=abc",
+		},
+		{
+			name: "denied",
+			body: `{"consent_challenge": "challenge-1", "approve": false}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("DecideConsent", mock.Anything, sdk.ConsentDecision{ConsentChallenge: "challenge-1"}).
+					Return(&sdk.AuthRedirectResponse{RedirectUrl: "http://callback.com?error=access_denied"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedUrl:    "http://callback.com?error=access_denied",
+		},
+		{
+			name:           "missing challenge",
+			body:           `{"approve": true}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "challenge answered already",
+			body: `{"consent_challenge": "challenge-1", "approve": true}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("DecideConsent", mock.Anything, sdk.ConsentDecision{ConsentChallenge: "challenge-1", Approve: true}).
+					Return(nil, fmt.Errorf("%w: key not found", sdk.ErrInvalidConsentChallenge)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/consent", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			if tt.expectedStatus == http.StatusOK {
+				var resp sdk.AuthRedirectResponse
+				err = json.NewDecoder(res.Body).Decode(&resp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedUrl, resp.RedirectUrl)
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
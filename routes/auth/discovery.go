@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// DiscoverLoginRoute registers the route that answers the login challenge of the identifier page with the email of the user
+func DiscoverLoginRoute(router fiber.Router, basePath string) {
+	routePath := "/login/discover"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Discover Login",
+		Description: "Answer the login challenge of the identifier page with the email address of the user. The login routing of the project picks the auth provider of the email domain and the response carries its login url. When several auth providers can log the user in, they are listed instead and the request is sent again with the one the user chose",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The login challenge, the email address and optionally the chosen auth provider",
+			Content:     new(sdk.LoginDiscoveryRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Auth provider of the email address found successfully",
+			Content:     new(sdk.LoginDiscoveryResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, DiscoverLogin)
+}
+
+func DiscoverLogin(c *fiber.Ctx) error {
+	log.Debug("received discover login request")
+	payload := new(sdk.LoginDiscoveryRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return sdk.AuthProviderBadRequest(fmt.Sprintf("invalid request body: %v", err), c)
+	}
+	if len(payload.LoginChallenge) == 0 || len(payload.Email) == 0 {
+		return sdk.AuthProviderBadRequest("login_challenge and email are required", c)
+	}
+
+	pr := providers.GetProviders(c)
+	resp, err := pr.S.Auth.DiscoverLogin(c.Context(), *payload)
+	if err != nil {
+		message := fmt.Errorf("failed to find the auth provider of the email. %w", err).Error()
+		log.Errorw("failed to find the auth provider of the email", "error", message)
+		if errors.Is(err, sdk.ErrInvalidLoginChallenge) || errors.Is(err, sdk.ErrAuthProviderNotFound) ||
+			errors.Is(err, sdk.ErrNoAuthProviderForEmail) {
+			return sdk.AuthProviderBadRequest(message, c)
+		}
+		return sdk.AuthProviderInternalServerError(message, c)
+	}
+	log.Debug("auth provider of the email found successfully")
+	return c.Status(http.StatusOK).JSON(resp)
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverLogin(t *testing.T) {
+	discoverReq := sdk.LoginDiscoveryRequest{LoginChallenge: "challenge-1", Email: "jane@acme.com"}
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+		expected       *sdk.LoginDiscoveryResponse
+	}{
+		{
+			name: "auth provider of the domain",
+			body: `{"login_challenge": "challenge-1", "email": "jane@acme.com"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("DiscoverLogin", mock.Anything, discoverReq).Return(&sdk.LoginDiscoveryResponse{RedirectUrl: "https://acme.example.com/authorize"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expected:       &sdk.LoginDiscoveryResponse{RedirectUrl: "https://acme.example.com/authorize"},
+		},
+		{
+			name: "several auth providers to choose from",
+			body: `{"login_challenge": "challenge-1", "email": "jane@acme.com"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("DiscoverLogin", mock.Anything, discoverReq).Return(&sdk.LoginDiscoveryResponse{
+					AuthProviders: []sdk.LoginProvider{{Id: "ap-google", Name: "Google", Provider: sdk.AuthProviderTypeGoogle}},
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expected: &sdk.LoginDiscoveryResponse{
+				AuthProviders: []sdk.LoginProvider{{Id: "ap-google", Name: "Google", Provider: sdk.AuthProviderTypeGoogle}},
+			},
+		},
+		{
+			name:           "missing email",
+			body:           `{"login_challenge": "challenge-1"}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "challenge answered already",
+			body: `{"login_challenge": "challenge-1", "email": "jane@acme.com"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("DiscoverLogin", mock.Anything, discoverReq).Return(nil, fmt.Errorf("%w: key not found", sdk.ErrInvalidLoginChallenge)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "no auth provider for the email",
+			body: `{"login_challenge": "challenge-1", "email": "jane@acme.com"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("DiscoverLogin", mock.Anything, discoverReq).Return(nil, sdk.ErrNoAuthProviderForEmail).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: `{"login_challenge": "challenge-1", "email": "jane@acme.com"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("DiscoverLogin", mock.Anything, discoverReq).Return(nil, errors.New("db down")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/login/discover", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			if tt.expected != nil {
+				var resp sdk.LoginDiscoveryResponse
+				err = json.NewDecoder(res.Body).Decode(&resp)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.expected, resp)
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// IntrospectRoute registers the RFC 7662 token introspection route
+func IntrospectRoute(router fiber.Router, basePath string) {
+	routePath := "/introspect"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Introspect Token",
+		Description: "Check whether an access token is active and get the user it was issued for. Meant for resource servers, so the client has to authenticate with its secret either in the body or with basic auth. Tokens of users from other projects are reported as inactive",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "Token to introspect along with the credentials of the resource server",
+			Content:     new(sdk.TokenIntrospectionRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Introspection result",
+			Content:     new(sdk.TokenIntrospectionResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, Introspect)
+}
+
+func Introspect(c *fiber.Ctx) error {
+	log.Debug("received token introspection request")
+
+	payload := new(sdk.TokenIntrospectionRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid_request", "error_description": fmt.Sprintf("invalid request body: %v", err)})
+	}
+	if clId, clSec, ok := getClientDetails(c); ok {
+		payload.ClientId = clId
+		payload.ClientSecret = clSec
+	}
+
+	if payload.Token == "" || payload.ClientId == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid_request", "error_description": "token and client_id are required"})
+	}
+
+	pr := providers.GetProviders(c)
+	resp, err := pr.S.Auth.IntrospectToken(c.Context(), payload.Token, payload.ClientId, payload.ClientSecret, payload.IncludeRoles)
+	if err != nil {
+		log.Errorw("token introspection failed",
+			"client_id", payload.ClientId,
+			"error", err.Error())
+		if errors.Is(err, sdk.ErrClientAuthenticationRequired) {
+			c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="go-iam"`)
+		}
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "invalid_client", "error_description": fmt.Sprintf("failed to authenticate the client. %s", err)})
+	}
+
+	return c.Status(http.StatusOK).JSON(resp)
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospect(t *testing.T) {
+	active := &sdk.TokenIntrospectionResponse{Active: true, Sub: "user-1", ClientId: "app", ProjectId: "project-1", TokenType: "Bearer"}
+
+	tests := []struct {
+		name           string
+		body           string
+		contentType    string
+		basicAuth      string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+		expectedActive bool
+	}{
+		{
+			name:        "active token with basic auth",
+			body:        "token=at",
+			contentType: "application/x-www-form-urlencoded",
+			basicAuth:   "dGVzdDpzZWNyZXQ=", // test:secret
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("IntrospectToken", mock.Anything, "at", "test", "secret", false).Return(active, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedActive: true,
+		},
+		{
+			name:        "inactive token with roles requested",
+			body:        `{"token": "at", "client_id": "test", "client_secret": "secret", "include_roles": true}`,
+			contentType: "application/json",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("IntrospectToken", mock.Anything, "at", "test", "secret", true).Return(&sdk.TokenIntrospectionResponse{}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing token",
+			body:           `{"client_id": "test"}`,
+			contentType:    "application/json",
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "public client",
+			body:        `{"token": "at", "client_id": "test"}`,
+			contentType: "application/json",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("IntrospectToken", mock.Anything, "at", "test", "", false).Return(nil, sdk.ErrClientAuthenticationRequired).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "invalid client secret",
+			body:        `{"token": "at", "client_id": "test", "client_secret": "wrong"}`,
+			contentType: "application/json",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("IntrospectToken", mock.Anything, "at", "test", "wrong", false).Return(nil, errors.New("invalid client secret")).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/introspect", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.basicAuth != "" {
+				req.Header.Set("Authorization", "Basic "+tt.basicAuth)
+			}
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			if tt.expectedStatus == http.StatusOK {
+				var resp sdk.TokenIntrospectionResponse
+				err = json.NewDecoder(res.Body).Decode(&resp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedActive, resp.Active)
+			} else {
+				var resp map[string]string
+				err = json.NewDecoder(res.Body).Decode(&resp)
+				assert.NoError(t, err)
+				assert.NotEmpty(t, resp["error"])
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// LdapLoginRoute registers the route the login page of the ldap auth providers posts the credentials to
+func LdapLoginRoute(router fiber.Router, basePath string) {
+	routePath := "/ldap/login"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Ldap Login",
+		Description: "Log in with the username and password of the directory of an ldap auth provider. go-iam searches the entry of the username and binds as it with the password. On success the user is sent to the client redirect url with the auth code, the same way as the other auth providers",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The state passed to the login page and the credentials of the user",
+			Content:     new(sdk.LdapLoginRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Redirect URL generated successfully",
+			Content:     new(sdk.AuthRedirectResponse),
+		},
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "postback",
+				In:          "query",
+				Description: "Whether to return the redirect URL in the response",
+				Required:    false,
+			},
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, LdapLogin)
+}
+
+func LdapLogin(c *fiber.Ctx) error {
+	log.Debug("received ldap login request")
+	payload := new(sdk.LdapLoginRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return sdk.AuthProviderBadRequest(fmt.Sprintf("invalid request body: %v", err), c)
+	}
+	if len(payload.State) == 0 || len(payload.Username) == 0 || len(payload.Password) == 0 {
+		return sdk.AuthProviderBadRequest("state, username and password are required", c)
+	}
+	postback := c.Query("postback", "false")
+
+	pr := providers.GetProviders(c)
+	resp, err := pr.S.Auth.LdapLogin(c.Context(), *payload, c.IP())
+	if err != nil {
+		message := fmt.Errorf("failed to log in. %w", err).Error()
+		log.Errorw("failed to log in with ldap", "error", message)
+		return sdk.NewErrorAuthProviderResponse(message, ldapErrorStatus(err), c)
+	}
+	log.Debug("logged in with ldap successfully")
+	if postback == "true" {
+		return c.Status(http.StatusOK).JSON(sdk.AuthRedirectResponse{
+			RedirectUrl: resp.RedirectUrl,
+		})
+	}
+	// the login page posts a form, see other turns the post into a get of the client redirect url
+	return c.Redirect(resp.RedirectUrl, http.StatusSeeOther)
+}
+
+func ldapErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sdk.ErrInvalidLdapCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, sdk.ErrNotLdapProvider):
+		return http.StatusBadRequest
+	case errors.Is(err, sdk.ErrTooManyLoginAttempts):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLdapLogin(t *testing.T) {
+	loginReq := sdk.LdapLoginRequest{State: "state-1", Username: "jdoe", Password: "secret"}
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "success - redirects to the client",
+			body: `{"state": "state-1", "username": "jdoe", "password": "secret"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("LdapLogin", mock.Anything, loginReq, mock.AnythingOfType("string")).Return(&sdk.AuthRedirectResponse{RedirectUrl: "http://callback.com?code=abc"}, nil).Once()
+			},
+			expectedStatus: http.StatusSeeOther,
+		},
+		{
+			name:  "success - postback",
+			query: "?postback=true",
+			body:  `{"state": "state-1", "username": "jdoe", "password": "secret"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("LdapLogin", mock.Anything, loginReq, mock.AnythingOfType("string")).Return(&sdk.AuthRedirectResponse{RedirectUrl: "http://callback.com?code=abc"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing username",
+			body:           `{"state": "state-1", "password": "secret"}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid credentials",
+			body: `{"state": "state-1", "username": "jdoe", "password": "secret"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("LdapLogin", mock.Anything, loginReq, mock.AnythingOfType("string")).Return(nil, fmt.Errorf("error checking the credentials %w", sdk.ErrInvalidLdapCredentials)).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "too many failed logins",
+			body: `{"state": "state-1", "username": "jdoe", "password": "secret"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("LdapLogin", mock.Anything, loginReq, mock.AnythingOfType("string")).Return(nil, fmt.Errorf("error checking the credentials %w", sdk.ErrTooManyLoginAttempts)).Once()
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name: "provider of another type",
+			body: `{"state": "state-1", "username": "jdoe", "password": "secret"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("LdapLogin", mock.Anything, loginReq, mock.AnythingOfType("string")).Return(nil, sdk.ErrNotLdapProvider).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "directory unreachable",
+			body: `{"state": "state-1", "username": "jdoe", "password": "secret"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("LdapLogin", mock.Anything, loginReq, mock.AnythingOfType("string")).Return(nil, errors.New("error checking the credentials error connecting to ldap.example.com:636")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/ldap/login"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+			if tt.expectedStatus == http.StatusSeeOther {
+				assert.Equal(t, "http://callback.com?code=abc", res.Header.Get("Location"))
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
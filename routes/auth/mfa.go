@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// MfaRoute registers the route that describes a pending mfa challenge to the mfa page
+func MfaRoute(router fiber.Router, basePath string) {
+	routePath := "/mfa"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodGet,
+		Name:        "Get Mfa Challenge",
+		Description: "Get the user of a pending mfa challenge, along with the authenticator to set up when the user has none yet",
+		Tags:        routeTags,
+		Response: &docs.ApiResponse{
+			Description: "Mfa challenge fetched successfully",
+			Content:     new(sdk.MfaPromptResponse),
+		},
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "mfa_challenge",
+				In:          "query",
+				Description: "The mfa challenge passed to the mfa page",
+				Required:    true,
+			},
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Get(routePath, Mfa)
+}
+
+func Mfa(c *fiber.Ctx) error {
+	log.Debug("received get mfa challenge request")
+	challenge := c.Query("mfa_challenge")
+	if len(challenge) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(sdk.MfaPromptResponse{
+			Success: false,
+			Message: "mfa_challenge is required",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	prompt, err := pr.S.Auth.GetMfaPrompt(c.Context(), challenge)
+	if err != nil {
+		message := fmt.Errorf("failed to get the mfa challenge. %w", err).Error()
+		log.Errorw("failed to get the mfa challenge", "error", message)
+		return c.Status(mfaErrorStatus(err)).JSON(sdk.MfaPromptResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("mfa challenge fetched successfully")
+
+	return c.Status(http.StatusOK).JSON(sdk.MfaPromptResponse{
+		Success: true,
+		Message: "Mfa challenge fetched successfully",
+		Data:    prompt,
+	})
+}
+
+// VerifyMfaRoute registers the route that answers a pending mfa challenge with a code
+func VerifyMfaRoute(router fiber.Router, basePath string) {
+	routePath := "/mfa/verify"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Verify Mfa",
+		Description: "Answer a pending mfa challenge with a code from the authenticator app or a recovery code. On success the login continues with the consent page or the client redirect url. Users enrolling during the login get their recovery codes once in the response. The challenge is dropped after 5 wrong codes",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The mfa challenge and the code",
+			Content:     new(sdk.MfaVerifyRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Redirect URL generated successfully",
+			Content:     new(sdk.MfaVerifyResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, VerifyMfa)
+}
+
+func VerifyMfa(c *fiber.Ctx) error {
+	log.Debug("received verify mfa request")
+	payload := new(sdk.MfaVerifyRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return sdk.AuthProviderBadRequest(fmt.Sprintf("invalid request body: %v", err), c)
+	}
+	if len(payload.MfaChallenge) == 0 || len(payload.Code) == 0 {
+		return sdk.AuthProviderBadRequest("mfa_challenge and code are required", c)
+	}
+
+	pr := providers.GetProviders(c)
+	resp, err := pr.S.Auth.VerifyMfa(c.Context(), *payload)
+	if err != nil {
+		message := fmt.Errorf("failed to verify the second factor. %w", err).Error()
+		log.Errorw("failed to verify the second factor", "error", message)
+		return sdk.NewErrorAuthProviderResponse(message, mfaErrorStatus(err), c)
+	}
+	log.Debug("second factor verified successfully")
+	return c.Status(http.StatusOK).JSON(resp)
+}
+
+// mfaErrorStatus maps the errors of the mfa challenges to the response status
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sdk.ErrInvalidMfaCode), errors.Is(err, sdk.ErrMfaAttemptsExceeded), errors.Is(err, sdk.ErrInvalidPasskey):
+		return http.StatusUnauthorized
+	case errors.Is(err, sdk.ErrInvalidMfaChallenge), errors.Is(err, sdk.ErrInvalidPasskeyChallenge),
+		errors.Is(err, sdk.ErrPasskeyNotFound), errors.Is(err, sdk.ErrPasskeysNotConfigured), errors.Is(err, sdk.ErrMfaNotEnrolled):
+		return http.StatusBadRequest
+	case errors.Is(err, sdk.ErrTooManyMfaAttempts):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMfa(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:  "success",
+			query: "?mfa_challenge=challenge-1",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("GetMfaPrompt", mock.Anything, "challenge-1").Return(&sdk.MfaPrompt{
+					MfaChallenge: "challenge-1",
+					UserEmail:    "user@example.com",
+					Enrolled:     true,
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing challenge",
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "expired challenge",
+			query: "?mfa_challenge=challenge-1",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("GetMfaPrompt", mock.Anything, "challenge-1").Return(nil, fmt.Errorf("%w: key not found", sdk.ErrInvalidMfaChallenge)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "service error",
+			query: "?mfa_challenge=challenge-1",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("GetMfaPrompt", mock.Anything, "challenge-1").Return(nil, errors.New("error decrypting the pending mfa")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("GET", "/auth/v1/mfa"+tt.query, nil)
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.MfaPromptResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "user@example.com", resp.Data.UserEmail)
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestVerifyMfa(t *testing.T) {
+	verifyReq := sdk.MfaVerifyRequest{MfaChallenge: "challenge-1", Code: "123456"}
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+		expected       *sdk.MfaVerifyResponse
+	}{
+		{
+			name: "success",
+			body: `{"mfa_challenge": "challenge-1", "code": "123456"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("VerifyMfa", mock.Anything, verifyReq).Return(&sdk.MfaVerifyResponse{RedirectUrl: "http://callback.com?code=abc"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expected:       &sdk.MfaVerifyResponse{RedirectUrl: "http://callback.com?code=abc"},
+		},
+		{
+			name: "enrollment returns the recovery codes",
+			body: `{"mfa_challenge": "challenge-1", "code": "123456"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("VerifyMfa", mock.Anything, verifyReq).Return(&sdk.MfaVerifyResponse{
+					RedirectUrl:   "http://callback.com?code=abc",
+					RecoveryCodes: []string{"aaaaa-bbbbb"},
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expected:       &sdk.MfaVerifyResponse{RedirectUrl: "http://callback.com?code=abc", RecoveryCodes: []string{"aaaaa-bbbbb"}},
+		},
+		{
+			name:           "missing code",
+			body:           `{"mfa_challenge": "challenge-1"}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "wrong code",
+			body: `{"mfa_challenge": "challenge-1", "code": "123456"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("VerifyMfa", mock.Anything, verifyReq).Return(nil, sdk.ErrInvalidMfaCode).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "too many wrong codes",
+			body: `{"mfa_challenge": "challenge-1", "code": "123456"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("VerifyMfa", mock.Anything, verifyReq).Return(nil, sdk.ErrMfaAttemptsExceeded).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "too many wrong codes of the user",
+			body: `{"mfa_challenge": "challenge-1", "code": "123456"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("VerifyMfa", mock.Anything, verifyReq).Return(nil, fmt.Errorf("error verifying the second factor %w", sdk.ErrTooManyMfaAttempts)).Once()
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name: "challenge answered already",
+			body: `{"mfa_challenge": "challenge-1", "code": "123456"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("VerifyMfa", mock.Anything, verifyReq).Return(nil, fmt.Errorf("%w: key not found", sdk.ErrInvalidMfaChallenge)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/mfa/verify", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			if tt.expected != nil {
+				var resp sdk.MfaVerifyResponse
+				err = json.NewDecoder(res.Body).Decode(&resp)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.expected, resp)
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// OpenIdConfigurationRoute registers the OpenID Connect discovery route
+func OpenIdConfigurationRoute(router fiber.Router, basePath string) {
+	routePath := "/openid-configuration"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodGet,
+		Name:        "OpenID Configuration",
+		Description: "OpenID Connect discovery document",
+		Tags:        routeTags,
+		Response: &docs.ApiResponse{
+			Description: "Discovery document",
+			Content:     new(sdk.OpenIdConfiguration),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Get(routePath, OpenIdConfiguration)
+}
+
+func OpenIdConfiguration(c *fiber.Ctx) error {
+	log.Debug("received openid configuration request")
+	pr := providers.GetProviders(c)
+	return c.Status(http.StatusOK).JSON(pr.S.Auth.GetOpenIdConfiguration())
+}
+
+// JwksRoute registers the route publishing the JSON Web Key Set
+func JwksRoute(router fiber.Router, basePath string) {
+	routePath := "/jwks"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodGet,
+		Name:        "JWKS",
+		Description: "Public keys for verifying the id tokens issued by Go IAM",
+		Tags:        routeTags,
+		Response: &docs.ApiResponse{
+			Description: "JSON Web Key Set",
+			Content:     new(sdk.Jwks),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Get(routePath, Jwks)
+}
+
+func Jwks(c *fiber.Ctx) error {
+	log.Debug("received jwks request")
+	pr := providers.GetProviders(c)
+	return c.Status(http.StatusOK).JSON(pr.S.Auth.GetJwks())
+}
+
+// UserInfoRoute registers the OpenID Connect userinfo route
+func UserInfoRoute(router fiber.Router, basePath string) {
+	routePath := "/userinfo"
+	path := basePath + routePath
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		docs.RegisterApi(docs.ApiWrapper{
+			Path:        path,
+			Method:      method,
+			Name:        "User Info",
+			Description: "Standard claims of the user behind the bearer access token",
+			Tags:        routeTags,
+			Response: &docs.ApiResponse{
+				Description: "User claims",
+				Content:     new(sdk.UserInfo),
+			},
+			UnAuthenticated:      true,
+			ProjectIDNotRequired: true,
+		})
+	}
+	router.Get(routePath, UserInfo)
+	router.Post(routePath, UserInfo)
+}
+
+func UserInfo(c *fiber.Ctx) error {
+	log.Debug("received userinfo request")
+	token, ok := getBearerToken(c)
+	if !ok {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_request"`)
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "invalid_request", "error_description": "bearer token is missing"})
+	}
+	pr := providers.GetProviders(c)
+	usr, err := pr.S.Auth.GetIdentity(c.Context(), token, false)
+	if err != nil {
+		log.Debugw("invalid access token for userinfo", "error", err)
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "invalid_token", "error_description": fmt.Sprintf("failed to fetch identity. %s", err)})
+	}
+	return c.Status(http.StatusOK).JSON(sdk.NewUserInfo(*usr))
+}
+
+func getBearerToken(c *fiber.Ctx) (string, bool) {
+	authHeader := c.Get(fiber.HeaderAuthorization)
+	token, found := strings.CutPrefix(authHeader, "Bearer ")
+	if !found || len(token) == 0 {
+		return "", false
+	}
+	return token, true
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/melvinodsa/go-iam/config"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/melvinodsa/go-iam/utils/test/server"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupOidcTestApp(t *testing.T, mockAuthSvc *services.MockAuthService) *fiber.App {
+	err := os.Setenv("JWT_SECRET", "abcd")
+	require.NoError(t, err)
+	cnf := config.NewAppConfig()
+
+	app := fiber.New(fiber.Config{
+		ReadBufferSize: 8192,
+	})
+	d := test.SetupMockDB()
+	cs := cache.NewMockService()
+	svcs, err := server.GetServices(*cnf, cs, d)
+	require.NoError(t, err)
+	svcs.Auth = mockAuthSvc
+
+	prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+	app.Use(providers.Handle(prv))
+
+	RegisterRoutes(app, "/auth")
+	RegisterWellKnownRoutes(app, "/.well-known")
+	return app
+}
+
+func TestOpenIdConfiguration(t *testing.T) {
+	mockAuthSvc := &services.MockAuthService{}
+	mockAuthSvc.On("GetOpenIdConfiguration").Return(sdk.OpenIdConfiguration{
+		Issuer:  "http://localhost:3000",
+		JwksUri: "http://localhost:3000/auth/v1/jwks",
+	}).Once()
+	app := setupOidcTestApp(t, mockAuthSvc)
+
+	req, _ := http.NewRequest("GET", "/.well-known/openid-configuration", nil)
+	res, err := app.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var resp sdk.OpenIdConfiguration
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:3000", resp.Issuer)
+	assert.Equal(t, "http://localhost:3000/auth/v1/jwks", resp.JwksUri)
+	mockAuthSvc.AssertExpectations(t)
+}
+
+func TestJwks(t *testing.T) {
+	mockAuthSvc := &services.MockAuthService{}
+	mockAuthSvc.On("GetJwks").Return(sdk.Jwks{Keys: []sdk.Jwk{{Kid: "kid-1", Kty: "RSA", Alg: "RS256"}}}).Once()
+	app := setupOidcTestApp(t, mockAuthSvc)
+
+	req, _ := http.NewRequest("GET", "/auth/v1/jwks", nil)
+	res, err := app.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var resp sdk.Jwks
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	require.NoError(t, err)
+	require.Len(t, resp.Keys, 1)
+	assert.Equal(t, "kid-1", resp.Keys[0].Kid)
+	mockAuthSvc.AssertExpectations(t)
+}
+
+func TestUserInfo(t *testing.T) {
+	t.Run("returns standard claims", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		mockAuthSvc.On("GetIdentity", mock.Anything, "valid-token", false).Return(&sdk.User{
+			Id:         "user-1",
+			Email:      "user@example.com",
+			Name:       "Test User",
+			ProfilePic: "https://pic",
+		}, nil).Twice()
+		app := setupOidcTestApp(t, mockAuthSvc)
+
+		for _, method := range []string{"GET", "POST"} {
+			req, _ := http.NewRequest(method, "/auth/v1/userinfo", nil)
+			req.Header.Set("Authorization", "Bearer valid-token")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+
+			var resp sdk.UserInfo
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", resp.Sub)
+			assert.Equal(t, "user@example.com", resp.Email)
+			assert.Equal(t, "Test User", resp.Name)
+			assert.Equal(t, "https://pic", resp.Picture)
+		}
+		mockAuthSvc.AssertExpectations(t)
+	})
+
+	t.Run("missing bearer token", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		app := setupOidcTestApp(t, mockAuthSvc)
+
+		req, _ := http.NewRequest("GET", "/auth/v1/userinfo", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Contains(t, res.Header.Get("WWW-Authenticate"), "invalid_request")
+	})
+
+	t.Run("invalid access token", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		mockAuthSvc.On("GetIdentity", mock.Anything, "bad-token", false).Return(nil, errors.New("invalid token")).Once()
+		app := setupOidcTestApp(t, mockAuthSvc)
+
+		req, _ := http.NewRequest("GET", "/auth/v1/userinfo", nil)
+		req.Header.Set("Authorization", "Bearer bad-token")
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Contains(t, res.Header.Get("WWW-Authenticate"), "invalid_token")
+		mockAuthSvc.AssertExpectations(t)
+	})
+}
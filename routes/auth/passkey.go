@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// PasskeyLoginStartRoute registers the route starting the passkey ceremony of a passkey auth provider login
+func PasskeyLoginStartRoute(router fiber.Router, basePath string) {
+	routePath := "/passkey/start"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Start Passkey Login",
+		Description: "Get the options for navigator.credentials.get to log in with a passkey. The user picks any of their passkeys for the project. Binary values are base64url encoded",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The state passed to the login page",
+			Content:     new(sdk.PasskeyLoginStartRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Passkey login started",
+			Content:     new(sdk.WebAuthnRequestOptionsResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, PasskeyLoginStart)
+}
+
+func PasskeyLoginStart(c *fiber.Ctx) error {
+	log.Debug("received passkey login start request")
+	payload := new(sdk.PasskeyLoginStartRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.WebAuthnRequestOptionsResponse{
+			Success: false,
+			Message: fmt.Sprintf("invalid request body: %v", err),
+		})
+	}
+	if len(payload.State) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(sdk.WebAuthnRequestOptionsResponse{
+			Success: false,
+			Message: "state is required",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	options, err := pr.S.Auth.PasskeyLoginStart(c.Context(), *payload)
+	if err != nil {
+		message := fmt.Errorf("failed to start the passkey login. %w", err).Error()
+		log.Errorw("failed to start the passkey login", "error", message)
+		return c.Status(passkeyErrorStatus(err)).JSON(sdk.WebAuthnRequestOptionsResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("passkey login started")
+	return c.Status(http.StatusOK).JSON(sdk.WebAuthnRequestOptionsResponse{
+		Success: true,
+		Message: "Passkey login started",
+		Data:    options,
+	})
+}
+
+// PasskeyLoginVerifyRoute registers the route completing a passkey auth provider login with the response of the authenticator
+func PasskeyLoginVerifyRoute(router fiber.Router, basePath string) {
+	routePath := "/passkey/verify"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Verify Passkey Login",
+		Description: "Complete a passkey login with the credential returned by navigator.credentials.get. On success the user is sent to the client redirect url with the auth code, the same way as the other auth providers. Passkey logins are multi-factor, so the user is not asked for a second factor",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The state and the credential returned by the browser",
+			Content:     new(sdk.PasskeyLoginVerifyRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Redirect URL generated successfully",
+			Content:     new(sdk.AuthRedirectResponse),
+		},
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "postback",
+				In:          "query",
+				Description: "Whether to return the redirect URL in the response",
+				Required:    false,
+			},
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, PasskeyLoginVerify)
+}
+
+func PasskeyLoginVerify(c *fiber.Ctx) error {
+	log.Debug("received passkey login verify request")
+	payload := new(sdk.PasskeyLoginVerifyRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return sdk.AuthProviderBadRequest(fmt.Sprintf("invalid request body: %v", err), c)
+	}
+	if len(payload.State) == 0 || len(payload.Credential.Id) == 0 {
+		return sdk.AuthProviderBadRequest("state and credential are required", c)
+	}
+	postback := c.Query("postback", "false")
+
+	pr := providers.GetProviders(c)
+	resp, err := pr.S.Auth.PasskeyLoginVerify(c.Context(), *payload)
+	if err != nil {
+		message := fmt.Errorf("failed to log in. %w", err).Error()
+		log.Errorw("failed to verify the passkey login", "error", message)
+		return sdk.NewErrorAuthProviderResponse(message, passkeyErrorStatus(err), c)
+	}
+	log.Debug("logged in with passkey successfully")
+	if postback == "true" {
+		return c.Status(http.StatusOK).JSON(sdk.AuthRedirectResponse{
+			RedirectUrl: resp.RedirectUrl,
+		})
+	}
+	return c.Redirect(resp.RedirectUrl, http.StatusSeeOther)
+}
+
+// StartMfaPasskeyRoute registers the route starting the passkey ceremony answering a pending mfa challenge
+func StartMfaPasskeyRoute(router fiber.Router, basePath string) {
+	routePath := "/mfa/passkey/start"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Start Mfa Passkey",
+		Description: "Get the options for navigator.credentials.get to answer a pending mfa challenge with one of the passkeys of the user. Binary values are base64url encoded",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The mfa challenge",
+			Content:     new(sdk.MfaPasskeyStartRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Passkey login started",
+			Content:     new(sdk.WebAuthnRequestOptionsResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, StartMfaPasskey)
+}
+
+func StartMfaPasskey(c *fiber.Ctx) error {
+	log.Debug("received start mfa passkey request")
+	payload := new(sdk.MfaPasskeyStartRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.WebAuthnRequestOptionsResponse{
+			Success: false,
+			Message: fmt.Sprintf("invalid request body: %v", err),
+		})
+	}
+	if len(payload.MfaChallenge) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(sdk.WebAuthnRequestOptionsResponse{
+			Success: false,
+			Message: "mfa_challenge is required",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	options, err := pr.S.Auth.StartMfaPasskey(c.Context(), *payload)
+	if err != nil {
+		message := fmt.Errorf("failed to start the passkey login. %w", err).Error()
+		log.Errorw("failed to start the mfa passkey login", "error", message)
+		return c.Status(mfaErrorStatus(err)).JSON(sdk.WebAuthnRequestOptionsResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("mfa passkey login started")
+	return c.Status(http.StatusOK).JSON(sdk.WebAuthnRequestOptionsResponse{
+		Success: true,
+		Message: "Passkey login started",
+		Data:    options,
+	})
+}
+
+// VerifyMfaPasskeyRoute registers the route answering a pending mfa challenge with a passkey
+func VerifyMfaPasskeyRoute(router fiber.Router, basePath string) {
+	routePath := "/mfa/passkey/verify"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Verify Mfa Passkey",
+		Description: "Answer a pending mfa challenge with the credential returned by navigator.credentials.get. On success the login continues with the consent page or the client redirect url. Failed passkeys count towards the 5 attempts of the challenge",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The mfa challenge and the credential returned by the browser",
+			Content:     new(sdk.MfaPasskeyVerifyRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Redirect URL generated successfully",
+			Content:     new(sdk.MfaVerifyResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, VerifyMfaPasskey)
+}
+
+func VerifyMfaPasskey(c *fiber.Ctx) error {
+	log.Debug("received verify mfa passkey request")
+	payload := new(sdk.MfaPasskeyVerifyRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return sdk.AuthProviderBadRequest(fmt.Sprintf("invalid request body: %v", err), c)
+	}
+	if len(payload.MfaChallenge) == 0 || len(payload.Credential.Id) == 0 {
+		return sdk.AuthProviderBadRequest("mfa_challenge and credential are required", c)
+	}
+
+	pr := providers.GetProviders(c)
+	resp, err := pr.S.Auth.VerifyMfaPasskey(c.Context(), *payload)
+	if err != nil {
+		message := fmt.Errorf("failed to verify the passkey. %w", err).Error()
+		log.Errorw("failed to verify the mfa passkey", "error", message)
+		return sdk.NewErrorAuthProviderResponse(message, mfaErrorStatus(err), c)
+	}
+	log.Debug("mfa passkey verified successfully")
+	return c.Status(http.StatusOK).JSON(resp)
+}
+
+// passkeyErrorStatus maps the errors of the passkey auth provider to the response status
+func passkeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sdk.ErrInvalidPasskey):
+		return http.StatusUnauthorized
+	case errors.Is(err, sdk.ErrInvalidPasskeyChallenge), errors.Is(err, sdk.ErrPasskeysNotConfigured), errors.Is(err, sdk.ErrNotPasskeyProvider):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
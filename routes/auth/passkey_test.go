@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testAssertionBody = `"credential": {"id": "cred-1", "type": "public-key", "response": {"client_data_json": "Y2Q", "authenticator_data": "YWQ", "signature": "c2ln"}}`
+
+var testAssertion = sdk.WebAuthnAssertion{
+	Id:   "cred-1",
+	Type: "public-key",
+	Response: sdk.WebAuthnAssertionResponse{
+		ClientDataJSON:    "Y2Q",
+		AuthenticatorData: "YWQ",
+		Signature:         "c2ln",
+	},
+}
+
+func TestPasskeyLoginStart(t *testing.T) {
+	startReq := sdk.PasskeyLoginStartRequest{State: "state-1"}
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			body: `{"state": "state-1"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasskeyLoginStart", mock.Anything, startReq).Return(&sdk.WebAuthnRequestOptions{Challenge: "challenge", RpId: "example.com"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing state",
+			body:           `{}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "provider of another type",
+			body: `{"state": "state-1"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasskeyLoginStart", mock.Anything, startReq).Return(nil, sdk.ErrNotPasskeyProvider).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "passkeys not configured",
+			body: `{"state": "state-1"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasskeyLoginStart", mock.Anything, startReq).Return(nil, fmt.Errorf("error starting the passkey login %w", sdk.ErrPasskeysNotConfigured)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/passkey/start", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.WebAuthnRequestOptionsResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "example.com", resp.Data.RpId)
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPasskeyLoginVerify(t *testing.T) {
+	verifyReq := sdk.PasskeyLoginVerifyRequest{State: "state-1", Credential: testAssertion}
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "redirects to the client",
+			body: `{"state": "state-1", ` + testAssertionBody + `}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasskeyLoginVerify", mock.Anything, verifyReq).Return(&sdk.AuthRedirectResponse{RedirectUrl: "http://callback.com?code=abc"}, nil).Once()
+			},
+			expectedStatus: http.StatusSeeOther,
+		},
+		{
+			name:  "postback",
+			query: "?postback=true",
+			body:  `{"state": "state-1", ` + testAssertionBody + `}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasskeyLoginVerify", mock.Anything, verifyReq).Return(&sdk.AuthRedirectResponse{RedirectUrl: "http://callback.com?code=abc"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing credential",
+			body:           `{"state": "state-1"}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid passkey",
+			body: `{"state": "state-1", ` + testAssertionBody + `}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasskeyLoginVerify", mock.Anything, verifyReq).Return(nil, fmt.Errorf("%w: invalid signature", sdk.ErrInvalidPasskey)).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "expired ceremony",
+			body: `{"state": "state-1", ` + testAssertionBody + `}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasskeyLoginVerify", mock.Anything, verifyReq).Return(nil, sdk.ErrInvalidPasskeyChallenge).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/passkey/verify"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			switch tt.expectedStatus {
+			case http.StatusSeeOther:
+				assert.Equal(t, "http://callback.com?code=abc", res.Header.Get("Location"))
+			case http.StatusOK:
+				var resp sdk.AuthRedirectResponse
+				err = json.NewDecoder(res.Body).Decode(&resp)
+				assert.NoError(t, err)
+				assert.Equal(t, "http://callback.com?code=abc", resp.RedirectUrl)
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStartMfaPasskey(t *testing.T) {
+	startReq := sdk.MfaPasskeyStartRequest{MfaChallenge: "challenge-1"}
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			body: `{"mfa_challenge": "challenge-1"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("StartMfaPasskey", mock.Anything, startReq).Return(&sdk.WebAuthnRequestOptions{Challenge: "challenge", RpId: "example.com"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing challenge",
+			body:           `{}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "user without passkeys",
+			body: `{"mfa_challenge": "challenge-1"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("StartMfaPasskey", mock.Anything, startReq).Return(nil, sdk.ErrPasskeyNotFound).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/mfa/passkey/start", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.WebAuthnRequestOptionsResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestVerifyMfaPasskey(t *testing.T) {
+	verifyReq := sdk.MfaPasskeyVerifyRequest{MfaChallenge: "challenge-1", Credential: testAssertion}
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			body: `{"mfa_challenge": "challenge-1", ` + testAssertionBody + `}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("VerifyMfaPasskey", mock.Anything, verifyReq).Return(&sdk.MfaVerifyResponse{RedirectUrl: "http://callback.com?code=abc"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing credential",
+			body:           `{"mfa_challenge": "challenge-1"}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid passkey",
+			body: `{"mfa_challenge": "challenge-1", ` + testAssertionBody + `}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("VerifyMfaPasskey", mock.Anything, verifyReq).Return(nil, fmt.Errorf("%w: user not verified", sdk.ErrInvalidPasskey)).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "too many attempts",
+			body: `{"mfa_challenge": "challenge-1", ` + testAssertionBody + `}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("VerifyMfaPasskey", mock.Anything, verifyReq).Return(nil, sdk.ErrMfaAttemptsExceeded).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/mfa/passkey/verify", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			if tt.expectedStatus == http.StatusOK {
+				var resp sdk.MfaVerifyResponse
+				err = json.NewDecoder(res.Body).Decode(&resp)
+				assert.NoError(t, err)
+				assert.Equal(t, "http://callback.com?code=abc", resp.RedirectUrl)
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// PasswordLoginRoute registers the route the login page of the password auth provider posts the credentials to
+func PasswordLoginRoute(router fiber.Router, basePath string) {
+	routePath := "/password/login"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Password Login",
+		Description: "Log in with the email and password of a password auth provider. On success the user is sent to the client redirect url with the auth code, the same way as the other auth providers",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The state passed to the login page and the credentials of the user",
+			Content:     new(sdk.PasswordLoginRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Redirect URL generated successfully",
+			Content:     new(sdk.AuthRedirectResponse),
+		},
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "postback",
+				In:          "query",
+				Description: "Whether to return the redirect URL in the response",
+				Required:    false,
+			},
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, PasswordLogin)
+}
+
+func PasswordLogin(c *fiber.Ctx) error {
+	log.Debug("received password login request")
+	payload := new(sdk.PasswordLoginRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return sdk.AuthProviderBadRequest(fmt.Sprintf("invalid request body: %v", err), c)
+	}
+	if len(payload.State) == 0 || len(payload.Email) == 0 || len(payload.Password) == 0 {
+		return sdk.AuthProviderBadRequest("state, email and password are required", c)
+	}
+	postback := c.Query("postback", "false")
+
+	pr := providers.GetProviders(c)
+	resp, err := pr.S.Auth.PasswordLogin(c.Context(), *payload, c.IP())
+	if err != nil {
+		message := fmt.Errorf("failed to log in. %w", err).Error()
+		log.Errorw("failed to log in with password", "error", message)
+		return sdk.NewErrorAuthProviderResponse(message, passwordErrorStatus(err), c)
+	}
+	log.Debug("logged in with password successfully")
+	if postback == "true" {
+		return c.Status(http.StatusOK).JSON(sdk.AuthRedirectResponse{
+			RedirectUrl: resp.RedirectUrl,
+		})
+	}
+	// the login page posts a form, see other turns the post into a get of the client redirect url
+	return c.Redirect(resp.RedirectUrl, http.StatusSeeOther)
+}
+
+// PasswordSignupRoute registers the signup route of the password auth provider
+func PasswordSignupRoute(router fiber.Router, basePath string) {
+	routePath := "/password/signup"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Password Signup",
+		Description: "Sign up with an email and password when the password auth provider allows it. The user gets an email to verify the address before they can log in",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The state passed to the login page and the details of the user",
+			Content:     new(sdk.PasswordSignupRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Signed up successfully",
+			Content:     new(sdk.PasswordResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, PasswordSignup)
+}
+
+func PasswordSignup(c *fiber.Ctx) error {
+	log.Debug("received password signup request")
+	payload := new(sdk.PasswordSignupRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return passwordResponse(c, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+	}
+	if len(payload.State) == 0 || len(payload.Email) == 0 || len(payload.Password) == 0 {
+		return passwordResponse(c, http.StatusBadRequest, "state, email and password are required")
+	}
+
+	pr := providers.GetProviders(c)
+	err := pr.S.Auth.PasswordSignup(c.Context(), *payload)
+	if err != nil {
+		message := fmt.Errorf("failed to sign up. %w", err).Error()
+		log.Errorw("failed to sign up", "error", message)
+		return passwordResponse(c, passwordErrorStatus(err), message)
+	}
+	log.Debug("signed up successfully")
+	return passwordResponse(c, http.StatusOK, "Signed up successfully, check your email to verify the address")
+}
+
+// PasswordVerifyRoute registers the route verifying the email address of a new password credential
+func PasswordVerifyRoute(router fiber.Router, basePath string) {
+	routePath := "/password/verify"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Password Verify Email",
+		Description: "Verify the email address of a user who signed up with the token sent to them",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The verification token from the email",
+			Content:     new(sdk.PasswordVerifyRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Email verified successfully",
+			Content:     new(sdk.PasswordResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, PasswordVerify)
+}
+
+func PasswordVerify(c *fiber.Ctx) error {
+	log.Debug("received email verification request")
+	payload := new(sdk.PasswordVerifyRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return passwordResponse(c, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+	}
+	if len(payload.Token) == 0 {
+		return passwordResponse(c, http.StatusBadRequest, "token is required")
+	}
+
+	pr := providers.GetProviders(c)
+	err := pr.S.Passwords.Verify(c.Context(), payload.Token)
+	if err != nil {
+		message := fmt.Errorf("failed to verify the email. %w", err).Error()
+		log.Errorw("failed to verify the email", "error", message)
+		return passwordResponse(c, passwordErrorStatus(err), message)
+	}
+	log.Debug("email verified successfully")
+	return passwordResponse(c, http.StatusOK, "Email verified successfully")
+}
+
+// ForgotPasswordRoute registers the route sending the password reset link
+func ForgotPasswordRoute(router fiber.Router, basePath string) {
+	routePath := "/password/forgot"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Forgot Password",
+		Description: "Email a password reset link to the user. The response is the same whether the email has a credential or not",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The state passed to the login page and the email of the user",
+			Content:     new(sdk.PasswordForgotRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Password reset link sent",
+			Content:     new(sdk.PasswordResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, ForgotPassword)
+}
+
+func ForgotPassword(c *fiber.Ctx) error {
+	log.Debug("received forgot password request")
+	payload := new(sdk.PasswordForgotRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return passwordResponse(c, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+	}
+	if len(payload.State) == 0 || len(payload.Email) == 0 {
+		return passwordResponse(c, http.StatusBadRequest, "state and email are required")
+	}
+
+	pr := providers.GetProviders(c)
+	err := pr.S.Auth.ForgotPassword(c.Context(), *payload)
+	if err != nil {
+		message := fmt.Errorf("failed to send the password reset link. %w", err).Error()
+		log.Errorw("failed to send the password reset link", "error", message)
+		return passwordResponse(c, passwordErrorStatus(err), message)
+	}
+	log.Debug("password reset link sent")
+	return passwordResponse(c, http.StatusOK, "If the email has an account, a password reset link has been sent to it")
+}
+
+// ResetPasswordRoute registers the route setting a new password with a password reset token
+func ResetPasswordRoute(router fiber.Router, basePath string) {
+	routePath := "/password/reset"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Reset Password",
+		Description: "Set a new password with the single use token from the password reset email",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The password reset token and the new password",
+			Content:     new(sdk.PasswordResetRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Password reset successfully",
+			Content:     new(sdk.PasswordResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, ResetPassword)
+}
+
+func ResetPassword(c *fiber.Ctx) error {
+	log.Debug("received reset password request")
+	payload := new(sdk.PasswordResetRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return passwordResponse(c, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+	}
+	if len(payload.Token) == 0 || len(payload.Password) == 0 {
+		return passwordResponse(c, http.StatusBadRequest, "token and password are required")
+	}
+
+	pr := providers.GetProviders(c)
+	err := pr.S.Passwords.Reset(c.Context(), *payload)
+	if err != nil {
+		message := fmt.Errorf("failed to reset the password. %w", err).Error()
+		log.Errorw("failed to reset the password", "error", message)
+		return passwordResponse(c, passwordErrorStatus(err), message)
+	}
+	log.Debug("password reset successfully")
+	return passwordResponse(c, http.StatusOK, "Password reset successfully")
+}
+
+func passwordResponse(c *fiber.Ctx, status int, message string) error {
+	return c.Status(status).JSON(sdk.PasswordResponse{
+		Success: status == http.StatusOK,
+		Message: message,
+	})
+}
+
+// passwordErrorStatus maps the errors of the password auth provider to the response status
+func passwordErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sdk.ErrInvalidCredentials), errors.Is(err, sdk.ErrEmailNotVerified):
+		return http.StatusUnauthorized
+	case errors.Is(err, sdk.ErrSignupDisabled):
+		return http.StatusForbidden
+	case errors.Is(err, sdk.ErrTooManyLoginAttempts):
+		return http.StatusTooManyRequests
+	case errors.Is(err, sdk.ErrWeakPassword),
+		errors.Is(err, sdk.ErrInvalidEmail),
+		errors.Is(err, sdk.ErrInvalidPasswordResetToken),
+		errors.Is(err, sdk.ErrInvalidVerificationToken),
+		errors.Is(err, sdk.ErrNotPasswordProvider):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
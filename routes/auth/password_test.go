@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/melvinodsa/go-iam/config"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/melvinodsa/go-iam/utils/test/server"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPasswordTestApp(t *testing.T, mockAuthSvc *services.MockAuthService, mockPasswordSvc *services.MockPasswordService) *fiber.App {
+	err := os.Setenv("JWT_SECRET", "abcd")
+	require.NoError(t, err)
+	cnf := config.NewAppConfig()
+
+	app := fiber.New(fiber.Config{
+		ReadBufferSize: 8192,
+	})
+	d := test.SetupMockDB()
+	cs := cache.NewMockService()
+	svcs, err := server.GetServices(*cnf, cs, d)
+	require.NoError(t, err)
+	svcs.Auth = mockAuthSvc
+	svcs.Passwords = mockPasswordSvc
+
+	prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+	app.Use(providers.Handle(prv))
+
+	RegisterRoutes(app, "/auth")
+	return app
+}
+
+func TestPasswordLogin(t *testing.T) {
+	loginReq := sdk.PasswordLoginRequest{State: "state-1", Email: "user@example.com", Password: "secret"}
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "success - redirects to the client",
+			body: `{"state": "state-1", "email": "user@example.com", "password": "secret"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasswordLogin", mock.Anything, loginReq, mock.AnythingOfType("string")).Return(&sdk.AuthRedirectResponse{RedirectUrl: "http://callback.com?code=abc"}, nil).Once()
+			},
+			expectedStatus: http.StatusSeeOther,
+		},
+		{
+			name:  "success - postback",
+			query: "?postback=true",
+			body:  `{"state": "state-1", "email": "user@example.com", "password": "secret"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasswordLogin", mock.Anything, loginReq, mock.AnythingOfType("string")).Return(&sdk.AuthRedirectResponse{RedirectUrl: "http://callback.com?code=abc"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing password",
+			body:           `{"state": "state-1", "email": "user@example.com"}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid credentials",
+			body: `{"state": "state-1", "email": "user@example.com", "password": "secret"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasswordLogin", mock.Anything, loginReq, mock.AnythingOfType("string")).Return(nil, fmt.Errorf("error checking the credentials %w", sdk.ErrInvalidCredentials)).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "service error",
+			body: `{"state": "state-1", "email": "user@example.com", "password": "secret"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasswordLogin", mock.Anything, loginReq, mock.AnythingOfType("string")).Return(nil, errors.New("error getting the state from cache")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "too many failed logins",
+			body: `{"state": "state-1", "email": "user@example.com", "password": "secret"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasswordLogin", mock.Anything, loginReq, mock.AnythingOfType("string")).Return(nil, fmt.Errorf("error checking the credentials %w", sdk.ErrTooManyLoginAttempts)).Once()
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupPasswordTestApp(t, mockAuthSvc, &services.MockPasswordService{})
+
+			req, _ := http.NewRequest("POST", "/auth/v1/password/login"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+			if tt.expectedStatus == http.StatusSeeOther {
+				assert.Equal(t, "http://callback.com?code=abc", res.Header.Get("Location"))
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPasswordSignup(t *testing.T) {
+	signupReq := sdk.PasswordSignupRequest{State: "state-1", Email: "user@example.com", Name: "User", Password: "secret"}
+	body := `{"state": "state-1", "email": "user@example.com", "name": "User", "password": "secret"}`
+	tests := []struct {
+		name           string
+		signupErr      error
+		expectedStatus int
+	}{
+		{name: "success", expectedStatus: http.StatusOK},
+		{name: "signup disabled", signupErr: sdk.ErrSignupDisabled, expectedStatus: http.StatusForbidden},
+		{name: "weak password", signupErr: fmt.Errorf("%w: at least 8 characters", sdk.ErrWeakPassword), expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			mockAuthSvc.On("PasswordSignup", mock.Anything, signupReq).Return(tt.signupErr).Once()
+			app := setupPasswordTestApp(t, mockAuthSvc, &services.MockPasswordService{})
+
+			req, _ := http.NewRequest("POST", "/auth/v1/password/signup", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.PasswordResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		mockAuthSvc.On("ForgotPassword", mock.Anything, sdk.PasswordForgotRequest{State: "state-1", Email: "user@example.com"}).Return(nil).Once()
+		app := setupPasswordTestApp(t, mockAuthSvc, &services.MockPasswordService{})
+
+		req, _ := http.NewRequest("POST", "/auth/v1/password/forgot", strings.NewReader(`{"state": "state-1", "email": "user@example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		mockAuthSvc.AssertExpectations(t)
+	})
+
+	t.Run("missing email", func(t *testing.T) {
+		app := setupPasswordTestApp(t, &services.MockAuthService{}, &services.MockPasswordService{})
+
+		req, _ := http.NewRequest("POST", "/auth/v1/password/forgot", strings.NewReader(`{"state": "state-1"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestPasswordVerifyAndReset(t *testing.T) {
+	t.Run("verify", func(t *testing.T) {
+		mockPasswordSvc := &services.MockPasswordService{}
+		mockPasswordSvc.On("Verify", mock.Anything, "token-1").Return(nil).Once()
+		app := setupPasswordTestApp(t, &services.MockAuthService{}, mockPasswordSvc)
+
+		req, _ := http.NewRequest("POST", "/auth/v1/password/verify", strings.NewReader(`{"token": "token-1"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		mockPasswordSvc.AssertExpectations(t)
+	})
+
+	t.Run("reset with a used token", func(t *testing.T) {
+		mockPasswordSvc := &services.MockPasswordService{}
+		mockPasswordSvc.On("Reset", mock.Anything, sdk.PasswordResetRequest{Token: "token-1", Password: "new-secret"}).
+			Return(fmt.Errorf("%w: key not found", sdk.ErrInvalidPasswordResetToken)).Once()
+		app := setupPasswordTestApp(t, &services.MockAuthService{}, mockPasswordSvc)
+
+		req, _ := http.NewRequest("POST", "/auth/v1/password/reset", strings.NewReader(`{"token": "token-1", "password": "new-secret"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		var resp sdk.PasswordResponse
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		assert.NoError(t, err)
+		assert.False(t, resp.Success)
+		mockPasswordSvc.AssertExpectations(t)
+	})
+}
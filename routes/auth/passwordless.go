@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// StartPasswordlessRoute registers the route emailing the login code or magic link of a passwordless auth provider
+func StartPasswordlessRoute(router fiber.Router, basePath string) {
+	routePath := "/passwordless/start"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Start Passwordless Login",
+		Description: "Email a single use login code or magic link to the user, depending on the method of the passwordless auth provider. The requests are rate limited per email and IP address",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The state passed to the login page and the email of the user",
+			Content:     new(sdk.PasswordlessStartRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Login email sent",
+			Content:     new(sdk.PasswordlessResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, StartPasswordless)
+}
+
+func StartPasswordless(c *fiber.Ctx) error {
+	log.Debug("received passwordless login request")
+	payload := new(sdk.PasswordlessStartRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return passwordlessResponse(c, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+	}
+	if len(payload.State) == 0 || len(payload.Email) == 0 {
+		return passwordlessResponse(c, http.StatusBadRequest, "state and email are required")
+	}
+
+	pr := providers.GetProviders(c)
+	err := pr.S.Auth.StartPasswordless(c.Context(), *payload, c.IP())
+	if err != nil {
+		message := fmt.Errorf("failed to send the login email. %w", err).Error()
+		log.Errorw("failed to send the login email", "error", message)
+		return passwordlessResponse(c, passwordlessErrorStatus(err), message)
+	}
+	log.Debug("login email sent")
+	return passwordlessResponse(c, http.StatusOK, "Login email sent, check your inbox")
+}
+
+// PasswordlessVerifyRoute registers the route completing a passwordless or sms login with the code sent or the magic link
+func PasswordlessVerifyRoute(router fiber.Router, basePath string) {
+	routePath := "/passwordless/verify"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Verify Passwordless Login",
+		Description: "Complete a passwordless or sms login with the state and the code sent to the user, or with the token of the magic link. On success the user is sent to the client redirect url with the auth code, the same way as the other auth providers",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The state and the code, or the token of the magic link",
+			Content:     new(sdk.PasswordlessVerifyRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Redirect URL generated successfully",
+			Content:     new(sdk.AuthRedirectResponse),
+		},
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "postback",
+				In:          "query",
+				Description: "Whether to return the redirect URL in the response",
+				Required:    false,
+			},
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, PasswordlessVerify)
+}
+
+func PasswordlessVerify(c *fiber.Ctx) error {
+	log.Debug("received passwordless verify request")
+	payload := new(sdk.PasswordlessVerifyRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return sdk.AuthProviderBadRequest(fmt.Sprintf("invalid request body: %v", err), c)
+	}
+	if len(payload.Token) == 0 && (len(payload.State) == 0 || len(payload.Code) == 0) {
+		return sdk.AuthProviderBadRequest("either token or state and code are required", c)
+	}
+	postback := c.Query("postback", "false")
+
+	pr := providers.GetProviders(c)
+	resp, err := pr.S.Auth.PasswordlessVerify(c.Context(), *payload)
+	if err != nil {
+		message := fmt.Errorf("failed to log in. %w", err).Error()
+		log.Errorw("failed to verify the passwordless login", "error", message)
+		return sdk.NewErrorAuthProviderResponse(message, passwordlessErrorStatus(err), c)
+	}
+	log.Debug("logged in without password successfully")
+	if postback == "true" {
+		return c.Status(http.StatusOK).JSON(sdk.AuthRedirectResponse{
+			RedirectUrl: resp.RedirectUrl,
+		})
+	}
+	return c.Redirect(resp.RedirectUrl, http.StatusSeeOther)
+}
+
+func passwordlessResponse(c *fiber.Ctx, status int, message string) error {
+	return c.Status(status).JSON(sdk.PasswordlessResponse{
+		Success: status == http.StatusOK,
+		Message: message,
+	})
+}
+
+// passwordlessErrorStatus maps the errors of the passwordless auth provider to the response status
+func passwordlessErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sdk.ErrInvalidOtp), errors.Is(err, sdk.ErrOtpAttemptsExceeded):
+		return http.StatusUnauthorized
+	case errors.Is(err, sdk.ErrTooManyOtpRequests):
+		return http.StatusTooManyRequests
+	case errors.Is(err, sdk.ErrInvalidEmail), errors.Is(err, sdk.ErrNotPasswordlessProvider):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartPasswordless(t *testing.T) {
+	startReq := sdk.PasswordlessStartRequest{State: "state-1", Email: "user@example.com"}
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			body: `{"state": "state-1", "email": "user@example.com"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("StartPasswordless", mock.Anything, startReq, mock.AnythingOfType("string")).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing email",
+			body:           `{"state": "state-1"}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "rate limited",
+			body: `{"state": "state-1", "email": "user@example.com"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("StartPasswordless", mock.Anything, startReq, mock.AnythingOfType("string")).
+					Return(fmt.Errorf("error sending the login email %w", sdk.ErrTooManyOtpRequests)).Once()
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name: "invalid email",
+			body: `{"state": "state-1", "email": "user@example.com"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("StartPasswordless", mock.Anything, startReq, mock.AnythingOfType("string")).Return(sdk.ErrInvalidEmail).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/passwordless/start", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.PasswordlessResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPasswordlessVerify(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "code redirects to the client",
+			body: `{"state": "state-1", "code": "123456"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasswordlessVerify", mock.Anything, sdk.PasswordlessVerifyRequest{State: "state-1", Code: "123456"}).
+					Return(&sdk.AuthRedirectResponse{RedirectUrl: "http://callback.com?code=abc"}, nil).Once()
+			},
+			expectedStatus: http.StatusSeeOther,
+		},
+		{
+			name:  "magic link with postback",
+			query: "?postback=true",
+			body:  `{"token": "link-token"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasswordlessVerify", mock.Anything, sdk.PasswordlessVerifyRequest{Token: "link-token"}).
+					Return(&sdk.AuthRedirectResponse{RedirectUrl: "http://callback.com?code=abc"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "code without the state",
+			body:           `{"code": "123456"}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "wrong code",
+			body: `{"state": "state-1", "code": "000000"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("PasswordlessVerify", mock.Anything, sdk.PasswordlessVerifyRequest{State: "state-1", Code: "000000"}).
+					Return(nil, fmt.Errorf("error verifying the login code %w", sdk.ErrInvalidOtp)).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/passwordless/verify"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+			if tt.expectedStatus == http.StatusSeeOther {
+				assert.Equal(t, "http://callback.com?code=abc", res.Header.Get("Location"))
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// RevokeRoute registers the RFC 7009 token revocation route
+func RevokeRoute(router fiber.Router, basePath string) {
+	routePath := "/revoke"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Revoke Token",
+		Description: "Revoke an access token or a refresh token. Revoking a refresh token revokes every refresh token of the session. Unknown or expired tokens are reported as revoked. Confidential clients can authenticate with basic auth instead of the client secret in the body",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "Token to revoke along with the client it was issued to",
+			Content:     new(sdk.TokenRevocationRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Token revoked successfully",
+			Content:     new(sdk.TokenRevocationResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, Revoke)
+}
+
+func Revoke(c *fiber.Ctx) error {
+	log.Debug("received token revocation request")
+
+	payload := new(sdk.TokenRevocationRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.TokenRevocationResponse{
+			Success: false,
+			Message: fmt.Sprintf("invalid request body: %v", err),
+		})
+	}
+	if clId, clSec, ok := getClientDetails(c); ok {
+		payload.ClientId = clId
+		payload.ClientSecret = clSec
+	}
+
+	if payload.Token == "" || payload.ClientId == "" {
+		return c.Status(http.StatusBadRequest).JSON(sdk.TokenRevocationResponse{
+			Success: false,
+			Message: "token and client_id are required",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	err := pr.S.Auth.RevokeToken(c.Context(), payload.Token, payload.TokenTypeHint, payload.ClientId, payload.ClientSecret)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, sdk.ErrTokenClientMismatch) {
+			status = http.StatusBadRequest
+		}
+		log.Errorw("token revocation failed",
+			"client_id", payload.ClientId,
+			"error", err.Error())
+		return c.Status(status).JSON(sdk.TokenRevocationResponse{
+			Success: false,
+			Message: fmt.Sprintf("failed to revoke the token: %v", err),
+		})
+	}
+
+	log.Debugw("token revoked successfully",
+		"client_id", payload.ClientId)
+
+	return c.Status(http.StatusOK).JSON(sdk.TokenRevocationResponse{
+		Success: true,
+		Message: "Token revoked successfully",
+	})
+}
+
+// LogoutRoute registers the logout route
+func LogoutRoute(router fiber.Router, basePath string) {
+	routePath := "/logout"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodGet,
+		Name:        "Logout",
+		Description: "Clear the session of the bearer access token and revoke its refresh tokens. Optionally redirects to one of the post logout redirect urls registered for the client",
+		Tags:        routeTags,
+		Response: &docs.ApiResponse{
+			Description: "Logged out successfully",
+			Content:     new(sdk.LogoutResponse),
+		},
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "client_id",
+				In:          "query",
+				Description: "The client id, required along with post_logout_redirect_uri",
+				Required:    false,
+			},
+			{
+				Name:        "post_logout_redirect_uri",
+				In:          "query",
+				Description: "The url to redirect to after logout. It has to be registered for the client",
+				Required:    false,
+			},
+			{
+				Name:        "state",
+				In:          "query",
+				Description: "Opaque value passed back to the post logout redirect url",
+				Required:    false,
+			},
+			{
+				Name:        "postback",
+				In:          "query",
+				Description: "Return the redirect url in the response instead of redirecting",
+				Required:    false,
+			},
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Get(routePath, Logout)
+}
+
+func Logout(c *fiber.Ctx) error {
+	log.Debug("received logout request")
+	accessToken, _ := getBearerToken(c)
+	clientId := c.Query("client_id")
+	redirectUrl := c.Query("post_logout_redirect_uri")
+	state := c.Query("state")
+	postback := c.Query("postback", "false")
+
+	if len(redirectUrl) != 0 && len(clientId) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(sdk.LogoutResponse{
+			Success: false,
+			Message: "client_id is required along with post_logout_redirect_uri",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	redirectUrl, err := pr.S.Auth.Logout(c.Context(), accessToken, clientId, redirectUrl, state)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, sdk.ErrPostLogoutRedirectUrlNotFound) {
+			status = http.StatusBadRequest
+		}
+		message := fmt.Errorf("failed to logout. %w", err).Error()
+		log.Errorw("failed to logout", "error", message)
+		return c.Status(status).JSON(sdk.LogoutResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("logged out successfully")
+
+	if len(redirectUrl) == 0 {
+		return c.Status(http.StatusOK).JSON(sdk.LogoutResponse{
+			Success: true,
+			Message: "Logged out successfully",
+		})
+	}
+	if postback == "true" {
+		return c.Status(http.StatusOK).JSON(sdk.LogoutResponse{
+			Success: true,
+			Message: "Logged out successfully",
+			Data:    &sdk.AuthRedirectResponse{RedirectUrl: redirectUrl},
+		})
+	}
+	return c.Redirect(redirectUrl, http.StatusTemporaryRedirect)
+}
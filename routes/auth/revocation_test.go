@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevoke(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		contentType    string
+		basicAuth      string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:        "success with form body",
+			body:        "token=rt&token_type_hint=refresh_token&client_id=test&client_secret=secret",
+			contentType: "application/x-www-form-urlencoded",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("RevokeToken", mock.Anything, "rt", sdk.TokenTypeHintRefreshToken, "test", "secret").Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "success with basic auth",
+			body:        `{"token": "at"}`,
+			contentType: "application/json",
+			basicAuth:   "dGVzdDpzZWNyZXQ=", // test:secret
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("RevokeToken", mock.Anything, "at", "", "test", "secret").Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing token",
+			body:           `{"client_id": "test"}`,
+			contentType:    "application/json",
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "token issued to another client",
+			body:        `{"token": "at", "client_id": "test"}`,
+			contentType: "application/json",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("RevokeToken", mock.Anything, "at", "", "test", "").Return(sdk.ErrTokenClientMismatch).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "client authentication fails",
+			body:        `{"token": "at", "client_id": "test", "client_secret": "wrong"}`,
+			contentType: "application/json",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("RevokeToken", mock.Anything, "at", "", "test", "wrong").Return(errors.New("invalid client secret")).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/revoke", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.basicAuth != "" {
+				req.Header.Set("Authorization", "Basic "+tt.basicAuth)
+			}
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.TokenRevocationResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	t.Run("clears the session of the bearer token", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		mockAuthSvc.On("Logout", mock.Anything, "access-token", "", "", "").Return("", nil).Once()
+		app := setupOidcTestApp(t, mockAuthSvc)
+
+		req, _ := http.NewRequest("GET", "/auth/v1/logout", nil)
+		req.Header.Set("Authorization", "Bearer access-token")
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		var resp sdk.LogoutResponse
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		assert.NoError(t, err)
+		assert.True(t, resp.Success)
+		assert.Nil(t, resp.Data)
+		mockAuthSvc.AssertExpectations(t)
+	})
+
+	t.Run("redirects to the post logout url", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		mockAuthSvc.On("Logout", mock.Anything, "", "test", "http://localhost/bye", "xyz").Return("http://localhost/bye?state=xyz", nil).Once()
+		app := setupOidcTestApp(t, mockAuthSvc)
+
+		req, _ := http.NewRequest("GET", "/auth/v1/logout?client_id=test&post_logout_redirect_uri=http://localhost/bye&state=xyz", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+		assert.Equal(t, "http://localhost/bye?state=xyz", res.Header.Get("Location"))
+		mockAuthSvc.AssertExpectations(t)
+	})
+
+	t.Run("returns the post logout url on postback", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		mockAuthSvc.On("Logout", mock.Anything, "", "test", "http://localhost/bye", "").Return("http://localhost/bye", nil).Once()
+		app := setupOidcTestApp(t, mockAuthSvc)
+
+		req, _ := http.NewRequest("GET", "/auth/v1/logout?client_id=test&post_logout_redirect_uri=http://localhost/bye&postback=true", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		var resp sdk.LogoutResponse
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		assert.NoError(t, err)
+		require.NotNil(t, resp.Data)
+		assert.Equal(t, "http://localhost/bye", resp.Data.RedirectUrl)
+	})
+
+	t.Run("post logout url without client id", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		app := setupOidcTestApp(t, mockAuthSvc)
+
+		req, _ := http.NewRequest("GET", "/auth/v1/logout?post_logout_redirect_uri=http://localhost/bye", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		mockAuthSvc.AssertNotCalled(t, "Logout")
+	})
+
+	t.Run("unregistered post logout url", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		mockAuthSvc.On("Logout", mock.Anything, "", "test", "http://evil.com", "").
+			Return("", fmt.Errorf("error getting the post logout redirect url %w", sdk.ErrPostLogoutRedirectUrlNotFound)).Once()
+		app := setupOidcTestApp(t, mockAuthSvc)
+
+		req, _ := http.NewRequest("GET", "/auth/v1/logout?client_id=test&post_logout_redirect_uri=http://evil.com", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		mockAuthSvc.AssertExpectations(t)
+	})
+}
@@ -6,9 +6,42 @@ func RegisterRoutes(router fiber.Router, path string) {
 	v1Path := path + "/v1"
 	v1 := router.Group(v1Path)
 	LoginRoute(v1, v1Path)
+	DiscoverLoginRoute(v1, v1Path)
 	RedirectRoute(v1, v1Path)
+	ConsentRoute(v1, v1Path)
+	DecideConsentRoute(v1, v1Path)
+	MfaRoute(v1, v1Path)
+	VerifyMfaRoute(v1, v1Path)
+	StartMfaPasskeyRoute(v1, v1Path)
+	VerifyMfaPasskeyRoute(v1, v1Path)
+	PasswordLoginRoute(v1, v1Path)
+	PasswordSignupRoute(v1, v1Path)
+	PasswordVerifyRoute(v1, v1Path)
+	ForgotPasswordRoute(v1, v1Path)
+	ResetPasswordRoute(v1, v1Path)
+	StartPasswordlessRoute(v1, v1Path)
+	PasswordlessVerifyRoute(v1, v1Path)
+	StartSmsOtpRoute(v1, v1Path)
+	PasskeyLoginStartRoute(v1, v1Path)
+	PasskeyLoginVerifyRoute(v1, v1Path)
+	SamlAcsRoute(v1, v1Path)
+	SamlMetadataRoute(v1, v1Path)
+	LdapLoginRoute(v1, v1Path)
+	TokenRoute(v1, v1Path)
 	VerifyRoute(v1, v1Path)
 	ClientCredentialsRoute(v1, v1Path)
+	RefreshTokenRoute(v1, v1Path)
+	RevokeRoute(v1, v1Path)
+	IntrospectRoute(v1, v1Path)
+	LogoutRoute(v1, v1Path)
+	JwksRoute(v1, v1Path)
+	UserInfoRoute(v1, v1Path)
+}
+
+// RegisterWellKnownRoutes registers the discovery documents served under /.well-known
+func RegisterWellKnownRoutes(router fiber.Router, path string) {
+	wk := router.Group(path)
+	OpenIdConfigurationRoute(wk, path)
 }
 
 var routeTags = []string{"Auth"}
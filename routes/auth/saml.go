@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// SamlAcsRoute registers the assertion consumer service the IdPs of the saml auth providers post their responses to
+func SamlAcsRoute(router fiber.Router, basePath string) {
+	routePath := "/saml/acs"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Saml Assertion Consumer Service",
+		Description: "Receive the SAML response the IdP posts with the browser of the user, with the HTTP-POST binding. The signed assertion is verified and the user is sent to the client redirect url with the auth code, the same way as the other auth providers. Only the logins started by go-iam are accepted, the relay state has to be the one sent with the authentication request",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The form posted by the IdP",
+			Content:     new(sdk.SamlAcsRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Redirect to the client",
+			Content:     new(sdk.AuthRedirectResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, SamlAcs)
+}
+
+func SamlAcs(c *fiber.Ctx) error {
+	log.Debug("received saml response")
+	payload := new(sdk.SamlAcsRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return sdk.AuthProviderBadRequest(fmt.Sprintf("invalid request body: %v", err), c)
+	}
+	if len(payload.SamlResponse) == 0 || len(payload.RelayState) == 0 {
+		return sdk.AuthProviderBadRequest("SAMLResponse and RelayState are required", c)
+	}
+
+	pr := providers.GetProviders(c)
+	resp, err := pr.S.Auth.SamlAcs(c.Context(), *payload)
+	if err != nil {
+		message := fmt.Errorf("failed to log in. %w", err).Error()
+		log.Errorw("failed to verify the saml response", "error", message)
+		return sdk.NewErrorAuthProviderResponse(message, samlErrorStatus(err), c)
+	}
+	log.Debug("logged in with saml successfully")
+	return c.Redirect(resp.RedirectUrl, http.StatusSeeOther)
+}
+
+// SamlMetadataRoute registers the route serving the service provider metadata of a saml auth provider
+func SamlMetadataRoute(router fiber.Router, basePath string) {
+	routePath := "/saml/:id/metadata"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodGet,
+		Name:        "Saml Service Provider Metadata",
+		Description: "Get the metadata xml registering go-iam as the service provider of a saml auth provider with the IdP. It only needs the acs url of the auth provider, so it is available before the metadata of the IdP is configured",
+		Tags:        routeTags,
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Description: "The ID of the saml auth provider",
+				Required:    true,
+			},
+		},
+		Response: &docs.ApiResponse{
+			Description: "The metadata xml",
+			Content:     new(string),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Get(routePath, SamlMetadata)
+}
+
+func SamlMetadata(c *fiber.Ctx) error {
+	pr := providers.GetProviders(c)
+	md, err := pr.S.Auth.SamlMetadata(c.Context(), c.Params("id"))
+	if err != nil {
+		message := fmt.Errorf("failed to get the saml metadata. %w", err).Error()
+		log.Errorw("failed to get the saml metadata", "error", message)
+		return sdk.NewErrorAuthProviderResponse(message, samlErrorStatus(err), c)
+	}
+	c.Set(fiber.HeaderContentType, "application/samlmetadata+xml")
+	return c.Status(http.StatusOK).Send(md)
+}
+
+// samlErrorStatus maps the errors of the saml auth provider to the response status
+func samlErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sdk.ErrInvalidSamlResponse):
+		return http.StatusUnauthorized
+	case errors.Is(err, sdk.ErrNotSamlProvider):
+		return http.StatusBadRequest
+	case errors.Is(err, sdk.ErrAuthProviderNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamlAcs(t *testing.T) {
+	acsReq := sdk.SamlAcsRequest{SamlResponse: "PHNhbWxwOlJlc3BvbnNlLz4=", RelayState: "state-1"}
+	form := url.Values{"SAMLResponse": {acsReq.SamlResponse}, "RelayState": {acsReq.RelayState}}.Encode()
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "redirects to the client",
+			body: form,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("SamlAcs", mock.Anything, acsReq).Return(&sdk.AuthRedirectResponse{RedirectUrl: "http://callback.com?code=abc"}, nil).Once()
+			},
+			expectedStatus: http.StatusSeeOther,
+		},
+		{
+			name:           "missing relay state",
+			body:           url.Values{"SAMLResponse": {acsReq.SamlResponse}}.Encode(),
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid response",
+			body: form,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("SamlAcs", mock.Anything, acsReq).Return(nil, fmt.Errorf("error verifying the code %w: digest mismatch", sdk.ErrInvalidSamlResponse)).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "provider of another type",
+			body: form,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("SamlAcs", mock.Anything, acsReq).Return(nil, sdk.ErrNotSamlProvider).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/saml/acs", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+			if tt.expectedStatus == http.StatusSeeOther {
+				assert.Equal(t, "http://callback.com?code=abc", res.Header.Get("Location"))
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSamlMetadata(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		mockAuthSvc.On("SamlMetadata", mock.Anything, "provider-1").Return([]byte(`<md:EntityDescriptor/>`), nil).Once()
+		app := setupOidcTestApp(t, mockAuthSvc)
+
+		req, _ := http.NewRequest("GET", "/auth/v1/saml/provider-1/metadata", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/samlmetadata+xml", res.Header.Get("Content-Type"))
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `<md:EntityDescriptor/>`, string(body))
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		mockAuthSvc.On("SamlMetadata", mock.Anything, "provider-1").Return(nil, fmt.Errorf("error fetching auth provider details %w", sdk.ErrAuthProviderNotFound)).Once()
+		app := setupOidcTestApp(t, mockAuthSvc)
+
+		req, _ := http.NewRequest("GET", "/auth/v1/saml/provider-1/metadata", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// StartSmsOtpRoute registers the route texting the login code of an sms auth provider
+func StartSmsOtpRoute(router fiber.Router, basePath string) {
+	routePath := "/sms/start"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Start Sms Login",
+		Description: "Text a single use login code to the phone number of the user. The number is normalized to E.164 using the default country code of the auth provider. A number has to wait a minute before another code is sent to it, and the requests are rate limited per number and IP address. The code is verified with the passwordless verify api",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The state passed to the login page and the phone number of the user",
+			Content:     new(sdk.SmsOtpStartRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Login code sent",
+			Content:     new(sdk.PasswordlessResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, StartSmsOtp)
+}
+
+func StartSmsOtp(c *fiber.Ctx) error {
+	log.Debug("received sms login request")
+	payload := new(sdk.SmsOtpStartRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return passwordlessResponse(c, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+	}
+	if len(payload.State) == 0 || len(payload.Phone) == 0 {
+		return passwordlessResponse(c, http.StatusBadRequest, "state and phone are required")
+	}
+
+	pr := providers.GetProviders(c)
+	err := pr.S.Auth.StartSmsOtp(c.Context(), *payload, c.IP())
+	if err != nil {
+		message := fmt.Errorf("failed to send the login sms. %w", err).Error()
+		log.Errorw("failed to send the login sms", "error", message)
+		return passwordlessResponse(c, smsErrorStatus(err), message)
+	}
+	log.Debug("login sms sent")
+	return passwordlessResponse(c, http.StatusOK, "Login code sent, check your messages")
+}
+
+// smsErrorStatus maps the errors of the sms auth provider to the response status
+func smsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sdk.ErrInvalidPhone), errors.Is(err, sdk.ErrNotSmsProvider):
+		return http.StatusBadRequest
+	case errors.Is(err, sdk.ErrOtpResendCooldown):
+		return http.StatusTooManyRequests
+	}
+	return passwordlessErrorStatus(err)
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartSmsOtp(t *testing.T) {
+	startReq := sdk.SmsOtpStartRequest{State: "state-1", Phone: "+919876543210"}
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			body: `{"state": "state-1", "phone": "+919876543210"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("StartSmsOtp", mock.Anything, startReq, mock.AnythingOfType("string")).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing phone",
+			body:           `{"state": "state-1"}`,
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid phone",
+			body: `{"state": "state-1", "phone": "+919876543210"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("StartSmsOtp", mock.Anything, startReq, mock.AnythingOfType("string")).
+					Return(fmt.Errorf("error sending the login sms %w", sdk.ErrInvalidPhone)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "resend cooldown",
+			body: `{"state": "state-1", "phone": "+919876543210"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("StartSmsOtp", mock.Anything, startReq, mock.AnythingOfType("string")).Return(sdk.ErrOtpResendCooldown).Once()
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name: "rate limited",
+			body: `{"state": "state-1", "phone": "+919876543210"}`,
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("StartSmsOtp", mock.Anything, startReq, mock.AnythingOfType("string")).Return(sdk.ErrTooManyOtpRequests).Once()
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/sms/start", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.PasswordlessResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
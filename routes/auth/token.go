@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// TokenRoute registers the OAuth2 token route
+func TokenRoute(router fiber.Router, basePath string) {
+	routePath := "/token"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Token",
+		Description: "OAuth2 token endpoint accepting application/x-www-form-urlencoded requests with the authorization_code, client_credentials and refresh_token grants. Clients authenticate with basic auth (client_secret_basic) or with the credentials in the body (client_secret_post). Errors follow RFC 6749",
+		Tags:        routeTags,
+		RequestBody: &docs.ApiRequestBody{
+			Description: "Token request",
+			Content:     new(sdk.TokenRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Tokens issued successfully",
+			Content:     new(sdk.AuthVerifyCodeResponse),
+		},
+		UnAuthenticated:      true,
+		ProjectIDNotRequired: true,
+	})
+	router.Post(routePath, Token)
+}
+
+func Token(c *fiber.Ctx) error {
+	log.Debug("received token request")
+	// token responses must never be cached
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderPragma, "no-cache")
+
+	payload := new(sdk.TokenRequest)
+	if err := c.BodyParser(payload); err != nil {
+		log.Errorw("invalid token request body", "error", err.Error())
+		return tokenError(c, http.StatusBadRequest, sdk.OAuthErrorInvalidRequest, "invalid request body")
+	}
+
+	basicAuth := false
+	if clId, clSec, ok := getClientDetails(c); ok {
+		// client_secret_basic encodes the credentials as application/x-www-form-urlencoded before base64
+		id, idErr := url.QueryUnescape(clId)
+		secret, secretErr := url.QueryUnescape(clSec)
+		if idErr != nil || secretErr != nil {
+			return tokenError(c, http.StatusBadRequest, sdk.OAuthErrorInvalidRequest, "invalid client credentials encoding in the authorization header")
+		}
+		if len(payload.ClientSecret) > 0 {
+			return tokenError(c, http.StatusBadRequest, sdk.OAuthErrorInvalidRequest, "only one client authentication method can be used")
+		}
+		payload.ClientId = id
+		payload.ClientSecret = secret
+		basicAuth = true
+	}
+
+	if len(payload.GrantType) == 0 {
+		return tokenError(c, http.StatusBadRequest, sdk.OAuthErrorInvalidRequest, "grant_type is required")
+	}
+	if len(payload.ClientId) == 0 {
+		return tokenError(c, http.StatusBadRequest, sdk.OAuthErrorInvalidRequest, "client_id is required")
+	}
+	switch payload.GrantType {
+	case sdk.GrantTypeAuthorizationCode:
+		if len(payload.Code) == 0 || len(payload.RedirectUri) == 0 {
+			return tokenError(c, http.StatusBadRequest, sdk.OAuthErrorInvalidRequest, "code and redirect_uri are required")
+		}
+	case sdk.GrantTypeClientCredentials:
+		if len(payload.ClientSecret) == 0 {
+			return tokenError(c, http.StatusUnauthorized, sdk.OAuthErrorInvalidClient, "client_secret is required")
+		}
+	case sdk.GrantTypeRefreshToken:
+		if len(payload.RefreshToken) == 0 {
+			return tokenError(c, http.StatusBadRequest, sdk.OAuthErrorInvalidRequest, "refresh_token is required")
+		}
+	}
+
+	pr := providers.GetProviders(c)
+	resp, err := pr.S.Auth.Token(c.Context(), *payload)
+	if err != nil {
+		log.Errorw("token request failed",
+			"grant_type", payload.GrantType,
+			"client_id", payload.ClientId,
+			"error", err.Error())
+		// the wrapped error is only logged, the client gets the fixed description of the error code
+		switch {
+		case errors.Is(err, sdk.ErrInvalidClient):
+			if basicAuth {
+				c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="go-iam"`)
+			}
+			return tokenError(c, http.StatusUnauthorized, sdk.OAuthErrorInvalidClient, "client authentication failed")
+		case errors.Is(err, sdk.ErrInvalidGrant):
+			return tokenError(c, http.StatusBadRequest, sdk.OAuthErrorInvalidGrant, "the grant is invalid, expired, revoked or was issued to another client")
+		case errors.Is(err, sdk.ErrUnauthorizedClient):
+			return tokenError(c, http.StatusBadRequest, sdk.OAuthErrorUnauthorizedClient, "the client is not authorized to use this grant type")
+		case errors.Is(err, sdk.ErrInvalidScope):
+			return tokenError(c, http.StatusBadRequest, sdk.OAuthErrorInvalidScope, "the requested scope is invalid or exceeds the granted scope")
+		case errors.Is(err, sdk.ErrUnsupportedGrantType):
+			return tokenError(c, http.StatusBadRequest, sdk.OAuthErrorUnsupportedGrantType, "the grant type is not supported")
+		}
+		return tokenError(c, http.StatusInternalServerError, sdk.OAuthErrorServerError, "failed to issue the token")
+	}
+
+	log.Debugw("token issued successfully",
+		"grant_type", payload.GrantType,
+		"client_id", payload.ClientId)
+	return c.Status(http.StatusOK).JSON(resp)
+}
+
+func tokenError(c *fiber.Ctx, status int, code, description string) error {
+	return c.Status(status).JSON(sdk.TokenErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+// deprecated marks the responses of the routes replaced by /auth/v1/token
+func deprecated(handler fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", "true")
+		c.Set(fiber.HeaderLink, `</auth/v1/token>; rel="successor-version"`)
+		return handler(c)
+	}
+}
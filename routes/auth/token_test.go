@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToken(t *testing.T) {
+	issued := &sdk.AuthVerifyCodeResponse{AccessToken: "access-token", RefreshToken: "refresh-token", TokenType: "Bearer", ExpiresIn: 3600}
+
+	tests := []struct {
+		name           string
+		body           string
+		basicAuth      string
+		setupMocks     func(m *services.MockAuthService)
+		expectedStatus int
+		expectedError  string
+		expectedDesc   string
+	}{
+		{
+			name:      "authorization code with client_secret_basic",
+			body:      "grant_type=authorization_code&code=abc&redirect_uri=http%3A%2F%2Flocalhost%2Fcb",
+			basicAuth: "dGVzdDpzZWNyZXQ=", // test:secret
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("Token", mock.Anything, sdk.TokenRequest{GrantType: "authorization_code", Code: "abc", RedirectUri: "http://localhost/cb", ClientId: "test", ClientSecret: "secret"}).Return(issued, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "authorization code with pkce",
+			body: "grant_type=authorization_code&code=abc&redirect_uri=http%3A%2F%2Flocalhost%2Fcb&client_id=test&code_verifier=verifier",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("Token", mock.Anything, sdk.TokenRequest{GrantType: "authorization_code", Code: "abc", RedirectUri: "http://localhost/cb", ClientId: "test", CodeVerifier: "verifier"}).Return(issued, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "client credentials with client_secret_post",
+			body: "grant_type=client_credentials&client_id=test&client_secret=secret",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("Token", mock.Anything, sdk.TokenRequest{GrantType: "client_credentials", ClientId: "test", ClientSecret: "secret"}).Return(issued, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "client credentials with a scope that is not allowed",
+			body: "grant_type=client_credentials&client_id=test&client_secret=secret&scope=admin",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("Token", mock.Anything, sdk.TokenRequest{GrantType: "client_credentials", ClientId: "test", ClientSecret: "secret", Scope: "admin"}).Return(nil, fmt.Errorf("%w: admin is not allowed for the client", sdk.ErrInvalidScope)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  sdk.OAuthErrorInvalidScope,
+		},
+		{
+			name:           "missing grant type",
+			body:           "client_id=test",
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  sdk.OAuthErrorInvalidRequest,
+		},
+		{
+			name:           "authorization code without redirect uri",
+			body:           "grant_type=authorization_code&code=abc&client_id=test",
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  sdk.OAuthErrorInvalidRequest,
+		},
+		{
+			name:           "two client authentication methods",
+			body:           "grant_type=client_credentials&client_secret=secret",
+			basicAuth:      "dGVzdDpzZWNyZXQ=",
+			setupMocks:     func(m *services.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  sdk.OAuthErrorInvalidRequest,
+		},
+		{
+			name: "unsupported grant type",
+			body: "grant_type=password&client_id=test",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("Token", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w - password", sdk.ErrUnsupportedGrantType)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  sdk.OAuthErrorUnsupportedGrantType,
+		},
+		{
+			name: "invalid client",
+			body: "grant_type=client_credentials&client_id=test&client_secret=wrong",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("Token", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: authentication failed", sdk.ErrInvalidClient)).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  sdk.OAuthErrorInvalidClient,
+			expectedDesc:   "client authentication failed",
+		},
+		{
+			name: "reused refresh token",
+			body: "grant_type=refresh_token&refresh_token=rt&client_id=test",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("Token", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: %w", sdk.ErrInvalidGrant, sdk.ErrRefreshTokenReused)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  sdk.OAuthErrorInvalidGrant,
+			expectedDesc:   "the grant is invalid, expired, revoked or was issued to another client",
+		},
+		{
+			name: "client not allowed to use the grant",
+			body: "grant_type=client_credentials&client_id=test&client_secret=secret",
+			setupMocks: func(m *services.MockAuthService) {
+				m.On("Token", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: client does not support service account flow", sdk.ErrUnauthorizedClient)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  sdk.OAuthErrorUnauthorizedClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			tt.setupMocks(mockAuthSvc)
+			app := setupOidcTestApp(t, mockAuthSvc)
+
+			req, _ := http.NewRequest("POST", "/auth/v1/token", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.basicAuth != "" {
+				req.Header.Set("Authorization", "Basic "+tt.basicAuth)
+			}
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+			assert.Equal(t, "no-store", res.Header.Get("Cache-Control"))
+
+			if tt.expectedStatus == http.StatusOK {
+				var resp sdk.AuthVerifyCodeResponse
+				err = json.NewDecoder(res.Body).Decode(&resp)
+				assert.NoError(t, err)
+				assert.Equal(t, *issued, resp)
+			} else {
+				var resp sdk.TokenErrorResponse
+				err = json.NewDecoder(res.Body).Decode(&resp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, resp.Error)
+				if tt.expectedDesc != "" {
+					assert.Equal(t, tt.expectedDesc, resp.ErrorDescription)
+				}
+			}
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeprecatedTokenRoutes(t *testing.T) {
+	mockAuthSvc := &services.MockAuthService{}
+	mockAuthSvc.On("ClientCredentials", mock.Anything, "test", "secret").Return(&sdk.AuthVerifyCodeResponse{AccessToken: "access-token"}, nil).Once()
+	app := setupOidcTestApp(t, mockAuthSvc)
+
+	req, _ := http.NewRequest("POST", "/auth/v1/client", strings.NewReader(`{"client_id": "test", "client_secret": "secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res, err := app.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "true", res.Header.Get("Deprecation"))
+	assert.Contains(t, res.Header.Get("Link"), "/auth/v1/token")
+}
@@ -44,6 +44,9 @@ func Create(c *fiber.Ctx) error {
 	pr := providers.GetProviders(c)
 	err := pr.S.AuthProviders.Create(c.Context(), payload)
 	if err != nil {
+		if errors.Is(err, sdk.ErrInvalidAuthProviderConfig) {
+			return sdk.AuthProviderBadRequest(err.Error(), c)
+		}
 		message := fmt.Errorf("failed to create authprovider. %w", err).Error()
 		log.Errorw("failed to create authprovider", "error", message)
 		return sdk.AuthProviderInternalServerError(message, c)
@@ -57,6 +60,36 @@ func Create(c *fiber.Ctx) error {
 	})
 }
 
+// TypesRoute registers the route listing the types of auth providers with the schema of their params
+func TypesRoute(router fiber.Router, basePath string) {
+	routePath := "/types"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:                 path,
+		Method:               http.MethodGet,
+		Name:                 "Get AuthProvider Types",
+		Description:          "List the types of authproviders with the params their configurations take, to render the forms of the admin UI",
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+		Response: &docs.ApiResponse{
+			Description: "AuthProvider types fetched successfully",
+			Content:     new(sdk.AuthProviderTypesResponse),
+		},
+	})
+	router.Get(routePath, Types)
+}
+
+func Types(c *fiber.Ctx) error {
+	log.Debug("received get authprovider types request")
+	pr := providers.GetProviders(c)
+	types := pr.S.AuthProviders.GetTypes(c.Context())
+	return c.Status(http.StatusOK).JSON(sdk.AuthProviderTypesResponse{
+		Success: true,
+		Message: "Authprovider types fetched successfully",
+		Data:    types,
+	})
+}
+
 func GetRoute(router fiber.Router, basePath string) {
 	routePath := "/:id"
 	path := basePath + routePath
@@ -185,6 +218,9 @@ func Update(c *fiber.Ctx) error {
 		if errors.Is(err, sdk.ErrAuthProviderNotFound) {
 			return sdk.AuthProviderNotFound("Auth Provider not found", c)
 		}
+		if errors.Is(err, sdk.ErrInvalidAuthProviderConfig) {
+			return sdk.AuthProviderBadRequest(err.Error(), c)
+		}
 		message := fmt.Errorf("failed to update authprovider. %w", err).Error()
 		log.Errorw("failed to update authprovider", "error", message)
 		return sdk.AuthProviderInternalServerError(message, c)
@@ -3,6 +3,7 @@ package authprovider
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
@@ -146,6 +147,90 @@ func TestCreate(t *testing.T) {
 		assert.NotNil(t, resp)
 		assert.False(t, resp.Success)
 	})
+	t.Run("invalid config", func(t *testing.T) {
+		app := fiber.New(fiber.Config{
+			ReadBufferSize: 8192,
+		})
+
+		d := test.SetupMockDB()
+		cs := cache.NewMockService()
+		svcs, err := server.GetServices(*cnf, cs, d)
+		if err != nil {
+			t.Errorf("error getting services: %s", err)
+			return
+		}
+
+		mockAuthProviderSvc := services.MockAuthProviderService{}
+		mockAuthProviderSvc.On("Create", mock.Anything, mock.Anything).Return(fmt.Errorf("%w: @GOOGLE/CLIENT_ID is required", sdk.ErrInvalidAuthProviderConfig)).Once()
+
+		svcs.AuthProviders = &mockAuthProviderSvc
+
+		prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+
+		app.Use(providers.Handle(prv))
+
+		RegisterRoutes(app, "/authprovider")
+
+		req, _ := http.NewRequest("POST", "/authprovider/v1", strings.NewReader(`{
+			"name": "Test Auth Provider",
+			"provider": "GOOGLE"
+		}`))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req, -1)
+		assert.Nil(t, err)
+		assert.Equalf(t, 400, res.StatusCode, "Expected status code 400")
+		var resp sdk.AuthProviderResponse
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		assert.Nil(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "@GOOGLE/CLIENT_ID is required")
+	})
+}
+
+func TestTypes(t *testing.T) {
+	err := os.Setenv("JWT_SECRET", "abcd")
+	require.NoError(t, err)
+	cnf := config.NewAppConfig()
+
+	t.Run("list auth provider types", func(t *testing.T) {
+		app := fiber.New(fiber.Config{
+			ReadBufferSize: 8192,
+		})
+
+		d := test.SetupMockDB()
+		cs := cache.NewMockService()
+		svcs, err := server.GetServices(*cnf, cs, d)
+		if err != nil {
+			t.Errorf("error getting services: %s", err)
+			return
+		}
+
+		mockAuthProviderSvc := services.MockAuthProviderService{}
+		mockAuthProviderSvc.On("GetTypes", mock.Anything).Return([]sdk.AuthProviderTypeInfo{
+			{Type: sdk.AuthProviderTypeGoogle, Name: "Google", Params: []sdk.AuthProviderParamSpec{{Key: "@GOOGLE/CLIENT_ID", Required: true}}},
+		}).Once()
+
+		svcs.AuthProviders = &mockAuthProviderSvc
+
+		prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+
+		app.Use(providers.Handle(prv))
+
+		RegisterRoutes(app, "/authprovider")
+
+		req, _ := http.NewRequest("GET", "/authprovider/v1/types", nil)
+		res, err := app.Test(req, -1)
+		assert.Nil(t, err)
+		assert.Equalf(t, 200, res.StatusCode, "Expected status code 200")
+		var resp sdk.AuthProviderTypesResponse
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		assert.Nil(t, err)
+		assert.True(t, resp.Success)
+		require.Len(t, resp.Data, 1)
+		assert.Equal(t, sdk.AuthProviderTypeGoogle, resp.Data[0].Type)
+		assert.True(t, resp.Data[0].Params[0].Required)
+		mockAuthProviderSvc.AssertExpectations(t)
+	})
 }
 
 func TestGet(t *testing.T) {
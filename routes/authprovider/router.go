@@ -6,6 +6,8 @@ func RegisterRoutes(router fiber.Router, path string) {
 	v1Path := path + "/v1"
 	v1 := router.Group(v1Path)
 	CreateRoute(v1, v1Path)
+	// registered before the get route, for types not to be taken for an id
+	TypesRoute(v1, v1Path)
 	GetRoute(v1, v1Path)
 	FetchAllRoute(v1, v1Path)
 	UpdateRoute(v1, v1Path)
@@ -47,9 +47,15 @@ func Create(c *fiber.Ctx) error {
 		message := "either service account email or default auth provider id must be provided"
 		return sdk.ClientBadRequest(message, c)
 	}
+	if err := payload.ValidateAccessTokenSettings(); err != nil {
+		return sdk.ClientBadRequest(err.Error(), c)
+	}
 
 	err := pr.S.Clients.Create(c.Context(), payload)
 	if err != nil {
+		if errors.Is(err, sdk.ErrInvalidScope) {
+			return sdk.ClientBadRequest(err.Error(), c)
+		}
 		message := fmt.Errorf("failed to create client. %w", err).Error()
 		log.Errorw("failed to create client", "error", err)
 		return sdk.ClientInternalServerError(message, c)
@@ -194,6 +200,9 @@ func Update(c *fiber.Ctx) error {
 		message := "either service account email or default auth provider id must be provided"
 		return sdk.ClientBadRequest(message, c)
 	}
+	if err := payload.ValidateAccessTokenSettings(); err != nil {
+		return sdk.ClientBadRequest(err.Error(), c)
+	}
 	pr := providers.GetProviders(c)
 
 	err := pr.S.Clients.Update(c.Context(), payload)
@@ -201,6 +210,9 @@ func Update(c *fiber.Ctx) error {
 		if errors.Is(err, sdk.ErrClientNotFound) {
 			return sdk.ClientNotFound("Client not found", c)
 		}
+		if errors.Is(err, sdk.ErrInvalidScope) {
+			return sdk.ClientBadRequest(err.Error(), c)
+		}
 		message := fmt.Errorf("failed to update client. %w", err).Error()
 		log.Error("failed to update client", "error", err)
 		return sdk.ClientInternalServerError(message, c)
@@ -203,6 +203,45 @@ func TestCreate(t *testing.T) {
 		assert.NotNil(t, resp)
 		assert.Nil(t, resp.Data)
 	})
+
+	t.Run("create client with a reserved access token claim", func(t *testing.T) {
+		app := fiber.New(fiber.Config{
+			ReadBufferSize: 8192,
+		})
+
+		d := test.SetupMockDB()
+		cs := cache.NewMockService()
+		svcs, err := server.GetServices(*cnf, cs, d)
+		if err != nil {
+			t.Errorf("error getting services: %s", err)
+			return
+		}
+		// client mock
+
+		mockClientSvc := services.MockClientService{}
+		mockClientSvc.On("GetGoIamClients", mock.Anything, mock.Anything).Return([]sdk.Client{}, nil)
+		mockClientSvc.On("Subscribe", mock.Anything, mock.Anything).Return()
+
+		svcs.Clients = &mockClientSvc
+
+		prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+
+		app.Use(providers.Handle(prv))
+
+		RegisterRoutes(app, "/client")
+
+		req, _ := http.NewRequest("POST", "/client/v1", strings.NewReader(`{
+			"name": "Test Client",
+			"default_auth_provider_id": "google",
+			"access_token_format": "self_contained",
+			"access_token_claims": {"sub": "email"}
+		}`))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req, -1)
+		assert.Equalf(t, 400, res.StatusCode, "Expected status code 400")
+		assert.Nil(t, err)
+		mockClientSvc.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
 }
 
 func TestGet(t *testing.T) {
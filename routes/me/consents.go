@@ -0,0 +1,118 @@
+package me
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/middlewares"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// ConsentsRoute registers the route listing the consents of the current user
+func ConsentsRoute(router fiber.Router, basePath string) {
+	routePath := "/consents"
+	path := basePath + routePath
+	router.Get(routePath, Consents)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodGet,
+		Name:        "Get My Consents",
+		Description: "List the third party clients the current user has consented to along with the granted scopes",
+		Response: &docs.ApiResponse{
+			Description: "Consents fetched successfully",
+			Content:     new(sdk.ConsentsResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func Consents(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.ConsentsResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	consents, err := pr.S.Consents.List(c.Context(), user.Id)
+	if err != nil {
+		message := fmt.Errorf("failed to fetch the consents. %w", err).Error()
+		log.Errorw("failed to fetch the consents", "error", message)
+		return c.Status(http.StatusInternalServerError).JSON(sdk.ConsentsResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("consents fetched successfully")
+	return c.Status(http.StatusOK).JSON(sdk.ConsentsResponse{
+		Success: true,
+		Message: "Consents fetched successfully",
+		Data:    consents,
+	})
+}
+
+// RevokeConsentRoute registers the route revoking a consent of the current user
+func RevokeConsentRoute(router fiber.Router, basePath string) {
+	routePath := "/consents/:clientId"
+	path := basePath + routePath
+	router.Delete(routePath, RevokeConsent)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodDelete,
+		Name:        "Revoke My Consent",
+		Description: "Revoke the consent given to a third party client. The tokens issued to the client for the current user stop working",
+		Response: &docs.ApiResponse{
+			Description: "Consent revoked successfully",
+			Content:     new(sdk.ConsentsResponse),
+		},
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "clientId",
+				In:          "path",
+				Description: "The client whose consent is revoked",
+				Required:    true,
+			},
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func RevokeConsent(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.ConsentsResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+	clientId := c.Params("clientId")
+
+	pr := providers.GetProviders(c)
+	err := pr.S.Auth.RevokeConsent(c.Context(), user.Id, clientId)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, sdk.ErrConsentNotFound) {
+			status = http.StatusNotFound
+		}
+		message := fmt.Errorf("failed to revoke the consent. %w", err).Error()
+		log.Errorw("failed to revoke the consent", "client_id", clientId, "error", message)
+		return c.Status(status).JSON(sdk.ConsentsResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debugw("consent revoked successfully", "client_id", clientId)
+	return c.Status(http.StatusOK).JSON(sdk.ConsentsResponse{
+		Success: true,
+		Message: "Consent revoked successfully",
+	})
+}
@@ -0,0 +1,127 @@
+package me
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/melvinodsa/go-iam/config"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/melvinodsa/go-iam/utils/test/server"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupConsentsTestApp(t *testing.T, mockConsentSvc *services.MockConsentService, mockAuthSvc *services.MockAuthService, usr *sdk.User) *fiber.App {
+	err := os.Setenv("JWT_SECRET", "abcd")
+	require.NoError(t, err)
+	cnf := config.NewAppConfig()
+
+	app := fiber.New(fiber.Config{
+		ReadBufferSize: 8192,
+	})
+	d := test.SetupMockDB()
+	cs := cache.NewMockService()
+	svcs, err := server.GetServices(*cnf, cs, d)
+	require.NoError(t, err)
+	svcs.Consents = mockConsentSvc
+	svcs.Auth = mockAuthSvc
+
+	prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+	app.Use(providers.Handle(prv))
+	if usr != nil {
+		app.Use(func(c *fiber.Ctx) error {
+			c.Context().SetUserValue(sdk.UserTypeVal, usr)
+			return c.Next()
+		})
+	}
+
+	RegisterRoutes(app, "/me")
+	return app
+}
+
+func TestConsents(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", Email: "test@example.com"}
+
+	t.Run("success - lists the consents of the user", func(t *testing.T) {
+		mockConsentSvc := &services.MockConsentService{}
+		mockConsentSvc.On("List", mock.Anything, "user-123").Return([]sdk.Consent{
+			{Id: "consent-1", UserId: "user-123", ClientId: "client-1", Scopes: []string{"openid"}},
+		}, nil).Once()
+		app := setupConsentsTestApp(t, mockConsentSvc, &services.MockAuthService{}, usr)
+
+		req, _ := http.NewRequest("GET", "/me/v1/consents", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp sdk.ConsentsResponse
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		assert.NoError(t, err)
+		assert.True(t, resp.Success)
+		assert.Len(t, resp.Data, 1)
+		assert.Equal(t, "client-1", resp.Data[0].ClientId)
+		mockConsentSvc.AssertExpectations(t)
+	})
+
+	t.Run("error - listing fails", func(t *testing.T) {
+		mockConsentSvc := &services.MockConsentService{}
+		mockConsentSvc.On("List", mock.Anything, "user-123").Return(nil, errors.New("database error")).Once()
+		app := setupConsentsTestApp(t, mockConsentSvc, &services.MockAuthService{}, usr)
+
+		req, _ := http.NewRequest("GET", "/me/v1/consents", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("error - no user in the context", func(t *testing.T) {
+		app := setupConsentsTestApp(t, &services.MockConsentService{}, &services.MockAuthService{}, nil)
+
+		req, _ := http.NewRequest("GET", "/me/v1/consents", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
+
+func TestRevokeConsent(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", Email: "test@example.com"}
+
+	tests := []struct {
+		name           string
+		revokeErr      error
+		expectedStatus int
+	}{
+		{name: "success", expectedStatus: http.StatusOK},
+		{name: "consent not found", revokeErr: sdk.ErrConsentNotFound, expectedStatus: http.StatusNotFound},
+		{name: "revocation fails", revokeErr: errors.New("database error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthSvc := &services.MockAuthService{}
+			mockAuthSvc.On("RevokeConsent", mock.Anything, "user-123", "client-1").Return(tt.revokeErr).Once()
+			app := setupConsentsTestApp(t, &services.MockConsentService{}, mockAuthSvc, usr)
+
+			req, _ := http.NewRequest("DELETE", "/me/v1/consents/client-1", nil)
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.ConsentsResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package me
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/middlewares"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// IdentitiesRoute registers the route listing the identities at the auth providers linked to the current user
+func IdentitiesRoute(router fiber.Router, basePath string) {
+	routePath := "/identities"
+	path := basePath + routePath
+	router.Get(routePath, Identities)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodGet,
+		Name:        "Get My Identities",
+		Description: "List the identities at the auth providers linked to the current user, oldest first. The user can log in with any of them",
+		Response: &docs.ApiResponse{
+			Description: "Identities fetched successfully",
+			Content:     new(sdk.UserIdentitiesResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func Identities(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.UserIdentitiesResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	identities, err := pr.S.Identities.List(c.Context(), user.Id)
+	if err != nil {
+		message := fmt.Errorf("failed to get the identities. %w", err).Error()
+		log.Errorw("failed to get the identities", "error", message)
+		return c.Status(http.StatusInternalServerError).JSON(sdk.UserIdentitiesResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	return c.Status(http.StatusOK).JSON(sdk.UserIdentitiesResponse{
+		Success: true,
+		Message: "Identities fetched successfully",
+		Data:    identities,
+	})
+}
+
+// LinkIdentityRoute registers the route starting the linking of an identity at an auth provider to the current user
+func LinkIdentityRoute(router fiber.Router, basePath string) {
+	routePath := "/identities/link"
+	path := basePath + routePath
+	router.Post(routePath, LinkIdentity)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Link My Identity",
+		Description: "Get the url the current user logs in at with the auth provider to link their identity. Once linked, the user is sent back to the redirect url of the client with the id of the identity in the linked_identity query param",
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The auth provider of the identity and the client the user is sent back to",
+			Content:     new(sdk.UserIdentityLinkRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Identity linking started",
+			Content:     new(sdk.AuthLoginResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func LinkIdentity(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.AuthLoginResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+	payload := new(sdk.UserIdentityLinkRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.AuthLoginResponse{
+			Success: false,
+			Message: fmt.Sprintf("invalid request body: %v", err),
+		})
+	}
+	if len(payload.AuthProviderId) == 0 || len(payload.ClientId) == 0 || len(payload.RedirectUrl) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(sdk.AuthLoginResponse{
+			Success: false,
+			Message: "auth_provider_id, client_id and redirect_url are required",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	loginUrl, err := pr.S.Auth.StartIdentityLink(c.Context(), *user, *payload)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, sdk.ErrAuthProviderNotFound) || errors.Is(err, sdk.ErrClientNotFound) || errors.Is(err, sdk.ErrRedirectUrlNotFound) {
+			status = http.StatusBadRequest
+		}
+		message := fmt.Errorf("failed to start linking the identity. %w", err).Error()
+		log.Errorw("failed to start linking the identity", "error", message)
+		return c.Status(status).JSON(sdk.AuthLoginResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("identity linking started")
+	return c.Status(http.StatusOK).JSON(sdk.AuthLoginResponse{
+		Success: true,
+		Message: "Identity linking started",
+		Data:    sdk.AuthLoginDataResponse{LoginUrl: loginUrl},
+	})
+}
+
+// UnlinkIdentityRoute registers the route removing an identity linked to the current user
+func UnlinkIdentityRoute(router fiber.Router, basePath string) {
+	routePath := "/identities/:id"
+	path := basePath + routePath
+	router.Delete(routePath, UnlinkIdentity)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodDelete,
+		Name:        "Unlink My Identity",
+		Description: "Unlink an identity from the current user. Logging in with it is refused until the user links it again",
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Description: "The ID of the linked identity",
+				Required:    true,
+			},
+		},
+		Response: &docs.ApiResponse{
+			Description: "Identity unlinked successfully",
+			Content:     new(sdk.UserIdentitiesResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func UnlinkIdentity(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.UserIdentitiesResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	err := pr.S.Identities.Unlink(c.Context(), user.Id, c.Params("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, sdk.ErrUserIdentityNotFound) {
+			status = http.StatusNotFound
+		}
+		message := fmt.Errorf("failed to unlink the identity. %w", err).Error()
+		log.Errorw("failed to unlink the identity", "error", message)
+		return c.Status(status).JSON(sdk.UserIdentitiesResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("identity unlinked successfully")
+	return c.Status(http.StatusOK).JSON(sdk.UserIdentitiesResponse{
+		Success: true,
+		Message: "Identity unlinked successfully",
+	})
+}
@@ -0,0 +1,156 @@
+package me
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/melvinodsa/go-iam/config"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/melvinodsa/go-iam/utils/test/server"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupIdentitiesTestApp(t *testing.T, mockIdentitySvc *services.MockIdentityService, mockAuthSvc *services.MockAuthService, usr *sdk.User) *fiber.App {
+	err := os.Setenv("JWT_SECRET", "abcd")
+	require.NoError(t, err)
+	cnf := config.NewAppConfig()
+
+	app := fiber.New(fiber.Config{
+		ReadBufferSize: 8192,
+	})
+	d := test.SetupMockDB()
+	cs := cache.NewMockService()
+	svcs, err := server.GetServices(*cnf, cs, d)
+	require.NoError(t, err)
+
+	prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+	svcs.Identities = mockIdentitySvc
+	svcs.Auth = mockAuthSvc
+	app.Use(providers.Handle(prv))
+	app.Use(func(c *fiber.Ctx) error {
+		c.Context().SetUserValue(sdk.UserTypeVal, usr)
+		return c.Next()
+	})
+
+	RegisterRoutes(app, "/me")
+	return app
+}
+
+func TestIdentities(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", ProjectId: "project-1", Email: "test@example.com"}
+
+	t.Run("success", func(t *testing.T) {
+		mockIdentitySvc := &services.MockIdentityService{}
+		mockIdentitySvc.On("List", mock.Anything, "user-123").Return([]sdk.UserIdentity{{Id: "identity-1", AuthProviderId: "provider-1", Subject: "subject-1"}}, nil).Once()
+		app := setupIdentitiesTestApp(t, mockIdentitySvc, &services.MockAuthService{}, usr)
+
+		req, _ := http.NewRequest("GET", "/me/v1/identities", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp sdk.UserIdentitiesResponse
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 1)
+		assert.Equal(t, "subject-1", resp.Data[0].Subject)
+	})
+
+	t.Run("list fails", func(t *testing.T) {
+		mockIdentitySvc := &services.MockIdentityService{}
+		mockIdentitySvc.On("List", mock.Anything, "user-123").Return(nil, errors.New("database error")).Once()
+		app := setupIdentitiesTestApp(t, mockIdentitySvc, &services.MockAuthService{}, usr)
+
+		req, _ := http.NewRequest("GET", "/me/v1/identities", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+func TestLinkIdentity(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", ProjectId: "project-1", Email: "test@example.com"}
+	body := `{"auth_provider_id": "provider-1", "client_id": "client-1", "redirect_url": "https://app.example.com/linked", "state": "xyz"}`
+
+	t.Run("success", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		mockAuthSvc.On("StartIdentityLink", mock.Anything, *usr, sdk.UserIdentityLinkRequest{
+			AuthProviderId: "provider-1",
+			ClientId:       "client-1",
+			RedirectUrl:    "https://app.example.com/linked",
+			State:          "xyz",
+		}).Return("https://idp.example.com/authorize", nil).Once()
+		app := setupIdentitiesTestApp(t, &services.MockIdentityService{}, mockAuthSvc, usr)
+
+		req, _ := http.NewRequest("POST", "/me/v1/identities/link", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp sdk.AuthLoginResponse
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, "https://idp.example.com/authorize", resp.Data.LoginUrl)
+	})
+
+	t.Run("missing fields", func(t *testing.T) {
+		app := setupIdentitiesTestApp(t, &services.MockIdentityService{}, &services.MockAuthService{}, usr)
+
+		req, _ := http.NewRequest("POST", "/me/v1/identities/link", strings.NewReader(`{"auth_provider_id": "provider-1"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("auth provider of another project", func(t *testing.T) {
+		mockAuthSvc := &services.MockAuthService{}
+		mockAuthSvc.On("StartIdentityLink", mock.Anything, *usr, mock.Anything).Return("", sdk.ErrAuthProviderNotFound).Once()
+		app := setupIdentitiesTestApp(t, &services.MockIdentityService{}, mockAuthSvc, usr)
+
+		req, _ := http.NewRequest("POST", "/me/v1/identities/link", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", ProjectId: "project-1", Email: "test@example.com"}
+
+	t.Run("success", func(t *testing.T) {
+		mockIdentitySvc := &services.MockIdentityService{}
+		mockIdentitySvc.On("Unlink", mock.Anything, "user-123", "identity-1").Return(nil).Once()
+		app := setupIdentitiesTestApp(t, mockIdentitySvc, &services.MockAuthService{}, usr)
+
+		req, _ := http.NewRequest("DELETE", "/me/v1/identities/identity-1", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		mockIdentitySvc.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockIdentitySvc := &services.MockIdentityService{}
+		mockIdentitySvc.On("Unlink", mock.Anything, "user-123", "identity-2").Return(sdk.ErrUserIdentityNotFound).Once()
+		app := setupIdentitiesTestApp(t, mockIdentitySvc, &services.MockAuthService{}, usr)
+
+		req, _ := http.NewRequest("DELETE", "/me/v1/identities/identity-2", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -0,0 +1,267 @@
+package me
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/middlewares"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// MfaStatusRoute registers the route describing the second factor of the current user
+func MfaStatusRoute(router fiber.Router, basePath string) {
+	routePath := "/mfa"
+	path := basePath + routePath
+	router.Get(routePath, MfaStatus)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodGet,
+		Name:        "Get My Mfa Status",
+		Description: "Tell whether the current user has an authenticator, whether the mfa policy of the project requires one and how many recovery codes are left",
+		Response: &docs.ApiResponse{
+			Description: "Mfa status fetched successfully",
+			Content:     new(sdk.MfaStatusResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func MfaStatus(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.MfaStatusResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	status, err := pr.S.Mfa.Status(c.Context(), *user)
+	if err != nil {
+		message := fmt.Errorf("failed to get the mfa status. %w", err).Error()
+		log.Errorw("failed to get the mfa status", "error", message)
+		return c.Status(http.StatusInternalServerError).JSON(sdk.MfaStatusResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	return c.Status(http.StatusOK).JSON(sdk.MfaStatusResponse{
+		Success: true,
+		Message: "Mfa status fetched successfully",
+		Data:    status,
+	})
+}
+
+// EnrollMfaRoute registers the route starting the enrollment of an authenticator for the current user
+func EnrollMfaRoute(router fiber.Router, basePath string) {
+	routePath := "/mfa/enroll"
+	path := basePath + routePath
+	router.Post(routePath, EnrollMfa)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Enroll My Authenticator",
+		Description: "Generate a TOTP secret for the current user along with the otpauth uri to show as a QR code. The authenticator is used only once the enrollment is confirmed with a code from it. Starting again replaces a pending secret",
+		Response: &docs.ApiResponse{
+			Description: "Authenticator generated successfully",
+			Content:     new(sdk.MfaEnrollmentResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func EnrollMfa(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.MfaEnrollmentResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	enrollment, err := pr.S.Mfa.Enroll(c.Context(), *user)
+	if err != nil {
+		message := fmt.Errorf("failed to enroll the authenticator. %w", err).Error()
+		log.Errorw("failed to enroll the authenticator", "error", message)
+		return c.Status(mfaErrorStatus(err)).JSON(sdk.MfaEnrollmentResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("authenticator enrollment started")
+	return c.Status(http.StatusOK).JSON(sdk.MfaEnrollmentResponse{
+		Success: true,
+		Message: "Authenticator generated successfully, confirm it with a code",
+		Data:    enrollment,
+	})
+}
+
+// ConfirmMfaRoute registers the route confirming the enrollment of the authenticator of the current user
+func ConfirmMfaRoute(router fiber.Router, basePath string) {
+	routePath := "/mfa/confirm"
+	path := basePath + routePath
+	router.Post(routePath, ConfirmMfa)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Confirm My Authenticator",
+		Description: "Enable the pending authenticator of the current user with a code from it. The response has the recovery codes, they are shown only once",
+		RequestBody: &docs.ApiRequestBody{
+			Description: "A code from the authenticator app",
+			Content:     new(sdk.MfaCodeRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Authenticator enabled successfully",
+			Content:     new(sdk.MfaRecoveryCodesResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func ConfirmMfa(c *fiber.Ctx) error {
+	return recoveryCodesResponse(c, "enable the authenticator", "Authenticator enabled successfully", func(user sdk.User, code string) ([]string, error) {
+		return providers.GetProviders(c).S.Mfa.Confirm(c.Context(), user, code)
+	})
+}
+
+// RegenerateRecoveryCodesRoute registers the route replacing the recovery codes of the current user
+func RegenerateRecoveryCodesRoute(router fiber.Router, basePath string) {
+	routePath := "/mfa/recovery-codes"
+	path := basePath + routePath
+	router.Post(routePath, RegenerateRecoveryCodes)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Regenerate My Recovery Codes",
+		Description: "Replace the recovery codes of the current user. The previous codes stop working and the new ones are shown only once",
+		RequestBody: &docs.ApiRequestBody{
+			Description: "A code from the authenticator app, or a recovery code",
+			Content:     new(sdk.MfaCodeRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Recovery codes regenerated successfully",
+			Content:     new(sdk.MfaRecoveryCodesResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	return recoveryCodesResponse(c, "regenerate the recovery codes", "Recovery codes regenerated successfully", func(user sdk.User, code string) ([]string, error) {
+		return providers.GetProviders(c).S.Mfa.RegenerateRecoveryCodes(c.Context(), user, code)
+	})
+}
+
+// DisableMfaRoute registers the route removing the authenticator of the current user
+func DisableMfaRoute(router fiber.Router, basePath string) {
+	routePath := "/mfa/disable"
+	path := basePath + routePath
+	router.Post(routePath, DisableMfa)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Disable My Authenticator",
+		Description: "Remove the authenticator and the recovery codes of the current user. Users the mfa policy requires a second factor from enroll a new one at their next login",
+		RequestBody: &docs.ApiRequestBody{
+			Description: "A code from the authenticator app, or a recovery code",
+			Content:     new(sdk.MfaCodeRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Authenticator disabled successfully",
+			Content:     new(sdk.MfaStatusResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func DisableMfa(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.MfaStatusResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+	payload := new(sdk.MfaCodeRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.MfaStatusResponse{
+			Success: false,
+			Message: fmt.Sprintf("invalid request body: %v", err),
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	err := pr.S.Mfa.Disable(c.Context(), *user, payload.Code)
+	if err != nil {
+		message := fmt.Errorf("failed to disable the authenticator. %w", err).Error()
+		log.Errorw("failed to disable the authenticator", "error", message)
+		return c.Status(mfaErrorStatus(err)).JSON(sdk.MfaStatusResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("authenticator disabled successfully")
+	return c.Status(http.StatusOK).JSON(sdk.MfaStatusResponse{
+		Success: true,
+		Message: "Authenticator disabled successfully",
+	})
+}
+
+// recoveryCodesResponse runs an action of the current user taking a code and returning new recovery codes
+func recoveryCodesResponse(c *fiber.Ctx, action, successMessage string, run func(user sdk.User, code string) ([]string, error)) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.MfaRecoveryCodesResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+	payload := new(sdk.MfaCodeRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.MfaRecoveryCodesResponse{
+			Success: false,
+			Message: fmt.Sprintf("invalid request body: %v", err),
+		})
+	}
+
+	codes, err := run(*user, payload.Code)
+	if err != nil {
+		message := fmt.Errorf("failed to %s. %w", action, err).Error()
+		log.Errorw("failed to "+action, "error", message)
+		return c.Status(mfaErrorStatus(err)).JSON(sdk.MfaRecoveryCodesResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	return c.Status(http.StatusOK).JSON(sdk.MfaRecoveryCodesResponse{
+		Success: true,
+		Message: successMessage,
+		Data:    codes,
+	})
+}
+
+// mfaErrorStatus maps the errors of the mfa service to the response status
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sdk.ErrInvalidMfaCode):
+		return http.StatusBadRequest
+	case errors.Is(err, sdk.ErrMfaNotEnrolled):
+		return http.StatusNotFound
+	case errors.Is(err, sdk.ErrMfaAlreadyEnrolled):
+		return http.StatusConflict
+	case errors.Is(err, sdk.ErrTooManyMfaAttempts):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,212 @@
+package me
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/melvinodsa/go-iam/config"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/melvinodsa/go-iam/utils/test/server"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMfaTestApp(t *testing.T, mockMfaSvc *services.MockMfaService, usr *sdk.User) *fiber.App {
+	err := os.Setenv("JWT_SECRET", "abcd")
+	require.NoError(t, err)
+	cnf := config.NewAppConfig()
+
+	app := fiber.New(fiber.Config{
+		ReadBufferSize: 8192,
+	})
+	d := test.SetupMockDB()
+	cs := cache.NewMockService()
+	svcs, err := server.GetServices(*cnf, cs, d)
+	require.NoError(t, err)
+	svcs.Mfa = mockMfaSvc
+
+	prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+	app.Use(providers.Handle(prv))
+	app.Use(func(c *fiber.Ctx) error {
+		c.Context().SetUserValue(sdk.UserTypeVal, usr)
+		return c.Next()
+	})
+
+	RegisterRoutes(app, "/me")
+	return app
+}
+
+func TestMfaStatus(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", ProjectId: "project-1", Email: "test@example.com"}
+
+	t.Run("success", func(t *testing.T) {
+		mockMfaSvc := &services.MockMfaService{}
+		mockMfaSvc.On("Status", mock.Anything, *usr).Return(&sdk.MfaStatus{Enabled: true, RecoveryCodesLeft: 8}, nil).Once()
+		app := setupMfaTestApp(t, mockMfaSvc, usr)
+
+		req, _ := http.NewRequest("GET", "/me/v1/mfa", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp sdk.MfaStatusResponse
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.True(t, resp.Data.Enabled)
+		assert.Equal(t, 8, resp.Data.RecoveryCodesLeft)
+	})
+
+	t.Run("status fails", func(t *testing.T) {
+		mockMfaSvc := &services.MockMfaService{}
+		mockMfaSvc.On("Status", mock.Anything, *usr).Return(nil, errors.New("database error")).Once()
+		app := setupMfaTestApp(t, mockMfaSvc, usr)
+
+		req, _ := http.NewRequest("GET", "/me/v1/mfa", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+func TestEnrollMfa(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", ProjectId: "project-1", Email: "test@example.com"}
+
+	tests := []struct {
+		name           string
+		enrollErr      error
+		expectedStatus int
+	}{
+		{name: "success", expectedStatus: http.StatusOK},
+		{name: "already enrolled", enrollErr: sdk.ErrMfaAlreadyEnrolled, expectedStatus: http.StatusConflict},
+		{name: "enroll fails", enrollErr: errors.New("database error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMfaSvc := &services.MockMfaService{}
+			var enrollment *sdk.MfaEnrollment
+			if tt.enrollErr == nil {
+				enrollment = &sdk.MfaEnrollment{Secret: "SECRET", ProvisioningUri: "otpauth://totp/x"}
+			}
+			mockMfaSvc.On("Enroll", mock.Anything, *usr).Return(enrollment, tt.enrollErr).Once()
+			app := setupMfaTestApp(t, mockMfaSvc, usr)
+
+			req, _ := http.NewRequest("POST", "/me/v1/mfa/enroll", nil)
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.MfaEnrollmentResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "SECRET", resp.Data.Secret)
+			}
+			mockMfaSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMfaCodeRoutes(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", ProjectId: "project-1", Email: "test@example.com"}
+	codes := []string{"aaaaa-bbbbb", "ccccc-ddddd"}
+
+	tests := []struct {
+		name           string
+		path           string
+		setupMocks     func(m *services.MockMfaService)
+		expectedStatus int
+		expectedCodes  []string
+	}{
+		{
+			name: "confirm",
+			path: "/me/v1/mfa/confirm",
+			setupMocks: func(m *services.MockMfaService) {
+				m.On("Confirm", mock.Anything, *usr, "123456").Return(codes, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedCodes:  codes,
+		},
+		{
+			name: "confirm with a wrong code",
+			path: "/me/v1/mfa/confirm",
+			setupMocks: func(m *services.MockMfaService) {
+				m.On("Confirm", mock.Anything, *usr, "123456").Return(nil, sdk.ErrInvalidMfaCode).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "confirm without an enrollment",
+			path: "/me/v1/mfa/confirm",
+			setupMocks: func(m *services.MockMfaService) {
+				m.On("Confirm", mock.Anything, *usr, "123456").Return(nil, sdk.ErrMfaNotEnrolled).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "regenerate the recovery codes",
+			path: "/me/v1/mfa/recovery-codes",
+			setupMocks: func(m *services.MockMfaService) {
+				m.On("RegenerateRecoveryCodes", mock.Anything, *usr, "123456").Return(codes, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedCodes:  codes,
+		},
+		{
+			name: "disable",
+			path: "/me/v1/mfa/disable",
+			setupMocks: func(m *services.MockMfaService) {
+				m.On("Disable", mock.Anything, *usr, "123456").Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "disable with a wrong code",
+			path: "/me/v1/mfa/disable",
+			setupMocks: func(m *services.MockMfaService) {
+				m.On("Disable", mock.Anything, *usr, "123456").Return(sdk.ErrInvalidMfaCode).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "disable after too many wrong codes",
+			path: "/me/v1/mfa/disable",
+			setupMocks: func(m *services.MockMfaService) {
+				m.On("Disable", mock.Anything, *usr, "123456").Return(sdk.ErrTooManyMfaAttempts).Once()
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMfaSvc := &services.MockMfaService{}
+			tt.setupMocks(mockMfaSvc)
+			app := setupMfaTestApp(t, mockMfaSvc, usr)
+
+			req, _ := http.NewRequest("POST", tt.path, strings.NewReader(`{"code": "123456"}`))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.MfaRecoveryCodesResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			assert.Equal(t, tt.expectedCodes, resp.Data)
+			mockMfaSvc.AssertExpectations(t)
+		})
+	}
+}
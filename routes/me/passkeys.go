@@ -0,0 +1,232 @@
+package me
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/middlewares"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// PasskeysRoute registers the route listing the passkeys of the current user
+func PasskeysRoute(router fiber.Router, basePath string) {
+	routePath := "/passkeys"
+	path := basePath + routePath
+	router.Get(routePath, Passkeys)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodGet,
+		Name:        "Get My Passkeys",
+		Description: "List the passkeys registered by the current user, oldest first",
+		Response: &docs.ApiResponse{
+			Description: "Passkeys fetched successfully",
+			Content:     new(sdk.PasskeysResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func Passkeys(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.PasskeysResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	passkeys, err := pr.S.Passkeys.List(c.Context(), user.Id)
+	if err != nil {
+		message := fmt.Errorf("failed to get the passkeys. %w", err).Error()
+		log.Errorw("failed to get the passkeys", "error", message)
+		return c.Status(http.StatusInternalServerError).JSON(sdk.PasskeysResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	return c.Status(http.StatusOK).JSON(sdk.PasskeysResponse{
+		Success: true,
+		Message: "Passkeys fetched successfully",
+		Data:    passkeys,
+	})
+}
+
+// StartPasskeyRegistrationRoute registers the route starting the registration of a passkey for the current user
+func StartPasskeyRegistrationRoute(router fiber.Router, basePath string) {
+	routePath := "/passkeys/register/start"
+	path := basePath + routePath
+	router.Post(routePath, StartPasskeyRegistration)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Start My Passkey Registration",
+		Description: "Get the options for navigator.credentials.create to register a passkey for the current user. The project needs a webauthn relying party. Binary values are base64url encoded",
+		Response: &docs.ApiResponse{
+			Description: "Passkey registration started",
+			Content:     new(sdk.WebAuthnCreationOptionsResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func StartPasskeyRegistration(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.WebAuthnCreationOptionsResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	options, err := pr.S.Passkeys.BeginRegistration(c.Context(), *user)
+	if err != nil {
+		message := fmt.Errorf("failed to start the passkey registration. %w", err).Error()
+		log.Errorw("failed to start the passkey registration", "error", message)
+		return c.Status(passkeyErrorStatus(err)).JSON(sdk.WebAuthnCreationOptionsResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("passkey registration started")
+	return c.Status(http.StatusOK).JSON(sdk.WebAuthnCreationOptionsResponse{
+		Success: true,
+		Message: "Passkey registration started",
+		Data:    options,
+	})
+}
+
+// FinishPasskeyRegistrationRoute registers the route saving the passkey created by the authenticator of the current user
+func FinishPasskeyRegistrationRoute(router fiber.Router, basePath string) {
+	routePath := "/passkeys/register/finish"
+	path := basePath + routePath
+	router.Post(routePath, FinishPasskeyRegistration)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Finish My Passkey Registration",
+		Description: "Save the credential returned by navigator.credentials.create. The passkey can be used to log in and as a second factor right away",
+		RequestBody: &docs.ApiRequestBody{
+			Description: "A name for the passkey and the credential returned by the browser",
+			Content:     new(sdk.PasskeyRegisterRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Passkey registered successfully",
+			Content:     new(sdk.PasskeyResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func FinishPasskeyRegistration(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.PasskeyResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+	payload := new(sdk.PasskeyRegisterRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.PasskeyResponse{
+			Success: false,
+			Message: fmt.Sprintf("invalid request body: %v", err),
+		})
+	}
+	if len(payload.Credential.Id) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(sdk.PasskeyResponse{
+			Success: false,
+			Message: "credential is required",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	passkey, err := pr.S.Passkeys.FinishRegistration(c.Context(), *user, payload.Name, payload.Credential)
+	if err != nil {
+		message := fmt.Errorf("failed to register the passkey. %w", err).Error()
+		log.Errorw("failed to register the passkey", "error", message)
+		return c.Status(passkeyErrorStatus(err)).JSON(sdk.PasskeyResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("passkey registered successfully")
+	return c.Status(http.StatusOK).JSON(sdk.PasskeyResponse{
+		Success: true,
+		Message: "Passkey registered successfully",
+		Data:    passkey,
+	})
+}
+
+// DeletePasskeyRoute registers the route removing a passkey of the current user
+func DeletePasskeyRoute(router fiber.Router, basePath string) {
+	routePath := "/passkeys/:id"
+	path := basePath + routePath
+	router.Delete(routePath, DeletePasskey)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodDelete,
+		Name:        "Delete My Passkey",
+		Description: "Remove a passkey of the current user. The passkey stays on the authenticator but can no longer be used to log in",
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Description: "The ID of the passkey",
+				Required:    true,
+			},
+		},
+		Response: &docs.ApiResponse{
+			Description: "Passkey deleted successfully",
+			Content:     new(sdk.PasskeyResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func DeletePasskey(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.PasskeyResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	err := pr.S.Passkeys.Delete(c.Context(), user.Id, c.Params("id"))
+	if err != nil {
+		message := fmt.Errorf("failed to delete the passkey. %w", err).Error()
+		log.Errorw("failed to delete the passkey", "error", message)
+		return c.Status(passkeyErrorStatus(err)).JSON(sdk.PasskeyResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("passkey deleted successfully")
+	return c.Status(http.StatusOK).JSON(sdk.PasskeyResponse{
+		Success: true,
+		Message: "Passkey deleted successfully",
+	})
+}
+
+// passkeyErrorStatus maps the errors of the passkey service to the response status
+func passkeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sdk.ErrInvalidPasskey), errors.Is(err, sdk.ErrInvalidPasskeyChallenge), errors.Is(err, sdk.ErrPasskeysNotConfigured):
+		return http.StatusBadRequest
+	case errors.Is(err, sdk.ErrPasskeyNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
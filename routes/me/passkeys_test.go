@@ -0,0 +1,210 @@
+package me
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/melvinodsa/go-iam/config"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/melvinodsa/go-iam/utils/test/server"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPasskeysTestApp(t *testing.T, mockPasskeySvc *services.MockPasskeyService, usr *sdk.User) *fiber.App {
+	err := os.Setenv("JWT_SECRET", "abcd")
+	require.NoError(t, err)
+	cnf := config.NewAppConfig()
+
+	app := fiber.New(fiber.Config{
+		ReadBufferSize: 8192,
+	})
+	d := test.SetupMockDB()
+	cs := cache.NewMockService()
+	svcs, err := server.GetServices(*cnf, cs, d)
+	require.NoError(t, err)
+	svcs.Passkeys = mockPasskeySvc
+
+	prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+	app.Use(providers.Handle(prv))
+	app.Use(func(c *fiber.Ctx) error {
+		c.Context().SetUserValue(sdk.UserTypeVal, usr)
+		return c.Next()
+	})
+
+	RegisterRoutes(app, "/me")
+	return app
+}
+
+func TestPasskeys(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", ProjectId: "project-1", Email: "test@example.com"}
+
+	t.Run("success", func(t *testing.T) {
+		mockPasskeySvc := &services.MockPasskeyService{}
+		mockPasskeySvc.On("List", mock.Anything, "user-123").Return([]sdk.Passkey{{Id: "passkey-1", Name: "Laptop"}}, nil).Once()
+		app := setupPasskeysTestApp(t, mockPasskeySvc, usr)
+
+		req, _ := http.NewRequest("GET", "/me/v1/passkeys", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp sdk.PasskeysResponse
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 1)
+		assert.Equal(t, "Laptop", resp.Data[0].Name)
+	})
+
+	t.Run("list fails", func(t *testing.T) {
+		mockPasskeySvc := &services.MockPasskeyService{}
+		mockPasskeySvc.On("List", mock.Anything, "user-123").Return(nil, errors.New("database error")).Once()
+		app := setupPasskeysTestApp(t, mockPasskeySvc, usr)
+
+		req, _ := http.NewRequest("GET", "/me/v1/passkeys", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+func TestStartPasskeyRegistration(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", ProjectId: "project-1", Email: "test@example.com"}
+
+	t.Run("success", func(t *testing.T) {
+		mockPasskeySvc := &services.MockPasskeyService{}
+		mockPasskeySvc.On("BeginRegistration", mock.Anything, *usr).Return(&sdk.WebAuthnCreationOptions{
+			Challenge: "challenge",
+			Rp:        sdk.WebAuthnRelyingParty{Id: "example.com", Name: "Example"},
+		}, nil).Once()
+		app := setupPasskeysTestApp(t, mockPasskeySvc, usr)
+
+		req, _ := http.NewRequest("POST", "/me/v1/passkeys/register/start", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var resp sdk.WebAuthnCreationOptionsResponse
+		err = json.NewDecoder(res.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, "example.com", resp.Data.Rp.Id)
+	})
+
+	t.Run("passkeys not configured", func(t *testing.T) {
+		mockPasskeySvc := &services.MockPasskeyService{}
+		mockPasskeySvc.On("BeginRegistration", mock.Anything, *usr).Return(nil, sdk.ErrPasskeysNotConfigured).Once()
+		app := setupPasskeysTestApp(t, mockPasskeySvc, usr)
+
+		req, _ := http.NewRequest("POST", "/me/v1/passkeys/register/start", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestFinishPasskeyRegistration(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", ProjectId: "project-1", Email: "test@example.com"}
+	body := `{"name": "Laptop", "credential": {"id": "cred-1", "type": "public-key", "response": {"client_data_json": "Y2Q", "attestation_object": "YW8"}}}`
+	credential := sdk.WebAuthnAttestation{
+		Id:       "cred-1",
+		Type:     "public-key",
+		Response: sdk.WebAuthnAttestationResponse{ClientDataJSON: "Y2Q", AttestationObject: "YW8"},
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(m *services.MockPasskeyService)
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			body: body,
+			setupMocks: func(m *services.MockPasskeyService) {
+				m.On("FinishRegistration", mock.Anything, *usr, "Laptop", credential).Return(&sdk.Passkey{Id: "passkey-1", Name: "Laptop"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing credential",
+			body:           `{"name": "Laptop"}`,
+			setupMocks:     func(m *services.MockPasskeyService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid attestation",
+			body: body,
+			setupMocks: func(m *services.MockPasskeyService) {
+				m.On("FinishRegistration", mock.Anything, *usr, "Laptop", credential).
+					Return(nil, fmt.Errorf("%w: origin https://evil.com is not allowed", sdk.ErrInvalidPasskey)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "expired ceremony",
+			body: body,
+			setupMocks: func(m *services.MockPasskeyService) {
+				m.On("FinishRegistration", mock.Anything, *usr, "Laptop", credential).Return(nil, sdk.ErrInvalidPasskeyChallenge).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPasskeySvc := &services.MockPasskeyService{}
+			tt.setupMocks(mockPasskeySvc)
+			app := setupPasskeysTestApp(t, mockPasskeySvc, usr)
+
+			req, _ := http.NewRequest("POST", "/me/v1/passkeys/register/finish", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.PasskeyResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			mockPasskeySvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeletePasskey(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", ProjectId: "project-1", Email: "test@example.com"}
+
+	t.Run("success", func(t *testing.T) {
+		mockPasskeySvc := &services.MockPasskeyService{}
+		mockPasskeySvc.On("Delete", mock.Anything, "user-123", "passkey-1").Return(nil).Once()
+		app := setupPasskeysTestApp(t, mockPasskeySvc, usr)
+
+		req, _ := http.NewRequest("DELETE", "/me/v1/passkeys/passkey-1", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		mockPasskeySvc.AssertExpectations(t)
+	})
+
+	t.Run("passkey of another user", func(t *testing.T) {
+		mockPasskeySvc := &services.MockPasskeyService{}
+		mockPasskeySvc.On("Delete", mock.Anything, "user-123", "passkey-2").Return(sdk.ErrPasskeyNotFound).Once()
+		app := setupPasskeysTestApp(t, mockPasskeySvc, usr)
+
+		req, _ := http.NewRequest("DELETE", "/me/v1/passkeys/passkey-2", nil)
+		res, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -0,0 +1,77 @@
+package me
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/middlewares"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+// ChangePasswordRoute registers the route changing the password of the current user
+func ChangePasswordRoute(router fiber.Router, basePath string) {
+	routePath := "/password"
+	path := basePath + routePath
+	router.Put(routePath, ChangePassword)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPut,
+		Name:        "Change My Password",
+		Description: "Change the password of the current user. Only for users logging in with a password auth provider",
+		RequestBody: &docs.ApiRequestBody{
+			Description: "The current and the new password",
+			Content:     new(sdk.PasswordChangeRequest),
+		},
+		Response: &docs.ApiResponse{
+			Description: "Password changed successfully",
+			Content:     new(sdk.PasswordResponse),
+		},
+		Tags:                 routeTags,
+		ProjectIDNotRequired: true,
+	})
+}
+
+func ChangePassword(c *fiber.Ctx) error {
+	user := middlewares.GetUser(c.Context())
+	if user == nil {
+		return c.Status(http.StatusUnauthorized).JSON(sdk.PasswordResponse{
+			Success: false,
+			Message: "user not found",
+		})
+	}
+	payload := new(sdk.PasswordChangeRequest)
+	if err := c.BodyParser(payload); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.PasswordResponse{
+			Success: false,
+			Message: fmt.Sprintf("invalid request body: %v", err),
+		})
+	}
+
+	pr := providers.GetProviders(c)
+	err := pr.S.Passwords.Change(c.Context(), user.ProjectId, user.Email, *payload)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, sdk.ErrInvalidCredentials), errors.Is(err, sdk.ErrWeakPassword):
+			status = http.StatusBadRequest
+		case errors.Is(err, sdk.ErrPasswordCredentialNotFound):
+			status = http.StatusNotFound
+		}
+		message := fmt.Errorf("failed to change the password. %w", err).Error()
+		log.Errorw("failed to change the password", "error", message)
+		return c.Status(status).JSON(sdk.PasswordResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+	log.Debug("password changed successfully")
+	return c.Status(http.StatusOK).JSON(sdk.PasswordResponse{
+		Success: true,
+		Message: "Password changed successfully",
+	})
+}
@@ -0,0 +1,83 @@
+package me
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/melvinodsa/go-iam/config"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/melvinodsa/go-iam/utils/test/server"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPasswordTestApp(t *testing.T, mockPasswordSvc *services.MockPasswordService, usr *sdk.User) *fiber.App {
+	err := os.Setenv("JWT_SECRET", "abcd")
+	require.NoError(t, err)
+	cnf := config.NewAppConfig()
+
+	app := fiber.New(fiber.Config{
+		ReadBufferSize: 8192,
+	})
+	d := test.SetupMockDB()
+	cs := cache.NewMockService()
+	svcs, err := server.GetServices(*cnf, cs, d)
+	require.NoError(t, err)
+	svcs.Passwords = mockPasswordSvc
+
+	prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+	app.Use(providers.Handle(prv))
+	app.Use(func(c *fiber.Ctx) error {
+		c.Context().SetUserValue(sdk.UserTypeVal, usr)
+		return c.Next()
+	})
+
+	RegisterRoutes(app, "/me")
+	return app
+}
+
+func TestChangePassword(t *testing.T) {
+	usr := &sdk.User{Id: "user-123", ProjectId: "project-1", Email: "test@example.com"}
+	changeReq := sdk.PasswordChangeRequest{CurrentPassword: "old-secret", NewPassword: "new-secret"}
+
+	tests := []struct {
+		name           string
+		changeErr      error
+		expectedStatus int
+	}{
+		{name: "success", expectedStatus: http.StatusOK},
+		{name: "wrong current password", changeErr: sdk.ErrInvalidCredentials, expectedStatus: http.StatusBadRequest},
+		{name: "no password credential", changeErr: sdk.ErrPasswordCredentialNotFound, expectedStatus: http.StatusNotFound},
+		{name: "change fails", changeErr: errors.New("database error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPasswordSvc := &services.MockPasswordService{}
+			mockPasswordSvc.On("Change", mock.Anything, "project-1", "test@example.com", changeReq).Return(tt.changeErr).Once()
+			app := setupPasswordTestApp(t, mockPasswordSvc, usr)
+
+			req, _ := http.NewRequest("PUT", "/me/v1/password", strings.NewReader(`{"current_password": "old-secret", "new_password": "new-secret"}`))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			var resp sdk.PasswordResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			mockPasswordSvc.AssertExpectations(t)
+		})
+	}
+}
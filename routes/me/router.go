@@ -9,6 +9,21 @@ func RegisterRoutes(router fiber.Router, path string) {
 	v1Path := path + "/v1"
 	v1 := router.Group(v1Path)
 	MeRoute(v1, v1Path)
+	ConsentsRoute(v1, v1Path)
+	RevokeConsentRoute(v1, v1Path)
+	ChangePasswordRoute(v1, v1Path)
+	MfaStatusRoute(v1, v1Path)
+	EnrollMfaRoute(v1, v1Path)
+	ConfirmMfaRoute(v1, v1Path)
+	RegenerateRecoveryCodesRoute(v1, v1Path)
+	DisableMfaRoute(v1, v1Path)
+	PasskeysRoute(v1, v1Path)
+	StartPasskeyRegistrationRoute(v1, v1Path)
+	FinishPasskeyRegistrationRoute(v1, v1Path)
+	DeletePasskeyRoute(v1, v1Path)
+	IdentitiesRoute(v1, v1Path)
+	LinkIdentityRoute(v1, v1Path)
+	UnlinkIdentityRoute(v1, v1Path)
 }
 
 func RegisterOpenRoutes(router fiber.Router, path string, prv *providers.Provider) {
@@ -44,6 +44,36 @@ func Create(c *fiber.Ctx) error {
 		})
 	}
 	log.Debug("parsed create project request")
+	if err := payload.ValidateScopes(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.ProjectResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+	if err := payload.ValidatePasswordPolicy(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.ProjectResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+	if err := payload.ValidateMfaPolicy(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.ProjectResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+	if err := payload.ValidateWebAuthn(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.ProjectResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+	if err := payload.ValidateLoginRouting(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.ProjectResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
 	pr := providers.GetProviders(c)
 	err := pr.S.Projects.Create(c.Context(), payload)
 	if err != nil {
@@ -203,6 +233,36 @@ func Update(c *fiber.Ctx) error {
 	}
 
 	payload.Id = id
+	if err := payload.ValidateScopes(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.ProjectResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+	if err := payload.ValidatePasswordPolicy(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.ProjectResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+	if err := payload.ValidateMfaPolicy(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.ProjectResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+	if err := payload.ValidateWebAuthn(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.ProjectResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+	if err := payload.ValidateLoginRouting(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(sdk.ProjectResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
 	pr := providers.GetProviders(c)
 	err := pr.S.Projects.Update(c.Context(), payload)
 	if err != nil {
@@ -164,6 +164,86 @@ func TestProject(t *testing.T) {
 		assert.Nil(t, err)
 		assert.NotNil(t, resp)
 	})
+
+	t.Run("duplicate scope definitions", func(t *testing.T) {
+		app := fiber.New(fiber.Config{
+			ReadBufferSize: 8192,
+		})
+
+		d := test.SetupMockDB()
+		cs := cache.NewMockService()
+		svcs, err := server.GetServices(*cnf, cs, d)
+		if err != nil {
+			t.Errorf("error getting services: %s", err)
+			return
+		}
+		// project mock
+
+		mockProjectSvc := services.MockProjectService{}
+		mockProjectSvc.On("GetByName", mock.Anything, mock.Anything).Return(&sdk.Project{
+			Id:          "project-id",
+			Name:        "Test Project",
+			Description: "test project",
+		}, nil)
+
+		svcs.Projects = &mockProjectSvc
+
+		prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+
+		app.Use(providers.Handle(prv))
+
+		RegisterRoutes(app, "/project")
+
+		req, _ := http.NewRequest("POST", "/project/v1", strings.NewReader(`{
+			"name": "Test Project",
+			"scopes": [
+				{"name": "orders:read", "description": "Read the orders"},
+				{"name": "orders:read", "description": "Read the orders again"}
+			]
+		}`))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req, -1)
+		assert.Equalf(t, 400, res.StatusCode, "Expected status code 400")
+		assert.Nil(t, err)
+		mockProjectSvc.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("password policy that cannot be satisfied", func(t *testing.T) {
+		app := fiber.New(fiber.Config{
+			ReadBufferSize: 8192,
+		})
+
+		d := test.SetupMockDB()
+		cs := cache.NewMockService()
+		svcs, err := server.GetServices(*cnf, cs, d)
+		if err != nil {
+			t.Errorf("error getting services: %s", err)
+			return
+		}
+
+		mockProjectSvc := services.MockProjectService{}
+		mockProjectSvc.On("GetByName", mock.Anything, mock.Anything).Return(&sdk.Project{
+			Id:   "project-id",
+			Name: "Test Project",
+		}, nil)
+		svcs.Projects = &mockProjectSvc
+
+		prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+
+		app.Use(providers.Handle(prv))
+
+		RegisterRoutes(app, "/project")
+
+		req, _ := http.NewRequest("POST", "/project/v1", strings.NewReader(`{
+			"name": "Test Project",
+			"password_policy": {"min_length": 100}
+		}`))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req, -1)
+		assert.Equalf(t, 400, res.StatusCode, "Expected status code 400")
+		assert.Nil(t, err)
+		mockProjectSvc.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
 }
 
 func TestGet(t *testing.T) {
@@ -12,6 +12,7 @@ import (
 	"github.com/melvinodsa/go-iam/routes/project"
 	"github.com/melvinodsa/go-iam/routes/resource"
 	"github.com/melvinodsa/go-iam/routes/role"
+	"github.com/melvinodsa/go-iam/routes/signingkey"
 	"github.com/melvinodsa/go-iam/routes/user"
 )
 
@@ -30,12 +31,14 @@ func RegisterAuthRoutes(app *fiber.App, prv *providers.Provider) {
 	resource.RegisterRoutes(ap, "/resource")
 	role.RegisterRoutes(ap, "/role")
 	policy.RegisterRoutes(ap, "/policy")
+	signingkey.RegisterRoutes(ap, "/signing-key")
 	me.RegisterRoutes(app, "/me")
 }
 
 func RegisterOpenRoutes(app *fiber.App, prv *providers.Provider) {
 	me.RegisterOpenRoutes(app, "/me", prv)
 	auth.RegisterRoutes(app, "/auth")
+	auth.RegisterWellKnownRoutes(app, "/.well-known")
 	health.RegisterRoutes(app, "/health")
 
 	app.Static("/docs", "./docs")
@@ -0,0 +1,13 @@
+package signingkey
+
+import "github.com/gofiber/fiber/v2"
+
+func RegisterRoutes(router fiber.Router, path string) {
+	v1Path := path + "/v1"
+	v1 := router.Group(v1Path)
+	FetchAllRoute(v1, v1Path)
+	RotateRoute(v1, v1Path)
+	RetireRoute(v1, v1Path)
+}
+
+var routeTags = []string{"Signing Key"}
@@ -0,0 +1,139 @@
+package signingkey
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/docs"
+)
+
+func FetchAllRoute(router fiber.Router, basePath string) {
+	routePath := "/"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodGet,
+		Name:        "Fetch All Signing Keys",
+		Description: "Fetch all the keys of the token signing keyset. Private keys are never returned",
+		Response: &docs.ApiResponse{
+			Description: "Signing keys fetched successfully",
+			Content:     new(sdk.SigningKeysResponse),
+		},
+		ProjectIDNotRequired: true,
+		Tags:                 routeTags,
+	})
+	router.Get(routePath, FetchAll)
+}
+
+func FetchAll(c *fiber.Ctx) error {
+	log.Debug("received get signing keys request")
+	pr := providers.GetProviders(c)
+	keys, err := pr.S.Jwt.GetKeys(c.Context())
+	if err != nil {
+		message := fmt.Errorf("failed to get signing keys. %w", err).Error()
+		log.Errorw("failed to get signing keys", "error", err)
+		return c.Status(http.StatusInternalServerError).JSON(sdk.SigningKeysResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+
+	log.Debug("signing keys fetched successfully")
+	return c.Status(http.StatusOK).JSON(sdk.SigningKeysResponse{
+		Success: true,
+		Message: "Signing keys fetched successfully",
+		Data:    keys,
+	})
+}
+
+func RotateRoute(router fiber.Router, basePath string) {
+	routePath := "/rotate"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Rotate Signing Key",
+		Description: "Generate a new active signing key. The previous key keeps verifying tokens until the overlap window ends",
+		Response: &docs.ApiResponse{
+			Description: "Signing key rotated successfully",
+			Content:     new(sdk.SigningKeyResponse),
+		},
+		ProjectIDNotRequired: true,
+		Tags:                 routeTags,
+	})
+	router.Post(routePath, Rotate)
+}
+
+func Rotate(c *fiber.Ctx) error {
+	log.Debug("received rotate signing key request")
+	pr := providers.GetProviders(c)
+	key, err := pr.S.Jwt.RotateKey(c.Context())
+	if err != nil {
+		message := fmt.Errorf("failed to rotate signing key. %w", err).Error()
+		log.Errorw("failed to rotate signing key", "error", err)
+		return sdk.SigningKeyInternalServerError(message, c)
+	}
+
+	log.Debugw("signing key rotated successfully", "kid", key.Id)
+	return c.Status(http.StatusCreated).JSON(sdk.SigningKeyResponse{
+		Success: true,
+		Message: "Signing key rotated successfully",
+		Data:    key,
+	})
+}
+
+func RetireRoute(router fiber.Router, basePath string) {
+	routePath := "/:id/retire"
+	path := basePath + routePath
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Retire Signing Key",
+		Description: "Retire a signing key. Tokens signed with the key are no longer accepted",
+		Response: &docs.ApiResponse{
+			Description: "Signing key retired successfully",
+			Content:     new(sdk.SigningKeyResponse),
+		},
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Description: "The key ID (kid) of the signing key",
+				Required:    true,
+			},
+		},
+		ProjectIDNotRequired: true,
+		Tags:                 routeTags,
+	})
+	router.Post(routePath, Retire)
+}
+
+func Retire(c *fiber.Ctx) error {
+	log.Debug("received retire signing key request")
+	id := c.Params("id")
+	pr := providers.GetProviders(c)
+	key, err := pr.S.Jwt.RetireKey(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, sdk.ErrSigningKeyNotFound) {
+			return sdk.SigningKeyNotFound("signing key not found", c)
+		}
+		if errors.Is(err, sdk.ErrActiveSigningKey) {
+			return sdk.SigningKeyBadRequest(err.Error(), c)
+		}
+		message := fmt.Errorf("failed to retire signing key. %w", err).Error()
+		log.Errorw("failed to retire signing key", "error", err)
+		return sdk.SigningKeyInternalServerError(message, c)
+	}
+
+	log.Debugw("signing key retired successfully", "kid", id)
+	return c.Status(http.StatusOK).JSON(sdk.SigningKeyResponse{
+		Success: true,
+		Message: "Signing key retired successfully",
+		Data:    key,
+	})
+}
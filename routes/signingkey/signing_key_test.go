@@ -0,0 +1,167 @@
+package signingkey
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/melvinodsa/go-iam/config"
+	"github.com/melvinodsa/go-iam/providers"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/melvinodsa/go-iam/utils/test/server"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSigningKeyTestApp(t *testing.T, mockJwt *services.MockJWTService) *fiber.App {
+	err := os.Setenv("JWT_SECRET", "abcd")
+	require.NoError(t, err)
+	cnf := config.NewAppConfig()
+
+	app := fiber.New()
+	d := test.SetupMockDB()
+	cs := cache.NewMockService()
+	svcs, err := server.GetServices(*cnf, cs, d)
+	require.NoError(t, err)
+	svcs.Jwt = mockJwt
+
+	prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+	app.Use(providers.Handle(prv))
+	RegisterRoutes(app, "/signing-key")
+	return app
+}
+
+func TestFetchAll(t *testing.T) {
+	now := time.Now()
+	keys := []sdk.SigningKey{
+		{Id: "kid-2", Algorithm: "RS256", Status: sdk.SigningKeyStatusActive, CreatedAt: &now},
+		{Id: "kid-1", Algorithm: "RS256", Status: sdk.SigningKeyStatusRotated, CreatedAt: &now, ExpiresAt: &now},
+	}
+
+	tests := []struct {
+		name       string
+		keys       []sdk.SigningKey
+		err        error
+		wantStatus int
+	}{
+		{name: "success", keys: keys, wantStatus: http.StatusOK},
+		{name: "service error", err: errors.New("database error"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockJwt := &services.MockJWTService{}
+			if tt.err != nil {
+				mockJwt.On("GetKeys", mock.Anything).Return(nil, tt.err)
+			} else {
+				mockJwt.On("GetKeys", mock.Anything).Return(tt.keys, nil)
+			}
+			app := setupSigningKeyTestApp(t, mockJwt)
+
+			req, _ := http.NewRequest(http.MethodGet, "/signing-key/v1/", nil)
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+
+			var resp sdk.SigningKeysResponse
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+			assert.Equal(t, tt.err == nil, resp.Success)
+			assert.Len(t, resp.Data, len(tt.keys))
+			mockJwt.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRotate(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        *sdk.SigningKey
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			key:        &sdk.SigningKey{Id: "kid-3", Algorithm: "ES256", Status: sdk.SigningKeyStatusActive, PublicKey: sdk.Jwk{Kid: "kid-3", Kty: "EC"}},
+			wantStatus: http.StatusCreated,
+		},
+		{name: "service error", err: errors.New("store error"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockJwt := &services.MockJWTService{}
+			if tt.err != nil {
+				mockJwt.On("RotateKey", mock.Anything).Return(nil, tt.err)
+			} else {
+				mockJwt.On("RotateKey", mock.Anything).Return(tt.key, nil)
+			}
+			app := setupSigningKeyTestApp(t, mockJwt)
+
+			req, _ := http.NewRequest(http.MethodPost, "/signing-key/v1/rotate", nil)
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+
+			var resp sdk.SigningKeyResponse
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+			assert.Equal(t, tt.err == nil, resp.Success)
+			if tt.key != nil {
+				assert.Equal(t, tt.key.Id, resp.Data.Id)
+				assert.Equal(t, "EC", resp.Data.PublicKey.Kty)
+			}
+			mockJwt.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRetire(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		key        *sdk.SigningKey
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			key:        &sdk.SigningKey{Id: "kid-1", Status: sdk.SigningKeyStatusRetired, RetiredAt: &now},
+			wantStatus: http.StatusOK,
+		},
+		{name: "not found", err: sdk.ErrSigningKeyNotFound, wantStatus: http.StatusNotFound},
+		{name: "active key", err: sdk.ErrActiveSigningKey, wantStatus: http.StatusBadRequest},
+		{name: "service error", err: errors.New("store error"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockJwt := &services.MockJWTService{}
+			if tt.err != nil {
+				mockJwt.On("RetireKey", mock.Anything, "kid-1").Return(nil, tt.err)
+			} else {
+				mockJwt.On("RetireKey", mock.Anything, "kid-1").Return(tt.key, nil)
+			}
+			app := setupSigningKeyTestApp(t, mockJwt)
+
+			req, _ := http.NewRequest(http.MethodPost, "/signing-key/v1/kid-1/retire", nil)
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+
+			var resp sdk.SigningKeyResponse
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+			assert.Equal(t, tt.err == nil, resp.Success)
+			if tt.key != nil {
+				assert.Equal(t, sdk.SigningKeyStatusRetired, resp.Data.Status)
+			}
+			mockJwt.AssertExpectations(t)
+		})
+	}
+}
@@ -13,6 +13,8 @@ func RegisterRoutes(router fiber.Router, path string) {
 	UpdatePoliciesRoute(v1, v1Path)
 	TransferOwnershipRoute(v1, v1Path)
 	CopyResourcesRoute(v1, v1Path)
+	SignOutRoute(v1, v1Path)
+	IdentitiesRoute(v1, v1Path)
 }
 
 var routeTags = []string{"User"}
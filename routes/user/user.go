@@ -567,3 +567,137 @@ func CopyResources(c *fiber.Ctx) error {
 		Message: "User resources copied` successfully",
 	})
 }
+
+// SignOutRoute registers the route for signing a user out of every session
+func SignOutRoute(router fiber.Router, basePath string) {
+	routePath := "/:id/sign-out"
+	path := basePath + routePath
+	router.Post(routePath, SignOut)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodPost,
+		Name:        "Sign Out User Everywhere",
+		Description: "Revoke every access token and refresh token issued for a user",
+		Response: &docs.ApiResponse{
+			Description: "User signed out successfully",
+			Content:     new(sdk.UserResponse),
+		},
+		// Parameters for the user ID in the path
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Description: "The ID of the user",
+				Required:    true,
+			},
+		},
+		Tags: routeTags,
+	})
+}
+
+// SignOut revokes all the tokens of a user
+func SignOut(c *fiber.Ctx) error {
+	log.Debug("received sign out user request")
+	id := c.Params("id")
+
+	pr := providers.GetProviders(c)
+	usr, err := pr.S.User.GetById(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, sdk.ErrUserNotFound) {
+			return c.Status(http.StatusNotFound).JSON(sdk.UserResponse{
+				Success: false,
+				Message: "User not found",
+			})
+		}
+		message := fmt.Sprintf("failed to get user. %v", err)
+		log.Errorw("failed to get user", "error", message)
+		return c.Status(http.StatusInternalServerError).JSON(sdk.UserResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+
+	err = pr.S.Auth.RevokeUserTokens(c.Context(), usr.Id)
+	if err != nil {
+		message := fmt.Sprintf("failed to sign out user. %v", err)
+		log.Errorw("failed to sign out user", "error", message)
+		return c.Status(http.StatusInternalServerError).JSON(sdk.UserResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+
+	log.Debug("user signed out successfully")
+	return c.Status(http.StatusOK).JSON(sdk.UserResponse{
+		Success: true,
+		Message: "User signed out successfully",
+		Data:    usr,
+	})
+}
+
+// IdentitiesRoute registers the route listing the identities at the auth providers linked to a user
+func IdentitiesRoute(router fiber.Router, basePath string) {
+	routePath := "/:id/identities"
+	path := basePath + routePath
+	router.Get(routePath, Identities)
+	docs.RegisterApi(docs.ApiWrapper{
+		Path:        path,
+		Method:      http.MethodGet,
+		Name:        "Get User Identities",
+		Description: "List the identities at the auth providers linked to a user, oldest first",
+		Response: &docs.ApiResponse{
+			Description: "User identities fetched successfully",
+			Content:     new(sdk.UserIdentitiesResponse),
+		},
+		// Parameters for the user ID in the path
+		Parameters: []docs.ApiParameter{
+			{
+				Name:        "id",
+				In:          "path",
+				Description: "The ID of the user",
+				Required:    true,
+			},
+		},
+		Tags: routeTags,
+	})
+}
+
+// Identities lists the identities linked to a user
+func Identities(c *fiber.Ctx) error {
+	log.Debug("received get user identities request")
+	id := c.Params("id")
+
+	pr := providers.GetProviders(c)
+	usr, err := pr.S.User.GetById(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, sdk.ErrUserNotFound) {
+			return c.Status(http.StatusNotFound).JSON(sdk.UserIdentitiesResponse{
+				Success: false,
+				Message: "User not found",
+			})
+		}
+		message := fmt.Sprintf("failed to get user. %v", err)
+		log.Errorw("failed to get user", "error", message)
+		return c.Status(http.StatusInternalServerError).JSON(sdk.UserIdentitiesResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+
+	identities, err := pr.S.Identities.List(c.Context(), usr.Id)
+	if err != nil {
+		message := fmt.Sprintf("failed to get user identities. %v", err)
+		log.Errorw("failed to get user identities", "error", message)
+		return c.Status(http.StatusInternalServerError).JSON(sdk.UserIdentitiesResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+
+	log.Debug("user identities fetched successfully")
+	return c.Status(http.StatusOK).JSON(sdk.UserIdentitiesResponse{
+		Success: true,
+		Message: "User identities fetched successfully",
+		Data:    identities,
+	})
+}
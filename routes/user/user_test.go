@@ -1280,3 +1280,145 @@ func TestCopyResources(t *testing.T) {
 		assert.Contains(t, resp.Message, "failed to copy resources")
 	})
 }
+
+func TestSignOut(t *testing.T) {
+
+	err := os.Setenv("JWT_SECRET", "abcd")
+	require.NoError(t, err)
+	cnf := config.NewAppConfig()
+
+	tests := []struct {
+		name           string
+		setupMocks     func(usr *services.MockUserService, auth *services.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "sign out user successfully",
+			setupMocks: func(usr *services.MockUserService, auth *services.MockAuthService) {
+				usr.On("GetById", mock.Anything, "0001").Return(&sdk.User{Id: "0001"}, nil).Once()
+				auth.On("RevokeUserTokens", mock.Anything, "0001").Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "user not found",
+			setupMocks: func(usr *services.MockUserService, auth *services.MockAuthService) {
+				usr.On("GetById", mock.Anything, "0001").Return(&sdk.User{}, sdk.ErrUserNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "revoking the tokens fails",
+			setupMocks: func(usr *services.MockUserService, auth *services.MockAuthService) {
+				usr.On("GetById", mock.Anything, "0001").Return(&sdk.User{Id: "0001"}, nil).Once()
+				auth.On("RevokeUserTokens", mock.Anything, "0001").Return(errors.New("cache error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{
+				ReadBufferSize: 8192,
+			})
+
+			d := test.SetupMockDB()
+			cs := cache.NewMockService()
+			svcs, err := server.GetServices(*cnf, cs, d)
+			require.NoError(t, err)
+
+			mockUserSvc := services.MockUserService{}
+			mockAuthSvc := services.MockAuthService{}
+			tt.setupMocks(&mockUserSvc, &mockAuthSvc)
+			svcs.User = &mockUserSvc
+			svcs.Auth = &mockAuthSvc
+
+			prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+			app.Use(providers.Handle(prv))
+			RegisterRoutes(app, "/user")
+
+			req, _ := http.NewRequest("POST", "/user/v1/0001/sign-out", nil)
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+			var resp sdk.UserResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			mockUserSvc.AssertExpectations(t)
+			mockAuthSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIdentities(t *testing.T) {
+
+	err := os.Setenv("JWT_SECRET", "abcd")
+	require.NoError(t, err)
+	cnf := config.NewAppConfig()
+
+	tests := []struct {
+		name           string
+		setupMocks     func(usr *services.MockUserService, identity *services.MockIdentityService)
+		expectedStatus int
+	}{
+		{
+			name: "list identities successfully",
+			setupMocks: func(usr *services.MockUserService, identity *services.MockIdentityService) {
+				usr.On("GetById", mock.Anything, "0001").Return(&sdk.User{Id: "0001"}, nil).Once()
+				identity.On("List", mock.Anything, "0001").Return([]sdk.UserIdentity{{Id: "identity-1", UserId: "0001"}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "user not found",
+			setupMocks: func(usr *services.MockUserService, identity *services.MockIdentityService) {
+				usr.On("GetById", mock.Anything, "0001").Return(&sdk.User{}, sdk.ErrUserNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "listing the identities fails",
+			setupMocks: func(usr *services.MockUserService, identity *services.MockIdentityService) {
+				usr.On("GetById", mock.Anything, "0001").Return(&sdk.User{Id: "0001"}, nil).Once()
+				identity.On("List", mock.Anything, "0001").Return(nil, errors.New("database error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{
+				ReadBufferSize: 8192,
+			})
+
+			d := test.SetupMockDB()
+			cs := cache.NewMockService()
+			svcs, err := server.GetServices(*cnf, cs, d)
+			require.NoError(t, err)
+
+			mockUserSvc := services.MockUserService{}
+			mockIdentitySvc := services.MockIdentityService{}
+			tt.setupMocks(&mockUserSvc, &mockIdentitySvc)
+			svcs.User = &mockUserSvc
+			svcs.Identities = &mockIdentitySvc
+
+			prv := server.SetupTestServer(app, cnf, svcs, cs, d)
+			app.Use(providers.Handle(prv))
+			RegisterRoutes(app, "/user")
+
+			req, _ := http.NewRequest("GET", "/user/v1/0001/identities", nil)
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+			var resp sdk.UserIdentitiesResponse
+			err = json.NewDecoder(res.Body).Decode(&resp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus == http.StatusOK, resp.Success)
+			mockUserSvc.AssertExpectations(t)
+			mockIdentitySvc.AssertExpectations(t)
+		})
+	}
+}
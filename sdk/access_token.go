@@ -0,0 +1,83 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrInvalidAccessTokenSettings is returned when the access token format or the claims mapping of a client is invalid.
+var ErrInvalidAccessTokenSettings = errors.New("invalid access token settings")
+
+const (
+	// AccessTokenFormatOpaque access tokens only carry a reference to the session. Resource servers
+	// resolve them with the userinfo or the introspection endpoint. This is the default format.
+	AccessTokenFormatOpaque = "opaque"
+
+	// AccessTokenFormatSelfContained access tokens carry the subject, the project and the permissions
+	// of the user so that resource servers can authorize the requests offline using the JWKS.
+	AccessTokenFormatSelfContained = "self_contained"
+)
+
+// User attributes that can be mapped to the claims of a self contained access token.
+const (
+	AccessTokenClaimProjectId = "project_id" // ID of the project the user belongs to
+	AccessTokenClaimEmail     = "email"      // Email address of the user
+	AccessTokenClaimName      = "name"       // Display name of the user
+	AccessTokenClaimPhone     = "phone"      // Phone number of the user
+	AccessTokenClaimRoles     = "roles"      // IDs of the roles assigned to the user
+	AccessTokenClaimResources = "resources"  // Keys of the resources the user has access to
+)
+
+// DefaultAccessTokenClaims is the claims mapping used for clients that don't configure one.
+var DefaultAccessTokenClaims = map[string]string{
+	AccessTokenClaimProjectId: AccessTokenClaimProjectId,
+	AccessTokenClaimRoles:     AccessTokenClaimRoles,
+	AccessTokenClaimResources: AccessTokenClaimResources,
+}
+
+// reservedAccessTokenClaims are always set by go-iam and cannot be mapped by the clients.
+var reservedAccessTokenClaims = map[string]bool{
+	"id": true, "iss": true, "sub": true, "aud": true, "exp": true,
+	"iat": true, "nbf": true, "jti": true, "client_id": true, "scope": true,
+	"amr": true, "acr": true, "auth_time": true,
+}
+
+// HasSelfContainedAccessToken returns true if the client is issued self contained access tokens.
+func (c Client) HasSelfContainedAccessToken() bool {
+	return c.AccessTokenFormat == AccessTokenFormatSelfContained
+}
+
+// GetAccessTokenClaims returns the claims mapping of the client, falling back to DefaultAccessTokenClaims.
+func (c Client) GetAccessTokenClaims() map[string]string {
+	if len(c.AccessTokenClaims) == 0 {
+		return DefaultAccessTokenClaims
+	}
+	return c.AccessTokenClaims
+}
+
+// ValidateAccessTokenSettings checks the access token format and the claims mapping of the client.
+func (c Client) ValidateAccessTokenSettings() error {
+	switch c.AccessTokenFormat {
+	case "", AccessTokenFormatOpaque, AccessTokenFormatSelfContained:
+	default:
+		return fmt.Errorf("%w: unknown access token format %s", ErrInvalidAccessTokenSettings, c.AccessTokenFormat)
+	}
+	claims := make([]string, 0, len(c.AccessTokenClaims))
+	for claim := range c.AccessTokenClaims {
+		claims = append(claims, claim)
+	}
+	sort.Strings(claims)
+	for _, claim := range claims {
+		if len(claim) == 0 || reservedAccessTokenClaims[claim] {
+			return fmt.Errorf("%w: claim %q cannot be mapped", ErrInvalidAccessTokenSettings, claim)
+		}
+		switch c.AccessTokenClaims[claim] {
+		case AccessTokenClaimProjectId, AccessTokenClaimEmail, AccessTokenClaimName,
+			AccessTokenClaimPhone, AccessTokenClaimRoles, AccessTokenClaimResources:
+		default:
+			return fmt.Errorf("%w: unknown user attribute %q for the claim %q", ErrInvalidAccessTokenSettings, c.AccessTokenClaims[claim], claim)
+		}
+	}
+	return nil
+}
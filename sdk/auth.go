@@ -1,9 +1,15 @@
 package sdk
 
 // AuthVerifyCodeResponse represents the response from OAuth2 authorization code verification.
-// This contains the access token that can be used to authenticate API requests.
+// This contains the access token that can be used to authenticate API requests
+// and the refresh token to obtain a new one once it expires.
 type AuthVerifyCodeResponse struct {
-	AccessToken string `json:"access_token"` // JWT access token for API authentication
+	AccessToken  string `json:"access_token"`            // JWT access token for API authentication
+	IdToken      string `json:"id_token,omitempty"`      // OpenID Connect ID token describing the authenticated user
+	RefreshToken string `json:"refresh_token,omitempty"` // Token used to obtain a new access token
+	TokenType    string `json:"token_type,omitempty"`    // Type of the access token (always "Bearer")
+	ExpiresIn    int64  `json:"expires_in,omitempty"`    // Number of seconds until the access token expires
+	Scope        string `json:"scope,omitempty"`         // Space delimited scopes granted to the access token
 }
 
 // AuthCallbackResponse represents the response from OAuth2 callback processing.
@@ -14,6 +20,23 @@ type AuthCallbackResponse struct {
 	Data    *AuthVerifyCodeResponse `json:"data"`    // Token data (present only on success)
 }
 
+// AuthLoginParams holds the parameters of an OAuth2 login request.
+// They are carried through the auth provider round trip as part of the cached state.
+type AuthLoginParams struct {
+	ClientId            string `json:"client_id"`                // OAuth2 client identifier
+	AuthProviderId      string `json:"auth_provider_id"`         // Auth provider to login with. Defaults to the client's default provider
+	State               string `json:"state"`                    // Opaque state sent back to the client
+	RedirectUrl         string `json:"redirect_url"`             // URL to redirect to after login
+	CodeChallengeMethod string `json:"code_challenge_method"`    // PKCE code challenge method
+	CodeChallenge       string `json:"code_challenge"`           // PKCE code challenge
+	Nonce               string `json:"nonce"`                    // OpenID Connect nonce echoed back in the ID token
+	Scope               string `json:"scope"`                    // Space delimited scopes requested by the client
+	AcrValues           string `json:"acr_values,omitempty"`     // Space delimited authentication context classes requested, eg. for a step-up to mfa
+	ProviderNonce       string `json:"provider_nonce,omitempty"` // Nonce sent to the auth provider, for the providers binding their tokens to the login
+	LinkUserId          string `json:"link_user_id,omitempty"`   // User the identity of the login is linked to, instead of logging in
+	LoginHint           string `json:"login_hint,omitempty"`     // Email address of the user, picks the auth provider of its domain when no provider is given
+}
+
 // AuthLoginResponse represents the response from initiating an OAuth2 login flow.
 // This contains the URL where the user should be redirected to complete authentication.
 type AuthLoginResponse struct {
@@ -58,8 +81,12 @@ type ClientCredentialsDataResponse struct {
 
 // RefreshTokenRequest represents a request to refresh an access token.
 // This is used to obtain a new access token when the current one expires.
+// Confidential clients authenticate either with the client secret in the body
+// or with basic auth.
 type RefreshTokenRequest struct {
-	RefreshToken string `json:"refresh_token"` // The refresh token obtained from previous authentication
+	RefreshToken string `json:"refresh_token"`           // The refresh token obtained from previous authentication
+	ClientId     string `json:"client_id"`               // OAuth2 client identifier the refresh token was issued to
+	ClientSecret string `json:"client_secret,omitempty"` // OAuth2 client secret for confidential clients
 }
 
 // RefreshTokenResponse represents the response from a token refresh operation.
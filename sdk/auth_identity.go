@@ -10,6 +10,13 @@ const (
 
 	// AuthIdentityTypePhone indicates phone-based authentication identity.
 	AuthIdentityTypePhone AuthIdentityType = "phone"
+
+	// AuthIdentityTypeSubject indicates the stable id of the user at the auth provider,
+	// which does not change when the user changes their email address.
+	AuthIdentityTypeSubject AuthIdentityType = "subject"
+
+	// AuthIdentityTypeGroups indicates the groups the user is a member of at the auth provider.
+	AuthIdentityTypeGroups AuthIdentityType = "groups"
 )
 
 // AuthMetadataType is an interface for authentication metadata that can update user details.
@@ -34,3 +41,22 @@ type AuthIdentity struct {
 func (a AuthIdentity) UpdateUserDetails(user *User) {
 	a.Metadata.UpdateUserDetails(user)
 }
+
+// AuthIdentitySubject is the metadata of the identities of type AuthIdentityTypeSubject.
+// It leaves the user details as they are, the subject is what links the user to their identity at the auth provider.
+type AuthIdentitySubject struct {
+	Subject string `json:"subject"` // Stable id of the user at the auth provider
+}
+
+// UpdateUserDetails leaves the user as is.
+func (a AuthIdentitySubject) UpdateUserDetails(user *User) {}
+
+// AuthIdentityGroups is the metadata of the identities of type AuthIdentityTypeGroups.
+// The roles the group role mapping of the auth provider grants follow the groups on every login.
+// Auth providers that cannot tell the groups of the user leave the identity out, so that the roles are kept as they are.
+type AuthIdentityGroups struct {
+	Groups []string `json:"groups"` // Ids or names of the groups of the user at the auth provider
+}
+
+// UpdateUserDetails leaves the user as is.
+func (a AuthIdentityGroups) UpdateUserDetails(user *User) {}
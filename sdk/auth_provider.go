@@ -12,6 +12,14 @@ import (
 // ErrAuthProviderNotFound is returned when a requested authentication provider cannot be found.
 var ErrAuthProviderNotFound = errors.New("auth provider not found")
 
+// ErrInvalidAuthProviderConfig is returned when the params of an auth provider do not match the schema of its type.
+var ErrInvalidAuthProviderConfig = errors.New("invalid auth provider configuration")
+
+// ErrLoginDenied is returned when the user logged in at the auth provider but is not let in,
+// like when the provisioning rules of the auth provider refuse to create them or they are disabled.
+// The user is sent back to the client with the access_denied error instead.
+var ErrLoginDenied = errors.New("login denied")
+
 // AuthProviderType represents the type of external authentication provider.
 type AuthProviderType string
 
@@ -27,23 +35,65 @@ const (
 
 	// AuthProviderTypeOIDC represents generic OpenID Connect authentication.
 	AuthProviderTypeOIDC AuthProviderType = "OIDC"
+
+	// AuthProviderTypePassword represents the built in email and password authentication.
+	AuthProviderTypePassword AuthProviderType = "PASSWORD"
+
+	// AuthProviderTypePasswordless represents the built in login with a magic link or a code sent by email.
+	AuthProviderTypePasswordless AuthProviderType = "PASSWORDLESS"
+
+	// AuthProviderTypeSms represents the built in login with a code sent by sms.
+	AuthProviderTypeSms AuthProviderType = "SMS"
+
+	// AuthProviderTypePasskey represents the built in login with a passkey (WebAuthn).
+	AuthProviderTypePasskey AuthProviderType = "PASSKEY"
+
+	// AuthProviderTypeSAML represents SAML 2.0 single sign-on with go-iam as the service provider.
+	AuthProviderTypeSAML AuthProviderType = "SAML"
+
+	// AuthProviderTypeLDAP represents the login with the credentials of an LDAP directory, like Active Directory.
+	AuthProviderTypeLDAP AuthProviderType = "LDAP"
+
+	// AuthProviderTypeOAuth2 represents a configurable OAuth2 provider not speaking OIDC, like GitLab or Discord.
+	AuthProviderTypeOAuth2 AuthProviderType = "OAUTH2"
 )
 
 // AuthProvider represents an external authentication provider configuration.
 // This contains the settings and credentials needed to integrate with
 // external identity providers like Google, Microsoft, or GitHub.
 type AuthProvider struct {
-	Id        string              `json:"id"`         // Unique identifier for the auth provider
-	Name      string              `json:"name"`       // Display name of the auth provider
-	Icon      string              `json:"icon"`       // Icon URL or identifier for UI display
-	Provider  AuthProviderType    `json:"provider"`   // Type of the authentication provider
-	Params    []AuthProviderParam `json:"params"`     // Configuration parameters for the provider
-	ProjectId string              `json:"project_id"` // ID of the project this provider belongs to
-	Enabled   bool                `json:"enabled"`    // Whether this provider is active
-	CreatedAt *time.Time          `json:"created_at"` // Timestamp when provider was created
-	UpdatedAt *time.Time          `json:"updated_at"` // Timestamp when provider was last updated
-	CreatedBy string              `json:"created_by"` // ID of the user who created this provider
-	UpdatedBy string              `json:"updated_by"` // ID of the user who last updated this provider
+	Id                   string                   `json:"id"`                     // Unique identifier for the auth provider
+	Name                 string                   `json:"name"`                   // Display name of the auth provider
+	Icon                 string                   `json:"icon"`                   // Icon URL or identifier for UI display
+	Provider             AuthProviderType         `json:"provider"`               // Type of the authentication provider
+	Params               []AuthProviderParam      `json:"params"`                 // Configuration parameters for the provider
+	ProjectId            string                   `json:"project_id"`             // ID of the project this provider belongs to
+	Enabled              bool                     `json:"enabled"`                // Whether this provider is active
+	RequireVerifiedEmail bool                     `json:"require_verified_email"` // Whether logins with an email address the provider does not report as verified are refused
+	Provisioning         AuthProviderProvisioning `json:"provisioning"`           // Rules for the users created on their first login with the provider
+	GroupRoles           []AuthProviderGroupRole  `json:"group_roles"`            // Roles the members of the groups at the provider get
+	CreatedAt            *time.Time               `json:"created_at"`             // Timestamp when provider was created
+	UpdatedAt            *time.Time               `json:"updated_at"`             // Timestamp when provider was last updated
+	CreatedBy            string                   `json:"created_by"`             // ID of the user who created this provider
+	UpdatedBy            string                   `json:"updated_by"`             // ID of the user who last updated this provider
+}
+
+// AuthProviderProvisioning are the rules for creating the users logging in with an auth provider for the first time.
+// The zero value creates every user, enabled and without roles or policies.
+type AuthProviderProvisioning struct {
+	Disabled        bool                  `json:"disabled"`         // Whether unknown users are refused instead of created, for invite only projects
+	AllowedDomains  []string              `json:"allowed_domains"`  // Email domains users are created for, any domain when empty
+	BlockedDomains  []string              `json:"blocked_domains"`  // Email domains users are never created for
+	DefaultRoles    []string              `json:"default_roles"`    // Ids of the roles assigned to the created users
+	DefaultPolicies map[string]UserPolicy `json:"default_policies"` // Policies assigned to the created users, mapped by policy id
+	RequireApproval bool                  `json:"require_approval"` // Whether the created users are pending approval until an admin enables them
+}
+
+// AuthProviderGroupRole maps a group at an auth provider to the go-iam role its members get.
+// The roles granted through the mapping are removed when the user leaves the group, the ones granted manually are kept.
+type AuthProviderGroupRole struct {
+	Group  string `json:"group"`   // Id, name or DN of the group at the provider, compared case insensitively
+	RoleId string `json:"role_id"` // Id of the role the members of the group get
 }
 
 // GetParam retrieves the value of a configuration parameter by key.
@@ -66,6 +116,55 @@ type AuthProviderParam struct {
 	IsSecret bool   `json:"is_secret"` // Whether this parameter contains sensitive information
 }
 
+// AuthProviderParamFormat is the format the value of an auth provider param is validated against.
+type AuthProviderParamFormat string
+
+const (
+	// AuthProviderParamFormatText accepts any value.
+	AuthProviderParamFormatText AuthProviderParamFormat = "text"
+
+	// AuthProviderParamFormatUrl accepts absolute http and https urls.
+	AuthProviderParamFormatUrl AuthProviderParamFormat = "url"
+
+	// AuthProviderParamFormatBoolean accepts "true" and "false".
+	AuthProviderParamFormatBoolean AuthProviderParamFormat = "boolean"
+
+	// AuthProviderParamFormatJson accepts json objects.
+	AuthProviderParamFormatJson AuthProviderParamFormat = "json"
+
+	// AuthProviderParamFormatPem accepts pem encoded blocks, like certificates.
+	AuthProviderParamFormatPem AuthProviderParamFormat = "pem"
+
+	// AuthProviderParamFormatXml accepts well formed xml documents.
+	AuthProviderParamFormatXml AuthProviderParamFormat = "xml"
+)
+
+// AuthProviderParamSpec describes a param the configuration of a type of auth provider takes.
+type AuthProviderParamSpec struct {
+	Key           string                  `json:"key"`                      // Key of the param
+	Label         string                  `json:"label"`                    // Human-readable label for the form field
+	Description   string                  `json:"description"`              // What the param is for
+	Required      bool                    `json:"required"`                 // Whether the configuration has to set the param
+	IsSecret      bool                    `json:"is_secret"`                // Whether the param is stored encrypted and masked in forms
+	Format        AuthProviderParamFormat `json:"format"`                   // Format the value is validated against
+	AllowedValues []string                `json:"allowed_values,omitempty"` // Values the param is limited to, any when empty
+}
+
+// AuthProviderTypeInfo describes a type of auth provider and the schema of its params.
+type AuthProviderTypeInfo struct {
+	Type   AuthProviderType        `json:"type"`   // Type of the auth provider
+	Name   string                  `json:"name"`   // Display name of the type
+	Icon   string                  `json:"icon"`   // Icon identifier of the type for UI display
+	Params []AuthProviderParamSpec `json:"params"` // Params the configuration of the type takes
+}
+
+// AuthProviderTypesResponse represents an API response listing the types of auth providers available.
+type AuthProviderTypesResponse struct {
+	Success bool                   `json:"success"` // Indicates if the operation was successful
+	Message string                 `json:"message"` // Human-readable message about the operation
+	Data    []AuthProviderTypeInfo `json:"data"`    // Types of auth providers with their param schemas
+}
+
 // AuthProviderResponse represents an API response containing a single authentication provider.
 type AuthProviderResponse struct {
 	Success bool          `json:"success"` // Indicates if the operation was successful
@@ -136,6 +235,19 @@ type ServiceProvider interface {
 	HasRefreshTokenFlow() bool
 }
 
+// NonceServiceProvider is implemented by the service providers binding the tokens of the upstream
+// provider to the login with a nonce, like OIDC. go-iam generates the nonce when the login starts and
+// keeps it with the state of the login until the code comes back.
+type NonceServiceProvider interface {
+	ServiceProvider
+
+	// GetAuthCodeUrlWithNonce returns the authorization URL asking for tokens carrying the nonce.
+	GetAuthCodeUrlWithNonce(state, nonce string) string
+
+	// VerifyCodeWithNonce exchanges an authorization code for tokens, which have to carry the nonce.
+	VerifyCodeWithNonce(ctx context.Context, code, nonce string) (*AuthToken, error)
+}
+
 // AuthProviderQueryParams represents query parameters for filtering authentication providers.
 type AuthProviderQueryParams struct {
 	ProjectIds []string `json:"project_id"` // Filter providers by project IDs
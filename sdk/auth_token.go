@@ -14,4 +14,12 @@ type AuthToken struct {
 	CodeChallenge        string    `json:"code_challenge"`          // PKCE code challenge value
 	ClientId             string    `json:"client_id"`               // OAuth2 client identifier
 	ServiceAccountUserId string    `json:"service_account_user_id"` // Associated service account user ID (if applicable)
+	Nonce                string    `json:"nonce,omitempty"`         // OpenID Connect nonce provided at login
+	AuthTime             time.Time `json:"auth_time"`               // Time at which the user authenticated with the auth provider
+	SessionId            string    `json:"session_id,omitempty"`    // Login session identifier, shared with the refresh token family
+	Scope                string    `json:"scope,omitempty"`         // Space delimited scopes granted to the token
+	RedirectUrl          string    `json:"redirect_url,omitempty"`  // Redirect url the authorization code was issued for
+	Amr                  []string  `json:"amr,omitempty"`           // Authentication methods used at login
+	Acr                  string    `json:"acr,omitempty"`           // Authentication context class satisfied at login
+	PublicClient         bool      `json:"public_client,omitempty"` // Whether the code was exchanged by a public client, only such tokens refresh without the client secret
 }
@@ -11,27 +11,37 @@ import (
 // ErrClientNotFound is returned when a requested OAuth2 client cannot be found.
 var ErrClientNotFound = errors.New("client not found")
 
+// ErrRedirectUrlNotFound is returned when a redirect url is not registered for the client.
+var ErrRedirectUrlNotFound = errors.New("redirect url not registered for the client")
+
 // Client represents an OAuth2 client in the Go IAM system.
 // Clients can be external applications that integrate with the IAM system
 // or internal service accounts used for server-to-server communication.
 type Client struct {
-	Id                    string     `json:"id"`                       // Unique identifier for the client
-	Name                  string     `json:"name"`                     // Display name of the client
-	Description           string     `json:"description"`              // Description of the client's purpose
-	Secret                string     `json:"secret"`                   // Client secret for authentication
-	Tags                  []string   `json:"tags"`                     // Tags for categorizing the client
-	RedirectURLs          []string   `json:"redirect_urls"`            // Allowed redirect URLs for OAuth2 flows
-	Scopes                []string   `json:"scopes"`                   // OAuth2 scopes this client can request
-	ProjectId             string     `json:"project_id"`               // ID of the project this client belongs to
-	DefaultAuthProviderId string     `json:"default_auth_provider_id"` // Default auth provider for this client
-	GoIamClient           bool       `json:"go_iam_client"`            // Indicates if this is a Go-IAM internal client
-	LinkedUserId          string     `json:"linked_user_id"`           // Associated user ID for service accounts
-	ServiceAccountEmail   string     `json:"service_account_email"`    // Email address for service account clients
-	Enabled               bool       `json:"enabled"`                  // Whether the client is active
-	CreatedAt             *time.Time `json:"created_at"`               // Timestamp when client was created
-	CreatedBy             string     `json:"created_by"`               // ID of the user who created this client
-	UpdatedAt             *time.Time `json:"updated_at"`               // Timestamp when client was last updated
-	UpdatedBy             string     `json:"updated_by"`               // ID of the user who last updated this client
+	Id                     string            `json:"id"`                        // Unique identifier for the client
+	Name                   string            `json:"name"`                      // Display name of the client
+	Description            string            `json:"description"`               // Description of the client's purpose
+	Secret                 string            `json:"secret"`                    // Client secret for authentication
+	Tags                   []string          `json:"tags"`                      // Tags for categorizing the client
+	RedirectURLs           []string          `json:"redirect_urls"`             // Allowed redirect URLs for OAuth2 flows
+	PostLogoutRedirectURLs []string          `json:"post_logout_redirect_urls"` // Allowed redirect URLs after logout
+	Scopes                 []string          `json:"scopes"`                    // OAuth2 scopes this client can request
+	ProjectId              string            `json:"project_id"`                // ID of the project this client belongs to
+	DefaultAuthProviderId  string            `json:"default_auth_provider_id"`  // Default auth provider for this client
+	GoIamClient            bool              `json:"go_iam_client"`             // Indicates if this is a Go-IAM internal client
+	LinkedUserId           string            `json:"linked_user_id"`            // Associated user ID for service accounts
+	ServiceAccountEmail    string            `json:"service_account_email"`     // Email address for service account clients
+	Enabled                bool              `json:"enabled"`                   // Whether the client is active
+	Public                 bool              `json:"public"`                    // Whether the client cannot keep its secret, like a single page or mobile app, and proves its logins with PKCE instead
+	PkceRequired           bool              `json:"pkce_required"`             // Whether logins of the client have to use PKCE
+	PkceAllowPlain         bool              `json:"pkce_allow_plain"`          // Whether the plain PKCE method is accepted in addition to S256
+	AccessTokenFormat      string            `json:"access_token_format"`       // Format of the access tokens issued to the client, opaque (default) or self_contained
+	AccessTokenClaims      map[string]string `json:"access_token_claims"`       // Claims of the self contained access tokens mapped to the user attribute they are filled from
+	ThirdParty             bool              `json:"third_party"`               // Whether the users have to consent to the scopes requested by the client
+	CreatedAt              *time.Time        `json:"created_at"`                // Timestamp when client was created
+	CreatedBy              string            `json:"created_by"`                // ID of the user who created this client
+	UpdatedAt              *time.Time        `json:"updated_at"`                // Timestamp when client was last updated
+	UpdatedBy              string            `json:"updated_by"`                // ID of the user who last updated this client
 }
 
 // IsServiceAccount returns true if this client represents a service account.
@@ -0,0 +1,73 @@
+package sdk
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrConsentNotFound is returned when the user has not granted any consent to the client.
+var ErrConsentNotFound = errors.New("consent not found")
+
+// ErrInvalidConsentChallenge is returned when a consent challenge is unknown, expired or already answered.
+var ErrInvalidConsentChallenge = errors.New("invalid or expired consent challenge")
+
+// OAuthErrorAccessDenied is returned to the client when the user denies the consent.
+const OAuthErrorAccessDenied = "access_denied"
+
+// Consent records the scopes a user has allowed a third party client to access.
+// There is at most one consent per user and client. Consenting to more scopes later extends it.
+type Consent struct {
+	Id        string     `json:"id"`         // Unique identifier of the consent
+	UserId    string     `json:"user_id"`    // User who granted the consent
+	ClientId  string     `json:"client_id"`  // Client the consent was granted to
+	ProjectId string     `json:"project_id"` // Project the client belongs to
+	Scopes    []string   `json:"scopes"`     // Scopes the client is allowed to access
+	CreatedAt *time.Time `json:"created_at"` // Time at which the consent was first granted
+	UpdatedAt *time.Time `json:"updated_at"` // Time at which the consent was last extended
+}
+
+// Covers returns true if every scope of the space delimited scope parameter is granted by the consent.
+func (c Consent) Covers(scope string) bool {
+	granted := map[string]bool{}
+	for _, sc := range c.Scopes {
+		granted[sc] = true
+	}
+	for _, sc := range ParseScope(scope) {
+		if !granted[sc] {
+			return false
+		}
+	}
+	return true
+}
+
+// ConsentsResponse represents an API response containing the consents of a user.
+type ConsentsResponse struct {
+	Success bool      `json:"success"`        // Indicates if the operation was successful
+	Message string    `json:"message"`        // Human-readable message about the operation
+	Data    []Consent `json:"data,omitempty"` // The consents of the user
+}
+
+// ConsentPrompt describes a pending consent so that the consent screen can ask the user.
+type ConsentPrompt struct {
+	ConsentChallenge string         `json:"consent_challenge"` // Challenge identifying the pending consent
+	ClientId         string         `json:"client_id"`         // Client asking for the consent
+	ClientName       string         `json:"client_name"`       // Display name of the client
+	ProjectId        string         `json:"project_id"`        // Project the client belongs to
+	Scopes           []string       `json:"scopes"`            // Scopes requested by the client
+	ScopeDetails     []ProjectScope `json:"scope_details"`     // Scopes requested by the client with their descriptions in the project
+	UserName         string         `json:"user_name"`         // Name of the user being asked
+	UserEmail        string         `json:"user_email"`        // Email address of the user being asked
+}
+
+// ConsentPromptResponse represents an API response containing a pending consent.
+type ConsentPromptResponse struct {
+	Success bool           `json:"success"`        // Indicates if the operation was successful
+	Message string         `json:"message"`        // Human-readable message about the operation
+	Data    *ConsentPrompt `json:"data,omitempty"` // The pending consent
+}
+
+// ConsentDecision is the answer of the user to a consent prompt.
+type ConsentDecision struct {
+	ConsentChallenge string `json:"consent_challenge"` // Challenge identifying the pending consent
+	Approve          bool   `json:"approve"`           // Whether the user allows the client to access the requested scopes
+}
@@ -0,0 +1,39 @@
+package sdk
+
+import "errors"
+
+// ErrInvalidLdapCredentials is returned when the directory does not know the username or rejects the password.
+// It does not tell which of the two was wrong, so that accounts cannot be enumerated.
+var ErrInvalidLdapCredentials = errors.New("invalid username or password")
+
+// ErrNotLdapProvider is returned when an LDAP login is used with an auth provider of another type.
+var ErrNotLdapProvider = errors.New("the auth provider is not an ldap provider")
+
+// Params of the LDAP auth provider.
+const (
+	LdapParamLoginUrl         = "@LDAP/LOGIN_URL"         // Login page, it receives the state of the login in the query
+	LdapParamUrl              = "@LDAP/URL"               // Url of the directory, ldap://host:389 or ldaps://host:636
+	LdapParamStartTls         = "@LDAP/START_TLS"         // "true" upgrades ldap:// connections with StartTLS
+	LdapParamCaCert           = "@LDAP/CA_CERT"           // Pem encoded certificates of the CAs of the directory, the system ones when empty
+	LdapParamBindDn           = "@LDAP/BIND_DN"           // DN of the service account searching the users, anonymous search when empty
+	LdapParamBindPassword     = "@LDAP/BIND_PASSWORD"     // Password of the service account
+	LdapParamUserSearchBase   = "@LDAP/USER_SEARCH_BASE"  // DN under which the users are searched, like ou=people,dc=example,dc=com
+	LdapParamUserFilter       = "@LDAP/USER_FILTER"       // Search filter of the users, {username} is replaced with the escaped username. (uid={username}) when empty
+	LdapParamAttributeMapping = "@LDAP/ATTRIBUTE_MAPPING" // Json object mapping email, name, phone and groups to the attributes of the entries
+)
+
+// LdapLoginRequest is posted by the login page of an LDAP auth provider.
+type LdapLoginRequest struct {
+	State    string `json:"state"`    // State passed to the login page by the login url
+	Username string `json:"username"` // Username of the user in the directory, matched by the user filter
+	Password string `json:"password"` // Password of the user in the directory
+}
+
+// LdapIdentity is what go-iam keeps of the entry of a user after they logged in with an LDAP auth provider.
+type LdapIdentity struct {
+	Dn     string   `json:"dn"`               // DN of the entry of the user
+	Email  string   `json:"email,omitempty"`  // Email address of the user
+	Name   string   `json:"name,omitempty"`   // Display name of the user
+	Phone  string   `json:"phone,omitempty"`  // Phone number of the user
+	Groups []string `json:"groups,omitempty"` // DNs of the groups the user is a member of
+}
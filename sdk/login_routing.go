@@ -0,0 +1,76 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidLoginChallenge is returned when a login challenge of the identifier page is unknown, expired or already answered.
+var ErrInvalidLoginChallenge = errors.New("invalid or expired login challenge")
+
+// ErrNoAuthProviderForEmail is returned when no auth provider of the project lets the user of an email address log in.
+var ErrNoAuthProviderForEmail = errors.New("no auth provider for the email address")
+
+// LoginRouting sends the users of a project to the auth provider of their organisation by the domain of their
+// email address. It applies to the logins that do not name an auth provider: the client passes the email address
+// in the login_hint, or the user types it on the identifier page.
+type LoginRouting struct {
+	Domains  []LoginDomainRoute `json:"domains"`  // Auth providers of the email domains
+	Fallback []string           `json:"fallback"` // Auth providers the users of the other domains choose from, the default one of the client when empty
+}
+
+// LoginDomainRoute routes the logins of the users of an email domain to an auth provider.
+type LoginDomainRoute struct {
+	Domain         string `json:"domain"`           // Email domain like acme.com, compared case insensitively
+	AuthProviderId string `json:"auth_provider_id"` // Auth provider the users of the domain log in with
+}
+
+// ValidateLoginRouting checks that every domain is routed once and to an auth provider.
+func (p Project) ValidateLoginRouting() error {
+	if p.LoginRouting == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, route := range p.LoginRouting.Domains {
+		domain := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(route.Domain), "@"))
+		if len(domain) == 0 || strings.ContainsAny(domain, "@/: ") {
+			return fmt.Errorf("login routing domain %q is not a domain", route.Domain)
+		}
+		if seen[domain] {
+			return fmt.Errorf("login routing domain %s is routed twice", domain)
+		}
+		seen[domain] = true
+		if len(route.AuthProviderId) == 0 {
+			return fmt.Errorf("login routing domain %s needs an auth_provider_id", domain)
+		}
+	}
+	for _, id := range p.LoginRouting.Fallback {
+		if len(id) == 0 {
+			return fmt.Errorf("login routing fallback has an empty auth provider id")
+		}
+	}
+	return nil
+}
+
+// LoginDiscoveryRequest answers the login challenge of the identifier page with the email address of the user.
+type LoginDiscoveryRequest struct {
+	LoginChallenge string `json:"login_challenge"`            // Challenge the identifier page received in the query
+	Email          string `json:"email"`                      // Email address the user typed
+	AuthProviderId string `json:"auth_provider_id,omitempty"` // Auth provider the user chose among the ones offered for their email address
+}
+
+// LoginDiscoveryResponse continues the login with the auth provider of the email address. When several auth
+// providers can log the user in, they are listed instead and the choice of the user is sent back with the email.
+type LoginDiscoveryResponse struct {
+	RedirectUrl   string          `json:"redirect_url,omitempty"`   // Login url of the auth provider to send the user to
+	AuthProviders []LoginProvider `json:"auth_providers,omitempty"` // Auth providers the user chooses from
+}
+
+// LoginProvider is an auth provider offered to the user on the identifier page.
+type LoginProvider struct {
+	Id       string           `json:"id"`       // Id of the auth provider, sent back when the user chooses it
+	Name     string           `json:"name"`     // Name of the auth provider
+	Icon     string           `json:"icon"`     // Icon of the auth provider
+	Provider AuthProviderType `json:"provider"` // Type of the auth provider
+}
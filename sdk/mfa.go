@@ -0,0 +1,185 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrMfaNotEnrolled is returned when a user without an enabled second factor tries to use it.
+var ErrMfaNotEnrolled = errors.New("multi-factor authentication is not enabled for the user")
+
+// ErrMfaAlreadyEnrolled is returned when a user with an enabled second factor tries to enroll again.
+var ErrMfaAlreadyEnrolled = errors.New("multi-factor authentication is already enabled for the user")
+
+// ErrInvalidMfaCode is returned when an authenticator or recovery code is wrong or was already used.
+var ErrInvalidMfaCode = errors.New("invalid authentication code")
+
+// ErrMfaAttemptsExceeded is returned when a login fails the second factor too many times.
+var ErrMfaAttemptsExceeded = errors.New("too many wrong authentication codes, start the login again")
+
+// ErrTooManyMfaAttempts is returned when a user entered too many wrong authentication codes within a short time.
+var ErrTooManyMfaAttempts = errors.New("too many wrong authentication codes, try again later")
+
+// ErrInvalidMfaChallenge is returned when an mfa challenge is unknown, expired or already answered.
+var ErrInvalidMfaChallenge = errors.New("invalid or expired mfa challenge")
+
+// Modes of the mfa policy of a project.
+const (
+	MfaModeOptional = "optional" // Users choose whether to enable a second factor
+	MfaModeRequired = "required" // Every user has to use a second factor
+	MfaModeRoles    = "roles"    // Users holding one of the roles of the policy have to use a second factor
+)
+
+// Authentication method references (RFC 8176) set in the amr claim of the tokens.
+const (
+	AmrPassword  = "pwd" // Password of the built in password auth provider
+	AmrOtp       = "otp" // One time code, either emailed or from an authenticator app
+	AmrSms       = "sms" // Code sent by sms
+	AmrFederated = "fed" // Login delegated to a third party identity provider
+	AmrPasskey   = "hwk" // Proof of possession of a passkey
+	AmrMfa       = "mfa" // More than one factor was used
+)
+
+// Authentication context classes set in the acr claim of the tokens.
+// Clients ask for a step-up by passing AcrMultiFactor in the acr_values of the login.
+const (
+	AcrSingleFactor = "urn:go-iam:acr:1fa" // The user logged in with a single factor
+	AcrMultiFactor  = "urn:go-iam:acr:mfa" // The user completed a second factor
+)
+
+// MfaPolicy decides which users of a project have to use a second factor when logging in.
+type MfaPolicy struct {
+	Mode    string   `json:"mode"`               // One of optional, required or roles
+	RoleIds []string `json:"role_ids,omitempty"` // Roles requiring a second factor in the roles mode
+}
+
+// DefaultMfaPolicy is used for the projects that do not define an mfa policy.
+var DefaultMfaPolicy = MfaPolicy{Mode: MfaModeOptional}
+
+// RequiresMfa tells whether the policy asks the user for a second factor.
+func (p MfaPolicy) RequiresMfa(user User) bool {
+	switch p.Mode {
+	case MfaModeRequired:
+		return true
+	case MfaModeRoles:
+		for _, id := range p.RoleIds {
+			if _, ok := user.Roles[id]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetMfaPolicy returns the mfa policy of the project, or the default one if it has none.
+func (p Project) GetMfaPolicy() MfaPolicy {
+	if p.MfaPolicy == nil {
+		return DefaultMfaPolicy
+	}
+	return *p.MfaPolicy
+}
+
+// ValidateMfaPolicy checks the mode of the mfa policy of the project.
+func (p Project) ValidateMfaPolicy() error {
+	if p.MfaPolicy == nil {
+		return nil
+	}
+	switch p.MfaPolicy.Mode {
+	case MfaModeOptional, MfaModeRequired:
+		return nil
+	case MfaModeRoles:
+		if len(p.MfaPolicy.RoleIds) == 0 {
+			return fmt.Errorf("mfa policy needs role_ids in the %s mode", MfaModeRoles)
+		}
+		return nil
+	}
+	return fmt.Errorf("mfa policy mode has to be one of %s, %s or %s", MfaModeOptional, MfaModeRequired, MfaModeRoles)
+}
+
+// MfaCredential is the TOTP second factor of a user.
+// It is stored apart from the user and is only used once the enrollment is confirmed.
+type MfaCredential struct {
+	Id                 string     `json:"id"`         // Unique identifier of the credential
+	UserId             string     `json:"user_id"`    // User the credential belongs to
+	ProjectId          string     `json:"project_id"` // Project of the user
+	Secret             string     `json:"-"`          // Base32 TOTP secret, encrypted at rest and never serialized
+	RecoveryCodeHashes []string   `json:"-"`          // Hashes of the unused recovery codes
+	LastUsedStep       int64      `json:"-"`          // Time step of the last accepted code, codes are not accepted twice
+	EnabledAt          *time.Time `json:"enabled_at"` // Timestamp when the enrollment was confirmed, nil while pending
+	CreatedAt          *time.Time `json:"created_at"` // Timestamp when the enrollment was started
+	UpdatedAt          *time.Time `json:"updated_at"` // Timestamp when the credential was last updated
+}
+
+// Enabled tells whether the enrollment of the credential was confirmed.
+func (c MfaCredential) Enabled() bool {
+	return c.EnabledAt != nil
+}
+
+// MfaEnrollment is the secret of a new authenticator, shown once to the user.
+type MfaEnrollment struct {
+	Secret          string `json:"secret"`           // Base32 secret for typing into the authenticator app
+	ProvisioningUri string `json:"provisioning_uri"` // otpauth:// uri to be shown as a QR code
+}
+
+// MfaStatus describes the second factor of the current user.
+type MfaStatus struct {
+	Enabled           bool `json:"enabled"`             // Whether the user has a confirmed authenticator
+	Required          bool `json:"required"`            // Whether the mfa policy of the project requires a second factor for the user
+	RecoveryCodesLeft int  `json:"recovery_codes_left"` // Number of unused recovery codes
+}
+
+// MfaCodeRequest carries an authenticator or recovery code of the current user.
+type MfaCodeRequest struct {
+	Code string `json:"code"` // Code from the authenticator app, or a recovery code
+}
+
+// MfaPrompt describes a pending mfa challenge of a login to the mfa page.
+type MfaPrompt struct {
+	MfaChallenge string         `json:"mfa_challenge"`        // Identifier of the challenge, sent back with the code
+	UserName     string         `json:"user_name"`            // Name of the user logging in
+	UserEmail    string         `json:"user_email"`           // Email of the user logging in
+	Enrolled     bool           `json:"enrolled"`             // Whether the user already has an authenticator
+	Passkey      bool           `json:"passkey"`              // Whether the user can answer with one of their passkeys instead of a code
+	Enrollment   *MfaEnrollment `json:"enrollment,omitempty"` // New authenticator to set up when the user has none yet
+}
+
+// MfaVerifyRequest answers the mfa challenge of a login.
+type MfaVerifyRequest struct {
+	MfaChallenge string `json:"mfa_challenge"` // Challenge the mfa page received in the query
+	Code         string `json:"code"`          // Code from the authenticator app, or a recovery code
+}
+
+// MfaVerifyResponse continues the login once the second factor is verified.
+type MfaVerifyResponse struct {
+	RedirectUrl   string   `json:"redirect_url"`             // Where to send the user next
+	RecoveryCodes []string `json:"recovery_codes,omitempty"` // Recovery codes of an enrollment completed during the login, shown only once
+}
+
+// MfaPromptResponse represents an API response containing a pending mfa challenge.
+type MfaPromptResponse struct {
+	Success bool       `json:"success"`        // Indicates if the operation was successful
+	Message string     `json:"message"`        // Human-readable message about the operation
+	Data    *MfaPrompt `json:"data,omitempty"` // The pending mfa challenge
+}
+
+// MfaStatusResponse represents an API response containing the mfa status of the current user.
+type MfaStatusResponse struct {
+	Success bool       `json:"success"`        // Indicates if the operation was successful
+	Message string     `json:"message"`        // Human-readable message about the operation
+	Data    *MfaStatus `json:"data,omitempty"` // The mfa status (present only on success)
+}
+
+// MfaEnrollmentResponse represents an API response containing a new authenticator.
+type MfaEnrollmentResponse struct {
+	Success bool           `json:"success"`        // Indicates if the operation was successful
+	Message string         `json:"message"`        // Human-readable message about the operation
+	Data    *MfaEnrollment `json:"data,omitempty"` // The authenticator to set up (present only on success)
+}
+
+// MfaRecoveryCodesResponse represents an API response containing new recovery codes, shown only once.
+type MfaRecoveryCodesResponse struct {
+	Success bool     `json:"success"`        // Indicates if the operation was successful
+	Message string   `json:"message"`        // Human-readable message about the operation
+	Data    []string `json:"data,omitempty"` // The recovery codes (present only on success)
+}
@@ -0,0 +1,17 @@
+package sdk
+
+// Params of the generic OAuth2 auth provider.
+const (
+	OAuth2ParamClientId         = "@OAUTH2/CLIENT_ID"         // OAuth2 client id at the provider
+	OAuth2ParamClientSecret     = "@OAUTH2/CLIENT_SECRET"     // OAuth2 client secret at the provider
+	OAuth2ParamRedirectUrl      = "@OAUTH2/REDIRECT_URL"      // Callback url of go-iam registered with the provider
+	OAuth2ParamAuthorizationUrl = "@OAUTH2/AUTHORIZATION_URL" // Authorization endpoint the users are sent to
+	OAuth2ParamTokenUrl         = "@OAUTH2/TOKEN_URL"         // Token endpoint the codes are exchanged at
+	OAuth2ParamUserinfoUrl      = "@OAUTH2/USERINFO_URL"      // Endpoint returning the profile of the user as a json object, like https://gitlab.com/api/v4/user
+	OAuth2ParamScopes           = "@OAUTH2/SCOPES"            // Space separated scopes requested (optional)
+	OAuth2ParamAuthParams       = "@OAUTH2/AUTH_PARAMS"       // Json object of the extra query params of the authorization url, like {"prompt": "consent"} (optional)
+	OAuth2ParamClaimMapping     = "@OAUTH2/CLAIM_MAPPING"     // Json object mapping subject, email, name, phone and picture to paths in the userinfo response (optional)
+	OAuth2ParamEmailsUrl        = "@OAUTH2/EMAILS_URL"        // Endpoint listing the email addresses of the user, the primary verified one is used as the email (optional)
+	OAuth2ParamEmailsMapping    = "@OAUTH2/EMAILS_MAPPING"    // Json object mapping list, email, primary and verified to paths in the emails response (optional)
+	OAuth2ParamRefreshEnabled   = "@OAUTH2/REFRESH_ENABLED"   // "true" when the provider issues refresh tokens with expiring access tokens
+)
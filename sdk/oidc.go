@@ -0,0 +1,79 @@
+package sdk
+
+import "errors"
+
+// ErrInvalidIdToken is returned when the id token returned by an OIDC provider fails the validation.
+var ErrInvalidIdToken = errors.New("invalid id token")
+
+// Params of the OIDC auth provider.
+const (
+	OidcParamClientId         = "@OIDC/CLIENT_ID"         // OAuth2 client id at the provider
+	OidcParamClientSecret     = "@OIDC/CLIENT_SECRET"     // OAuth2 client secret at the provider
+	OidcParamRedirectUrl      = "@OIDC/REDIRECT_URL"      // Callback url of go-iam registered with the provider
+	OidcParamIssuer           = "@OIDC/ISSUER"            // Issuer of the provider, its endpoints and signing keys are discovered from <issuer>/.well-known/openid-configuration
+	OidcParamAuthorizationUrl = "@OIDC/AUTHORIZATION_URL" // Authorization endpoint, overrides the discovered one
+	OidcParamTokenUrl         = "@OIDC/TOKEN_URL"         // Token endpoint, overrides the discovered one
+	OidcParamUserinfoUrl      = "@OIDC/USERINFO_URL"      // Userinfo endpoint, overrides the discovered one
+	OidcParamScopes           = "@OIDC/SCOPES"            // Space separated scopes requested, openid profile email when empty. openid is always requested
+	OidcParamGroupsClaim      = "@OIDC/GROUPS_CLAIM"      // Dot separated path of the claim of the userinfo listing the groups of the user, like groups or realm_access.roles
+)
+
+// Jwk represents a single JSON Web Key as defined in RFC 7517.
+// Only the members required for publishing public signing keys are included.
+type Jwk struct {
+	Kty string `json:"kty"`           // Key type (e.g. "RSA")
+	Use string `json:"use,omitempty"` // Intended use of the key (e.g. "sig")
+	Kid string `json:"kid"`           // Key identifier matched against the token header
+	Alg string `json:"alg,omitempty"` // Algorithm the key is used with (e.g. "RS256")
+	N   string `json:"n,omitempty"`   // RSA modulus, base64url encoded
+	E   string `json:"e,omitempty"`   // RSA public exponent, base64url encoded
+	Crv string `json:"crv,omitempty"` // Curve of EC and OKP keys (e.g. "P-256", "Ed25519")
+	X   string `json:"x,omitempty"`   // X coordinate of EC keys or the public key of OKP keys, base64url encoded
+	Y   string `json:"y,omitempty"`   // Y coordinate of EC keys, base64url encoded
+}
+
+// Jwks represents a JSON Web Key Set published at the jwks_uri.
+type Jwks struct {
+	Keys []Jwk `json:"keys"` // Public keys that can be used to verify tokens
+}
+
+// OpenIdConfiguration represents the OpenID Connect discovery document
+// served at /.well-known/openid-configuration.
+type OpenIdConfiguration struct {
+	Issuer                            string   `json:"issuer"`                                // Issuer identifier
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`                // URL of the login endpoint
+	TokenEndpoint                     string   `json:"token_endpoint"`                        // URL of the token endpoint
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`                     // URL of the userinfo endpoint
+	JwksUri                           string   `json:"jwks_uri"`                              // URL of the JSON Web Key Set
+	RevocationEndpoint                string   `json:"revocation_endpoint"`                   // URL of the token revocation endpoint
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`                // URL of the token introspection endpoint
+	EndSessionEndpoint                string   `json:"end_session_endpoint"`                  // URL of the logout endpoint
+	ResponseTypesSupported            []string `json:"response_types_supported"`              // Supported OAuth2 response types
+	SubjectTypesSupported             []string `json:"subject_types_supported"`               // Supported subject identifier types
+	IdTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"` // Algorithms used for signing ID tokens
+	ScopesSupported                   []string `json:"scopes_supported"`                      // Supported scopes
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"` // Supported client authentication methods
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`      // Supported PKCE code challenge methods
+	ClaimsSupported                   []string `json:"claims_supported"`                      // Claims that can be present in ID tokens and userinfo
+	AcrValuesSupported                []string `json:"acr_values_supported"`                  // Authentication context classes that can be asked for with acr_values
+}
+
+// UserInfo represents the standard claims returned by the OpenID Connect userinfo endpoint.
+type UserInfo struct {
+	Sub         string `json:"sub"`                    // Subject identifier - the Go IAM user id
+	Email       string `json:"email,omitempty"`        // Email address of the user
+	Name        string `json:"name,omitempty"`         // Full name of the user
+	Picture     string `json:"picture,omitempty"`      // URL of the user's profile picture
+	PhoneNumber string `json:"phone_number,omitempty"` // Phone number of the user
+}
+
+// NewUserInfo builds the userinfo claims for the given user.
+func NewUserInfo(u User) UserInfo {
+	return UserInfo{
+		Sub:         u.Id,
+		Email:       u.Email,
+		Name:        u.Name,
+		Picture:     u.ProfilePic,
+		PhoneNumber: u.Phone,
+	}
+}
@@ -0,0 +1,215 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrPasskeysNotConfigured is returned when the project of the user has no relying party for the passkeys.
+var ErrPasskeysNotConfigured = errors.New("passkeys are not configured for the project")
+
+// ErrPasskeyNotFound is returned when a passkey does not exist or belongs to another user.
+var ErrPasskeyNotFound = errors.New("passkey not found")
+
+// ErrInvalidPasskey is returned when the response of the authenticator fails the verification.
+var ErrInvalidPasskey = errors.New("passkey verification failed")
+
+// ErrInvalidPasskeyChallenge is returned when a passkey ceremony was not started or has expired.
+var ErrInvalidPasskeyChallenge = errors.New("invalid or expired passkey challenge")
+
+// ErrNotPasskeyProvider is returned when a passkey login is used with an auth provider of another type.
+var ErrNotPasskeyProvider = errors.New("the auth provider does not support passkey login")
+
+// Params of the passkey auth provider.
+const (
+	PasskeyParamLoginUrl = "@PASSKEY/LOGIN_URL" // Login page running the passkey ceremony, it receives the state of the login in the query
+)
+
+// WebAuthnConfig is the relying party the passkeys of the users of a project are bound to.
+type WebAuthnConfig struct {
+	RpId    string   `json:"rp_id"`             // Domain of the relying party, like example.com. Passkeys only work on this domain and its subdomains
+	RpName  string   `json:"rp_name,omitempty"` // Name shown by the authenticators, the project name when empty
+	Origins []string `json:"origins"`           // Origins of the pages allowed to run the ceremonies, like https://login.example.com
+}
+
+// ValidateWebAuthn checks that the origins of the relying party of the project are on its domain.
+func (p Project) ValidateWebAuthn() error {
+	if p.WebAuthn == nil {
+		return nil
+	}
+	rpId := p.WebAuthn.RpId
+	if len(rpId) == 0 || strings.ContainsAny(rpId, ":/") {
+		return fmt.Errorf("webauthn rp_id has to be a domain")
+	}
+	if len(p.WebAuthn.Origins) == 0 {
+		return fmt.Errorf("webauthn needs at least one origin")
+	}
+	for _, o := range p.WebAuthn.Origins {
+		u, err := url.Parse(o)
+		if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+			return fmt.Errorf("webauthn origin %s is not a valid origin", o)
+		}
+		host := u.Hostname()
+		if host != rpId && !strings.HasSuffix(host, "."+rpId) {
+			return fmt.Errorf("webauthn origin %s is not on the domain %s", o, rpId)
+		}
+	}
+	return nil
+}
+
+// Passkey is a WebAuthn credential registered by a user.
+type Passkey struct {
+	Id           string     `json:"id"`            // Unique identifier of the passkey
+	UserId       string     `json:"user_id"`       // User the passkey belongs to
+	ProjectId    string     `json:"project_id"`    // Project of the user
+	CredentialId string     `json:"credential_id"` // Base64url credential id given by the authenticator
+	PublicKey    []byte     `json:"-"`             // COSE encoded public key of the credential
+	SignCount    uint32     `json:"-"`             // Signature counter of the authenticator, used to detect cloned authenticators
+	Transports   []string   `json:"transports"`    // Transports of the authenticator, like usb or internal
+	Name         string     `json:"name"`          // Friendly name given by the user
+	CreatedAt    *time.Time `json:"created_at"`    // Timestamp when the passkey was registered
+	LastUsedAt   *time.Time `json:"last_used_at"`  // Timestamp when the passkey was last used
+}
+
+// WebAuthnRelyingParty identifies go-iam to the authenticator.
+type WebAuthnRelyingParty struct {
+	Id   string `json:"id"`   // Domain of the relying party
+	Name string `json:"name"` // Name shown by the authenticator
+}
+
+// WebAuthnUser identifies the user to the authenticator.
+type WebAuthnUser struct {
+	Id          string `json:"id"`           // Base64url user handle
+	Name        string `json:"name"`         // Email or phone number of the user
+	DisplayName string `json:"display_name"` // Name of the user
+}
+
+// WebAuthnCredentialParam is an algorithm accepted for new credentials.
+type WebAuthnCredentialParam struct {
+	Type string `json:"type"` // Always public-key
+	Alg  int    `json:"alg"`  // COSE algorithm identifier
+}
+
+// WebAuthnCredentialDescriptor refers to an existing credential.
+type WebAuthnCredentialDescriptor struct {
+	Type       string   `json:"type"`                 // Always public-key
+	Id         string   `json:"id"`                   // Base64url credential id
+	Transports []string `json:"transports,omitempty"` // Transports of the authenticator
+}
+
+// WebAuthnAuthenticatorSelection states the requirements on the authenticator.
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"resident_key"`      // Passkeys are discoverable credentials, so it is always required
+	UserVerification string `json:"user_verification"` // The user has to unlock the authenticator, so it is always required
+}
+
+// WebAuthnCreationOptions are passed to navigator.credentials.create to register a passkey.
+// Binary values are base64url encoded and have to be decoded by the page.
+type WebAuthnCreationOptions struct {
+	Challenge              string                         `json:"challenge"`                     // Base64url challenge signed by the authenticator
+	Rp                     WebAuthnRelyingParty           `json:"rp"`                            // Relying party of the project
+	User                   WebAuthnUser                   `json:"user"`                          // User registering the passkey
+	PubKeyCredParams       []WebAuthnCredentialParam      `json:"pub_key_cred_params"`           // Accepted algorithms, by preference
+	Timeout                int64                          `json:"timeout"`                       // Time in milliseconds to complete the ceremony
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"exclude_credentials,omitempty"` // Passkeys the user already has
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticator_selection"`       // Requirements on the authenticator
+	Attestation            string                         `json:"attestation"`                   // Always none, attestation statements are not verified
+}
+
+// WebAuthnRequestOptions are passed to navigator.credentials.get to log in with a passkey.
+// Binary values are base64url encoded and have to be decoded by the page.
+type WebAuthnRequestOptions struct {
+	Challenge        string                         `json:"challenge"`                   // Base64url challenge signed by the authenticator
+	RpId             string                         `json:"rp_id"`                       // Domain of the relying party
+	Timeout          int64                          `json:"timeout"`                     // Time in milliseconds to complete the ceremony
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allow_credentials,omitempty"` // Passkeys of the user, empty to let the user pick any passkey of the site
+	UserVerification string                         `json:"user_verification"`           // Always required
+}
+
+// WebAuthnAttestation is the credential returned by navigator.credentials.create, with the binary values base64url encoded.
+type WebAuthnAttestation struct {
+	Id       string                      `json:"id"`       // Base64url credential id
+	Type     string                      `json:"type"`     // Always public-key
+	Response WebAuthnAttestationResponse `json:"response"` // Response of the authenticator
+}
+
+// WebAuthnAttestationResponse is the response of the authenticator to a registration.
+type WebAuthnAttestationResponse struct {
+	ClientDataJSON    string   `json:"client_data_json"`     // Base64url client data
+	AttestationObject string   `json:"attestation_object"`   // Base64url CBOR attestation object
+	Transports        []string `json:"transports,omitempty"` // Result of getTransports()
+}
+
+// WebAuthnAssertion is the credential returned by navigator.credentials.get, with the binary values base64url encoded.
+type WebAuthnAssertion struct {
+	Id       string                    `json:"id"`       // Base64url credential id
+	Type     string                    `json:"type"`     // Always public-key
+	Response WebAuthnAssertionResponse `json:"response"` // Response of the authenticator
+}
+
+// WebAuthnAssertionResponse is the response of the authenticator to a login.
+type WebAuthnAssertionResponse struct {
+	ClientDataJSON    string `json:"client_data_json"`      // Base64url client data
+	AuthenticatorData string `json:"authenticator_data"`    // Base64url authenticator data
+	Signature         string `json:"signature"`             // Base64url signature
+	UserHandle        string `json:"user_handle,omitempty"` // Base64url user handle of discoverable credentials
+}
+
+// PasskeyRegisterRequest completes the registration of a passkey of the current user.
+type PasskeyRegisterRequest struct {
+	Name       string              `json:"name"`       // Friendly name of the passkey, like "Work laptop"
+	Credential WebAuthnAttestation `json:"credential"` // Credential returned by the browser
+}
+
+// PasskeyLoginStartRequest is submitted by the login page of a passkey auth provider to start the ceremony.
+type PasskeyLoginStartRequest struct {
+	State string `json:"state"` // State passed to the login page
+}
+
+// PasskeyLoginVerifyRequest completes a passkey login.
+type PasskeyLoginVerifyRequest struct {
+	State      string            `json:"state"`      // State passed to the login page
+	Credential WebAuthnAssertion `json:"credential"` // Credential returned by the browser
+}
+
+// MfaPasskeyStartRequest starts answering the mfa challenge of a login with a passkey.
+type MfaPasskeyStartRequest struct {
+	MfaChallenge string `json:"mfa_challenge"` // Challenge the mfa page received in the query
+}
+
+// MfaPasskeyVerifyRequest answers the mfa challenge of a login with a passkey.
+type MfaPasskeyVerifyRequest struct {
+	MfaChallenge string            `json:"mfa_challenge"` // Challenge the mfa page received in the query
+	Credential   WebAuthnAssertion `json:"credential"`    // Credential returned by the browser
+}
+
+// WebAuthnCreationOptionsResponse represents an API response containing the options to register a passkey.
+type WebAuthnCreationOptionsResponse struct {
+	Success bool                     `json:"success"`        // Indicates if the operation was successful
+	Message string                   `json:"message"`        // Human-readable message about the operation
+	Data    *WebAuthnCreationOptions `json:"data,omitempty"` // The options for navigator.credentials.create
+}
+
+// WebAuthnRequestOptionsResponse represents an API response containing the options to log in with a passkey.
+type WebAuthnRequestOptionsResponse struct {
+	Success bool                    `json:"success"`        // Indicates if the operation was successful
+	Message string                  `json:"message"`        // Human-readable message about the operation
+	Data    *WebAuthnRequestOptions `json:"data,omitempty"` // The options for navigator.credentials.get
+}
+
+// PasskeyResponse represents an API response containing a single passkey.
+type PasskeyResponse struct {
+	Success bool     `json:"success"`        // Indicates if the operation was successful
+	Message string   `json:"message"`        // Human-readable message about the operation
+	Data    *Passkey `json:"data,omitempty"` // The passkey (present only on success)
+}
+
+// PasskeysResponse represents an API response containing the passkeys of the current user.
+type PasskeysResponse struct {
+	Success bool      `json:"success"`        // Indicates if the operation was successful
+	Message string    `json:"message"`        // Human-readable message about the operation
+	Data    []Passkey `json:"data,omitempty"` // The passkeys
+}
@@ -0,0 +1,165 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalidCredentials is returned when the email or the password of a login is wrong.
+// It does not tell which of the two was wrong, so that accounts cannot be enumerated.
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ErrPasswordCredentialNotFound is returned when no password is set for the email in the project.
+var ErrPasswordCredentialNotFound = errors.New("password credential not found")
+
+// ErrTooManyLoginAttempts is returned when too many logins failed for an account or from an IP address within a short time.
+var ErrTooManyLoginAttempts = errors.New("too many failed login attempts, try again later")
+
+// ErrWeakPassword is returned when a password does not satisfy the password policy of the project.
+var ErrWeakPassword = errors.New("password does not satisfy the password policy")
+
+// ErrSignupDisabled is returned on signup when the auth provider does not allow users to sign up.
+var ErrSignupDisabled = errors.New("signup is disabled for the auth provider")
+
+// ErrInvalidPasswordResetToken is returned when a password reset token is unknown, expired or already used.
+var ErrInvalidPasswordResetToken = errors.New("invalid or expired password reset token")
+
+// ErrEmailNotVerified is returned on login until the user opens the verification link sent at signup.
+var ErrEmailNotVerified = errors.New("email address is not verified")
+
+// ErrInvalidVerificationToken is returned when an email verification token is unknown, expired or already used.
+var ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+// ErrInvalidEmail is returned when an email address given by a user cannot be parsed.
+var ErrInvalidEmail = errors.New("invalid email address")
+
+// ErrNotPasswordProvider is returned when a password flow is used with an auth provider of another type.
+var ErrNotPasswordProvider = errors.New("the auth provider does not support passwords")
+
+// Params of the password auth provider.
+const (
+	PasswordParamLoginUrl      = "@PASSWORD/LOGIN_URL"      // Login page, it receives the state of the login in the query
+	PasswordParamSignupEnabled = "@PASSWORD/SIGNUP_ENABLED" // "true" allows new users to sign up
+	PasswordParamVerifyUrl     = "@PASSWORD/VERIFY_URL"     // Page opened from the email verification link, it receives the token in the query
+	PasswordParamResetUrl      = "@PASSWORD/RESET_URL"      // Page opened from the password reset link, it receives the token in the query
+)
+
+// MaxPasswordLength is the longest password accepted. Longer passwords are truncated by bcrypt.
+const MaxPasswordLength = 72
+
+// PasswordPolicy defines the rules the passwords of the users of a project have to follow.
+type PasswordPolicy struct {
+	MinLength        int  `json:"min_length"`        // Minimum number of characters
+	RequireUppercase bool `json:"require_uppercase"` // Whether an uppercase letter is required
+	RequireLowercase bool `json:"require_lowercase"` // Whether a lowercase letter is required
+	RequireNumber    bool `json:"require_number"`    // Whether a digit is required
+	RequireSymbol    bool `json:"require_symbol"`    // Whether a symbol or punctuation character is required
+}
+
+// DefaultPasswordPolicy is used for the projects that do not define a password policy.
+var DefaultPasswordPolicy = PasswordPolicy{MinLength: 8}
+
+// Check returns ErrWeakPassword describing the first rule of the policy the password breaks.
+func (p PasswordPolicy) Check(password string) error {
+	length := len([]rune(password))
+	if length < p.MinLength {
+		return fmt.Errorf("%w: it has to be at least %d characters long", ErrWeakPassword, p.MinLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("%w: it has to be at most %d bytes long", ErrWeakPassword, MaxPasswordLength)
+	}
+	if p.RequireUppercase && strings.IndexFunc(password, unicode.IsUpper) < 0 {
+		return fmt.Errorf("%w: it has to contain an uppercase letter", ErrWeakPassword)
+	}
+	if p.RequireLowercase && strings.IndexFunc(password, unicode.IsLower) < 0 {
+		return fmt.Errorf("%w: it has to contain a lowercase letter", ErrWeakPassword)
+	}
+	if p.RequireNumber && strings.IndexFunc(password, unicode.IsDigit) < 0 {
+		return fmt.Errorf("%w: it has to contain a number", ErrWeakPassword)
+	}
+	isSymbol := func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) }
+	if p.RequireSymbol && strings.IndexFunc(password, isSymbol) < 0 {
+		return fmt.Errorf("%w: it has to contain a symbol", ErrWeakPassword)
+	}
+	return nil
+}
+
+// GetPasswordPolicy returns the password policy of the project, or the default one if it has none.
+func (p Project) GetPasswordPolicy() PasswordPolicy {
+	if p.PasswordPolicy == nil {
+		return DefaultPasswordPolicy
+	}
+	return *p.PasswordPolicy
+}
+
+// ValidatePasswordPolicy checks that the password policy of the project can be satisfied.
+func (p Project) ValidatePasswordPolicy() error {
+	if p.PasswordPolicy == nil {
+		return nil
+	}
+	if p.PasswordPolicy.MinLength < 1 || p.PasswordPolicy.MinLength > MaxPasswordLength {
+		return fmt.Errorf("password policy min_length has to be between 1 and %d", MaxPasswordLength)
+	}
+	return nil
+}
+
+// PasswordCredential is the password of a user of a project.
+// It is stored apart from the user, keyed by the project and the email address.
+type PasswordCredential struct {
+	Id             string     `json:"id"`               // Unique identifier of the credential
+	ProjectId      string     `json:"project_id"`       // Project the credential belongs to
+	AuthProviderId string     `json:"auth_provider_id"` // Password auth provider the user signed up with
+	Email          string     `json:"email"`            // Email address of the user, stored in lower case
+	Name           string     `json:"name"`             // Name given by the user at signup
+	PasswordHash   string     `json:"-"`                // bcrypt hash of the password, never serialized
+	VerifiedAt     *time.Time `json:"verified_at"`      // Timestamp when the user proved they own the email address
+	CreatedAt      *time.Time `json:"created_at"`       // Timestamp when the credential was created
+	UpdatedAt      *time.Time `json:"updated_at"`       // Timestamp when the password was last changed
+}
+
+// PasswordLoginRequest is submitted by the login page of a password auth provider.
+type PasswordLoginRequest struct {
+	State    string `json:"state"`    // State passed to the login page by the login url
+	Email    string `json:"email"`    // Email address of the user
+	Password string `json:"password"` // Password of the user
+}
+
+// PasswordSignupRequest is submitted by the signup page of a password auth provider.
+type PasswordSignupRequest struct {
+	State    string `json:"state"`    // State passed to the login page by the login url
+	Email    string `json:"email"`    // Email address of the new user
+	Name     string `json:"name"`     // Name of the new user
+	Password string `json:"password"` // Password of the new user
+}
+
+// PasswordForgotRequest asks for a password reset link to be emailed to the user.
+type PasswordForgotRequest struct {
+	State string `json:"state"` // State passed to the login page by the login url
+	Email string `json:"email"` // Email address of the user
+}
+
+// PasswordResetRequest sets a new password using the token of a password reset link.
+type PasswordResetRequest struct {
+	Token    string `json:"token"`    // Token of the password reset link
+	Password string `json:"password"` // New password
+}
+
+// PasswordVerifyRequest verifies the email address of a new user using the token of the verification link.
+type PasswordVerifyRequest struct {
+	Token string `json:"token"` // Token of the verification link
+}
+
+// PasswordChangeRequest changes the password of the current user.
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"current_password"` // Password the user has now
+	NewPassword     string `json:"new_password"`     // Password to set
+}
+
+// PasswordResponse represents an API response of the password flows that return no data.
+type PasswordResponse struct {
+	Success bool   `json:"success"` // Indicates if the operation was successful
+	Message string `json:"message"` // Human-readable message about the operation
+}
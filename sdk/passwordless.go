@@ -0,0 +1,49 @@
+package sdk
+
+import "errors"
+
+// ErrNotPasswordlessProvider is returned when a passwordless flow is used with an auth provider of another type.
+var ErrNotPasswordlessProvider = errors.New("the auth provider does not support passwordless login")
+
+// ErrInvalidOtp is returned when a login code or a magic link is wrong, expired or already used.
+var ErrInvalidOtp = errors.New("invalid or expired login code")
+
+// ErrOtpAttemptsExceeded is returned when a login code was guessed wrong too many times. A new code has to be requested.
+var ErrOtpAttemptsExceeded = errors.New("too many wrong attempts for the login code")
+
+// ErrTooManyOtpRequests is returned when too many login codes were requested for an email or from an IP address.
+var ErrTooManyOtpRequests = errors.New("too many login codes requested, try again later")
+
+// Params of the passwordless auth provider.
+const (
+	PasswordlessParamLoginUrl      = "@PASSWORDLESS/LOGIN_URL"      // Login page asking for the email, it receives the state of the login in the query
+	PasswordlessParamMethod        = "@PASSWORDLESS/METHOD"         // "code" emails a 6 digit code (default), "link" emails a magic link
+	PasswordlessParamLinkUrl       = "@PASSWORDLESS/LINK_URL"       // Page opened from the magic link, it receives the token in the query. Required for the link method
+	PasswordlessParamEmailSubject  = "@PASSWORDLESS/EMAIL_SUBJECT"  // Subject of the email (optional)
+	PasswordlessParamEmailTemplate = "@PASSWORDLESS/EMAIL_TEMPLATE" // text/template of the email body with .Code, .Link, .Email and .ExpiresInMinutes (optional)
+)
+
+// Methods of the passwordless auth provider.
+const (
+	PasswordlessMethodCode = "code" // Email a 6 digit code that the user types in the login page
+	PasswordlessMethodLink = "link" // Email a magic link that logs the user in when opened
+)
+
+// PasswordlessStartRequest is submitted by the login page of a passwordless auth provider to email a login code or link.
+type PasswordlessStartRequest struct {
+	State string `json:"state"` // State passed to the login page by the login url
+	Email string `json:"email"` // Email address of the user
+}
+
+// PasswordlessVerifyRequest completes a passwordless or sms login with either the emailed code or the token of the magic link.
+type PasswordlessVerifyRequest struct {
+	State string `json:"state"` // State of the login, required with the code
+	Code  string `json:"code"`  // 6 digit code from the email or the sms
+	Token string `json:"token"` // Token of the magic link
+}
+
+// PasswordlessResponse represents an API response of the passwordless flows that return no data.
+type PasswordlessResponse struct {
+	Success bool   `json:"success"` // Indicates if the operation was successful
+	Message string `json:"message"` // Human-readable message about the operation
+}
@@ -0,0 +1,20 @@
+package sdk
+
+import "errors"
+
+// Errors returned while validating the PKCE parameters of a login request.
+var (
+	// ErrPkceRequired is returned when a client that requires PKCE logs in without a code challenge.
+	ErrPkceRequired = errors.New("code challenge is required for the client")
+
+	// ErrInvalidCodeChallenge is returned for malformed code challenges or methods the client is not allowed to use.
+	ErrInvalidCodeChallenge = errors.New("invalid code challenge")
+)
+
+const (
+	// CodeChallengeMethodS256 derives the challenge as BASE64URL(SHA256(code_verifier)).
+	CodeChallengeMethodS256 = "S256"
+
+	// CodeChallengeMethodPlain uses the code verifier as the challenge. It has to be enabled per client.
+	CodeChallengeMethodPlain = "plain"
+)
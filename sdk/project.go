@@ -12,14 +12,19 @@ var ErrProjectNotFound = errors.New("project not found")
 // Projects provide multi-tenant isolation, ensuring that users, clients,
 // and other resources are scoped to specific organizational units.
 type Project struct {
-	Id          string     `json:"id"`          // Unique identifier for the project
-	Name        string     `json:"name"`        // Display name of the project
-	Tags        []string   `json:"tags"`        // Tags for categorizing the project
-	Description string     `json:"description"` // Description of the project's purpose
-	CreatedAt   *time.Time `json:"created_at"`  // Timestamp when project was created
-	CreatedBy   string     `json:"created_by"`  // ID of the user who created this project
-	UpdatedAt   *time.Time `json:"updated_at"`  // Timestamp when project was last updated
-	UpdatedBy   string     `json:"updated_by"`  // ID of the user who last updated this project
+	Id             string          `json:"id"`                        // Unique identifier for the project
+	Name           string          `json:"name"`                      // Display name of the project
+	Tags           []string        `json:"tags"`                      // Tags for categorizing the project
+	Description    string          `json:"description"`               // Description of the project's purpose
+	Scopes         []ProjectScope  `json:"scopes"`                    // Scopes defined for the clients of the project
+	PasswordPolicy *PasswordPolicy `json:"password_policy,omitempty"` // Rules for the passwords of the users, the default policy applies when empty
+	MfaPolicy      *MfaPolicy      `json:"mfa_policy,omitempty"`      // Which users have to use a second factor, it is optional for everyone when empty
+	WebAuthn       *WebAuthnConfig `json:"webauthn,omitempty"`        // Relying party of the passkeys of the users, passkeys are unavailable when empty
+	LoginRouting   *LoginRouting   `json:"login_routing,omitempty"`   // Auth providers of the email domains of the users, logins use the default provider of the client when empty
+	CreatedAt      *time.Time      `json:"created_at"`                // Timestamp when project was created
+	CreatedBy      string          `json:"created_by"`                // ID of the user who created this project
+	UpdatedAt      *time.Time      `json:"updated_at"`                // Timestamp when project was last updated
+	UpdatedBy      string          `json:"updated_by"`                // ID of the user who last updated this project
 }
 
 // ProjectResponse represents an API response containing a single project.
@@ -0,0 +1,33 @@
+package sdk
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRefreshTokenNotFound is returned when no refresh token matches the presented value.
+var ErrRefreshTokenNotFound = errors.New("refresh token not found")
+
+// ErrRefreshTokenInvalid is returned when a refresh token is unknown, expired, revoked
+// or was issued to a different client.
+var ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+
+// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again.
+// The whole token family is revoked when this happens.
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected. all tokens of the session are revoked")
+
+// RefreshToken represents a refresh token issued by go-iam.
+// Only the hash of the token is persisted. Every use rotates the token and the
+// successor joins the same family, so that a replayed token can revoke the whole session.
+type RefreshToken struct {
+	Id        string     `json:"id"`         // Unique identifier of the refresh token
+	FamilyId  string     `json:"family_id"`  // Identifier shared by all tokens rotated from the same login
+	ClientId  string     `json:"client_id"`  // Client the token was issued to
+	UserId    string     `json:"user_id"`    // User the token was issued for
+	TokenHash string     `json:"-"`          // SHA-256 hash of the raw token
+	AuthToken AuthToken  `json:"-"`          // Session details used to mint new access tokens
+	ExpiresAt *time.Time `json:"expires_at"` // Time after which the token can no longer be used
+	CreatedAt *time.Time `json:"created_at"` // Time at which the token was issued
+	UsedAt    *time.Time `json:"used_at"`    // Time at which the token was rotated
+	RevokedAt *time.Time `json:"revoked_at"` // Time at which the token was revoked
+}
@@ -0,0 +1,24 @@
+package sdk
+
+import "errors"
+
+// ErrInvalidSamlResponse is returned when the SAML response posted by the IdP fails the validation.
+var ErrInvalidSamlResponse = errors.New("invalid saml response")
+
+// ErrNotSamlProvider is returned when a SAML response or metadata is requested for an auth provider of another type.
+var ErrNotSamlProvider = errors.New("the auth provider is not a saml provider")
+
+// Params of the SAML auth provider.
+const (
+	SamlParamIdpMetadata      = "@SAML/IDP_METADATA"      // Metadata xml of the IdP, with its entity id, single sign-on url and signing certificates
+	SamlParamAcsUrl           = "@SAML/ACS_URL"           // Public url of the assertion consumer service of go-iam, like https://iam.example.com/auth/v1/saml/acs
+	SamlParamEntityId         = "@SAML/ENTITY_ID"         // Entity id of go-iam for the IdP, the acs url when empty
+	SamlParamNameIdFormat     = "@SAML/NAME_ID_FORMAT"    // NameID format requested from the IdP, unspecified when empty
+	SamlParamAttributeMapping = "@SAML/ATTRIBUTE_MAPPING" // Json object mapping email, name, given_name, family_name, phone and picture to the names of the attributes of the IdP
+)
+
+// SamlAcsRequest is the form posted by the browser of the user from the IdP to the assertion consumer service.
+type SamlAcsRequest struct {
+	SamlResponse string `json:"SAMLResponse" form:"SAMLResponse"` // Base64 encoded SAML response
+	RelayState   string `json:"RelayState" form:"RelayState"`     // State of the login sent to the IdP with the authentication request
+}
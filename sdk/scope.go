@@ -0,0 +1,96 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidScope is returned when a requested scope is unknown or not allowed for the client.
+var ErrInvalidScope = errors.New("invalid scope")
+
+// ScopeOpenId marks an OpenID Connect request.
+const ScopeOpenId = "openid"
+
+// StandardScopes are the OpenID Connect scopes every client can request
+// without having them in its allowed scopes.
+var StandardScopes = []string{ScopeOpenId, "email", "profile", "phone"}
+
+// ProjectScope describes a scope that the clients of a project can be allowed to request.
+type ProjectScope struct {
+	Name        string `json:"name"`        // Name of the scope as it is requested by the clients
+	Description string `json:"description"` // Description of the access granted by the scope, shown to the admins and on the consent screen
+}
+
+// ValidateScopes checks that the scopes of the project have unique names without spaces.
+func (p Project) ValidateScopes() error {
+	seen := map[string]bool{}
+	for _, sc := range p.Scopes {
+		if len(sc.Name) == 0 || strings.IndexFunc(sc.Name, unicode.IsSpace) >= 0 {
+			return fmt.Errorf("%w: scope name %q has to be a single word", ErrInvalidScope, sc.Name)
+		}
+		if seen[sc.Name] {
+			return fmt.Errorf("%w: scope %s is defined more than once", ErrInvalidScope, sc.Name)
+		}
+		seen[sc.Name] = true
+	}
+	return nil
+}
+
+// ValidateClientScopes checks that the scopes allowed for a client or requested by it are defined in the project.
+// Standard scopes are always accepted, and so is every scope when the project defines none.
+func (p Project) ValidateClientScopes(scopes []string) error {
+	if err := p.ValidateScopes(); err != nil {
+		return err
+	}
+	if len(p.Scopes) == 0 {
+		return nil
+	}
+	defined := map[string]bool{}
+	for _, sc := range StandardScopes {
+		defined[sc] = true
+	}
+	for _, sc := range p.Scopes {
+		defined[sc.Name] = true
+	}
+	for _, sc := range scopes {
+		if !defined[sc] {
+			return fmt.Errorf("%w: %s is not defined in the project", ErrInvalidScope, sc)
+		}
+	}
+	return nil
+}
+
+// DescribeScopes returns the scopes with their descriptions in the project, the description is empty for
+// the scopes the project does not define.
+func (p Project) DescribeScopes(scopes []string) []ProjectScope {
+	descriptions := map[string]string{}
+	for _, sc := range p.Scopes {
+		descriptions[sc.Name] = sc.Description
+	}
+	described := []ProjectScope{}
+	for _, sc := range scopes {
+		described = append(described, ProjectScope{Name: sc, Description: descriptions[sc]})
+	}
+	return described
+}
+
+// ParseScope splits a space delimited scope parameter into its distinct scopes, keeping their order.
+func ParseScope(scope string) []string {
+	scopes := []string{}
+	seen := map[string]bool{}
+	for _, sc := range strings.Fields(scope) {
+		if seen[sc] {
+			continue
+		}
+		seen[sc] = true
+		scopes = append(scopes, sc)
+	}
+	return scopes
+}
+
+// FormatScope joins the scopes into a space delimited scope parameter.
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
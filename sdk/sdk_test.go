@@ -3,6 +3,7 @@ package sdk
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -58,6 +59,26 @@ func TestClient(t *testing.T) {
 		}
 		assert.False(t, client.HasGoIamAuthProvider())
 	})
+
+	t.Run("GetAccessTokenClaims falls back to the default claims", func(t *testing.T) {
+		assert.Equal(t, DefaultAccessTokenClaims, Client{}.GetAccessTokenClaims())
+		mapping := map[string]string{"groups": AccessTokenClaimRoles}
+		assert.Equal(t, mapping, Client{AccessTokenClaims: mapping}.GetAccessTokenClaims())
+	})
+
+	t.Run("ValidateAccessTokenSettings", func(t *testing.T) {
+		assert.NoError(t, Client{}.ValidateAccessTokenSettings())
+		assert.NoError(t, Client{
+			AccessTokenFormat: AccessTokenFormatSelfContained,
+			AccessTokenClaims: map[string]string{"groups": AccessTokenClaimRoles, "mail": AccessTokenClaimEmail},
+		}.ValidateAccessTokenSettings())
+		assert.ErrorIs(t, Client{AccessTokenFormat: "jwe"}.ValidateAccessTokenSettings(), ErrInvalidAccessTokenSettings)
+		assert.ErrorIs(t, Client{AccessTokenClaims: map[string]string{"sub": AccessTokenClaimEmail}}.ValidateAccessTokenSettings(), ErrInvalidAccessTokenSettings)
+		assert.ErrorIs(t, Client{AccessTokenClaims: map[string]string{"amr": AccessTokenClaimRoles}}.ValidateAccessTokenSettings(), ErrInvalidAccessTokenSettings)
+		assert.ErrorIs(t, Client{AccessTokenClaims: map[string]string{"acr": AccessTokenClaimRoles}}.ValidateAccessTokenSettings(), ErrInvalidAccessTokenSettings)
+		assert.ErrorIs(t, Client{AccessTokenClaims: map[string]string{"auth_time": AccessTokenClaimRoles}}.ValidateAccessTokenSettings(), ErrInvalidAccessTokenSettings)
+		assert.ErrorIs(t, Client{AccessTokenClaims: map[string]string{"secret": "secret"}}.ValidateAccessTokenSettings(), ErrInvalidAccessTokenSettings)
+	})
 }
 
 func TestClientErrorResponses(t *testing.T) {
@@ -258,3 +279,144 @@ func TestAuthProvider_GetParam(t *testing.T) {
 		assert.Equal(t, "", provider.GetParam("any-key"))
 	})
 }
+
+func TestScope(t *testing.T) {
+	t.Run("ParseScope drops the duplicates and keeps the order", func(t *testing.T) {
+		assert.Equal(t, []string{"openid", "orders:read", "email"}, ParseScope(" openid orders:read  email openid "))
+		assert.Empty(t, ParseScope(""))
+	})
+
+	t.Run("FormatScope joins the scopes with spaces", func(t *testing.T) {
+		assert.Equal(t, "openid orders:read", FormatScope([]string{"openid", "orders:read"}))
+	})
+
+	t.Run("ValidateScopes", func(t *testing.T) {
+		assert.NoError(t, Project{Scopes: []ProjectScope{{Name: "orders:read"}, {Name: "orders:write"}}}.ValidateScopes())
+		assert.ErrorIs(t, Project{Scopes: []ProjectScope{{Name: ""}}}.ValidateScopes(), ErrInvalidScope)
+		assert.ErrorIs(t, Project{Scopes: []ProjectScope{{Name: "orders read"}}}.ValidateScopes(), ErrInvalidScope)
+		assert.ErrorIs(t, Project{Scopes: []ProjectScope{{Name: "orders:read"}, {Name: "orders:read"}}}.ValidateScopes(), ErrInvalidScope)
+	})
+
+	t.Run("ValidateClientScopes", func(t *testing.T) {
+		p := Project{Scopes: []ProjectScope{{Name: "orders:read"}}}
+		assert.NoError(t, p.ValidateClientScopes([]string{"openid", "orders:read"}))
+		assert.ErrorIs(t, p.ValidateClientScopes([]string{"orders:write"}), ErrInvalidScope)
+		assert.NoError(t, Project{}.ValidateClientScopes([]string{"orders:write"}))
+	})
+
+	t.Run("DescribeScopes", func(t *testing.T) {
+		p := Project{Scopes: []ProjectScope{{Name: "orders:read", Description: "Read your orders"}}}
+		assert.Equal(t, []ProjectScope{{Name: "openid"}, {Name: "orders:read", Description: "Read your orders"}}, p.DescribeScopes([]string{"openid", "orders:read"}))
+	})
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	t.Run("Check enforces each rule", func(t *testing.T) {
+		policy := PasswordPolicy{MinLength: 10, RequireUppercase: true, RequireLowercase: true, RequireNumber: true, RequireSymbol: true}
+		assert.NoError(t, policy.Check("Correct-horse-1"))
+		assert.ErrorIs(t, policy.Check("Short-1"), ErrWeakPassword)
+		assert.ErrorIs(t, policy.Check("correct-horse-1"), ErrWeakPassword)
+		assert.ErrorIs(t, policy.Check("CORRECT-HORSE-1"), ErrWeakPassword)
+		assert.ErrorIs(t, policy.Check("Correct-horse-a"), ErrWeakPassword)
+		assert.ErrorIs(t, policy.Check("Correcthorse12"), ErrWeakPassword)
+		assert.ErrorIs(t, policy.Check("A-1a"+strings.Repeat("x", MaxPasswordLength)), ErrWeakPassword)
+	})
+
+	t.Run("GetPasswordPolicy falls back to the default policy", func(t *testing.T) {
+		assert.Equal(t, DefaultPasswordPolicy, Project{}.GetPasswordPolicy())
+		assert.Equal(t, PasswordPolicy{MinLength: 12}, Project{PasswordPolicy: &PasswordPolicy{MinLength: 12}}.GetPasswordPolicy())
+	})
+
+	t.Run("ValidatePasswordPolicy", func(t *testing.T) {
+		assert.NoError(t, Project{}.ValidatePasswordPolicy())
+		assert.NoError(t, Project{PasswordPolicy: &PasswordPolicy{MinLength: 12}}.ValidatePasswordPolicy())
+		assert.Error(t, Project{PasswordPolicy: &PasswordPolicy{}}.ValidatePasswordPolicy())
+		assert.Error(t, Project{PasswordPolicy: &PasswordPolicy{MinLength: MaxPasswordLength + 1}}.ValidatePasswordPolicy())
+	})
+}
+
+func TestMfaPolicy(t *testing.T) {
+	admin := User{Roles: map[string]UserRole{"role-admin": {Id: "role-admin", Name: "admin"}}}
+
+	t.Run("RequiresMfa by mode", func(t *testing.T) {
+		assert.False(t, MfaPolicy{Mode: MfaModeOptional}.RequiresMfa(admin))
+		assert.True(t, MfaPolicy{Mode: MfaModeRequired}.RequiresMfa(User{}))
+		assert.True(t, MfaPolicy{Mode: MfaModeRoles, RoleIds: []string{"role-admin"}}.RequiresMfa(admin))
+		assert.False(t, MfaPolicy{Mode: MfaModeRoles, RoleIds: []string{"role-admin"}}.RequiresMfa(User{}))
+	})
+
+	t.Run("GetMfaPolicy falls back to the default policy", func(t *testing.T) {
+		assert.Equal(t, DefaultMfaPolicy, Project{}.GetMfaPolicy())
+		assert.Equal(t, MfaPolicy{Mode: MfaModeRequired}, Project{MfaPolicy: &MfaPolicy{Mode: MfaModeRequired}}.GetMfaPolicy())
+	})
+
+	t.Run("ValidateMfaPolicy", func(t *testing.T) {
+		assert.NoError(t, Project{}.ValidateMfaPolicy())
+		assert.NoError(t, Project{MfaPolicy: &MfaPolicy{Mode: MfaModeRequired}}.ValidateMfaPolicy())
+		assert.NoError(t, Project{MfaPolicy: &MfaPolicy{Mode: MfaModeRoles, RoleIds: []string{"role-admin"}}}.ValidateMfaPolicy())
+		assert.Error(t, Project{MfaPolicy: &MfaPolicy{Mode: MfaModeRoles}}.ValidateMfaPolicy())
+		assert.Error(t, Project{MfaPolicy: &MfaPolicy{Mode: "always"}}.ValidateMfaPolicy())
+	})
+}
+
+func TestValidateWebAuthn(t *testing.T) {
+	withWebAuthn := func(rpId string, origins ...string) Project {
+		return Project{WebAuthn: &WebAuthnConfig{RpId: rpId, Origins: origins}}
+	}
+
+	assert.NoError(t, Project{}.ValidateWebAuthn())
+	assert.NoError(t, withWebAuthn("example.com", "https://example.com", "https://login.example.com").ValidateWebAuthn())
+	assert.NoError(t, withWebAuthn("localhost", "http://localhost:4173").ValidateWebAuthn())
+	assert.Error(t, withWebAuthn("", "https://example.com").ValidateWebAuthn())
+	assert.Error(t, withWebAuthn("https://example.com", "https://example.com").ValidateWebAuthn())
+	assert.Error(t, withWebAuthn("example.com").ValidateWebAuthn())
+	assert.Error(t, withWebAuthn("example.com", "example.com").ValidateWebAuthn())
+	assert.Error(t, withWebAuthn("example.com", "https://badexample.com").ValidateWebAuthn())
+}
+
+func TestValidateLoginRouting(t *testing.T) {
+	withRoutes := func(fallback []string, routes ...LoginDomainRoute) Project {
+		return Project{LoginRouting: &LoginRouting{Domains: routes, Fallback: fallback}}
+	}
+
+	assert.NoError(t, Project{}.ValidateLoginRouting())
+	assert.NoError(t, withRoutes([]string{"ap-google"}, LoginDomainRoute{Domain: "acme.com", AuthProviderId: "ap-acme"}).ValidateLoginRouting())
+	assert.NoError(t, withRoutes(nil, LoginDomainRoute{Domain: "@acme.com", AuthProviderId: "ap-acme"}, LoginDomainRoute{Domain: "acme.org", AuthProviderId: "ap-acme"}).ValidateLoginRouting())
+	assert.Error(t, withRoutes(nil, LoginDomainRoute{Domain: "acme.com", AuthProviderId: "ap-acme"}, LoginDomainRoute{Domain: "@ACME.com", AuthProviderId: "ap-other"}).ValidateLoginRouting())
+	assert.Error(t, withRoutes(nil, LoginDomainRoute{Domain: "", AuthProviderId: "ap-acme"}).ValidateLoginRouting())
+	assert.Error(t, withRoutes(nil, LoginDomainRoute{Domain: "https://acme.com", AuthProviderId: "ap-acme"}).ValidateLoginRouting())
+	assert.Error(t, withRoutes(nil, LoginDomainRoute{Domain: "acme.com"}).ValidateLoginRouting())
+	assert.Error(t, withRoutes([]string{""}).ValidateLoginRouting())
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name               string
+		phone              string
+		defaultCountryCode string
+		expected           string
+		expectedErr        bool
+	}{
+		{name: "e164 already", phone: "+919876543210", expected: "+919876543210"},
+		{name: "formatted", phone: "+1 (415) 555-0132", expected: "+14155550132"},
+		{name: "international prefix", phone: "0044 20 7946 0958", expected: "+442079460958"},
+		{name: "national with default country", phone: "098765 43210", defaultCountryCode: "91", expected: "+919876543210"},
+		{name: "default country with plus", phone: "4155550132", defaultCountryCode: "+1", expected: "+14155550132"},
+		{name: "national without default country", phone: "9876543210", expectedErr: true},
+		{name: "letters", phone: "+91987654321a", expectedErr: true},
+		{name: "too long", phone: "+1234567890123456", expectedErr: true},
+		{name: "too short", phone: "+12345", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phone, err := NormalizePhone(tt.phone, tt.defaultCountryCode)
+			if tt.expectedErr {
+				assert.ErrorIs(t, err, ErrInvalidPhone)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, phone)
+		})
+	}
+}
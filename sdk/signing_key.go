@@ -0,0 +1,97 @@
+package sdk
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SigningKeyStatus represents the lifecycle state of a token signing key.
+type SigningKeyStatus string
+
+const (
+	// SigningKeyStatusActive keys are used for signing new tokens and for verification
+	SigningKeyStatusActive SigningKeyStatus = "active"
+	// SigningKeyStatusRotated keys no longer sign tokens but still verify them until ExpiresAt
+	SigningKeyStatusRotated SigningKeyStatus = "rotated"
+	// SigningKeyStatusRetired keys are no longer trusted for anything
+	SigningKeyStatusRetired SigningKeyStatus = "retired"
+)
+
+// Supported asymmetric signing algorithms for tokens.
+const (
+	SigningAlgorithmRS256 = "RS256" // RSA PKCS#1 v1.5 with SHA-256
+	SigningAlgorithmES256 = "ES256" // ECDSA P-256 with SHA-256
+	SigningAlgorithmEdDSA = "EdDSA" // Ed25519
+	SigningAlgorithmHS256 = "HS256" // HMAC with SHA-256 using JWT_SECRET. Legacy mode only
+)
+
+// SigningKey represents a key in the token signing keyset.
+// The key id is sent as the `kid` header of every token signed with it.
+type SigningKey struct {
+	Id         string           `json:"id"`                   // Key identifier used as the kid header
+	Algorithm  string           `json:"algorithm"`            // Signing algorithm (RS256, ES256 or EdDSA)
+	Status     SigningKeyStatus `json:"status"`               // Lifecycle state of the key
+	PublicKey  Jwk              `json:"public_key"`           // Public part of the key as a JSON Web Key
+	PrivateKey MaskedBytes      `json:"-"`                    // PEM encoded private key. Never leaves the server
+	CreatedAt  *time.Time       `json:"created_at"`           // Timestamp when the key was generated
+	RotatedAt  *time.Time       `json:"rotated_at,omitempty"` // Timestamp when the key stopped signing tokens
+	ExpiresAt  *time.Time       `json:"expires_at,omitempty"` // End of the overlap window during which a rotated key still verifies
+	RetiredAt  *time.Time       `json:"retired_at,omitempty"` // Timestamp when the key was retired
+}
+
+// CanVerify reports whether tokens signed with the key are still accepted at the given time.
+func (k SigningKey) CanVerify(now time.Time) bool {
+	switch k.Status {
+	case SigningKeyStatusActive:
+		return true
+	case SigningKeyStatusRotated:
+		return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+	}
+	return false
+}
+
+// SigningKeyResponse represents an API response containing a single signing key.
+type SigningKeyResponse struct {
+	Success bool        `json:"success"` // Indicates if the operation was successful
+	Message string      `json:"message"` // Human-readable message about the operation
+	Data    *SigningKey `json:"data"`    // The signing key data (present only on success)
+}
+
+// SigningKeysResponse represents an API response containing the signing keyset.
+type SigningKeysResponse struct {
+	Success bool         `json:"success"` // Indicates if the operation was successful
+	Message string       `json:"message"` // Human-readable message about the operation
+	Data    []SigningKey `json:"data"`    // Array of signing keys
+}
+
+// ErrSigningKeyNotFound is returned when a requested signing key cannot be found.
+var ErrSigningKeyNotFound = errors.New("signing key not found")
+
+// ErrActiveSigningKey is returned when trying to retire the key currently used for signing.
+var ErrActiveSigningKey = errors.New("active signing key cannot be retired. rotate the keys first")
+
+// NewErrorSigningKeyResponse creates a new error response for signing key operations.
+func NewErrorSigningKeyResponse(msg string, status int, c *fiber.Ctx) error {
+	return c.Status(status).JSON(SigningKeyResponse{
+		Success: false,
+		Message: msg,
+	})
+}
+
+// SigningKeyBadRequest returns a 400 Bad Request error response for signing key operations.
+func SigningKeyBadRequest(msg string, c *fiber.Ctx) error {
+	return NewErrorSigningKeyResponse(msg, http.StatusBadRequest, c)
+}
+
+// SigningKeyNotFound returns a 404 Not Found error response for signing key operations.
+func SigningKeyNotFound(msg string, c *fiber.Ctx) error {
+	return NewErrorSigningKeyResponse(msg, http.StatusNotFound, c)
+}
+
+// SigningKeyInternalServerError returns a 500 Internal Server Error response for signing key operations.
+func SigningKeyInternalServerError(msg string, c *fiber.Ctx) error {
+	return NewErrorSigningKeyResponse(msg, http.StatusInternalServerError, c)
+}
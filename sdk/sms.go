@@ -0,0 +1,59 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidPhone is returned when a phone number cannot be normalized to the E.164 format.
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// ErrNotSmsProvider is returned when an sms flow is used with an auth provider of another type.
+var ErrNotSmsProvider = errors.New("the auth provider does not support sms login")
+
+// ErrOtpResendCooldown is returned when a new login code is requested too soon after the previous one.
+var ErrOtpResendCooldown = errors.New("a login code was sent recently, wait before asking for another one")
+
+// Params of the sms auth provider.
+const (
+	SmsParamLoginUrl           = "@SMS/LOGIN_URL"            // Login page asking for the phone number, it receives the state of the login in the query
+	SmsParamDefaultCountryCode = "@SMS/DEFAULT_COUNTRY_CODE" // Country calling code added to the numbers given without one, like "91" (optional)
+	SmsParamMessageTemplate    = "@SMS/MESSAGE_TEMPLATE"     // text/template of the message with .Code, .Phone and .ExpiresInMinutes (optional)
+)
+
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// NormalizePhone returns the phone number in the E.164 format, like +919876543210.
+// Spaces, dashes, dots and brackets are dropped and a leading 00 is read as +.
+// Numbers without a country calling code get the default one, after dropping the national trunk prefix 0.
+func NormalizePhone(phone, defaultCountryCode string) (string, error) {
+	number := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+
+	switch {
+	case strings.HasPrefix(number, "+"):
+	case strings.HasPrefix(number, "00"):
+		number = "+" + strings.TrimPrefix(number, "00")
+	case len(defaultCountryCode) > 0:
+		number = "+" + strings.TrimPrefix(defaultCountryCode, "+") + strings.TrimPrefix(number, "0")
+	default:
+		return "", fmt.Errorf("%w: %s has no country calling code", ErrInvalidPhone, phone)
+	}
+	if !e164.MatchString(number) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPhone, phone)
+	}
+	return number, nil
+}
+
+// SmsOtpStartRequest is submitted by the login page of an sms auth provider to text a login code.
+type SmsOtpStartRequest struct {
+	State string `json:"state"` // State passed to the login page by the login url
+	Phone string `json:"phone"` // Phone number of the user
+}
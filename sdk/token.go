@@ -0,0 +1,60 @@
+package sdk
+
+import "errors"
+
+// Errors returned by the token endpoint, mapped to the RFC 6749 error codes.
+var (
+	// ErrInvalidClient is returned when the client authentication fails.
+	ErrInvalidClient = errors.New("client authentication failed")
+
+	// ErrInvalidGrant is returned when the code or the refresh token is invalid, expired or issued to another client.
+	ErrInvalidGrant = errors.New("invalid grant")
+
+	// ErrUnauthorizedClient is returned when the client is not allowed to use the grant type.
+	ErrUnauthorizedClient = errors.New("client is not authorized for the grant type")
+
+	// ErrUnsupportedGrantType is returned for grant types the token endpoint does not support.
+	ErrUnsupportedGrantType = errors.New("unsupported grant type")
+)
+
+const (
+	// GrantTypeAuthorizationCode exchanges an authorization code for tokens.
+	GrantTypeAuthorizationCode = "authorization_code"
+
+	// GrantTypeClientCredentials issues tokens for the service account linked to the client.
+	GrantTypeClientCredentials = "client_credentials"
+
+	// GrantTypeRefreshToken exchanges a refresh token for new tokens.
+	GrantTypeRefreshToken = "refresh_token"
+)
+
+// RFC 6749 error codes returned by the token endpoint.
+const (
+	OAuthErrorInvalidRequest       = "invalid_request"
+	OAuthErrorInvalidClient        = "invalid_client"
+	OAuthErrorInvalidGrant         = "invalid_grant"
+	OAuthErrorUnauthorizedClient   = "unauthorized_client"
+	OAuthErrorUnsupportedGrantType = "unsupported_grant_type"
+	OAuthErrorInvalidScope         = "invalid_scope"
+	OAuthErrorServerError          = "server_error"
+)
+
+// TokenRequest represents an RFC 6749 token request.
+// It is sent as application/x-www-form-urlencoded. Clients authenticate either with
+// basic auth (client_secret_basic) or with the credentials in the body (client_secret_post).
+type TokenRequest struct {
+	GrantType    string `json:"grant_type" form:"grant_type"`                 // One of authorization_code, client_credentials or refresh_token
+	Code         string `json:"code,omitempty" form:"code"`                   // Authorization code, for the authorization_code grant
+	RedirectUri  string `json:"redirect_uri,omitempty" form:"redirect_uri"`   // Redirect url used at login, for the authorization_code grant
+	CodeVerifier string `json:"code_verifier,omitempty" form:"code_verifier"` // PKCE code verifier, for the authorization_code grant
+	RefreshToken string `json:"refresh_token,omitempty" form:"refresh_token"` // Refresh token, for the refresh_token grant
+	ClientId     string `json:"client_id,omitempty" form:"client_id"`         // OAuth2 client identifier
+	ClientSecret string `json:"client_secret,omitempty" form:"client_secret"` // OAuth2 client secret for confidential clients
+	Scope        string `json:"scope,omitempty" form:"scope"`                 // Space delimited scopes, for the client_credentials and refresh_token grants
+}
+
+// TokenErrorResponse represents an RFC 6749 error response of the token endpoint.
+type TokenErrorResponse struct {
+	Error            string `json:"error"`                       // RFC 6749 error code
+	ErrorDescription string `json:"error_description,omitempty"` // Human-readable description of the error
+}
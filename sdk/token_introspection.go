@@ -0,0 +1,34 @@
+package sdk
+
+import "errors"
+
+// ErrClientAuthenticationRequired is returned when an endpoint is called without the client secret.
+var ErrClientAuthenticationRequired = errors.New("client authentication is required")
+
+// TokenIntrospectionRequest represents an RFC 7662 token introspection request.
+// Only confidential clients can introspect tokens. The client can authenticate
+// with basic auth instead of the client secret in the body.
+type TokenIntrospectionRequest struct {
+	Token         string `json:"token" form:"token"`                               // The access token to introspect
+	TokenTypeHint string `json:"token_type_hint,omitempty" form:"token_type_hint"` // Optional hint, only access_token is supported
+	ClientId      string `json:"client_id" form:"client_id"`                       // OAuth2 client identifier of the resource server
+	ClientSecret  string `json:"client_secret,omitempty" form:"client_secret"`     // OAuth2 client secret of the resource server
+	IncludeRoles  bool   `json:"include_roles,omitempty" form:"include_roles"`     // Include the roles and resource keys of the user
+}
+
+// TokenIntrospectionResponse represents the RFC 7662 introspection response.
+// Only Active is set for tokens that are unknown, expired, revoked or belong to another project.
+type TokenIntrospectionResponse struct {
+	Active    bool     `json:"active"`               // Whether the token is currently active
+	Sub       string   `json:"sub,omitempty"`        // ID of the user the token was issued for
+	ClientId  string   `json:"client_id,omitempty"`  // Client the token was issued to
+	Exp       int64    `json:"exp,omitempty"`        // Expiry of the token as unix timestamp
+	Iat       int64    `json:"iat,omitempty"`        // Issue time of the token as unix timestamp
+	Scope     string   `json:"scope,omitempty"`      // Space delimited scopes granted to the token
+	TokenType string   `json:"token_type,omitempty"` // Type of the token, always Bearer
+	ProjectId string   `json:"project_id,omitempty"` // Project of the user
+	Roles     []string `json:"roles,omitempty"`      // Names of the roles of the user (only if requested)
+	Resources []string `json:"resources,omitempty"`  // Keys of the resources the user has access to (only if requested)
+	Amr       []string `json:"amr,omitempty"`        // Authentication methods used at login
+	Acr       string   `json:"acr,omitempty"`        // Authentication context class satisfied at login, tells whether a second factor was used
+}
@@ -0,0 +1,44 @@
+package sdk
+
+import "errors"
+
+// ErrTokenRevoked is returned when a token is presented after it was revoked.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// ErrTokenClientMismatch is returned when a client tries to revoke a token issued to another client.
+var ErrTokenClientMismatch = errors.New("token was not issued to the client")
+
+// ErrPostLogoutRedirectUrlNotFound is returned when the post logout redirect url is not registered for the client.
+var ErrPostLogoutRedirectUrlNotFound = errors.New("post logout redirect url not registered for the client")
+
+const (
+	// TokenTypeHintAccessToken hints that the token to revoke is an access token.
+	TokenTypeHintAccessToken = "access_token"
+
+	// TokenTypeHintRefreshToken hints that the token to revoke is a refresh token.
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+// TokenRevocationRequest represents an RFC 7009 token revocation request.
+// It is accepted both as form-urlencoded and JSON body. Confidential clients can
+// authenticate with basic auth instead of the client secret in the body.
+type TokenRevocationRequest struct {
+	Token         string `json:"token" form:"token"`                               // The access or refresh token to revoke
+	TokenTypeHint string `json:"token_type_hint,omitempty" form:"token_type_hint"` // Optional hint, either access_token or refresh_token
+	ClientId      string `json:"client_id" form:"client_id"`                       // OAuth2 client identifier the token was issued to
+	ClientSecret  string `json:"client_secret,omitempty" form:"client_secret"`     // OAuth2 client secret for confidential clients
+}
+
+// TokenRevocationResponse represents the response of a token revocation request.
+// Unknown or already expired tokens are reported as revoked as per RFC 7009.
+type TokenRevocationResponse struct {
+	Success bool   `json:"success"` // Indicates if the revocation was accepted
+	Message string `json:"message"` // Human-readable message about the operation
+}
+
+// LogoutResponse represents the response of a logout request.
+type LogoutResponse struct {
+	Success bool                  `json:"success"`        // Indicates if the session was cleared
+	Message string                `json:"message"`        // Human-readable message about the operation
+	Data    *AuthRedirectResponse `json:"data,omitempty"` // Post logout redirect (present only if requested)
+}
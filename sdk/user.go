@@ -31,22 +31,25 @@ var ErrUserNotFound = errors.New("user not found")
 // permissions within projects. Each user belongs to a specific project
 // and can have roles, resources, and policies assigned to them.
 type User struct {
-	Id             string                  `json:"id"`                         // Unique identifier for the user
-	ProjectId      string                  `json:"project_id"`                 // ID of the project this user belongs to
-	Name           string                  `json:"name"`                       // Display name of the user
-	Email          string                  `json:"email"`                      // Email address (unique within project)
-	Phone          string                  `json:"phone"`                      // Phone number (optional)
-	Enabled        bool                    `json:"enabled"`                    // Whether the user account is active
-	ProfilePic     string                  `json:"profile_pic"`                // URL to the user's profile picture
-	LinkedClientId string                  `json:"linked_client_id,omitempty"` // Associated client ID for service accounts
-	Expiry         *time.Time              `json:"expiry"`                     // Account expiration time (optional)
-	Roles          map[string]UserRole     `json:"roles"`                      // Assigned roles mapped by role ID
-	Resources      map[string]UserResource `json:"resources"`                  // Associated resources mapped by resource key
-	Policies       map[string]UserPolicy   `json:"policies"`                   // Applied policies mapped by policy name
-	CreatedAt      *time.Time              `json:"created_at"`                 // Timestamp when user was created
-	CreatedBy      string                  `json:"created_by"`                 // ID of the user who created this user
-	UpdatedAt      *time.Time              `json:"updated_at"`                 // Timestamp when user was last updated
-	UpdatedBy      string                  `json:"updated_by"`                 // ID of the user who last updated this user
+	Id              string                  `json:"id"`                         // Unique identifier for the user
+	ProjectId       string                  `json:"project_id"`                 // ID of the project this user belongs to
+	Name            string                  `json:"name"`                       // Display name of the user
+	Email           string                  `json:"email"`                      // Email address (unique within project)
+	EmailVerified   bool                    `json:"email_verified"`             // Whether the ownership of the email address was verified
+	Phone           string                  `json:"phone"`                      // Phone number (optional)
+	Enabled         bool                    `json:"enabled"`                    // Whether the user account is active
+	PendingApproval bool                    `json:"pending_approval"`           // Whether the user was created on login and waits for an admin to enable them
+	ProfilePic      string                  `json:"profile_pic"`                // URL to the user's profile picture
+	LinkedClientId  string                  `json:"linked_client_id,omitempty"` // Associated client ID for service accounts
+	Expiry          *time.Time              `json:"expiry"`                     // Account expiration time (optional)
+	Roles           map[string]UserRole     `json:"roles"`                      // Assigned roles mapped by role ID
+	Resources       map[string]UserResource `json:"resources"`                  // Associated resources mapped by resource key
+	Policies        map[string]UserPolicy   `json:"policies"`                   // Applied policies mapped by policy name
+	GroupRoles      map[string][]string     `json:"group_roles,omitempty"`      // Ids of the linked identities whose groups at the auth provider grant the role, mapped by role ID
+	CreatedAt       *time.Time              `json:"created_at"`                 // Timestamp when user was created
+	CreatedBy       string                  `json:"created_by"`                 // ID of the user who created this user
+	UpdatedAt       *time.Time              `json:"updated_at"`                 // Timestamp when user was last updated
+	UpdatedBy       string                  `json:"updated_by"`                 // ID of the user who last updated this user
 }
 
 // UserPolicy represents a policy assigned to a user with optional argument mappings.
@@ -0,0 +1,52 @@
+package sdk
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUserIdentityNotFound is returned when a linked identity does not exist or belongs to another user.
+var ErrUserIdentityNotFound = errors.New("user identity not found")
+
+// ErrUserIdentityLinkedToAnotherUser is returned when linking an identity of an auth provider that already logs in another user.
+var ErrUserIdentityLinkedToAnotherUser = errors.New("the identity is linked to another user")
+
+// ErrUnverifiedEmailLink is returned when a login with an identity of an external auth provider matches an existing user by
+// email address, but the address is not verified on both sides. The user has to log in to their account and link the identity.
+var ErrUnverifiedEmailLink = errors.New("an account exists for the email address but the address is not verified, log in to the account to link the identity")
+
+// ErrUserIdentityUnlinked is returned when logging in with an identity the user unlinked. It is not linked to the
+// user by email address again, the user has to log in to their account and link it.
+var ErrUserIdentityUnlinked = errors.New("the identity was unlinked from its account, log in to the account to link it again")
+
+// UserIdentity is an identity of a user at an auth provider, linked to their go-iam user.
+// Logins with the auth provider find the user by the subject of the identity before falling back to the email address.
+type UserIdentity struct {
+	Id             string     `json:"id"`                    // Unique identifier of the linked identity
+	UserId         string     `json:"user_id"`               // User the identity is linked to
+	ProjectId      string     `json:"project_id"`            // Project of the user
+	AuthProviderId string     `json:"auth_provider_id"`      // Auth provider the identity belongs to
+	Subject        string     `json:"subject"`               // Stable id of the user at the auth provider
+	Email          string     `json:"email,omitempty"`       // Email address reported by the auth provider at the last login
+	EmailVerified  bool       `json:"email_verified"`        // Whether the auth provider reported the email address as verified
+	LastLoginAt    *time.Time `json:"last_login_at"`         // Timestamp of the last login with the identity
+	CreatedAt      *time.Time `json:"created_at"`            // Timestamp when the identity was linked
+	UnlinkedAt     *time.Time `json:"unlinked_at,omitempty"` // Timestamp when the user unlinked the identity, its logins are refused until it is linked again
+}
+
+// UserIdentitiesResponse represents an API response containing the linked identities of a user.
+type UserIdentitiesResponse struct {
+	Success bool           `json:"success"`        // Indicates if the operation was successful
+	Message string         `json:"message"`        // Human-readable message about the operation
+	Data    []UserIdentity `json:"data,omitempty"` // The linked identities
+}
+
+// UserIdentityLinkRequest starts linking an identity of an auth provider to the current user.
+// The user logs in with the auth provider and is sent back to the redirect url of the client,
+// with the id of the linked identity in the linked_identity query param.
+type UserIdentityLinkRequest struct {
+	AuthProviderId string `json:"auth_provider_id"` // Auth provider of the identity to link
+	ClientId       string `json:"client_id"`        // Client the user is sent back to
+	RedirectUrl    string `json:"redirect_url"`     // Redirect url of the client the user is sent back to
+	State          string `json:"state"`            // Opaque state sent back to the client
+}
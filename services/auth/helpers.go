@@ -3,12 +3,21 @@ package auth
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
 	"github.com/melvinodsa/go-iam/sdk"
 )
 
@@ -47,24 +56,1192 @@ func (s *service) handlePrivateClient(ctx context.Context, clientId, clientSecre
 	return nil
 }
 
-func (s *service) handlePublicClient(clientId, codeChallenge string, token sdk.AuthToken) error {
-	// Implement public client handling logic here
-	if token.CodeChallengeMethod != "S256" {
-		return fmt.Errorf("invalid code challenge")
+// validateCodeChallenge checks the PKCE parameters of a login against the client's settings
+// and returns the code challenge method to store with the login
+func validateCodeChallenge(cl sdk.Client, codeChallenge, codeChallengeMethod string) (string, error) {
+	if len(codeChallenge) == 0 {
+		if cl.PkceRequired || cl.Public {
+			return "", sdk.ErrPkceRequired
+		}
+		if len(codeChallengeMethod) != 0 {
+			return "", fmt.Errorf("%w: code challenge method is given without a code challenge", sdk.ErrInvalidCodeChallenge)
+		}
+		return "", nil
 	}
-	calculatedVerifier := generateCodeChallengeS256(token.CodeChallenge)
-	// Verify the code verifier
-	if strings.Compare(calculatedVerifier, codeChallenge) != 0 {
-		log.Debugw("invalid code verifier", "calculated_verifier", calculatedVerifier, "code_challenge", codeChallenge)
-		return fmt.Errorf("invalid code verifier")
+	// the method defaults to plain as per RFC 7636 section 4.3
+	if len(codeChallengeMethod) == 0 {
+		codeChallengeMethod = sdk.CodeChallengeMethodPlain
 	}
-	if strings.Compare(token.ClientId, clientId) != 0 {
-		return fmt.Errorf("invalid client id")
+	switch codeChallengeMethod {
+	case sdk.CodeChallengeMethodS256:
+	case sdk.CodeChallengeMethodPlain:
+		if !cl.PkceAllowPlain {
+			return "", fmt.Errorf("%w: the plain method is not allowed for the client", sdk.ErrInvalidCodeChallenge)
+		}
+	default:
+		return "", fmt.Errorf("%w: unsupported code challenge method %s", sdk.ErrInvalidCodeChallenge, codeChallengeMethod)
+	}
+	if !isValidPkceValue(codeChallenge) {
+		return "", fmt.Errorf("%w: code challenge has to be 43 to 128 unreserved characters", sdk.ErrInvalidCodeChallenge)
+	}
+	return codeChallengeMethod, nil
+}
+
+// verifyCodeVerifier verifies the code verifier sent at the token exchange against the challenge stored with the code
+func verifyCodeVerifier(token sdk.AuthToken, codeVerifier string) error {
+	if len(token.CodeChallenge) == 0 {
+		if len(codeVerifier) != 0 {
+			return fmt.Errorf("code verifier is given but the login had no code challenge")
+		}
+		return nil
+	}
+	if len(codeVerifier) == 0 {
+		return fmt.Errorf("code verifier is required")
+	}
+	if !isValidPkceValue(codeVerifier) {
+		return fmt.Errorf("code verifier has to be 43 to 128 unreserved characters")
+	}
+	var calculated string
+	switch token.CodeChallengeMethod {
+	case sdk.CodeChallengeMethodS256:
+		calculated = generateCodeChallengeS256(codeVerifier)
+	case sdk.CodeChallengeMethodPlain:
+		calculated = codeVerifier
+	default:
+		return fmt.Errorf("unsupported code challenge method %s", token.CodeChallengeMethod)
+	}
+	if subtle.ConstantTimeCompare([]byte(calculated), []byte(token.CodeChallenge)) != 1 {
+		return fmt.Errorf("code verifier does not match the code challenge")
 	}
 	return nil
 }
 
-func generateCodeChallengeS256(codeChallenge string) string {
-	hash := sha256.Sum256([]byte(codeChallenge))
+// isValidPkceValue checks the length and the character set RFC 7636 mandates for code verifiers and challenges
+func isValidPkceValue(value string) bool {
+	if len(value) < 43 || len(value) > 128 {
+		return false
+	}
+	for _, c := range value {
+		isUnreserved := (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '.' || c == '_' || c == '~'
+		if !isUnreserved {
+			return false
+		}
+	}
+	return true
+}
+
+func generateCodeChallengeS256(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
 	return base64.RawURLEncoding.EncodeToString(hash[:])
 }
+
+// idTokenTTL is the validity of the id tokens issued along with the access token
+const idTokenTTL = time.Hour
+
+func (s service) generateIdToken(usr sdk.User, token sdk.AuthToken) (string, error) {
+	/*
+	 * build the standard claims
+	 * sign them with the asymmetric key
+	 */
+	now := time.Now()
+	authTime := token.AuthTime
+	if authTime.IsZero() {
+		authTime = now
+	}
+	claims := map[string]interface{}{
+		"iss":       s.issuer,
+		"sub":       usr.Id,
+		"aud":       token.ClientId,
+		"iat":       now.Unix(),
+		"auth_time": authTime.Unix(),
+	}
+	if len(token.Nonce) > 0 {
+		claims["nonce"] = token.Nonce
+	}
+	addAuthenticationClaims(claims, token)
+	if len(usr.Email) > 0 {
+		claims["email"] = usr.Email
+	}
+	if len(usr.Name) > 0 {
+		claims["name"] = usr.Name
+	}
+	if len(usr.ProfilePic) > 0 {
+		claims["picture"] = usr.ProfilePic
+	}
+	return s.jwtSvc.GenerateIdToken(claims, now.Add(idTokenTTL).Unix())
+}
+
+// tokenTypeBearer is the token_type returned along with the access tokens
+const tokenTypeBearer = "Bearer"
+
+func (s service) accessTokenExpiry() time.Time {
+	return time.Now().Add(time.Minute * time.Duration(s.accessTokenTTL))
+}
+
+func (s service) tokenResponse(accessToken, idToken, refreshToken, scope string) *sdk.AuthVerifyCodeResponse {
+	return &sdk.AuthVerifyCodeResponse{
+		AccessToken:  accessToken,
+		IdToken:      idToken,
+		RefreshToken: refreshToken,
+		TokenType:    tokenTypeBearer,
+		ExpiresIn:    s.accessTokenTTL * 60,
+		Scope:        scope,
+	}
+}
+
+// grantScope validates the requested scopes against the ones allowed for the client.
+// The allowed scopes of the client are granted when none are requested
+func grantScope(cl sdk.Client, scope string) (string, error) {
+	requested := sdk.ParseScope(scope)
+	if len(requested) == 0 {
+		return sdk.FormatScope(cl.Scopes), nil
+	}
+	allowed := map[string]bool{}
+	for _, sc := range sdk.StandardScopes {
+		allowed[sc] = true
+	}
+	for _, sc := range cl.Scopes {
+		allowed[sc] = true
+	}
+	for _, sc := range requested {
+		if !allowed[sc] {
+			return "", fmt.Errorf("%w: %s is not allowed for the client", sdk.ErrInvalidScope, sc)
+		}
+	}
+	return sdk.FormatScope(requested), nil
+}
+
+// narrowScope validates that the scopes requested on refresh were granted at login
+func narrowScope(granted, scope string) (string, error) {
+	requested := sdk.ParseScope(scope)
+	if len(requested) == 0 {
+		return granted, nil
+	}
+	grantedScopes := map[string]bool{}
+	for _, sc := range sdk.ParseScope(granted) {
+		grantedScopes[sc] = true
+	}
+	for _, sc := range requested {
+		if !grantedScopes[sc] {
+			return "", fmt.Errorf("%w: %s was not granted at login", sdk.ErrInvalidScope, sc)
+		}
+	}
+	return sdk.FormatScope(requested), nil
+}
+
+// signingAlgorithms lists the distinct algorithms of the keys in the keyset
+func signingAlgorithms(jwks sdk.Jwks) []string {
+	algs := []string{}
+	seen := map[string]bool{}
+	for _, k := range jwks.Keys {
+		if seen[k.Alg] {
+			continue
+		}
+		seen[k.Alg] = true
+		algs = append(algs, k.Alg)
+	}
+	return algs
+}
+
+func (s service) generateAccessToken(accessTokenId, clientId string) (string, error) {
+	// iat and client_id are needed to reject the tokens issued before the user
+	// was signed out everywhere or revoked the consent of the client
+	return s.jwtSvc.GenerateToken(map[string]interface{}{
+		"id":        accessTokenId,
+		"iat":       time.Now().Unix(),
+		"client_id": clientId,
+	}, s.accessTokenExpiry().Unix())
+}
+
+// generateClientAccessToken generates the access token in the format configured for the client.
+// Self contained tokens going over the size cap are issued as opaque ones instead.
+func (s service) generateClientAccessToken(cl sdk.Client, accessTokenId string, usr sdk.User, token sdk.AuthToken) (string, error) {
+	if !cl.HasSelfContainedAccessToken() {
+		return s.generateAccessToken(accessTokenId, cl.Id)
+	}
+	claims := selfContainedClaims(cl, usr)
+	// id keeps the token usable with the userinfo, introspection and revocation endpoints
+	claims["id"] = accessTokenId
+	claims["iat"] = time.Now().Unix()
+	claims["iss"] = s.issuer
+	claims["sub"] = usr.Id
+	claims["client_id"] = cl.Id
+	if len(token.Scope) > 0 {
+		claims["scope"] = token.Scope
+	}
+	addAuthenticationClaims(claims, token)
+	accessToken, err := s.jwtSvc.GenerateToken(claims, s.accessTokenExpiry().Unix())
+	if err != nil {
+		return "", err
+	}
+	if s.maxTokenSize > 0 && int64(len(accessToken)) > s.maxTokenSize {
+		log.Warnw("self contained access token is over the size cap, issuing an opaque token",
+			"client_id", cl.Id,
+			"user_id", usr.Id,
+			"size", len(accessToken))
+		return s.generateAccessToken(accessTokenId, cl.Id)
+	}
+	return accessToken, nil
+}
+
+// addAuthenticationClaims sets the amr and acr claims telling resource servers how the user logged in,
+// so that they can ask for a step-up when a single factor isn't enough
+func addAuthenticationClaims(claims map[string]interface{}, token sdk.AuthToken) {
+	if len(token.Amr) > 0 {
+		claims["amr"] = token.Amr
+	}
+	if len(token.Acr) > 0 {
+		claims["acr"] = token.Acr
+	}
+}
+
+// selfContainedClaims fills the claims mapped for the client from the user details
+func selfContainedClaims(cl sdk.Client, usr sdk.User) map[string]interface{} {
+	claims := map[string]interface{}{}
+	for claim, attribute := range cl.GetAccessTokenClaims() {
+		switch attribute {
+		case sdk.AccessTokenClaimProjectId:
+			claims[claim] = usr.ProjectId
+		case sdk.AccessTokenClaimEmail:
+			claims[claim] = usr.Email
+		case sdk.AccessTokenClaimName:
+			claims[claim] = usr.Name
+		case sdk.AccessTokenClaimPhone:
+			claims[claim] = usr.Phone
+		case sdk.AccessTokenClaimRoles:
+			roles := []string{}
+			for id := range usr.Roles {
+				roles = append(roles, id)
+			}
+			sort.Strings(roles)
+			claims[claim] = roles
+		case sdk.AccessTokenClaimResources:
+			resources := []string{}
+			for key := range usr.Resources {
+				resources = append(resources, key)
+			}
+			sort.Strings(resources)
+			claims[claim] = resources
+		}
+	}
+	return claims
+}
+
+func (s service) authenticateClient(ctx context.Context, clientId, clientSecret string) (*sdk.Client, error) {
+	cl, err := s.clientSvc.Get(ctx, clientId, true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid client_id: %w", sdk.ErrInvalidClient, err)
+	}
+	if !cl.Enabled {
+		return nil, fmt.Errorf("%w: client is disabled", sdk.ErrInvalidClient)
+	}
+	// confidential clients always send their secret, public ones are verified only if they send one
+	if !cl.Public && len(clientSecret) == 0 {
+		return nil, fmt.Errorf("%w: %w", sdk.ErrInvalidClient, sdk.ErrClientAuthenticationRequired)
+	}
+	if len(clientSecret) != 0 {
+		err = s.handlePrivateClient(ctx, clientId, clientSecret)
+		if err != nil {
+			return nil, fmt.Errorf("%w: error handling private client %w", sdk.ErrInvalidClient, err)
+		}
+	}
+	return cl, nil
+}
+
+func (s service) revokeAccessToken(ctx context.Context, accessToken, clientId string) (bool, error) {
+	claims, err := s.jwtSvc.ValidateToken(accessToken)
+	if err != nil {
+		// not an access token issued by us, or it has already expired
+		return false, nil
+	}
+	accessTokenId, ok := claims["id"].(string)
+	if !ok {
+		return false, nil
+	}
+	token, err := s.getAccessTokenFromCache(ctx, accessTokenId)
+	if err != nil {
+		// the session is already gone
+		return true, nil
+	}
+	if token.ClientId != clientId {
+		return false, sdk.ErrTokenClientMismatch
+	}
+	return true, s.clearAccessToken(ctx, accessToken, accessTokenId)
+}
+
+func (s service) revokeRefreshToken(ctx context.Context, refreshToken, clientId string) (bool, error) {
+	err := s.refreshSvc.Revoke(ctx, refreshToken, clientId)
+	if errors.Is(err, sdk.ErrRefreshTokenNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s service) clearAccessToken(ctx context.Context, accessToken, accessTokenId string) error {
+	/*
+	 * drop the reverse mapping if it still points to this access token
+	 * drop the cached user and the cached session
+	 */
+	usr, err := s.getUserFromCache(ctx, accessToken)
+	if err == nil {
+		mapped, err := s.getAccessTokenForUserId(ctx, usr.Id)
+		if err == nil && mapped == accessToken {
+			err = s.cacheSvc.Delete(ctx, fmt.Sprintf("user-token-%s", usr.Id))
+			if err != nil {
+				return fmt.Errorf("error deleting the user reverse mapping %w", err)
+			}
+		}
+	}
+	err = s.cacheSvc.Delete(ctx, fmt.Sprintf("token-%s", accessToken))
+	if err != nil {
+		return fmt.Errorf("error deleting the cached user %w", err)
+	}
+	err = s.cacheSvc.Delete(ctx, fmt.Sprintf("access-token-%s", accessTokenId))
+	if err != nil {
+		return fmt.Errorf("error deleting the access token %w", err)
+	}
+	if s.introspectionTTL > 0 {
+		err = s.cacheSvc.Delete(ctx, introspectionCacheKey(accessToken))
+		if err != nil {
+			return fmt.Errorf("error deleting the cached introspection %w", err)
+		}
+	}
+	return nil
+}
+
+func (s service) endSession(ctx context.Context, accessToken string) error {
+	claims, err := s.jwtSvc.ValidateToken(accessToken)
+	if err != nil {
+		// an expired access token still lets the user log out
+		log.Debugw("ignoring invalid access token on logout", "error", err)
+		return nil
+	}
+	accessTokenId, ok := claims["id"].(string)
+	if !ok {
+		return nil
+	}
+	token, err := s.getAccessTokenFromCache(ctx, accessTokenId)
+	if err == nil && len(token.SessionId) > 0 {
+		err = s.refreshSvc.RevokeFamily(ctx, token.SessionId)
+		if err != nil {
+			return fmt.Errorf("error revoking the refresh tokens %w", err)
+		}
+	}
+	return s.clearAccessToken(ctx, accessToken, accessTokenId)
+}
+
+func (s service) getPostLogoutRedirectUrl(ctx context.Context, clientId, redirectUrl, state string) (string, error) {
+	cl, err := s.clientSvc.Get(ctx, clientId, true)
+	if err != nil {
+		return "", fmt.Errorf("error fetching client details %w", err)
+	}
+	found := false
+	for _, u := range cl.PostLogoutRedirectURLs {
+		if strings.EqualFold(u, redirectUrl) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("%w - %s", sdk.ErrPostLogoutRedirectUrlNotFound, redirectUrl)
+	}
+	if len(state) == 0 {
+		return redirectUrl, nil
+	}
+	if strings.Contains(redirectUrl, "?") {
+		return fmt.Sprintf("%s&state=%s", redirectUrl, url.QueryEscape(state)), nil
+	}
+	return fmt.Sprintf("%s?state=%s", redirectUrl, url.QueryEscape(state)), nil
+}
+
+// revocationTTL keeps the revocation marker until every access token issued before it has expired
+func (s service) revocationTTL() time.Duration {
+	ttl := s.tokenTTL
+	if s.accessTokenTTL > ttl {
+		ttl = s.accessTokenTTL
+	}
+	return time.Minute * time.Duration(ttl)
+}
+
+func (s service) checkRevocation(ctx context.Context, userId string, claims map[string]interface{}) error {
+	keys := []string{fmt.Sprintf("revoked-user-%s", userId)}
+	if clientId, ok := claims["client_id"].(string); ok && len(clientId) > 0 {
+		keys = append(keys, fmt.Sprintf("revoked-consent-%s-%s", userId, clientId))
+	}
+	for _, key := range keys {
+		val, err := s.cacheSvc.Get(ctx, key)
+		if err != nil {
+			// the user was never signed out everywhere or never revoked the consent
+			continue
+		}
+		revokedAt, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return sdk.ErrTokenRevoked
+		}
+		if numericClaim(claims, "iat") <= revokedAt {
+			return sdk.ErrTokenRevoked
+		}
+	}
+	return nil
+}
+
+// numericClaim reads a unix timestamp claim, json decoding gives float64 while locally built claims have int64
+func numericClaim(claims map[string]interface{}, name string) int64 {
+	switch v := claims[name].(type) {
+	case float64:
+		return int64(v)
+	case int64:
+		return v
+	}
+	return 0
+}
+
+// introspectionCacheKey keys the cached introspection result by the hash of the access token
+func introspectionCacheKey(accessToken string) string {
+	hash := sha256.Sum256([]byte(accessToken))
+	return fmt.Sprintf("introspection-%s", hex.EncodeToString(hash[:]))
+}
+
+func (s service) introspect(ctx context.Context, accessToken string) (*sdk.TokenIntrospectionResponse, error) {
+	/*
+	 * validate the jwt and get the session from cache
+	 * resolve the user through the identity cache, this rejects revoked and disabled users
+	 */
+	inactive := &sdk.TokenIntrospectionResponse{Active: false}
+	claims, err := s.jwtSvc.ValidateToken(accessToken)
+	if err != nil {
+		return inactive, nil
+	}
+	accessTokenId, ok := claims["id"].(string)
+	if !ok {
+		return inactive, nil
+	}
+	token, err := s.getAccessTokenFromCache(ctx, accessTokenId)
+	if err != nil {
+		return inactive, nil
+	}
+	usr, err := s.GetIdentity(ctx, accessToken, false)
+	if err != nil {
+		log.Debugw("introspected token has no identity", "error", err)
+		return inactive, nil
+	}
+
+	resp := &sdk.TokenIntrospectionResponse{
+		Active:    true,
+		Sub:       usr.Id,
+		ClientId:  token.ClientId,
+		Exp:       numericClaim(claims, "exp"),
+		Iat:       numericClaim(claims, "iat"),
+		Scope:     token.Scope,
+		TokenType: tokenTypeBearer,
+		ProjectId: usr.ProjectId,
+		Amr:       token.Amr,
+		Acr:       token.Acr,
+	}
+	for _, r := range usr.Roles {
+		resp.Roles = append(resp.Roles, r.Name)
+	}
+	for key := range usr.Resources {
+		resp.Resources = append(resp.Resources, key)
+	}
+	sort.Strings(resp.Roles)
+	sort.Strings(resp.Resources)
+	return resp, nil
+}
+
+func (s service) cacheIntrospection(ctx context.Context, accessToken string, resp sdk.TokenIntrospectionResponse) {
+	// the result is never cached beyond the expiry of the token
+	ttl := time.Second * time.Duration(s.introspectionTTL)
+	if untilExpiry := time.Until(time.Unix(resp.Exp, 0)); resp.Exp > 0 && untilExpiry < ttl {
+		ttl = untilExpiry
+	}
+	if ttl <= 0 {
+		return
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		log.Errorw("error encoding the introspection result", "error", err)
+		return
+	}
+	enc, err := s.encSvc.Encrypt(string(b))
+	if err != nil {
+		log.Errorw("error encrypting the introspection result", "error", err)
+		return
+	}
+	err = s.cacheSvc.Set(ctx, introspectionCacheKey(accessToken), enc, ttl)
+	if err != nil {
+		log.Errorw("error caching the introspection result", "error", err)
+	}
+}
+
+func (s service) getIntrospectionFromCache(ctx context.Context, accessToken string) (*sdk.TokenIntrospectionResponse, error) {
+	val, err := s.cacheSvc.Get(ctx, introspectionCacheKey(accessToken))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the value from cache %w", err)
+	}
+	dec, err := s.encSvc.Decrypt(val)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting the introspection result %w", err)
+	}
+	result := sdk.TokenIntrospectionResponse{}
+	err = json.Unmarshal([]byte(dec), &result)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the introspection result %w", err)
+	}
+	return &result, nil
+}
+
+// pendingConsent is a login waiting for the user to consent to the scopes requested by a third party client
+type pendingConsent struct {
+	UserId    string              `json:"user_id"`
+	UserName  string              `json:"user_name"`
+	UserEmail string              `json:"user_email"`
+	Params    sdk.AuthLoginParams `json:"params"`
+	Token     sdk.AuthToken       `json:"token"`
+}
+
+// completeLogin asks the user of a third party client for their consent if they haven't given it yet,
+// otherwise it issues the auth code. It returns the url to send the user to.
+func (s service) completeLogin(ctx context.Context, cl sdk.Client, usr sdk.User, token sdk.AuthToken, params sdk.AuthLoginParams) (string, error) {
+	if cl.ThirdParty {
+		consentPageUrl, err := s.requireConsent(ctx, cl, usr, token, params)
+		if err != nil {
+			return "", fmt.Errorf("error checking the consent of the user %w", err)
+		}
+		if len(consentPageUrl) > 0 {
+			return consentPageUrl, nil
+		}
+	}
+	return s.issueAuthCode(ctx, token, params)
+}
+
+// requireConsent returns the url of the consent page if the user hasn't consented to
+// all the requested scopes yet. An empty url means the login can continue.
+func (s service) requireConsent(ctx context.Context, cl sdk.Client, usr sdk.User, token sdk.AuthToken, params sdk.AuthLoginParams) (string, error) {
+	existing, err := s.consentSvc.Get(ctx, usr.Id, cl.Id)
+	if err != nil && !errors.Is(err, sdk.ErrConsentNotFound) {
+		return "", fmt.Errorf("error fetching the consent %w", err)
+	}
+	if existing != nil && existing.Covers(params.Scope) {
+		return "", nil
+	}
+
+	challenge, err := s.cachePendingConsent(ctx, pendingConsent{
+		UserId:    usr.Id,
+		UserName:  usr.Name,
+		UserEmail: usr.Email,
+		Params:    params,
+		Token:     token,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error caching the pending consent %w", err)
+	}
+	return withQuery(s.consentUrl, "consent_challenge", challenge), nil
+}
+
+// getLoginUser resolves the go-iam user of the auth provider token before the auth code is issued
+func (s service) getLoginUser(ctx context.Context, token sdk.AuthToken) (*sdk.User, error) {
+	if len(token.ServiceAccountUserId) > 0 {
+		return s.getServiceAccountUser(ctx, &token)
+	}
+	identity, err := s.getAuthProivderIdentity(ctx, &token, "")
+	if err != nil {
+		return nil, fmt.Errorf("error getting the identity from auth provider %w", err)
+	}
+	usr, linked, err := s.getOrCreateUser(ctx, *identity)
+	if errors.Is(err, sdk.ErrLoginDenied) {
+		// the reason is logged when the login is denied
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting or creating the user %w", err)
+	}
+	linked.Email = identity.User.Email
+	linked.EmailVerified = identity.User.EmailVerified
+	err = s.identitySvc.RecordLogin(ctx, linked)
+	if err != nil {
+		log.Errorf("error recording the login of the identity %s", err)
+	}
+	usr, err = s.syncGroupRoles(ctx, *usr, linked, *identity)
+	if err != nil {
+		return nil, fmt.Errorf("error syncing the roles of the user %w", err)
+	}
+	return usr, nil
+}
+
+// syncGroupRoles adds and removes the roles of the group role mapping of the auth provider, following the groups
+// the provider reported for the user. The user keeps the identities granting each role, so that only the roles no
+// identity grants any more are removed when the user leaves the groups and the roles assigned by an admin stay.
+func (s service) syncGroupRoles(ctx context.Context, usr sdk.User, linked *sdk.UserIdentity, pid providerIdentity) (*sdk.User, error) {
+	/*
+	 * providers not reporting the groups leave the roles as they are
+	 * find the roles the groups of the user grant
+	 * nothing changes when the identity grants no role now or before
+	 * otherwise the user service sets the roles the identity grants
+	 */
+	if pid.Groups == nil || linked == nil {
+		return &usr, nil
+	}
+	groups := map[string]bool{}
+	for _, g := range pid.Groups {
+		groups[normalizeGroup(g)] = true
+	}
+	granted := []string{}
+	for _, gr := range pid.Provider.GroupRoles {
+		if groups[normalizeGroup(gr.Group)] && !slices.Contains(granted, gr.RoleId) {
+			granted = append(granted, gr.RoleId)
+		}
+	}
+	if len(granted) == 0 && !grantsGroupRoles(usr, linked.Id) {
+		return &usr, nil
+	}
+	return s.usrSvc.SyncGroupRoles(ctx, usr.Id, linked.Id, granted)
+}
+
+// grantsGroupRoles tells whether the identity grants any role of the user through groups
+func grantsGroupRoles(usr sdk.User, identityId string) bool {
+	for _, identityIds := range usr.GroupRoles {
+		if slices.Contains(identityIds, identityId) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeGroup lower cases the group and drops the spaces around the separators of DNs, so that
+// the groups of the mapping match the ones of the auth provider however they are written
+func normalizeGroup(group string) string {
+	group = strings.ToLower(strings.TrimSpace(group))
+	if !strings.Contains(group, "=") {
+		return group
+	}
+	rdns := strings.Split(group, ",")
+	for i, rdn := range rdns {
+		attr, value, _ := strings.Cut(rdn, "=")
+		rdns[i] = strings.TrimSpace(attr) + "=" + strings.TrimSpace(value)
+	}
+	return strings.Join(rdns, ",")
+}
+
+// linkIdentity links the identity the user logged in with to the user who started the linking.
+// It returns the url sending them back to the client with the id of the linked identity.
+func (s service) linkIdentity(ctx context.Context, token sdk.AuthToken, params sdk.AuthLoginParams) (string, error) {
+	pid, err := s.getAuthProivderIdentity(ctx, &token, "")
+	if err != nil {
+		return "", fmt.Errorf("error getting the identity from auth provider %w", err)
+	}
+	err = pid.checkEmailVerified()
+	if err != nil {
+		return "", err
+	}
+	usr, err := s.usrSvc.GetById(ctx, params.LinkUserId)
+	if err != nil {
+		return "", fmt.Errorf("error fetching the user %w", err)
+	}
+	linked := &sdk.UserIdentity{
+		UserId:         usr.Id,
+		ProjectId:      usr.ProjectId,
+		AuthProviderId: pid.Provider.Id,
+		Subject:        pid.Subject,
+		Email:          pid.User.Email,
+		EmailVerified:  pid.User.EmailVerified,
+	}
+	err = s.identitySvc.Link(ctx, linked)
+	if err != nil {
+		return "", fmt.Errorf("error linking the identity %w", err)
+	}
+	redirectUrl := withQuery(params.RedirectUrl, "linked_identity", linked.Id)
+	if len(params.State) > 0 {
+		redirectUrl = withQuery(redirectUrl, "state", params.State)
+	}
+	return redirectUrl, nil
+}
+
+// registeredRedirectUrl tells whether the redirect url is one of the client
+func registeredRedirectUrl(cl sdk.Client, redirectUrl string) bool {
+	for _, cb := range cl.RedirectURLs {
+		if strings.EqualFold(cb, redirectUrl) {
+			return true
+		}
+	}
+	return false
+}
+
+// withQuery appends a query parameter to a page url that may already have a query
+func withQuery(pageUrl, key, value string) string {
+	separator := "?"
+	if strings.Contains(pageUrl, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%s%s=%s", pageUrl, separator, key, url.QueryEscape(value))
+}
+
+func (s service) cachePendingConsent(ctx context.Context, pending pendingConsent) (string, error) {
+	b, err := json.Marshal(pending)
+	if err != nil {
+		return "", fmt.Errorf("error encoding the pending consent %w", err)
+	}
+	val, err := s.encSvc.Encrypt(string(b))
+	if err != nil {
+		return "", fmt.Errorf("error encrypting the pending consent %w", err)
+	}
+	challenge := uuid.NewString()
+	// the user has the same time to answer as they had to log in with the auth provider
+	err = s.cacheSvc.Set(ctx, fmt.Sprintf("consent-%s", challenge), val, time.Minute*5)
+	if err != nil {
+		return "", fmt.Errorf("error saving the pending consent %w", err)
+	}
+	return challenge, nil
+}
+
+func (s service) getPendingConsent(ctx context.Context, challenge string) (*pendingConsent, error) {
+	val, err := s.cacheSvc.Get(ctx, fmt.Sprintf("consent-%s", challenge))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", sdk.ErrInvalidConsentChallenge, err)
+	}
+	raw, err := s.encSvc.Decrypt(val)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting the pending consent %w", err)
+	}
+	result := pendingConsent{}
+	err = json.Unmarshal([]byte(raw), &result)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the pending consent %w", err)
+	}
+	return &result, nil
+}
+
+// maxMfaAttempts is the number of wrong codes accepted for an mfa challenge before the login has to start again
+const maxMfaAttempts = 5
+
+// pendingMfa is a login waiting for the second factor of the user
+type pendingMfa struct {
+	UserId     string              `json:"user_id"`
+	UserName   string              `json:"user_name"`
+	UserEmail  string              `json:"user_email"`
+	Enrollment *sdk.MfaEnrollment  `json:"enrollment,omitempty"` // Authenticator set up during the login by users without one
+	Passkey    bool                `json:"passkey"`              // Whether the user can answer with a passkey
+	Attempts   int                 `json:"attempts"`
+	Params     sdk.AuthLoginParams `json:"params"`
+	Token      sdk.AuthToken       `json:"token"`
+}
+
+// requireMfa returns the url of the mfa page if the login needs a second factor.
+// That is the case for the users having one, the users the mfa policy requires one from
+// and the logins asking for the multi-factor acr. A TOTP authenticator and a passkey both count
+// as a second factor, the users with neither enroll an authenticator on the page.
+// Logins that are multi-factor already, like the passkey logins, and an empty url mean the login can continue.
+func (s service) requireMfa(ctx context.Context, usr sdk.User, token sdk.AuthToken, params sdk.AuthLoginParams) (string, error) {
+	if token.Acr == sdk.AcrMultiFactor {
+		return "", nil
+	}
+	status, err := s.mfaSvc.Status(ctx, usr)
+	if err != nil {
+		return "", fmt.Errorf("error fetching the mfa status %w", err)
+	}
+	passkeys, err := s.passkeySvc.List(ctx, usr.Id)
+	if err != nil {
+		return "", fmt.Errorf("error fetching the passkeys of the user %w", err)
+	}
+	hasPasskey := len(passkeys) > 0
+	if !status.Enabled && !hasPasskey && !status.Required && !requestsAcr(params.AcrValues, sdk.AcrMultiFactor) {
+		return "", nil
+	}
+
+	pending := pendingMfa{
+		UserId:    usr.Id,
+		UserName:  usr.Name,
+		UserEmail: usr.Email,
+		Passkey:   hasPasskey,
+		Params:    params,
+		Token:     token,
+	}
+	if !status.Enabled && !hasPasskey {
+		pending.Enrollment, err = s.mfaSvc.Enroll(ctx, usr)
+		if err != nil {
+			return "", fmt.Errorf("error enrolling the user %w", err)
+		}
+	}
+	challenge := uuid.NewString()
+	err = s.cachePendingMfa(ctx, challenge, pending)
+	if err != nil {
+		return "", err
+	}
+	return withQuery(s.mfaUrl, "mfa_challenge", challenge), nil
+}
+
+// failMfaAttempt records a failed answer to the challenge and drops it once there were too many.
+// cause is returned while attempts are left.
+func (s service) failMfaAttempt(ctx context.Context, challenge string, pending pendingMfa, cause error) error {
+	pending.Attempts++
+	if pending.Attempts >= maxMfaAttempts {
+		err := s.cacheSvc.Delete(ctx, mfaCacheKey(challenge))
+		if err != nil {
+			return fmt.Errorf("error invalidating the mfa challenge %w", err)
+		}
+		return sdk.ErrMfaAttemptsExceeded
+	}
+	err := s.cachePendingMfa(ctx, challenge, pending)
+	if err != nil {
+		return err
+	}
+	return cause
+}
+
+// completeMfa drops the answered challenge, marks the token as multi-factor with the method used
+// and continues the login with the consent or the auth code
+func (s service) completeMfa(ctx context.Context, challenge string, usr sdk.User, pending pendingMfa, method string) (string, error) {
+	err := s.cacheSvc.Delete(ctx, mfaCacheKey(challenge))
+	if err != nil {
+		return "", fmt.Errorf("error invalidating the mfa challenge %w", err)
+	}
+	token := pending.Token
+	token.Amr = addAmr(token.Amr, method, sdk.AmrMfa)
+	token.Acr = sdk.AcrMultiFactor
+
+	cl, err := s.clientSvc.Get(ctx, pending.Params.ClientId, true)
+	if err != nil {
+		return "", fmt.Errorf("error fetching client details %w", err)
+	}
+	return s.completeLogin(ctx, *cl, usr, token, pending.Params)
+}
+
+func mfaCacheKey(challenge string) string {
+	return fmt.Sprintf("mfa-%s", challenge)
+}
+
+// loginPasskeySession keys the passkey ceremony of a login with the passkey auth provider
+func loginPasskeySession(state string) string {
+	return fmt.Sprintf("login-%s", state)
+}
+
+// mfaPasskeySession keys the passkey ceremony answering an mfa challenge
+func mfaPasskeySession(challenge string) string {
+	return fmt.Sprintf("mfa-%s", challenge)
+}
+
+func (s service) cachePendingMfa(ctx context.Context, challenge string, pending pendingMfa) error {
+	b, err := json.Marshal(pending)
+	if err != nil {
+		return fmt.Errorf("error encoding the pending mfa %w", err)
+	}
+	val, err := s.encSvc.Encrypt(string(b))
+	if err != nil {
+		return fmt.Errorf("error encrypting the pending mfa %w", err)
+	}
+	err = s.cacheSvc.Set(ctx, mfaCacheKey(challenge), val, time.Minute*5)
+	if err != nil {
+		return fmt.Errorf("error saving the pending mfa %w", err)
+	}
+	return nil
+}
+
+func (s service) getPendingMfa(ctx context.Context, challenge string) (*pendingMfa, error) {
+	val, err := s.cacheSvc.Get(ctx, mfaCacheKey(challenge))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", sdk.ErrInvalidMfaChallenge, err)
+	}
+	raw, err := s.encSvc.Decrypt(val)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting the pending mfa %w", err)
+	}
+	result := pendingMfa{}
+	err = json.Unmarshal([]byte(raw), &result)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the pending mfa %w", err)
+	}
+	return &result, nil
+}
+
+// firstFactorAmr is the authentication method reference of the logins with the auth provider type
+func firstFactorAmr(provider sdk.AuthProviderType) string {
+	switch provider {
+	case sdk.AuthProviderTypePassword:
+		return sdk.AmrPassword
+	case sdk.AuthProviderTypePasswordless:
+		return sdk.AmrOtp
+	case sdk.AuthProviderTypeSms:
+		return sdk.AmrSms
+	case sdk.AuthProviderTypePasskey:
+		return sdk.AmrPasskey
+	}
+	return sdk.AmrFederated
+}
+
+// addAmr appends the methods missing from the authentication method references
+func addAmr(amr []string, methods ...string) []string {
+	result := append([]string{}, amr...)
+	for _, m := range methods {
+		if !slices.Contains(result, m) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// requestsAcr tells whether the space separated acr_values of the login contain the acr
+func requestsAcr(acrValues, acr string) bool {
+	return slices.Contains(strings.Fields(acrValues), acr)
+}
+
+// issueAuthCode caches the token against a new auth code and returns the client redirect url carrying it
+func (s service) issueAuthCode(ctx context.Context, token sdk.AuthToken, params sdk.AuthLoginParams) (string, error) {
+	authCode, err := s.cacheAuthToken(ctx, token)
+	if err != nil {
+		return "", fmt.Errorf("error caching the token %w", err)
+	}
+
+	redirectUrl, err := s.getRedirectUrl(ctx, params.ClientId, params.RedirectUrl, authCode, params.State)
+	if err != nil {
+		return "", fmt.Errorf("error getting the callback url %w", err)
+	}
+	return redirectUrl, nil
+}
+
+// getAccessDeniedRedirectUrl sends the user back to the client with the access_denied error of RFC 6749.
+// The description, when given, tells the client why.
+func (s service) getAccessDeniedRedirectUrl(ctx context.Context, clientId, redirectUrl, state, description string) (string, error) {
+	cl, err := s.clientSvc.Get(ctx, clientId, true)
+	if err != nil {
+		return "", fmt.Errorf("error fetching client details %w", err)
+	}
+	found := false
+	for _, cb := range cl.RedirectURLs {
+		if strings.EqualFold(cb, redirectUrl) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("callback url not found in the client details - %s", redirectUrl)
+	}
+	separator := "?"
+	if strings.Contains(redirectUrl, "?") {
+		separator = "&"
+	}
+	redirectUrl = fmt.Sprintf("%s%serror=%s&state=%s", redirectUrl, separator, sdk.OAuthErrorAccessDenied, url.QueryEscape(state))
+	if len(description) > 0 {
+		redirectUrl = withQuery(redirectUrl, "error_description", description)
+	}
+	return redirectUrl, nil
+}
+
+// loginDeniedDescription is the error_description of the logins the user is not let in with
+const loginDeniedDescription = "the user is not allowed to log in"
+
+// denyLogin ends a login the user is not let in with, sending them back to the client with the access_denied error.
+// The reason is only logged, the client gets a fixed description.
+func (s service) denyLogin(ctx context.Context, state string, params sdk.AuthLoginParams, reason error) (*sdk.AuthRedirectResponse, error) {
+	log.Infow("login denied", "client_id", params.ClientId, "reason", reason.Error())
+	redirectUrl, err := s.getAccessDeniedRedirectUrl(ctx, params.ClientId, params.RedirectUrl, params.State, loginDeniedDescription)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the redirect url of the denied login %w", err)
+	}
+	err = s.invalidateState(ctx, state)
+	if err != nil {
+		log.Errorf("error invalidating state %s", err)
+	}
+	return &sdk.AuthRedirectResponse{RedirectUrl: redirectUrl}, nil
+}
+
+// provisionUser creates the user logging in for the first time with the auth provider, following its provisioning rules
+func (s service) provisionUser(ctx context.Context, p sdk.AuthProvider, usr sdk.User) (*sdk.User, error) {
+	/*
+	 * refuse the user when the auth provider does not create users or the domain of their email is not allowed
+	 * users waiting for approval are created disabled
+	 * create the user with the default policies
+	 * assign the default roles, the user waits for approval until they have them so that a failure
+	 * locks the user out instead of letting them in without their roles
+	 * enable the user once the roles are assigned unless an admin has to approve them
+	 */
+	rules := p.Provisioning
+	if rules.Disabled {
+		return nil, fmt.Errorf("%w: the auth provider only lets in existing users", sdk.ErrLoginDenied)
+	}
+	if len(rules.AllowedDomains) > 0 || len(rules.BlockedDomains) > 0 {
+		domain := emailDomain(usr.Email)
+		if len(domain) == 0 {
+			return nil, fmt.Errorf("%w: users without an email address are not allowed", sdk.ErrLoginDenied)
+		}
+		if len(rules.AllowedDomains) > 0 && !containsDomain(rules.AllowedDomains, domain) {
+			return nil, fmt.Errorf("%w: users of the email domain %q are not allowed", sdk.ErrLoginDenied, domain)
+		}
+		if containsDomain(rules.BlockedDomains, domain) {
+			return nil, fmt.Errorf("%w: users of the email domain %q are not allowed", sdk.ErrLoginDenied, domain)
+		}
+	}
+
+	usr.PendingApproval = rules.RequireApproval || len(rules.DefaultRoles) > 0
+	if len(rules.DefaultPolicies) > 0 {
+		usr.Policies = map[string]sdk.UserPolicy{}
+		for id, policy := range rules.DefaultPolicies {
+			usr.Policies[id] = policy
+		}
+	}
+	err := s.usrSvc.Create(ctx, &usr)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the user %w", err)
+	}
+	if len(rules.DefaultRoles) == 0 {
+		return &usr, nil
+	}
+	for _, roleId := range rules.DefaultRoles {
+		err = s.usrSvc.AddRoleToUser(ctx, usr.Id, roleId)
+		if err != nil {
+			return nil, fmt.Errorf("error assigning the default role %s to the user %s %w", roleId, usr.Id, err)
+		}
+	}
+	created, err := s.usrSvc.GetById(ctx, usr.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the created user %w", err)
+	}
+	if rules.RequireApproval {
+		return created, nil
+	}
+	created.Enabled = true
+	created.PendingApproval = false
+	err = s.usrSvc.Update(ctx, created)
+	if err != nil {
+		return nil, fmt.Errorf("error enabling the user %w", err)
+	}
+	return created, nil
+}
+
+// emailDomain returns the lower cased domain of the email address, empty for users without one
+func emailDomain(email string) string {
+	i := strings.LastIndex(email, "@")
+	if i < 0 {
+		return ""
+	}
+	return strings.ToLower(email[i+1:])
+}
+
+// containsDomain tells whether the domain is one of the list, written with or without a leading @
+func containsDomain(domains []string, domain string) bool {
+	for _, d := range domains {
+		if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(d), "@"), domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProjectScopes validates the requested scopes against the ones defined in the project of the client
+func (s service) checkProjectScopes(ctx context.Context, projectId, scope string) error {
+	p, err := s.projectSvc.Get(ctx, projectId)
+	if err != nil {
+		return fmt.Errorf("error fetching the project of the client %w", err)
+	}
+	return p.ValidateClientScopes(sdk.ParseScope(scope))
+}
+
+// getLoginRouting returns the login routing of the project, nil when its logins are not routed by email domain
+func (s service) getLoginRouting(ctx context.Context, projectId string) (*sdk.LoginRouting, error) {
+	p, err := s.projectSvc.Get(ctx, projectId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the project of the client %w", err)
+	}
+	if p.LoginRouting == nil || (len(p.LoginRouting.Domains) == 0 && len(p.LoginRouting.Fallback) == 0) {
+		return nil, nil
+	}
+	return p.LoginRouting, nil
+}
+
+// routeLogin continues the login with the auth provider of the domain of the login hint, or sends the user
+// to the identifier page to type their email when there is no hint or it leaves several auth providers to choose from
+func (s service) routeLogin(ctx context.Context, client sdk.Client, routing sdk.LoginRouting, params sdk.AuthLoginParams) (string, error) {
+	if len(params.LoginHint) > 0 {
+		candidates, err := s.loginCandidates(ctx, client, routing, params.LoginHint)
+		if err != nil {
+			return "", err
+		}
+		if len(candidates) == 1 {
+			return s.authCodeUrl(ctx, candidates[0], params)
+		}
+	}
+	challenge := uuid.NewString()
+	err := s.cachePendingDiscovery(ctx, challenge, params)
+	if err != nil {
+		return "", err
+	}
+	return withQuery(s.identifierUrl, "login_challenge", challenge), nil
+}
+
+// loginCandidates returns the enabled auth providers of the project of the client the user of the email can log in with.
+// The route of the email domain wins, the other domains use the fallback list and the client's default provider without one.
+func (s service) loginCandidates(ctx context.Context, client sdk.Client, routing sdk.LoginRouting, email string) ([]sdk.AuthProvider, error) {
+	ids := routing.Fallback
+	if len(ids) == 0 && len(client.DefaultAuthProviderId) > 0 {
+		ids = []string{client.DefaultAuthProviderId}
+	}
+	domain := emailDomain(email)
+	for _, route := range routing.Domains {
+		if len(domain) > 0 && containsDomain([]string{route.Domain}, domain) {
+			ids = []string{route.AuthProviderId}
+			break
+		}
+	}
+
+	candidates := []sdk.AuthProvider{}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		p, err := s.authP.Get(ctx, id, true)
+		if errors.Is(err, sdk.ErrAuthProviderNotFound) {
+			log.Warnf("skipping the auth provider %s of the login routing of the project %s, it does not exist", id, client.ProjectId)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error fetching auth provider details %w", err)
+		}
+		if p.ProjectId != client.ProjectId || !p.Enabled {
+			continue
+		}
+		candidates = append(candidates, *p)
+	}
+	return candidates, nil
+}
+
+func discoveryCacheKey(challenge string) string {
+	return fmt.Sprintf("discovery-%s", challenge)
+}
+
+func (s service) cachePendingDiscovery(ctx context.Context, challenge string, params sdk.AuthLoginParams) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("error encoding the pending login %w", err)
+	}
+	val, err := s.encSvc.Encrypt(string(b))
+	if err != nil {
+		return fmt.Errorf("error encrypting the pending login %w", err)
+	}
+	err = s.cacheSvc.Set(ctx, discoveryCacheKey(challenge), val, time.Minute*5)
+	if err != nil {
+		return fmt.Errorf("error saving the pending login %w", err)
+	}
+	return nil
+}
+
+func (s service) getPendingDiscovery(ctx context.Context, challenge string) (*sdk.AuthLoginParams, error) {
+	val, err := s.cacheSvc.Get(ctx, discoveryCacheKey(challenge))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", sdk.ErrInvalidLoginChallenge, err)
+	}
+	raw, err := s.encSvc.Decrypt(val)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting the pending login %w", err)
+	}
+	result := sdk.AuthLoginParams{}
+	err = json.Unmarshal([]byte(raw), &result)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the pending login %w", err)
+	}
+	return &result, nil
+}
@@ -8,11 +8,39 @@ import (
 )
 
 type Service interface {
-	GetLoginUrl(ctx context.Context, clientId, authProviderId, state, redirectUrl, codeChallengeMethod, codeChallenge string) (string, error)
+	GetLoginUrl(ctx context.Context, params sdk.AuthLoginParams) (string, error)
+	DiscoverLogin(ctx context.Context, req sdk.LoginDiscoveryRequest) (*sdk.LoginDiscoveryResponse, error)
+	StartIdentityLink(ctx context.Context, usr sdk.User, req sdk.UserIdentityLinkRequest) (string, error)
 	Redirect(ctx context.Context, code, state string) (*sdk.AuthRedirectResponse, error)
-	ClientCallback(ctx context.Context, code, codeChallenge, clientId, clietSecret string) (*sdk.AuthVerifyCodeResponse, error)
+	GetConsentPrompt(ctx context.Context, consentChallenge string) (*sdk.ConsentPrompt, error)
+	DecideConsent(ctx context.Context, decision sdk.ConsentDecision) (*sdk.AuthRedirectResponse, error)
+	GetMfaPrompt(ctx context.Context, mfaChallenge string) (*sdk.MfaPrompt, error)
+	VerifyMfa(ctx context.Context, req sdk.MfaVerifyRequest) (*sdk.MfaVerifyResponse, error)
+	StartMfaPasskey(ctx context.Context, req sdk.MfaPasskeyStartRequest) (*sdk.WebAuthnRequestOptions, error)
+	VerifyMfaPasskey(ctx context.Context, req sdk.MfaPasskeyVerifyRequest) (*sdk.MfaVerifyResponse, error)
+	RevokeConsent(ctx context.Context, userId, clientId string) error
+	PasswordLogin(ctx context.Context, req sdk.PasswordLoginRequest, ip string) (*sdk.AuthRedirectResponse, error)
+	PasswordSignup(ctx context.Context, req sdk.PasswordSignupRequest) error
+	ForgotPassword(ctx context.Context, req sdk.PasswordForgotRequest) error
+	StartPasswordless(ctx context.Context, req sdk.PasswordlessStartRequest, ip string) error
+	StartSmsOtp(ctx context.Context, req sdk.SmsOtpStartRequest, ip string) error
+	PasswordlessVerify(ctx context.Context, req sdk.PasswordlessVerifyRequest) (*sdk.AuthRedirectResponse, error)
+	PasskeyLoginStart(ctx context.Context, req sdk.PasskeyLoginStartRequest) (*sdk.WebAuthnRequestOptions, error)
+	PasskeyLoginVerify(ctx context.Context, req sdk.PasskeyLoginVerifyRequest) (*sdk.AuthRedirectResponse, error)
+	SamlAcs(ctx context.Context, req sdk.SamlAcsRequest) (*sdk.AuthRedirectResponse, error)
+	SamlMetadata(ctx context.Context, authProviderId string) ([]byte, error)
+	LdapLogin(ctx context.Context, req sdk.LdapLoginRequest, ip string) (*sdk.AuthRedirectResponse, error)
+	ClientCallback(ctx context.Context, code, codeVerifier, clientId, clietSecret string) (*sdk.AuthVerifyCodeResponse, error)
+	Token(ctx context.Context, req sdk.TokenRequest) (*sdk.AuthVerifyCodeResponse, error)
 	GetIdentity(ctx context.Context, accessToken string, forceFetch bool) (*sdk.User, error)
 	SynchronizeIdentity(ctx context.Context, userId string) error
 	ClientCredentials(ctx context.Context, clientId, clientSecret string) (*sdk.AuthVerifyCodeResponse, error)
+	RefreshToken(ctx context.Context, refreshToken, clientId, clientSecret string) (*sdk.AuthVerifyCodeResponse, error)
+	RevokeToken(ctx context.Context, token, tokenTypeHint, clientId, clientSecret string) error
+	Logout(ctx context.Context, accessToken, clientId, postLogoutRedirectUrl, state string) (string, error)
+	RevokeUserTokens(ctx context.Context, userId string) error
+	IntrospectToken(ctx context.Context, token, clientId, clientSecret string, includeRoles bool) (*sdk.TokenIntrospectionResponse, error)
 	HandleEvent(event utils.Event[sdk.Client])
+	GetJwks() sdk.Jwks
+	GetOpenIdConfiguration() sdk.OpenIdConfiguration
 }
@@ -6,7 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,99 +14,345 @@ import (
 	"github.com/google/uuid"
 	"github.com/melvinodsa/go-iam/sdk"
 	"github.com/melvinodsa/go-iam/services/authprovider"
+	"github.com/melvinodsa/go-iam/services/authprovider/saml"
 	"github.com/melvinodsa/go-iam/services/cache"
 	"github.com/melvinodsa/go-iam/services/client"
+	"github.com/melvinodsa/go-iam/services/consent"
 	"github.com/melvinodsa/go-iam/services/encrypt"
+	"github.com/melvinodsa/go-iam/services/identity"
 	"github.com/melvinodsa/go-iam/services/jwt"
+	"github.com/melvinodsa/go-iam/services/ldap"
+	"github.com/melvinodsa/go-iam/services/mfa"
+	"github.com/melvinodsa/go-iam/services/passkey"
+	"github.com/melvinodsa/go-iam/services/password"
+	"github.com/melvinodsa/go-iam/services/passwordless"
+	"github.com/melvinodsa/go-iam/services/project"
+	"github.com/melvinodsa/go-iam/services/refreshtoken"
 	"github.com/melvinodsa/go-iam/services/user"
 )
 
 type service struct {
-	authP      authprovider.Service
-	clientSvc  client.Service
-	cacheSvc   cache.Service
-	jwtSvc     jwt.Service
-	encSvc     encrypt.Service
-	usrSvc     user.Service
-	tokenTTL   int64
-	refetchTTL int64
+	authP            authprovider.Service
+	clientSvc        client.Service
+	cacheSvc         cache.Service
+	jwtSvc           jwt.Service
+	encSvc           encrypt.Service
+	usrSvc           user.Service
+	refreshSvc       refreshtoken.Service
+	consentSvc       consent.Service
+	passwordSvc      password.Service
+	passwordlessSvc  passwordless.Service
+	mfaSvc           mfa.Service
+	passkeySvc       passkey.Service
+	ldapSvc          ldap.Service
+	identitySvc      identity.Service
+	projectSvc       project.Service
+	tokenTTL         int64
+	refetchTTL       int64
+	accessTokenTTL   int64
+	introspectionTTL int64
+	maxTokenSize     int64
+	issuer           string
+	consentUrl       string
+	mfaUrl           string
+	identifierUrl    string
 }
 
-func NewService(authP authprovider.Service, clientSvc client.Service, cacheSvc cache.Service, jwtSvc jwt.Service, encSvc encrypt.Service, usrSvc user.Service, tokenTTL int64, refetchTTL int64) *service {
+// Deps are the services the auth service works with.
+type Deps struct {
+	AuthProviders authprovider.Service
+	Clients       client.Service
+	Cache         cache.Service
+	Jwt           jwt.Service
+	Encrypt       encrypt.Service
+	Users         user.Service
+	RefreshTokens refreshtoken.Service
+	Consents      consent.Service
+	Passwords     password.Service     // Checks the credentials of the users logging in with the password auth provider
+	Passwordless  passwordless.Service // Emails the login codes and magic links of the passwordless auth provider
+	Mfa           mfa.Service          // Checks the second factor of the users, asked for on the mfa page during the login
+	Passkeys      passkey.Service      // Runs the passkey logins, both of the passkey auth provider and as a second factor
+	Ldap          ldap.Service         // Checks the credentials of the users logging in with the ldap auth providers against their directory
+	Identities    identity.Service     // Keeps the identities of the users at the auth providers, the logins find the users by
+	Projects      project.Service      // Reads the login routing of the projects, the logins without an auth provider are routed by
+}
+
+// Options configure the lifetimes of the sessions and tokens and the pages of the login flow.
+type Options struct {
+	TokenTTL         int64  // Cache duration of the sessions in minutes
+	RefetchTTL       int64  // Cache duration of the user details in minutes
+	AccessTokenTTL   int64  // Validity of the issued access tokens in minutes
+	IntrospectionTTL int64  // Cache duration of the introspection results in seconds, 0 disables caching them
+	MaxTokenSize     int64  // Size cap in bytes of the self contained access tokens
+	Issuer           string // Issuer of the tokens
+	ConsentUrl       string // Page asking the users to consent to the scopes requested by third party clients
+	MfaUrl           string // Page asking the users for their second factor
+	IdentifierUrl    string // Page asking the users for their email address when their auth provider is not known yet
+}
+
+// NewService creates the auth service.
+func NewService(deps Deps, opts Options) *service {
 	return &service{
-		authP:      authP,
-		clientSvc:  clientSvc,
-		cacheSvc:   cacheSvc,
-		jwtSvc:     jwtSvc,
-		encSvc:     encSvc,
-		usrSvc:     usrSvc,
-		tokenTTL:   tokenTTL,
-		refetchTTL: refetchTTL,
+		authP:            deps.AuthProviders,
+		clientSvc:        deps.Clients,
+		cacheSvc:         deps.Cache,
+		jwtSvc:           deps.Jwt,
+		encSvc:           deps.Encrypt,
+		usrSvc:           deps.Users,
+		refreshSvc:       deps.RefreshTokens,
+		consentSvc:       deps.Consents,
+		passwordSvc:      deps.Passwords,
+		passwordlessSvc:  deps.Passwordless,
+		mfaSvc:           deps.Mfa,
+		passkeySvc:       deps.Passkeys,
+		ldapSvc:          deps.Ldap,
+		identitySvc:      deps.Identities,
+		projectSvc:       deps.Projects,
+		tokenTTL:         opts.TokenTTL,
+		refetchTTL:       opts.RefetchTTL,
+		accessTokenTTL:   opts.AccessTokenTTL,
+		introspectionTTL: opts.IntrospectionTTL,
+		maxTokenSize:     opts.MaxTokenSize,
+		issuer:           opts.Issuer,
+		consentUrl:       opts.ConsentUrl,
+		mfaUrl:           opts.MfaUrl,
+		identifierUrl:    opts.IdentifierUrl,
 	}
 }
 
-func (s service) GetLoginUrl(ctx context.Context, clientId, authProviderId, state, redirectUrl, codeChallengeMethod, codeChallenge string) (string, error) {
+func (s service) GetLoginUrl(ctx context.Context, params sdk.AuthLoginParams) (string, error) {
 	/*
-	 * We first get the client details from the client service if authproviderid is not provided
-	 * Then we will get the auth provider details from the auth provider service for the default auth provider
-	 * Then we will call the GetLoginUrl method on the auth provider
+	 * We first get the client details from the client service
+	 * Then we validate the PKCE parameters and the requested scopes against the client's settings
+	 * When no auth provider is given and the project of the client routes the logins by email domain,
+	 * the login hint picks the provider, or the user is sent to the identifier page to type their email
+	 * Otherwise we will get the auth provider details from the auth provider service, the client's default one if authproviderid is not provided
+	 * The auth provider has to be of the project of the client
+	 * Then we will call the GetLoginUrl method on the auth provider, with a fresh nonce kept in the state
+	 * for the providers binding their tokens to the login
 	 */
-	if len(authProviderId) == 0 {
-		client, err := s.clientSvc.Get(ctx, clientId, true)
+	client, err := s.clientSvc.Get(ctx, params.ClientId, true)
+	if err != nil {
+		return "", fmt.Errorf("error fetching client details %w", err)
+	}
+	params.CodeChallengeMethod, err = validateCodeChallenge(*client, params.CodeChallenge, params.CodeChallengeMethod)
+	if err != nil {
+		return "", fmt.Errorf("error validating the code challenge %w", err)
+	}
+	params.Scope, err = grantScope(*client, params.Scope)
+	if err != nil {
+		return "", fmt.Errorf("error validating the scope %w", err)
+	}
+	err = s.checkProjectScopes(ctx, client.ProjectId, params.Scope)
+	if err != nil {
+		return "", fmt.Errorf("error validating the scope %w", err)
+	}
+	params.LinkUserId = ""
+	if len(params.AuthProviderId) == 0 {
+		routing, err := s.getLoginRouting(ctx, client.ProjectId)
 		if err != nil {
-			return "", fmt.Errorf("error fetching client details %w", err)
+			return "", err
+		}
+		if routing != nil {
+			return s.routeLogin(ctx, *client, *routing, params)
 		}
-		authProviderId = client.DefaultAuthProviderId
+		params.AuthProviderId = client.DefaultAuthProviderId
 	}
 
-	p, err := s.authP.Get(ctx, authProviderId, true)
+	p, err := s.authP.Get(ctx, params.AuthProviderId, true)
 	if err != nil {
 		return "", fmt.Errorf("error fetching auth provider details %w", err)
 	}
-	sp, err := s.authP.GetProvider(ctx, *p)
+	if p.ProjectId != client.ProjectId {
+		return "", sdk.ErrAuthProviderNotFound
+	}
+	return s.authCodeUrl(ctx, *p, params)
+}
+
+func (s service) DiscoverLogin(ctx context.Context, req sdk.LoginDiscoveryRequest) (*sdk.LoginDiscoveryResponse, error) {
+	/*
+	 * the challenge holds the params of the login waiting for the email address of the user
+	 * the routing of the project of the client gives the auth providers of the email domain
+	 * a single auth provider continues the login, several ones are offered to the user to choose from
+	 * and the chosen one has to be among them
+	 */
+	params, err := s.getPendingDiscovery(ctx, req.LoginChallenge)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.clientSvc.Get(ctx, params.ClientId, true)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching client details %w", err)
+	}
+	routing, err := s.getLoginRouting(ctx, client.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if routing == nil {
+		routing = &sdk.LoginRouting{}
+	}
+	candidates, err := s.loginCandidates(ctx, *client, *routing, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, sdk.ErrNoAuthProviderForEmail
+	}
+
+	var chosen *sdk.AuthProvider
+	if len(req.AuthProviderId) > 0 {
+		for i := range candidates {
+			if candidates[i].Id == req.AuthProviderId {
+				chosen = &candidates[i]
+			}
+		}
+		if chosen == nil {
+			return nil, sdk.ErrAuthProviderNotFound
+		}
+	} else if len(candidates) == 1 {
+		chosen = &candidates[0]
+	} else {
+		providers := []sdk.LoginProvider{}
+		for _, c := range candidates {
+			providers = append(providers, sdk.LoginProvider{Id: c.Id, Name: c.Name, Icon: c.Icon, Provider: c.Provider})
+		}
+		return &sdk.LoginDiscoveryResponse{AuthProviders: providers}, nil
+	}
+
+	err = s.cacheSvc.Delete(ctx, discoveryCacheKey(req.LoginChallenge))
+	if err != nil {
+		return nil, fmt.Errorf("error invalidating the login challenge %w", err)
+	}
+	params.LoginHint = req.Email
+	redirectUrl, err := s.authCodeUrl(ctx, *chosen, *params)
+	if err != nil {
+		return nil, err
+	}
+	return &sdk.LoginDiscoveryResponse{RedirectUrl: redirectUrl}, nil
+}
+
+func (s service) StartIdentityLink(ctx context.Context, usr sdk.User, req sdk.UserIdentityLinkRequest) (string, error) {
+	/*
+	 * the auth provider and the client have to be of the project of the user
+	 * and the redirect url has to be registered with the client
+	 * the user then logs in with the auth provider, the state remembers to link the
+	 * identity to them instead of logging them in
+	 */
+	p, err := s.authP.Get(ctx, req.AuthProviderId, true)
+	if err != nil {
+		return "", fmt.Errorf("error fetching auth provider details %w", err)
+	}
+	if p.ProjectId != usr.ProjectId {
+		return "", sdk.ErrAuthProviderNotFound
+	}
+	cl, err := s.clientSvc.Get(ctx, req.ClientId, true)
+	if err != nil {
+		return "", fmt.Errorf("error fetching client details %w", err)
+	}
+	if cl.ProjectId != usr.ProjectId {
+		return "", sdk.ErrClientNotFound
+	}
+	if !registeredRedirectUrl(*cl, req.RedirectUrl) {
+		return "", fmt.Errorf("%w - %s", sdk.ErrRedirectUrlNotFound, req.RedirectUrl)
+	}
+	return s.authCodeUrl(ctx, *p, sdk.AuthLoginParams{
+		ClientId:       cl.Id,
+		AuthProviderId: p.Id,
+		RedirectUrl:    req.RedirectUrl,
+		State:          req.State,
+		LinkUserId:     usr.Id,
+	})
+}
+
+// authCodeUrl caches the login params as the state and returns the url of the auth provider the user logs in at
+func (s service) authCodeUrl(ctx context.Context, p sdk.AuthProvider, params sdk.AuthLoginParams) (string, error) {
+	sp, err := s.authP.GetProvider(ctx, p)
 	if err != nil {
 		return "", fmt.Errorf("error getting service provider %w", err)
 	}
 	// it is important to note that we are combining the state with the client id
-	newState, err := s.cacheState(ctx, state, clientId, p.Id, redirectUrl, codeChallengeMethod, codeChallenge)
+	params.AuthProviderId = p.Id
+	params.ProviderNonce = ""
+	nsp, withNonce := sp.(sdk.NonceServiceProvider)
+	if withNonce {
+		params.ProviderNonce = uuid.NewString()
+	}
+	newState, err := s.cacheState(ctx, params)
 	if err != nil {
 		return "", fmt.Errorf("error caching the state %w", err)
 	}
+	if withNonce {
+		return nsp.GetAuthCodeUrlWithNonce(newState, params.ProviderNonce), nil
+	}
 	return sp.GetAuthCodeUrl(newState), nil
 }
 func (s service) Redirect(ctx context.Context, code, state string) (*sdk.AuthRedirectResponse, error) {
 	/*
 	 * get the state, authprovider id and client id from the state
 	 * generate the access token
+	 * logins started to link an identity link it to the user and send them back to the client
+	 * resolve the user logging in. users with a second factor, users the mfa policy of
+	 * the project requires one from and logins asking for a step-up are sent to the mfa page
+	 * denied logins send the user back to the client with the reason
+	 * third party clients need the consent of the user for the requested scopes.
+	 * the user is sent to the consent page if they haven't consented yet
 	 * cache the token
 	 * get the callback details from client service
 	 * return the callback details
 	 */
-	clientId, oState, authProviderId, redirectUrl, codeChallengeMethod, codeChallenge, err := s.getCacheState(ctx, state)
+	params, err := s.getCacheState(ctx, state)
 	if err != nil {
 		return nil, fmt.Errorf("error getting the state from cache %w", err)
 	}
 
-	if len(codeChallengeMethod) != 0 && strings.Compare(codeChallengeMethod, "S256") != 0 {
-		return nil, fmt.Errorf("invalid code challenge")
-	}
-
-	token, err := s.getToken(ctx, authProviderId, code)
+	token, err := s.getToken(ctx, params.AuthProviderId, code, params.ProviderNonce)
 	if err != nil {
 		return nil, fmt.Errorf("error getting the token %w", err)
 	}
-	token.CodeChallengeMethod = codeChallengeMethod
-	token.CodeChallenge = codeChallenge
-	token.ClientId = clientId
+	token.CodeChallengeMethod = params.CodeChallengeMethod
+	token.CodeChallenge = params.CodeChallenge
+	token.ClientId = params.ClientId
+	token.Nonce = params.Nonce
+	token.AuthTime = time.Now()
+	token.RedirectUrl = params.RedirectUrl
+	token.Scope = params.Scope
+
+	if len(params.LinkUserId) > 0 {
+		redirectUrl, err := s.linkIdentity(ctx, *token, *params)
+		if errors.Is(err, sdk.ErrLoginDenied) {
+			return s.denyLogin(ctx, state, *params, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = s.invalidateState(ctx, state)
+		if err != nil {
+			log.Errorf("error invalidating state %s", err)
+		}
+		return &sdk.AuthRedirectResponse{RedirectUrl: redirectUrl}, nil
+	}
 
-	authCode, err := s.cacheAuthToken(ctx, *token)
+	cl, err := s.clientSvc.Get(ctx, params.ClientId, true)
 	if err != nil {
-		return nil, fmt.Errorf("error caching the token %w", err)
+		return nil, fmt.Errorf("error fetching client details %w", err)
+	}
+	usr, err := s.getLoginUser(ctx, *token)
+	if errors.Is(err, sdk.ErrLoginDenied) {
+		return s.denyLogin(ctx, state, *params, err)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	redirectUrl, err = s.getRedirectUrl(ctx, clientId, redirectUrl, authCode, oState, codeChallenge)
+	redirectUrl, err := s.requireMfa(ctx, *usr, *token, *params)
 	if err != nil {
-		return nil, fmt.Errorf("error getting the callback url %w", err)
+		return nil, fmt.Errorf("error checking the second factor of the user %w", err)
+	}
+	if len(redirectUrl) == 0 {
+		redirectUrl, err = s.completeLogin(ctx, *cl, *usr, *token, *params)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	err = s.invalidateState(ctx, state)
@@ -117,6 +363,437 @@ func (s service) Redirect(ctx context.Context, code, state string) (*sdk.AuthRed
 	return &sdk.AuthRedirectResponse{RedirectUrl: redirectUrl}, nil
 }
 
+func (s service) GetMfaPrompt(ctx context.Context, mfaChallenge string) (*sdk.MfaPrompt, error) {
+	pending, err := s.getPendingMfa(ctx, mfaChallenge)
+	if err != nil {
+		return nil, err
+	}
+	return &sdk.MfaPrompt{
+		MfaChallenge: mfaChallenge,
+		UserName:     pending.UserName,
+		UserEmail:    pending.UserEmail,
+		Enrolled:     pending.Enrollment == nil,
+		Passkey:      pending.Passkey,
+		Enrollment:   pending.Enrollment,
+	}, nil
+}
+
+func (s service) VerifyMfa(ctx context.Context, req sdk.MfaVerifyRequest) (*sdk.MfaVerifyResponse, error) {
+	/*
+	 * get the pending login of the mfa challenge
+	 * users enrolling during the login confirm the new authenticator and get their recovery codes,
+	 * the others verify an authenticator or recovery code
+	 * a wrong code counts as an attempt, the challenge is dropped after too many of them
+	 * the mfa service also counts the wrong codes of the user across logins and refuses them for a while after too many
+	 * on success the challenge is dropped and the token is marked as multi-factor
+	 * then the login continues with the consent or the auth code
+	 */
+	pending, err := s.getPendingMfa(ctx, req.MfaChallenge)
+	if err != nil {
+		return nil, err
+	}
+	usr, err := s.usrSvc.GetById(ctx, pending.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the user %w", err)
+	}
+
+	var recoveryCodes []string
+	if pending.Enrollment != nil {
+		recoveryCodes, err = s.mfaSvc.Confirm(ctx, *usr, req.Code)
+	} else {
+		err = s.mfaSvc.Verify(ctx, *usr, req.Code)
+	}
+	if errors.Is(err, sdk.ErrInvalidMfaCode) {
+		return nil, s.failMfaAttempt(ctx, req.MfaChallenge, *pending, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error verifying the second factor %w", err)
+	}
+
+	redirectUrl, err := s.completeMfa(ctx, req.MfaChallenge, *usr, *pending, sdk.AmrOtp)
+	if err != nil {
+		return nil, err
+	}
+	return &sdk.MfaVerifyResponse{RedirectUrl: redirectUrl, RecoveryCodes: recoveryCodes}, nil
+}
+
+func (s service) StartMfaPasskey(ctx context.Context, req sdk.MfaPasskeyStartRequest) (*sdk.WebAuthnRequestOptions, error) {
+	/*
+	 * get the pending login of the mfa challenge
+	 * start a passkey login limited to the passkeys of the user
+	 */
+	pending, err := s.getPendingMfa(ctx, req.MfaChallenge)
+	if err != nil {
+		return nil, err
+	}
+	usr, err := s.usrSvc.GetById(ctx, pending.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the user %w", err)
+	}
+	return s.passkeySvc.BeginLogin(ctx, usr.ProjectId, mfaPasskeySession(req.MfaChallenge), usr)
+}
+
+func (s service) VerifyMfaPasskey(ctx context.Context, req sdk.MfaPasskeyVerifyRequest) (*sdk.MfaVerifyResponse, error) {
+	/*
+	 * get the pending login of the mfa challenge
+	 * verify the passkey login started for the challenge
+	 * a failed verification counts as an attempt, like a wrong code
+	 * on success the login continues as with a code
+	 */
+	pending, err := s.getPendingMfa(ctx, req.MfaChallenge)
+	if err != nil {
+		return nil, err
+	}
+	usr, err := s.usrSvc.GetById(ctx, pending.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the user %w", err)
+	}
+	_, err = s.passkeySvc.FinishLogin(ctx, usr.ProjectId, mfaPasskeySession(req.MfaChallenge), req.Credential)
+	if errors.Is(err, sdk.ErrInvalidPasskey) {
+		return nil, s.failMfaAttempt(ctx, req.MfaChallenge, *pending, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error verifying the passkey %w", err)
+	}
+
+	redirectUrl, err := s.completeMfa(ctx, req.MfaChallenge, *usr, *pending, sdk.AmrPasskey)
+	if err != nil {
+		return nil, err
+	}
+	return &sdk.MfaVerifyResponse{RedirectUrl: redirectUrl}, nil
+}
+
+func (s service) GetConsentPrompt(ctx context.Context, consentChallenge string) (*sdk.ConsentPrompt, error) {
+	pending, err := s.getPendingConsent(ctx, consentChallenge)
+	if err != nil {
+		return nil, err
+	}
+	cl, err := s.clientSvc.Get(ctx, pending.Params.ClientId, true)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching client details %w", err)
+	}
+	p, err := s.projectSvc.Get(ctx, cl.ProjectId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the project of the client %w", err)
+	}
+	scopes := sdk.ParseScope(pending.Params.Scope)
+	return &sdk.ConsentPrompt{
+		ConsentChallenge: consentChallenge,
+		ClientId:         cl.Id,
+		ClientName:       cl.Name,
+		ProjectId:        cl.ProjectId,
+		Scopes:           scopes,
+		ScopeDetails:     p.DescribeScopes(scopes),
+		UserName:         pending.UserName,
+		UserEmail:        pending.UserEmail,
+	}, nil
+}
+
+func (s service) DecideConsent(ctx context.Context, decision sdk.ConsentDecision) (*sdk.AuthRedirectResponse, error) {
+	/*
+	 * get the pending login of the consent challenge. a challenge can be answered only once
+	 * send the user back to the client with the access_denied error if they deny
+	 * otherwise record the consent and continue the login by issuing the auth code
+	 */
+	pending, err := s.getPendingConsent(ctx, decision.ConsentChallenge)
+	if err != nil {
+		return nil, err
+	}
+	err = s.cacheSvc.Delete(ctx, fmt.Sprintf("consent-%s", decision.ConsentChallenge))
+	if err != nil {
+		return nil, fmt.Errorf("error invalidating the consent challenge %w", err)
+	}
+
+	if !decision.Approve {
+		redirectUrl, err := s.getAccessDeniedRedirectUrl(ctx, pending.Params.ClientId, pending.Params.RedirectUrl, pending.Params.State, "")
+		if err != nil {
+			return nil, fmt.Errorf("error getting the callback url %w", err)
+		}
+		return &sdk.AuthRedirectResponse{RedirectUrl: redirectUrl}, nil
+	}
+
+	cl, err := s.clientSvc.Get(ctx, pending.Params.ClientId, true)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching client details %w", err)
+	}
+	_, err = s.consentSvc.Grant(ctx, pending.UserId, *cl, sdk.ParseScope(pending.Params.Scope))
+	if err != nil {
+		return nil, fmt.Errorf("error recording the consent %w", err)
+	}
+
+	redirectUrl, err := s.issueAuthCode(ctx, pending.Token, pending.Params)
+	if err != nil {
+		return nil, err
+	}
+	return &sdk.AuthRedirectResponse{RedirectUrl: redirectUrl}, nil
+}
+
+func (s service) RevokeConsent(ctx context.Context, userId, clientId string) error {
+	/*
+	 * delete the consent
+	 * revoke the refresh tokens issued to the client for the user
+	 * record the revocation time, access tokens of the client issued before it are rejected
+	 */
+	err := s.consentSvc.Revoke(ctx, userId, clientId)
+	if err != nil {
+		return fmt.Errorf("error revoking the consent %w", err)
+	}
+
+	err = s.refreshSvc.RevokeUserClient(ctx, userId, clientId)
+	if err != nil {
+		return fmt.Errorf("error revoking the refresh tokens %w", err)
+	}
+
+	err = s.cacheSvc.Set(ctx, fmt.Sprintf("revoked-consent-%s-%s", userId, clientId), strconv.FormatInt(time.Now().Unix(), 10), s.revocationTTL())
+	if err != nil {
+		return fmt.Errorf("error saving the revocation %w", err)
+	}
+	return nil
+}
+
+func (s service) PasswordLogin(ctx context.Context, req sdk.PasswordLoginRequest, ip string) (*sdk.AuthRedirectResponse, error) {
+	/*
+	 * get the password auth provider of the login from the state
+	 * check the credentials, we get back a single use code
+	 * continue as if the provider had redirected back with the code
+	 */
+	p, err := s.getPasswordProvider(ctx, req.State)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := s.passwordSvc.Login(ctx, *p, req.Email, req.Password, ip)
+	if err != nil {
+		return nil, fmt.Errorf("error checking the credentials %w", err)
+	}
+	return s.Redirect(ctx, code, req.State)
+}
+
+func (s service) PasswordSignup(ctx context.Context, req sdk.PasswordSignupRequest) error {
+	/*
+	 * get the password auth provider of the login from the state
+	 * sign up the user, they get an email to verify the address
+	 */
+	p, err := s.getPasswordProvider(ctx, req.State)
+	if err != nil {
+		return err
+	}
+
+	err = s.passwordSvc.Signup(ctx, *p, req)
+	if err != nil {
+		return fmt.Errorf("error signing up %w", err)
+	}
+	return nil
+}
+
+func (s service) ForgotPassword(ctx context.Context, req sdk.PasswordForgotRequest) error {
+	/*
+	 * get the password auth provider of the login from the state
+	 * send the password reset link to the user
+	 */
+	p, err := s.getPasswordProvider(ctx, req.State)
+	if err != nil {
+		return err
+	}
+
+	err = s.passwordSvc.Forgot(ctx, *p, req.Email)
+	if err != nil {
+		return fmt.Errorf("error sending the password reset link %w", err)
+	}
+	return nil
+}
+
+func (s service) StartPasswordless(ctx context.Context, req sdk.PasswordlessStartRequest, ip string) error {
+	/*
+	 * get the passwordless auth provider of the login from the state
+	 * email the login code or the magic link to the user
+	 */
+	p, err := s.getStateProvider(ctx, req.State)
+	if err != nil {
+		return err
+	}
+	if p.Provider != sdk.AuthProviderTypePasswordless {
+		return sdk.ErrNotPasswordlessProvider
+	}
+
+	err = s.passwordlessSvc.Start(ctx, *p, req.State, req.Email, ip)
+	if err != nil {
+		return fmt.Errorf("error sending the login email %w", err)
+	}
+	return nil
+}
+
+func (s service) StartSmsOtp(ctx context.Context, req sdk.SmsOtpStartRequest, ip string) error {
+	/*
+	 * get the sms auth provider of the login from the state
+	 * text the login code to the phone number, it is verified the same way as the emailed codes
+	 */
+	p, err := s.getStateProvider(ctx, req.State)
+	if err != nil {
+		return err
+	}
+	if p.Provider != sdk.AuthProviderTypeSms {
+		return sdk.ErrNotSmsProvider
+	}
+
+	err = s.passwordlessSvc.Start(ctx, *p, req.State, req.Phone, ip)
+	if err != nil {
+		return fmt.Errorf("error sending the login sms %w", err)
+	}
+	return nil
+}
+
+func (s service) PasswordlessVerify(ctx context.Context, req sdk.PasswordlessVerifyRequest) (*sdk.AuthRedirectResponse, error) {
+	/*
+	 * the magic link token leads to the state of its login, the code is checked against the state
+	 * either way we get back a single use login code
+	 * continue as if the provider had redirected back with the code
+	 */
+	state := req.State
+	var code string
+	var err error
+	if len(req.Token) > 0 {
+		code, state, err = s.passwordlessSvc.VerifyLink(ctx, req.Token)
+	} else {
+		code, err = s.passwordlessSvc.VerifyOtp(ctx, req.State, req.Code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error verifying the login code %w", err)
+	}
+	return s.Redirect(ctx, code, state)
+}
+
+func (s service) PasskeyLoginStart(ctx context.Context, req sdk.PasskeyLoginStartRequest) (*sdk.WebAuthnRequestOptions, error) {
+	/*
+	 * the state has to be of a login with the passkey auth provider
+	 * start a login where the user picks any passkey of the project
+	 */
+	p, err := s.getPasskeyProvider(ctx, req.State)
+	if err != nil {
+		return nil, err
+	}
+	return s.passkeySvc.BeginLogin(ctx, p.ProjectId, loginPasskeySession(req.State), nil)
+}
+
+func (s service) PasskeyLoginVerify(ctx context.Context, req sdk.PasskeyLoginVerifyRequest) (*sdk.AuthRedirectResponse, error) {
+	/*
+	 * verify the passkey login started for the state
+	 * get a single use login code for the address of the owner of the passkey
+	 * continue as if the provider had redirected back with the code
+	 */
+	p, err := s.getPasskeyProvider(ctx, req.State)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := s.passkeySvc.FinishLogin(ctx, p.ProjectId, loginPasskeySession(req.State), req.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying the passkey %w", err)
+	}
+	usr, err := s.usrSvc.GetById(ctx, pk.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the owner of the passkey %w", err)
+	}
+	address := usr.Email
+	if len(address) == 0 {
+		address = usr.Phone
+	}
+	if len(address) == 0 {
+		return nil, fmt.Errorf("the owner of the passkey has no email or phone")
+	}
+	code, err := s.passkeySvc.IssueLoginCode(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("error issuing the login code %w", err)
+	}
+	return s.Redirect(ctx, code, req.State)
+}
+
+func (s service) SamlAcs(ctx context.Context, req sdk.SamlAcsRequest) (*sdk.AuthRedirectResponse, error) {
+	/*
+	 * check that the login of the relay state is with a saml auth provider
+	 * continue as if the provider had redirected back with a code,
+	 * the provider verifies the saml response when the code is verified
+	 */
+	p, err := s.getStateProvider(ctx, req.RelayState)
+	if err != nil {
+		return nil, err
+	}
+	if p.Provider != sdk.AuthProviderTypeSAML {
+		return nil, sdk.ErrNotSamlProvider
+	}
+	return s.Redirect(ctx, saml.ResponseCode(req.RelayState, req.SamlResponse), req.RelayState)
+}
+
+func (s service) SamlMetadata(ctx context.Context, authProviderId string) ([]byte, error) {
+	p, err := s.authP.Get(ctx, authProviderId, true)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching auth provider details %w", err)
+	}
+	if p.Provider != sdk.AuthProviderTypeSAML {
+		return nil, sdk.ErrNotSamlProvider
+	}
+	return saml.Metadata(*p)
+}
+
+func (s service) LdapLogin(ctx context.Context, req sdk.LdapLoginRequest, ip string) (*sdk.AuthRedirectResponse, error) {
+	/*
+	 * get the ldap auth provider of the login from the state
+	 * check the credentials against the directory, we get back a single use code
+	 * continue as if the provider had redirected back with the code
+	 */
+	p, err := s.getStateProvider(ctx, req.State)
+	if err != nil {
+		return nil, err
+	}
+	if p.Provider != sdk.AuthProviderTypeLDAP {
+		return nil, sdk.ErrNotLdapProvider
+	}
+
+	code, err := s.ldapSvc.Login(ctx, *p, req.Username, req.Password, ip)
+	if err != nil {
+		return nil, fmt.Errorf("error checking the credentials %w", err)
+	}
+	return s.Redirect(ctx, code, req.State)
+}
+
+func (s service) getPasskeyProvider(ctx context.Context, state string) (*sdk.AuthProvider, error) {
+	p, err := s.getStateProvider(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	if p.Provider != sdk.AuthProviderTypePasskey {
+		return nil, sdk.ErrNotPasskeyProvider
+	}
+	return p, nil
+}
+
+func (s service) getPasswordProvider(ctx context.Context, state string) (*sdk.AuthProvider, error) {
+	p, err := s.getStateProvider(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	if p.Provider != sdk.AuthProviderTypePassword {
+		return nil, sdk.ErrNotPasswordProvider
+	}
+	return p, nil
+}
+
+func (s service) getStateProvider(ctx context.Context, state string) (*sdk.AuthProvider, error) {
+	/*
+	 * get the auth provider id from the state
+	 * fetch the auth provider
+	 */
+	params, err := s.getCacheState(ctx, state)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the state from cache %w", err)
+	}
+
+	p, err := s.authP.Get(ctx, params.AuthProviderId, true)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching auth provider details %w", err)
+	}
+	return p, nil
+}
+
 func (s service) SynchronizeIdentity(ctx context.Context, userId string) error {
 	accessToken, err := s.getAccessTokenForUserId(ctx, userId)
 	if err != nil {
@@ -129,54 +806,102 @@ func (s service) SynchronizeIdentity(ctx context.Context, userId string) error {
 	return nil
 }
 
-func (s service) ClientCallback(ctx context.Context, code, codeChallenge, clientId, clientSecret string) (*sdk.AuthVerifyCodeResponse, error) {
+func (s service) ClientCallback(ctx context.Context, code, codeVerifier, clientId, clientSecret string) (*sdk.AuthVerifyCodeResponse, error) {
+	return s.exchangeCode(ctx, code, codeVerifier, "", clientId, clientSecret)
+}
+
+func (s service) Token(ctx context.Context, req sdk.TokenRequest) (*sdk.AuthVerifyCodeResponse, error) {
+	switch req.GrantType {
+	case sdk.GrantTypeAuthorizationCode:
+		return s.exchangeCode(ctx, req.Code, req.CodeVerifier, req.RedirectUri, req.ClientId, req.ClientSecret)
+	case sdk.GrantTypeClientCredentials:
+		return s.clientCredentials(ctx, req.ClientId, req.ClientSecret, req.Scope)
+	case sdk.GrantTypeRefreshToken:
+		return s.refreshToken(ctx, req.RefreshToken, req.ClientId, req.ClientSecret, req.Scope)
+	default:
+		return nil, fmt.Errorf("%w - %s", sdk.ErrUnsupportedGrantType, req.GrantType)
+	}
+}
+
+func (s service) exchangeCode(ctx context.Context, code, codeVerifier, redirectUrl, clientId, clientSecret string) (*sdk.AuthVerifyCodeResponse, error) {
 	/*
-	 * get the code from the cache
+	 * take the code out of the cache before issuing anything, a code is redeemed only once
+	 * authenticate the client, confidential clients by their secret, disabled clients are refused
+	 * verify the code verifier against the code challenge stored with the code
+	 * the code has to be issued to the client and for the redirect url if it is given
 	 * generate the access token and store the original token in cache
-	 * invalidate the code from cache
-	 * return the access token
+	 * reissue the access token as a self contained one if the client is configured for it
+	 * issue the id token and the refresh token
+	 * return the tokens
 	 */
 
-	// get the auth token from cache
+	token, err := s.consumeAuthToken(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error getting the token from cache %w", sdk.ErrInvalidGrant, err)
+	}
 
-	isPublicClient := len(codeChallenge) != 0
-	isPrivateClient := len(clientSecret) != 0
-	token, err := s.getAuthTokenFromCache(ctx, code)
+	cl, err := s.authenticateClient(ctx, clientId, clientSecret)
 	if err != nil {
-		return nil, fmt.Errorf("error getting the token from cache %w", err)
+		return nil, err
 	}
 
-	if isPrivateClient {
-		err = s.handlePrivateClient(ctx, clientId, clientSecret)
-		if err != nil {
-			return nil, fmt.Errorf("error handling private client %w", err)
-		}
+	// public clients have nothing but the code verifier to prove that they started the login
+	if cl.Public && len(token.CodeChallenge) == 0 {
+		return nil, fmt.Errorf("%w: public clients have to use a code verifier", sdk.ErrInvalidGrant)
+	}
+	err = verifyCodeVerifier(*token, codeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error verifying the code verifier %w", sdk.ErrInvalidGrant, err)
 	}
 
-	if isPublicClient {
-		err = s.handlePublicClient(clientId, codeChallenge, *token)
-		if err != nil {
-			return nil, fmt.Errorf("error handling public client %w", err)
-		}
+	if len(token.ClientId) > 0 && token.ClientId != clientId {
+		return nil, fmt.Errorf("%w: code was not issued to the client", sdk.ErrInvalidGrant)
+	}
+	if len(redirectUrl) > 0 && !strings.EqualFold(token.RedirectUrl, redirectUrl) {
+		return nil, fmt.Errorf("%w: redirect url does not match the one used at login", sdk.ErrInvalidGrant)
 	}
 
+	token.SessionId = uuid.NewString()
+	token.PublicClient = cl.Public
 	accessTokenId, err := s.cacheAccessToken(ctx, *token, "")
 	if err != nil {
 		return nil, fmt.Errorf("error caching the access token %w", err)
 	}
 
 	// generate jwt access token
-	accessToken, err := s.jwtSvc.GenerateToken(map[string]interface{}{"id": accessTokenId}, time.Now().AddDate(0, 0, 1).Unix())
+	accessToken, err := s.generateAccessToken(accessTokenId, clientId)
 	if err != nil {
 		return nil, fmt.Errorf("error generating the access token %w", err)
 	}
 
-	err = s.invalidateAuthToken(ctx, code)
+	usr, err := s.GetIdentity(ctx, accessToken, false)
 	if err != nil {
-		return nil, fmt.Errorf("error invalidating the auth code %w", err)
+		return nil, fmt.Errorf("error fetching the user identity %w", err)
 	}
 
-	return &sdk.AuthVerifyCodeResponse{AccessToken: accessToken}, nil
+	// self contained access tokens can only be minted once the user is known
+	if cl.HasSelfContainedAccessToken() {
+		accessToken, err = s.generateClientAccessToken(*cl, accessTokenId, *usr, *token)
+		if err != nil {
+			return nil, fmt.Errorf("error generating the access token %w", err)
+		}
+		err = s.cacheUserDetails(ctx, accessToken, *usr)
+		if err != nil {
+			return nil, fmt.Errorf("error caching the user details %w", err)
+		}
+	}
+
+	idToken, err := s.generateIdToken(*usr, *token)
+	if err != nil {
+		return nil, fmt.Errorf("error generating the id token %w", err)
+	}
+
+	refreshToken, err := s.refreshSvc.Create(ctx, usr.Id, *token)
+	if err != nil {
+		return nil, fmt.Errorf("error issuing the refresh token %w", err)
+	}
+
+	return s.tokenResponse(accessToken, idToken, refreshToken, token.Scope), nil
 
 }
 
@@ -199,6 +924,10 @@ func (s service) GetIdentity(ctx context.Context, accessToken string, forceFetch
 	if !forceFetch {
 		usr, err = s.getUserFromCache(ctx, accessToken)
 		if err == nil && usr != nil {
+			err = s.checkRevocation(ctx, usr.Id, claims)
+			if err != nil {
+				return nil, err
+			}
 			log.Debugf("fetched user records from cache - %s", usr.Id)
 			return usr, nil
 		}
@@ -223,10 +952,15 @@ func (s service) GetIdentity(ctx context.Context, accessToken string, forceFetch
 			return nil, fmt.Errorf("error getting the identity from auth provider %w", err)
 		}
 
-		usr, err = s.getOrCreateUser(ctx, *identity)
+		var linked *sdk.UserIdentity
+		usr, linked, err = s.getOrCreateUser(ctx, *identity)
 		if err != nil {
 			return nil, fmt.Errorf("error getting or creating the user %w", err)
 		}
+		usr, err = s.syncGroupRoles(ctx, *usr, linked, *identity)
+		if err != nil {
+			return nil, fmt.Errorf("error syncing the roles of the user %w", err)
+		}
 
 	}
 	err = s.cacheUserDetails(ctx, accessToken, *usr)
@@ -234,49 +968,122 @@ func (s service) GetIdentity(ctx context.Context, accessToken string, forceFetch
 		return nil, fmt.Errorf("error caching the user details %w", err)
 	}
 
+	err = s.checkRevocation(ctx, usr.Id, claims)
+	if err != nil {
+		return nil, err
+	}
+
 	return usr, nil
 }
 
-func (s service) getOrCreateUser(ctx context.Context, usr sdk.User) (*sdk.User, error) {
+// providerIdentity is the user as reported by the auth provider they logged in with
+type providerIdentity struct {
+	User     sdk.User
+	Provider sdk.AuthProvider
+	Subject  string   // Stable id of the user at the auth provider, their email address or phone for the providers without one
+	External bool     // Whether the auth provider reported its own subject, the internal providers log in with the address of the account
+	Groups   []string // Groups of the user at the auth provider, nil when the provider did not report them
+}
+
+// checkEmailVerified refuses the identity when the auth provider requires verified email addresses and did not report its one as verified
+func (pid providerIdentity) checkEmailVerified() error {
+	if pid.Provider.RequireVerifiedEmail && len(pid.User.Email) > 0 && !pid.User.EmailVerified {
+		return fmt.Errorf("%w: %w", sdk.ErrLoginDenied, sdk.ErrEmailNotVerified)
+	}
+	return nil
+}
+
+func (s service) getOrCreateUser(ctx context.Context, pid providerIdentity) (*sdk.User, *sdk.UserIdentity, error) {
 	/*
-	 * get the user from the user service
-	 * if user not found, create the user
+	 * refuse unverified email addresses when the auth provider requires them
+	 * get the user of the identity linked to the subject at the auth provider, an identity the user unlinked is refused
+	 * otherwise get the user by their email or phone from the user service
+	 * an external identity is linked to a user found by email only when both sides verified the address,
+	 * an internal provider verifying the address marks the one of the user verified
+	 * if user not found, create the user following the provisioning rules of the auth provider
+	 * link the identity to the user found by email or phone or created
+	 * disabled, pending and expired users are denied
 	 */
+	err := pid.checkEmailVerified()
+	if err != nil {
+		return nil, nil, err
+	}
+	usr := pid.User
+	linked, err := s.identitySvc.Get(ctx, pid.Provider.Id, pid.Subject)
+	if err != nil && !errors.Is(err, sdk.ErrUserIdentityNotFound) {
+		return nil, nil, fmt.Errorf("error fetching the linked identity %w", err)
+	}
 	var u *sdk.User
-	var err error
-	if len(usr.Email) > 0 {
-		u, err = s.usrSvc.GetByEmail(ctx, usr.Email, usr.ProjectId)
-	} else if len(usr.Phone) > 0 {
-		u, err = s.usrSvc.GetByPhone(ctx, usr.Phone, usr.ProjectId)
-	} else {
-		return nil, fmt.Errorf("email or phone is required")
+	if linked != nil && linked.UnlinkedAt != nil {
+		// the user removed the identity from their account, finding them by email would link it right back
+		return nil, nil, fmt.Errorf("%w: %w", sdk.ErrLoginDenied, sdk.ErrUserIdentityUnlinked)
 	}
-	if err != nil && errors.Is(err, user.ErrorUserNotFound) {
-		// we need to create the user
-		err = s.usrSvc.Create(ctx, &usr)
+	if linked != nil {
+		u, err = s.usrSvc.GetById(ctx, linked.UserId)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error fetching the user of the linked identity %w", err)
+		}
+	} else {
+		if len(usr.Email) > 0 {
+			u, err = s.usrSvc.GetByEmail(ctx, usr.Email, usr.ProjectId)
+		} else if len(usr.Phone) > 0 {
+			u, err = s.usrSvc.GetByPhone(ctx, usr.Phone, usr.ProjectId)
+		} else {
+			return nil, nil, fmt.Errorf("email or phone is required")
+		}
+		if err != nil && errors.Is(err, user.ErrorUserNotFound) {
+			// we need to create the user
+			u, err = s.provisionUser(ctx, pid.Provider, usr)
+			if err != nil {
+				return nil, nil, err
+			}
+		} else if err != nil {
+			// other error occurred during user lookup
+			return nil, nil, fmt.Errorf("error fetching user %w", err)
+		} else if pid.External && len(usr.Email) > 0 && !(usr.EmailVerified && u.EmailVerified) {
+			// anyone can set an unverified address at a provider, linking on it would hand over the account
+			return nil, nil, fmt.Errorf("%w: %w", sdk.ErrLoginDenied, sdk.ErrUnverifiedEmailLink)
+		} else if !pid.External && usr.EmailVerified && !u.EmailVerified && len(usr.Email) > 0 {
+			// the internal providers proved the address of the account
+			u.EmailVerified = true
+			err = s.usrSvc.Update(ctx, u)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error verifying the email of the user %w", err)
+			}
+		}
+		linked = &sdk.UserIdentity{
+			UserId:         u.Id,
+			ProjectId:      u.ProjectId,
+			AuthProviderId: pid.Provider.Id,
+			Subject:        pid.Subject,
+			Email:          usr.Email,
+			EmailVerified:  usr.EmailVerified,
+		}
+		err = s.identitySvc.Link(ctx, linked)
 		if err != nil {
-			return nil, fmt.Errorf("error creating the user %w", err)
+			return nil, nil, fmt.Errorf("error linking the identity to the user %w", err)
 		}
-		u = &usr
-	} else if err != nil {
-		// other error occurred during user lookup
-		return nil, fmt.Errorf("error fetching user %w", err)
+	}
+	if !u.Enabled && u.PendingApproval {
+		return nil, nil, fmt.Errorf("%w: the user is pending approval by an admin", sdk.ErrLoginDenied)
 	}
 	if !u.Enabled {
-		return nil, errors.New("user is disabled")
+		return nil, nil, fmt.Errorf("%w: user is disabled", sdk.ErrLoginDenied)
 	}
 
 	if u.Expiry != nil && u.Expiry.Before(time.Now()) {
-		return nil, errors.New("user expired")
+		return nil, nil, fmt.Errorf("%w: user expired", sdk.ErrLoginDenied)
 	}
 
-	return u, nil
+	return u, linked, nil
 }
 
-func (s service) getAuthProivderIdentity(ctx context.Context, token *sdk.AuthToken, accessTokenId string) (*sdk.User, error) {
+func (s service) getAuthProivderIdentity(ctx context.Context, token *sdk.AuthToken, accessTokenId string) (*providerIdentity, error) {
 	/*
 	 * get the service provider
 	 * call the get identity method on the service provider
+	 * the subject identifies the user at the auth provider, the internal providers
+	 * know the users only by their email or phone
 	 */
 	p, err := s.authP.Get(ctx, token.AuthProviderID, true)
 	if err != nil {
@@ -300,11 +1107,24 @@ func (s service) getAuthProivderIdentity(ctx context.Context, token *sdk.AuthTok
 	if err != nil {
 		return nil, fmt.Errorf("error getting the identity from service provider %w", err)
 	}
-	user := sdk.User{ProjectId: p.ProjectId}
+	pid := providerIdentity{User: sdk.User{ProjectId: p.ProjectId}, Provider: *p}
 	for _, id := range identity {
-		id.UpdateUserDetails(&user)
+		id.UpdateUserDetails(&pid.User)
+		if sub, ok := id.Metadata.(sdk.AuthIdentitySubject); ok {
+			pid.Subject = sub.Subject
+			pid.External = true
+		}
+		if g, ok := id.Metadata.(sdk.AuthIdentityGroups); ok {
+			pid.Groups = append([]string{}, g.Groups...)
+		}
+	}
+	if len(pid.Subject) == 0 {
+		pid.Subject = pid.User.Email
 	}
-	return &user, nil
+	if len(pid.Subject) == 0 {
+		pid.Subject = pid.User.Phone
+	}
+	return &pid, nil
 }
 
 func (s service) getServiceAccountUser(ctx context.Context, token *sdk.AuthToken) (*sdk.User, error) {
@@ -387,12 +1207,12 @@ func (s service) getUserFromCache(ctx context.Context, accessToken string) (*sdk
 	return &result, nil
 }
 
-func (s service) getToken(ctx context.Context, authProviderId, code string) (*sdk.AuthToken, error) {
+func (s service) getToken(ctx context.Context, authProviderId, code, nonce string) (*sdk.AuthToken, error) {
 	/*
 	 * get the client details
 	 * get the auth provider details
 	 * get the service provider
-	 * call the verify code on the service provider
+	 * call the verify code on the service provider, checking the nonce of the login when the provider uses one
 	 */
 	p, err := s.authP.Get(ctx, authProviderId, true)
 	if err != nil {
@@ -403,11 +1223,23 @@ func (s service) getToken(ctx context.Context, authProviderId, code string) (*sd
 		return nil, fmt.Errorf("error getting service provider %w", err)
 	}
 
-	token, err := sp.VerifyCode(ctx, code)
+	var token *sdk.AuthToken
+	if nsp, ok := sp.(sdk.NonceServiceProvider); ok {
+		token, err = nsp.VerifyCodeWithNonce(ctx, code, nonce)
+	} else {
+		token, err = sp.VerifyCode(ctx, code)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error verifying the code %w", err)
 	}
 	token.AuthProviderID = authProviderId
+	token.Amr = []string{firstFactorAmr(p.Provider)}
+	token.Acr = sdk.AcrSingleFactor
+	if p.Provider == sdk.AuthProviderTypePasskey {
+		// the authenticator verified the user before signing, so the passkey stands for both factors
+		token.Amr = addAmr(token.Amr, sdk.AmrMfa)
+		token.Acr = sdk.AcrMultiFactor
+	}
 	return token, nil
 }
 
@@ -481,12 +1313,12 @@ func (s service) cacheAuthToken(ctx context.Context, token sdk.AuthToken) (strin
 	return authCode, nil
 }
 
-func (s service) getAuthTokenFromCache(ctx context.Context, authCode string) (*sdk.AuthToken, error) {
+func (s service) consumeAuthToken(ctx context.Context, authCode string) (*sdk.AuthToken, error) {
 	/*
-	 * get the value from cache
+	 * get and delete the value from cache at once, the code is gone for concurrent redemptions
 	 */
 
-	val, err := s.cacheSvc.Get(ctx, fmt.Sprintf("auth-code-%s", authCode))
+	val, err := s.cacheSvc.GetDel(ctx, fmt.Sprintf("auth-code-%s", authCode))
 	if err != nil {
 		return nil, fmt.Errorf("error fetching the value from cache %w", err)
 	}
@@ -503,25 +1335,18 @@ func (s service) getAuthTokenFromCache(ctx context.Context, authCode string) (*s
 	return &result, nil
 }
 
-func (s service) invalidateAuthToken(ctx context.Context, authCode string) error {
-	/*
-	 * delete the value from cache
-	 */
-	err := s.cacheSvc.Delete(ctx, fmt.Sprintf("auth-code-%s", authCode))
-	if err != nil {
-		return fmt.Errorf("error deleting the value from cache %w", err)
-	}
-	return nil
-}
-
-func (s service) cacheState(ctx context.Context, state, clientId, providerId, redirectUrl, codeChallengeMethod, codeChallenge string) (string, error) {
+func (s service) cacheState(ctx context.Context, params sdk.AuthLoginParams) (string, error) {
 	/*
-	 * add the extra info required in cache
+	 * encode the login params to json
 	 * generate a new state id
 	 * save the state in cache
 	 */
-	newState := fmt.Sprintf("%s:%s:%s:%s:%s:%s", state, clientId, providerId, url.QueryEscape(redirectUrl), codeChallengeMethod, codeChallenge)
-	st, err := s.encSvc.Encrypt(newState)
+	b := bytes.NewBuffer([]byte{})
+	err := json.NewEncoder(b).Encode(params)
+	if err != nil {
+		return "", fmt.Errorf("error encoding the state %w", err)
+	}
+	st, err := s.encSvc.Encrypt(b.String())
 	if err != nil {
 		return "", fmt.Errorf("error encrypting the state %w", err)
 	}
@@ -533,35 +1358,25 @@ func (s service) cacheState(ctx context.Context, state, clientId, providerId, re
 	return stateId, nil
 }
 
-func (s service) getCacheState(ctx context.Context, stateId string) (string, string, string, string, string, string, error) {
+func (s service) getCacheState(ctx context.Context, stateId string) (*sdk.AuthLoginParams, error) {
 	/*
 	 * get the state from cache
 	 */
 	val, err := s.cacheSvc.Get(ctx, fmt.Sprintf("state-%s", stateId))
 	if err != nil {
-		return "", "", "", "", "", "", fmt.Errorf("error fetching the state from cache %w", err)
+		return nil, fmt.Errorf("error fetching the state from cache %w", err)
 	}
 
 	state, err := s.encSvc.Decrypt(val)
 	if err != nil {
-		return "", "", "", "", "", "", fmt.Errorf("error decrypting the state %w", err)
+		return nil, fmt.Errorf("error decrypting the state %w", err)
 	}
-	stateParts := strings.Split(state, ":")
-	if len(stateParts) != 6 {
-		return "", "", "", "", "", "", fmt.Errorf("invalid state. expected to have 6 parts but got %d", len(stateParts))
-	}
-	clientId := stateParts[1]
-	oState := stateParts[0]
-	authProviderId := stateParts[2]
-	redirectUrl := stateParts[3]
-	codeChallengeMethod := stateParts[4]
-	codeChallenge := stateParts[5]
-
-	urlDecoded, err := url.QueryUnescape(redirectUrl)
+	result := sdk.AuthLoginParams{}
+	err = json.NewDecoder(strings.NewReader(state)).Decode(&result)
 	if err != nil {
-		return "", "", "", "", "", "", fmt.Errorf("error decoding the redirect url %w", err)
+		return nil, fmt.Errorf("error decoding the state %w", err)
 	}
-	return clientId, oState, authProviderId, urlDecoded, codeChallengeMethod, codeChallenge, nil
+	return &result, nil
 }
 
 func (s service) invalidateState(ctx context.Context, stateId string) error {
@@ -575,7 +1390,7 @@ func (s service) invalidateState(ctx context.Context, stateId string) error {
 	return nil
 }
 
-func (s service) getRedirectUrl(ctx context.Context, clientId, redirectUrl, authCode, state, codeChallenge string) (string, error) {
+func (s service) getRedirectUrl(ctx context.Context, clientId, redirectUrl, authCode, state string) (string, error) {
 	/*
 	 * get the client details
 	 * get the auth provider details
@@ -586,14 +1401,7 @@ func (s service) getRedirectUrl(ctx context.Context, clientId, redirectUrl, auth
 	if err != nil {
 		return "", fmt.Errorf("error fetching client details %w", err)
 	}
-	found := false
-	for _, cb := range cl.RedirectURLs {
-		if strings.EqualFold(cb, redirectUrl) {
-			found = true
-			break
-		}
-	}
-	if !found {
+	if !registeredRedirectUrl(*cl, redirectUrl) {
 		return "", fmt.Errorf("callback url not found in the client details - %s", redirectUrl)
 	}
 	if strings.Contains(redirectUrl, "?") {
@@ -601,55 +1409,67 @@ func (s service) getRedirectUrl(ctx context.Context, clientId, redirectUrl, auth
 	} else {
 		redirectUrl = fmt.Sprintf("%s?code=%s&state=%s", redirectUrl, authCode, state)
 	}
-
-	if len(codeChallenge) != 0 {
-		redirectUrl = fmt.Sprintf("%s&code_challenge=%s", redirectUrl, generateCodeChallengeS256(codeChallenge))
-	}
 	return redirectUrl, nil
 }
 
 func (s service) ClientCredentials(ctx context.Context, clientId, clientSecret string) (*sdk.AuthVerifyCodeResponse, error) {
+	return s.clientCredentials(ctx, clientId, clientSecret, "")
+}
+
+// clientCredentials issues the tokens of the service account linked to the client for the requested scopes
+func (s service) clientCredentials(ctx context.Context, clientId, clientSecret, scope string) (*sdk.AuthVerifyCodeResponse, error) {
 	// Step 1: Validate client credentials
 	cl, err := s.clientSvc.Get(ctx, clientId, true)
 	if err != nil {
-		return nil, fmt.Errorf("invalid client_id: %w", err)
+		return nil, fmt.Errorf("%w: invalid client_id: %w", sdk.ErrInvalidClient, err)
 	}
 
 	if !cl.Enabled {
-		return nil, errors.New("client is disabled")
+		return nil, fmt.Errorf("%w: client is disabled", sdk.ErrInvalidClient)
 	}
 
 	err = s.clientSvc.VerifySecret(clientSecret, cl.Secret)
 	if err != nil {
-		return nil, fmt.Errorf("authentication failed: %w", err)
+		return nil, fmt.Errorf("%w: authentication failed: %w", sdk.ErrInvalidClient, err)
 	}
 
 	// Step 2: Check if client uses GoIAM/CLIENT auth provider
 	if !cl.IsServiceAccount() {
-		return nil, errors.New("client does not support service account flow")
+		return nil, fmt.Errorf("%w: client does not support service account flow", sdk.ErrUnauthorizedClient)
 	}
 
 	// Step 3: Validate linked user
 	if cl.LinkedUserId == "" {
-		return nil, errors.New("client does not have a linked user for service account flow")
+		return nil, fmt.Errorf("%w: client does not have a linked user for service account flow", sdk.ErrUnauthorizedClient)
 	}
 
 	user, err := s.usrSvc.GetById(ctx, cl.LinkedUserId)
 	if err != nil {
-		return nil, fmt.Errorf("linked user not found: %w", err)
+		return nil, fmt.Errorf("%w: linked user not found: %w", sdk.ErrInvalidGrant, err)
 	}
 
 	if !user.Enabled {
-		return nil, errors.New("linked user is disabled")
+		return nil, fmt.Errorf("%w: linked user is disabled", sdk.ErrInvalidGrant)
 	}
 
 	if user.Expiry != nil && user.Expiry.Before(time.Now()) {
-		return nil, errors.New("linked user has expired")
+		return nil, fmt.Errorf("%w: linked user has expired", sdk.ErrInvalidGrant)
+	}
+
+	grantedScope, err := grantScope(*cl, scope)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkProjectScopes(ctx, cl.ProjectId, grantedScope)
+	if err != nil {
+		return nil, err
 	}
 
 	token := sdk.AuthToken{
 		ClientId:             clientId,
 		ServiceAccountUserId: user.Id,
+		SessionId:            uuid.NewString(),
+		Scope:                grantedScope,
 	}
 
 	// Step 6: Cache the token (same as OAuth flow)
@@ -659,10 +1479,238 @@ func (s service) ClientCredentials(ctx context.Context, clientId, clientSecret s
 	}
 
 	// generate jwt access token
-	accessToken, err := s.jwtSvc.GenerateToken(map[string]interface{}{"id": accessTokenId}, time.Now().AddDate(0, 0, 1).Unix())
+	accessToken, err := s.generateClientAccessToken(*cl, accessTokenId, *user, token)
 	if err != nil {
 		return nil, fmt.Errorf("error generating the access token %w", err)
 	}
 
-	return &sdk.AuthVerifyCodeResponse{AccessToken: accessToken}, nil
+	refreshToken, err := s.refreshSvc.Create(ctx, user.Id, token)
+	if err != nil {
+		return nil, fmt.Errorf("error issuing the refresh token: %w", err)
+	}
+
+	return s.tokenResponse(accessToken, "", refreshToken, token.Scope), nil
+}
+
+func (s service) RefreshToken(ctx context.Context, refreshToken, clientId, clientSecret string) (*sdk.AuthVerifyCodeResponse, error) {
+	return s.refreshToken(ctx, refreshToken, clientId, clientSecret, "")
+}
+
+func (s service) refreshToken(ctx context.Context, refreshToken, clientId, clientSecret, scope string) (*sdk.AuthVerifyCodeResponse, error) {
+	/*
+	 * validate the client, the secret is verified whenever it is sent
+	 * rotate the refresh token, the ones issued to confidential clients need the secret
+	 * and the token has to be issued to the authenticated client
+	 * the access token can be issued for fewer scopes than the ones granted at login
+	 * the user is checked again since they could have been disabled or expired since the login
+	 * cache the session against a new access token, self contained tokens get the latest user details
+	 * return the new access token along with the rotated refresh token
+	 */
+	cl, err := s.authenticateClient(ctx, clientId, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	next, newRefreshToken, err := s.refreshSvc.Rotate(ctx, refreshToken, clientId, len(clientSecret) != 0)
+	if errors.Is(err, sdk.ErrClientAuthenticationRequired) {
+		return nil, fmt.Errorf("%w: %w", sdk.ErrInvalidClient, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: error rotating the refresh token: %w", sdk.ErrInvalidGrant, err)
+	}
+	if next.ClientId != cl.Id {
+		return nil, fmt.Errorf("%w: refresh token was not issued to the client", sdk.ErrInvalidGrant)
+	}
+	token := next.AuthToken
+	token.Scope, err = narrowScope(token.Scope, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var usr *sdk.User
+	if len(token.ServiceAccountUserId) > 0 {
+		usr, err = s.getServiceAccountUser(ctx, &token)
+	} else {
+		usr, err = s.usrSvc.GetById(ctx, next.UserId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", sdk.ErrInvalidGrant, err)
+	}
+	if !usr.Enabled {
+		return nil, fmt.Errorf("%w: user is disabled", sdk.ErrInvalidGrant)
+	}
+	if usr.Expiry != nil && usr.Expiry.Before(time.Now()) {
+		return nil, fmt.Errorf("%w: user has expired", sdk.ErrInvalidGrant)
+	}
+
+	accessTokenId, err := s.cacheAccessToken(ctx, token, "")
+	if err != nil {
+		return nil, fmt.Errorf("error caching the access token %w", err)
+	}
+	if !cl.HasSelfContainedAccessToken() {
+		accessToken, err := s.generateAccessToken(accessTokenId, clientId)
+		if err != nil {
+			return nil, fmt.Errorf("error generating the access token %w", err)
+		}
+		return s.tokenResponse(accessToken, "", newRefreshToken, token.Scope), nil
+	}
+
+	// the claims of self contained tokens are taken from the latest user details
+	accessToken, err := s.generateClientAccessToken(*cl, accessTokenId, *usr, token)
+	if err != nil {
+		return nil, fmt.Errorf("error generating the access token %w", err)
+	}
+
+	return s.tokenResponse(accessToken, "", newRefreshToken, token.Scope), nil
+}
+
+func (s service) RevokeToken(ctx context.Context, token, tokenTypeHint, clientId, clientSecret string) error {
+	/*
+	 * authenticate the client
+	 * try the token type given in the hint first and fall back to the other one
+	 * unknown or expired tokens are not an error as per RFC 7009
+	 */
+	_, err := s.authenticateClient(ctx, clientId, clientSecret)
+	if err != nil {
+		return err
+	}
+
+	if tokenTypeHint == sdk.TokenTypeHintRefreshToken {
+		revoked, err := s.revokeRefreshToken(ctx, token, clientId)
+		if err != nil || revoked {
+			return err
+		}
+		_, err = s.revokeAccessToken(ctx, token, clientId)
+		return err
+	}
+
+	revoked, err := s.revokeAccessToken(ctx, token, clientId)
+	if err != nil || revoked {
+		return err
+	}
+	_, err = s.revokeRefreshToken(ctx, token, clientId)
+	return err
+}
+
+func (s service) Logout(ctx context.Context, accessToken, clientId, postLogoutRedirectUrl, state string) (string, error) {
+	/*
+	 * validate the post logout redirect url against the client
+	 * revoke the refresh tokens of the session
+	 * clear the cached session of the access token
+	 */
+	redirectUrl := ""
+	if len(postLogoutRedirectUrl) != 0 {
+		u, err := s.getPostLogoutRedirectUrl(ctx, clientId, postLogoutRedirectUrl, state)
+		if err != nil {
+			return "", fmt.Errorf("error getting the post logout redirect url %w", err)
+		}
+		redirectUrl = u
+	}
+
+	if len(accessToken) != 0 {
+		err := s.endSession(ctx, accessToken)
+		if err != nil {
+			return "", fmt.Errorf("error ending the session %w", err)
+		}
+	}
+	return redirectUrl, nil
+}
+
+func (s service) RevokeUserTokens(ctx context.Context, userId string) error {
+	/*
+	 * revoke all the refresh tokens of the user
+	 * record the revocation time, access tokens issued before it are rejected
+	 * drop the cached identity of the last access token of the user
+	 */
+	err := s.refreshSvc.RevokeUser(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("error revoking the refresh tokens %w", err)
+	}
+
+	err = s.cacheSvc.Set(ctx, fmt.Sprintf("revoked-user-%s", userId), strconv.FormatInt(time.Now().Unix(), 10), s.revocationTTL())
+	if err != nil {
+		return fmt.Errorf("error saving the revocation %w", err)
+	}
+
+	accessToken, err := s.getAccessTokenForUserId(ctx, userId)
+	if err != nil {
+		// no access token of the user is cached
+		return nil
+	}
+	err = s.cacheSvc.Delete(ctx, fmt.Sprintf("token-%s", accessToken))
+	if err != nil {
+		return fmt.Errorf("error deleting the cached user %w", err)
+	}
+	err = s.cacheSvc.Delete(ctx, fmt.Sprintf("user-token-%s", userId))
+	if err != nil {
+		return fmt.Errorf("error deleting the user reverse mapping %w", err)
+	}
+	return nil
+}
+
+func (s service) IntrospectToken(ctx context.Context, token, clientId, clientSecret string, includeRoles bool) (*sdk.TokenIntrospectionResponse, error) {
+	/*
+	 * only confidential clients can introspect tokens
+	 * serve the cached result if there is one and the user did not sign out everywhere or revoke the consent since
+	 * introspect the token and cache it if it is active
+	 * tokens of users from other projects are reported as inactive
+	 */
+	if len(clientSecret) == 0 {
+		return nil, sdk.ErrClientAuthenticationRequired
+	}
+	cl, err := s.authenticateClient(ctx, clientId, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *sdk.TokenIntrospectionResponse
+	if s.introspectionTTL > 0 {
+		resp, err = s.getIntrospectionFromCache(ctx, token)
+		if err == nil && resp.Active && s.checkRevocation(ctx, resp.Sub, map[string]interface{}{"client_id": resp.ClientId, "iat": resp.Iat}) != nil {
+			return &sdk.TokenIntrospectionResponse{Active: false}, nil
+		}
+	}
+	if resp == nil || err != nil {
+		resp, err = s.introspect(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Active && s.introspectionTTL > 0 {
+			s.cacheIntrospection(ctx, token, *resp)
+		}
+	}
+
+	if !resp.Active || resp.ProjectId != cl.ProjectId {
+		return &sdk.TokenIntrospectionResponse{Active: false}, nil
+	}
+	if !includeRoles {
+		resp.Roles = nil
+		resp.Resources = nil
+	}
+	return resp, nil
+}
+
+func (s service) GetJwks() sdk.Jwks {
+	return s.jwtSvc.Jwks()
+}
+
+func (s service) GetOpenIdConfiguration() sdk.OpenIdConfiguration {
+	return sdk.OpenIdConfiguration{
+		Issuer:                            s.issuer,
+		AuthorizationEndpoint:             s.issuer + "/auth/v1/login",
+		TokenEndpoint:                     s.issuer + "/auth/v1/token",
+		UserinfoEndpoint:                  s.issuer + "/auth/v1/userinfo",
+		JwksUri:                           s.issuer + "/auth/v1/jwks",
+		RevocationEndpoint:                s.issuer + "/auth/v1/revoke",
+		IntrospectionEndpoint:             s.issuer + "/auth/v1/introspect",
+		EndSessionEndpoint:                s.issuer + "/auth/v1/logout",
+		ResponseTypesSupported:            []string{"code"},
+		SubjectTypesSupported:             []string{"public"},
+		IdTokenSigningAlgValuesSupported:  signingAlgorithms(s.jwtSvc.Jwks()),
+		ScopesSupported:                   sdk.StandardScopes,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post"},
+		CodeChallengeMethodsSupported:     []string{sdk.CodeChallengeMethodS256, sdk.CodeChallengeMethodPlain},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "amr", "acr", "email", "name", "picture"},
+		AcrValuesSupported:                []string{sdk.AcrSingleFactor, sdk.AcrMultiFactor},
+	}
 }
@@ -7,6 +7,11 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,6 +20,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
 	"github.com/melvinodsa/go-iam/services/user"
 	"github.com/melvinodsa/go-iam/utils"
 	"github.com/melvinodsa/go-iam/utils/goiamuniverse"
@@ -36,6 +42,11 @@ func (m *MockCacheService) Set(ctx context.Context, key string, value string, ex
 	return args.Error(0)
 }
 
+func (m *MockCacheService) GetDel(ctx context.Context, key string) (string, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockCacheService) Delete(ctx context.Context, key string) error {
 	args := m.Called(ctx, key)
 	return args.Error(0)
@@ -85,6 +96,11 @@ func (m *MockAuthProviderService) GetProvider(ctx context.Context, v sdk.AuthPro
 	return args.Get(0).(sdk.ServiceProvider), args.Error(1)
 }
 
+func (m *MockAuthProviderService) GetTypes(ctx context.Context) []sdk.AuthProviderTypeInfo {
+	args := m.Called(ctx)
+	return args.Get(0).([]sdk.AuthProviderTypeInfo)
+}
+
 type MockServiceProvider struct {
 	mock.Mock
 }
@@ -122,6 +138,24 @@ func (m *MockServiceProvider) HasRefreshTokenFlow() bool {
 	return true
 }
 
+// MockNonceServiceProvider is a service provider binding its tokens to the login with a nonce
+type MockNonceServiceProvider struct {
+	MockServiceProvider
+}
+
+func (m *MockNonceServiceProvider) GetAuthCodeUrlWithNonce(state, nonce string) string {
+	args := m.Called(state, nonce)
+	return args.String(0)
+}
+
+func (m *MockNonceServiceProvider) VerifyCodeWithNonce(ctx context.Context, code, nonce string) (*sdk.AuthToken, error) {
+	args := m.Called(ctx, code, nonce)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.AuthToken), args.Error(1)
+}
+
 type MockJWTService struct {
 	mock.Mock
 }
@@ -139,6 +173,44 @@ func (m *MockJWTService) ValidateToken(token string) (map[string]interface{}, er
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
 
+func (m *MockJWTService) GenerateIdToken(claims map[string]interface{}, expiryTimeInSeconds int64) (string, error) {
+	args := m.Called(claims, expiryTimeInSeconds)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockJWTService) Jwks() sdk.Jwks {
+	args := m.Called()
+	return args.Get(0).(sdk.Jwks)
+}
+
+func (m *MockJWTService) GetKeys(ctx context.Context) ([]sdk.SigningKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]sdk.SigningKey), args.Error(1)
+}
+
+func (m *MockJWTService) RotateKey(ctx context.Context) (*sdk.SigningKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.SigningKey), args.Error(1)
+}
+
+func (m *MockJWTService) RetireKey(ctx context.Context, id string) (*sdk.SigningKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.SigningKey), args.Error(1)
+}
+
+func (m *MockJWTService) RunKeyRotation(ctx context.Context) {
+	m.Called(ctx)
+}
+
 type MockEncryptService struct {
 	mock.Mock
 }
@@ -163,19 +235,49 @@ func setupFullTestService() (*service, *MockAuthProviderService, *services.MockC
 	mockUser := &services.MockUserService{}
 
 	svc := &service{
-		authP:      mockAuthProvider,
-		clientSvc:  mockClient,
-		cacheSvc:   mockCache,
-		jwtSvc:     mockJWT,
-		encSvc:     mockEncrypt,
-		usrSvc:     mockUser,
-		tokenTTL:   86400, // 24 hours
-		refetchTTL: 3600,  // 1 hour
+		authP:           mockAuthProvider,
+		clientSvc:       mockClient,
+		cacheSvc:        mockCache,
+		jwtSvc:          mockJWT,
+		encSvc:          mockEncrypt,
+		usrSvc:          mockUser,
+		refreshSvc:      &services.MockRefreshTokenService{},
+		consentSvc:      &services.MockConsentService{},
+		passwordSvc:     &services.MockPasswordService{},
+		passwordlessSvc: &services.MockPasswordlessService{},
+		mfaSvc:          &services.MockMfaService{},
+		passkeySvc:      &services.MockPasskeyService{},
+		ldapSvc:         &services.MockLdapService{},
+		identitySvc:     newMockIdentityService(),
+		projectSvc:      newMockProjectService(),
+		tokenTTL:        86400, // 24 hours
+		refetchTTL:      3600,  // 1 hour
+		accessTokenTTL:  60,    // 1 hour
+		issuer:          "https://iam.example.com",
+		consentUrl:      "https://iam.example.com/consent",
+		mfaUrl:          "https://iam.example.com/mfa",
+		identifierUrl:   "https://iam.example.com/identifier",
 	}
 
 	return svc, mockAuthProvider, mockClient, mockCache, mockJWT, mockEncrypt, mockUser
 }
 
+// newMockIdentityService returns an identity service without linked identities, linking the ones of the logins
+func newMockIdentityService() *services.MockIdentityService {
+	m := &services.MockIdentityService{}
+	m.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(nil, sdk.ErrUserIdentityNotFound).Maybe()
+	m.On("Link", mock.Anything, mock.Anything).Return(nil).Maybe()
+	m.On("RecordLogin", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return m
+}
+
+// newMockProjectService returns a project service of projects without login routing
+func newMockProjectService() *services.MockProjectService {
+	m := &services.MockProjectService{}
+	m.On("Get", mock.Anything, mock.Anything).Return(&sdk.Project{}, nil).Maybe()
+	return m
+}
+
 // TestNewService tests the NewService constructor function
 func TestNewService(t *testing.T) {
 	// Create mock services
@@ -185,22 +287,51 @@ func TestNewService(t *testing.T) {
 	mockJWT := &MockJWTService{}
 	mockEncrypt := &MockEncryptService{}
 	mockUser := &services.MockUserService{}
+	mockRefresh := &services.MockRefreshTokenService{}
+	mockConsent := &services.MockConsentService{}
+	mockPassword := &services.MockPasswordService{}
+	mockPasswordless := &services.MockPasswordlessService{}
+	mockMfa := &services.MockMfaService{}
+	mockPasskey := &services.MockPasskeyService{}
+	mockLdap := &services.MockLdapService{}
+	mockIdentity := &services.MockIdentityService{}
+	mockProject := &services.MockProjectService{}
 
 	// Test parameters
-	tokenTTL := int64(86400)  // 24 hours
-	refetchTTL := int64(3600) // 1 hour
+	tokenTTL := int64(86400)    // 24 hours
+	refetchTTL := int64(3600)   // 1 hour
+	accessTokenTTL := int64(60) // 1 hour
+	introspectionTTL := int64(30)
+	maxTokenSize := int64(4096)
 
 	// Call NewService
-	result := NewService(
-		mockAuthProvider,
-		mockClient,
-		mockCache,
-		mockJWT,
-		mockEncrypt,
-		mockUser,
-		tokenTTL,
-		refetchTTL,
-	)
+	result := NewService(Deps{
+		AuthProviders: mockAuthProvider,
+		Clients:       mockClient,
+		Cache:         mockCache,
+		Jwt:           mockJWT,
+		Encrypt:       mockEncrypt,
+		Users:         mockUser,
+		RefreshTokens: mockRefresh,
+		Consents:      mockConsent,
+		Passwords:     mockPassword,
+		Passwordless:  mockPasswordless,
+		Mfa:           mockMfa,
+		Passkeys:      mockPasskey,
+		Ldap:          mockLdap,
+		Identities:    mockIdentity,
+		Projects:      mockProject,
+	}, Options{
+		TokenTTL:         tokenTTL,
+		RefetchTTL:       refetchTTL,
+		AccessTokenTTL:   accessTokenTTL,
+		IntrospectionTTL: introspectionTTL,
+		MaxTokenSize:     maxTokenSize,
+		Issuer:           "https://iam.example.com",
+		ConsentUrl:       "http://localhost:4173/consent",
+		MfaUrl:           "http://localhost:4173/mfa",
+		IdentifierUrl:    "http://localhost:4173/identifier",
+	})
 
 	// Verify the result
 	require.NotNil(t, result)
@@ -212,8 +343,24 @@ func TestNewService(t *testing.T) {
 	assert.Equal(t, mockJWT, result.jwtSvc)
 	assert.Equal(t, mockEncrypt, result.encSvc)
 	assert.Equal(t, mockUser, result.usrSvc)
+	assert.Equal(t, mockRefresh, result.refreshSvc)
+	assert.Equal(t, mockConsent, result.consentSvc)
+	assert.Equal(t, mockPassword, result.passwordSvc)
+	assert.Equal(t, mockPasswordless, result.passwordlessSvc)
+	assert.Equal(t, mockMfa, result.mfaSvc)
+	assert.Equal(t, mockPasskey, result.passkeySvc)
+	assert.Equal(t, mockLdap, result.ldapSvc)
+	assert.Equal(t, mockIdentity, result.identitySvc)
+	assert.Equal(t, mockProject, result.projectSvc)
 	assert.Equal(t, tokenTTL, result.tokenTTL)
 	assert.Equal(t, refetchTTL, result.refetchTTL)
+	assert.Equal(t, accessTokenTTL, result.accessTokenTTL)
+	assert.Equal(t, introspectionTTL, result.introspectionTTL)
+	assert.Equal(t, maxTokenSize, result.maxTokenSize)
+	assert.Equal(t, "https://iam.example.com", result.issuer)
+	assert.Equal(t, "http://localhost:4173/consent", result.consentUrl)
+	assert.Equal(t, "http://localhost:4173/mfa", result.mfaUrl)
+	assert.Equal(t, "http://localhost:4173/identifier", result.identifierUrl)
 
 	// Verify the returned type is correct
 	assert.IsType(t, &service{}, result)
@@ -494,74 +641,68 @@ func TestGenerateCodeChallengeS256EdgeCases(t *testing.T) {
 	})
 }
 
-// TestHandlePublicClient tests the PKCE validation logic
-func TestHandlePublicClient(t *testing.T) {
-	svc := &service{} // No dependencies needed for this function
-
-	// Helper to generate the expected hash
-	generateExpectedHash := func(challenge string) string {
-		hash := sha256.Sum256([]byte(challenge))
-		return base64.RawURLEncoding.EncodeToString(hash[:])
-	}
-
-	originalChallenge := "test-verifier-string"
-	expectedHash := generateExpectedHash(originalChallenge)
+// a valid code verifier and its S256 code challenge
+const (
+	testCodeVerifier  = "dBjftJeZ4CVP-mJ92ZG1vC4hqlqAfBnpS3Sp8yMK6wY"
+	testCodeChallenge = "sXvbxVTq_9EBo7BC-IQS1w8oldGPjgInDJlZhcJF8QE"
+)
 
+// TestVerifyCodeVerifier tests the PKCE code verifier validation
+func TestVerifyCodeVerifier(t *testing.T) {
 	tests := []struct {
 		name          string
-		clientId      string
-		codeChallenge string // This is the hash that should match
+		codeVerifier  string
 		token         sdk.AuthToken
 		expectedError string
 	}{
 		{
-			name:          "success",
-			clientId:      "test-client",
-			codeChallenge: expectedHash, // Provide the correct hash
-			token: sdk.AuthToken{
-				ClientId:            "test-client",
-				CodeChallenge:       originalChallenge, // Store the original verifier
-				CodeChallengeMethod: "S256",
-			},
+			name:         "success - S256",
+			codeVerifier: testCodeVerifier,
+			token:        sdk.AuthToken{CodeChallenge: testCodeChallenge, CodeChallengeMethod: sdk.CodeChallengeMethodS256},
 		},
 		{
-			name:          "error - invalid code challenge method",
-			clientId:      "test-client",
-			codeChallenge: expectedHash,
-			token: sdk.AuthToken{
-				ClientId:            "test-client",
-				CodeChallenge:       originalChallenge,
-				CodeChallengeMethod: "plain",
-			},
-			expectedError: "invalid code challenge",
+			name:         "success - plain",
+			codeVerifier: testCodeVerifier,
+			token:        sdk.AuthToken{CodeChallenge: testCodeVerifier, CodeChallengeMethod: sdk.CodeChallengeMethodPlain},
 		},
 		{
-			name:          "error - invalid code verifier",
-			clientId:      "test-client",
-			codeChallenge: "wrong-hash", // Wrong hash
-			token: sdk.AuthToken{
-				ClientId:            "test-client",
-				CodeChallenge:       originalChallenge, // Correct original verifier
-				CodeChallengeMethod: "S256",
-			},
-			expectedError: "invalid code verifier",
+			name:  "success - no code challenge and no code verifier",
+			token: sdk.AuthToken{},
 		},
 		{
-			name:          "error - invalid client id",
-			clientId:      "wrong-client",
-			codeChallenge: expectedHash,
-			token: sdk.AuthToken{
-				ClientId:            "test-client",
-				CodeChallenge:       originalChallenge,
-				CodeChallengeMethod: "S256",
-			},
-			expectedError: "invalid client id",
+			name:          "error - code verifier without a code challenge",
+			codeVerifier:  testCodeVerifier,
+			token:         sdk.AuthToken{},
+			expectedError: "login had no code challenge",
+		},
+		{
+			name:          "error - missing code verifier",
+			token:         sdk.AuthToken{CodeChallenge: testCodeChallenge, CodeChallengeMethod: sdk.CodeChallengeMethodS256},
+			expectedError: "code verifier is required",
+		},
+		{
+			name:          "error - the challenge itself is sent as the verifier",
+			codeVerifier:  testCodeChallenge,
+			token:         sdk.AuthToken{CodeChallenge: testCodeChallenge, CodeChallengeMethod: sdk.CodeChallengeMethodS256},
+			expectedError: "does not match",
+		},
+		{
+			name:          "error - malformed code verifier",
+			codeVerifier:  "short-verifier",
+			token:         sdk.AuthToken{CodeChallenge: testCodeChallenge, CodeChallengeMethod: sdk.CodeChallengeMethodS256},
+			expectedError: "43 to 128 unreserved characters",
+		},
+		{
+			name:          "error - unsupported method",
+			codeVerifier:  testCodeVerifier,
+			token:         sdk.AuthToken{CodeChallenge: testCodeChallenge, CodeChallengeMethod: "S512"},
+			expectedError: "unsupported code challenge method",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := svc.handlePublicClient(tt.clientId, tt.codeChallenge, tt.token)
+			err := verifyCodeVerifier(tt.token, tt.codeVerifier)
 
 			if tt.expectedError != "" {
 				require.Error(t, err)
@@ -586,6 +727,7 @@ func TestGetLoginUrl(t *testing.T) {
 		redirectUrl         string
 		codeChallengeMethod string
 		codeChallenge       string
+		scope               string
 		setupMocks          func()
 		expectedError       string
 	}{
@@ -596,7 +738,7 @@ func TestGetLoginUrl(t *testing.T) {
 			state:               "test-state",
 			redirectUrl:         "http://localhost:3000/callback",
 			codeChallengeMethod: "S256",
-			codeChallenge:       "test-challenge",
+			codeChallenge:       testCodeChallenge,
 			setupMocks: func() {
 				mockClient.On("Get", ctx, "invalid-client", true).Return((*sdk.Client)(nil), errors.New("client not found"))
 			},
@@ -609,8 +751,9 @@ func TestGetLoginUrl(t *testing.T) {
 			state:               "test-state",
 			redirectUrl:         "http://localhost:3000/callback",
 			codeChallengeMethod: "S256",
-			codeChallenge:       "test-challenge",
+			codeChallenge:       testCodeChallenge,
 			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123"}, nil)
 				mockAuthProvider.On("Get", ctx, "invalid-provider", true).Return((*sdk.AuthProvider)(nil), errors.New("provider not found"))
 			},
 			expectedError: "error fetching auth provider details",
@@ -622,11 +765,12 @@ func TestGetLoginUrl(t *testing.T) {
 			state:               "test-state",
 			redirectUrl:         "http://localhost:3000/callback",
 			codeChallengeMethod: "S256",
-			codeChallenge:       "test-challenge",
+			codeChallenge:       testCodeChallenge,
 			setupMocks: func() {
 				// Client found but default auth provider not found
 				client := &sdk.Client{
 					Id:                    "test-client",
+					ProjectId:             "project-123",
 					DefaultAuthProviderId: "default-provider-id",
 				}
 				mockClient.On("Get", ctx, "test-client", true).Return(client, nil)
@@ -641,8 +785,9 @@ func TestGetLoginUrl(t *testing.T) {
 			state:               "test-state",
 			redirectUrl:         "http://localhost:3000/callback",
 			codeChallengeMethod: "S256",
-			codeChallenge:       "test-challenge",
+			codeChallenge:       testCodeChallenge,
 			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123"}, nil)
 				// Auth provider found but service provider creation fails
 				authProvider := &sdk.AuthProvider{
 					Id:        "valid-provider",
@@ -660,8 +805,9 @@ func TestGetLoginUrl(t *testing.T) {
 			state:               "test-state",
 			redirectUrl:         "http://localhost:3000/callback",
 			codeChallengeMethod: "S256",
-			codeChallenge:       "test-challenge",
+			codeChallenge:       testCodeChallenge,
 			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123"}, nil)
 				// Auth provider and service provider succeed but state caching fails
 				authProvider := &sdk.AuthProvider{
 					Id:        "valid-provider",
@@ -682,8 +828,9 @@ func TestGetLoginUrl(t *testing.T) {
 			state:               "test-state",
 			redirectUrl:         "http://localhost:3000/callback",
 			codeChallengeMethod: "S256",
-			codeChallenge:       "test-challenge",
+			codeChallenge:       testCodeChallenge,
 			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123"}, nil)
 				// Auth provider and service provider succeed, encryption succeeds, but cache set fails
 				authProvider := &sdk.AuthProvider{
 					Id:        "valid-provider",
@@ -706,8 +853,9 @@ func TestGetLoginUrl(t *testing.T) {
 			state:               "test-state",
 			redirectUrl:         "http://localhost:3000/callback",
 			codeChallengeMethod: "S256",
-			codeChallenge:       "test-challenge",
+			codeChallenge:       testCodeChallenge,
 			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123"}, nil)
 				// Full successful flow with explicit auth provider
 				authProvider := &sdk.AuthProvider{
 					Id:        "valid-provider",
@@ -730,11 +878,12 @@ func TestGetLoginUrl(t *testing.T) {
 			state:               "test-state",
 			redirectUrl:         "http://localhost:3000/callback",
 			codeChallengeMethod: "S256",
-			codeChallenge:       "test-challenge",
+			codeChallenge:       testCodeChallenge,
 			setupMocks: func() {
 				// Full successful flow with default auth provider lookup
 				client := &sdk.Client{
 					Id:                    "test-client",
+					ProjectId:             "project-123",
 					DefaultAuthProviderId: "default-provider-id",
 				}
 				authProvider := &sdk.AuthProvider{
@@ -761,6 +910,7 @@ func TestGetLoginUrl(t *testing.T) {
 			codeChallengeMethod: "",
 			codeChallenge:       "",
 			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123"}, nil)
 				// Full successful flow without PKCE
 				authProvider := &sdk.AuthProvider{
 					Id:        "valid-provider",
@@ -776,6 +926,126 @@ func TestGetLoginUrl(t *testing.T) {
 			},
 			expectedError: "", // Should succeed
 		},
+		{
+			name:                "error - code challenge missing for a client requiring pkce",
+			clientId:            "test-client",
+			authProviderId:      "valid-provider",
+			redirectUrl:         "http://localhost:3000/callback",
+			codeChallengeMethod: "",
+			codeChallenge:       "",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123", PkceRequired: true}, nil)
+			},
+			expectedError: sdk.ErrPkceRequired.Error(),
+		},
+		{
+			name:                "error - code challenge missing for a public client",
+			clientId:            "test-client",
+			authProviderId:      "valid-provider",
+			redirectUrl:         "http://localhost:3000/callback",
+			codeChallengeMethod: "",
+			codeChallenge:       "",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123", Public: true}, nil)
+			},
+			expectedError: sdk.ErrPkceRequired.Error(),
+		},
+		{
+			name:                "error - plain method not allowed for the client",
+			clientId:            "test-client",
+			authProviderId:      "valid-provider",
+			redirectUrl:         "http://localhost:3000/callback",
+			codeChallengeMethod: "plain",
+			codeChallenge:       testCodeVerifier,
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123"}, nil)
+			},
+			expectedError: "the plain method is not allowed",
+		},
+		{
+			name:                "error - method defaults to plain",
+			clientId:            "test-client",
+			authProviderId:      "valid-provider",
+			redirectUrl:         "http://localhost:3000/callback",
+			codeChallengeMethod: "",
+			codeChallenge:       testCodeChallenge,
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123"}, nil)
+			},
+			expectedError: "the plain method is not allowed",
+		},
+		{
+			name:                "error - unsupported code challenge method",
+			clientId:            "test-client",
+			authProviderId:      "valid-provider",
+			redirectUrl:         "http://localhost:3000/callback",
+			codeChallengeMethod: "S512",
+			codeChallenge:       testCodeChallenge,
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123", PkceAllowPlain: true}, nil)
+			},
+			expectedError: "unsupported code challenge method",
+		},
+		{
+			name:                "error - malformed code challenge",
+			clientId:            "test-client",
+			authProviderId:      "valid-provider",
+			redirectUrl:         "http://localhost:3000/callback",
+			codeChallengeMethod: "S256",
+			codeChallenge:       "test-challenge",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123"}, nil)
+			},
+			expectedError: "43 to 128 unreserved characters",
+		},
+		{
+			name:                "error - scope not allowed for the client",
+			clientId:            "test-client",
+			authProviderId:      "valid-provider",
+			redirectUrl:         "http://localhost:3000/callback",
+			codeChallengeMethod: "S256",
+			codeChallenge:       testCodeChallenge,
+			scope:               "openid orders:write",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123", Scopes: []string{"orders:read"}}, nil)
+			},
+			expectedError: "orders:write is not allowed for the client",
+		},
+		{
+			name:                "error - auth provider of another project",
+			clientId:            "test-client",
+			authProviderId:      "other-provider",
+			redirectUrl:         "http://localhost:3000/callback",
+			codeChallengeMethod: "S256",
+			codeChallenge:       testCodeChallenge,
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123"}, nil)
+				mockAuthProvider.On("Get", ctx, "other-provider", true).Return(&sdk.AuthProvider{Id: "other-provider", ProjectId: "project-456"}, nil)
+			},
+			expectedError: sdk.ErrAuthProviderNotFound.Error(),
+		},
+		{
+			name:                "success - plain method allowed for the client",
+			clientId:            "test-client",
+			authProviderId:      "valid-provider",
+			redirectUrl:         "http://localhost:3000/callback",
+			codeChallengeMethod: "plain",
+			codeChallenge:       testCodeVerifier,
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123", PkceAllowPlain: true, PkceRequired: true}, nil)
+				authProvider := &sdk.AuthProvider{
+					Id:        "valid-provider",
+					ProjectId: "project-123",
+				}
+				mockServiceProvider := &MockServiceProvider{}
+				mockAuthProvider.On("Get", ctx, "valid-provider", true).Return(authProvider, nil)
+				mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
+				mockServiceProvider.On("GetAuthCodeUrl", mock.AnythingOfType("string")).Return("https://auth-provider.com/oauth/authorize?state=cached-state-id")
+				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-state", nil)
+				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-state", mock.Anything).Return(nil)
+			},
+			expectedError: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -788,7 +1058,15 @@ func TestGetLoginUrl(t *testing.T) {
 
 			tt.setupMocks()
 
-			url, err := svc.GetLoginUrl(ctx, tt.clientId, tt.authProviderId, tt.state, tt.redirectUrl, tt.codeChallengeMethod, tt.codeChallenge)
+			url, err := svc.GetLoginUrl(ctx, sdk.AuthLoginParams{
+				ClientId:            tt.clientId,
+				AuthProviderId:      tt.authProviderId,
+				State:               tt.state,
+				RedirectUrl:         tt.redirectUrl,
+				CodeChallengeMethod: tt.codeChallengeMethod,
+				CodeChallenge:       tt.codeChallenge,
+				Scope:               tt.scope,
+			})
 
 			if tt.expectedError != "" {
 				require.Error(t, err)
@@ -807,12 +1085,216 @@ func TestGetLoginUrl(t *testing.T) {
 			mockEncrypt.AssertExpectations(t)
 		})
 	}
+
+	t.Run("error - scope not defined in the project", func(t *testing.T) {
+		mockClient.ExpectedCalls = nil
+		mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", ProjectId: "project-123", Scopes: []string{"orders:write"}}, nil)
+		mockProject := &services.MockProjectService{}
+		mockProject.On("Get", ctx, "project-123").Return(&sdk.Project{Id: "project-123", Scopes: []sdk.ProjectScope{{Name: "orders:read"}}}, nil)
+		svc.projectSvc = mockProject
+		defer func() { svc.projectSvc = newMockProjectService() }()
+
+		url, err := svc.GetLoginUrl(ctx, sdk.AuthLoginParams{
+			ClientId:            "test-client",
+			AuthProviderId:      "valid-provider",
+			RedirectUrl:         "http://localhost:3000/callback",
+			CodeChallengeMethod: "S256",
+			CodeChallenge:       testCodeChallenge,
+			Scope:               "openid orders:write",
+		})
+		assert.ErrorIs(t, err, sdk.ErrInvalidScope)
+		assert.Empty(t, url)
+	})
+}
+
+// setupLoginRouting returns a service whose project routes acme.com to its own auth provider
+// and lets the users of the other domains choose between the fallback providers
+func setupLoginRouting() (*service, *MockAuthProviderService, *services.MockClientService, *MockCacheService, *MockEncryptService) {
+	ctx := context.Background()
+	svc, mockAuthProvider, mockClient, mockCache, _, mockEncrypt, _ := setupFullTestService()
+	mockProject := &services.MockProjectService{}
+	mockProject.On("Get", ctx, "project-1").Return(&sdk.Project{
+		Id: "project-1",
+		LoginRouting: &sdk.LoginRouting{
+			Domains:  []sdk.LoginDomainRoute{{Domain: "@acme.com", AuthProviderId: "ap-acme"}},
+			Fallback: []string{"ap-google", "ap-password", "ap-other-project", "ap-disabled", "ap-deleted"},
+		},
+	}, nil)
+	svc.projectSvc = mockProject
+	mockClient.On("Get", ctx, "client-id", true).Return(&sdk.Client{Id: "client-id", ProjectId: "project-1", DefaultAuthProviderId: "ap-password"}, nil)
+	providers := []sdk.AuthProvider{
+		{Id: "ap-acme", Name: "Acme", ProjectId: "project-1", Provider: sdk.AuthProviderTypeOIDC, Enabled: true},
+		{Id: "ap-google", Name: "Google", ProjectId: "project-1", Provider: sdk.AuthProviderTypeGoogle, Enabled: true},
+		{Id: "ap-password", Name: "Password", ProjectId: "project-1", Provider: sdk.AuthProviderTypePassword, Enabled: true},
+		{Id: "ap-other-project", Name: "Other", ProjectId: "project-2", Provider: sdk.AuthProviderTypeGoogle, Enabled: true},
+		{Id: "ap-disabled", Name: "Disabled", ProjectId: "project-1", Provider: sdk.AuthProviderTypeGoogle},
+	}
+	for i := range providers {
+		mockAuthProvider.On("Get", ctx, providers[i].Id, true).Return(&providers[i], nil).Maybe()
+		sp := &MockServiceProvider{}
+		sp.On("GetAuthCodeUrl", mock.AnythingOfType("string")).Return("https://" + providers[i].Id + ".example.com/authorize").Maybe()
+		mockAuthProvider.On("GetProvider", ctx, providers[i]).Return(sp, nil).Maybe()
+	}
+	mockAuthProvider.On("Get", ctx, "ap-deleted", true).Return(nil, sdk.ErrAuthProviderNotFound).Maybe()
+	return svc, mockAuthProvider, mockClient, mockCache, mockEncrypt
+}
+
+// TestGetLoginUrlRouting tests routing the logins without an auth provider by the domain of the login hint
+func TestGetLoginUrlRouting(t *testing.T) {
+	ctx := context.Background()
+	discoveryKey := mock.MatchedBy(func(key string) bool { return strings.HasPrefix(key, "discovery-") })
+
+	t.Run("without a hint the user is asked for their email", func(t *testing.T) {
+		svc, _, _, mockCache, mockEncrypt := setupLoginRouting()
+		mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-login", nil)
+		mockCache.On("Set", ctx, discoveryKey, "encrypted-login", time.Minute*5).Return(nil)
+
+		url, err := svc.GetLoginUrl(ctx, sdk.AuthLoginParams{ClientId: "client-id", RedirectUrl: "http://callback.com"})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(url, "https://iam.example.com/identifier?login_challenge="))
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("hint of a routed domain goes to its auth provider", func(t *testing.T) {
+		svc, _, _, mockCache, mockEncrypt := setupLoginRouting()
+		mockEncrypt.On("Encrypt", mock.MatchedBy(func(raw string) bool {
+			params := sdk.AuthLoginParams{}
+			return json.Unmarshal([]byte(raw), &params) == nil && params.AuthProviderId == "ap-acme"
+		})).Return("encrypted-state", nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-state", mock.Anything).Return(nil)
+
+		url, err := svc.GetLoginUrl(ctx, sdk.AuthLoginParams{ClientId: "client-id", RedirectUrl: "http://callback.com", LoginHint: "Jane@ACME.com"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://ap-acme.example.com/authorize", url)
+	})
+
+	t.Run("hint of another domain with several fallbacks asks the user", func(t *testing.T) {
+		svc, _, _, mockCache, mockEncrypt := setupLoginRouting()
+		mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-login", nil)
+		mockCache.On("Set", ctx, discoveryKey, "encrypted-login", time.Minute*5).Return(nil)
+
+		url, err := svc.GetLoginUrl(ctx, sdk.AuthLoginParams{ClientId: "client-id", RedirectUrl: "http://callback.com", LoginHint: "jane@gmail.com"})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(url, "https://iam.example.com/identifier?login_challenge="))
+	})
+
+	t.Run("explicit auth provider skips the routing", func(t *testing.T) {
+		svc, _, _, mockCache, mockEncrypt := setupLoginRouting()
+		mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-state", nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-state", mock.Anything).Return(nil)
+
+		url, err := svc.GetLoginUrl(ctx, sdk.AuthLoginParams{ClientId: "client-id", AuthProviderId: "ap-google", LoginHint: "jane@acme.com"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://ap-google.example.com/authorize", url)
+	})
+
+	t.Run("explicit auth provider of another project", func(t *testing.T) {
+		svc, _, _, _, _ := setupLoginRouting()
+
+		url, err := svc.GetLoginUrl(ctx, sdk.AuthLoginParams{ClientId: "client-id", AuthProviderId: "ap-other-project"})
+		assert.ErrorIs(t, err, sdk.ErrAuthProviderNotFound)
+		assert.Empty(t, url)
+	})
+}
+
+// TestDiscoverLogin tests answering the login challenge of the identifier page with an email address
+func TestDiscoverLogin(t *testing.T) {
+	ctx := context.Background()
+	pending := `{"client_id":"client-id","state":"original-state","redirect_url":"http://callback.com"}`
+
+	setupPending := func() (*service, *MockCacheService, *MockEncryptService) {
+		svc, _, _, mockCache, mockEncrypt := setupLoginRouting()
+		mockCache.On("Get", ctx, "discovery-challenge-1").Return("encrypted-login", nil)
+		mockEncrypt.On("Decrypt", "encrypted-login").Return(pending, nil)
+		return svc, mockCache, mockEncrypt
+	}
+	expectLogin := func(mockCache *MockCacheService, mockEncrypt *MockEncryptService, providerId string) {
+		mockCache.On("Delete", ctx, "discovery-challenge-1").Return(nil)
+		mockEncrypt.On("Encrypt", mock.MatchedBy(func(raw string) bool {
+			params := sdk.AuthLoginParams{}
+			return json.Unmarshal([]byte(raw), &params) == nil &&
+				params.AuthProviderId == providerId &&
+				params.State == "original-state" &&
+				len(params.LoginHint) > 0
+		})).Return("encrypted-state", nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-state", mock.Anything).Return(nil)
+	}
+
+	t.Run("email of a routed domain continues with its auth provider", func(t *testing.T) {
+		svc, mockCache, mockEncrypt := setupPending()
+		expectLogin(mockCache, mockEncrypt, "ap-acme")
+
+		result, err := svc.DiscoverLogin(ctx, sdk.LoginDiscoveryRequest{LoginChallenge: "challenge-1", Email: "jane@acme.com"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://ap-acme.example.com/authorize", result.RedirectUrl)
+		assert.Empty(t, result.AuthProviders)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("email of another domain lists the usable fallbacks", func(t *testing.T) {
+		svc, mockCache, _ := setupPending()
+
+		result, err := svc.DiscoverLogin(ctx, sdk.LoginDiscoveryRequest{LoginChallenge: "challenge-1", Email: "jane@gmail.com"})
+		require.NoError(t, err)
+		assert.Empty(t, result.RedirectUrl)
+		assert.Equal(t, []sdk.LoginProvider{
+			{Id: "ap-google", Name: "Google", Provider: sdk.AuthProviderTypeGoogle},
+			{Id: "ap-password", Name: "Password", Provider: sdk.AuthProviderTypePassword},
+		}, result.AuthProviders)
+		mockCache.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("chosen fallback continues the login", func(t *testing.T) {
+		svc, mockCache, mockEncrypt := setupPending()
+		expectLogin(mockCache, mockEncrypt, "ap-google")
+
+		result, err := svc.DiscoverLogin(ctx, sdk.LoginDiscoveryRequest{LoginChallenge: "challenge-1", Email: "jane@gmail.com", AuthProviderId: "ap-google"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://ap-google.example.com/authorize", result.RedirectUrl)
+	})
+
+	t.Run("chosen auth provider has to be offered for the email", func(t *testing.T) {
+		for _, providerId := range []string{"ap-acme", "ap-other-project", "ap-disabled"} {
+			svc, _, _ := setupPending()
+
+			result, err := svc.DiscoverLogin(ctx, sdk.LoginDiscoveryRequest{LoginChallenge: "challenge-1", Email: "jane@gmail.com", AuthProviderId: providerId})
+			assert.ErrorIs(t, err, sdk.ErrAuthProviderNotFound, providerId)
+			assert.Nil(t, result)
+		}
+	})
+
+	t.Run("no usable auth provider for the email", func(t *testing.T) {
+		svc, _, _ := setupPending()
+		mockProject := &services.MockProjectService{}
+		mockProject.On("Get", ctx, "project-1").Return(&sdk.Project{
+			Id:           "project-1",
+			LoginRouting: &sdk.LoginRouting{Domains: []sdk.LoginDomainRoute{{Domain: "acme.com", AuthProviderId: "ap-disabled"}}},
+		}, nil)
+		svc.projectSvc = mockProject
+
+		result, err := svc.DiscoverLogin(ctx, sdk.LoginDiscoveryRequest{LoginChallenge: "challenge-1", Email: "jane@acme.com"})
+		assert.ErrorIs(t, err, sdk.ErrNoAuthProviderForEmail)
+		assert.Nil(t, result)
+	})
+
+	t.Run("unknown challenge", func(t *testing.T) {
+		svc, _, _, mockCache, _, _, _ := setupFullTestService()
+		mockCache.On("Get", ctx, "discovery-unknown").Return("", errors.New("key not found"))
+
+		result, err := svc.DiscoverLogin(ctx, sdk.LoginDiscoveryRequest{LoginChallenge: "unknown", Email: "jane@acme.com"})
+		assert.ErrorIs(t, err, sdk.ErrInvalidLoginChallenge)
+		assert.Nil(t, result)
+	})
 }
 
 // TestRedirect tests the Redirect method - focusing on error cases
 func TestRedirect(t *testing.T) {
 	ctx := context.Background()
-	svc, mockAuthProvider, mockClient, mockCache, _, mockEncrypt, _ := setupFullTestService()
+	svc, mockAuthProvider, mockClient, mockCache, _, mockEncrypt, mockUser := setupFullTestService()
+	mockMfa := svc.mfaSvc.(*services.MockMfaService)
+	mockPasskey := svc.passkeySvc.(*services.MockPasskeyService)
+	mockPasskey.On("List", mock.Anything, mock.Anything).Return([]sdk.Passkey{}, nil)
+	usr := &sdk.User{Id: "user-1", Email: "user@example.com", ProjectId: "project-123", Enabled: true}
 
 	tests := []struct {
 		name          string
@@ -846,21 +1328,10 @@ func TestRedirect(t *testing.T) {
 			state: "valid-state",
 			setupMocks: func() {
 				mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
-				// State with only 4 parts instead of 6
+				// State is not a json encoded login request
 				mockEncrypt.On("Decrypt", "encrypted-state").Return("state:client:provider:url", nil)
 			},
-			expectedError: "invalid state. expected to have 6 parts but got 4",
-		},
-		{
-			name:  "error - invalid code challenge method",
-			code:  "auth-code",
-			state: "valid-state",
-			setupMocks: func() {
-				mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
-				// State with invalid code challenge method (not S256)
-				mockEncrypt.On("Decrypt", "encrypted-state").Return("original-state:client-id:provider-id:http%3A//callback.com:SHA1:challenge", nil)
-			},
-			expectedError: "invalid code challenge",
+			expectedError: "error decoding the state",
 		},
 		{
 			name:  "error - auth provider getToken fails",
@@ -868,7 +1339,7 @@ func TestRedirect(t *testing.T) {
 			state: "valid-state",
 			setupMocks: func() {
 				mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
-				mockEncrypt.On("Decrypt", "encrypted-state").Return("original-state:client-id:provider-id:http%3A//callback.com:S256:challenge", nil)
+				mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com","code_challenge_method":"S256","code_challenge":"challenge"}`, nil)
 				// getToken will fail when trying to get auth provider
 				mockAuthProvider.On("Get", ctx, "provider-id", true).Return((*sdk.AuthProvider)(nil), errors.New("auth provider not found"))
 			},
@@ -880,7 +1351,7 @@ func TestRedirect(t *testing.T) {
 			state: "valid-state",
 			setupMocks: func() {
 				mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
-				mockEncrypt.On("Decrypt", "encrypted-state").Return("original-state:client-id:provider-id:http%3A//callback.com:S256:challenge", nil)
+				mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com","code_challenge_method":"S256","code_challenge":"challenge"}`, nil)
 				// Auth provider found but service provider creation fails
 				authProvider := &sdk.AuthProvider{
 					Id:        "provider-id",
@@ -897,7 +1368,7 @@ func TestRedirect(t *testing.T) {
 			state: "valid-state",
 			setupMocks: func() {
 				mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
-				mockEncrypt.On("Decrypt", "encrypted-state").Return("original-state:client-id:provider-id:http%3A//callback.com:S256:challenge", nil)
+				mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com","code_challenge_method":"S256","code_challenge":"challenge"}`, nil)
 				// Auth provider and service provider setup succeed but token verification fails
 				authProvider := &sdk.AuthProvider{
 					Id:        "provider-id",
@@ -916,7 +1387,7 @@ func TestRedirect(t *testing.T) {
 			state: "valid-state",
 			setupMocks: func() {
 				mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
-				mockEncrypt.On("Decrypt", "encrypted-state").Return("original-state:client-id:provider-id:http%3A//callback.com:S256:challenge", nil)
+				mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com","code_challenge_method":"S256","code_challenge":"challenge"}`, nil)
 				// Token verification succeeds but caching fails
 				authProvider := &sdk.AuthProvider{
 					Id:        "provider-id",
@@ -932,19 +1403,25 @@ func TestRedirect(t *testing.T) {
 				mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
 				mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
 				mockServiceProvider.On("VerifyCode", ctx, "valid-code").Return(authToken, nil)
+				mockServiceProvider.On("GetIdentity", "access-token").Return([]sdk.AuthIdentity{
+					{Type: sdk.AuthIdentityTypeEmail, Metadata: MockEmailMetadata{Email: "user@example.com"}},
+				}, nil)
+				mockUser.On("GetByEmail", ctx, "user@example.com", "project-123").Return(usr, nil)
+				mockMfa.On("Status", ctx, *usr).Return(&sdk.MfaStatus{}, nil)
+				mockClient.On("Get", ctx, "client-id", true).Return(&sdk.Client{Id: "client-id"}, nil)
 				// Caching fails during encryption
 				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("", errors.New("encryption failed"))
 			},
 			expectedError: "error caching the token",
 		},
 		{
-			name:  "error - redirect URL validation fails",
+			name:  "error - client not found",
 			code:  "valid-code",
 			state: "valid-state",
 			setupMocks: func() {
 				mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
-				mockEncrypt.On("Decrypt", "encrypted-state").Return("original-state:client-id:provider-id:http%3A//callback.com:S256:challenge", nil)
-				// Token operations succeed but redirect URL validation fails
+				mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com","code_challenge_method":"S256","code_challenge":"challenge"}`, nil)
+				// Token operations succeed but the client is gone
 				authProvider := &sdk.AuthProvider{
 					Id:        "provider-id",
 					ProjectId: "project-123",
@@ -959,13 +1436,14 @@ func TestRedirect(t *testing.T) {
 				mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
 				mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
 				mockServiceProvider.On("VerifyCode", ctx, "valid-code").Return(authToken, nil)
-				// Auth token caching succeeds
-				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-auth-token", nil)
-				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
-				// Client validation fails - client not found
+				mockServiceProvider.On("GetIdentity", "access-token").Return([]sdk.AuthIdentity{
+					{Type: sdk.AuthIdentityTypeEmail, Metadata: MockEmailMetadata{Email: "user@example.com"}},
+				}, nil)
+				mockUser.On("GetByEmail", ctx, "user@example.com", "project-123").Return(usr, nil)
+				mockMfa.On("Status", ctx, *usr).Return(&sdk.MfaStatus{}, nil)
 				mockClient.On("Get", ctx, "client-id", true).Return((*sdk.Client)(nil), errors.New("client not found"))
 			},
-			expectedError: "error getting the callback url",
+			expectedError: "error fetching client details",
 		},
 		{
 			name:  "error - redirect URL not in allowed list",
@@ -973,7 +1451,7 @@ func TestRedirect(t *testing.T) {
 			state: "valid-state",
 			setupMocks: func() {
 				mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
-				mockEncrypt.On("Decrypt", "encrypted-state").Return("original-state:client-id:provider-id:http%3A//unauthorized.com:S256:challenge", nil)
+				mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://unauthorized.com","code_challenge_method":"S256","code_challenge":"challenge"}`, nil)
 				// Token operations succeed but redirect URL not in client's allowed URLs
 				authProvider := &sdk.AuthProvider{
 					Id:        "provider-id",
@@ -993,6 +1471,11 @@ func TestRedirect(t *testing.T) {
 				mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
 				mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
 				mockServiceProvider.On("VerifyCode", ctx, "valid-code").Return(authToken, nil)
+				mockServiceProvider.On("GetIdentity", "access-token").Return([]sdk.AuthIdentity{
+					{Type: sdk.AuthIdentityTypeEmail, Metadata: MockEmailMetadata{Email: "user@example.com"}},
+				}, nil)
+				mockUser.On("GetByEmail", ctx, "user@example.com", "project-123").Return(usr, nil)
+				mockMfa.On("Status", ctx, *usr).Return(&sdk.MfaStatus{}, nil)
 				// Auth token caching succeeds
 				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-auth-token", nil)
 				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
@@ -1002,13 +1485,13 @@ func TestRedirect(t *testing.T) {
 			expectedError: "callback url not found in the client details",
 		},
 		{
-			name:  "error - URL decoding fails in state",
+			name:  "error - truncated state",
 			code:  "valid-code",
 			state: "valid-state",
 			setupMocks: func() {
 				mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
-				// State with invalid URL encoding that will fail to decode
-				mockEncrypt.On("Decrypt", "encrypted-state").Return("original-state:client-id:provider-id:http%3A//callback.com%ZZ:S256:challenge", nil)
+				// State json got truncated and will fail to decode
+				mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id"`, nil)
 			},
 			expectedError: "error getting the state from cache",
 		},
@@ -1018,7 +1501,7 @@ func TestRedirect(t *testing.T) {
 			state: "valid-state",
 			setupMocks: func() {
 				mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
-				mockEncrypt.On("Decrypt", "encrypted-state").Return("original-state:client-id:provider-id:http%3A//callback.com:S256:challenge", nil)
+				mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com","code_challenge_method":"S256","code_challenge":"challenge"}`, nil)
 				// Full successful flow to verify state invalidation is called
 				authProvider := &sdk.AuthProvider{
 					Id:        "provider-id",
@@ -1038,6 +1521,11 @@ func TestRedirect(t *testing.T) {
 				mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
 				mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
 				mockServiceProvider.On("VerifyCode", ctx, "valid-code").Return(authToken, nil)
+				mockServiceProvider.On("GetIdentity", "access-token").Return([]sdk.AuthIdentity{
+					{Type: sdk.AuthIdentityTypeEmail, Metadata: MockEmailMetadata{Email: "user@example.com"}},
+				}, nil)
+				mockUser.On("GetByEmail", ctx, "user@example.com", "project-123").Return(usr, nil)
+				mockMfa.On("Status", ctx, *usr).Return(&sdk.MfaStatus{}, nil)
 				// Auth token caching succeeds
 				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-auth-token", nil)
 				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
@@ -1054,7 +1542,7 @@ func TestRedirect(t *testing.T) {
 			state: "valid-state-fail-invalidation",
 			setupMocks: func() {
 				mockCache.On("Get", ctx, "state-valid-state-fail-invalidation").Return("encrypted-state", nil)
-				mockEncrypt.On("Decrypt", "encrypted-state").Return("original-state:client-id:provider-id:http%3A//callback.com::challenge", nil)
+				mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com","code_challenge_method":"","code_challenge":"challenge"}`, nil)
 				// Full successful flow but state invalidation fails
 				authProvider := &sdk.AuthProvider{
 					Id:        "provider-id",
@@ -1074,6 +1562,11 @@ func TestRedirect(t *testing.T) {
 				mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
 				mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
 				mockServiceProvider.On("VerifyCode", ctx, "valid-code").Return(authToken, nil)
+				mockServiceProvider.On("GetIdentity", "access-token").Return([]sdk.AuthIdentity{
+					{Type: sdk.AuthIdentityTypeEmail, Metadata: MockEmailMetadata{Email: "user@example.com"}},
+				}, nil)
+				mockUser.On("GetByEmail", ctx, "user@example.com", "project-123").Return(usr, nil)
+				mockMfa.On("Status", ctx, *usr).Return(&sdk.MfaStatus{}, nil)
 				// Auth token caching succeeds
 				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-auth-token", nil)
 				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
@@ -1093,6 +1586,8 @@ func TestRedirect(t *testing.T) {
 			mockEncrypt.ExpectedCalls = nil
 			mockAuthProvider.ExpectedCalls = nil
 			mockClient.ExpectedCalls = nil
+			mockUser.ExpectedCalls = nil
+			mockMfa.ExpectedCalls = nil
 
 			tt.setupMocks()
 
@@ -1119,47 +1614,1013 @@ func TestRedirect(t *testing.T) {
 	}
 }
 
-// TestClientCallback tests the ClientCallback method - focusing on error cases
-func TestClientCallback(t *testing.T) {
+// TestRedirectConsent tests the consent step of the logins of third party clients
+func TestRedirectConsent(t *testing.T) {
 	ctx := context.Background()
-	svc, _, mockClient, mockCache, mockJWT, mockEncrypt, _ := setupFullTestService()
+	svc, mockAuthProvider, mockClient, mockCache, _, mockEncrypt, mockUser := setupFullTestService()
+	mockConsent := svc.consentSvc.(*services.MockConsentService)
+	mockMfa := svc.mfaSvc.(*services.MockMfaService)
+	mockPasskey := svc.passkeySvc.(*services.MockPasskeyService)
+	mockPasskey.On("List", mock.Anything, mock.Anything).Return([]sdk.Passkey{}, nil)
+	mockMfa.On("Status", ctx, mock.Anything).Return(&sdk.MfaStatus{}, nil)
+
+	authProvider := &sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123"}
+	client := &sdk.Client{Id: "client-id", ThirdParty: true, RedirectURLs: []string{"http://callback.com"}}
+	usr := &sdk.User{Id: "user-1", Email: "user@example.com", Enabled: true}
+
+	setupLogin := func() {
+		mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
+		mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com","scope":"openid orders:read"}`, nil)
+		mockServiceProvider := &MockServiceProvider{}
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
+		mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
+		mockServiceProvider.On("VerifyCode", ctx, "valid-code").Return(&sdk.AuthToken{AccessToken: "access-token", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+		mockServiceProvider.On("GetIdentity", "access-token").Return([]sdk.AuthIdentity{
+			{Type: sdk.AuthIdentityTypeEmail, Metadata: MockEmailMetadata{Email: "user@example.com"}},
+		}, nil)
+		mockUser.On("GetByEmail", ctx, "user@example.com", "project-123").Return(usr, nil)
+		mockClient.On("Get", ctx, "client-id", true).Return(client, nil)
+		mockCache.On("Delete", ctx, "state-valid-state").Return(nil)
+	}
 
-	// Pre-calculate the values for the client ID mismatch test
-	originalVerifier := "original-verifier"
-	hash := sha256.Sum256([]byte(originalVerifier))
-	calculatedHash := base64.RawURLEncoding.EncodeToString(hash[:])
+	t.Run("sends the user to the consent page", func(t *testing.T) {
+		mockCache.ExpectedCalls = nil
+		mockEncrypt.ExpectedCalls = nil
+		mockAuthProvider.ExpectedCalls = nil
+		mockClient.ExpectedCalls = nil
+		mockUser.ExpectedCalls = nil
+		mockConsent.ExpectedCalls = nil
+		setupLogin()
+		// the user consented to fewer scopes than the ones requested now
+		mockConsent.On("Get", ctx, "user-1", "client-id").Return(&sdk.Consent{Scopes: []string{"openid"}}, nil)
+		mockEncrypt.On("Encrypt", mock.MatchedBy(func(val string) bool {
+			return strings.Contains(val, `"user_id":"user-1"`)
+		})).Return("encrypted-consent", nil)
+		mockCache.On("Set", ctx, mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, "consent-")
+		}), "encrypted-consent", time.Minute*5).Return(nil)
+
+		result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.RedirectUrl, "https://iam.example.com/consent?consent_challenge="))
+		mockCache.AssertExpectations(t)
+		mockConsent.AssertExpectations(t)
+	})
 
-	tests := []struct {
-		name          string
-		code          string
-		codeChallenge string
-		clientId      string
-		clientSecret  string
-		setupMocks    func()
-		expectedError string
-	}{
-		{
-			name:          "error - invalid code",
-			code:          "invalid-code",
-			codeChallenge: "",
-			clientId:      "test-client",
-			clientSecret:  "test-secret",
-			setupMocks: func() {
-				mockCache.On("Get", ctx, "auth-code-invalid-code").Return("", errors.New("code not found"))
-			},
-			expectedError: "error getting the token from cache",
-		},
-		{
-			name:          "error - auth token decryption fails",
-			code:          "valid-code-decrypt-fail",
-			codeChallenge: "",
-			clientId:      "test-client",
-			clientSecret:  "test-secret",
-			setupMocks: func() {
-				mockCache.On("Get", ctx, "auth-code-valid-code-decrypt-fail").Return("encrypted-data", nil)
-				mockEncrypt.On("Decrypt", "encrypted-data").Return("", errors.New("decryption failed"))
-			},
-			expectedError: "error decrypting the access token",
+	t.Run("skips the consent page when the scopes were consented", func(t *testing.T) {
+		mockCache.ExpectedCalls = nil
+		mockEncrypt.ExpectedCalls = nil
+		mockAuthProvider.ExpectedCalls = nil
+		mockClient.ExpectedCalls = nil
+		mockUser.ExpectedCalls = nil
+		mockConsent.ExpectedCalls = nil
+		setupLogin()
+		mockConsent.On("Get", ctx, "user-1", "client-id").Return(&sdk.Consent{Scopes: []string{"openid", "orders:read", "orders:write"}}, nil)
+		mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-auth-token", nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
+
+		result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.RedirectUrl, "http://callback.com?code="))
+		mockConsent.AssertExpectations(t)
+	})
+}
+
+// TestGetConsentPrompt tests the details of a pending consent shown to the user
+func TestGetConsentPrompt(t *testing.T) {
+	ctx := context.Background()
+	svc, _, mockClient, mockCache, _, mockEncrypt, _ := setupFullTestService()
+
+	t.Run("unknown challenge", func(t *testing.T) {
+		mockCache.ExpectedCalls = nil
+		mockCache.On("Get", ctx, "consent-unknown").Return("", errors.New("key not found"))
+
+		prompt, err := svc.GetConsentPrompt(ctx, "unknown")
+		assert.ErrorIs(t, err, sdk.ErrInvalidConsentChallenge)
+		assert.Nil(t, prompt)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockCache.ExpectedCalls = nil
+		mockCache.On("Get", ctx, "consent-challenge-1").Return("encrypted-consent", nil)
+		mockEncrypt.On("Decrypt", "encrypted-consent").Return(`{"user_id":"user-1","user_email":"user@example.com","params":{"client_id":"client-id","scope":"openid orders:read"}}`, nil)
+		mockClient.On("Get", ctx, "client-id", true).Return(&sdk.Client{Id: "client-id", Name: "Orders App", ProjectId: "project-1"}, nil)
+		mockProject := &services.MockProjectService{}
+		mockProject.On("Get", ctx, "project-1").Return(&sdk.Project{Id: "project-1", Scopes: []sdk.ProjectScope{{Name: "orders:read", Description: "Read your orders"}}}, nil)
+		svc.projectSvc = mockProject
+
+		prompt, err := svc.GetConsentPrompt(ctx, "challenge-1")
+		require.NoError(t, err)
+		assert.Equal(t, "Orders App", prompt.ClientName)
+		assert.Equal(t, "user@example.com", prompt.UserEmail)
+		assert.Equal(t, []string{"openid", "orders:read"}, prompt.Scopes)
+		assert.Equal(t, []sdk.ProjectScope{{Name: "openid"}, {Name: "orders:read", Description: "Read your orders"}}, prompt.ScopeDetails)
+	})
+}
+
+// TestDecideConsent tests the answer of the user to the consent prompt
+func TestDecideConsent(t *testing.T) {
+	ctx := context.Background()
+	svc, _, mockClient, mockCache, _, mockEncrypt, _ := setupFullTestService()
+	mockConsent := svc.consentSvc.(*services.MockConsentService)
+	client := &sdk.Client{Id: "client-id", ThirdParty: true, RedirectURLs: []string{"http://callback.com"}}
+
+	setupPending := func() {
+		mockCache.ExpectedCalls = nil
+		mockEncrypt.ExpectedCalls = nil
+		mockClient.ExpectedCalls = nil
+		mockConsent.ExpectedCalls = nil
+		mockCache.On("Get", ctx, "consent-challenge-1").Return("encrypted-consent", nil)
+		mockEncrypt.On("Decrypt", "encrypted-consent").Return(`{"user_id":"user-1","params":{"client_id":"client-id","state":"original state","redirect_url":"http://callback.com","scope":"openid orders:read"},"token":{"access_token":"access-token"}}`, nil)
+		mockCache.On("Delete", ctx, "consent-challenge-1").Return(nil)
+		mockClient.On("Get", ctx, "client-id", true).Return(client, nil)
+	}
+
+	t.Run("denied", func(t *testing.T) {
+		setupPending()
+
+		result, err := svc.DecideConsent(ctx, sdk.ConsentDecision{ConsentChallenge: "challenge-1"})
+		require.NoError(t, err)
+		assert.Equal(t, "http://callback.com?error=access_denied&state=original+state", result.RedirectUrl)
+		mockConsent.AssertNotCalled(t, "Grant", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("approved", func(t *testing.T) {
+		setupPending()
+		mockConsent.On("Grant", ctx, "user-1", *client, []string{"openid", "orders:read"}).Return(&sdk.Consent{}, nil)
+		mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-auth-token", nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
+
+		result, err := svc.DecideConsent(ctx, sdk.ConsentDecision{ConsentChallenge: "challenge-1", Approve: true})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.RedirectUrl, "http://callback.com?code="))
+		mockConsent.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("challenge answered already", func(t *testing.T) {
+		mockCache.ExpectedCalls = nil
+		mockCache.On("Get", ctx, "consent-challenge-1").Return("", errors.New("key not found"))
+
+		result, err := svc.DecideConsent(ctx, sdk.ConsentDecision{ConsentChallenge: "challenge-1", Approve: true})
+		assert.ErrorIs(t, err, sdk.ErrInvalidConsentChallenge)
+		assert.Nil(t, result)
+	})
+}
+
+// TestRedirectMfa tests that the logins needing a second factor are sent to the mfa page
+func TestRedirectMfa(t *testing.T) {
+	ctx := context.Background()
+	svc, mockAuthProvider, mockClient, mockCache, _, mockEncrypt, mockUser := setupFullTestService()
+	mockMfa := svc.mfaSvc.(*services.MockMfaService)
+	mockPasskey := svc.passkeySvc.(*services.MockPasskeyService)
+	userPasskeys := []sdk.Passkey{}
+
+	authProvider := &sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123", Provider: sdk.AuthProviderTypePassword}
+	client := &sdk.Client{Id: "client-id", RedirectURLs: []string{"http://callback.com"}}
+	usr := &sdk.User{Id: "user-1", Email: "user@example.com", ProjectId: "project-123", Enabled: true}
+
+	setupLogin := func(state string) {
+		mockCache.ExpectedCalls = nil
+		mockEncrypt.ExpectedCalls = nil
+		mockAuthProvider.ExpectedCalls = nil
+		mockClient.ExpectedCalls = nil
+		mockUser.ExpectedCalls = nil
+		mockMfa.ExpectedCalls = nil
+		mockMfa.Calls = nil
+		mockPasskey.ExpectedCalls = nil
+		mockPasskey.On("List", ctx, "user-1").Return(userPasskeys, nil)
+		mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
+		mockEncrypt.On("Decrypt", "encrypted-state").Return(state, nil)
+		mockServiceProvider := &MockServiceProvider{}
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
+		mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
+		mockServiceProvider.On("VerifyCode", ctx, "valid-code").Return(&sdk.AuthToken{AccessToken: "access-token", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+		mockServiceProvider.On("GetIdentity", "access-token").Return([]sdk.AuthIdentity{
+			{Type: sdk.AuthIdentityTypeEmail, Metadata: MockEmailMetadata{Email: "user@example.com"}},
+		}, nil)
+		mockUser.On("GetByEmail", ctx, "user@example.com", "project-123").Return(usr, nil)
+		mockClient.On("Get", ctx, "client-id", true).Return(client, nil)
+		mockCache.On("Delete", ctx, "state-valid-state").Return(nil)
+	}
+	expectPendingMfa := func(match func(pending pendingMfa) bool) {
+		mockEncrypt.On("Encrypt", mock.MatchedBy(func(raw string) bool {
+			pending := pendingMfa{}
+			return json.Unmarshal([]byte(raw), &pending) == nil && pending.UserId == "user-1" && match(pending)
+		})).Return("encrypted-mfa", nil)
+		mockCache.On("Set", ctx, mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, "mfa-")
+		}), "encrypted-mfa", time.Minute*5).Return(nil)
+	}
+	state := `{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com"}`
+
+	t.Run("users with a second factor are asked for it", func(t *testing.T) {
+		setupLogin(state)
+		mockMfa.On("Status", ctx, *usr).Return(&sdk.MfaStatus{Enabled: true}, nil)
+		expectPendingMfa(func(pending pendingMfa) bool {
+			return pending.Enrollment == nil &&
+				slices.Equal(pending.Token.Amr, []string{sdk.AmrPassword}) &&
+				pending.Token.Acr == sdk.AcrSingleFactor
+		})
+
+		result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.RedirectUrl, "https://iam.example.com/mfa?mfa_challenge="))
+		mockCache.AssertExpectations(t)
+		mockMfa.AssertNotCalled(t, "Enroll", mock.Anything, mock.Anything)
+	})
+
+	t.Run("users the policy requires a second factor from enroll one", func(t *testing.T) {
+		setupLogin(state)
+		enrollment := &sdk.MfaEnrollment{Secret: "SECRET", ProvisioningUri: "otpauth://totp/x"}
+		mockMfa.On("Status", ctx, *usr).Return(&sdk.MfaStatus{Required: true}, nil)
+		mockMfa.On("Enroll", ctx, *usr).Return(enrollment, nil)
+		expectPendingMfa(func(pending pendingMfa) bool {
+			return pending.Enrollment != nil && pending.Enrollment.Secret == "SECRET"
+		})
+
+		result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.RedirectUrl, "https://iam.example.com/mfa?mfa_challenge="))
+		mockMfa.AssertExpectations(t)
+	})
+
+	t.Run("clients asking for the multi-factor acr get a step-up", func(t *testing.T) {
+		setupLogin(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com","acr_values":"urn:go-iam:acr:mfa"}`)
+		mockMfa.On("Status", ctx, *usr).Return(&sdk.MfaStatus{}, nil)
+		mockMfa.On("Enroll", ctx, *usr).Return(&sdk.MfaEnrollment{Secret: "SECRET"}, nil)
+		expectPendingMfa(func(pending pendingMfa) bool { return pending.Enrollment != nil })
+
+		result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.RedirectUrl, "https://iam.example.com/mfa?mfa_challenge="))
+	})
+
+	t.Run("users without a second factor log in with one", func(t *testing.T) {
+		setupLogin(state)
+		mockMfa.On("Status", ctx, *usr).Return(&sdk.MfaStatus{}, nil)
+		mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-auth-token", nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
+
+		result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.RedirectUrl, "http://callback.com?code="))
+	})
+
+	t.Run("users with a passkey are asked for it without enrolling an authenticator", func(t *testing.T) {
+		userPasskeys = []sdk.Passkey{{Id: "passkey-1", UserId: "user-1"}}
+		defer func() { userPasskeys = []sdk.Passkey{} }()
+		setupLogin(state)
+		mockMfa.On("Status", ctx, *usr).Return(&sdk.MfaStatus{Required: true}, nil)
+		expectPendingMfa(func(pending pendingMfa) bool { return pending.Passkey && pending.Enrollment == nil })
+
+		result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.RedirectUrl, "https://iam.example.com/mfa?mfa_challenge="))
+		mockMfa.AssertNotCalled(t, "Enroll", mock.Anything, mock.Anything)
+	})
+
+	t.Run("passkey logins are multi-factor already", func(t *testing.T) {
+		authProvider.Provider = sdk.AuthProviderTypePasskey
+		defer func() { authProvider.Provider = sdk.AuthProviderTypePassword }()
+		setupLogin(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com","acr_values":"urn:go-iam:acr:mfa"}`)
+		mockEncrypt.On("Encrypt", mock.MatchedBy(func(raw string) bool {
+			token := sdk.AuthToken{}
+			return json.Unmarshal([]byte(raw), &token) == nil &&
+				slices.Equal(token.Amr, []string{sdk.AmrPasskey, sdk.AmrMfa}) &&
+				token.Acr == sdk.AcrMultiFactor
+		})).Return("encrypted-auth-token", nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
+
+		result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.RedirectUrl, "http://callback.com?code="))
+		mockMfa.AssertNotCalled(t, "Status", mock.Anything, mock.Anything)
+	})
+
+	t.Run("mfa status fails", func(t *testing.T) {
+		setupLogin(state)
+		mockMfa.On("Status", ctx, *usr).Return(nil, errors.New("db down"))
+
+		result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error checking the second factor of the user")
+		assert.Nil(t, result)
+	})
+}
+
+// TestGetMfaPrompt tests describing a pending mfa challenge to the mfa page
+func TestGetMfaPrompt(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, mockCache, _, mockEncrypt, _ := setupFullTestService()
+
+	t.Run("unknown challenge", func(t *testing.T) {
+		mockCache.ExpectedCalls = nil
+		mockCache.On("Get", ctx, "mfa-unknown").Return("", errors.New("key not found"))
+
+		prompt, err := svc.GetMfaPrompt(ctx, "unknown")
+		assert.ErrorIs(t, err, sdk.ErrInvalidMfaChallenge)
+		assert.Nil(t, prompt)
+	})
+
+	t.Run("enrollment during the login", func(t *testing.T) {
+		mockCache.ExpectedCalls = nil
+		mockCache.On("Get", ctx, "mfa-challenge-1").Return("encrypted-mfa", nil)
+		mockEncrypt.On("Decrypt", "encrypted-mfa").Return(`{"user_id":"user-1","user_email":"user@example.com","enrollment":{"secret":"SECRET","provisioning_uri":"otpauth://totp/x"}}`, nil)
+
+		prompt, err := svc.GetMfaPrompt(ctx, "challenge-1")
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", prompt.UserEmail)
+		assert.False(t, prompt.Enrolled)
+		assert.Equal(t, "SECRET", prompt.Enrollment.Secret)
+	})
+}
+
+// TestVerifyMfa tests answering the mfa challenge of a login
+func TestVerifyMfa(t *testing.T) {
+	ctx := context.Background()
+	svc, _, mockClient, mockCache, _, mockEncrypt, mockUser := setupFullTestService()
+	mockMfa := svc.mfaSvc.(*services.MockMfaService)
+	client := &sdk.Client{Id: "client-id", RedirectURLs: []string{"http://callback.com"}}
+	usr := &sdk.User{Id: "user-1", Email: "user@example.com", Enabled: true}
+
+	setupPending := func(pending string) {
+		mockCache.ExpectedCalls = nil
+		mockEncrypt.ExpectedCalls = nil
+		mockClient.ExpectedCalls = nil
+		mockUser.ExpectedCalls = nil
+		mockMfa.ExpectedCalls = nil
+		mockMfa.Calls = nil
+		mockCache.On("Get", ctx, "mfa-challenge-1").Return("encrypted-mfa", nil)
+		mockEncrypt.On("Decrypt", "encrypted-mfa").Return(pending, nil)
+		mockUser.On("GetById", ctx, "user-1").Return(usr, nil)
+		mockClient.On("Get", ctx, "client-id", true).Return(client, nil)
+	}
+	pending := `{"user_id":"user-1","attempts":0,"params":{"client_id":"client-id","state":"original-state","redirect_url":"http://callback.com"},"token":{"access_token":"access-token","amr":["pwd"],"acr":"urn:go-iam:acr:1fa"}}`
+
+	t.Run("correct code continues the login as multi-factor", func(t *testing.T) {
+		setupPending(pending)
+		mockMfa.On("Verify", ctx, *usr, "123456").Return(nil)
+		mockCache.On("Delete", ctx, "mfa-challenge-1").Return(nil)
+		mockEncrypt.On("Encrypt", mock.MatchedBy(func(raw string) bool {
+			token := sdk.AuthToken{}
+			return json.Unmarshal([]byte(raw), &token) == nil &&
+				slices.Equal(token.Amr, []string{sdk.AmrPassword, sdk.AmrOtp, sdk.AmrMfa}) &&
+				token.Acr == sdk.AcrMultiFactor
+		})).Return("encrypted-auth-token", nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
+
+		result, err := svc.VerifyMfa(ctx, sdk.MfaVerifyRequest{MfaChallenge: "challenge-1", Code: "123456"})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.RedirectUrl, "http://callback.com?code="))
+		assert.Empty(t, result.RecoveryCodes)
+		mockCache.AssertExpectations(t)
+		mockEncrypt.AssertExpectations(t)
+	})
+
+	t.Run("enrollment during the login returns the recovery codes", func(t *testing.T) {
+		setupPending(`{"user_id":"user-1","enrollment":{"secret":"SECRET"},"params":{"client_id":"client-id","state":"original-state","redirect_url":"http://callback.com"},"token":{"access_token":"access-token"}}`)
+		mockMfa.On("Confirm", ctx, *usr, "123456").Return([]string{"aaaaa-bbbbb"}, nil)
+		mockCache.On("Delete", ctx, "mfa-challenge-1").Return(nil)
+		mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-auth-token", nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
+
+		result, err := svc.VerifyMfa(ctx, sdk.MfaVerifyRequest{MfaChallenge: "challenge-1", Code: "123456"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aaaaa-bbbbb"}, result.RecoveryCodes)
+		mockMfa.AssertNotCalled(t, "Verify", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("wrong code counts as an attempt", func(t *testing.T) {
+		setupPending(pending)
+		mockMfa.On("Verify", ctx, *usr, "000000").Return(sdk.ErrInvalidMfaCode)
+		mockEncrypt.On("Encrypt", mock.MatchedBy(func(raw string) bool {
+			p := pendingMfa{}
+			return json.Unmarshal([]byte(raw), &p) == nil && p.Attempts == 1
+		})).Return("encrypted-mfa-2", nil)
+		mockCache.On("Set", ctx, "mfa-challenge-1", "encrypted-mfa-2", time.Minute*5).Return(nil)
+
+		result, err := svc.VerifyMfa(ctx, sdk.MfaVerifyRequest{MfaChallenge: "challenge-1", Code: "000000"})
+		assert.ErrorIs(t, err, sdk.ErrInvalidMfaCode)
+		assert.Nil(t, result)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("too many wrong codes drop the challenge", func(t *testing.T) {
+		setupPending(strings.Replace(pending, `"attempts":0`, `"attempts":4`, 1))
+		mockMfa.On("Verify", ctx, *usr, "000000").Return(sdk.ErrInvalidMfaCode)
+		mockCache.On("Delete", ctx, "mfa-challenge-1").Return(nil)
+
+		result, err := svc.VerifyMfa(ctx, sdk.MfaVerifyRequest{MfaChallenge: "challenge-1", Code: "000000"})
+		assert.ErrorIs(t, err, sdk.ErrMfaAttemptsExceeded)
+		assert.Nil(t, result)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("user with too many wrong codes is refused", func(t *testing.T) {
+		setupPending(pending)
+		mockCache.Calls = nil
+		mockMfa.On("Verify", ctx, *usr, "123456").Return(sdk.ErrTooManyMfaAttempts)
+
+		result, err := svc.VerifyMfa(ctx, sdk.MfaVerifyRequest{MfaChallenge: "challenge-1", Code: "123456"})
+		assert.ErrorIs(t, err, sdk.ErrTooManyMfaAttempts)
+		assert.Nil(t, result)
+		mockCache.AssertNotCalled(t, "Delete", ctx, "mfa-challenge-1")
+	})
+
+	t.Run("unknown challenge", func(t *testing.T) {
+		mockCache.ExpectedCalls = nil
+		mockCache.On("Get", ctx, "mfa-unknown").Return("", errors.New("key not found"))
+
+		result, err := svc.VerifyMfa(ctx, sdk.MfaVerifyRequest{MfaChallenge: "unknown", Code: "123456"})
+		assert.ErrorIs(t, err, sdk.ErrInvalidMfaChallenge)
+		assert.Nil(t, result)
+	})
+}
+
+// TestMfaPasskey tests answering the mfa challenge of a login with a passkey
+func TestMfaPasskey(t *testing.T) {
+	ctx := context.Background()
+	svc, _, mockClient, mockCache, _, mockEncrypt, mockUser := setupFullTestService()
+	mockPasskey := svc.passkeySvc.(*services.MockPasskeyService)
+	client := &sdk.Client{Id: "client-id", RedirectURLs: []string{"http://callback.com"}}
+	usr := &sdk.User{Id: "user-1", ProjectId: "project-123", Email: "user@example.com", Enabled: true}
+	credential := sdk.WebAuthnAssertion{Id: "cred-1", Type: "public-key"}
+
+	setupPending := func(pending string) {
+		mockCache.ExpectedCalls = nil
+		mockEncrypt.ExpectedCalls = nil
+		mockClient.ExpectedCalls = nil
+		mockUser.ExpectedCalls = nil
+		mockPasskey.ExpectedCalls = nil
+		mockCache.On("Get", ctx, "mfa-challenge-1").Return("encrypted-mfa", nil)
+		mockEncrypt.On("Decrypt", "encrypted-mfa").Return(pending, nil)
+		mockUser.On("GetById", ctx, "user-1").Return(usr, nil)
+		mockClient.On("Get", ctx, "client-id", true).Return(client, nil)
+	}
+	pending := `{"user_id":"user-1","passkey":true,"attempts":0,"params":{"client_id":"client-id","state":"original-state","redirect_url":"http://callback.com"},"token":{"access_token":"access-token","amr":["pwd"],"acr":"urn:go-iam:acr:1fa"}}`
+
+	t.Run("start is limited to the passkeys of the user", func(t *testing.T) {
+		setupPending(pending)
+		options := &sdk.WebAuthnRequestOptions{Challenge: "challenge"}
+		mockPasskey.On("BeginLogin", ctx, "project-123", "mfa-challenge-1", usr).Return(options, nil)
+
+		result, err := svc.StartMfaPasskey(ctx, sdk.MfaPasskeyStartRequest{MfaChallenge: "challenge-1"})
+		require.NoError(t, err)
+		assert.Equal(t, options, result)
+	})
+
+	t.Run("passkey continues the login as multi-factor", func(t *testing.T) {
+		setupPending(pending)
+		mockPasskey.On("FinishLogin", ctx, "project-123", "mfa-challenge-1", credential).Return(&sdk.Passkey{Id: "passkey-1", UserId: "user-1"}, nil)
+		mockCache.On("Delete", ctx, "mfa-challenge-1").Return(nil)
+		mockEncrypt.On("Encrypt", mock.MatchedBy(func(raw string) bool {
+			token := sdk.AuthToken{}
+			return json.Unmarshal([]byte(raw), &token) == nil &&
+				slices.Equal(token.Amr, []string{sdk.AmrPassword, sdk.AmrPasskey, sdk.AmrMfa}) &&
+				token.Acr == sdk.AcrMultiFactor
+		})).Return("encrypted-auth-token", nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
+
+		result, err := svc.VerifyMfaPasskey(ctx, sdk.MfaPasskeyVerifyRequest{MfaChallenge: "challenge-1", Credential: credential})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.RedirectUrl, "http://callback.com?code="))
+		mockCache.AssertExpectations(t)
+		mockEncrypt.AssertExpectations(t)
+	})
+
+	t.Run("failed passkey counts as an attempt", func(t *testing.T) {
+		setupPending(pending)
+		mockPasskey.On("FinishLogin", ctx, "project-123", "mfa-challenge-1", credential).Return(nil, sdk.ErrInvalidPasskey)
+		mockEncrypt.On("Encrypt", mock.MatchedBy(func(raw string) bool {
+			p := pendingMfa{}
+			return json.Unmarshal([]byte(raw), &p) == nil && p.Attempts == 1
+		})).Return("encrypted-mfa-2", nil)
+		mockCache.On("Set", ctx, "mfa-challenge-1", "encrypted-mfa-2", time.Minute*5).Return(nil)
+
+		result, err := svc.VerifyMfaPasskey(ctx, sdk.MfaPasskeyVerifyRequest{MfaChallenge: "challenge-1", Credential: credential})
+		assert.ErrorIs(t, err, sdk.ErrInvalidPasskey)
+		assert.Nil(t, result)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("too many failures drop the challenge", func(t *testing.T) {
+		setupPending(strings.Replace(pending, `"attempts":0`, `"attempts":4`, 1))
+		mockPasskey.On("FinishLogin", ctx, "project-123", "mfa-challenge-1", credential).Return(nil, sdk.ErrInvalidPasskey)
+		mockCache.On("Delete", ctx, "mfa-challenge-1").Return(nil)
+
+		_, err := svc.VerifyMfaPasskey(ctx, sdk.MfaPasskeyVerifyRequest{MfaChallenge: "challenge-1", Credential: credential})
+		assert.ErrorIs(t, err, sdk.ErrMfaAttemptsExceeded)
+	})
+}
+
+// TestRevokeConsent tests that revoking a consent revokes the tokens of the client
+func TestRevokeConsent(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, mockCache, _, _, _ := setupFullTestService()
+	mockConsent := svc.consentSvc.(*services.MockConsentService)
+	mockRefresh := svc.refreshSvc.(*services.MockRefreshTokenService)
+
+	t.Run("success", func(t *testing.T) {
+		mockConsent.On("Revoke", ctx, "user-1", "client-1").Return(nil).Once()
+		mockRefresh.On("RevokeUserClient", ctx, "user-1", "client-1").Return(nil).Once()
+		mockCache.On("Set", ctx, "revoked-consent-user-1-client-1", mock.AnythingOfType("string"), mock.Anything).Return(nil).Once()
+
+		err := svc.RevokeConsent(ctx, "user-1", "client-1")
+		require.NoError(t, err)
+		mockConsent.AssertExpectations(t)
+		mockRefresh.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("consent not found", func(t *testing.T) {
+		mockConsent.On("Revoke", ctx, "user-1", "client-2").Return(sdk.ErrConsentNotFound).Once()
+
+		err := svc.RevokeConsent(ctx, "user-1", "client-2")
+		assert.ErrorIs(t, err, sdk.ErrConsentNotFound)
+		mockRefresh.AssertNotCalled(t, "RevokeUserClient", ctx, "user-1", "client-2")
+	})
+}
+
+// TestPasswordLogin tests the login, signup and forgot password requests of the password auth provider
+func TestPasswordLogin(t *testing.T) {
+	ctx := context.Background()
+	svc, mockAuthProvider, _, mockCache, _, mockEncrypt, _ := setupFullTestService()
+	mockPassword := svc.passwordSvc.(*services.MockPasswordService)
+
+	passwordProvider := &sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123", Provider: sdk.AuthProviderTypePassword}
+	setupState := func(p *sdk.AuthProvider) {
+		mockCache.ExpectedCalls = nil
+		mockEncrypt.ExpectedCalls = nil
+		mockAuthProvider.ExpectedCalls = nil
+		mockPassword.ExpectedCalls = nil
+		mockPassword.Calls = nil
+		mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
+		mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","redirect_url":"http://callback.com"}`, nil)
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(p, nil)
+	}
+
+	t.Run("login continues with the code of the credential", func(t *testing.T) {
+		setupState(passwordProvider)
+		mockPassword.On("Login", ctx, *passwordProvider, "user@example.com", "secret", "10.0.0.1").Return("password-code", nil).Once()
+		mockServiceProvider := &MockServiceProvider{}
+		mockAuthProvider.On("GetProvider", ctx, *passwordProvider).Return(mockServiceProvider, nil)
+		mockServiceProvider.On("VerifyCode", ctx, "password-code").Return((*sdk.AuthToken)(nil), errors.New("code already used"))
+
+		_, err := svc.PasswordLogin(ctx, sdk.PasswordLoginRequest{State: "valid-state", Email: "user@example.com", Password: "secret"}, "10.0.0.1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error getting the token")
+		mockPassword.AssertExpectations(t)
+		mockServiceProvider.AssertExpectations(t)
+	})
+
+	t.Run("login with invalid credentials", func(t *testing.T) {
+		setupState(passwordProvider)
+		mockPassword.On("Login", ctx, *passwordProvider, "user@example.com", "wrong", "10.0.0.1").Return("", sdk.ErrInvalidCredentials).Once()
+
+		_, err := svc.PasswordLogin(ctx, sdk.PasswordLoginRequest{State: "valid-state", Email: "user@example.com", Password: "wrong"}, "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrInvalidCredentials)
+	})
+
+	t.Run("state of another provider type", func(t *testing.T) {
+		setupState(&sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123", Provider: sdk.AuthProviderTypeGoogle})
+
+		_, err := svc.PasswordLogin(ctx, sdk.PasswordLoginRequest{State: "valid-state", Email: "user@example.com", Password: "secret"}, "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrNotPasswordProvider)
+		mockPassword.AssertNotCalled(t, "Login", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("signup", func(t *testing.T) {
+		setupState(passwordProvider)
+		req := sdk.PasswordSignupRequest{State: "valid-state", Email: "user@example.com", Password: "secret"}
+		mockPassword.On("Signup", ctx, *passwordProvider, req).Return(sdk.ErrSignupDisabled).Once()
+
+		err := svc.PasswordSignup(ctx, req)
+		assert.ErrorIs(t, err, sdk.ErrSignupDisabled)
+		mockPassword.AssertExpectations(t)
+	})
+
+	t.Run("forgot password", func(t *testing.T) {
+		setupState(passwordProvider)
+		mockPassword.On("Forgot", ctx, *passwordProvider, "user@example.com").Return(nil).Once()
+
+		err := svc.ForgotPassword(ctx, sdk.PasswordForgotRequest{State: "valid-state", Email: "user@example.com"})
+		require.NoError(t, err)
+		mockPassword.AssertExpectations(t)
+	})
+
+	t.Run("expired state", func(t *testing.T) {
+		mockCache.ExpectedCalls = nil
+		mockCache.On("Get", ctx, "state-expired-state").Return("", errors.New("key not found"))
+
+		err := svc.ForgotPassword(ctx, sdk.PasswordForgotRequest{State: "expired-state", Email: "user@example.com"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error getting the state from cache")
+	})
+}
+
+// TestPasswordlessLogin tests the login of the passwordless auth provider with a code and with a magic link
+func TestPasswordlessLogin(t *testing.T) {
+	ctx := context.Background()
+	svc, mockAuthProvider, _, mockCache, _, mockEncrypt, _ := setupFullTestService()
+	mockPasswordless := svc.passwordlessSvc.(*services.MockPasswordlessService)
+
+	passwordlessProvider := &sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123", Provider: sdk.AuthProviderTypePasswordless}
+	reset := func() {
+		mockCache.ExpectedCalls = nil
+		mockEncrypt.ExpectedCalls = nil
+		mockAuthProvider.ExpectedCalls = nil
+		mockPasswordless.ExpectedCalls = nil
+		mockPasswordless.Calls = nil
+	}
+	setupState := func(p *sdk.AuthProvider) {
+		mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
+		mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","redirect_url":"http://callback.com"}`, nil)
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(p, nil)
+	}
+
+	t.Run("start sends the login email", func(t *testing.T) {
+		reset()
+		setupState(passwordlessProvider)
+		mockPasswordless.On("Start", ctx, *passwordlessProvider, "valid-state", "user@example.com", "10.0.0.1").Return(nil).Once()
+
+		err := svc.StartPasswordless(ctx, sdk.PasswordlessStartRequest{State: "valid-state", Email: "user@example.com"}, "10.0.0.1")
+		require.NoError(t, err)
+		mockPasswordless.AssertExpectations(t)
+	})
+
+	t.Run("start with the state of another provider type", func(t *testing.T) {
+		reset()
+		setupState(&sdk.AuthProvider{Id: "provider-id", Provider: sdk.AuthProviderTypePassword})
+
+		err := svc.StartPasswordless(ctx, sdk.PasswordlessStartRequest{State: "valid-state", Email: "user@example.com"}, "")
+		assert.ErrorIs(t, err, sdk.ErrNotPasswordlessProvider)
+		mockPasswordless.AssertNotCalled(t, "Start", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("code continues with the login code", func(t *testing.T) {
+		reset()
+		setupState(passwordlessProvider)
+		mockPasswordless.On("VerifyOtp", ctx, "valid-state", "123456").Return("login-code", nil).Once()
+		mockServiceProvider := &MockServiceProvider{}
+		mockAuthProvider.On("GetProvider", ctx, *passwordlessProvider).Return(mockServiceProvider, nil)
+		mockServiceProvider.On("VerifyCode", ctx, "login-code").Return((*sdk.AuthToken)(nil), errors.New("code already used"))
+
+		_, err := svc.PasswordlessVerify(ctx, sdk.PasswordlessVerifyRequest{State: "valid-state", Code: "123456"})
+		assert.ErrorContains(t, err, "error getting the token")
+		mockServiceProvider.AssertExpectations(t)
+	})
+
+	t.Run("magic link continues with the state of its login", func(t *testing.T) {
+		reset()
+		setupState(passwordlessProvider)
+		mockPasswordless.On("VerifyLink", ctx, "link-token").Return("login-code", "valid-state", nil).Once()
+		mockServiceProvider := &MockServiceProvider{}
+		mockAuthProvider.On("GetProvider", ctx, *passwordlessProvider).Return(mockServiceProvider, nil)
+		mockServiceProvider.On("VerifyCode", ctx, "login-code").Return((*sdk.AuthToken)(nil), errors.New("code already used"))
+
+		_, err := svc.PasswordlessVerify(ctx, sdk.PasswordlessVerifyRequest{Token: "link-token"})
+		assert.ErrorContains(t, err, "error getting the token")
+		mockServiceProvider.AssertExpectations(t)
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		reset()
+		mockPasswordless.On("VerifyOtp", ctx, "valid-state", "000000").Return("", sdk.ErrInvalidOtp).Once()
+
+		_, err := svc.PasswordlessVerify(ctx, sdk.PasswordlessVerifyRequest{State: "valid-state", Code: "000000"})
+		assert.ErrorIs(t, err, sdk.ErrInvalidOtp)
+	})
+
+	t.Run("sms start texts the code to the phone", func(t *testing.T) {
+		reset()
+		smsProvider := &sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123", Provider: sdk.AuthProviderTypeSms}
+		setupState(smsProvider)
+		mockPasswordless.On("Start", ctx, *smsProvider, "valid-state", "+919876543210", "10.0.0.1").Return(nil).Once()
+
+		err := svc.StartSmsOtp(ctx, sdk.SmsOtpStartRequest{State: "valid-state", Phone: "+919876543210"}, "10.0.0.1")
+		require.NoError(t, err)
+		mockPasswordless.AssertExpectations(t)
+	})
+
+	t.Run("sms start with the state of an email provider", func(t *testing.T) {
+		reset()
+		setupState(passwordlessProvider)
+
+		err := svc.StartSmsOtp(ctx, sdk.SmsOtpStartRequest{State: "valid-state", Phone: "+919876543210"}, "")
+		assert.ErrorIs(t, err, sdk.ErrNotSmsProvider)
+		mockPasswordless.AssertNotCalled(t, "Start", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("sms resend during the cooldown", func(t *testing.T) {
+		reset()
+		smsProvider := &sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123", Provider: sdk.AuthProviderTypeSms}
+		setupState(smsProvider)
+		mockPasswordless.On("Start", ctx, *smsProvider, "valid-state", "+919876543210", "").Return(sdk.ErrOtpResendCooldown).Once()
+
+		err := svc.StartSmsOtp(ctx, sdk.SmsOtpStartRequest{State: "valid-state", Phone: "+919876543210"}, "")
+		assert.ErrorIs(t, err, sdk.ErrOtpResendCooldown)
+	})
+}
+
+// TestPasskeyLogin tests the login with the passkey auth provider
+func TestPasskeyLogin(t *testing.T) {
+	ctx := context.Background()
+	svc, mockAuthProvider, _, mockCache, _, mockEncrypt, mockUser := setupFullTestService()
+	mockPasskey := svc.passkeySvc.(*services.MockPasskeyService)
+
+	passkeyProvider := &sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123", Provider: sdk.AuthProviderTypePasskey}
+	credential := sdk.WebAuthnAssertion{Id: "cred-1", Type: "public-key"}
+	reset := func() {
+		mockCache.ExpectedCalls = nil
+		mockEncrypt.ExpectedCalls = nil
+		mockAuthProvider.ExpectedCalls = nil
+		mockUser.ExpectedCalls = nil
+		mockPasskey.ExpectedCalls = nil
+		mockPasskey.Calls = nil
+	}
+	setupState := func(p *sdk.AuthProvider) {
+		mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
+		mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","redirect_url":"http://callback.com"}`, nil)
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(p, nil)
+	}
+
+	t.Run("start lets the user pick any passkey of the project", func(t *testing.T) {
+		reset()
+		setupState(passkeyProvider)
+		options := &sdk.WebAuthnRequestOptions{Challenge: "challenge", RpId: "example.com"}
+		mockPasskey.On("BeginLogin", ctx, "project-123", "login-valid-state", (*sdk.User)(nil)).Return(options, nil).Once()
+
+		result, err := svc.PasskeyLoginStart(ctx, sdk.PasskeyLoginStartRequest{State: "valid-state"})
+		require.NoError(t, err)
+		assert.Equal(t, options, result)
+	})
+
+	t.Run("start with the state of another provider type", func(t *testing.T) {
+		reset()
+		setupState(&sdk.AuthProvider{Id: "provider-id", Provider: sdk.AuthProviderTypePassword})
+
+		_, err := svc.PasskeyLoginStart(ctx, sdk.PasskeyLoginStartRequest{State: "valid-state"})
+		assert.ErrorIs(t, err, sdk.ErrNotPasskeyProvider)
+		mockPasskey.AssertNotCalled(t, "BeginLogin", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("verify continues with a login code for the owner of the passkey", func(t *testing.T) {
+		reset()
+		setupState(passkeyProvider)
+		mockPasskey.On("FinishLogin", ctx, "project-123", "login-valid-state", credential).Return(&sdk.Passkey{Id: "passkey-1", UserId: "user-1"}, nil).Once()
+		mockUser.On("GetById", ctx, "user-1").Return(&sdk.User{Id: "user-1", Email: "user@example.com"}, nil)
+		mockPasskey.On("IssueLoginCode", ctx, "user@example.com").Return("login-code", nil).Once()
+		mockServiceProvider := &MockServiceProvider{}
+		mockAuthProvider.On("GetProvider", ctx, *passkeyProvider).Return(mockServiceProvider, nil)
+		mockServiceProvider.On("VerifyCode", ctx, "login-code").Return((*sdk.AuthToken)(nil), errors.New("code already used"))
+
+		_, err := svc.PasskeyLoginVerify(ctx, sdk.PasskeyLoginVerifyRequest{State: "valid-state", Credential: credential})
+		assert.ErrorContains(t, err, "error getting the token")
+		mockPasskey.AssertExpectations(t)
+		mockServiceProvider.AssertExpectations(t)
+	})
+
+	t.Run("verify fails", func(t *testing.T) {
+		reset()
+		setupState(passkeyProvider)
+		mockPasskey.On("FinishLogin", ctx, "project-123", "login-valid-state", credential).Return(nil, sdk.ErrInvalidPasskey).Once()
+
+		_, err := svc.PasskeyLoginVerify(ctx, sdk.PasskeyLoginVerifyRequest{State: "valid-state", Credential: credential})
+		assert.ErrorIs(t, err, sdk.ErrInvalidPasskey)
+		mockPasskey.AssertNotCalled(t, "IssueLoginCode", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSaml(t *testing.T) {
+	ctx := context.Background()
+	svc, mockAuthProvider, _, mockCache, _, mockEncrypt, _ := setupFullTestService()
+
+	samlProvider := &sdk.AuthProvider{
+		Id:       "provider-id",
+		Name:     "Acme SAML",
+		Provider: sdk.AuthProviderTypeSAML,
+		Params: []sdk.AuthProviderParam{
+			{Key: sdk.SamlParamAcsUrl, Value: "https://iam.example.com/auth/v1/saml/acs"},
+		},
+	}
+	reset := func() {
+		mockCache.ExpectedCalls = nil
+		mockEncrypt.ExpectedCalls = nil
+		mockAuthProvider.ExpectedCalls = nil
+		mockAuthProvider.Calls = nil
+	}
+	setupState := func(p *sdk.AuthProvider) {
+		mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
+		mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","redirect_url":"http://callback.com"}`, nil)
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(p, nil)
+	}
+
+	t.Run("acs continues the login with the response as the code", func(t *testing.T) {
+		reset()
+		setupState(samlProvider)
+		mockServiceProvider := &MockServiceProvider{}
+		mockAuthProvider.On("GetProvider", ctx, *samlProvider).Return(mockServiceProvider, nil)
+		mockServiceProvider.On("VerifyCode", ctx, "valid-state.PHNhbWxwOlJlc3BvbnNlLz4=").Return((*sdk.AuthToken)(nil), sdk.ErrInvalidSamlResponse)
+
+		_, err := svc.SamlAcs(ctx, sdk.SamlAcsRequest{SamlResponse: "PHNhbWxwOlJlc3BvbnNlLz4=", RelayState: "valid-state"})
+		assert.ErrorIs(t, err, sdk.ErrInvalidSamlResponse)
+		mockServiceProvider.AssertExpectations(t)
+	})
+
+	t.Run("acs with the state of another provider type", func(t *testing.T) {
+		reset()
+		setupState(&sdk.AuthProvider{Id: "provider-id", Provider: sdk.AuthProviderTypeGoogle})
+
+		_, err := svc.SamlAcs(ctx, sdk.SamlAcsRequest{SamlResponse: "PHNhbWxwOlJlc3BvbnNlLz4=", RelayState: "valid-state"})
+		assert.ErrorIs(t, err, sdk.ErrNotSamlProvider)
+		mockAuthProvider.AssertNotCalled(t, "GetProvider", mock.Anything, mock.Anything)
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		reset()
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(samlProvider, nil)
+
+		md, err := svc.SamlMetadata(ctx, "provider-id")
+		require.NoError(t, err)
+		assert.Contains(t, string(md), `entityID="https://iam.example.com/auth/v1/saml/acs"`)
+	})
+
+	t.Run("metadata of another provider type", func(t *testing.T) {
+		reset()
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(&sdk.AuthProvider{Id: "provider-id", Provider: sdk.AuthProviderTypeGoogle}, nil)
+
+		_, err := svc.SamlMetadata(ctx, "provider-id")
+		assert.ErrorIs(t, err, sdk.ErrNotSamlProvider)
+	})
+}
+
+func TestLdap(t *testing.T) {
+	ctx := context.Background()
+	svc, mockAuthProvider, _, mockCache, _, mockEncrypt, mockUser := setupFullTestService()
+	mockLdap := svc.ldapSvc.(*services.MockLdapService)
+
+	ldapProvider := &sdk.AuthProvider{
+		Id:       "provider-id",
+		Name:     "Corp Directory",
+		Provider: sdk.AuthProviderTypeLDAP,
+	}
+	reset := func() {
+		mockCache.ExpectedCalls = nil
+		mockEncrypt.ExpectedCalls = nil
+		mockAuthProvider.ExpectedCalls = nil
+		mockUser.ExpectedCalls = nil
+		mockUser.Calls = nil
+		mockLdap.ExpectedCalls = nil
+		mockLdap.Calls = nil
+	}
+	setupState := func(p *sdk.AuthProvider) {
+		mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
+		mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","redirect_url":"http://callback.com"}`, nil)
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(p, nil)
+	}
+
+	t.Run("login checks the credentials against the directory", func(t *testing.T) {
+		reset()
+		setupState(ldapProvider)
+		mockLdap.On("Login", ctx, *ldapProvider, "jdoe", "wrong", "10.0.0.1").Return("", sdk.ErrInvalidLdapCredentials)
+
+		_, err := svc.LdapLogin(ctx, sdk.LdapLoginRequest{State: "valid-state", Username: "jdoe", Password: "wrong"}, "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrInvalidLdapCredentials)
+		mockLdap.AssertExpectations(t)
+	})
+
+	t.Run("login with the state of another provider type", func(t *testing.T) {
+		reset()
+		setupState(&sdk.AuthProvider{Id: "provider-id", Provider: sdk.AuthProviderTypeGoogle})
+
+		_, err := svc.LdapLogin(ctx, sdk.LdapLoginRequest{State: "valid-state", Username: "jdoe", Password: "secret"}, "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrNotLdapProvider)
+		mockLdap.AssertNotCalled(t, "Login", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSyncGroupRoles(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, _, _, _, mockUser := setupFullTestService()
+
+	provider := sdk.AuthProvider{
+		Id:       "provider-id",
+		Provider: sdk.AuthProviderTypeLDAP,
+		GroupRoles: []sdk.AuthProviderGroupRole{
+			{Group: "cn=admins,ou=groups,dc=example,dc=com", RoleId: "role-admin"},
+			{Group: "cn=ops,ou=groups,dc=example,dc=com", RoleId: "role-ops"},
+			{Group: "Engineering", RoleId: "role-eng"},
+			{Group: "cn=eng-leads,ou=groups,dc=example,dc=com", RoleId: "role-eng"},
+		},
+	}
+	linked := &sdk.UserIdentity{Id: "identity-1"}
+	reset := func() {
+		mockUser.ExpectedCalls = nil
+		mockUser.Calls = nil
+	}
+
+	t.Run("roles follow the groups of the mapping", func(t *testing.T) {
+		reset()
+		usr := sdk.User{Id: "user-1", Roles: map[string]sdk.UserRole{"role-ops": {Id: "role-ops"}}}
+		updated := &sdk.User{Id: "user-1", Roles: map[string]sdk.UserRole{
+			"role-admin": {Id: "role-admin"},
+			"role-eng":   {Id: "role-eng"},
+		}}
+		mockUser.On("SyncGroupRoles", ctx, "user-1", "identity-1", []string{"role-admin", "role-eng"}).Return(updated, nil)
+
+		result, err := svc.syncGroupRoles(ctx, usr, linked, providerIdentity{
+			Provider: provider,
+			Groups:   []string{"CN=Admins, OU=Groups, DC=example, DC=com", "cn=staff,ou=groups,dc=example,dc=com", "engineering", "cn=eng-leads,ou=groups,dc=example,dc=com"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, updated, result)
+		mockUser.AssertExpectations(t)
+	})
+
+	t.Run("roles granted earlier are removed when the user left the groups", func(t *testing.T) {
+		reset()
+		usr := sdk.User{
+			Id:         "user-1",
+			Roles:      map[string]sdk.UserRole{"role-ops": {Id: "role-ops"}},
+			GroupRoles: map[string][]string{"role-ops": {"identity-1"}},
+		}
+		updated := &sdk.User{Id: "user-1"}
+		mockUser.On("SyncGroupRoles", ctx, "user-1", "identity-1", []string{}).Return(updated, nil)
+
+		result, err := svc.syncGroupRoles(ctx, usr, linked, providerIdentity{Provider: provider, Groups: []string{"cn=staff,ou=groups,dc=example,dc=com"}})
+		require.NoError(t, err)
+		assert.Equal(t, updated, result)
+		mockUser.AssertExpectations(t)
+	})
+
+	t.Run("identities granting no roles leave the user alone", func(t *testing.T) {
+		reset()
+		usr := sdk.User{
+			Id:         "user-1",
+			Roles:      map[string]sdk.UserRole{"role-ops": {Id: "role-ops"}},
+			GroupRoles: map[string][]string{"role-ops": {"identity-2"}},
+		}
+
+		result, err := svc.syncGroupRoles(ctx, usr, linked, providerIdentity{Provider: provider, Groups: []string{}})
+		require.NoError(t, err)
+		assert.Equal(t, usr, *result)
+		mockUser.AssertNotCalled(t, "SyncGroupRoles", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("providers not reporting the groups keep the roles", func(t *testing.T) {
+		reset()
+		usr := sdk.User{
+			Id:         "user-1",
+			Roles:      map[string]sdk.UserRole{"role-ops": {Id: "role-ops"}},
+			GroupRoles: map[string][]string{"role-ops": {"identity-1"}},
+		}
+
+		result, err := svc.syncGroupRoles(ctx, usr, linked, providerIdentity{Provider: provider})
+		require.NoError(t, err)
+		assert.Equal(t, usr, *result)
+		mockUser.AssertNotCalled(t, "SyncGroupRoles", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// TestClientCallback tests the ClientCallback method - focusing on error cases
+func TestClientCallback(t *testing.T) {
+	ctx := context.Background()
+	svc, _, mockClient, mockCache, mockJWT, mockEncrypt, _ := setupFullTestService()
+	mockRefresh := svc.refreshSvc.(*services.MockRefreshTokenService)
+	publicClient := &sdk.Client{Id: "test-client", Enabled: true, Public: true}
+
+	tests := []struct {
+		name          string
+		code          string
+		codeChallenge string
+		clientId      string
+		clientSecret  string
+		setupMocks    func()
+		expectedError string
+	}{
+		{
+			name:          "error - invalid code",
+			code:          "invalid-code",
+			codeChallenge: "",
+			clientId:      "test-client",
+			clientSecret:  "test-secret",
+			setupMocks: func() {
+				mockCache.On("GetDel", ctx, "auth-code-invalid-code").Return("", errors.New("code not found"))
+			},
+			expectedError: "error getting the token from cache",
+		},
+		{
+			name:          "error - auth token decryption fails",
+			code:          "valid-code-decrypt-fail",
+			codeChallenge: "",
+			clientId:      "test-client",
+			clientSecret:  "test-secret",
+			setupMocks: func() {
+				mockCache.On("GetDel", ctx, "auth-code-valid-code-decrypt-fail").Return("encrypted-data", nil)
+				mockEncrypt.On("Decrypt", "encrypted-data").Return("", errors.New("decryption failed"))
+			},
+			expectedError: "error decrypting the access token",
 		},
 		{
 			name:          "error - auth token JSON unmarshal fails",
@@ -1168,7 +2629,7 @@ func TestClientCallback(t *testing.T) {
 			clientId:      "test-client",
 			clientSecret:  "test-secret",
 			setupMocks: func() {
-				mockCache.On("Get", ctx, "auth-code-valid-code-unmarshal-fail").Return("encrypted-data", nil)
+				mockCache.On("GetDel", ctx, "auth-code-valid-code-unmarshal-fail").Return("encrypted-data", nil)
 				mockEncrypt.On("Decrypt", "encrypted-data").Return("invalid-json", nil)
 			},
 			expectedError: "error decoding the token",
@@ -1182,13 +2643,11 @@ func TestClientCallback(t *testing.T) {
 			setupMocks: func() {
 				// Mock successful auth token retrieval
 				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"","code_challenge_method":""}`
-				mockCache.On("Get", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
+				mockCache.On("GetDel", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
 				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
-				// Client secret cache miss, then client not found in DB
-				mockCache.On("Get", ctx, "client-unknown-client").Return("", errors.New("cache miss"))
 				mockClient.On("Get", ctx, "unknown-client", true).Return((*sdk.Client)(nil), errors.New("client not found"))
 			},
-			expectedError: "error handling private client",
+			expectedError: "invalid client_id",
 		},
 		{
 			name:          "error - private client - invalid client secret",
@@ -1199,13 +2658,14 @@ func TestClientCallback(t *testing.T) {
 			setupMocks: func() {
 				// Mock successful auth token retrieval
 				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"","code_challenge_method":""}`
-				mockCache.On("Get", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
+				mockCache.On("GetDel", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
 				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
 				// Client secret cache miss, then found in DB but wrong secret
 				mockCache.On("Get", ctx, "client-test-client").Return("", errors.New("cache miss"))
 				client := &sdk.Client{
-					Id:     "test-client",
-					Secret: "correct-secret",
+					Id:      "test-client",
+					Secret:  "correct-secret",
+					Enabled: true,
 				}
 				mockClient.On("Get", ctx, "test-client", true).Return(client, nil)
 				mockClient.On("VerifySecret", mock.Anything, mock.Anything).Return(errors.New("invalid client secret"))
@@ -1216,44 +2676,47 @@ func TestClientCallback(t *testing.T) {
 		{
 			name:          "error - public client - invalid code challenge method",
 			code:          "valid-code",
-			codeChallenge: "test-challenge",
+			codeChallenge: testCodeVerifier,
 			clientId:      "test-client",
 			clientSecret:  "",
 			setupMocks: func() {
 				// Mock successful auth token retrieval with invalid code challenge method
-				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"wrong-hash","code_challenge_method":"SHA1"}`
-				mockCache.On("Get", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
+				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"` + testCodeChallenge + `","code_challenge_method":"SHA1"}`
+				mockCache.On("GetDel", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
 				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
+				mockClient.On("Get", ctx, "test-client", true).Return(publicClient, nil)
 			},
-			expectedError: "invalid code challenge",
+			expectedError: "unsupported code challenge method",
 		},
 		{
 			name:          "error - public client - invalid code verifier",
 			code:          "valid-code",
-			codeChallenge: "wrong-verifier",
+			codeChallenge: strings.Repeat("a", 43),
 			clientId:      "test-client",
 			clientSecret:  "",
 			setupMocks: func() {
 				// Mock successful auth token retrieval with wrong code challenge
-				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"different-hash","code_challenge_method":"S256"}`
-				mockCache.On("Get", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
+				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"` + testCodeChallenge + `","code_challenge_method":"S256"}`
+				mockCache.On("GetDel", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
 				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
+				mockClient.On("Get", ctx, "test-client", true).Return(publicClient, nil)
 			},
-			expectedError: "invalid code verifier",
+			expectedError: "code verifier does not match the code challenge",
 		},
 		{
 			name:          "error - public client - client ID mismatch",
 			code:          "valid-code",
-			codeChallenge: calculatedHash, // Use the pre-calculated hash
+			codeChallenge: testCodeVerifier,
 			clientId:      "different-client",
 			clientSecret:  "",
 			setupMocks: func() {
 				// Mock successful auth token retrieval with matching code challenge but different client ID
-				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"original-client","code_challenge":"` + originalVerifier + `","code_challenge_method":"S256"}`
-				mockCache.On("Get", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
+				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"original-client","code_challenge":"` + testCodeChallenge + `","code_challenge_method":"S256"}`
+				mockCache.On("GetDel", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
 				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
+				mockClient.On("Get", ctx, "different-client", true).Return(&sdk.Client{Id: "different-client", Enabled: true, Public: true}, nil)
 			},
-			expectedError: "invalid client id",
+			expectedError: "code was not issued to the client",
 		},
 		{
 			name:          "error - access token caching fails",
@@ -1264,13 +2727,14 @@ func TestClientCallback(t *testing.T) {
 			setupMocks: func() {
 				// Mock successful auth token retrieval and private client validation
 				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"","code_challenge_method":""}`
-				mockCache.On("Get", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
+				mockCache.On("GetDel", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
 				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
 				// Client secret cache miss, then found in DB with correct secret
 				mockCache.On("Get", ctx, "client-test-client").Return("", errors.New("cache miss"))
 				client := &sdk.Client{
-					Id:     "test-client",
-					Secret: "test-secret",
+					Id:      "test-client",
+					Secret:  "test-secret",
+					Enabled: true,
 				}
 				mockClient.On("Get", ctx, "test-client", true).Return(client, nil)
 				mockClient.On("VerifySecret", "test-secret", "test-secret").Return(nil)
@@ -1289,13 +2753,14 @@ func TestClientCallback(t *testing.T) {
 			setupMocks: func() {
 				// Mock successful auth token retrieval and private client validation
 				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"","code_challenge_method":""}`
-				mockCache.On("Get", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
+				mockCache.On("GetDel", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
 				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
 				// Client secret cache miss, then found in DB with correct secret
 				mockCache.On("Get", ctx, "client-test-client").Return("", errors.New("cache miss"))
 				client := &sdk.Client{
-					Id:     "test-client",
-					Secret: "test-secret",
+					Id:      "test-client",
+					Secret:  "test-secret",
+					Enabled: true,
 				}
 				mockClient.On("Get", ctx, "test-client", true).Return(client, nil)
 				mockClient.On("VerifySecret", "test-secret", "test-secret").Return(nil)
@@ -1309,34 +2774,106 @@ func TestClientCallback(t *testing.T) {
 			expectedError: "error generating the access token",
 		},
 		{
-			name:          "error - auth token invalidation fails",
+			name:          "error - user identity cannot be resolved",
 			code:          "valid-code",
 			codeChallenge: "",
 			clientId:      "test-client",
 			clientSecret:  "test-secret",
 			setupMocks: func() {
-				// Mock successful flow until invalidation
 				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"","code_challenge_method":""}`
-				mockCache.On("Get", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
+				mockCache.On("GetDel", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
 				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
-				// Client secret cache miss, then found in DB with correct secret
 				mockCache.On("Get", ctx, "client-test-client").Return("", errors.New("cache miss"))
 				client := &sdk.Client{
-					Id:     "test-client",
-					Secret: "test-secret",
+					Id:      "test-client",
+					Secret:  "test-secret",
+					Enabled: true,
+				}
+				mockClient.On("Get", ctx, "test-client", true).Return(client, nil)
+				mockClient.On("VerifySecret", "test-secret", "test-secret").Return(nil)
+				mockCache.On("Set", ctx, "client-test-client", "test-secret", mock.Anything).Return(nil)
+				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-access-token", nil)
+				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-access-token", mock.Anything).Return(nil)
+				mockJWT.On("GenerateToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("jwt-token", nil)
+				mockJWT.On("ValidateToken", "jwt-token").Return(nil, errors.New("invalid token"))
+			},
+			expectedError: "error fetching the user identity",
+		},
+		{
+			name:          "error - refresh token issue fails",
+			code:          "valid-code",
+			codeChallenge: "",
+			clientId:      "test-client",
+			clientSecret:  "test-secret",
+			setupMocks: func() {
+				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"","code_challenge_method":""}`
+				mockCache.On("GetDel", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
+				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
+				mockCache.On("Get", ctx, "client-test-client").Return("", errors.New("cache miss"))
+				client := &sdk.Client{
+					Id:      "test-client",
+					Secret:  "test-secret",
+					Enabled: true,
+				}
+				mockClient.On("Get", ctx, "test-client", true).Return(client, nil)
+				mockClient.On("VerifySecret", "test-secret", "test-secret").Return(nil)
+				mockCache.On("Set", ctx, "client-test-client", "test-secret", mock.Anything).Return(nil)
+				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-access-token", nil)
+				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-access-token", mock.Anything).Return(nil)
+				mockJWT.On("GenerateToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("jwt-token", nil)
+				mockJWT.On("ValidateToken", "jwt-token").Return(map[string]interface{}{"id": "access-token-id"}, nil)
+				mockCache.On("Get", ctx, "token-jwt-token").Return("encrypted-user", nil)
+				mockEncrypt.On("Decrypt", "encrypted-user").Return(`{"id":"user-1"}`, nil)
+				mockCache.On("Get", ctx, "revoked-user-user-1").Return("", errors.New("key not found"))
+				mockJWT.On("GenerateIdToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("id-token", nil)
+				mockRefresh.On("Create", ctx, "user-1", mock.AnythingOfType("sdk.AuthToken")).Return("", errors.New("database error"))
+			},
+			expectedError: "error issuing the refresh token",
+		},
+		{
+			name:          "error - ID token generation fails",
+			code:          "valid-code",
+			codeChallenge: "",
+			clientId:      "test-client",
+			clientSecret:  "test-secret",
+			setupMocks: func() {
+				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"","code_challenge_method":""}`
+				mockCache.On("GetDel", ctx, "auth-code-valid-code").Return("encrypted-data", nil)
+				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
+				mockCache.On("Get", ctx, "client-test-client").Return("", errors.New("cache miss"))
+				client := &sdk.Client{
+					Id:      "test-client",
+					Secret:  "test-secret",
+					Enabled: true,
 				}
 				mockClient.On("Get", ctx, "test-client", true).Return(client, nil)
 				mockClient.On("VerifySecret", "test-secret", "test-secret").Return(nil)
 				mockCache.On("Set", ctx, "client-test-client", "test-secret", mock.Anything).Return(nil)
-				// Access token caching succeeds
 				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-access-token", nil)
 				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-access-token", mock.Anything).Return(nil)
-				// JWT generation succeeds
 				mockJWT.On("GenerateToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("jwt-token", nil)
-				// Auth token invalidation fails
-				mockCache.On("Delete", ctx, "auth-code-valid-code").Return(errors.New("cache deletion failed"))
+				mockJWT.On("ValidateToken", "jwt-token").Return(map[string]interface{}{"id": "access-token-id"}, nil)
+				mockCache.On("Get", ctx, "token-jwt-token").Return("encrypted-user", nil)
+				mockEncrypt.On("Decrypt", "encrypted-user").Return(`{"id":"user-1"}`, nil)
+				mockCache.On("Get", ctx, "revoked-user-user-1").Return("", errors.New("key not found"))
+				// Signing the ID token fails
+				mockJWT.On("GenerateIdToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("", errors.New("signing failed"))
 			},
-			expectedError: "error invalidating the auth code",
+			expectedError: "error generating the id token",
+		},
+		{
+			name:          "error - disabled client",
+			code:          "code-disabled",
+			codeChallenge: testCodeVerifier,
+			clientId:      "test-client",
+			clientSecret:  "",
+			setupMocks: func() {
+				tokenJSON := `{"client_id":"test-client","code_challenge":"` + testCodeChallenge + `","code_challenge_method":"S256"}`
+				mockCache.On("GetDel", ctx, "auth-code-code-disabled").Return("encrypted-data", nil)
+				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Public: true}, nil)
+			},
+			expectedError: "client is disabled",
 		},
 		{
 			name:          "success - private client flow",
@@ -1347,13 +2884,14 @@ func TestClientCallback(t *testing.T) {
 			setupMocks: func() {
 				// Mock complete successful private client flow
 				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"","code_challenge_method":""}`
-				mockCache.On("Get", ctx, "auth-code-success-code").Return("encrypted-data", nil)
+				mockCache.On("GetDel", ctx, "auth-code-success-code").Return("encrypted-data", nil)
 				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
 				// Client secret cache miss, then found in DB with correct secret
 				mockCache.On("Get", ctx, "client-test-client").Return("", errors.New("cache miss"))
 				client := &sdk.Client{
-					Id:     "test-client",
-					Secret: "test-secret",
+					Id:      "test-client",
+					Secret:  "test-secret",
+					Enabled: true,
 				}
 				mockClient.On("Get", ctx, "test-client", true).Return(client, nil)
 				mockClient.On("VerifySecret", "test-secret", "test-secret").Return(nil)
@@ -1363,54 +2901,88 @@ func TestClientCallback(t *testing.T) {
 				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-access-token", mock.Anything).Return(nil)
 				// JWT generation succeeds
 				mockJWT.On("GenerateToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("jwt-token", nil)
-				// Auth token invalidation succeeds
-				mockCache.On("Delete", ctx, "auth-code-success-code").Return(nil)
+				// ID token is built from the cached identity
+				mockJWT.On("ValidateToken", "jwt-token").Return(map[string]interface{}{"id": "access-token-id"}, nil)
+				mockCache.On("Get", ctx, "token-jwt-token").Return("encrypted-user", nil)
+				mockEncrypt.On("Decrypt", "encrypted-user").Return(`{"id":"user-1","email":"user@example.com","name":"Test User"}`, nil)
+				mockCache.On("Get", ctx, "revoked-user-user-1").Return("", errors.New("key not found"))
+				mockJWT.On("GenerateIdToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("id-token", nil)
+				mockRefresh.On("Create", ctx, "user-1", mock.MatchedBy(func(token sdk.AuthToken) bool { return !token.PublicClient })).Return("refresh-token", nil)
 			},
 			expectedError: "", // Should succeed
 		},
 		{
 			name:          "success - public client flow",
 			code:          "success-code-public",
-			codeChallenge: calculatedHash, // Use the pre-calculated hash
+			codeChallenge: testCodeVerifier,
 			clientId:      "test-client",
 			clientSecret:  "",
 			setupMocks: func() {
 				// Mock complete successful public client flow
-				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"` + originalVerifier + `","code_challenge_method":"S256"}`
-				mockCache.On("Get", ctx, "auth-code-success-code-public").Return("encrypted-data", nil)
+				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"` + testCodeChallenge + `","code_challenge_method":"S256"}`
+				mockCache.On("GetDel", ctx, "auth-code-success-code-public").Return("encrypted-data", nil)
 				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
 				// No client secret validation needed for public client
+				mockClient.On("Get", ctx, "test-client", true).Return(publicClient, nil)
 				// Access token caching succeeds
 				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-access-token", nil)
 				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-access-token", mock.Anything).Return(nil)
 				// JWT generation succeeds
 				mockJWT.On("GenerateToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("jwt-token", nil)
-				// Auth token invalidation succeeds
-				mockCache.On("Delete", ctx, "auth-code-success-code-public").Return(nil)
+				// ID token is built from the cached identity
+				mockJWT.On("ValidateToken", "jwt-token").Return(map[string]interface{}{"id": "access-token-id"}, nil)
+				mockCache.On("Get", ctx, "token-jwt-token").Return("encrypted-user", nil)
+				mockEncrypt.On("Decrypt", "encrypted-user").Return(`{"id":"user-1","email":"user@example.com","name":"Test User"}`, nil)
+				mockCache.On("Get", ctx, "revoked-user-user-1").Return("", errors.New("key not found"))
+				mockJWT.On("GenerateIdToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("id-token", nil)
+				// the refresh token of a public client rotates without the secret
+				mockRefresh.On("Create", ctx, "user-1", mock.MatchedBy(func(token sdk.AuthToken) bool { return token.PublicClient })).Return("refresh-token", nil)
 			},
 			expectedError: "", // Should succeed
 		},
 		{
-			name:          "success - neither private nor public client",
-			code:          "success-code-neither",
+			name:          "error - public client without a code verifier",
+			code:          "code-neither",
 			codeChallenge: "",
 			clientId:      "test-client",
 			clientSecret:  "",
 			setupMocks: func() {
-				// Mock complete successful flow with neither private nor public client validation
 				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"","code_challenge_method":""}`
-				mockCache.On("Get", ctx, "auth-code-success-code-neither").Return("encrypted-data", nil)
+				mockCache.On("GetDel", ctx, "auth-code-code-neither").Return("encrypted-data", nil)
 				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
-				// No client validation needed
-				// Access token caching succeeds
-				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-access-token", nil)
-				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-access-token", mock.Anything).Return(nil)
-				// JWT generation succeeds
-				mockJWT.On("GenerateToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("jwt-token", nil)
-				// Auth token invalidation succeeds
-				mockCache.On("Delete", ctx, "auth-code-success-code-neither").Return(nil)
+				mockClient.On("Get", ctx, "test-client", true).Return(publicClient, nil)
 			},
-			expectedError: "", // Should succeed
+			expectedError: "public clients have to use a code verifier",
+		},
+		{
+			name:          "error - confidential client redeeming a PKCE code without its secret",
+			code:          "code-no-secret",
+			codeChallenge: testCodeVerifier,
+			clientId:      "test-client",
+			clientSecret:  "",
+			setupMocks: func() {
+				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"` + testCodeChallenge + `","code_challenge_method":"S256"}`
+				mockCache.On("GetDel", ctx, "auth-code-code-no-secret").Return("encrypted-data", nil)
+				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Secret: "test-secret", Enabled: true}, nil)
+			},
+			expectedError: "client authentication is required",
+		},
+		{
+			name:          "error - private client skipping the code verifier of its login",
+			code:          "code-private-pkce",
+			codeChallenge: "",
+			clientId:      "test-client",
+			clientSecret:  "test-secret",
+			setupMocks: func() {
+				tokenJSON := `{"access_token":"at_123","refresh_token":"rt_123","client_id":"test-client","code_challenge":"` + testCodeChallenge + `","code_challenge_method":"S256"}`
+				mockCache.On("GetDel", ctx, "auth-code-code-private-pkce").Return("encrypted-data", nil)
+				mockEncrypt.On("Decrypt", "encrypted-data").Return(tokenJSON, nil)
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Secret: "test-secret", Enabled: true}, nil)
+				mockCache.On("Get", ctx, "client-test-client").Return("test-secret", nil)
+				mockClient.On("VerifySecret", "test-secret", "test-secret").Return(nil)
+			},
+			expectedError: "code verifier is required",
 		},
 	}
 
@@ -1421,6 +2993,7 @@ func TestClientCallback(t *testing.T) {
 			mockEncrypt.ExpectedCalls = nil
 			mockClient.ExpectedCalls = nil
 			mockJWT.ExpectedCalls = nil
+			mockRefresh.ExpectedCalls = nil
 
 			tt.setupMocks()
 
@@ -1434,16 +3007,59 @@ func TestClientCallback(t *testing.T) {
 				require.NoError(t, err)
 				assert.NotNil(t, result)
 				assert.Equal(t, "jwt-token", result.AccessToken)
+				assert.Equal(t, "id-token", result.IdToken)
+				assert.Equal(t, "refresh-token", result.RefreshToken)
+				assert.Equal(t, "Bearer", result.TokenType)
+				assert.Equal(t, int64(3600), result.ExpiresIn)
 			}
 
 			mockCache.AssertExpectations(t)
 			mockEncrypt.AssertExpectations(t)
 			mockClient.AssertExpectations(t)
 			mockJWT.AssertExpectations(t)
+			mockRefresh.AssertExpectations(t)
 		})
 	}
 }
 
+// TestExchangeCodeSingleUse tests that an auth code is redeemed once, even by concurrent or failed redemptions
+func TestExchangeCodeSingleUse(t *testing.T) {
+	ctx := context.Background()
+	svc, _, mockClient, _, _, mockEncrypt, _ := setupFullTestService()
+	cacheSvc := cache.NewMockService()
+	svc.cacheSvc = cacheSvc
+	tokenJSON := `{"client_id":"test-client","code_challenge":"` + testCodeChallenge + `","code_challenge_method":"S256"}`
+	mockEncrypt.On("Decrypt", "encrypted-code").Return(tokenJSON, nil)
+	mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+
+	t.Run("concurrent redemptions", func(t *testing.T) {
+		require.NoError(t, cacheSvc.Set(ctx, "auth-code-abc", "encrypted-code", time.Minute))
+		var redeemed atomic.Int32
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := svc.consumeAuthToken(ctx, "abc"); err == nil {
+					redeemed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), redeemed.Load())
+	})
+
+	t.Run("a failed redemption uses up the code", func(t *testing.T) {
+		require.NoError(t, cacheSvc.Set(ctx, "auth-code-abc", "encrypted-code", time.Minute))
+		_, err := svc.ClientCallback(ctx, "abc", strings.Repeat("a", 43), "test-client", "")
+		assert.ErrorContains(t, err, "code verifier does not match the code challenge")
+
+		_, err = svc.ClientCallback(ctx, "abc", testCodeVerifier, "test-client", "")
+		assert.ErrorIs(t, err, sdk.ErrInvalidGrant)
+		assert.ErrorContains(t, err, "error getting the token from cache")
+	})
+}
+
 // Mock metadata types for testing
 type MockEmailMetadata struct {
 	Email string
@@ -1547,6 +3163,7 @@ func TestGetIdentity(t *testing.T) {
 				mockEncrypt.On("Encrypt", mock.Anything).Return("encrypted-user-data", nil)
 				mockCache.On("Set", ctx, "token-valid-token-full-flow", "encrypted-user-data", mock.Anything).Return(nil)
 				mockCache.On("Set", ctx, "user-token-user-123", "valid-token-full-flow", mock.Anything).Return(nil)
+				mockCache.On("Get", ctx, "revoked-user-user-123").Return("", errors.New("key not found"))
 			},
 			expectedUser: &sdk.User{
 				Id:        "user-123",
@@ -1571,6 +3188,8 @@ func TestGetIdentity(t *testing.T) {
 				// Mock successful decryption of cached user data
 				userJSON := `{"id":"cached-user-123","email":"cached@example.com","name":"Cached User","project_id":"test-project","enabled":true}`
 				mockEncrypt.On("Decrypt", encryptedUserData).Return(userJSON, nil)
+				// The user was never signed out everywhere
+				mockCache.On("Get", ctx, "revoked-user-cached-user-123").Return("", errors.New("key not found"))
 
 				// No other service calls should be made since we return early from cache hit
 			},
@@ -1646,6 +3265,7 @@ func TestGetIdentity(t *testing.T) {
 				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-user-data", nil)
 				mockCache.On("Set", ctx, "token-valid-token-cache-decrypt-fail", "encrypted-user-data", mock.Anything).Return(nil)
 				mockCache.On("Set", ctx, "user-token-existing-user-456", "valid-token-cache-decrypt-fail", mock.Anything).Return(nil)
+				mockCache.On("Get", ctx, "revoked-user-existing-user-456").Return("", errors.New("key not found"))
 			},
 			expectedUser: &sdk.User{
 				Id:        "existing-user-456",
@@ -1720,6 +3340,7 @@ func TestGetIdentity(t *testing.T) {
 				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-user-data", nil)
 				mockCache.On("Set", ctx, "token-valid-token-cache-json-fail", "encrypted-user-data", mock.Anything).Return(nil)
 				mockCache.On("Set", ctx, "user-token-recovery-user-789", "valid-token-cache-json-fail", mock.Anything).Return(nil)
+				mockCache.On("Get", ctx, "revoked-user-recovery-user-789").Return("", errors.New("key not found"))
 			},
 			expectedUser: &sdk.User{
 				Id:        "recovery-user-789",
@@ -2568,16 +4189,20 @@ func TestClientCredentials(t *testing.T) {
 		mockAuthProviderService := &MockAuthProviderService{}
 		mockEncryptService := &services.MockEncryptService{}
 		mockJWTService := &services.MockJWTService{}
+		mockRefreshService := &services.MockRefreshTokenService{}
 
 		svc := service{
-			usrSvc:     mockUserService,
-			cacheSvc:   mockCacheService,
-			clientSvc:  mockClientService,
-			authP:      mockAuthProviderService,
-			encSvc:     mockEncryptService,
-			jwtSvc:     mockJWTService,
-			tokenTTL:   60,
-			refetchTTL: 30,
+			usrSvc:         mockUserService,
+			cacheSvc:       mockCacheService,
+			clientSvc:      mockClientService,
+			authP:          mockAuthProviderService,
+			encSvc:         mockEncryptService,
+			jwtSvc:         mockJWTService,
+			refreshSvc:     mockRefreshService,
+			projectSvc:     newMockProjectService(),
+			tokenTTL:       60,
+			refetchTTL:     30,
+			accessTokenTTL: 15,
 		}
 
 		ctx := context.Background()
@@ -2610,17 +4235,24 @@ func TestClientCredentials(t *testing.T) {
 		mockEncryptService.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-token", nil)
 		mockJWTService.On("GenerateToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("jwt-token", nil)
 		mockCacheService.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("time.Duration")).Return(nil)
+		mockRefreshService.On("Create", ctx, linkedUserId, mock.MatchedBy(func(token sdk.AuthToken) bool {
+			return token.ClientId == clientId && token.ServiceAccountUserId == linkedUserId && len(token.SessionId) > 0
+		})).Return("refresh-token", nil)
 
 		response, err := svc.ClientCredentials(ctx, clientId, clientSecret)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, response)
 		assert.NotEmpty(t, response.AccessToken)
+		assert.Equal(t, "refresh-token", response.RefreshToken)
+		assert.Equal(t, "Bearer", response.TokenType)
+		assert.Equal(t, int64(900), response.ExpiresIn)
 
 		mockClientService.AssertExpectations(t)
 		mockUserService.AssertExpectations(t)
 		mockAuthProviderService.AssertExpectations(t)
 		mockCacheService.AssertExpectations(t)
+		mockRefreshService.AssertExpectations(t)
 	})
 
 	t.Run("invalid client id", func(t *testing.T) {
@@ -2943,3 +4575,1422 @@ func TestClientCredentials(t *testing.T) {
 		mockClientService.AssertExpectations(t)
 	})
 }
+
+// TestGenerateClientAccessToken tests the access token formats of the clients
+func TestGenerateClientAccessToken(t *testing.T) {
+	usr := sdk.User{
+		Id:        "user-1",
+		ProjectId: "project-1",
+		Email:     "user@example.com",
+		Roles:     map[string]sdk.UserRole{"role-2": {Id: "role-2"}, "role-1": {Id: "role-1"}},
+		Resources: map[string]sdk.UserResource{"orders": {Key: "orders"}},
+	}
+
+	t.Run("opaque by default", func(t *testing.T) {
+		svc, _, _, _, mockJWT, _, _ := setupFullTestService()
+		mockJWT.On("GenerateToken", mock.MatchedBy(func(claims map[string]interface{}) bool {
+			return len(claims) == 3 && claims["id"] == "at-1" && claims["client_id"] == "client-1"
+		}), mock.AnythingOfType("int64")).Return("opaque-token", nil).Once()
+
+		token, err := svc.generateClientAccessToken(sdk.Client{Id: "client-1"}, "at-1", usr, sdk.AuthToken{})
+		require.NoError(t, err)
+		assert.Equal(t, "opaque-token", token)
+		mockJWT.AssertExpectations(t)
+	})
+
+	t.Run("self contained with the default claims", func(t *testing.T) {
+		svc, _, _, _, mockJWT, _, _ := setupFullTestService()
+		svc.maxTokenSize = 4096
+		mockJWT.On("GenerateToken", mock.MatchedBy(func(claims map[string]interface{}) bool {
+			return claims["id"] == "at-1" &&
+				claims["sub"] == "user-1" &&
+				claims["client_id"] == "client-1" &&
+				claims["iss"] == "https://iam.example.com" &&
+				claims["project_id"] == "project-1" &&
+				assert.ObjectsAreEqual([]string{"role-1", "role-2"}, claims["roles"]) &&
+				assert.ObjectsAreEqual([]string{"orders"}, claims["resources"]) &&
+				claims["scope"] == "openid orders:read" &&
+				assert.ObjectsAreEqual([]string{sdk.AmrPassword, sdk.AmrOtp, sdk.AmrMfa}, claims["amr"]) &&
+				claims["acr"] == sdk.AcrMultiFactor &&
+				claims["email"] == nil
+		}), mock.AnythingOfType("int64")).Return("self-contained-token", nil).Once()
+
+		cl := sdk.Client{Id: "client-1", AccessTokenFormat: sdk.AccessTokenFormatSelfContained}
+		token, err := svc.generateClientAccessToken(cl, "at-1", usr, sdk.AuthToken{Scope: "openid orders:read", Amr: []string{sdk.AmrPassword, sdk.AmrOtp, sdk.AmrMfa}, Acr: sdk.AcrMultiFactor})
+		require.NoError(t, err)
+		assert.Equal(t, "self-contained-token", token)
+		mockJWT.AssertExpectations(t)
+	})
+
+	t.Run("self contained with a claims mapping", func(t *testing.T) {
+		svc, _, _, _, mockJWT, _, _ := setupFullTestService()
+		mockJWT.On("GenerateToken", mock.MatchedBy(func(claims map[string]interface{}) bool {
+			return claims["mail"] == "user@example.com" &&
+				assert.ObjectsAreEqual([]string{"role-1", "role-2"}, claims["groups"]) &&
+				claims["project_id"] == nil && claims["resources"] == nil
+		}), mock.AnythingOfType("int64")).Return("self-contained-token", nil).Once()
+
+		cl := sdk.Client{
+			Id:                "client-1",
+			AccessTokenFormat: sdk.AccessTokenFormatSelfContained,
+			AccessTokenClaims: map[string]string{"mail": sdk.AccessTokenClaimEmail, "groups": sdk.AccessTokenClaimRoles},
+		}
+		token, err := svc.generateClientAccessToken(cl, "at-1", usr, sdk.AuthToken{})
+		require.NoError(t, err)
+		assert.Equal(t, "self-contained-token", token)
+		mockJWT.AssertExpectations(t)
+	})
+
+	t.Run("falls back to opaque over the size cap", func(t *testing.T) {
+		svc, _, _, _, mockJWT, _, _ := setupFullTestService()
+		svc.maxTokenSize = 16
+		mockJWT.On("GenerateToken", mock.MatchedBy(func(claims map[string]interface{}) bool {
+			return claims["sub"] == "user-1"
+		}), mock.AnythingOfType("int64")).Return(strings.Repeat("a", 17), nil).Once()
+		mockJWT.On("GenerateToken", mock.MatchedBy(func(claims map[string]interface{}) bool {
+			return len(claims) == 3 && claims["id"] == "at-1" && claims["client_id"] == "client-1"
+		}), mock.AnythingOfType("int64")).Return("opaque-token", nil).Once()
+
+		cl := sdk.Client{Id: "client-1", AccessTokenFormat: sdk.AccessTokenFormatSelfContained}
+		token, err := svc.generateClientAccessToken(cl, "at-1", usr, sdk.AuthToken{})
+		require.NoError(t, err)
+		assert.Equal(t, "opaque-token", token)
+		mockJWT.AssertExpectations(t)
+	})
+}
+
+// TestGrantScope tests the validation of the requested scopes against the allowed scopes of the client
+func TestGrantScope(t *testing.T) {
+	cl := sdk.Client{Id: "client-1", Scopes: []string{"orders:read", "orders:write"}}
+
+	tests := []struct {
+		name          string
+		scope         string
+		expected      string
+		expectedError bool
+	}{
+		{name: "defaults to the allowed scopes", scope: "", expected: "orders:read orders:write"},
+		{name: "subset of the allowed scopes", scope: "orders:read", expected: "orders:read"},
+		{name: "standard scopes are always allowed", scope: "openid  email orders:read openid", expected: "openid email orders:read"},
+		{name: "scope not allowed", scope: "orders:read admin", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			granted, err := grantScope(cl, tt.scope)
+			if tt.expectedError {
+				assert.ErrorIs(t, err, sdk.ErrInvalidScope)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, granted)
+		})
+	}
+}
+
+// TestNarrowScope tests that a refreshed access token can only be issued for the scopes granted at login
+func TestNarrowScope(t *testing.T) {
+	scope, err := narrowScope("openid orders:read orders:write", "")
+	require.NoError(t, err)
+	assert.Equal(t, "openid orders:read orders:write", scope)
+
+	scope, err = narrowScope("openid orders:read orders:write", "orders:read")
+	require.NoError(t, err)
+	assert.Equal(t, "orders:read", scope)
+
+	_, err = narrowScope("openid orders:read", "orders:write")
+	assert.ErrorIs(t, err, sdk.ErrInvalidScope)
+}
+
+// TestRefreshToken tests the refresh token grant
+func TestRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	svc, _, mockClient, mockCache, mockJWT, mockEncrypt, mockUser := setupFullTestService()
+	mockRefresh := svc.refreshSvc.(*services.MockRefreshTokenService)
+	past := time.Now().Add(-time.Hour)
+
+	userSession := &sdk.RefreshToken{Id: "rt-2", FamilyId: "family-1", ClientId: "test-client", UserId: "user-1", AuthToken: sdk.AuthToken{ClientId: "test-client", AuthProviderID: "provider-1"}}
+	serviceAccountSession := &sdk.RefreshToken{Id: "rt-2", FamilyId: "family-1", ClientId: "test-client", UserId: "sa-user", AuthToken: sdk.AuthToken{ClientId: "test-client", ServiceAccountUserId: "sa-user"}}
+
+	tests := []struct {
+		name          string
+		clientSecret  string
+		setupMocks    func()
+		expectedError string
+		expectedIs    error
+	}{
+		{
+			name: "error - unknown client",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return((*sdk.Client)(nil), errors.New("client not found"))
+			},
+			expectedError: "invalid client_id",
+		},
+		{
+			name: "error - disabled client",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client"}, nil)
+			},
+			expectedError: "client is disabled",
+		},
+		{
+			name:         "error - invalid client secret",
+			clientSecret: "wrong-secret",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockCache.On("Get", ctx, "client-test-client").Return("hashed-secret", nil)
+				mockClient.On("VerifySecret", "wrong-secret", "hashed-secret").Return(errors.New("mismatch"))
+			},
+			expectedError: "invalid client secret",
+		},
+		{
+			name: "error - confidential client without its secret",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Secret: "hashed-secret", Enabled: true}, nil)
+			},
+			expectedIs: sdk.ErrClientAuthenticationRequired,
+		},
+		{
+			name: "error - refresh token reused",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockRefresh.On("Rotate", ctx, "refresh-token", "test-client", mock.AnythingOfType("bool")).Return(nil, "", sdk.ErrRefreshTokenReused)
+			},
+			expectedIs: sdk.ErrRefreshTokenReused,
+		},
+		{
+			name: "error - service account user disabled",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockRefresh.On("Rotate", ctx, "refresh-token", "test-client", mock.AnythingOfType("bool")).Return(serviceAccountSession, "new-refresh-token", nil)
+				mockUser.On("GetById", ctx, "sa-user").Return(&sdk.User{Id: "sa-user"}, nil)
+			},
+			expectedError: "user is disabled",
+		},
+		{
+			name: "error - service account user expired",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockRefresh.On("Rotate", ctx, "refresh-token", "test-client", mock.AnythingOfType("bool")).Return(serviceAccountSession, "new-refresh-token", nil)
+				mockUser.On("GetById", ctx, "sa-user").Return(&sdk.User{Id: "sa-user", Enabled: true, Expiry: &past}, nil)
+			},
+			expectedError: "user has expired",
+		},
+		{
+			name: "error - confidential client token refreshed without the client secret",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockRefresh.On("Rotate", ctx, "refresh-token", "test-client", false).Return(nil, "", sdk.ErrClientAuthenticationRequired)
+			},
+			expectedIs: sdk.ErrInvalidClient,
+		},
+		{
+			name: "error - refresh token of another client",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				otherSession := *userSession
+				otherSession.ClientId = "other-client"
+				mockRefresh.On("Rotate", ctx, "refresh-token", "test-client", false).Return(&otherSession, "new-refresh-token", nil)
+			},
+			expectedError: "refresh token was not issued to the client",
+		},
+		{
+			name: "error - user disabled since the login",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockRefresh.On("Rotate", ctx, "refresh-token", "test-client", false).Return(userSession, "new-refresh-token", nil)
+				mockUser.On("GetById", ctx, "user-1").Return(&sdk.User{Id: "user-1"}, nil)
+			},
+			expectedError: "user is disabled",
+		},
+		{
+			name: "error - user expired since the login",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockRefresh.On("Rotate", ctx, "refresh-token", "test-client", false).Return(userSession, "new-refresh-token", nil)
+				mockUser.On("GetById", ctx, "user-1").Return(&sdk.User{Id: "user-1", Enabled: true, Expiry: &past}, nil)
+			},
+			expectedError: "user has expired",
+		},
+		{
+			name: "error - access token generation fails",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockRefresh.On("Rotate", ctx, "refresh-token", "test-client", mock.AnythingOfType("bool")).Return(userSession, "new-refresh-token", nil)
+				mockUser.On("GetById", ctx, "user-1").Return(&sdk.User{Id: "user-1", Enabled: true}, nil)
+				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-access-token", nil)
+				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-access-token", mock.Anything).Return(nil)
+				mockJWT.On("GenerateToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("", errors.New("signing failed"))
+			},
+			expectedError: "error generating the access token",
+		},
+		{
+			name: "success - user session",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockRefresh.On("Rotate", ctx, "refresh-token", "test-client", mock.AnythingOfType("bool")).Return(userSession, "new-refresh-token", nil)
+				mockUser.On("GetById", ctx, "user-1").Return(&sdk.User{Id: "user-1", Enabled: true}, nil)
+				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-access-token", nil)
+				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-access-token", mock.Anything).Return(nil)
+				mockJWT.On("GenerateToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("jwt-token", nil)
+			},
+		},
+		{
+			name:         "success - confidential client with service account session",
+			clientSecret: "test-secret",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockCache.On("Get", ctx, "client-test-client").Return("hashed-secret", nil)
+				mockClient.On("VerifySecret", "test-secret", "hashed-secret").Return(nil)
+				mockRefresh.On("Rotate", ctx, "refresh-token", "test-client", true).Return(serviceAccountSession, "new-refresh-token", nil)
+				mockUser.On("GetById", ctx, "sa-user").Return(&sdk.User{Id: "sa-user", Enabled: true}, nil)
+				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-access-token", nil)
+				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-access-token", mock.Anything).Return(nil)
+				mockJWT.On("GenerateToken", mock.AnythingOfType("map[string]interface {}"), mock.AnythingOfType("int64")).Return("jwt-token", nil)
+			},
+		},
+		{
+			name: "success - self contained access token with the latest roles",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true, AccessTokenFormat: sdk.AccessTokenFormatSelfContained}, nil)
+				mockRefresh.On("Rotate", ctx, "refresh-token", "test-client", mock.AnythingOfType("bool")).Return(userSession, "new-refresh-token", nil)
+				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-access-token", nil)
+				mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-access-token", mock.Anything).Return(nil)
+				mockUser.On("GetById", ctx, "user-1").Return(&sdk.User{Id: "user-1", Enabled: true, Roles: map[string]sdk.UserRole{"role-1": {Id: "role-1"}}}, nil)
+				mockJWT.On("GenerateToken", mock.MatchedBy(func(claims map[string]interface{}) bool {
+					return claims["sub"] == "user-1" && assert.ObjectsAreEqual([]string{"role-1"}, claims["roles"])
+				}), mock.AnythingOfType("int64")).Return("jwt-token", nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient.ExpectedCalls = nil
+			mockCache.ExpectedCalls = nil
+			mockJWT.ExpectedCalls = nil
+			mockEncrypt.ExpectedCalls = nil
+			mockUser.ExpectedCalls = nil
+			mockRefresh.ExpectedCalls = nil
+
+			tt.setupMocks()
+
+			result, err := svc.RefreshToken(ctx, "refresh-token", "test-client", tt.clientSecret)
+
+			if tt.expectedError != "" || tt.expectedIs != nil {
+				require.Error(t, err)
+				assert.Nil(t, result)
+				if tt.expectedError != "" {
+					assert.Contains(t, err.Error(), tt.expectedError)
+				}
+				if tt.expectedIs != nil {
+					assert.ErrorIs(t, err, tt.expectedIs)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "jwt-token", result.AccessToken)
+			assert.Equal(t, "new-refresh-token", result.RefreshToken)
+			assert.Equal(t, "Bearer", result.TokenType)
+			assert.Equal(t, int64(3600), result.ExpiresIn)
+			assert.Empty(t, result.IdToken)
+			mockClient.AssertExpectations(t)
+			mockRefresh.AssertExpectations(t)
+			mockUser.AssertExpectations(t)
+		})
+	}
+}
+
+// TestGenerateIdToken tests the claims that go into the id token
+func TestGenerateIdToken(t *testing.T) {
+	svc, _, _, _, mockJWT, _, _ := setupFullTestService()
+	authTime := time.Now().Add(-time.Minute)
+
+	t.Run("success - standard claims with nonce", func(t *testing.T) {
+		mockJWT.ExpectedCalls = nil
+
+		usr := sdk.User{Id: "user-1", Email: "user@example.com", Name: "Test User", ProfilePic: "https://pic"}
+		mockJWT.On("GenerateIdToken", mock.MatchedBy(func(claims map[string]interface{}) bool {
+			return claims["iss"] == "https://iam.example.com" &&
+				claims["sub"] == "user-1" &&
+				claims["aud"] == "client-1" &&
+				claims["nonce"] == "nonce-1" &&
+				claims["auth_time"] == authTime.Unix() &&
+				assert.ObjectsAreEqual([]string{sdk.AmrPassword}, claims["amr"]) &&
+				claims["acr"] == sdk.AcrSingleFactor &&
+				claims["email"] == "user@example.com" &&
+				claims["name"] == "Test User" &&
+				claims["picture"] == "https://pic"
+		}), mock.AnythingOfType("int64")).Return("id-token", nil)
+
+		idToken, err := svc.generateIdToken(usr, sdk.AuthToken{ClientId: "client-1", Nonce: "nonce-1", AuthTime: authTime, Amr: []string{sdk.AmrPassword}, Acr: sdk.AcrSingleFactor})
+		require.NoError(t, err)
+		assert.Equal(t, "id-token", idToken)
+		mockJWT.AssertExpectations(t)
+	})
+
+	t.Run("success - optional claims are omitted", func(t *testing.T) {
+		mockJWT.ExpectedCalls = nil
+
+		usr := sdk.User{Id: "user-1", Phone: "+10000000000"}
+		mockJWT.On("GenerateIdToken", mock.MatchedBy(func(claims map[string]interface{}) bool {
+			_, hasNonce := claims["nonce"]
+			_, hasEmail := claims["email"]
+			_, hasAuthTime := claims["auth_time"]
+			_, hasAmr := claims["amr"]
+			return !hasNonce && !hasEmail && hasAuthTime && !hasAmr
+		}), mock.AnythingOfType("int64")).Return("id-token", nil)
+
+		_, err := svc.generateIdToken(usr, sdk.AuthToken{ClientId: "client-1"})
+		require.NoError(t, err)
+		mockJWT.AssertExpectations(t)
+	})
+}
+
+// TestRedirectCarriesNonce tests that the nonce given at login ends up in the auth code
+func TestRedirectCarriesNonce(t *testing.T) {
+	ctx := context.Background()
+	svc, mockAuthProvider, mockClient, mockCache, _, mockEncrypt, mockUser := setupFullTestService()
+	mockMfa := svc.mfaSvc.(*services.MockMfaService)
+	mockPasskey := svc.passkeySvc.(*services.MockPasskeyService)
+	mockPasskey.On("List", mock.Anything, mock.Anything).Return([]sdk.Passkey{}, nil)
+
+	mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
+	mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com","nonce":"nonce-1"}`, nil)
+	authProvider := &sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123"}
+	mockServiceProvider := &MockServiceProvider{}
+	mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
+	mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
+	mockServiceProvider.On("VerifyCode", ctx, "valid-code").Return(&sdk.AuthToken{AccessToken: "access-token", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+	mockServiceProvider.On("GetIdentity", "access-token").Return([]sdk.AuthIdentity{
+		{Type: sdk.AuthIdentityTypeEmail, Metadata: MockEmailMetadata{Email: "user@example.com"}},
+	}, nil)
+	mockUser.On("GetByEmail", ctx, "user@example.com", "project-123").Return(&sdk.User{Id: "user-1", Email: "user@example.com", Enabled: true}, nil)
+	mockMfa.On("Status", ctx, mock.Anything).Return(&sdk.MfaStatus{}, nil)
+	mockEncrypt.On("Encrypt", mock.MatchedBy(func(raw string) bool {
+		token := sdk.AuthToken{}
+		err := json.Unmarshal([]byte(raw), &token)
+		return err == nil && token.Nonce == "nonce-1" && token.ClientId == "client-id" && !token.AuthTime.IsZero() &&
+			token.Acr == sdk.AcrSingleFactor && slices.Equal(token.Amr, []string{sdk.AmrFederated})
+	})).Return("encrypted-auth-token", nil)
+	mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
+	mockClient.On("Get", ctx, "client-id", true).Return(&sdk.Client{Id: "client-id", RedirectURLs: []string{"http://callback.com"}}, nil)
+	mockCache.On("Delete", ctx, "state-valid-state").Return(nil)
+
+	result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+	require.NoError(t, err)
+	assert.Contains(t, result.RedirectUrl, "state=original-state")
+	mockEncrypt.AssertExpectations(t)
+}
+
+func TestProviderNonce(t *testing.T) {
+	ctx := context.Background()
+	svc, mockAuthProvider, mockClient, mockCache, _, mockEncrypt, mockUser := setupFullTestService()
+	authProvider := &sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123"}
+	mockServiceProvider := &MockNonceServiceProvider{}
+	mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
+	mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
+
+	t.Run("login url carries the nonce kept in the state", func(t *testing.T) {
+		nonce := ""
+		mockClient.On("Get", ctx, "client-id", true).Return(&sdk.Client{Id: "client-id", ProjectId: "project-123"}, nil).Once()
+		mockEncrypt.On("Encrypt", mock.MatchedBy(func(raw string) bool {
+			params := sdk.AuthLoginParams{}
+			err := json.Unmarshal([]byte(raw), &params)
+			nonce = params.ProviderNonce
+			return err == nil && len(nonce) > 0
+		})).Return("encrypted-state", nil).Once()
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-state", mock.Anything).Return(nil).Once()
+		mockServiceProvider.On("GetAuthCodeUrlWithNonce", mock.AnythingOfType("string"), mock.MatchedBy(func(n string) bool {
+			return n == nonce
+		})).Return("https://idp.example.com/authorize").Once()
+
+		url, err := svc.GetLoginUrl(ctx, sdk.AuthLoginParams{
+			ClientId:       "client-id",
+			AuthProviderId: "provider-id",
+			RedirectUrl:    "http://callback.com",
+			ProviderNonce:  "chosen-by-the-client",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "https://idp.example.com/authorize", url)
+		assert.NotEqual(t, "chosen-by-the-client", nonce)
+		mockServiceProvider.AssertNotCalled(t, "GetAuthCodeUrl", mock.Anything)
+	})
+
+	t.Run("code is verified with the nonce of the state", func(t *testing.T) {
+		mockMfa := svc.mfaSvc.(*services.MockMfaService)
+		mockPasskey := svc.passkeySvc.(*services.MockPasskeyService)
+		mockPasskey.On("List", mock.Anything, mock.Anything).Return([]sdk.Passkey{}, nil)
+		mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
+		mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com","provider_nonce":"provider-nonce-1"}`, nil)
+		mockServiceProvider.On("VerifyCodeWithNonce", ctx, "valid-code", "provider-nonce-1").Return(&sdk.AuthToken{AccessToken: "access-token", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+		mockServiceProvider.On("GetIdentity", "access-token").Return([]sdk.AuthIdentity{
+			{Type: sdk.AuthIdentityTypeEmail, Metadata: MockEmailMetadata{Email: "user@example.com"}},
+		}, nil)
+		mockUser.On("GetByEmail", ctx, "user@example.com", "project-123").Return(&sdk.User{Id: "user-1", Email: "user@example.com", Enabled: true}, nil)
+		mockMfa.On("Status", ctx, mock.Anything).Return(&sdk.MfaStatus{}, nil)
+		mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-auth-token", nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-auth-token", mock.Anything).Return(nil)
+		mockClient.On("Get", ctx, "client-id", true).Return(&sdk.Client{Id: "client-id", RedirectURLs: []string{"http://callback.com"}}, nil)
+		mockCache.On("Delete", ctx, "state-valid-state").Return(nil)
+
+		_, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.NoError(t, err)
+		mockServiceProvider.AssertExpectations(t)
+		mockServiceProvider.AssertNotCalled(t, "VerifyCode", mock.Anything, mock.Anything)
+	})
+}
+
+// TestGetOpenIdConfiguration tests the discovery document
+func TestGetOpenIdConfiguration(t *testing.T) {
+	svc, _, _, _, mockJWT, _, _ := setupFullTestService()
+	jwks := sdk.Jwks{Keys: []sdk.Jwk{
+		{Kid: "kid-2", Kty: "EC", Alg: "ES256"},
+		{Kid: "kid-1", Kty: "RSA", Alg: "RS256"},
+		{Kid: "kid-0", Kty: "RSA", Alg: "RS256"},
+	}}
+	mockJWT.On("Jwks").Return(jwks)
+
+	conf := svc.GetOpenIdConfiguration()
+	assert.Equal(t, "https://iam.example.com", conf.Issuer)
+	assert.Equal(t, "https://iam.example.com/auth/v1/login", conf.AuthorizationEndpoint)
+	assert.Equal(t, "https://iam.example.com/auth/v1/jwks", conf.JwksUri)
+	assert.Equal(t, "https://iam.example.com/auth/v1/userinfo", conf.UserinfoEndpoint)
+	assert.Equal(t, "https://iam.example.com/auth/v1/revoke", conf.RevocationEndpoint)
+	assert.Equal(t, "https://iam.example.com/auth/v1/introspect", conf.IntrospectionEndpoint)
+	assert.Equal(t, "https://iam.example.com/auth/v1/logout", conf.EndSessionEndpoint)
+	assert.Equal(t, []string{"ES256", "RS256"}, conf.IdTokenSigningAlgValuesSupported)
+
+	assert.Equal(t, jwks, svc.GetJwks())
+}
+
+// TestRevokeToken tests the RFC 7009 revocation of access and refresh tokens
+func TestRevokeToken(t *testing.T) {
+	ctx := context.Background()
+	svc, _, mockClient, mockCache, mockJWT, mockEncrypt, _ := setupFullTestService()
+	mockRefresh := svc.refreshSvc.(*services.MockRefreshTokenService)
+
+	tests := []struct {
+		name          string
+		tokenTypeHint string
+		setupMocks    func()
+		expectedError string
+		expectedIs    error
+	}{
+		{
+			name: "error - disabled client",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client"}, nil)
+			},
+			expectedError: "client is disabled",
+		},
+		{
+			name: "success - access token",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockJWT.On("ValidateToken", "the-token").Return(map[string]interface{}{"id": "token-123"}, nil)
+				mockCache.On("Get", ctx, "access-token-token-123").Return("encrypted-token", nil)
+				mockEncrypt.On("Decrypt", "encrypted-token").Return(`{"client_id":"test-client"}`, nil)
+				mockCache.On("Get", ctx, "token-the-token").Return("encrypted-user", nil)
+				mockEncrypt.On("Decrypt", "encrypted-user").Return(`{"id":"user-1"}`, nil)
+				mockCache.On("Get", ctx, "user-token-user-1").Return("the-token", nil)
+				mockCache.On("Delete", ctx, "user-token-user-1").Return(nil).Once()
+				mockCache.On("Delete", ctx, "token-the-token").Return(nil).Once()
+				mockCache.On("Delete", ctx, "access-token-token-123").Return(nil).Once()
+			},
+		},
+		{
+			name: "error - access token of another client",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockJWT.On("ValidateToken", "the-token").Return(map[string]interface{}{"id": "token-123"}, nil)
+				mockCache.On("Get", ctx, "access-token-token-123").Return("encrypted-token", nil)
+				mockEncrypt.On("Decrypt", "encrypted-token").Return(`{"client_id":"other-client"}`, nil)
+			},
+			expectedIs: sdk.ErrTokenClientMismatch,
+		},
+		{
+			name: "success - falls back to the refresh token",
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockJWT.On("ValidateToken", "the-token").Return(nil, errors.New("token is malformed"))
+				mockRefresh.On("Revoke", ctx, "the-token", "test-client").Return(nil).Once()
+			},
+		},
+		{
+			name:          "success - refresh token hint",
+			tokenTypeHint: sdk.TokenTypeHintRefreshToken,
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockRefresh.On("Revoke", ctx, "the-token", "test-client").Return(nil).Once()
+			},
+		},
+		{
+			name:          "success - unknown token",
+			tokenTypeHint: sdk.TokenTypeHintRefreshToken,
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockRefresh.On("Revoke", ctx, "the-token", "test-client").Return(sdk.ErrRefreshTokenNotFound).Once()
+				mockJWT.On("ValidateToken", "the-token").Return(nil, errors.New("token is malformed"))
+			},
+		},
+		{
+			name:          "error - refresh token of another client",
+			tokenTypeHint: sdk.TokenTypeHintRefreshToken,
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockRefresh.On("Revoke", ctx, "the-token", "test-client").Return(sdk.ErrTokenClientMismatch).Once()
+			},
+			expectedIs: sdk.ErrTokenClientMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient.ExpectedCalls = nil
+			mockCache.ExpectedCalls = nil
+			mockJWT.ExpectedCalls = nil
+			mockEncrypt.ExpectedCalls = nil
+			mockRefresh.ExpectedCalls = nil
+
+			tt.setupMocks()
+
+			err := svc.RevokeToken(ctx, "the-token", tt.tokenTypeHint, "test-client", "")
+
+			if tt.expectedError != "" || tt.expectedIs != nil {
+				require.Error(t, err)
+				if tt.expectedError != "" {
+					assert.Contains(t, err.Error(), tt.expectedError)
+				}
+				if tt.expectedIs != nil {
+					assert.ErrorIs(t, err, tt.expectedIs)
+				}
+				return
+			}
+			require.NoError(t, err)
+			mockCache.AssertExpectations(t)
+			mockRefresh.AssertExpectations(t)
+		})
+	}
+}
+
+// TestLogout tests clearing the session and the post logout redirect
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+	svc, _, mockClient, mockCache, mockJWT, mockEncrypt, _ := setupFullTestService()
+	mockRefresh := svc.refreshSvc.(*services.MockRefreshTokenService)
+	client := &sdk.Client{Id: "test-client", Enabled: true, PostLogoutRedirectURLs: []string{"https://app.example.com/bye"}}
+
+	t.Run("error - unregistered post logout redirect url", func(t *testing.T) {
+		mockClient.ExpectedCalls = nil
+		mockClient.On("Get", ctx, "test-client", true).Return(client, nil)
+
+		_, err := svc.Logout(ctx, "the-token", "test-client", "https://evil.example.com", "")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sdk.ErrPostLogoutRedirectUrlNotFound)
+	})
+
+	t.Run("success - clears the session and redirects with state", func(t *testing.T) {
+		mockClient.ExpectedCalls = nil
+		mockCache.ExpectedCalls = nil
+		mockJWT.ExpectedCalls = nil
+		mockEncrypt.ExpectedCalls = nil
+		mockRefresh.ExpectedCalls = nil
+		mockClient.On("Get", ctx, "test-client", true).Return(client, nil)
+		mockJWT.On("ValidateToken", "the-token").Return(map[string]interface{}{"id": "token-123"}, nil)
+		mockCache.On("Get", ctx, "access-token-token-123").Return("encrypted-token", nil)
+		mockEncrypt.On("Decrypt", "encrypted-token").Return(`{"client_id":"test-client","session_id":"session-1"}`, nil)
+		mockRefresh.On("RevokeFamily", ctx, "session-1").Return(nil).Once()
+		mockCache.On("Get", ctx, "token-the-token").Return("", errors.New("key not found"))
+		mockCache.On("Delete", ctx, "token-the-token").Return(nil).Once()
+		mockCache.On("Delete", ctx, "access-token-token-123").Return(nil).Once()
+
+		redirectUrl, err := svc.Logout(ctx, "the-token", "test-client", "https://app.example.com/bye", "a b")
+		require.NoError(t, err)
+		assert.Equal(t, "https://app.example.com/bye?state=a+b", redirectUrl)
+		mockCache.AssertExpectations(t)
+		mockRefresh.AssertExpectations(t)
+	})
+
+	t.Run("success - expired access token", func(t *testing.T) {
+		mockJWT.ExpectedCalls = nil
+		mockJWT.On("ValidateToken", "expired-token").Return(nil, errors.New("token is expired"))
+
+		redirectUrl, err := svc.Logout(ctx, "expired-token", "", "", "")
+		require.NoError(t, err)
+		assert.Empty(t, redirectUrl)
+	})
+}
+
+// TestRevokeUserTokens tests signing a user out everywhere
+func TestRevokeUserTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, mockCache, _, _, _ := setupFullTestService()
+	mockRefresh := svc.refreshSvc.(*services.MockRefreshTokenService)
+
+	t.Run("error - revoking the refresh tokens fails", func(t *testing.T) {
+		mockRefresh.ExpectedCalls = nil
+		mockRefresh.On("RevokeUser", ctx, "user-1").Return(errors.New("db error"))
+
+		err := svc.RevokeUserTokens(ctx, "user-1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error revoking the refresh tokens")
+	})
+
+	t.Run("success - drops the cached identity", func(t *testing.T) {
+		mockRefresh.ExpectedCalls = nil
+		mockCache.ExpectedCalls = nil
+		mockRefresh.On("RevokeUser", ctx, "user-1").Return(nil).Once()
+		mockCache.On("Set", ctx, "revoked-user-user-1", mock.AnythingOfType("string"), time.Minute*86400).Return(nil).Once()
+		mockCache.On("Get", ctx, "user-token-user-1").Return("the-token", nil)
+		mockCache.On("Delete", ctx, "token-the-token").Return(nil).Once()
+		mockCache.On("Delete", ctx, "user-token-user-1").Return(nil).Once()
+
+		err := svc.RevokeUserTokens(ctx, "user-1")
+		require.NoError(t, err)
+		mockCache.AssertExpectations(t)
+		mockRefresh.AssertExpectations(t)
+	})
+}
+
+// TestCheckRevocation tests rejecting the access tokens issued before the user was signed out
+func TestCheckRevocation(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, mockCache, _, _, _ := setupFullTestService()
+	revokedAt := time.Now().Add(-time.Minute).Unix()
+
+	tests := []struct {
+		name       string
+		cached     string
+		cacheErr   error
+		claims     map[string]interface{}
+		expectedIs error
+	}{
+		{name: "never revoked", cacheErr: errors.New("key not found"), claims: map[string]interface{}{}},
+		{name: "issued before the revocation", cached: strconv.FormatInt(revokedAt, 10), claims: map[string]interface{}{"iat": float64(revokedAt - 10)}, expectedIs: sdk.ErrTokenRevoked},
+		{name: "issued without iat", cached: strconv.FormatInt(revokedAt, 10), claims: map[string]interface{}{}, expectedIs: sdk.ErrTokenRevoked},
+		{name: "issued after the revocation", cached: strconv.FormatInt(revokedAt, 10), claims: map[string]interface{}{"iat": float64(revokedAt + 10)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCache.ExpectedCalls = nil
+			mockCache.On("Get", ctx, "revoked-user-user-1").Return(tt.cached, tt.cacheErr)
+
+			err := svc.checkRevocation(ctx, "user-1", tt.claims)
+			if tt.expectedIs != nil {
+				assert.ErrorIs(t, err, tt.expectedIs)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("consent of the client revoked", func(t *testing.T) {
+		mockCache.ExpectedCalls = nil
+		mockCache.On("Get", ctx, "revoked-user-user-1").Return("", errors.New("key not found"))
+		mockCache.On("Get", ctx, "revoked-consent-user-1-client-1").Return(strconv.FormatInt(revokedAt, 10), nil)
+
+		err := svc.checkRevocation(ctx, "user-1", map[string]interface{}{"iat": float64(revokedAt - 10), "client_id": "client-1"})
+		assert.ErrorIs(t, err, sdk.ErrTokenRevoked)
+	})
+}
+
+// TestIntrospectToken tests the RFC 7662 introspection of access tokens
+func TestIntrospectToken(t *testing.T) {
+	ctx := context.Background()
+	svc, _, mockClient, mockCache, mockJWT, mockEncrypt, _ := setupFullTestService()
+	now := time.Now()
+	exp := now.Add(time.Hour).Unix()
+	cachedUser := `{"id":"user-1","project_id":"project-1","roles":{"role-1":{"id":"role-1","name":"editor"}},"resources":{"@docs/write":{"key":"@docs/write"}}}`
+
+	authenticated := func(projectId string) {
+		mockClient.On("Get", ctx, "resource-server", true).Return(&sdk.Client{Id: "resource-server", ProjectId: projectId, Enabled: true}, nil)
+		mockCache.On("Get", ctx, "client-resource-server").Return("hashed-secret", nil)
+		mockClient.On("VerifySecret", "secret", "hashed-secret").Return(nil)
+	}
+	activeToken := func() {
+		mockJWT.On("ValidateToken", "the-token").Return(map[string]interface{}{"id": "token-123", "exp": float64(exp), "iat": float64(now.Unix())}, nil)
+		mockCache.On("Get", ctx, "access-token-token-123").Return("encrypted-token", nil)
+		mockEncrypt.On("Decrypt", "encrypted-token").Return(`{"client_id":"app","scope":"openid email"}`, nil)
+		mockCache.On("Get", ctx, "token-the-token").Return("encrypted-user", nil)
+		mockEncrypt.On("Decrypt", "encrypted-user").Return(cachedUser, nil)
+		mockCache.On("Get", ctx, "revoked-user-user-1").Return("", errors.New("key not found"))
+	}
+
+	tests := []struct {
+		name             string
+		clientSecret     string
+		includeRoles     bool
+		introspectionTTL int64
+		setupMocks       func()
+		expectedIs       error
+		expected         *sdk.TokenIntrospectionResponse
+	}{
+		{
+			name:       "error - client secret is missing",
+			setupMocks: func() {},
+			expectedIs: sdk.ErrClientAuthenticationRequired,
+		},
+		{
+			name:         "inactive - invalid jwt",
+			clientSecret: "secret",
+			setupMocks: func() {
+				authenticated("project-1")
+				mockJWT.On("ValidateToken", "the-token").Return(nil, errors.New("token is expired"))
+			},
+			expected: &sdk.TokenIntrospectionResponse{Active: false},
+		},
+		{
+			name:         "inactive - session is gone",
+			clientSecret: "secret",
+			setupMocks: func() {
+				authenticated("project-1")
+				mockJWT.On("ValidateToken", "the-token").Return(map[string]interface{}{"id": "token-123"}, nil)
+				mockCache.On("Get", ctx, "access-token-token-123").Return("", errors.New("key not found"))
+			},
+			expected: &sdk.TokenIntrospectionResponse{Active: false},
+		},
+		{
+			name:         "inactive - user of another project",
+			clientSecret: "secret",
+			setupMocks: func() {
+				authenticated("project-2")
+				activeToken()
+			},
+			expected: &sdk.TokenIntrospectionResponse{Active: false},
+		},
+		{
+			name:         "active - without roles",
+			clientSecret: "secret",
+			setupMocks: func() {
+				authenticated("project-1")
+				activeToken()
+			},
+			expected: &sdk.TokenIntrospectionResponse{Active: true, Sub: "user-1", ClientId: "app", Exp: exp, Iat: now.Unix(), Scope: "openid email", TokenType: "Bearer", ProjectId: "project-1"},
+		},
+		{
+			name:             "active - with roles and cached",
+			clientSecret:     "secret",
+			includeRoles:     true,
+			introspectionTTL: 30,
+			setupMocks: func() {
+				authenticated("project-1")
+				mockCache.On("Get", ctx, introspectionCacheKey("the-token")).Return("", errors.New("key not found"))
+				activeToken()
+				mockEncrypt.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-introspection", nil)
+				mockCache.On("Set", ctx, introspectionCacheKey("the-token"), "encrypted-introspection", 30*time.Second).Return(nil).Once()
+			},
+			expected: &sdk.TokenIntrospectionResponse{Active: true, Sub: "user-1", ClientId: "app", Exp: exp, Iat: now.Unix(), Scope: "openid email", TokenType: "Bearer", ProjectId: "project-1", Roles: []string{"editor"}, Resources: []string{"@docs/write"}},
+		},
+		{
+			name:             "active - served from cache",
+			clientSecret:     "secret",
+			introspectionTTL: 30,
+			setupMocks: func() {
+				authenticated("project-1")
+				mockCache.On("Get", ctx, introspectionCacheKey("the-token")).Return("encrypted-introspection", nil)
+				mockEncrypt.On("Decrypt", "encrypted-introspection").Return(`{"active":true,"sub":"user-1","project_id":"project-1","roles":["editor"]}`, nil)
+				mockCache.On("Get", ctx, "revoked-user-user-1").Return("", errors.New("key not found"))
+			},
+			expected: &sdk.TokenIntrospectionResponse{Active: true, Sub: "user-1", ProjectId: "project-1"},
+		},
+		{
+			name:             "inactive - cached result of a user signed out everywhere",
+			clientSecret:     "secret",
+			introspectionTTL: 30,
+			setupMocks: func() {
+				authenticated("project-1")
+				mockCache.On("Get", ctx, introspectionCacheKey("the-token")).Return("encrypted-introspection", nil)
+				mockEncrypt.On("Decrypt", "encrypted-introspection").Return(`{"active":true,"sub":"user-1","client_id":"app","iat":`+strconv.FormatInt(now.Unix(), 10)+`,"project_id":"project-1"}`, nil)
+				mockCache.On("Get", ctx, "revoked-user-user-1").Return(strconv.FormatInt(now.Unix(), 10), nil)
+			},
+			expected: &sdk.TokenIntrospectionResponse{Active: false},
+		},
+		{
+			name:             "inactive - cached result of a revoked consent",
+			clientSecret:     "secret",
+			introspectionTTL: 30,
+			setupMocks: func() {
+				authenticated("project-1")
+				mockCache.On("Get", ctx, introspectionCacheKey("the-token")).Return("encrypted-introspection", nil)
+				mockEncrypt.On("Decrypt", "encrypted-introspection").Return(`{"active":true,"sub":"user-1","client_id":"app","iat":`+strconv.FormatInt(now.Unix(), 10)+`,"project_id":"project-1"}`, nil)
+				mockCache.On("Get", ctx, "revoked-user-user-1").Return("", errors.New("key not found"))
+				mockCache.On("Get", ctx, "revoked-consent-user-1-app").Return(strconv.FormatInt(now.Unix(), 10), nil)
+			},
+			expected: &sdk.TokenIntrospectionResponse{Active: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient.ExpectedCalls = nil
+			mockCache.ExpectedCalls = nil
+			mockJWT.ExpectedCalls = nil
+			mockEncrypt.ExpectedCalls = nil
+			svc.introspectionTTL = tt.introspectionTTL
+
+			tt.setupMocks()
+
+			resp, err := svc.IntrospectToken(ctx, "the-token", "resource-server", tt.clientSecret, tt.includeRoles)
+			if tt.expectedIs != nil {
+				assert.ErrorIs(t, err, tt.expectedIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, resp)
+			mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+// TestToken tests the grant type dispatch of the token endpoint and the RFC 6749 error kinds
+func TestToken(t *testing.T) {
+	ctx := context.Background()
+	svc, _, mockClient, mockCache, _, mockEncrypt, _ := setupFullTestService()
+	mockRefresh := svc.refreshSvc.(*services.MockRefreshTokenService)
+	cachedCode := func() {
+		mockCache.On("GetDel", ctx, "auth-code-abc").Return("encrypted-code", nil)
+		mockEncrypt.On("Decrypt", "encrypted-code").Return(`{"client_id":"test-client","redirect_url":"https://app.example.com/cb","code_challenge":"`+testCodeChallenge+`","code_challenge_method":"S256"}`, nil)
+		for _, id := range []string{"test-client", "other-client"} {
+			mockClient.On("Get", ctx, id, true).Return(&sdk.Client{Id: id, Enabled: true, Public: true}, nil).Maybe()
+		}
+	}
+
+	tests := []struct {
+		name       string
+		req        sdk.TokenRequest
+		setupMocks func()
+		expectedIs []error
+	}{
+		{
+			name:       "unsupported grant type",
+			req:        sdk.TokenRequest{GrantType: "password", ClientId: "test-client"},
+			setupMocks: func() {},
+			expectedIs: []error{sdk.ErrUnsupportedGrantType},
+		},
+		{
+			name: "unknown authorization code",
+			req:  sdk.TokenRequest{GrantType: sdk.GrantTypeAuthorizationCode, Code: "abc", RedirectUri: "https://app.example.com/cb", ClientId: "test-client"},
+			setupMocks: func() {
+				mockCache.On("GetDel", ctx, "auth-code-abc").Return("", errors.New("key not found"))
+			},
+			expectedIs: []error{sdk.ErrInvalidGrant},
+		},
+		{
+			name:       "authorization code of another client",
+			req:        sdk.TokenRequest{GrantType: sdk.GrantTypeAuthorizationCode, Code: "abc", CodeVerifier: testCodeVerifier, RedirectUri: "https://app.example.com/cb", ClientId: "other-client"},
+			setupMocks: cachedCode,
+			expectedIs: []error{sdk.ErrInvalidGrant},
+		},
+		{
+			name:       "redirect uri does not match",
+			req:        sdk.TokenRequest{GrantType: sdk.GrantTypeAuthorizationCode, Code: "abc", CodeVerifier: testCodeVerifier, RedirectUri: "https://evil.example.com/cb", ClientId: "test-client"},
+			setupMocks: cachedCode,
+			expectedIs: []error{sdk.ErrInvalidGrant},
+		},
+		{
+			name: "invalid client secret",
+			req:  sdk.TokenRequest{GrantType: sdk.GrantTypeAuthorizationCode, Code: "abc", RedirectUri: "https://app.example.com/cb", ClientId: "test-client", ClientSecret: "wrong"},
+			setupMocks: func() {
+				cachedCode()
+				mockCache.On("Get", ctx, "client-test-client").Return("hashed-secret", nil)
+				mockClient.On("VerifySecret", "wrong", "hashed-secret").Return(errors.New("mismatch"))
+			},
+			expectedIs: []error{sdk.ErrInvalidClient},
+		},
+		{
+			name: "client credentials of a disabled client",
+			req:  sdk.TokenRequest{GrantType: sdk.GrantTypeClientCredentials, ClientId: "test-client", ClientSecret: "secret"},
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client"}, nil)
+			},
+			expectedIs: []error{sdk.ErrInvalidClient},
+		},
+		{
+			name: "client credentials of a regular client",
+			req:  sdk.TokenRequest{GrantType: sdk.GrantTypeClientCredentials, ClientId: "test-client", ClientSecret: "secret"},
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Secret: "hashed-secret"}, nil)
+				mockClient.On("VerifySecret", "secret", "hashed-secret").Return(nil)
+			},
+			expectedIs: []error{sdk.ErrUnauthorizedClient},
+		},
+		{
+			name: "reused refresh token",
+			req:  sdk.TokenRequest{GrantType: sdk.GrantTypeRefreshToken, RefreshToken: "rt", ClientId: "test-client"},
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				mockRefresh.On("Rotate", ctx, "rt", "test-client", mock.AnythingOfType("bool")).Return(nil, "", sdk.ErrRefreshTokenReused)
+			},
+			expectedIs: []error{sdk.ErrInvalidGrant, sdk.ErrRefreshTokenReused},
+		}, {
+			name: "refresh for a scope not granted at login",
+			req:  sdk.TokenRequest{GrantType: sdk.GrantTypeRefreshToken, RefreshToken: "rt", ClientId: "test-client", Scope: "orders:write"},
+			setupMocks: func() {
+				mockClient.On("Get", ctx, "test-client", true).Return(&sdk.Client{Id: "test-client", Enabled: true, Public: true}, nil)
+				session := &sdk.RefreshToken{Id: "rt-2", ClientId: "test-client", UserId: "user-1", AuthToken: sdk.AuthToken{ClientId: "test-client", Scope: "openid orders:read"}}
+				mockRefresh.On("Rotate", ctx, "rt", "test-client", mock.AnythingOfType("bool")).Return(session, "new-refresh-token", nil)
+			},
+			expectedIs: []error{sdk.ErrInvalidScope},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient.ExpectedCalls = nil
+			mockCache.ExpectedCalls = nil
+			mockEncrypt.ExpectedCalls = nil
+			mockRefresh.ExpectedCalls = nil
+
+			tt.setupMocks()
+
+			result, err := svc.Token(ctx, tt.req)
+			require.Error(t, err)
+			assert.Nil(t, result)
+			for _, target := range tt.expectedIs {
+				assert.ErrorIs(t, err, target)
+			}
+		})
+	}
+}
+
+func TestGetOrCreateUserLinkedIdentity(t *testing.T) {
+	ctx := context.Background()
+	pid := providerIdentity{
+		User:     sdk.User{Email: "new@example.com", EmailVerified: true, ProjectId: "project-123"},
+		Provider: sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123"},
+		Subject:  "subject-1",
+		External: true,
+	}
+
+	t.Run("the linked identity leads to the user whatever their email", func(t *testing.T) {
+		svc, _, _, _, _, _, mockUser := setupFullTestService()
+		mockIdentity := &services.MockIdentityService{}
+		svc.identitySvc = mockIdentity
+		linked := &sdk.UserIdentity{Id: "identity-1", UserId: "user-1", AuthProviderId: "provider-id", Subject: "subject-1"}
+		mockIdentity.On("Get", ctx, "provider-id", "subject-1").Return(linked, nil)
+		mockUser.On("GetById", ctx, "user-1").Return(&sdk.User{Id: "user-1", Email: "old@example.com", Enabled: true}, nil)
+
+		usr, identity, err := svc.getOrCreateUser(ctx, pid)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", usr.Id)
+		assert.Equal(t, linked, identity)
+		mockUser.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything, mock.Anything)
+		mockIdentity.AssertNotCalled(t, "Link", mock.Anything, mock.Anything)
+	})
+
+	t.Run("the identity is linked to the user found by email", func(t *testing.T) {
+		svc, _, _, _, _, _, mockUser := setupFullTestService()
+		mockIdentity := &services.MockIdentityService{}
+		svc.identitySvc = mockIdentity
+		mockIdentity.On("Get", ctx, "provider-id", "subject-1").Return(nil, sdk.ErrUserIdentityNotFound)
+		mockUser.On("GetByEmail", ctx, "new@example.com", "project-123").Return(&sdk.User{Id: "user-2", ProjectId: "project-123", EmailVerified: true, Enabled: true}, nil)
+		mockIdentity.On("Link", ctx, &sdk.UserIdentity{
+			UserId:         "user-2",
+			ProjectId:      "project-123",
+			AuthProviderId: "provider-id",
+			Subject:        "subject-1",
+			Email:          "new@example.com",
+			EmailVerified:  true,
+		}).Return(nil)
+
+		usr, _, err := svc.getOrCreateUser(ctx, pid)
+		require.NoError(t, err)
+		assert.Equal(t, "user-2", usr.Id)
+		mockIdentity.AssertExpectations(t)
+	})
+
+	t.Run("login after unlink is not linked by email again", func(t *testing.T) {
+		svc, _, _, _, _, _, mockUser := setupFullTestService()
+		mockIdentity := &services.MockIdentityService{}
+		svc.identitySvc = mockIdentity
+		unlinkedAt := time.Now().Add(-time.Minute)
+		mockIdentity.On("Get", ctx, "provider-id", "subject-1").Return(&sdk.UserIdentity{Id: "identity-1", UserId: "user-1", AuthProviderId: "provider-id", Subject: "subject-1", UnlinkedAt: &unlinkedAt}, nil)
+
+		usr, identity, err := svc.getOrCreateUser(ctx, pid)
+		assert.ErrorIs(t, err, sdk.ErrLoginDenied)
+		assert.ErrorIs(t, err, sdk.ErrUserIdentityUnlinked)
+		assert.Nil(t, usr)
+		assert.Nil(t, identity)
+		mockUser.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything, mock.Anything)
+		mockUser.AssertNotCalled(t, "GetById", mock.Anything, mock.Anything)
+		mockIdentity.AssertNotCalled(t, "Link", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - linking fails", func(t *testing.T) {
+		svc, _, _, _, _, _, mockUser := setupFullTestService()
+		mockIdentity := &services.MockIdentityService{}
+		svc.identitySvc = mockIdentity
+		mockIdentity.On("Get", ctx, "provider-id", "subject-1").Return(nil, sdk.ErrUserIdentityNotFound)
+		mockUser.On("GetByEmail", ctx, "new@example.com", "project-123").Return(&sdk.User{Id: "user-2", EmailVerified: true, Enabled: true}, nil)
+		mockIdentity.On("Link", ctx, mock.Anything).Return(errors.New("db error"))
+
+		_, _, err := svc.getOrCreateUser(ctx, pid)
+		assert.ErrorContains(t, err, "error linking the identity to the user")
+	})
+}
+
+func TestGetOrCreateUserVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	external := providerIdentity{
+		User:     sdk.User{Email: "new@example.com", ProjectId: "project-123"},
+		Provider: sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123"},
+		Subject:  "subject-1",
+		External: true,
+	}
+
+	tests := []struct {
+		name          string
+		pid           func() providerIdentity
+		userVerified  bool
+		expectedError error
+	}{
+		{
+			name:          "unverified email of the identity is not linked to the existing user",
+			pid:           func() providerIdentity { return external },
+			userVerified:  true,
+			expectedError: sdk.ErrUnverifiedEmailLink,
+		},
+		{
+			name: "unverified email of the existing user is not linked to",
+			pid: func() providerIdentity {
+				pid := external
+				pid.User.EmailVerified = true
+				return pid
+			},
+			expectedError: sdk.ErrUnverifiedEmailLink,
+		},
+		{
+			name: "internal providers log in to the user of their address",
+			pid: func() providerIdentity {
+				pid := external
+				pid.External = false
+				pid.Subject = "new@example.com"
+				return pid
+			},
+		},
+		{
+			name: "unverified email refused when the provider requires verified ones",
+			pid: func() providerIdentity {
+				pid := external
+				pid.Provider.RequireVerifiedEmail = true
+				return pid
+			},
+			userVerified:  true,
+			expectedError: sdk.ErrEmailNotVerified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _, _, _, _, mockUser := setupFullTestService()
+			mockUser.On("GetByEmail", ctx, "new@example.com", "project-123").Return(&sdk.User{Id: "user-2", ProjectId: "project-123", EmailVerified: tt.userVerified, Enabled: true}, nil)
+
+			usr, _, err := svc.getOrCreateUser(ctx, tt.pid())
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, usr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "user-2", usr.Id)
+		})
+	}
+
+	t.Run("internal providers verify the email of the user", func(t *testing.T) {
+		svc, _, _, _, _, _, mockUser := setupFullTestService()
+		pid := external
+		pid.External = false
+		pid.User.EmailVerified = true
+		mockUser.On("GetByEmail", ctx, "new@example.com", "project-123").Return(&sdk.User{Id: "user-2", ProjectId: "project-123", Enabled: true}, nil)
+		mockUser.On("Update", ctx, mock.MatchedBy(func(u *sdk.User) bool { return u.Id == "user-2" && u.EmailVerified })).Return(nil)
+
+		usr, _, err := svc.getOrCreateUser(ctx, pid)
+		require.NoError(t, err)
+		assert.True(t, usr.EmailVerified)
+		mockUser.AssertExpectations(t)
+	})
+}
+
+func TestIdentityLink(t *testing.T) {
+	ctx := context.Background()
+	usr := sdk.User{Id: "user-1", ProjectId: "project-123", Enabled: true}
+	authProvider := &sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123"}
+	client := &sdk.Client{Id: "client-id", ProjectId: "project-123", RedirectURLs: []string{"http://callback.com"}}
+	req := sdk.UserIdentityLinkRequest{AuthProviderId: "provider-id", ClientId: "client-id", RedirectUrl: "http://callback.com", State: "client-state"}
+
+	t.Run("login url remembers the user to link the identity to", func(t *testing.T) {
+		svc, mockAuthProvider, mockClient, mockCache, _, mockEncrypt, _ := setupFullTestService()
+		mockServiceProvider := &MockServiceProvider{}
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
+		mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
+		mockClient.On("Get", ctx, "client-id", true).Return(client, nil)
+		mockEncrypt.On("Encrypt", mock.MatchedBy(func(raw string) bool {
+			params := sdk.AuthLoginParams{}
+			err := json.Unmarshal([]byte(raw), &params)
+			return err == nil && params.LinkUserId == "user-1" && params.State == "client-state" && params.AuthProviderId == "provider-id"
+		})).Return("encrypted-state", nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("string"), "encrypted-state", mock.Anything).Return(nil)
+		mockServiceProvider.On("GetAuthCodeUrl", mock.AnythingOfType("string")).Return("https://idp.example.com/authorize")
+
+		url, err := svc.StartIdentityLink(ctx, usr, req)
+		require.NoError(t, err)
+		assert.Equal(t, "https://idp.example.com/authorize", url)
+	})
+
+	t.Run("error - auth provider of another project", func(t *testing.T) {
+		svc, mockAuthProvider, _, _, _, _, _ := setupFullTestService()
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(&sdk.AuthProvider{Id: "provider-id", ProjectId: "project-456"}, nil)
+
+		_, err := svc.StartIdentityLink(ctx, usr, req)
+		assert.ErrorIs(t, err, sdk.ErrAuthProviderNotFound)
+	})
+
+	t.Run("error - redirect url not registered", func(t *testing.T) {
+		svc, mockAuthProvider, mockClient, _, _, _, _ := setupFullTestService()
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
+		mockClient.On("Get", ctx, "client-id", true).Return(client, nil)
+
+		_, err := svc.StartIdentityLink(ctx, usr, sdk.UserIdentityLinkRequest{AuthProviderId: "provider-id", ClientId: "client-id", RedirectUrl: "http://evil.com"})
+		assert.ErrorIs(t, err, sdk.ErrRedirectUrlNotFound)
+	})
+
+	t.Run("login links the identity instead of logging in", func(t *testing.T) {
+		svc, mockAuthProvider, _, mockCache, _, mockEncrypt, mockUser := setupFullTestService()
+		mockIdentity := &services.MockIdentityService{}
+		svc.identitySvc = mockIdentity
+		mockServiceProvider := &MockServiceProvider{}
+		mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
+		mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"client-state","redirect_url":"http://callback.com","link_user_id":"user-1"}`, nil)
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
+		mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
+		mockServiceProvider.On("VerifyCode", ctx, "valid-code").Return(&sdk.AuthToken{AccessToken: "access-token", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+		mockServiceProvider.On("GetIdentity", "access-token").Return([]sdk.AuthIdentity{
+			{Type: sdk.AuthIdentityTypeSubject, Metadata: sdk.AuthIdentitySubject{Subject: "subject-1"}},
+			{Type: sdk.AuthIdentityTypeEmail, Metadata: MockEmailMetadata{Email: "other@example.com"}},
+		}, nil)
+		mockUser.On("GetById", ctx, "user-1").Return(&usr, nil)
+		mockIdentity.On("Link", ctx, mock.MatchedBy(func(identity *sdk.UserIdentity) bool {
+			return identity.UserId == "user-1" && identity.Subject == "subject-1" && identity.Email == "other@example.com"
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*sdk.UserIdentity).Id = "identity-1"
+		}).Return(nil)
+		mockCache.On("Delete", ctx, "state-valid-state").Return(nil)
+
+		result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.NoError(t, err)
+		assert.Equal(t, "http://callback.com?linked_identity=identity-1&state=client-state", result.RedirectUrl)
+		mockUser.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - identity linked to another user", func(t *testing.T) {
+		svc, mockAuthProvider, _, mockCache, _, mockEncrypt, mockUser := setupFullTestService()
+		mockIdentity := &services.MockIdentityService{}
+		svc.identitySvc = mockIdentity
+		mockServiceProvider := &MockServiceProvider{}
+		mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
+		mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","redirect_url":"http://callback.com","link_user_id":"user-1"}`, nil)
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
+		mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
+		mockServiceProvider.On("VerifyCode", ctx, "valid-code").Return(&sdk.AuthToken{AccessToken: "access-token", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+		mockServiceProvider.On("GetIdentity", "access-token").Return([]sdk.AuthIdentity{
+			{Type: sdk.AuthIdentityTypeSubject, Metadata: sdk.AuthIdentitySubject{Subject: "subject-1"}},
+		}, nil)
+		mockUser.On("GetById", ctx, "user-1").Return(&usr, nil)
+		mockIdentity.On("Link", ctx, mock.Anything).Return(sdk.ErrUserIdentityLinkedToAnotherUser)
+
+		_, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		assert.ErrorIs(t, err, sdk.ErrUserIdentityLinkedToAnotherUser)
+	})
+}
+
+func TestProvisionUser(t *testing.T) {
+	ctx := context.Background()
+	newUser := sdk.User{Email: "jane@Example.com", ProjectId: "project-123"}
+
+	tests := []struct {
+		name          string
+		rules         sdk.AuthProviderProvisioning
+		user          sdk.User
+		setupMocks    func(mockUser *services.MockUserService)
+		expectedError string
+		checkUser     func(t *testing.T, usr *sdk.User)
+	}{
+		{
+			name: "creates the user without rules",
+			user: newUser,
+			setupMocks: func(mockUser *services.MockUserService) {
+				mockUser.On("Create", ctx, mock.MatchedBy(func(u *sdk.User) bool { return !u.PendingApproval && u.Policies == nil })).Return(nil)
+			},
+			checkUser: func(t *testing.T, usr *sdk.User) {
+				assert.Equal(t, "jane@Example.com", usr.Email)
+			},
+		},
+		{
+			name:          "invite only",
+			rules:         sdk.AuthProviderProvisioning{Disabled: true},
+			user:          newUser,
+			expectedError: "the auth provider only lets in existing users",
+		},
+		{
+			name:  "allowed domain",
+			rules: sdk.AuthProviderProvisioning{AllowedDomains: []string{"@example.com"}},
+			user:  newUser,
+			setupMocks: func(mockUser *services.MockUserService) {
+				mockUser.On("Create", ctx, mock.Anything).Return(nil)
+			},
+		},
+		{
+			name:          "domain not allowed",
+			rules:         sdk.AuthProviderProvisioning{AllowedDomains: []string{"corp.example.com"}},
+			user:          newUser,
+			expectedError: `users of the email domain "example.com" are not allowed`,
+		},
+		{
+			name:          "blocked domain",
+			rules:         sdk.AuthProviderProvisioning{BlockedDomains: []string{"EXAMPLE.com"}},
+			user:          newUser,
+			expectedError: `users of the email domain "example.com" are not allowed`,
+		},
+		{
+			name:          "domain rules need an email address",
+			rules:         sdk.AuthProviderProvisioning{BlockedDomains: []string{"example.com"}},
+			user:          sdk.User{Phone: "+15550100", ProjectId: "project-123"},
+			expectedError: "users without an email address are not allowed",
+		},
+		{
+			name: "default roles and policies, pending approval",
+			rules: sdk.AuthProviderProvisioning{
+				DefaultRoles:    []string{"role-1", "role-2"},
+				DefaultPolicies: map[string]sdk.UserPolicy{"policy-1": {Name: "Policy 1"}},
+				RequireApproval: true,
+			},
+			user: newUser,
+			setupMocks: func(mockUser *services.MockUserService) {
+				mockUser.On("Create", ctx, mock.MatchedBy(func(u *sdk.User) bool {
+					_, ok := u.Policies["policy-1"]
+					return u.PendingApproval && ok
+				})).Run(func(args mock.Arguments) {
+					args.Get(1).(*sdk.User).Id = "user-1"
+				}).Return(nil)
+				mockUser.On("AddRoleToUser", ctx, "user-1", "role-1").Return(nil)
+				mockUser.On("AddRoleToUser", ctx, "user-1", "role-2").Return(nil)
+				mockUser.On("GetById", ctx, "user-1").Return(&sdk.User{Id: "user-1", PendingApproval: true, Roles: map[string]sdk.UserRole{"role-1": {Id: "role-1"}, "role-2": {Id: "role-2"}}}, nil)
+			},
+			checkUser: func(t *testing.T, usr *sdk.User) {
+				assert.True(t, usr.PendingApproval)
+				assert.False(t, usr.Enabled)
+				assert.Contains(t, usr.Roles, "role-1")
+				assert.Contains(t, usr.Roles, "role-2")
+			},
+		},
+		{
+			name:  "default roles, enabled once assigned",
+			rules: sdk.AuthProviderProvisioning{DefaultRoles: []string{"role-1"}},
+			user:  newUser,
+			setupMocks: func(mockUser *services.MockUserService) {
+				mockUser.On("Create", ctx, mock.MatchedBy(func(u *sdk.User) bool {
+					return u.PendingApproval
+				})).Run(func(args mock.Arguments) {
+					args.Get(1).(*sdk.User).Id = "user-1"
+				}).Return(nil)
+				mockUser.On("AddRoleToUser", ctx, "user-1", "role-1").Return(nil)
+				mockUser.On("GetById", ctx, "user-1").Return(&sdk.User{Id: "user-1", PendingApproval: true, Roles: map[string]sdk.UserRole{"role-1": {Id: "role-1"}}}, nil)
+				mockUser.On("Update", ctx, mock.MatchedBy(func(u *sdk.User) bool {
+					return u.Id == "user-1" && u.Enabled && !u.PendingApproval
+				})).Return(nil)
+			},
+			checkUser: func(t *testing.T, usr *sdk.User) {
+				assert.True(t, usr.Enabled)
+				assert.False(t, usr.PendingApproval)
+				assert.Contains(t, usr.Roles, "role-1")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _, _, _, _, mockUser := setupFullTestService()
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockUser)
+			}
+
+			usr, err := svc.provisionUser(ctx, sdk.AuthProvider{Id: "provider-id", Provisioning: tt.rules}, tt.user)
+			if len(tt.expectedError) > 0 {
+				assert.ErrorIs(t, err, sdk.ErrLoginDenied)
+				assert.ErrorContains(t, err, tt.expectedError)
+				mockUser.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			if tt.checkUser != nil {
+				tt.checkUser(t, usr)
+			}
+			mockUser.AssertExpectations(t)
+		})
+	}
+}
+
+// TestProvisionUserDefaultRoleFailure tests that a user whose default roles could not be assigned is left waiting for approval
+func TestProvisionUserDefaultRoleFailure(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, _, _, _, mockUser := setupFullTestService()
+	mockUser.On("Create", ctx, mock.MatchedBy(func(u *sdk.User) bool {
+		return u.PendingApproval
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*sdk.User).Id = "user-1"
+	}).Return(nil)
+	mockUser.On("AddRoleToUser", ctx, "user-1", "role-1").Return(nil)
+	mockUser.On("AddRoleToUser", ctx, "user-1", "role-2").Return(errors.New("role not found"))
+
+	rules := sdk.AuthProviderProvisioning{DefaultRoles: []string{"role-1", "role-2", "role-3"}}
+	usr, err := svc.provisionUser(ctx, sdk.AuthProvider{Id: "provider-id", Provisioning: rules}, sdk.User{Email: "new@example.com", ProjectId: "project-123"})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "role not found")
+	assert.Nil(t, usr)
+	mockUser.AssertNotCalled(t, "AddRoleToUser", ctx, "user-1", "role-3")
+	mockUser.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// TestRedirectDenied tests that the logins the user is not let in with send them back to the client
+func TestRedirectDenied(t *testing.T) {
+	ctx := context.Background()
+	authProvider := &sdk.AuthProvider{Id: "provider-id", ProjectId: "project-123"}
+	client := &sdk.Client{Id: "client-id", ProjectId: "project-123", RedirectURLs: []string{"http://callback.com"}}
+
+	setupLogin := func(usr *sdk.User) (*service, *MockCacheService) {
+		svc, mockAuthProvider, mockClient, mockCache, _, mockEncrypt, mockUser := setupFullTestService()
+		mockCache.On("Get", ctx, "state-valid-state").Return("encrypted-state", nil)
+		mockEncrypt.On("Decrypt", "encrypted-state").Return(`{"client_id":"client-id","auth_provider_id":"provider-id","state":"original-state","redirect_url":"http://callback.com"}`, nil)
+		mockServiceProvider := &MockServiceProvider{}
+		mockAuthProvider.On("Get", ctx, "provider-id", true).Return(authProvider, nil)
+		mockAuthProvider.On("GetProvider", ctx, *authProvider).Return(mockServiceProvider, nil)
+		mockServiceProvider.On("VerifyCode", ctx, "valid-code").Return(&sdk.AuthToken{AccessToken: "access-token", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+		mockServiceProvider.On("GetIdentity", "access-token").Return([]sdk.AuthIdentity{
+			{Type: sdk.AuthIdentityTypeEmail, Metadata: MockEmailMetadata{Email: "user@example.com"}},
+		}, nil)
+		mockUser.On("GetByEmail", ctx, "user@example.com", "project-123").Return(usr, nil)
+		mockClient.On("Get", ctx, "client-id", true).Return(client, nil)
+		mockCache.On("Delete", ctx, "state-valid-state").Return(nil)
+		return svc, mockCache
+	}
+
+	t.Run("pending approval", func(t *testing.T) {
+		svc, mockCache := setupLogin(&sdk.User{Id: "user-1", Email: "user@example.com", PendingApproval: true})
+
+		result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.NoError(t, err)
+		assert.Equal(t, "http://callback.com?error=access_denied&state=original-state&error_description=the+user+is+not+allowed+to+log+in", result.RedirectUrl)
+		mockCache.AssertCalled(t, "Delete", ctx, "state-valid-state")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		svc, _ := setupLogin(&sdk.User{Id: "user-1", Email: "user@example.com"})
+
+		result, err := svc.Redirect(ctx, "valid-code", "valid-state")
+		require.NoError(t, err)
+		assert.Contains(t, result.RedirectUrl, "error_description=the+user+is+not+allowed+to+log+in")
+		assert.NotContains(t, result.RedirectUrl, "disabled")
+	})
+}
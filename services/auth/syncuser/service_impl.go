@@ -27,6 +27,14 @@ func (s *service) HandleEvent(event utils.Event[sdk.User]) {
 		if user.Id == "" {
 			return
 		}
+		if !user.Enabled {
+			// a disabled user should not be able to use the tokens issued earlier
+			err := s.authSvc.RevokeUserTokens(event.Context(), user.Id)
+			if err != nil {
+				log.Errorw("failed to revoke the tokens of the disabled user", "error", err, "userId", user.Id)
+			}
+			return
+		}
 		err := s.authSvc.SynchronizeIdentity(event.Context(), user.Id)
 		if err != nil {
 			log.Errorw("failed to synchronize identity", "error", err, "userId", user.Id)
@@ -0,0 +1,16 @@
+package authprovider
+
+// the built in types of auth providers register themselves with the registry
+import (
+	_ "github.com/melvinodsa/go-iam/services/authprovider/github"
+	_ "github.com/melvinodsa/go-iam/services/authprovider/google"
+	_ "github.com/melvinodsa/go-iam/services/authprovider/ldap"
+	_ "github.com/melvinodsa/go-iam/services/authprovider/microsoft"
+	_ "github.com/melvinodsa/go-iam/services/authprovider/oauth2"
+	_ "github.com/melvinodsa/go-iam/services/authprovider/oidc"
+	_ "github.com/melvinodsa/go-iam/services/authprovider/passkey"
+	_ "github.com/melvinodsa/go-iam/services/authprovider/password"
+	_ "github.com/melvinodsa/go-iam/services/authprovider/passwordless"
+	_ "github.com/melvinodsa/go-iam/services/authprovider/saml"
+	_ "github.com/melvinodsa/go-iam/services/authprovider/sms"
+)
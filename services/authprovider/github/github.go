@@ -6,21 +6,26 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/melvinodsa/go-iam/sdk"
 	"golang.org/x/oauth2"
 )
 
+// apiUrl is the base url of the github api
+var apiUrl = "https://api.github.com"
+
 type authProvider struct {
 	cnf oauth2.Config
 }
 
 func NewAuthProvider(p sdk.AuthProvider) sdk.ServiceProvider {
 	oauthConfig := oauth2.Config{
-		ClientID:     p.GetParam("@GITHUB/CLIENT_ID"),
-		ClientSecret: p.GetParam("@GITHUB/CLIENT_SECRET"),
-		RedirectURL:  p.GetParam("@GITHUB/REDIRECT_URL"),
+		ClientID:     p.GetParam(paramClientId),
+		ClientSecret: p.GetParam(paramClientSecret),
+		RedirectURL:  p.GetParam(paramRedirectUrl),
 		Scopes:       []string{"user:email", "read:user"},
 		Endpoint: oauth2.Endpoint{
 			AuthURL:  "https://github.com/login/oauth/authorize",
@@ -62,11 +67,13 @@ func (g authProvider) RefreshToken(refreshToken string) (*sdk.AuthToken, error)
 }
 
 type GitHubIdentityEmail struct {
-	Email string `json:"email"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
 func (g GitHubIdentityEmail) UpdateUserDetails(user *sdk.User) {
 	user.Email = g.Email
+	user.EmailVerified = g.EmailVerified
 }
 
 type GitHubIdentityName struct {
@@ -98,7 +105,7 @@ func (g GitHubIdentityUsername) UpdateUserDetails(user *sdk.User) {
 
 func (g authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
 	// Get user info from GitHub API
-	req, err := http.NewRequest("GET", "https://api.github.com/user", nil)
+	req, err := http.NewRequest("GET", apiUrl+"/user", nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request. %w", err)
 	}
@@ -120,13 +127,12 @@ func (g authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
 		return nil, fmt.Errorf("error reading the response. %w", err)
 	}
 
-	fmt.Println(string(respBytes))
-
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("API error: %s", string(respBytes))
 	}
 
 	var userInfo struct {
+		Id        int64  `json:"id"`
 		Email     string `json:"email"`
 		Name      string `json:"name"`
 		AvatarURL string `json:"avatar_url"`
@@ -137,30 +143,58 @@ func (g authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
 		return nil, fmt.Errorf("error unmarshalling the response. %s - %w", string(respBytes), err)
 	}
 
-	fmt.Println("GitHub User Info:", userInfo)
-
 	identities := []sdk.AuthIdentity{
+		{Type: sdk.AuthIdentityTypeSubject, Metadata: sdk.AuthIdentitySubject{Subject: strconv.FormatInt(userInfo.Id, 10)}},
 		{Type: sdk.AuthIdentityTypeEmail, Metadata: GitHubIdentityEmail{Email: userInfo.Email}},
 		{Type: sdk.AuthIdentityTypeEmail, Metadata: GitHubIdentityName{Name: userInfo.Name}},
 		{Type: sdk.AuthIdentityTypeEmail, Metadata: GitHubIdentityProfilePic{ProfilePic: userInfo.AvatarURL}},
 		{Type: sdk.AuthIdentityTypeEmail, Metadata: GitHubIdentityUsername{Username: userInfo.Login}},
 	}
 
-	// GitHub API might not return email in the user endpoint if it's private
-	// In that case, we need to fetch emails separately
-	if userInfo.Email == "" {
-		emailIdentities, err := g.getGitHubEmails(token)
-		if err == nil && len(emailIdentities) > 0 {
-			// Replace the first identity with the primary email
-			identities[0] = emailIdentities[0]
-		}
+	// GitHub API might not return email in the user endpoint if it's private, and it never says
+	// whether the email is verified. The emails of the user tell both, when they cannot be fetched
+	// the email of the profile is kept as unverified
+	emails, err := g.getGitHubEmails(token)
+	if err != nil {
+		log.Errorf("error fetching the emails of the github user: %v", err)
+	} else if email, ok := pickEmail(emails, userInfo.Email); ok {
+		identities[1] = sdk.AuthIdentity{Type: sdk.AuthIdentityTypeEmail, Metadata: GitHubIdentityEmail{Email: email.Email, EmailVerified: email.Verified}}
 	}
 
 	return identities, nil
 }
 
-func (g authProvider) getGitHubEmails(token string) ([]sdk.AuthIdentity, error) {
-	req, err := http.NewRequest("GET", "https://api.github.com/user/emails", nil)
+// gitHubEmail is an email address of the user listed by github
+type gitHubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// pickEmail returns the entry of the profile email when the profile has one, else the primary
+// email of the user or their first one when none is primary
+func pickEmail(emails []gitHubEmail, profileEmail string) (gitHubEmail, bool) {
+	if len(profileEmail) > 0 {
+		for _, email := range emails {
+			if strings.EqualFold(email.Email, profileEmail) {
+				return email, true
+			}
+		}
+		return gitHubEmail{}, false
+	}
+	for _, email := range emails {
+		if email.Primary {
+			return email, true
+		}
+	}
+	if len(emails) > 0 {
+		return emails[0], true
+	}
+	return gitHubEmail{}, false
+}
+
+func (g authProvider) getGitHubEmails(token string) ([]gitHubEmail, error) {
+	req, err := http.NewRequest("GET", apiUrl+"/user/emails", nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating emails request. %w", err)
 	}
@@ -186,30 +220,10 @@ func (g authProvider) getGitHubEmails(token string) ([]sdk.AuthIdentity, error)
 		return nil, fmt.Errorf("error reading emails response. %w", err)
 	}
 
-	var emails []struct {
-		Email   string `json:"email"`
-		Primary bool   `json:"primary"`
-	}
+	var emails []gitHubEmail
 	err = json.Unmarshal(respBytes, &emails)
 	if err != nil {
 		return nil, fmt.Errorf("error unmarshalling emails response. %w", err)
 	}
-
-	// Find primary email
-	for _, email := range emails {
-		if email.Primary {
-			return []sdk.AuthIdentity{
-				{Type: sdk.AuthIdentityTypeEmail, Metadata: GitHubIdentityEmail{Email: email.Email}},
-			}, nil
-		}
-	}
-
-	// If no primary email found, return the first one
-	if len(emails) > 0 {
-		return []sdk.AuthIdentity{
-			{Type: sdk.AuthIdentityTypeEmail, Metadata: GitHubIdentityEmail{Email: emails[0].Email}},
-		}, nil
-	}
-
-	return nil, fmt.Errorf("no emails found")
+	return emails, nil
 }
@@ -789,3 +789,44 @@ func TestAuthProvider_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestGetIdentity_VerifiedEmail(t *testing.T) {
+	emails := `[{"email": "old@example.com", "primary": false, "verified": false}, {"email": "jane@example.com", "primary": true, "verified": true}]`
+	newServer := func(profile string, emailsStatus int) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/user":
+				_, _ = w.Write([]byte(profile))
+			case "/user/emails":
+				w.WriteHeader(emailsStatus)
+				_, _ = w.Write([]byte(emails))
+			}
+		}))
+		t.Cleanup(srv.Close)
+		prev := apiUrl
+		apiUrl = srv.URL
+		t.Cleanup(func() { apiUrl = prev })
+	}
+	emailOf := func(t *testing.T) GitHubIdentityEmail {
+		identities, err := NewAuthProvider(createMockGitHubProvider()).GetIdentity("token")
+		require.NoError(t, err)
+		email, ok := identities[1].Metadata.(GitHubIdentityEmail)
+		require.True(t, ok)
+		return email
+	}
+
+	t.Run("private email uses the primary one", func(t *testing.T) {
+		newServer(`{"id": 1, "login": "jane"}`, http.StatusOK)
+		assert.Equal(t, GitHubIdentityEmail{Email: "jane@example.com", EmailVerified: true}, emailOf(t))
+	})
+
+	t.Run("public email takes the verified flag of its entry", func(t *testing.T) {
+		newServer(`{"id": 1, "login": "jane", "email": "Old@example.com"}`, http.StatusOK)
+		assert.Equal(t, GitHubIdentityEmail{Email: "old@example.com", EmailVerified: false}, emailOf(t))
+	})
+
+	t.Run("emails not fetched keep the profile email unverified", func(t *testing.T) {
+		newServer(`{"id": 1, "login": "jane", "email": "jane@example.com"}`, http.StatusForbidden)
+		assert.Equal(t, GitHubIdentityEmail{Email: "jane@example.com"}, emailOf(t))
+	})
+}
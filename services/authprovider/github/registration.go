@@ -0,0 +1,33 @@
+package github
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/authprovider/registry"
+)
+
+// Params of the GitHub auth provider
+const (
+	paramClientId     = "@GITHUB/CLIENT_ID"
+	paramClientSecret = "@GITHUB/CLIENT_SECRET"
+	paramRedirectUrl  = "@GITHUB/REDIRECT_URL"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		AuthProviderTypeInfo: sdk.AuthProviderTypeInfo{
+			Type: sdk.AuthProviderTypeGitHub,
+			Name: "GitHub",
+			Icon: "github",
+			Params: []sdk.AuthProviderParamSpec{
+				{Key: paramClientId, Label: "Client ID", Description: "Client id of the GitHub OAuth app", Required: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: paramClientSecret, Label: "Client secret", Description: "Client secret of the GitHub OAuth app", Required: true, IsSecret: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: paramRedirectUrl, Label: "Redirect URL", Description: "Authorization callback URL of the OAuth app, the callback of go-iam", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+			},
+		},
+		New: func(_ context.Context, p sdk.AuthProvider, _ registry.Dependencies) (sdk.ServiceProvider, error) {
+			return NewAuthProvider(p), nil
+		},
+	})
+}
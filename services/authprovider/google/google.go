@@ -23,9 +23,9 @@ type authProvider struct {
 
 func NewAuthProvider(p sdk.AuthProvider) sdk.ServiceProvider {
 	oauthConfig := oauth2.Config{
-		ClientID:     p.GetParam("@GOOGLE/CLIENT_ID"),
-		ClientSecret: p.GetParam("@GOOGLE/CLIENT_SECRET"), // Set this in your environment
-		RedirectURL:  p.GetParam("@GOOGLE/REDIRECT_URL"),
+		ClientID:     p.GetParam(paramClientId),
+		ClientSecret: p.GetParam(paramClientSecret), // Set this in your environment
+		RedirectURL:  p.GetParam(paramRedirectUrl),
 		Scopes:       []string{gauth.UserinfoEmailScope, gauth.UserinfoProfileScope},
 		Endpoint:     google.Endpoint,
 	}
@@ -103,11 +103,13 @@ func (g authProvider) RefreshToken(refreshToken string) (*sdk.AuthToken, error)
 }
 
 type GoogleIdentityEmail struct {
-	Email string `json:"email"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
 func (g GoogleIdentityEmail) UpdateUserDetails(user *sdk.User) {
 	user.Email = g.Email
+	user.EmailVerified = g.EmailVerified
 }
 
 type GoogleIdentityName struct {
@@ -148,7 +150,8 @@ func (g authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
 		return nil, fmt.Errorf("error unmarshalling the response. %s - %w", string(respBytes), err)
 	}
 	return []sdk.AuthIdentity{
-		{Type: sdk.AuthIdentityTypeEmail, Metadata: GoogleIdentityEmail{Email: tokenResponse.Email}},
+		{Type: sdk.AuthIdentityTypeSubject, Metadata: sdk.AuthIdentitySubject{Subject: tokenResponse.Id}},
+		{Type: sdk.AuthIdentityTypeEmail, Metadata: GoogleIdentityEmail{Email: tokenResponse.Email, EmailVerified: tokenResponse.VerifiedEmail != nil && *tokenResponse.VerifiedEmail}},
 		{Type: sdk.AuthIdentityTypeEmail, Metadata: GoogleIdentityName{FirstName: tokenResponse.GivenName}},
 		{Type: sdk.AuthIdentityTypeEmail, Metadata: GoogleIdentityProfilePic{ProfilePic: tokenResponse.Picture}},
 	}, nil
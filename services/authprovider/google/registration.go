@@ -0,0 +1,33 @@
+package google
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/authprovider/registry"
+)
+
+// Params of the Google auth provider
+const (
+	paramClientId     = "@GOOGLE/CLIENT_ID"
+	paramClientSecret = "@GOOGLE/CLIENT_SECRET"
+	paramRedirectUrl  = "@GOOGLE/REDIRECT_URL"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		AuthProviderTypeInfo: sdk.AuthProviderTypeInfo{
+			Type: sdk.AuthProviderTypeGoogle,
+			Name: "Google",
+			Icon: "google",
+			Params: []sdk.AuthProviderParamSpec{
+				{Key: paramClientId, Label: "Client ID", Description: "Client id of the Google Cloud console credentials", Required: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: paramClientSecret, Label: "Client secret", Description: "Client secret of the Google Cloud console credentials", Required: true, IsSecret: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: paramRedirectUrl, Label: "Redirect URL", Description: "Authorized redirect URI of the OAuth client, the callback of go-iam", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+			},
+		},
+		New: func(_ context.Context, p sdk.AuthProvider, _ registry.Dependencies) (sdk.ServiceProvider, error) {
+			return NewAuthProvider(p), nil
+		},
+	})
+}
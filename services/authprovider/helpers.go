@@ -7,17 +7,20 @@ import (
 
 func fromModelToSdk(provider *models.AuthProvider) *sdk.AuthProvider {
 	return &sdk.AuthProvider{
-		Id:        provider.Id,
-		Name:      provider.Name,
-		Icon:      provider.Icon,
-		Provider:  sdk.AuthProviderType(provider.Provider),
-		Params:    fromProviderParamsModelToSdk(provider.Params),
-		ProjectId: provider.ProjectId,
-		Enabled:   provider.Enabled,
-		CreatedAt: provider.CreatedAt,
-		UpdatedAt: provider.UpdatedAt,
-		CreatedBy: provider.CreatedBy,
-		UpdatedBy: provider.UpdatedBy,
+		Id:                   provider.Id,
+		Name:                 provider.Name,
+		Icon:                 provider.Icon,
+		Provider:             sdk.AuthProviderType(provider.Provider),
+		Params:               fromProviderParamsModelToSdk(provider.Params),
+		ProjectId:            provider.ProjectId,
+		Enabled:              provider.Enabled,
+		RequireVerifiedEmail: provider.RequireVerifiedEmail,
+		Provisioning:         fromProvisioningModelToSdk(provider.Provisioning),
+		GroupRoles:           fromGroupRolesModelToSdk(provider.GroupRoles),
+		CreatedAt:            provider.CreatedAt,
+		UpdatedAt:            provider.UpdatedAt,
+		CreatedBy:            provider.CreatedBy,
+		UpdatedBy:            provider.UpdatedBy,
 	}
 }
 
@@ -44,17 +47,20 @@ func fromProviderParamsModelToSdk(params []models.AuthProviderParam) []sdk.AuthP
 
 func fromSdkToModel(provider sdk.AuthProvider) models.AuthProvider {
 	return models.AuthProvider{
-		Id:        provider.Id,
-		Name:      provider.Name,
-		Icon:      provider.Icon,
-		Provider:  models.AuthProviderType(provider.Provider),
-		Params:    fromProviderParamsSdkToModel(provider.Params),
-		ProjectId: provider.ProjectId,
-		Enabled:   provider.Enabled,
-		CreatedAt: provider.CreatedAt,
-		UpdatedAt: provider.UpdatedAt,
-		CreatedBy: provider.CreatedBy,
-		UpdatedBy: provider.UpdatedBy,
+		Id:                   provider.Id,
+		Name:                 provider.Name,
+		Icon:                 provider.Icon,
+		Provider:             models.AuthProviderType(provider.Provider),
+		Params:               fromProviderParamsSdkToModel(provider.Params),
+		ProjectId:            provider.ProjectId,
+		Enabled:              provider.Enabled,
+		RequireVerifiedEmail: provider.RequireVerifiedEmail,
+		Provisioning:         fromProvisioningSdkToModel(provider.Provisioning),
+		GroupRoles:           fromGroupRolesSdkToModel(provider.GroupRoles),
+		CreatedAt:            provider.CreatedAt,
+		UpdatedAt:            provider.UpdatedAt,
+		CreatedBy:            provider.CreatedBy,
+		UpdatedBy:            provider.UpdatedBy,
 	}
 }
 
@@ -70,3 +76,63 @@ func fromProviderParamsSdkToModel(params []sdk.AuthProviderParam) []models.AuthP
 	}
 	return res
 }
+
+func fromProvisioningModelToSdk(p models.AuthProviderProvisioning) sdk.AuthProviderProvisioning {
+	var policies map[string]sdk.UserPolicy
+	if len(p.DefaultPolicies) > 0 {
+		policies = map[string]sdk.UserPolicy{}
+	}
+	for id, policy := range p.DefaultPolicies {
+		args := map[string]sdk.UserPolicyMappingValue{}
+		for k, v := range policy.Mapping.Arguments {
+			args[k] = sdk.UserPolicyMappingValue{Static: v.Static}
+		}
+		policies[id] = sdk.UserPolicy{Name: policy.Name, Mapping: sdk.UserPolicyMapping{Arguments: args}}
+	}
+	return sdk.AuthProviderProvisioning{
+		Disabled:        p.Disabled,
+		AllowedDomains:  p.AllowedDomains,
+		BlockedDomains:  p.BlockedDomains,
+		DefaultRoles:    p.DefaultRoles,
+		DefaultPolicies: policies,
+		RequireApproval: p.RequireApproval,
+	}
+}
+
+func fromProvisioningSdkToModel(p sdk.AuthProviderProvisioning) models.AuthProviderProvisioning {
+	var policies map[string]models.UserPolicy
+	if len(p.DefaultPolicies) > 0 {
+		policies = map[string]models.UserPolicy{}
+	}
+	for id, policy := range p.DefaultPolicies {
+		args := map[string]models.UserPolicyMappingValue{}
+		for k, v := range policy.Mapping.Arguments {
+			args[k] = models.UserPolicyMappingValue{Static: v.Static}
+		}
+		policies[id] = models.UserPolicy{Name: policy.Name, Mapping: models.UserPolicyMapping{Arguments: args}}
+	}
+	return models.AuthProviderProvisioning{
+		Disabled:        p.Disabled,
+		AllowedDomains:  p.AllowedDomains,
+		BlockedDomains:  p.BlockedDomains,
+		DefaultRoles:    p.DefaultRoles,
+		DefaultPolicies: policies,
+		RequireApproval: p.RequireApproval,
+	}
+}
+
+func fromGroupRolesModelToSdk(mapping []models.AuthProviderGroupRole) []sdk.AuthProviderGroupRole {
+	var res []sdk.AuthProviderGroupRole
+	for _, m := range mapping {
+		res = append(res, sdk.AuthProviderGroupRole{Group: m.Group, RoleId: m.RoleId})
+	}
+	return res
+}
+
+func fromGroupRolesSdkToModel(mapping []sdk.AuthProviderGroupRole) []models.AuthProviderGroupRole {
+	var res []models.AuthProviderGroupRole
+	for _, m := range mapping {
+		res = append(res, models.AuthProviderGroupRole{Group: m.Group, RoleId: m.RoleId})
+	}
+	return res
+}
@@ -267,3 +267,30 @@ func TestFromProviderParamsSdkToModel(t *testing.T) {
 		})
 	}
 }
+
+func TestProvisioningRoundTrip(t *testing.T) {
+	provisioning := sdk.AuthProviderProvisioning{
+		Disabled:       true,
+		AllowedDomains: []string{"example.com"},
+		BlockedDomains: []string{"spam.example.com"},
+		DefaultRoles:   []string{"role-1"},
+		DefaultPolicies: map[string]sdk.UserPolicy{
+			"policy-1": {Name: "Policy 1", Mapping: sdk.UserPolicyMapping{Arguments: map[string]sdk.UserPolicyMappingValue{"@userId": {Static: "user-1"}}}},
+		},
+		RequireApproval: true,
+	}
+
+	model := fromSdkToModel(sdk.AuthProvider{Id: "ap1", Provisioning: provisioning})
+	result := fromModelToSdk(&model)
+
+	assert.Equal(t, provisioning, result.Provisioning)
+	assert.Nil(t, fromModelToSdk(&models.AuthProvider{}).Provisioning.DefaultPolicies)
+}
+
+func TestGroupRolesRoundTrip(t *testing.T) {
+	groupRoles := []sdk.AuthProviderGroupRole{{Group: "cn=admins,dc=example,dc=com", RoleId: "role-1"}}
+
+	model := fromSdkToModel(sdk.AuthProvider{Id: "ap1", GroupRoles: groupRoles})
+	assert.Equal(t, groupRoles, fromModelToSdk(&model).GroupRoles)
+	assert.Nil(t, fromModelToSdk(&models.AuthProvider{}).GroupRoles)
+}
@@ -0,0 +1,150 @@
+package ldap
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// CodeService is the part of the ldap service the provider needs to resolve the logins
+type CodeService interface {
+	ExchangeCode(ctx context.Context, code string) (*sdk.LdapIdentity, error)
+}
+
+// authProvider implements the SDK ServiceProvider interface for the login against an LDAP directory.
+// The login page posts the username and the password to go-iam, which checks them against the
+// directory and continues the code flow with a single use code. The identity read from the entry
+// of the user acts as the access token of the provider.
+type authProvider struct {
+	loginUrl string
+	codes    CodeService
+}
+
+// NewAuthProvider creates a new LDAP provider instance
+// Parameters in the AuthProvider configuration:
+// - @LDAP/LOGIN_URL: Login page receiving the state of the login
+// - @LDAP/URL: Url of the directory, ldap:// or ldaps://
+// - @LDAP/START_TLS: "true" upgrades ldap:// connections with StartTLS (optional)
+// - @LDAP/CA_CERT: Pem encoded certificates of the CAs of the directory (optional)
+// - @LDAP/BIND_DN: DN of the service account searching the users (optional)
+// - @LDAP/BIND_PASSWORD: Password of the service account, a secret
+// - @LDAP/USER_SEARCH_BASE: DN under which the users are searched
+// - @LDAP/USER_FILTER: Filter of the users with a {username} placeholder (optional, defaults to (uid={username}))
+// - @LDAP/ATTRIBUTE_MAPPING: Json object of the attributes of the user fields (optional)
+func NewAuthProvider(p sdk.AuthProvider, codes CodeService) sdk.ServiceProvider {
+	return authProvider{
+		loginUrl: p.GetParam(sdk.LdapParamLoginUrl),
+		codes:    codes,
+	}
+}
+
+// HasRefreshTokenFlow returns false, the user has to log in again for a fresh entry
+func (a authProvider) HasRefreshTokenFlow() bool {
+	return false
+}
+
+// GetAuthCodeUrl returns the login page with the state in its query
+func (a authProvider) GetAuthCodeUrl(state string) string {
+	u, err := url.Parse(a.loginUrl)
+	if err != nil {
+		return a.loginUrl
+	}
+	q := u.Query()
+	q.Set("state", state)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// VerifyCode exchanges the single use code issued at login for the identity of the user
+func (a authProvider) VerifyCode(ctx context.Context, code string) (*sdk.AuthToken, error) {
+	identity, err := a.codes.ExchangeCode(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying the ldap login code. %w", err)
+	}
+	token, err := json.Marshal(identity)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding the ldap identity %w", err)
+	}
+	return &sdk.AuthToken{
+		AccessToken: base64.RawURLEncoding.EncodeToString(token),
+		ExpiresAt:   time.Now().Add(time.Hour * 24),
+	}, nil
+}
+
+// RefreshToken is not supported by the ldap provider
+func (a authProvider) RefreshToken(refreshToken string) (*sdk.AuthToken, error) {
+	return nil, fmt.Errorf("refresh token flow is not supported by the ldap provider")
+}
+
+// LdapIdentityEmail handles email identity information
+type LdapIdentityEmail struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+func (l LdapIdentityEmail) UpdateUserDetails(user *sdk.User) {
+	user.Email = l.Email
+	user.EmailVerified = l.EmailVerified
+}
+
+// LdapIdentityName handles name identity information
+type LdapIdentityName struct {
+	Name string `json:"name"`
+}
+
+func (l LdapIdentityName) UpdateUserDetails(user *sdk.User) {
+	user.Name = l.Name
+}
+
+// LdapIdentityPhone handles phone identity information
+type LdapIdentityPhone struct {
+	Phone string `json:"phone"`
+}
+
+func (l LdapIdentityPhone) UpdateUserDetails(user *sdk.User) {
+	user.Phone = l.Phone
+}
+
+// GetIdentity decodes the identity read from the entry of the user
+func (a authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
+	id, err := decodeIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// the DN of the entry identifies the user in the directory
+	identities := []sdk.AuthIdentity{
+		{Type: sdk.AuthIdentityTypeSubject, Metadata: sdk.AuthIdentitySubject{Subject: id.Dn}},
+	}
+	// the directory of the organisation is the authority of the email addresses it holds
+	if len(id.Email) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeEmail, Metadata: LdapIdentityEmail{Email: id.Email, EmailVerified: true}})
+	}
+	if len(id.Phone) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypePhone, Metadata: LdapIdentityPhone{Phone: id.Phone}})
+	}
+	if len(id.Name) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeEmail, Metadata: LdapIdentityName{Name: id.Name}})
+	}
+	// the entry lists all the groups of the user, no groups means they left them all
+	identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeGroups, Metadata: sdk.AuthIdentityGroups{Groups: id.Groups}})
+	return identities, nil
+}
+
+func decodeIdentity(token string) (*sdk.LdapIdentity, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ldap access token %w", err)
+	}
+	id := sdk.LdapIdentity{}
+	err = json.Unmarshal(b, &id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ldap access token %w", err)
+	}
+	return &id, nil
+}
@@ -0,0 +1,94 @@
+package ldap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCodes resolves a single identity through the code "code-1"
+type fakeCodes struct {
+	identity *sdk.LdapIdentity
+}
+
+func (f fakeCodes) ExchangeCode(ctx context.Context, code string) (*sdk.LdapIdentity, error) {
+	if code != "code-1" {
+		return nil, sdk.ErrInvalidLdapCredentials
+	}
+	return f.identity, nil
+}
+
+var testIdentity = &sdk.LdapIdentity{
+	Dn:     "uid=jdoe,ou=people,dc=example,dc=com",
+	Email:  "jdoe@example.com",
+	Name:   "John Doe",
+	Phone:  "+15550100",
+	Groups: []string{"CN=Admins,OU=Groups,DC=example,DC=com", "cn=staff,ou=groups,dc=example,dc=com"},
+}
+
+func createLdapProvider(params ...sdk.AuthProviderParam) sdk.AuthProvider {
+	return sdk.AuthProvider{
+		Id:       "ldap-test-id",
+		Name:     "Corp Directory",
+		Provider: sdk.AuthProviderTypeLDAP,
+		Params:   append(params, sdk.AuthProviderParam{Key: "@LDAP/LOGIN_URL", Value: "https://app.example.com/ldap-login"}),
+	}
+}
+
+func TestGetAuthCodeUrl(t *testing.T) {
+	provider := NewAuthProvider(createLdapProvider(), fakeCodes{identity: testIdentity})
+
+	assert.Equal(t, "https://app.example.com/ldap-login?state=state+1", provider.GetAuthCodeUrl("state 1"))
+	assert.False(t, provider.HasRefreshTokenFlow())
+}
+
+func TestVerifyCodeAndGetIdentity(t *testing.T) {
+	provider := NewAuthProvider(createLdapProvider(), fakeCodes{identity: testIdentity})
+
+	token, err := provider.VerifyCode(context.Background(), "code-1")
+	require.NoError(t, err)
+
+	identities, err := provider.GetIdentity(token.AccessToken)
+	require.NoError(t, err)
+	user := &sdk.User{}
+	for _, id := range identities {
+		id.UpdateUserDetails(user)
+	}
+	assert.Equal(t, "jdoe@example.com", user.Email)
+	assert.Equal(t, "John Doe", user.Name)
+	assert.Equal(t, "+15550100", user.Phone)
+
+	_, err = provider.VerifyCode(context.Background(), "unknown")
+	assert.ErrorIs(t, err, sdk.ErrInvalidLdapCredentials)
+
+	_, err = provider.GetIdentity("not-a-token")
+	assert.Error(t, err)
+
+	_, err = provider.RefreshToken("refresh")
+	assert.Error(t, err)
+}
+
+func TestGetIdentityGroups(t *testing.T) {
+	t.Run("groups of the entry", func(t *testing.T) {
+		provider := NewAuthProvider(createLdapProvider(), fakeCodes{identity: testIdentity})
+		token, err := provider.VerifyCode(context.Background(), "code-1")
+		require.NoError(t, err)
+
+		identities, err := provider.GetIdentity(token.AccessToken)
+		require.NoError(t, err)
+		assert.Contains(t, identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeGroups, Metadata: sdk.AuthIdentityGroups{Groups: testIdentity.Groups}})
+	})
+
+	t.Run("entry without groups", func(t *testing.T) {
+		provider := NewAuthProvider(createLdapProvider(), fakeCodes{identity: &sdk.LdapIdentity{Dn: "uid=jdoe,ou=people,dc=example,dc=com", Email: "jdoe@example.com"}})
+		token, err := provider.VerifyCode(context.Background(), "code-1")
+		require.NoError(t, err)
+
+		identities, err := provider.GetIdentity(token.AccessToken)
+		require.NoError(t, err)
+		assert.Contains(t, identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeGroups, Metadata: sdk.AuthIdentityGroups{}})
+	})
+}
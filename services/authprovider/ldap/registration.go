@@ -0,0 +1,64 @@
+package ldap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/authprovider/registry"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		AuthProviderTypeInfo: sdk.AuthProviderTypeInfo{
+			Type: sdk.AuthProviderTypeLDAP,
+			Name: "LDAP / Active Directory",
+			Icon: "ldap",
+			Params: []sdk.AuthProviderParamSpec{
+				{Key: sdk.LdapParamLoginUrl, Label: "Login page", Description: "Page posting the username and the password, it receives the state of the login in the query", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.LdapParamUrl, Label: "Directory URL", Description: "ldap://host:389 or ldaps://host:636", Required: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.LdapParamStartTls, Label: "StartTLS", Description: "Whether ldap:// connections are upgraded with StartTLS", Format: sdk.AuthProviderParamFormatBoolean},
+				{Key: sdk.LdapParamCaCert, Label: "CA certificates", Description: "Pem encoded certificates of the CAs of the directory, the system ones when empty", Format: sdk.AuthProviderParamFormatPem},
+				{Key: sdk.LdapParamBindDn, Label: "Bind DN", Description: "DN of the service account searching the users, anonymous search when empty", Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.LdapParamBindPassword, Label: "Bind password", Description: "Password of the service account", IsSecret: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.LdapParamUserSearchBase, Label: "User search base", Description: "DN under which the users are searched", Required: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.LdapParamUserFilter, Label: "User filter", Description: "Search filter of the users with a {username} placeholder, (uid={username}) when empty", Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.LdapParamAttributeMapping, Label: "Attribute mapping", Description: "Json object mapping email, name, phone and groups to the attributes of the entries", Format: sdk.AuthProviderParamFormatJson},
+			},
+		},
+		New: func(_ context.Context, p sdk.AuthProvider, deps registry.Dependencies) (sdk.ServiceProvider, error) {
+			directories, _ := deps.Directories.(CodeService)
+			return NewAuthProvider(p, directories), nil
+		},
+		Validate: validate,
+	})
+}
+
+func validate(p sdk.AuthProvider) error {
+	u, err := url.Parse(p.GetParam(sdk.LdapParamUrl))
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || len(u.Hostname()) == 0 {
+		return fmt.Errorf("the directory url has to be an ldap:// or ldaps:// url")
+	}
+	if filter := p.GetParam(sdk.LdapParamUserFilter); len(filter) > 0 && !strings.Contains(filter, "{username}") {
+		return fmt.Errorf("the user filter has no {username} placeholder")
+	}
+	if param := p.GetParam(sdk.LdapParamAttributeMapping); len(param) > 0 {
+		mapping := map[string]string{}
+		if err := json.Unmarshal([]byte(param), &mapping); err != nil {
+			return fmt.Errorf("%s has to map names to names", sdk.LdapParamAttributeMapping)
+		}
+		for field := range mapping {
+			if !slices.Contains(userFields, field) {
+				return fmt.Errorf("unknown user field %s in the attribute mapping", field)
+			}
+		}
+	}
+	return nil
+}
+
+// userFields are the fields of the users the attribute mapping can set
+var userFields = []string{"email", "name", "phone", "groups"}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2/log"
@@ -14,22 +15,31 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// graphUrl is the base url of Microsoft Graph
+var graphUrl = "https://graph.microsoft.com"
+
 type authProvider struct {
-	cnf oauth2.Config
+	cnf        oauth2.Config
+	syncGroups bool
 }
 
 func NewAuthProvider(p sdk.AuthProvider) sdk.ServiceProvider {
+	syncGroups := p.GetParam(paramSyncGroups) == "true"
+	scopes := []string{"openid", "profile", "email"}
+	if syncGroups {
+		scopes = append(scopes, "GroupMember.Read.All")
+	}
 	oauthConfig := oauth2.Config{
-		ClientID:     p.GetParam("@MICROSOFT/CLIENT_ID"),
-		ClientSecret: p.GetParam("@MICROSOFT/CLIENT_SECRET"),
-		RedirectURL:  p.GetParam("@MICROSOFT/REDIRECT_URL"),
-		Scopes:       []string{"openid", "profile", "email"},
+		ClientID:     p.GetParam(paramClientId),
+		ClientSecret: p.GetParam(paramClientSecret),
+		RedirectURL:  p.GetParam(paramRedirectUrl),
+		Scopes:       scopes,
 		Endpoint: oauth2.Endpoint{
 			AuthURL:  "https://login.microsoftonline.com/common/oauth2/v2.0/authorize",
 			TokenURL: "https://login.microsoftonline.com/common/oauth2/v2.0/token",
 		},
 	}
-	return authProvider{cnf: oauthConfig}
+	return authProvider{cnf: oauthConfig, syncGroups: syncGroups}
 }
 
 func (m authProvider) HasRefreshTokenFlow() bool {
@@ -65,7 +75,7 @@ func (m authProvider) RefreshToken(refreshToken string) (*sdk.AuthToken, error)
 	data.Set("client_secret", m.cnf.ClientSecret)
 	data.Set("refresh_token", refreshToken)
 	data.Set("grant_type", "refresh_token")
-	data.Set("scope", "openid profile email")
+	data.Set("scope", strings.Join(m.cnf.Scopes, " "))
 
 	resp, err := http.PostForm(urlStr, data)
 	if err != nil {
@@ -100,11 +110,13 @@ func (m authProvider) RefreshToken(refreshToken string) (*sdk.AuthToken, error)
 }
 
 type MicrosoftIdentityEmail struct {
-	Email string `json:"email"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
 func (m MicrosoftIdentityEmail) UpdateUserDetails(user *sdk.User) {
 	user.Email = m.Email
+	user.EmailVerified = m.EmailVerified
 }
 
 type MicrosoftIdentityName struct {
@@ -124,7 +136,7 @@ func (m MicrosoftIdentityProfilePic) UpdateUserDetails(user *sdk.User) {
 }
 
 func (m authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
-	req, err := http.NewRequest("GET", "https://graph.microsoft.com/oidc/userinfo", nil)
+	req, err := http.NewRequest("GET", graphUrl+"/oidc/userinfo", nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request. %w", err)
 	}
@@ -146,6 +158,7 @@ func (m authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
 	}
 
 	var userInfo struct {
+		Sub        string `json:"sub"`
 		Email      string `json:"email"`
 		FirstName  string `json:"givenname"`
 		LastName   string `json:"familyname"`
@@ -155,9 +168,70 @@ func (m authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error unmarshalling the response. %s - %w", string(respBytes), err)
 	}
-	return []sdk.AuthIdentity{
+	identities := []sdk.AuthIdentity{
+		{Type: sdk.AuthIdentityTypeSubject, Metadata: sdk.AuthIdentitySubject{Subject: userInfo.Sub}},
+		// graph does not say whether the mail of the user was verified, any tenant can set it
 		{Type: sdk.AuthIdentityTypeEmail, Metadata: MicrosoftIdentityEmail{Email: userInfo.Email}},
 		{Type: sdk.AuthIdentityTypeEmail, Metadata: MicrosoftIdentityName{Name: fmt.Sprintf("%s %s", userInfo.FirstName, userInfo.LastName)}},
 		{Type: sdk.AuthIdentityTypeEmail, Metadata: MicrosoftIdentityProfilePic{ProfilePic: userInfo.ProfilePic}},
-	}, nil
+	}
+	if !m.syncGroups {
+		return identities, nil
+	}
+	groups, err := getMemberOf(token)
+	if err != nil {
+		// without the groups the roles of the user are kept as they are
+		log.Errorf("error fetching the groups of the user from microsoft graph %s", err)
+		return identities, nil
+	}
+	return append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeGroups, Metadata: sdk.AuthIdentityGroups{Groups: groups}}), nil
+}
+
+// getMemberOf lists the groups the user of the token is a direct member of, following the pages of the response.
+// Every group is listed by its object id and its display name, so that the group roles can use either.
+func getMemberOf(token string) ([]string, error) {
+	groups := []string{}
+	next := graphUrl + "/v1.0/me/memberOf?$select=id,displayName"
+	for len(next) > 0 {
+		req, err := http.NewRequest("GET", next, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating request. %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching the groups. %w", err)
+		}
+		respBytes, err := io.ReadAll(resp.Body)
+		if err := resp.Body.Close(); err != nil {
+			log.Errorf("failed to close response body: %w", err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading the response. %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("error fetching the groups, status: %d, response: %s", resp.StatusCode, string(respBytes))
+		}
+
+		var page struct {
+			Value []struct {
+				Id          string `json:"id"`
+				DisplayName string `json:"displayName"`
+			} `json:"value"`
+			NextLink string `json:"@odata.nextLink"`
+		}
+		err = json.Unmarshal(respBytes, &page)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshalling the response. %s - %w", string(respBytes), err)
+		}
+		for _, g := range page.Value {
+			groups = append(groups, g.Id)
+			if len(g.DisplayName) > 0 {
+				groups = append(groups, g.DisplayName)
+			}
+		}
+		next = page.NextLink
+	}
+	return groups, nil
 }
@@ -511,6 +511,55 @@ func TestGetIdentity_NetworkError(t *testing.T) {
 	}
 }
 
+func TestGetIdentity_Groups(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		switch {
+		case r.URL.Path == "/oidc/userinfo":
+			_, _ = w.Write([]byte(`{"sub":"sub-1","email":"jdoe@example.com"}`))
+		case r.URL.Path == "/v1.0/me/memberOf" && r.URL.Query().Get("page") == "":
+			_, _ = fmt.Fprintf(w, `{"value":[{"id":"group-1","displayName":"Admins"}],"@odata.nextLink":"%s/v1.0/me/memberOf?page=2"}`, server.URL)
+		case r.URL.Path == "/v1.0/me/memberOf":
+			_, _ = w.Write([]byte(`{"value":[{"id":"role-1"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer func(url string) { graphUrl = url }(graphUrl)
+	graphUrl = server.URL
+
+	t.Run("groups of the user with sync enabled", func(t *testing.T) {
+		p := createMockMicrosoftProvider()
+		p.Params = append(p.Params, sdk.AuthProviderParam{Key: "@MICROSOFT/SYNC_GROUPS", Value: "true"})
+		provider := NewAuthProvider(p).(authProvider)
+		assert.Contains(t, provider.cnf.Scopes, "GroupMember.Read.All")
+
+		identities, err := provider.GetIdentity("test-token")
+		require.NoError(t, err)
+		assert.Contains(t, identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeGroups, Metadata: sdk.AuthIdentityGroups{Groups: []string{"group-1", "Admins", "role-1"}}})
+	})
+
+	t.Run("groups are not read without sync", func(t *testing.T) {
+		identities, err := NewAuthProvider(createMockMicrosoftProvider()).GetIdentity("test-token")
+		require.NoError(t, err)
+		assert.Len(t, identities, 4)
+	})
+}
+
+func TestGetMemberOf_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	defer func(url string) { graphUrl = url }(graphUrl)
+	graphUrl = server.URL
+
+	_, err := getMemberOf("test-token")
+	assert.ErrorContains(t, err, "status: 403")
+}
+
 // Test identity metadata types
 func TestMicrosoftIdentityEmail_UpdateUserDetails(t *testing.T) {
 	user := &sdk.User{}
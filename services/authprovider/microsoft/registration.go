@@ -0,0 +1,35 @@
+package microsoft
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/authprovider/registry"
+)
+
+// Params of the Microsoft auth provider
+const (
+	paramClientId     = "@MICROSOFT/CLIENT_ID"
+	paramClientSecret = "@MICROSOFT/CLIENT_SECRET"
+	paramRedirectUrl  = "@MICROSOFT/REDIRECT_URL"
+	paramSyncGroups   = "@MICROSOFT/SYNC_GROUPS"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		AuthProviderTypeInfo: sdk.AuthProviderTypeInfo{
+			Type: sdk.AuthProviderTypeMicrosoft,
+			Name: "Microsoft",
+			Icon: "microsoft",
+			Params: []sdk.AuthProviderParamSpec{
+				{Key: paramClientId, Label: "Client ID", Description: "Client id of the app registration in Microsoft Entra ID", Required: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: paramClientSecret, Label: "Client secret", Description: "Client secret of the app registration in Microsoft Entra ID", Required: true, IsSecret: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: paramRedirectUrl, Label: "Redirect URL", Description: "Redirect URI of the app registration, the callback of go-iam", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+				{Key: paramSyncGroups, Label: "Sync groups", Description: "Whether the groups of the users are read from Microsoft Graph for the group roles, it needs the GroupMember.Read.All permission", Format: sdk.AuthProviderParamFormatBoolean},
+			},
+		},
+		New: func(_ context.Context, p sdk.AuthProvider, _ registry.Dependencies) (sdk.ServiceProvider, error) {
+			return NewAuthProvider(p), nil
+		},
+	})
+}
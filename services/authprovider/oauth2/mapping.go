@@ -0,0 +1,127 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fields of the user picked out of the userinfo response
+const (
+	fieldSubject       = "subject"
+	fieldEmail         = "email"
+	fieldEmailVerified = "email_verified"
+	fieldName          = "name"
+	fieldPhone         = "phone"
+	fieldPicture       = "picture"
+)
+
+// defaultClaims are the paths looked up when the mapping of a field is not configured.
+// They cover the OIDC claim names and the ones of the GitHub, GitLab and Discord apis.
+var defaultClaims = map[string][]string{
+	fieldSubject:       {"sub", "id"},
+	fieldEmail:         {"email"},
+	fieldEmailVerified: {"email_verified", "verified"},
+	fieldName:          {"name", "display_name", "global_name", "username", "login"},
+	fieldPhone:         {"phone_number", "phone"},
+	fieldPicture:       {"picture", "avatar_url"},
+}
+
+// fields of the entries of the emails response
+const (
+	emailsList     = "list"
+	emailsEmail    = "email"
+	emailsPrimary  = "primary"
+	emailsVerified = "verified"
+)
+
+// defaultEmailPaths read a json array of {"email", "primary", "verified"} objects, like the one of GitHub
+var defaultEmailPaths = map[string][]string{
+	emailsList:     {""},
+	emailsEmail:    {"email"},
+	emailsPrimary:  {"primary"},
+	emailsVerified: {"verified"},
+}
+
+// mapping merges the configured paths of the fields with the default ones. A field is mapped to
+// a path or to an array of paths, the first one with a value is used.
+func mapping(param string, defaults map[string][]string) (map[string][]string, error) {
+	paths := make(map[string][]string, len(defaults))
+	for k, v := range defaults {
+		paths[k] = v
+	}
+	if len(param) == 0 {
+		return paths, nil
+	}
+	configured := map[string]json.RawMessage{}
+	err := json.Unmarshal([]byte(param), &configured)
+	if err != nil {
+		return nil, err
+	}
+	for field, raw := range configured {
+		if _, ok := defaults[field]; !ok {
+			return nil, fmt.Errorf("unknown field %s", field)
+		}
+		var path string
+		if json.Unmarshal(raw, &path) == nil {
+			paths[field] = []string{path}
+			continue
+		}
+		var alternatives []string
+		if json.Unmarshal(raw, &alternatives) != nil || len(alternatives) == 0 {
+			return nil, fmt.Errorf("the mapping of %s has to be a path or an array of paths", field)
+		}
+		paths[field] = alternatives
+	}
+	return paths, nil
+}
+
+// lookup walks a decoded json document along a dot separated path like "data.attributes.email".
+// Numeric segments index arrays, as in "emails.0.value". The empty path is the document itself.
+func lookup(doc interface{}, path string) (interface{}, bool) {
+	if len(path) == 0 {
+		return doc, doc != nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			doc = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			doc = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, doc != nil
+}
+
+// text returns the value at the first of the paths holding a string, a number or a boolean.
+// Numbers are decoded as json.Number, for numeric ids not to be formatted as floats.
+func text(doc interface{}, paths []string) string {
+	for _, path := range paths {
+		v, _ := lookup(doc, path)
+		var s string
+		switch v := v.(type) {
+		case string:
+			s = v
+		case json.Number:
+			s = v.String()
+		case bool:
+			s = strconv.FormatBool(v)
+		}
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			return s
+		}
+	}
+	return ""
+}
+
+// flag tells whether the value at the first path set is true, some apis send booleans as strings
+func flag(doc interface{}, paths []string) bool {
+	return text(doc, paths) == "true"
+}
@@ -0,0 +1,271 @@
+package oauth2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/sdk"
+	xoauth2 "golang.org/x/oauth2"
+)
+
+// maxResponseSize is the largest userinfo or emails response read from the provider
+const maxResponseSize = 1 << 20
+
+// authProvider implements the SDK ServiceProvider interface for OAuth2 providers that do not speak OIDC.
+// The user details are picked out of the userinfo response with the configured paths.
+type authProvider struct {
+	cnf            xoauth2.Config
+	authParams     []xoauth2.AuthCodeOption
+	userInfoURL    string
+	emailsURL      string
+	claims         map[string][]string
+	emails         map[string][]string
+	refreshEnabled bool
+	providerName   string
+}
+
+// NewAuthProvider creates a new generic OAuth2 provider instance
+// Parameters in the AuthProvider configuration:
+// - @OAUTH2/CLIENT_ID: OAuth2 client ID
+// - @OAUTH2/CLIENT_SECRET: OAuth2 client secret
+// - @OAUTH2/REDIRECT_URL: OAuth2 redirect URL
+// - @OAUTH2/AUTHORIZATION_URL: Authorization endpoint
+// - @OAUTH2/TOKEN_URL: Token endpoint
+// - @OAUTH2/USERINFO_URL: Endpoint returning the profile of the user
+// - @OAUTH2/SCOPES: Space-separated list of OAuth2 scopes (optional)
+// - @OAUTH2/AUTH_PARAMS: Json object of extra query params of the authorization url (optional)
+// - @OAUTH2/CLAIM_MAPPING: Json object of the paths of the user fields in the userinfo response (optional)
+// - @OAUTH2/EMAILS_URL: Endpoint listing the email addresses of the user (optional)
+// - @OAUTH2/EMAILS_MAPPING: Json object of the paths of the fields of the emails response (optional)
+// - @OAUTH2/REFRESH_ENABLED: "true" when the provider issues refresh tokens (optional)
+func NewAuthProvider(p sdk.AuthProvider) (sdk.ServiceProvider, error) {
+	authParams, err := authCodeOptions(p.GetParam(sdk.OAuth2ParamAuthParams))
+	if err != nil {
+		return nil, fmt.Errorf("error reading the auth params of %s %w", p.Name, err)
+	}
+	claims, err := mapping(p.GetParam(sdk.OAuth2ParamClaimMapping), defaultClaims)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the claim mapping of %s %w", p.Name, err)
+	}
+	emails, err := mapping(p.GetParam(sdk.OAuth2ParamEmailsMapping), defaultEmailPaths)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the emails mapping of %s %w", p.Name, err)
+	}
+	return authProvider{
+		cnf: xoauth2.Config{
+			ClientID:     p.GetParam(sdk.OAuth2ParamClientId),
+			ClientSecret: p.GetParam(sdk.OAuth2ParamClientSecret),
+			RedirectURL:  p.GetParam(sdk.OAuth2ParamRedirectUrl),
+			Scopes:       strings.Fields(p.GetParam(sdk.OAuth2ParamScopes)),
+			Endpoint: xoauth2.Endpoint{
+				AuthURL:  p.GetParam(sdk.OAuth2ParamAuthorizationUrl),
+				TokenURL: p.GetParam(sdk.OAuth2ParamTokenUrl),
+			},
+		},
+		authParams:     authParams,
+		userInfoURL:    p.GetParam(sdk.OAuth2ParamUserinfoUrl),
+		emailsURL:      p.GetParam(sdk.OAuth2ParamEmailsUrl),
+		claims:         claims,
+		emails:         emails,
+		refreshEnabled: p.GetParam(sdk.OAuth2ParamRefreshEnabled) == "true",
+		providerName:   p.Name,
+	}, nil
+}
+
+// authCodeOptions reads the extra query params of the authorization url
+func authCodeOptions(param string) ([]xoauth2.AuthCodeOption, error) {
+	if len(param) == 0 {
+		return nil, nil
+	}
+	params := map[string]string{}
+	err := json.Unmarshal([]byte(param), &params)
+	if err != nil {
+		return nil, fmt.Errorf("the auth params have to be a json object of strings %w", err)
+	}
+	opts := make([]xoauth2.AuthCodeOption, 0, len(params))
+	for k, v := range params {
+		opts = append(opts, xoauth2.SetAuthURLParam(k, v))
+	}
+	return opts, nil
+}
+
+// HasRefreshTokenFlow tells whether the provider was configured to issue refresh tokens.
+// Providers like GitHub issue access tokens that do not expire and no refresh tokens.
+func (o authProvider) HasRefreshTokenFlow() bool {
+	return o.refreshEnabled
+}
+
+// GetAuthCodeUrl returns the authorization URL with the extra params configured
+func (o authProvider) GetAuthCodeUrl(state string) string {
+	return o.cnf.AuthCodeURL(state, o.authParams...)
+}
+
+// VerifyCode exchanges an authorization code for access and refresh tokens
+func (o authProvider) VerifyCode(ctx context.Context, code string) (*sdk.AuthToken, error) {
+	token, err := o.cnf.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying code with OAuth2 provider %s: %w", o.providerName, err)
+	}
+	return &sdk.AuthToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		ExpiresAt:    token.Expiry,
+	}, nil
+}
+
+// RefreshToken uses a refresh token to obtain a new access token
+func (o authProvider) RefreshToken(refreshToken string) (*sdk.AuthToken, error) {
+	token, err := o.cnf.TokenSource(context.Background(), &xoauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		return nil, fmt.Errorf("error refreshing token with OAuth2 provider %s: %w", o.providerName, err)
+	}
+	return &sdk.AuthToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		ExpiresAt:    token.Expiry,
+	}, nil
+}
+
+// OAuth2IdentityEmail handles email identity information
+type OAuth2IdentityEmail struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+func (o OAuth2IdentityEmail) UpdateUserDetails(user *sdk.User) {
+	user.Email = o.Email
+	user.EmailVerified = o.EmailVerified
+}
+
+// OAuth2IdentityName handles name identity information
+type OAuth2IdentityName struct {
+	Name string `json:"name"`
+}
+
+func (o OAuth2IdentityName) UpdateUserDetails(user *sdk.User) {
+	user.Name = o.Name
+}
+
+// OAuth2IdentityPhone handles phone identity information
+type OAuth2IdentityPhone struct {
+	Phone string `json:"phone"`
+}
+
+func (o OAuth2IdentityPhone) UpdateUserDetails(user *sdk.User) {
+	user.Phone = o.Phone
+}
+
+// OAuth2IdentityProfilePic handles profile picture identity information
+type OAuth2IdentityProfilePic struct {
+	ProfilePic string `json:"picture"`
+}
+
+func (o OAuth2IdentityProfilePic) UpdateUserDetails(user *sdk.User) {
+	user.ProfilePic = o.ProfilePic
+}
+
+// GetIdentity maps the userinfo response to the user details. With an emails url configured,
+// the email address is the primary verified one it lists instead of the one of the profile.
+// Otherwise the email address is verified when the email_verified claim of the mapping is true.
+func (o authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
+	userInfo, err := o.getJson(o.userInfoURL, token)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching identity from OAuth2 provider %s: %w", o.providerName, err)
+	}
+	subject := text(userInfo, o.claims[fieldSubject])
+	if len(subject) == 0 {
+		return nil, fmt.Errorf("the userinfo response of OAuth2 provider %s has no subject", o.providerName)
+	}
+
+	email := text(userInfo, o.claims[fieldEmail])
+	verified := flag(userInfo, o.claims[fieldEmailVerified])
+	if len(o.emailsURL) > 0 {
+		email, err = o.primaryEmail(token)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching emails from OAuth2 provider %s: %w", o.providerName, err)
+		}
+		verified = len(email) > 0
+	}
+
+	identities := []sdk.AuthIdentity{
+		{Type: sdk.AuthIdentityTypeSubject, Metadata: sdk.AuthIdentitySubject{Subject: subject}},
+	}
+	if len(email) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeEmail, Metadata: OAuth2IdentityEmail{Email: email, EmailVerified: verified}})
+	}
+	if phone := text(userInfo, o.claims[fieldPhone]); len(phone) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypePhone, Metadata: OAuth2IdentityPhone{Phone: phone}})
+	}
+	if name := text(userInfo, o.claims[fieldName]); len(name) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeEmail, Metadata: OAuth2IdentityName{Name: name}})
+	}
+	if picture := text(userInfo, o.claims[fieldPicture]); len(picture) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeEmail, Metadata: OAuth2IdentityProfilePic{ProfilePic: picture}})
+	}
+	return identities, nil
+}
+
+// primaryEmail returns the primary email address listed by the emails url, when it is verified.
+// Unverified addresses are never used, they could be claimed by anyone.
+func (o authProvider) primaryEmail(token string) (string, error) {
+	doc, err := o.getJson(o.emailsURL, token)
+	if err != nil {
+		return "", err
+	}
+	var list []interface{}
+	for _, path := range o.emails[emailsList] {
+		if v, ok := lookup(doc, path); ok {
+			list, _ = v.([]interface{})
+			break
+		}
+	}
+	for _, entry := range list {
+		if flag(entry, o.emails[emailsPrimary]) && flag(entry, o.emails[emailsVerified]) {
+			return text(entry, o.emails[emailsEmail]), nil
+		}
+	}
+	return "", nil
+}
+
+// getJson fetches a json document from the provider with the access token
+func (o authProvider) getJson(url, token string) (interface{}, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Errorf("failed to close response body: %w", err)
+		}
+	}()
+
+	respBytes, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status: %d, response: %s", resp.StatusCode, string(respBytes))
+	}
+
+	var doc interface{}
+	d := json.NewDecoder(bytes.NewReader(respBytes))
+	d.UseNumber()
+	err = d.Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,261 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockAuthProvider(params map[string]string) sdk.AuthProvider {
+	p := sdk.AuthProvider{Name: "Test Provider", Provider: sdk.AuthProviderTypeOAuth2}
+	for k, v := range params {
+		p.Params = append(p.Params, sdk.AuthProviderParam{Key: k, Value: v})
+	}
+	return p
+}
+
+// testServer serves the token endpoint and the given json documents at their paths
+func testServer(t *testing.T, docs map[string]string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			if r.Form.Get("code") != "test-code" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"access","refresh_token":"refresh","token_type":"bearer","expires_in":3600}`))
+		case "refresh_token":
+			assert.Equal(t, "refresh", r.Form.Get("refresh_token"))
+			_, _ = w.Write([]byte(`{"access_token":"refreshed","token_type":"bearer","expires_in":3600}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	for path, doc := range docs {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer access" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(doc))
+		})
+	}
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestProvider(t *testing.T, srv *httptest.Server, params map[string]string) authProvider {
+	all := map[string]string{
+		sdk.OAuth2ParamClientId:         "test-client-id",
+		sdk.OAuth2ParamClientSecret:     "test-client-secret",
+		sdk.OAuth2ParamRedirectUrl:      "https://example.com/callback",
+		sdk.OAuth2ParamAuthorizationUrl: srv.URL + "/authorize",
+		sdk.OAuth2ParamTokenUrl:         srv.URL + "/token",
+		sdk.OAuth2ParamUserinfoUrl:      srv.URL + "/user",
+	}
+	for k, v := range params {
+		all[k] = v
+	}
+	sp, err := NewAuthProvider(mockAuthProvider(all))
+	require.NoError(t, err)
+	return sp.(authProvider)
+}
+
+func TestNewAuthProvider(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+		err    string
+	}{
+		{name: "defaults"},
+		{name: "configured mappings", params: map[string]string{
+			sdk.OAuth2ParamAuthParams:    `{"prompt": "consent"}`,
+			sdk.OAuth2ParamClaimMapping:  `{"subject": "data.id", "name": ["data.name", "data.username"]}`,
+			sdk.OAuth2ParamEmailsMapping: `{"list": "values", "email": "address"}`,
+		}},
+		{name: "auth params not strings", params: map[string]string{sdk.OAuth2ParamAuthParams: `{"max_age": 10}`}, err: "auth params"},
+		{name: "unknown field", params: map[string]string{sdk.OAuth2ParamClaimMapping: `{"username": "login"}`}, err: "unknown field username"},
+		{name: "path not a string", params: map[string]string{sdk.OAuth2ParamClaimMapping: `{"email": {"path": "email"}}`}, err: "a path or an array of paths"},
+		{name: "no paths", params: map[string]string{sdk.OAuth2ParamClaimMapping: `{"email": []}`}, err: "a path or an array of paths"},
+		{name: "invalid emails mapping", params: map[string]string{sdk.OAuth2ParamEmailsMapping: `{"primary": true}`}, err: "emails mapping"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAuthProvider(mockAuthProvider(tt.params))
+			if len(tt.err) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestAuthProvider_GetAuthCodeUrl(t *testing.T) {
+	srv := testServer(t, nil)
+	p := newTestProvider(t, srv, map[string]string{
+		sdk.OAuth2ParamScopes:     "read_user openid",
+		sdk.OAuth2ParamAuthParams: `{"prompt": "consent"}`,
+	})
+
+	u, err := url.Parse(p.GetAuthCodeUrl("test-state"))
+	require.NoError(t, err)
+	assert.Equal(t, srv.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	q := u.Query()
+	assert.Equal(t, "test-state", q.Get("state"))
+	assert.Equal(t, "test-client-id", q.Get("client_id"))
+	assert.Equal(t, "read_user openid", q.Get("scope"))
+	assert.Equal(t, "consent", q.Get("prompt"))
+	assert.Empty(t, q.Get("access_type"))
+}
+
+func TestAuthProvider_VerifyCode(t *testing.T) {
+	srv := testServer(t, nil)
+	p := newTestProvider(t, srv, map[string]string{sdk.OAuth2ParamRefreshEnabled: "true"})
+
+	token, err := p.VerifyCode(context.Background(), "test-code")
+	require.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+	assert.Equal(t, "refresh", token.RefreshToken)
+	assert.False(t, token.ExpiresAt.IsZero())
+
+	assert.True(t, p.HasRefreshTokenFlow())
+	token, err = p.RefreshToken("refresh")
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed", token.AccessToken)
+	// the refresh token is kept when the provider does not rotate it
+	assert.Equal(t, "refresh", token.RefreshToken)
+
+	_, err = p.VerifyCode(context.Background(), "wrong-code")
+	assert.Error(t, err)
+}
+
+func TestAuthProvider_HasRefreshTokenFlow(t *testing.T) {
+	srv := testServer(t, nil)
+	assert.False(t, newTestProvider(t, srv, nil).HasRefreshTokenFlow())
+}
+
+func identityOf(identities []sdk.AuthIdentity) (string, sdk.User) {
+	subject := ""
+	user := sdk.User{}
+	for _, id := range identities {
+		if s, ok := id.Metadata.(sdk.AuthIdentitySubject); ok {
+			subject = s.Subject
+		}
+		id.UpdateUserDetails(&user)
+	}
+	return subject, user
+}
+
+func TestAuthProvider_GetIdentity(t *testing.T) {
+	t.Run("default claims", func(t *testing.T) {
+		srv := testServer(t, map[string]string{
+			"/user": `{"id": 1234567890123, "login": "octocat", "email": "octo@example.com", "avatar_url": "https://example.com/octo.png"}`,
+		})
+		identities, err := newTestProvider(t, srv, nil).GetIdentity("access")
+		require.NoError(t, err)
+
+		subject, user := identityOf(identities)
+		// numeric ids are not formatted as floats
+		assert.Equal(t, "1234567890123", subject)
+		assert.Equal(t, "octo@example.com", user.Email)
+		assert.Equal(t, "octocat", user.Name)
+		assert.Equal(t, "https://example.com/octo.png", user.ProfilePic)
+		assert.Equal(t, sdk.AuthIdentityTypeSubject, identities[0].Type)
+	})
+
+	t.Run("configured paths", func(t *testing.T) {
+		srv := testServer(t, map[string]string{
+			"/user": `{"data": {"uuid": "u-1", "attributes": {"mail": "jane@example.com", "phones": [{"number": "+15550100"}], "full_name": ""}, "nickname": "jane"}}`,
+		})
+		identities, err := newTestProvider(t, srv, map[string]string{
+			sdk.OAuth2ParamClaimMapping: `{"subject": "data.uuid", "email": "data.attributes.mail", "phone": "data.attributes.phones.0.number", "name": ["data.attributes.full_name", "data.nickname"]}`,
+		}).GetIdentity("access")
+		require.NoError(t, err)
+
+		subject, user := identityOf(identities)
+		assert.Equal(t, "u-1", subject)
+		assert.Equal(t, "jane@example.com", user.Email)
+		assert.Equal(t, "+15550100", user.Phone)
+		// empty values fall back to the next path
+		assert.Equal(t, "jane", user.Name)
+		assert.Empty(t, user.ProfilePic)
+	})
+
+	t.Run("primary verified email from the emails url", func(t *testing.T) {
+		emails, _ := json.Marshal([]map[string]interface{}{
+			{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": "octo@example.com", "primary": true, "verified": true},
+		})
+		srv := testServer(t, map[string]string{
+			"/user":        `{"id": 1, "login": "octocat", "email": null}`,
+			"/user/emails": string(emails),
+		})
+		identities, err := newTestProvider(t, srv, map[string]string{sdk.OAuth2ParamEmailsUrl: srv.URL + "/user/emails"}).GetIdentity("access")
+		require.NoError(t, err)
+
+		_, user := identityOf(identities)
+		assert.Equal(t, "octo@example.com", user.Email)
+	})
+
+	t.Run("unverified primary email is not used", func(t *testing.T) {
+		srv := testServer(t, map[string]string{
+			"/user":   `{"id": 1, "login": "octocat", "email": "public@example.com"}`,
+			"/emails": `{"values": [{"address": "octo@example.com", "is_primary": "true", "is_confirmed": "false"}]}`,
+		})
+		identities, err := newTestProvider(t, srv, map[string]string{
+			sdk.OAuth2ParamEmailsUrl:     srv.URL + "/emails",
+			sdk.OAuth2ParamEmailsMapping: `{"list": "values", "email": "address", "primary": "is_primary", "verified": "is_confirmed"}`,
+		}).GetIdentity("access")
+		require.NoError(t, err)
+
+		_, user := identityOf(identities)
+		assert.Empty(t, user.Email)
+	})
+
+	t.Run("no subject", func(t *testing.T) {
+		srv := testServer(t, map[string]string{"/user": `{"login": "octocat"}`})
+		_, err := newTestProvider(t, srv, nil).GetIdentity("access")
+		assert.ErrorContains(t, err, "has no subject")
+	})
+
+	t.Run("userinfo error", func(t *testing.T) {
+		srv := testServer(t, map[string]string{"/user": `{"id": 1}`})
+		_, err := newTestProvider(t, srv, nil).GetIdentity("expired")
+		assert.ErrorContains(t, err, "status: 401")
+	})
+
+	t.Run("emails error", func(t *testing.T) {
+		srv := testServer(t, map[string]string{"/user": `{"id": 1}`})
+		_, err := newTestProvider(t, srv, map[string]string{sdk.OAuth2ParamEmailsUrl: srv.URL + "/missing"}).GetIdentity("access")
+		assert.ErrorContains(t, err, "error fetching emails")
+	})
+}
+
+func TestLookup(t *testing.T) {
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"a": {"b": [{"c": "d"}]}, "n": null}`), &doc))
+
+	v, ok := lookup(doc, "a.b.0.c")
+	assert.True(t, ok)
+	assert.Equal(t, "d", v)
+	for _, path := range []string{"a.b.1.c", "a.b.x", "a.b.0.c.d", "n", "missing"} {
+		_, ok = lookup(doc, path)
+		assert.False(t, ok, path)
+	}
+}
@@ -0,0 +1,40 @@
+package oauth2
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/authprovider/registry"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		AuthProviderTypeInfo: sdk.AuthProviderTypeInfo{
+			Type: sdk.AuthProviderTypeOAuth2,
+			Name: "OAuth2",
+			Icon: "oauth2",
+			Params: []sdk.AuthProviderParamSpec{
+				{Key: sdk.OAuth2ParamClientId, Label: "Client ID", Description: "Client id of go-iam at the provider", Required: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.OAuth2ParamClientSecret, Label: "Client secret", Description: "Client secret of go-iam at the provider", Required: true, IsSecret: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.OAuth2ParamRedirectUrl, Label: "Redirect URL", Description: "Callback of go-iam registered with the provider", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.OAuth2ParamAuthorizationUrl, Label: "Authorization URL", Description: "Authorization endpoint the users are sent to", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.OAuth2ParamTokenUrl, Label: "Token URL", Description: "Token endpoint the codes are exchanged at", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.OAuth2ParamUserinfoUrl, Label: "Userinfo URL", Description: "Endpoint returning the profile of the user as a json object", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.OAuth2ParamScopes, Label: "Scopes", Description: "Space separated scopes", Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.OAuth2ParamAuthParams, Label: "Auth params", Description: "Json object of extra query params of the authorization url", Format: sdk.AuthProviderParamFormatJson},
+				{Key: sdk.OAuth2ParamClaimMapping, Label: "Claim mapping", Description: "Json object mapping subject, email, name, phone and picture to dot separated paths in the userinfo response", Format: sdk.AuthProviderParamFormatJson},
+				{Key: sdk.OAuth2ParamEmailsUrl, Label: "Emails URL", Description: "Endpoint listing the email addresses of the user, the primary verified one is used", Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.OAuth2ParamEmailsMapping, Label: "Emails mapping", Description: "Json object mapping list, email, primary and verified to paths in the emails response", Format: sdk.AuthProviderParamFormatJson},
+				{Key: sdk.OAuth2ParamRefreshEnabled, Label: "Refresh tokens", Description: "Whether the provider issues refresh tokens with expiring access tokens", Format: sdk.AuthProviderParamFormatBoolean},
+			},
+		},
+		New: func(_ context.Context, p sdk.AuthProvider, _ registry.Dependencies) (sdk.ServiceProvider, error) {
+			return NewAuthProvider(p)
+		},
+		// the auth params and the mappings have to be readable for the logins to work
+		Validate: func(p sdk.AuthProvider) error {
+			_, err := NewAuthProvider(p)
+			return err
+		},
+	})
+}
@@ -0,0 +1,243 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+const (
+	// discoveryTTL is how long the discovery documents and the signing keys of the providers are cached
+	discoveryTTL = time.Hour
+	// keyRefetchCooldown limits how often an unknown kid triggers a refetch of the signing keys
+	keyRefetchCooldown = 30 * time.Second
+	// requestTimeout bounds the requests for the discovery documents and the signing keys
+	requestTimeout = 10 * time.Second
+	// maxDocumentSize caps the size of the discovery documents and the key sets read
+	maxDocumentSize = 1 << 20
+)
+
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// providerCache keeps the discovery documents by issuer and the signing keys by jwks uri.
+// The service providers are created for every login, so the cache lives with the package.
+type providerCache struct {
+	mu             sync.Mutex
+	configurations map[string]cachedConfiguration
+	keySets        map[string]cachedKeySet
+}
+
+type cachedConfiguration struct {
+	cnf       sdk.OpenIdConfiguration
+	fetchedAt time.Time
+}
+
+type cachedKeySet struct {
+	keys      []publicKey
+	fetchedAt time.Time
+}
+
+// publicKey is a signing key of the provider
+type publicKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+var cache = &providerCache{
+	configurations: map[string]cachedConfiguration{},
+	keySets:        map[string]cachedKeySet{},
+}
+
+// discover returns the discovery document of the issuer
+func (c *providerCache) discover(ctx context.Context, issuer string) (*sdk.OpenIdConfiguration, error) {
+	c.mu.Lock()
+	cached, ok := c.configurations[issuer]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < discoveryTTL {
+		return &cached.cnf, nil
+	}
+
+	cnf := sdk.OpenIdConfiguration{}
+	err := getJson(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &cnf)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the discovery document of %s %w", issuer, err)
+	}
+	// the issuer of the document has to be the one configured, the id tokens are checked against it
+	if strings.TrimSuffix(cnf.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("the discovery document of %s is for the issuer %s", issuer, cnf.Issuer)
+	}
+	if len(cnf.AuthorizationEndpoint) == 0 || len(cnf.TokenEndpoint) == 0 || len(cnf.JwksUri) == 0 {
+		return nil, fmt.Errorf("the discovery document of %s misses the authorization, token or jwks endpoint", issuer)
+	}
+
+	c.mu.Lock()
+	c.configurations[issuer] = cachedConfiguration{cnf: cnf, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return &cnf, nil
+}
+
+// signingKeys returns the keys of the key set which can have signed a token with the kid.
+// An unknown kid could be a key the provider rotated in, so the key set is refetched before giving up.
+func (c *providerCache) signingKeys(ctx context.Context, jwksUri, kid string) ([]publicKey, error) {
+	c.mu.Lock()
+	cached, ok := c.keySets[jwksUri]
+	c.mu.Unlock()
+
+	expired := !ok || time.Since(cached.fetchedAt) > discoveryTTL
+	keys := matchingKeys(cached.keys, kid)
+	if expired || (len(keys) == 0 && time.Since(cached.fetchedAt) > keyRefetchCooldown) {
+		fetched, err := fetchKeys(ctx, jwksUri)
+		switch {
+		case err != nil && expired:
+			return nil, err
+		case err != nil:
+			// the cached keys are still fresh, the token might be signed with one of them
+			log.Errorw("error refetching the signing keys of the oidc provider", "jwks_uri", jwksUri, "error", err)
+		default:
+			c.mu.Lock()
+			c.keySets[jwksUri] = cachedKeySet{keys: fetched, fetchedAt: time.Now()}
+			c.mu.Unlock()
+			keys = matchingKeys(fetched, kid)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return keys, nil
+}
+
+// matchingKeys returns the key with the kid, or all the keys for tokens without a kid
+func matchingKeys(keys []publicKey, kid string) []publicKey {
+	if len(kid) == 0 {
+		return keys
+	}
+	for _, k := range keys {
+		if k.kid == kid {
+			return []publicKey{k}
+		}
+	}
+	return nil
+}
+
+func fetchKeys(ctx context.Context, jwksUri string) ([]publicKey, error) {
+	jwks := sdk.Jwks{}
+	err := getJson(ctx, jwksUri, &jwks)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the signing keys %w", err)
+	}
+	var keys []publicKey
+	for _, jwk := range jwks.Keys {
+		if len(jwk.Use) > 0 && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseJwk(jwk)
+		if err != nil {
+			// keys of other types do not keep the supported ones from being used
+			log.Warnw("skipping a signing key of the oidc provider", "kid", jwk.Kid, "error", err)
+			continue
+		}
+		keys = append(keys, publicKey{kid: jwk.Kid, alg: jwk.Alg, key: key})
+	}
+	return keys, nil
+}
+
+// parseJwk reads the public key of an RSA, EC or Ed25519 json web key
+func parseJwk(jwk sdk.Jwk) (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if n.BitLen() < 2048 || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid rsa key")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		// ECDH checks that the point is on the curve
+		if _, err := key.ECDH(); err != nil {
+			return nil, fmt.Errorf("invalid ec key %w", err)
+		}
+		return key, nil
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", jwk.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func getJson(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Errorf("failed to close response body: %w", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(v)
+}
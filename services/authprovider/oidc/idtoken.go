@@ -0,0 +1,72 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	jwtp "github.com/golang-jwt/jwt/v4"
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// signingMethods are the algorithms accepted for the id tokens. Tokens signed with the client secret
+// or not signed at all are refused.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// idTokenClaims are the claims of the id token checked at login
+type idTokenClaims struct {
+	jwtp.RegisteredClaims
+	Nonce           string `json:"nonce,omitempty"`
+	AuthorizedParty string `json:"azp,omitempty"`
+}
+
+// verifyIdToken checks the signature of the id token with the keys of the provider, that it was issued
+// by the provider for the client and has not expired. A nonce sent with the login has to come back in it.
+func (o authProvider) verifyIdToken(ctx context.Context, rawIdToken, nonce string) (*idTokenClaims, error) {
+	cnf, err := cache.discover(ctx, o.issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &idTokenClaims{}
+	parser := jwtp.NewParser(jwtp.WithValidMethods(signingMethods))
+	_, err = parser.ParseWithClaims(rawIdToken, claims, func(token *jwtp.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		keys, err := cache.signingKeys(ctx, cnf.JwksUri, kid)
+		if err != nil {
+			return nil, err
+		}
+		// tokens without a kid are checked against the only key of the provider
+		if len(keys) > 1 {
+			return nil, fmt.Errorf("the id token has no kid and the provider has %d signing keys", len(keys))
+		}
+		if len(keys[0].alg) > 0 && keys[0].alg != token.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Method.Alg())
+		}
+		return keys[0].key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", sdk.ErrInvalidIdToken, err)
+	}
+
+	switch {
+	case claims.Issuer != cnf.Issuer:
+		err = fmt.Errorf("issued by %s", claims.Issuer)
+	case !claims.VerifyAudience(o.cnf.ClientID, true):
+		err = fmt.Errorf("not issued for the client")
+	case len(claims.AuthorizedParty) > 0 && claims.AuthorizedParty != o.cnf.ClientID:
+		err = fmt.Errorf("authorized party %s is not the client", claims.AuthorizedParty)
+	case claims.ExpiresAt == nil || claims.ExpiresAt.Before(time.Now()):
+		err = fmt.Errorf("expired")
+	case len(claims.Subject) == 0:
+		err = fmt.Errorf("no subject")
+	case len(nonce) > 0 && subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1:
+		err = errors.New("the nonce does not match the login")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", sdk.ErrInvalidIdToken, err)
+	}
+	return claims, nil
+}
@@ -0,0 +1,299 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	jwtp "github.com/golang-jwt/jwt/v4"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testIdp is an OIDC provider serving the discovery document, its signing keys and a token endpoint
+// returning the id token set by the test
+type testIdp struct {
+	*httptest.Server
+	mu        sync.Mutex
+	keys      map[string]crypto.Signer
+	idToken   string
+	jwksCalls int
+}
+
+func newTestIdp(t *testing.T) *testIdp {
+	idp := &testIdp{keys: map[string]crypto.Signer{}}
+	idp.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(sdk.OpenIdConfiguration{
+				Issuer:                idp.URL,
+				AuthorizationEndpoint: idp.URL + "/auth",
+				TokenEndpoint:         idp.URL + "/token",
+				UserinfoEndpoint:      idp.URL + "/userinfo",
+				JwksUri:               idp.URL + "/jwks",
+			})
+		case "/jwks":
+			idp.jwksCalls++
+			jwks := sdk.Jwks{}
+			for kid, key := range idp.keys {
+				jwks.Keys = append(jwks.Keys, testJwk(kid, key))
+			}
+			_ = json.NewEncoder(w).Encode(jwks)
+		case "/token":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": "access-token-123",
+				"token_type":   "Bearer",
+				"expires_in":   3600,
+				"id_token":     idp.idToken,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// addKey adds a signing key to the key set of the provider
+func (idp *testIdp) addKey(t *testing.T, kid string, ec bool) {
+	var key crypto.Signer
+	var err error
+	if ec {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	} else {
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	require.NoError(t, err)
+	idp.mu.Lock()
+	idp.keys[kid] = key
+	idp.mu.Unlock()
+}
+
+// sign returns an id token signed with the key of the kid, with the claims overriding the valid defaults
+func (idp *testIdp) sign(t *testing.T, kid string, overrides jwtp.MapClaims) string {
+	claims := jwtp.MapClaims{
+		"iss":   idp.URL,
+		"sub":   "user-123",
+		"aud":   "test-client-id",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": "test-nonce",
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+			continue
+		}
+		claims[k] = v
+	}
+	idp.mu.Lock()
+	key := idp.keys[kid]
+	idp.mu.Unlock()
+	method := jwtp.SigningMethod(jwtp.SigningMethodRS256)
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		method = jwtp.SigningMethodES256
+	}
+	token := jwtp.NewWithClaims(method, claims)
+	if len(kid) > 0 {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func (idp *testIdp) provider(t *testing.T) authProvider {
+	p, err := NewAuthProvider(context.Background(), mockAuthProvider(map[string]string{
+		"@OIDC/CLIENT_ID":     "test-client-id",
+		"@OIDC/CLIENT_SECRET": "test-client-secret",
+		"@OIDC/REDIRECT_URL":  "https://example.com/callback",
+		"@OIDC/ISSUER":        idp.URL,
+	}))
+	require.NoError(t, err)
+	return p.(authProvider)
+}
+
+func testJwk(kid string, key crypto.Signer) sdk.Jwk {
+	switch k := key.Public().(type) {
+	case *rsa.PublicKey:
+		return sdk.Jwk{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		return sdk.Jwk{
+			Kty: "EC",
+			Kid: kid,
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, 32))),
+			Y:   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, 32))),
+		}
+	}
+	return sdk.Jwk{}
+}
+
+func TestNewAuthProvider_Discovery(t *testing.T) {
+	t.Run("endpoints are discovered from the issuer", func(t *testing.T) {
+		idp := newTestIdp(t)
+		p := idp.provider(t)
+		assert.Equal(t, idp.URL+"/auth", p.cnf.Endpoint.AuthURL)
+		assert.Equal(t, idp.URL+"/token", p.cnf.Endpoint.TokenURL)
+		assert.Equal(t, idp.URL+"/userinfo", p.userInfoURL)
+		assert.Equal(t, idp.URL, p.issuer)
+	})
+
+	t.Run("configured endpoints override the discovered ones", func(t *testing.T) {
+		idp := newTestIdp(t)
+		p, err := NewAuthProvider(context.Background(), mockAuthProvider(map[string]string{
+			"@OIDC/CLIENT_ID":    "test-client-id",
+			"@OIDC/ISSUER":       idp.URL,
+			"@OIDC/USERINFO_URL": "https://userinfo.example.com",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, idp.URL+"/auth", p.(authProvider).cnf.Endpoint.AuthURL)
+		assert.Equal(t, "https://userinfo.example.com", p.(authProvider).userInfoURL)
+	})
+
+	t.Run("document of another issuer", func(t *testing.T) {
+		idp := newTestIdp(t)
+		_, err := NewAuthProvider(context.Background(), mockAuthProvider(map[string]string{
+			"@OIDC/ISSUER": idp.URL + "/tenant",
+		}))
+		assert.Error(t, err)
+	})
+}
+
+func TestAuthProvider_GetAuthCodeUrlWithNonce(t *testing.T) {
+	provider := createTestProvider()
+
+	authURL := provider.GetAuthCodeUrlWithNonce("test-state", "test-nonce")
+
+	parsedURL, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "test-state", parsedURL.Query().Get("state"))
+	assert.Equal(t, "test-nonce", parsedURL.Query().Get("nonce"))
+	assert.Equal(t, "openid profile email", parsedURL.Query().Get("scope"))
+}
+
+func TestAuthProvider_VerifyCodeWithNonce(t *testing.T) {
+	idp := newTestIdp(t)
+	idp.addKey(t, "key-1", false)
+	idp.addKey(t, "key-2", true)
+	p := idp.provider(t)
+
+	tests := []struct {
+		name    string
+		idToken func() string
+		nonce   string
+		wantErr bool
+	}{
+		{name: "rsa signed token", idToken: func() string { return idp.sign(t, "key-1", nil) }, nonce: "test-nonce"},
+		{name: "ec signed token", idToken: func() string { return idp.sign(t, "key-2", nil) }, nonce: "test-nonce"},
+		{name: "audience list with the client as authorized party", idToken: func() string {
+			return idp.sign(t, "key-1", jwtp.MapClaims{"aud": []string{"test-client-id", "api"}, "azp": "test-client-id"})
+		}, nonce: "test-nonce"},
+		{name: "no id token", idToken: func() string { return "" }, nonce: "test-nonce", wantErr: true},
+		{name: "wrong nonce", idToken: func() string { return idp.sign(t, "key-1", nil) }, nonce: "other-nonce", wantErr: true},
+		{name: "no nonce", idToken: func() string { return idp.sign(t, "key-1", jwtp.MapClaims{"nonce": nil}) }, nonce: "test-nonce", wantErr: true},
+		{name: "other issuer", idToken: func() string { return idp.sign(t, "key-1", jwtp.MapClaims{"iss": "https://evil.example.com"}) }, nonce: "test-nonce", wantErr: true},
+		{name: "other audience", idToken: func() string { return idp.sign(t, "key-1", jwtp.MapClaims{"aud": "other-client"}) }, nonce: "test-nonce", wantErr: true},
+		{name: "other authorized party", idToken: func() string {
+			return idp.sign(t, "key-1", jwtp.MapClaims{"aud": []string{"test-client-id", "other-client"}, "azp": "other-client"})
+		}, nonce: "test-nonce", wantErr: true},
+		{name: "expired", idToken: func() string {
+			return idp.sign(t, "key-1", jwtp.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})
+		}, nonce: "test-nonce", wantErr: true},
+		{name: "no expiry", idToken: func() string { return idp.sign(t, "key-1", jwtp.MapClaims{"exp": nil}) }, nonce: "test-nonce", wantErr: true},
+		{name: "tampered", idToken: func() string {
+			return idp.sign(t, "key-1", nil) + "x"
+		}, nonce: "test-nonce", wantErr: true},
+		{name: "unsigned", idToken: func() string {
+			token, err := jwtp.NewWithClaims(jwtp.SigningMethodNone, jwtp.MapClaims{
+				"iss": idp.URL, "sub": "user-123", "aud": "test-client-id", "exp": time.Now().Add(time.Hour).Unix(), "nonce": "test-nonce",
+			}).SignedString(jwtp.UnsafeAllowNoneSignatureType)
+			require.NoError(t, err)
+			return token
+		}, nonce: "test-nonce", wantErr: true},
+		{name: "signed with the client secret", idToken: func() string {
+			token, err := jwtp.NewWithClaims(jwtp.SigningMethodHS256, jwtp.MapClaims{
+				"iss": idp.URL, "sub": "user-123", "aud": "test-client-id", "exp": time.Now().Add(time.Hour).Unix(), "nonce": "test-nonce",
+			}).SignedString([]byte("test-client-secret"))
+			require.NoError(t, err)
+			return token
+		}, nonce: "test-nonce", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idToken := tt.idToken()
+			idp.mu.Lock()
+			idp.idToken = idToken
+			idp.mu.Unlock()
+
+			token, err := p.VerifyCodeWithNonce(context.Background(), "test-code", tt.nonce)
+			if tt.wantErr {
+				require.Error(t, err)
+				if len(idToken) > 0 {
+					assert.ErrorIs(t, err, sdk.ErrInvalidIdToken)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "access-token-123", token.AccessToken)
+		})
+	}
+}
+
+func TestVerifyIdToken_KeyRotation(t *testing.T) {
+	idp := newTestIdp(t)
+	idp.addKey(t, "key-1", false)
+	p := idp.provider(t)
+	ctx := context.Background()
+
+	_, err := p.verifyIdToken(ctx, idp.sign(t, "key-1", nil), "test-nonce")
+	require.NoError(t, err)
+	assert.Equal(t, 1, idp.jwksCalls)
+
+	// the cached keys are used until a token comes with an unknown kid
+	_, err = p.verifyIdToken(ctx, idp.sign(t, "key-1", nil), "test-nonce")
+	require.NoError(t, err)
+	assert.Equal(t, 1, idp.jwksCalls)
+
+	idp.addKey(t, "key-2", false)
+	t.Run("unknown kids are looked up again after the cooldown only", func(t *testing.T) {
+		_, err := p.verifyIdToken(ctx, idp.sign(t, "key-2", nil), "test-nonce")
+		assert.ErrorIs(t, err, sdk.ErrInvalidIdToken)
+		assert.Equal(t, 1, idp.jwksCalls)
+	})
+
+	t.Run("rotated keys are fetched", func(t *testing.T) {
+		cache.mu.Lock()
+		set := cache.keySets[idp.URL+"/jwks"]
+		set.fetchedAt = time.Now().Add(-keyRefetchCooldown)
+		cache.keySets[idp.URL+"/jwks"] = set
+		cache.mu.Unlock()
+
+		_, err := p.verifyIdToken(ctx, idp.sign(t, "key-2", nil), "test-nonce")
+		require.NoError(t, err)
+		assert.Equal(t, 2, idp.jwksCalls)
+	})
+}
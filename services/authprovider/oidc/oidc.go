@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2/log"
@@ -19,46 +21,74 @@ type authProvider struct {
 	cnf          oauth2.Config
 	userInfoURL  string
 	issuer       string
+	groupsClaim  string
 	providerName string
 }
 
+// defaultScopes are requested when the provider has no scopes configured
+var defaultScopes = []string{"openid", "profile", "email"}
+
 // NewAuthProvider creates a new generic OIDC provider instance
-// Required parameters in the AuthProvider configuration:
+// Parameters in the AuthProvider configuration:
 // - @OIDC/CLIENT_ID: OAuth2 client ID
 // - @OIDC/CLIENT_SECRET: OAuth2 client secret
 // - @OIDC/REDIRECT_URL: OAuth2 redirect URL
-// - @OIDC/AUTHORIZATION_URL: OIDC authorization endpoint
-// - @OIDC/TOKEN_URL: OIDC token endpoint
-// - @OIDC/USERINFO_URL: OIDC userinfo endpoint
+// - @OIDC/ISSUER: Issuer of the provider, the endpoints and the signing keys of the id tokens are discovered from it
+// - @OIDC/AUTHORIZATION_URL: OIDC authorization endpoint (optional with an issuer)
+// - @OIDC/TOKEN_URL: OIDC token endpoint (optional with an issuer)
+// - @OIDC/USERINFO_URL: OIDC userinfo endpoint (optional with an issuer)
 // - @OIDC/SCOPES: Space-separated list of OAuth2 scopes (optional, defaults to "openid profile email")
-func NewAuthProvider(p sdk.AuthProvider) sdk.ServiceProvider {
-	// Get configuration parameters
-	clientID := p.GetParam("@OIDC/CLIENT_ID")
-	clientSecret := p.GetParam("@OIDC/CLIENT_SECRET")
-	redirectURL := p.GetParam("@OIDC/REDIRECT_URL")
-	authURL := p.GetParam("@OIDC/AUTHORIZATION_URL")
-	tokenURL := p.GetParam("@OIDC/TOKEN_URL")
-	userInfoURL := p.GetParam("@OIDC/USERINFO_URL")
-
-	// Default scopes if not specified
-	scopes := []string{"openid", "profile", "email"}
-
-	oauthConfig := oauth2.Config{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		RedirectURL:  redirectURL,
-		Scopes:       scopes,
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  authURL,
-			TokenURL: tokenURL,
+// - @OIDC/GROUPS_CLAIM: Dot separated path of the groups claim of the userinfo (optional)
+//
+// The id tokens are validated when the issuer is configured. The discovery document is only fetched
+// here when an endpoint is not configured.
+func NewAuthProvider(ctx context.Context, p sdk.AuthProvider) (sdk.ServiceProvider, error) {
+	a := authProvider{
+		cnf: oauth2.Config{
+			ClientID:     p.GetParam(sdk.OidcParamClientId),
+			ClientSecret: p.GetParam(sdk.OidcParamClientSecret),
+			RedirectURL:  p.GetParam(sdk.OidcParamRedirectUrl),
+			Scopes:       scopes(p.GetParam(sdk.OidcParamScopes)),
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  p.GetParam(sdk.OidcParamAuthorizationUrl),
+				TokenURL: p.GetParam(sdk.OidcParamTokenUrl),
+			},
 		},
+		userInfoURL:  p.GetParam(sdk.OidcParamUserinfoUrl),
+		issuer:       p.GetParam(sdk.OidcParamIssuer),
+		groupsClaim:  p.GetParam(sdk.OidcParamGroupsClaim),
+		providerName: p.Name,
+	}
+	if len(a.issuer) == 0 || (len(a.cnf.Endpoint.AuthURL) > 0 && len(a.cnf.Endpoint.TokenURL) > 0 && len(a.userInfoURL) > 0) {
+		return a, nil
 	}
 
-	return authProvider{
-		cnf:          oauthConfig,
-		userInfoURL:  userInfoURL,
-		providerName: p.Name,
+	cnf, err := cache.discover(ctx, a.issuer)
+	if err != nil {
+		return nil, fmt.Errorf("error discovering the OIDC provider %s: %w", p.Name, err)
 	}
+	if len(a.cnf.Endpoint.AuthURL) == 0 {
+		a.cnf.Endpoint.AuthURL = cnf.AuthorizationEndpoint
+	}
+	if len(a.cnf.Endpoint.TokenURL) == 0 {
+		a.cnf.Endpoint.TokenURL = cnf.TokenEndpoint
+	}
+	if len(a.userInfoURL) == 0 {
+		a.userInfoURL = cnf.UserinfoEndpoint
+	}
+	return a, nil
+}
+
+// scopes reads the configured scopes, openid is always asked for to get an id token
+func scopes(param string) []string {
+	configured := strings.Fields(param)
+	if len(configured) == 0 {
+		return defaultScopes
+	}
+	if !slices.Contains(configured, "openid") {
+		configured = append([]string{"openid"}, configured...)
+	}
+	return configured
 }
 
 // HasRefreshTokenFlow indicates that OIDC providers typically support refresh tokens
@@ -71,13 +101,35 @@ func (o authProvider) GetAuthCodeUrl(state string) string {
 	return o.cnf.AuthCodeURL(state, oauth2.AccessTypeOffline)
 }
 
+// GetAuthCodeUrlWithNonce returns the authorization URL asking for an id token with the nonce of the login
+func (o authProvider) GetAuthCodeUrlWithNonce(state, nonce string) string {
+	return o.cnf.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.SetAuthURLParam("nonce", nonce))
+}
+
 // VerifyCode exchanges an authorization code for access and refresh tokens
 func (o authProvider) VerifyCode(ctx context.Context, code string) (*sdk.AuthToken, error) {
+	return o.VerifyCodeWithNonce(ctx, code, "")
+}
+
+// VerifyCodeWithNonce exchanges an authorization code for access and refresh tokens.
+// With an issuer configured, the id token returned along has to be valid and carry the nonce.
+func (o authProvider) VerifyCodeWithNonce(ctx context.Context, code, nonce string) (*sdk.AuthToken, error) {
 	token, err := o.cnf.Exchange(ctx, code)
 	if err != nil {
 		return nil, fmt.Errorf("error verifying code with OIDC provider %s: %w", o.providerName, err)
 	}
 
+	if len(o.issuer) > 0 {
+		rawIdToken, _ := token.Extra("id_token").(string)
+		if len(rawIdToken) == 0 {
+			return nil, fmt.Errorf("%w: OIDC provider %s returned no id token", sdk.ErrInvalidIdToken, o.providerName)
+		}
+		_, err = o.verifyIdToken(ctx, rawIdToken, nonce)
+		if err != nil {
+			return nil, fmt.Errorf("error verifying the id token of OIDC provider %s: %w", o.providerName, err)
+		}
+	}
+
 	return &sdk.AuthToken{
 		AccessToken:  token.AccessToken,
 		RefreshToken: token.RefreshToken,
@@ -140,11 +192,13 @@ func (o authProvider) RefreshToken(refreshToken string) (*sdk.AuthToken, error)
 
 // OIDCIdentityEmail handles email identity information
 type OIDCIdentityEmail struct {
-	Email string `json:"email"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
 func (o OIDCIdentityEmail) UpdateUserDetails(user *sdk.User) {
 	user.Email = o.Email
+	user.EmailVerified = o.EmailVerified
 }
 
 // OIDCIdentityName handles name identity information
@@ -180,6 +234,9 @@ type UserInfoResponse struct {
 
 // GetIdentity retrieves user identity information using an access token
 func (o authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
+	if len(o.userInfoURL) == 0 {
+		return nil, fmt.Errorf("OIDC provider %s has no userinfo endpoint", o.providerName)
+	}
 	req, err := http.NewRequest("GET", o.userInfoURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating userinfo request: %w", err)
@@ -215,11 +272,19 @@ func (o authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
 
 	var identities []sdk.AuthIdentity
 
+	// The subject identifies the user at the provider
+	if userInfo.Sub != "" {
+		identities = append(identities, sdk.AuthIdentity{
+			Type:     sdk.AuthIdentityTypeSubject,
+			Metadata: sdk.AuthIdentitySubject{Subject: userInfo.Sub},
+		})
+	}
+
 	// Add email identity if available
 	if userInfo.Email != "" {
 		identities = append(identities, sdk.AuthIdentity{
 			Type:     sdk.AuthIdentityTypeEmail,
-			Metadata: OIDCIdentityEmail{Email: userInfo.Email},
+			Metadata: OIDCIdentityEmail{Email: userInfo.Email, EmailVerified: userInfo.EmailVerified},
 		})
 	}
 
@@ -250,5 +315,51 @@ func (o authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
 		})
 	}
 
+	if len(o.groupsClaim) > 0 {
+		groups, err := readGroups(respBytes, o.groupsClaim)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the groups of the userinfo of OIDC provider %s: %w", o.providerName, err)
+		}
+		identities = append(identities, sdk.AuthIdentity{
+			Type:     sdk.AuthIdentityTypeGroups,
+			Metadata: sdk.AuthIdentityGroups{Groups: groups},
+		})
+	}
+
 	return identities, nil
 }
+
+// readGroups reads the groups claim at the dot separated path of the userinfo. The claim is a list of
+// names or a single one, a missing claim means the user is in no group.
+func readGroups(userInfo []byte, claim string) ([]string, error) {
+	var doc interface{}
+	err := json.Unmarshal(userInfo, &doc)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range strings.Split(claim, ".") {
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return []string{}, nil
+		}
+		doc = obj[key]
+	}
+	switch v := doc.(type) {
+	case nil:
+		return []string{}, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		groups := make([]string, 0, len(v))
+		for _, g := range v {
+			name, ok := g.(string)
+			if !ok {
+				return nil, fmt.Errorf("the claim %s has to list the groups by name", claim)
+			}
+			groups = append(groups, name)
+		}
+		return groups, nil
+	default:
+		return nil, fmt.Errorf("the claim %s has to list the groups by name", claim)
+	}
+}
@@ -37,12 +37,28 @@ func TestNewAuthProvider(t *testing.T) {
 				providerName: "Test Provider",
 			},
 		},
+		{
+			name: "creates provider with configured scopes",
+			provider: mockAuthProvider(map[string]string{
+				"@OIDC/CLIENT_ID":         "test-client-id",
+				"@OIDC/CLIENT_SECRET":     "test-client-secret",
+				"@OIDC/REDIRECT_URL":      "https://example.com/callback",
+				"@OIDC/AUTHORIZATION_URL": "https://provider.example.com/auth",
+				"@OIDC/TOKEN_URL":         "https://provider.example.com/token",
+				"@OIDC/USERINFO_URL":      "https://provider.example.com/userinfo",
+				"@OIDC/SCOPES":            "profile email custom_scope",
+			}),
+			expected: authProvider{
+				userInfoURL:  "https://provider.example.com/userinfo",
+				providerName: "Test Provider",
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			provider := NewAuthProvider(tt.provider)
-			assert.NotNil(t, provider)
+			provider, err := NewAuthProvider(context.Background(), tt.provider)
+			require.NoError(t, err)
 
 			oidcProvider, ok := provider.(authProvider)
 			require.True(t, ok)
@@ -52,6 +68,8 @@ func TestNewAuthProvider(t *testing.T) {
 			assert.Equal(t, "https://example.com/callback", oidcProvider.cnf.RedirectURL)
 			assert.Equal(t, "https://provider.example.com/auth", oidcProvider.cnf.Endpoint.AuthURL)
 			assert.Equal(t, "https://provider.example.com/token", oidcProvider.cnf.Endpoint.TokenURL)
+			assert.Equal(t, tt.expected.userInfoURL, oidcProvider.userInfoURL)
+			assert.Equal(t, tt.expected.issuer, oidcProvider.issuer)
 
 			if tt.provider.GetParam("@OIDC/SCOPES") != "" {
 				assert.Equal(t, []string{"openid", "profile", "email", "custom_scope"}, oidcProvider.cnf.Scopes)
@@ -177,30 +195,71 @@ func TestAuthProvider_GetIdentity(t *testing.T) {
 	identities, err := provider.GetIdentity("test-access-token")
 
 	require.NoError(t, err)
-	assert.Len(t, identities, 3)
+	assert.Len(t, identities, 4)
+
+	// Check subject identity
+	subjectIdentity := identities[0]
+	assert.Equal(t, sdk.AuthIdentityTypeSubject, subjectIdentity.Type)
+	assert.Equal(t, sdk.AuthIdentitySubject{Subject: "user-123"}, subjectIdentity.Metadata)
 
 	// Check email identity
-	emailIdentity := identities[0]
+	emailIdentity := identities[1]
 	assert.Equal(t, sdk.AuthIdentityTypeEmail, emailIdentity.Type)
 	emailMeta, ok := emailIdentity.Metadata.(OIDCIdentityEmail)
 	require.True(t, ok)
 	assert.Equal(t, "john.doe@example.com", emailMeta.Email)
 
 	// Check name identity
-	nameIdentity := identities[1]
+	nameIdentity := identities[2]
 	assert.Equal(t, sdk.AuthIdentityTypeEmail, nameIdentity.Type)
 	nameMeta, ok := nameIdentity.Metadata.(OIDCIdentityName)
 	require.True(t, ok)
 	assert.Equal(t, "John Doe", nameMeta.Name)
 
 	// Check profile picture identity
-	picIdentity := identities[2]
+	picIdentity := identities[3]
 	assert.Equal(t, sdk.AuthIdentityTypeEmail, picIdentity.Type)
 	picMeta, ok := picIdentity.Metadata.(OIDCIdentityProfilePic)
 	require.True(t, ok)
 	assert.Equal(t, "https://example.com/avatar.jpg", picMeta.ProfilePic)
 }
 
+func TestAuthProvider_GetIdentityGroups(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"sub":"user-123","groups":["Admins","Ops"],"realm_access":{"roles":"admin"}}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		claim    string
+		expected []string
+	}{
+		{name: "list of groups", claim: "groups", expected: []string{"Admins", "Ops"}},
+		{name: "nested single group", claim: "realm_access.roles", expected: []string{"admin"}},
+		{name: "missing claim", claim: "roles", expected: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := createTestProviderWithUserInfoServer(server.URL)
+			provider.groupsClaim = tt.claim
+
+			identities, err := provider.GetIdentity("test-access-token")
+			require.NoError(t, err)
+			assert.Contains(t, identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeGroups, Metadata: sdk.AuthIdentityGroups{Groups: tt.expected}})
+		})
+	}
+
+	t.Run("groups are not reported without a claim", func(t *testing.T) {
+		identities, err := createTestProviderWithUserInfoServer(server.URL).GetIdentity("test-access-token")
+		require.NoError(t, err)
+		for _, id := range identities {
+			assert.NotEqual(t, sdk.AuthIdentityTypeGroups, id.Type)
+		}
+	})
+}
+
 func TestIdentityTypes_UpdateUserDetails(t *testing.T) {
 	user := &sdk.User{}
 
@@ -247,7 +306,11 @@ func createTestProvider() authProvider {
 		"@OIDC/TOKEN_URL":         "https://provider.example.com/token",
 		"@OIDC/USERINFO_URL":      "https://provider.example.com/userinfo",
 	})
-	return NewAuthProvider(provider).(authProvider)
+	p, err := NewAuthProvider(context.Background(), provider)
+	if err != nil {
+		panic(err)
+	}
+	return p.(authProvider)
 }
 
 func createTestProviderWithServer(serverURL string) authProvider {
@@ -259,7 +322,11 @@ func createTestProviderWithServer(serverURL string) authProvider {
 		"@OIDC/TOKEN_URL":         serverURL + "/token",
 		"@OIDC/USERINFO_URL":      serverURL + "/userinfo",
 	})
-	return NewAuthProvider(provider).(authProvider)
+	p, err := NewAuthProvider(context.Background(), provider)
+	if err != nil {
+		panic(err)
+	}
+	return p.(authProvider)
 }
 
 func createTestProviderWithUserInfoServer(serverURL string) authProvider {
@@ -271,5 +338,9 @@ func createTestProviderWithUserInfoServer(serverURL string) authProvider {
 		"@OIDC/TOKEN_URL":         "https://provider.example.com/token",
 		"@OIDC/USERINFO_URL":      serverURL + "/userinfo",
 	})
-	return NewAuthProvider(provider).(authProvider)
+	p, err := NewAuthProvider(context.Background(), provider)
+	if err != nil {
+		panic(err)
+	}
+	return p.(authProvider)
 }
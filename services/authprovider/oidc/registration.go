@@ -0,0 +1,42 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/authprovider/registry"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		AuthProviderTypeInfo: sdk.AuthProviderTypeInfo{
+			Type: sdk.AuthProviderTypeOIDC,
+			Name: "OpenID Connect",
+			Icon: "openid",
+			Params: []sdk.AuthProviderParamSpec{
+				{Key: sdk.OidcParamClientId, Label: "Client ID", Description: "Client id of go-iam at the provider", Required: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.OidcParamClientSecret, Label: "Client secret", Description: "Client secret of go-iam at the provider", Required: true, IsSecret: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.OidcParamRedirectUrl, Label: "Redirect URL", Description: "Callback of go-iam registered with the provider", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.OidcParamIssuer, Label: "Issuer", Description: "Issuer of the provider. The endpoints and the signing keys of the id tokens are discovered from it", Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.OidcParamAuthorizationUrl, Label: "Authorization URL", Description: "Authorization endpoint, the discovered one when empty", Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.OidcParamTokenUrl, Label: "Token URL", Description: "Token endpoint, the discovered one when empty", Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.OidcParamUserinfoUrl, Label: "Userinfo URL", Description: "Userinfo endpoint, the discovered one when empty", Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.OidcParamScopes, Label: "Scopes", Description: "Space separated scopes, openid profile email when empty", Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.OidcParamGroupsClaim, Label: "Groups claim", Description: "Claim of the userinfo listing the groups of the user, for the group roles. The groups are not synced when empty", Format: sdk.AuthProviderParamFormatText},
+			},
+		},
+		New: func(ctx context.Context, p sdk.AuthProvider, _ registry.Dependencies) (sdk.ServiceProvider, error) {
+			return NewAuthProvider(ctx, p)
+		},
+		Validate: func(p sdk.AuthProvider) error {
+			if len(p.GetParam(sdk.OidcParamIssuer)) > 0 {
+				return nil
+			}
+			if len(p.GetParam(sdk.OidcParamAuthorizationUrl)) == 0 || len(p.GetParam(sdk.OidcParamTokenUrl)) == 0 || len(p.GetParam(sdk.OidcParamUserinfoUrl)) == 0 {
+				return errors.New("the issuer or the authorization, token and userinfo urls are required")
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,107 @@
+package passkey
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// CodeService is the part of the passkey service the provider needs to resolve the logins
+type CodeService interface {
+	ExchangeCode(ctx context.Context, code string) (string, error)
+}
+
+// authProvider implements the SDK ServiceProvider interface for the passkey login.
+// The login page runs the WebAuthn ceremony against go-iam, after which go-iam continues
+// the code flow with a single use code. The email address of the owner of the passkey,
+// or their phone number when they have no email address, acts as the access token of the provider.
+type authProvider struct {
+	loginUrl string
+	codes    CodeService
+}
+
+// NewAuthProvider creates a new passkey provider instance
+// Parameters in the AuthProvider configuration:
+// - @PASSKEY/LOGIN_URL: Login page receiving the state of the login
+//
+// The relying party of the passkeys is configured on the project.
+func NewAuthProvider(p sdk.AuthProvider, codes CodeService) sdk.ServiceProvider {
+	return authProvider{
+		loginUrl: p.GetParam(sdk.PasskeyParamLoginUrl),
+		codes:    codes,
+	}
+}
+
+// HasRefreshTokenFlow returns false, the verified address never expires on the provider side
+func (a authProvider) HasRefreshTokenFlow() bool {
+	return false
+}
+
+// GetAuthCodeUrl returns the login page with the state in its query
+func (a authProvider) GetAuthCodeUrl(state string) string {
+	u, err := url.Parse(a.loginUrl)
+	if err != nil {
+		return a.loginUrl
+	}
+	q := u.Query()
+	q.Set("state", state)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// VerifyCode exchanges the single use code issued on a passkey login for the address of the user
+func (a authProvider) VerifyCode(ctx context.Context, code string) (*sdk.AuthToken, error) {
+	address, err := a.codes.ExchangeCode(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying the passkey login code. %w", err)
+	}
+	return &sdk.AuthToken{
+		AccessToken: address,
+		ExpiresAt:   time.Now().Add(time.Hour * 24),
+	}, nil
+}
+
+// RefreshToken is not supported by the passkey provider
+func (a authProvider) RefreshToken(refreshToken string) (*sdk.AuthToken, error) {
+	return nil, fmt.Errorf("refresh token flow is not supported by the passkey provider")
+}
+
+// PasskeyIdentityEmail handles email identity information
+type PasskeyIdentityEmail struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+func (p PasskeyIdentityEmail) UpdateUserDetails(user *sdk.User) {
+	user.Email = p.Email
+	user.EmailVerified = p.EmailVerified
+}
+
+// PasskeyIdentityPhone handles phone identity information
+type PasskeyIdentityPhone struct {
+	Phone string `json:"phone"`
+}
+
+func (p PasskeyIdentityPhone) UpdateUserDetails(user *sdk.User) {
+	user.Phone = p.Phone
+}
+
+// GetIdentity returns the email address or the phone number of the owner of the passkey.
+// The address is the one of the account the passkey was registered to.
+func (a authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
+	if len(token) == 0 {
+		return nil, fmt.Errorf("empty passkey access token")
+	}
+	if strings.Contains(token, "@") {
+		return []sdk.AuthIdentity{
+			{Type: sdk.AuthIdentityTypeEmail, Metadata: PasskeyIdentityEmail{Email: token, EmailVerified: true}},
+		}, nil
+	}
+	return []sdk.AuthIdentity{
+		{Type: sdk.AuthIdentityTypePhone, Metadata: PasskeyIdentityPhone{Phone: token}},
+	}, nil
+}
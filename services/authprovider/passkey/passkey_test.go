@@ -0,0 +1,71 @@
+package passkey
+
+import (
+	"context"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCodes resolves the codes to the address of the owner of the passkey
+type fakeCodes map[string]string
+
+func (f fakeCodes) ExchangeCode(ctx context.Context, code string) (string, error) {
+	address, ok := f[code]
+	if !ok {
+		return "", sdk.ErrInvalidPasskeyChallenge
+	}
+	return address, nil
+}
+
+func createPasskeyProvider(loginUrl string) sdk.ServiceProvider {
+	p := sdk.AuthProvider{
+		Id:       "passkey-test-id",
+		Provider: sdk.AuthProviderTypePasskey,
+		Params:   []sdk.AuthProviderParam{{Key: "@PASSKEY/LOGIN_URL", Value: loginUrl}},
+	}
+	return NewAuthProvider(p, fakeCodes{"code-email": "user@example.com", "code-phone": "+919876543210"})
+}
+
+func TestGetAuthCodeUrl(t *testing.T) {
+	provider := createPasskeyProvider("https://app.example.com/passkey-login")
+
+	assert.Equal(t, "https://app.example.com/passkey-login?state=state-1", provider.GetAuthCodeUrl("state-1"))
+	assert.False(t, provider.HasRefreshTokenFlow())
+}
+
+func TestVerifyCodeAndGetIdentity(t *testing.T) {
+	provider := createPasskeyProvider("https://app.example.com/passkey-login")
+
+	t.Run("email", func(t *testing.T) {
+		token, err := provider.VerifyCode(context.Background(), "code-email")
+		require.NoError(t, err)
+		identities, err := provider.GetIdentity(token.AccessToken)
+		require.NoError(t, err)
+		require.Len(t, identities, 1)
+		assert.Equal(t, sdk.AuthIdentityTypeEmail, identities[0].Type)
+		user := &sdk.User{}
+		identities[0].UpdateUserDetails(user)
+		assert.Equal(t, "user@example.com", user.Email)
+	})
+
+	t.Run("phone", func(t *testing.T) {
+		token, err := provider.VerifyCode(context.Background(), "code-phone")
+		require.NoError(t, err)
+		identities, err := provider.GetIdentity(token.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, sdk.AuthIdentityTypePhone, identities[0].Type)
+		user := &sdk.User{}
+		identities[0].UpdateUserDetails(user)
+		assert.Equal(t, "+919876543210", user.Phone)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := provider.VerifyCode(context.Background(), "wrong-code")
+		assert.ErrorIs(t, err, sdk.ErrInvalidPasskeyChallenge)
+		_, err = provider.GetIdentity("")
+		assert.Error(t, err)
+	})
+}
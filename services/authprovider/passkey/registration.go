@@ -0,0 +1,25 @@
+package passkey
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/authprovider/registry"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		AuthProviderTypeInfo: sdk.AuthProviderTypeInfo{
+			Type: sdk.AuthProviderTypePasskey,
+			Name: "Passkey",
+			Icon: "passkey",
+			Params: []sdk.AuthProviderParamSpec{
+				{Key: sdk.PasskeyParamLoginUrl, Label: "Login page", Description: "Page running the passkey ceremony, it receives the state of the login in the query", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+			},
+		},
+		New: func(_ context.Context, p sdk.AuthProvider, deps registry.Dependencies) (sdk.ServiceProvider, error) {
+			passkeys, _ := deps.Passkeys.(CodeService)
+			return NewAuthProvider(p, passkeys), nil
+		},
+	})
+}
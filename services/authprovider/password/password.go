@@ -0,0 +1,106 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// CredentialService is the part of the password service the provider needs to resolve the logins
+type CredentialService interface {
+	ExchangeCode(ctx context.Context, code string) (*sdk.PasswordCredential, error)
+	Get(ctx context.Context, id string) (*sdk.PasswordCredential, error)
+}
+
+// authProvider implements the SDK ServiceProvider interface for the built in password login.
+// The login page posts the credentials to go-iam, which continues the code flow with
+// a single use code. The id of the credential acts as the access token of the provider.
+type authProvider struct {
+	loginUrl    string
+	credentials CredentialService
+}
+
+// NewAuthProvider creates a new password provider instance
+// Parameters in the AuthProvider configuration:
+// - @PASSWORD/LOGIN_URL: Login page receiving the state of the login
+// - @PASSWORD/SIGNUP_ENABLED: "true" allows new users to sign up (optional)
+// - @PASSWORD/VERIFY_URL: Page of the email verification links, required for signups
+// - @PASSWORD/RESET_URL: Page of the password reset links
+func NewAuthProvider(p sdk.AuthProvider, credentials CredentialService) sdk.ServiceProvider {
+	return authProvider{
+		loginUrl:    p.GetParam(sdk.PasswordParamLoginUrl),
+		credentials: credentials,
+	}
+}
+
+// HasRefreshTokenFlow returns false, the credential never expires on the provider side
+func (a authProvider) HasRefreshTokenFlow() bool {
+	return false
+}
+
+// GetAuthCodeUrl returns the login page with the state in its query
+func (a authProvider) GetAuthCodeUrl(state string) string {
+	u, err := url.Parse(a.loginUrl)
+	if err != nil {
+		return a.loginUrl
+	}
+	q := u.Query()
+	q.Set("state", state)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// VerifyCode exchanges the single use code issued at login for the credential
+func (a authProvider) VerifyCode(ctx context.Context, code string) (*sdk.AuthToken, error) {
+	credential, err := a.credentials.ExchangeCode(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying the password login code. %w", err)
+	}
+	return &sdk.AuthToken{
+		AccessToken: credential.Id,
+		ExpiresAt:   time.Now().Add(time.Hour * 24),
+	}, nil
+}
+
+// RefreshToken is not supported by the password provider
+func (a authProvider) RefreshToken(refreshToken string) (*sdk.AuthToken, error) {
+	return nil, fmt.Errorf("refresh token flow is not supported by the password provider")
+}
+
+// PasswordIdentityEmail handles email identity information
+type PasswordIdentityEmail struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+func (p PasswordIdentityEmail) UpdateUserDetails(user *sdk.User) {
+	user.Email = p.Email
+	user.EmailVerified = p.EmailVerified
+}
+
+// PasswordIdentityName handles name identity information
+type PasswordIdentityName struct {
+	Name string `json:"name"`
+}
+
+func (p PasswordIdentityName) UpdateUserDetails(user *sdk.User) {
+	user.Name = p.Name
+}
+
+// GetIdentity returns the email and the name of the credential
+func (a authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
+	credential, err := a.credentials.Get(context.Background(), token)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the password credential. %w", err)
+	}
+	identities := []sdk.AuthIdentity{
+		{Type: sdk.AuthIdentityTypeEmail, Metadata: PasswordIdentityEmail{Email: credential.Email, EmailVerified: credential.VerifiedAt != nil}},
+	}
+	if len(credential.Name) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeEmail, Metadata: PasswordIdentityName{Name: credential.Name}})
+	}
+	return identities, nil
+}
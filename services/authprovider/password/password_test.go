@@ -0,0 +1,68 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCredentials resolves a single credential through the code "code-1"
+type fakeCredentials struct {
+	credential *sdk.PasswordCredential
+}
+
+func (f fakeCredentials) ExchangeCode(ctx context.Context, code string) (*sdk.PasswordCredential, error) {
+	if code != "code-1" {
+		return nil, sdk.ErrInvalidCredentials
+	}
+	return f.credential, nil
+}
+
+func (f fakeCredentials) Get(ctx context.Context, id string) (*sdk.PasswordCredential, error) {
+	if id != f.credential.Id {
+		return nil, errors.New("credential not found")
+	}
+	return f.credential, nil
+}
+
+func createPasswordProvider(loginUrl string) sdk.ServiceProvider {
+	p := sdk.AuthProvider{
+		Id:       "password-test-id",
+		Provider: sdk.AuthProviderTypePassword,
+		Params:   []sdk.AuthProviderParam{{Key: "@PASSWORD/LOGIN_URL", Value: loginUrl}},
+	}
+	return NewAuthProvider(p, fakeCredentials{credential: &sdk.PasswordCredential{Id: "cred-1", Email: "user@example.com", Name: "Test User"}})
+}
+
+func TestGetAuthCodeUrl(t *testing.T) {
+	provider := createPasswordProvider("https://app.example.com/login?lang=en")
+
+	assert.Equal(t, "https://app.example.com/login?lang=en&state=state+1", provider.GetAuthCodeUrl("state 1"))
+	assert.False(t, provider.HasRefreshTokenFlow())
+}
+
+func TestVerifyCodeAndGetIdentity(t *testing.T) {
+	provider := createPasswordProvider("https://app.example.com/login")
+
+	token, err := provider.VerifyCode(context.Background(), "code-1")
+	require.NoError(t, err)
+	assert.Equal(t, "cred-1", token.AccessToken)
+
+	identities, err := provider.GetIdentity(token.AccessToken)
+	require.NoError(t, err)
+	user := &sdk.User{}
+	for _, id := range identities {
+		id.UpdateUserDetails(user)
+	}
+	assert.Equal(t, "user@example.com", user.Email)
+	assert.Equal(t, "Test User", user.Name)
+
+	_, err = provider.VerifyCode(context.Background(), "unknown")
+	assert.ErrorIs(t, err, sdk.ErrInvalidCredentials)
+	_, err = provider.RefreshToken("refresh")
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package password
+
+import (
+	"context"
+	"errors"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/authprovider/registry"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		AuthProviderTypeInfo: sdk.AuthProviderTypeInfo{
+			Type: sdk.AuthProviderTypePassword,
+			Name: "Email and password",
+			Icon: "password",
+			Params: []sdk.AuthProviderParamSpec{
+				{Key: sdk.PasswordParamLoginUrl, Label: "Login page", Description: "Page posting the credentials, it receives the state of the login in the query", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.PasswordParamSignupEnabled, Label: "Sign up", Description: "Whether new users can sign up", Format: sdk.AuthProviderParamFormatBoolean},
+				{Key: sdk.PasswordParamVerifyUrl, Label: "Email verification page", Description: "Page opened from the email verification links, required when users can sign up", Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.PasswordParamResetUrl, Label: "Password reset page", Description: "Page opened from the password reset links", Format: sdk.AuthProviderParamFormatUrl},
+			},
+		},
+		New: func(_ context.Context, p sdk.AuthProvider, deps registry.Dependencies) (sdk.ServiceProvider, error) {
+			credentials, _ := deps.Credentials.(CredentialService)
+			return NewAuthProvider(p, credentials), nil
+		},
+		Validate: func(p sdk.AuthProvider) error {
+			if p.GetParam(sdk.PasswordParamSignupEnabled) == "true" && len(p.GetParam(sdk.PasswordParamVerifyUrl)) == 0 {
+				return errors.New("the email verification page is required when users can sign up")
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,93 @@
+package passwordless
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// CodeService is the part of the passwordless service the provider needs to resolve the logins
+type CodeService interface {
+	ExchangeCode(ctx context.Context, code string) (string, error)
+}
+
+// authProvider implements the SDK ServiceProvider interface for the built in passwordless login.
+// The user proves they own the email address with an emailed code or magic link, after which
+// go-iam continues the code flow with a single use code. The verified email address acts as
+// the access token of the provider.
+type authProvider struct {
+	loginUrl string
+	codes    CodeService
+}
+
+// NewAuthProvider creates a new passwordless provider instance
+// Parameters in the AuthProvider configuration:
+// - @PASSWORDLESS/LOGIN_URL: Login page receiving the state of the login
+// - @PASSWORDLESS/METHOD: "code" (default) or "link"
+// - @PASSWORDLESS/LINK_URL: Page of the magic links, required for the link method
+// - @PASSWORDLESS/EMAIL_SUBJECT: Subject of the login email (optional)
+// - @PASSWORDLESS/EMAIL_TEMPLATE: Body template of the login email (optional)
+func NewAuthProvider(p sdk.AuthProvider, codes CodeService) sdk.ServiceProvider {
+	return authProvider{
+		loginUrl: p.GetParam(sdk.PasswordlessParamLoginUrl),
+		codes:    codes,
+	}
+}
+
+// HasRefreshTokenFlow returns false, the verified email address never expires on the provider side
+func (a authProvider) HasRefreshTokenFlow() bool {
+	return false
+}
+
+// GetAuthCodeUrl returns the login page with the state in its query
+func (a authProvider) GetAuthCodeUrl(state string) string {
+	u, err := url.Parse(a.loginUrl)
+	if err != nil {
+		return a.loginUrl
+	}
+	q := u.Query()
+	q.Set("state", state)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// VerifyCode exchanges the single use code issued on verification for the email address
+func (a authProvider) VerifyCode(ctx context.Context, code string) (*sdk.AuthToken, error) {
+	email, err := a.codes.ExchangeCode(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying the passwordless login code. %w", err)
+	}
+	return &sdk.AuthToken{
+		AccessToken: email,
+		ExpiresAt:   time.Now().Add(time.Hour * 24),
+	}, nil
+}
+
+// RefreshToken is not supported by the passwordless provider
+func (a authProvider) RefreshToken(refreshToken string) (*sdk.AuthToken, error) {
+	return nil, fmt.Errorf("refresh token flow is not supported by the passwordless provider")
+}
+
+// PasswordlessIdentityEmail handles email identity information
+type PasswordlessIdentityEmail struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+func (p PasswordlessIdentityEmail) UpdateUserDetails(user *sdk.User) {
+	user.Email = p.Email
+	user.EmailVerified = p.EmailVerified
+}
+
+// GetIdentity returns the verified email address
+func (a authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
+	if len(token) == 0 {
+		return nil, fmt.Errorf("empty passwordless access token")
+	}
+	return []sdk.AuthIdentity{
+		{Type: sdk.AuthIdentityTypeEmail, Metadata: PasswordlessIdentityEmail{Email: token, EmailVerified: true}},
+	}, nil
+}
@@ -0,0 +1,57 @@
+package passwordless
+
+import (
+	"context"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCodes resolves the code "code-1" to a single email address
+type fakeCodes struct{}
+
+func (f fakeCodes) ExchangeCode(ctx context.Context, code string) (string, error) {
+	if code != "code-1" {
+		return "", sdk.ErrInvalidOtp
+	}
+	return "user@example.com", nil
+}
+
+func createPasswordlessProvider(loginUrl string) sdk.ServiceProvider {
+	p := sdk.AuthProvider{
+		Id:       "passwordless-test-id",
+		Provider: sdk.AuthProviderTypePasswordless,
+		Params:   []sdk.AuthProviderParam{{Key: "@PASSWORDLESS/LOGIN_URL", Value: loginUrl}},
+	}
+	return NewAuthProvider(p, fakeCodes{})
+}
+
+func TestGetAuthCodeUrl(t *testing.T) {
+	provider := createPasswordlessProvider("https://app.example.com/login?lang=en")
+
+	assert.Equal(t, "https://app.example.com/login?lang=en&state=state+1", provider.GetAuthCodeUrl("state 1"))
+	assert.False(t, provider.HasRefreshTokenFlow())
+}
+
+func TestVerifyCodeAndGetIdentity(t *testing.T) {
+	provider := createPasswordlessProvider("https://app.example.com/login")
+
+	token, err := provider.VerifyCode(context.Background(), "code-1")
+	require.NoError(t, err)
+
+	identities, err := provider.GetIdentity(token.AccessToken)
+	require.NoError(t, err)
+	user := &sdk.User{}
+	for _, id := range identities {
+		id.UpdateUserDetails(user)
+	}
+	assert.Equal(t, "user@example.com", user.Email)
+
+	_, err = provider.VerifyCode(context.Background(), "wrong-code")
+	assert.ErrorIs(t, err, sdk.ErrInvalidOtp)
+
+	_, err = provider.RefreshToken("refresh")
+	assert.Error(t, err)
+}
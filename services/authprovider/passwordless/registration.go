@@ -0,0 +1,36 @@
+package passwordless
+
+import (
+	"context"
+	"errors"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/authprovider/registry"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		AuthProviderTypeInfo: sdk.AuthProviderTypeInfo{
+			Type: sdk.AuthProviderTypePasswordless,
+			Name: "Email code or magic link",
+			Icon: "email",
+			Params: []sdk.AuthProviderParamSpec{
+				{Key: sdk.PasswordlessParamLoginUrl, Label: "Login page", Description: "Page asking for the email, it receives the state of the login in the query", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.PasswordlessParamMethod, Label: "Method", Description: "code emails a 6 digit code, link emails a magic link. code when empty", Format: sdk.AuthProviderParamFormatText, AllowedValues: []string{sdk.PasswordlessMethodCode, sdk.PasswordlessMethodLink}},
+				{Key: sdk.PasswordlessParamLinkUrl, Label: "Magic link page", Description: "Page opened from the magic links, required for the link method", Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.PasswordlessParamEmailSubject, Label: "Email subject", Description: "Subject of the login email", Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.PasswordlessParamEmailTemplate, Label: "Email template", Description: "text/template of the email body with .Code, .Link, .Email and .ExpiresInMinutes", Format: sdk.AuthProviderParamFormatText},
+			},
+		},
+		New: func(_ context.Context, p sdk.AuthProvider, deps registry.Dependencies) (sdk.ServiceProvider, error) {
+			codes, _ := deps.Codes.(CodeService)
+			return NewAuthProvider(p, codes), nil
+		},
+		Validate: func(p sdk.AuthProvider) error {
+			if p.GetParam(sdk.PasswordlessParamMethod) == sdk.PasswordlessMethodLink && len(p.GetParam(sdk.PasswordlessParamLinkUrl)) == 0 {
+				return errors.New("the magic link page is required for the link method")
+			}
+			return nil
+		},
+	})
+}
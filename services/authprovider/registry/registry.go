@@ -0,0 +1,80 @@
+// Package registry keeps the types of auth providers go-iam supports. Every provider package
+// registers its type with the schema of its params and a factory of its service providers.
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// Dependencies are the services of go-iam the built in providers resolve their logins with.
+// Every provider asserts the one it needs to the interface it declares.
+type Dependencies struct {
+	Credentials interface{} // Password credentials, for the password provider
+	Codes       interface{} // Single use login codes, for the passwordless and sms providers
+	Passkeys    interface{} // Passkey login codes, for the passkey provider
+	Directories interface{} // Directory login codes, for the ldap provider
+}
+
+// Factory creates the service provider of the configuration of an auth provider
+type Factory func(ctx context.Context, p sdk.AuthProvider, deps Dependencies) (sdk.ServiceProvider, error)
+
+// Registration is a type of auth provider
+type Registration struct {
+	sdk.AuthProviderTypeInfo
+	New Factory
+	// Validate checks the rules spanning several params, after each param matched its spec (optional)
+	Validate func(p sdk.AuthProvider) error
+}
+
+var (
+	mu            sync.RWMutex
+	registrations = map[sdk.AuthProviderType]Registration{}
+)
+
+// Register adds a type of auth provider. It panics when the type is registered already,
+// as two packages claiming the same type is a programming error.
+func Register(r Registration) {
+	mu.Lock()
+	defer mu.Unlock()
+	if r.New == nil {
+		panic(fmt.Sprintf("auth provider %s registered without a factory", r.Type))
+	}
+	if _, ok := registrations[r.Type]; ok {
+		panic(fmt.Sprintf("auth provider %s registered twice", r.Type))
+	}
+	registrations[r.Type] = r
+}
+
+// Get returns the registration of the type
+func Get(t sdk.AuthProviderType) (Registration, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	r, ok := registrations[t]
+	return r, ok
+}
+
+// Types lists the registered types of auth providers, sorted by type
+func Types() []sdk.AuthProviderTypeInfo {
+	mu.RLock()
+	defer mu.RUnlock()
+	types := make([]sdk.AuthProviderTypeInfo, 0, len(registrations))
+	for _, r := range registrations {
+		types = append(types, r.AuthProviderTypeInfo)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Type < types[j].Type })
+	return types
+}
+
+// New creates the service provider of the configuration with the factory of its type
+func New(ctx context.Context, p sdk.AuthProvider, deps Dependencies) (sdk.ServiceProvider, error) {
+	r, ok := Get(p.Provider)
+	if !ok {
+		return nil, fmt.Errorf("unknown auth provider: %s", p.Provider)
+	}
+	return r.New(ctx, p, deps)
+}
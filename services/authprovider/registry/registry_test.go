@@ -0,0 +1,131 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testType sdk.AuthProviderType = "TEST"
+
+const testCert = `-----BEGIN CERTIFICATE-----
+MIIBhTCCASugAwIBAgIQIRi6zePL6mKjOipn+dNuaTAKBggqhkjOPQQDAjASMRAw
+-----END CERTIFICATE-----`
+
+func init() {
+	Register(Registration{
+		AuthProviderTypeInfo: sdk.AuthProviderTypeInfo{
+			Type: testType,
+			Name: "Test",
+			Params: []sdk.AuthProviderParamSpec{
+				{Key: "@TEST/URL", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+				{Key: "@TEST/SECRET", IsSecret: true, Format: sdk.AuthProviderParamFormatText},
+				{Key: "@TEST/ENABLED", Format: sdk.AuthProviderParamFormatBoolean},
+				{Key: "@TEST/MAPPING", Format: sdk.AuthProviderParamFormatJson},
+				{Key: "@TEST/CERT", Format: sdk.AuthProviderParamFormatPem},
+				{Key: "@TEST/METADATA", Format: sdk.AuthProviderParamFormatXml},
+				{Key: "@TEST/METHOD", Format: sdk.AuthProviderParamFormatText, AllowedValues: []string{"code", "link"}},
+			},
+		},
+		New: func(_ context.Context, p sdk.AuthProvider, _ Dependencies) (sdk.ServiceProvider, error) {
+			return nil, errors.New("created " + p.Name)
+		},
+		Validate: func(p sdk.AuthProvider) error {
+			if p.GetParam("@TEST/METHOD") == "link" && p.GetParam("@TEST/ENABLED") != "true" {
+				return errors.New("links need enabling")
+			}
+			return nil
+		},
+	})
+}
+
+func testProvider(params ...sdk.AuthProviderParam) *sdk.AuthProvider {
+	return &sdk.AuthProvider{
+		Name:     "test",
+		Provider: testType,
+		Params:   append([]sdk.AuthProviderParam{{Key: "@TEST/URL", Value: "https://example.com/login"}}, params...),
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider *sdk.AuthProvider
+		err      string
+	}{
+		{name: "valid", provider: testProvider(
+			sdk.AuthProviderParam{Key: "@TEST/ENABLED", Value: "true"},
+			sdk.AuthProviderParam{Key: "@TEST/MAPPING", Value: `{"email":"mail"}`},
+			sdk.AuthProviderParam{Key: "@TEST/CERT", Value: testCert},
+			sdk.AuthProviderParam{Key: "@TEST/METADATA", Value: `<EntityDescriptor entityID="idp"/>`},
+			sdk.AuthProviderParam{Key: "@TEST/METHOD", Value: "link"},
+		)},
+		{name: "empty optional params", provider: testProvider(sdk.AuthProviderParam{Key: "@TEST/MAPPING", Value: ""})},
+		{name: "unknown type", provider: &sdk.AuthProvider{Provider: "UNKNOWN"}, err: `unknown auth provider type "UNKNOWN"`},
+		{name: "missing required param", provider: &sdk.AuthProvider{Provider: testType}, err: "@TEST/URL is required"},
+		{name: "blank required param", provider: &sdk.AuthProvider{Provider: testType, Params: []sdk.AuthProviderParam{{Key: "@TEST/URL", Value: " "}}}, err: "@TEST/URL is required"},
+		{name: "unknown param", provider: testProvider(sdk.AuthProviderParam{Key: "@TEST/TYPO", Value: "x"}), err: "unknown param @TEST/TYPO"},
+		{name: "param set twice", provider: testProvider(sdk.AuthProviderParam{Key: "@TEST/URL", Value: "https://example.com"}), err: "param @TEST/URL is set twice"},
+		{name: "relative url", provider: &sdk.AuthProvider{Provider: testType, Params: []sdk.AuthProviderParam{{Key: "@TEST/URL", Value: "/login"}}}, err: "absolute http or https url"},
+		{name: "javascript url", provider: &sdk.AuthProvider{Provider: testType, Params: []sdk.AuthProviderParam{{Key: "@TEST/URL", Value: "javascript:alert(1)"}}}, err: "absolute http or https url"},
+		{name: "invalid boolean", provider: testProvider(sdk.AuthProviderParam{Key: "@TEST/ENABLED", Value: "yes"}), err: "has to be true or false"},
+		{name: "json array", provider: testProvider(sdk.AuthProviderParam{Key: "@TEST/MAPPING", Value: `["mail"]`}), err: "has to be a json object"},
+		{name: "invalid pem", provider: testProvider(sdk.AuthProviderParam{Key: "@TEST/CERT", Value: "not a certificate"}), err: "has to be pem encoded"},
+		{name: "invalid xml", provider: testProvider(sdk.AuthProviderParam{Key: "@TEST/METADATA", Value: "<EntityDescriptor>"}), err: "has to be an xml document"},
+		{name: "text instead of xml", provider: testProvider(sdk.AuthProviderParam{Key: "@TEST/METADATA", Value: "metadata"}), err: "has to be an xml document"},
+		{name: "value not allowed", provider: testProvider(sdk.AuthProviderParam{Key: "@TEST/METHOD", Value: "sms"}), err: "has to be one of code, link"},
+		{name: "group role without a role", provider: &sdk.AuthProvider{Provider: testType, Params: []sdk.AuthProviderParam{{Key: "@TEST/URL", Value: "https://example.com"}}, GroupRoles: []sdk.AuthProviderGroupRole{{Group: "admins"}}}, err: "need a group and a role id"},
+		{name: "cross param rule", provider: testProvider(sdk.AuthProviderParam{Key: "@TEST/METHOD", Value: "link"}), err: "links need enabling"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.provider)
+			if len(tt.err) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.ErrorIs(t, err, sdk.ErrInvalidAuthProviderConfig)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestValidate_FlagsSecrets(t *testing.T) {
+	p := testProvider(sdk.AuthProviderParam{Key: "@TEST/SECRET", Value: "secret"}, sdk.AuthProviderParam{Key: "@TEST/ENABLED", Value: "true", IsSecret: true})
+
+	require.NoError(t, Validate(p))
+	assert.False(t, p.Params[0].IsSecret)
+	assert.True(t, p.Params[1].IsSecret)
+	// params flagged by the admin stay secret
+	assert.True(t, p.Params[2].IsSecret)
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("new uses the factory of the type", func(t *testing.T) {
+		_, err := New(context.Background(), sdk.AuthProvider{Name: "p1", Provider: testType}, Dependencies{})
+		assert.EqualError(t, err, "created p1")
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, err := New(context.Background(), sdk.AuthProvider{Provider: "UNKNOWN"}, Dependencies{})
+		assert.EqualError(t, err, "unknown auth provider: UNKNOWN")
+	})
+
+	t.Run("types list the registrations", func(t *testing.T) {
+		types := Types()
+		require.NotEmpty(t, types)
+		assert.Equal(t, testType, types[len(types)-1].Type)
+		assert.Len(t, types[len(types)-1].Params, 7)
+	})
+
+	t.Run("types registered twice", func(t *testing.T) {
+		r, _ := Get(testType)
+		assert.Panics(t, func() { Register(r) })
+	})
+}
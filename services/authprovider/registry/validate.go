@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// Validate checks the params of the auth provider against the schema of its type, so that broken
+// configurations are refused when they are saved instead of failing the logins.
+// The params the schema flags as secrets are flagged on the auth provider too, for them to be stored encrypted.
+func Validate(p *sdk.AuthProvider) error {
+	r, ok := Get(p.Provider)
+	if !ok {
+		return fmt.Errorf("%w: unknown auth provider type %q", sdk.ErrInvalidAuthProviderConfig, p.Provider)
+	}
+	specs := make(map[string]sdk.AuthProviderParamSpec, len(r.Params))
+	for _, spec := range r.Params {
+		specs[spec.Key] = spec
+	}
+
+	seen := map[string]bool{}
+	for i, param := range p.Params {
+		spec, ok := specs[param.Key]
+		if !ok {
+			return fmt.Errorf("%w: unknown param %s for %s", sdk.ErrInvalidAuthProviderConfig, param.Key, p.Provider)
+		}
+		if seen[param.Key] {
+			return fmt.Errorf("%w: param %s is set twice", sdk.ErrInvalidAuthProviderConfig, param.Key)
+		}
+		seen[param.Key] = true
+		p.Params[i].IsSecret = param.IsSecret || spec.IsSecret
+		if len(strings.TrimSpace(param.Value)) == 0 {
+			continue
+		}
+		if err := validateValue(spec, param.Value); err != nil {
+			return fmt.Errorf("%w: %s %w", sdk.ErrInvalidAuthProviderConfig, param.Key, err)
+		}
+	}
+	for _, spec := range r.Params {
+		if spec.Required && len(strings.TrimSpace(p.GetParam(spec.Key))) == 0 {
+			return fmt.Errorf("%w: %s is required", sdk.ErrInvalidAuthProviderConfig, spec.Key)
+		}
+	}
+	for _, gr := range p.GroupRoles {
+		if len(strings.TrimSpace(gr.Group)) == 0 || len(strings.TrimSpace(gr.RoleId)) == 0 {
+			return fmt.Errorf("%w: the group roles need a group and a role id", sdk.ErrInvalidAuthProviderConfig)
+		}
+	}
+	if r.Validate != nil {
+		if err := r.Validate(*p); err != nil {
+			return fmt.Errorf("%w: %w", sdk.ErrInvalidAuthProviderConfig, err)
+		}
+	}
+	return nil
+}
+
+// validateValue checks a value set for a param against its spec
+func validateValue(spec sdk.AuthProviderParamSpec, value string) error {
+	if len(spec.AllowedValues) > 0 && !slices.Contains(spec.AllowedValues, value) {
+		return fmt.Errorf("has to be one of %s", strings.Join(spec.AllowedValues, ", "))
+	}
+	switch spec.Format {
+	case sdk.AuthProviderParamFormatUrl:
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return errors.New("has to be an absolute http or https url")
+		}
+	case sdk.AuthProviderParamFormatBoolean:
+		if value != "true" && value != "false" {
+			return errors.New("has to be true or false")
+		}
+	case sdk.AuthProviderParamFormatJson:
+		obj := map[string]json.RawMessage{}
+		if err := json.Unmarshal([]byte(value), &obj); err != nil {
+			return errors.New("has to be a json object")
+		}
+	case sdk.AuthProviderParamFormatPem:
+		if block, _ := pem.Decode([]byte(value)); block == nil {
+			return errors.New("has to be pem encoded")
+		}
+	case sdk.AuthProviderParamFormatXml:
+		if err := wellFormedXml(value); err != nil {
+			return fmt.Errorf("has to be an xml document %w", err)
+		}
+	}
+	return nil
+}
+
+func wellFormedXml(value string) error {
+	d := xml.NewDecoder(strings.NewReader(value))
+	elements := 0
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			elements++
+		}
+	}
+	if elements == 0 {
+		return errors.New("without elements")
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+)
+
+// algorithms of XML signature accepted for the responses, SHA-1 is not
+const (
+	nsDsig         = "http://www.w3.org/2000/09/xmldsig#"
+	algExcC14n     = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnveloped   = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algRsaSha256   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algRsaSha512   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	algEcdsaSha256 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+	algSha256      = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algSha512      = "http://www.w3.org/2001/04/xmlenc#sha512"
+)
+
+var errInvalidSignature = errors.New("invalid signature")
+
+// verifyEnvelopedSignature checks the signature enveloped in the element against the certificates of the IdP.
+// The signature has to cover the element itself through its ID, with exclusive canonicalization.
+// It returns false when the element is not signed.
+func verifyEnvelopedSignature(el *xmlNode, certs []*x509.Certificate) (bool, error) {
+	sigs := el.elements(nsDsig, "Signature")
+	if len(sigs) == 0 {
+		return false, nil
+	}
+	if len(sigs) > 1 {
+		return false, fmt.Errorf("%w: more than one signature", errInvalidSignature)
+	}
+	sig := sigs[0]
+	signedInfo := sig.element(nsDsig, "SignedInfo")
+	if signedInfo == nil {
+		return false, fmt.Errorf("%w: no signed info", errInvalidSignature)
+	}
+
+	c14n := signedInfo.element(nsDsig, "CanonicalizationMethod")
+	if c14n == nil || c14n.attr("Algorithm") != algExcC14n {
+		return false, fmt.Errorf("%w: unsupported canonicalization", errInvalidSignature)
+	}
+	refs := signedInfo.elements(nsDsig, "Reference")
+	if len(refs) != 1 {
+		return false, fmt.Errorf("%w: expected a single reference", errInvalidSignature)
+	}
+	ref := refs[0]
+	id := el.attr("ID")
+	if len(id) == 0 || ref.attr("URI") != "#"+id {
+		return false, fmt.Errorf("%w: the signature does not cover the element", errInvalidSignature)
+	}
+
+	// the only transforms accepted are the removal of the signature followed by the canonicalization
+	transforms := []*xmlNode{}
+	if t := ref.element(nsDsig, "Transforms"); t != nil {
+		transforms = t.elements(nsDsig, "Transform")
+	}
+	if len(transforms) != 2 || transforms[0].attr("Algorithm") != algEnveloped || transforms[1].attr("Algorithm") != algExcC14n {
+		return false, fmt.Errorf("%w: unsupported transforms", errInvalidSignature)
+	}
+
+	digestMethod := ref.element(nsDsig, "DigestMethod")
+	digestValue := ref.element(nsDsig, "DigestValue")
+	if digestMethod == nil || digestValue == nil {
+		return false, fmt.Errorf("%w: no digest", errInvalidSignature)
+	}
+	h, err := digestHash(digestMethod.attr("Algorithm"))
+	if err != nil {
+		return false, err
+	}
+	h.Write(canonicalize(el, inclusivePrefixes(transforms[1]), sig))
+	expected, err := decodeBase64(digestValue.text())
+	if err != nil {
+		return false, fmt.Errorf("%w: invalid digest value", errInvalidSignature)
+	}
+	if subtle.ConstantTimeCompare(h.Sum(nil), expected) != 1 {
+		return false, fmt.Errorf("%w: digest mismatch", errInvalidSignature)
+	}
+
+	sigMethod := signedInfo.element(nsDsig, "SignatureMethod")
+	sigValue := sig.element(nsDsig, "SignatureValue")
+	if sigMethod == nil || sigValue == nil {
+		return false, fmt.Errorf("%w: no signature value", errInvalidSignature)
+	}
+	value, err := decodeBase64(sigValue.text())
+	if err != nil {
+		return false, fmt.Errorf("%w: invalid signature value", errInvalidSignature)
+	}
+	signed := canonicalize(signedInfo, inclusivePrefixes(c14n), nil)
+	for _, cert := range certs {
+		if verifySignedInfo(sigMethod.attr("Algorithm"), cert.PublicKey, signed, value) {
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("%w: not signed by the IdP", errInvalidSignature)
+}
+
+// inclusivePrefixes returns the PrefixList of the InclusiveNamespaces of a canonicalization
+func inclusivePrefixes(method *xmlNode) []string {
+	in := method.element(algExcC14n, "InclusiveNamespaces")
+	if in == nil {
+		return nil
+	}
+	return strings.Fields(in.attr("PrefixList"))
+}
+
+func digestHash(alg string) (hash.Hash, error) {
+	switch alg {
+	case algSha256:
+		return sha256.New(), nil
+	case algSha512:
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("%w: unsupported digest %s", errInvalidSignature, alg)
+}
+
+// verifySignedInfo checks the signature value over the canonical signed info with a public key of the IdP
+func verifySignedInfo(alg string, key crypto.PublicKey, signed, value []byte) bool {
+	switch alg {
+	case algRsaSha256:
+		k, ok := key.(*rsa.PublicKey)
+		digest := sha256.Sum256(signed)
+		return ok && rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], value) == nil
+	case algRsaSha512:
+		k, ok := key.(*rsa.PublicKey)
+		digest := sha512.Sum512(signed)
+		return ok && rsa.VerifyPKCS1v15(k, crypto.SHA512, digest[:], value) == nil
+	case algEcdsaSha256:
+		// XML signature concatenates r and s instead of using ASN.1
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(value) == 0 || len(value)%2 != 0 {
+			return false
+		}
+		digest := sha256.Sum256(signed)
+		r := new(big.Int).SetBytes(value[:len(value)/2])
+		s := new(big.Int).SetBytes(value[len(value)/2:])
+		return ecdsa.Verify(k, digest[:], r, s)
+	}
+	return false
+}
+
+// decodeBase64 decodes base64 values of xml documents, which are often wrapped over several lines
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
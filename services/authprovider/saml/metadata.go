@@ -0,0 +1,98 @@
+package saml
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/xml"
+	"fmt"
+)
+
+const (
+	nsMetadata      = "urn:oasis:names:tc:SAML:2.0:metadata"
+	nsProtocol      = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsAssertion     = "urn:oasis:names:tc:SAML:2.0:assertion"
+	bindingRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	bindingPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+)
+
+// idpMetadata is what go-iam needs from the metadata of the IdP
+type idpMetadata struct {
+	entityId string
+	ssoUrl   string
+	certs    []*x509.Certificate
+}
+
+// parseIdpMetadata reads the entity id, the single sign-on url of the redirect binding and the signing
+// certificates of the IdP. The metadata may list several entities, the first one with an IdP role is used.
+func parseIdpMetadata(b []byte) (*idpMetadata, error) {
+	root, err := parseXml(b)
+	if err != nil {
+		return nil, err
+	}
+	entities := []*xmlNode{root}
+	if root.is(nsMetadata, "EntitiesDescriptor") {
+		entities = root.elements(nsMetadata, "EntityDescriptor")
+	}
+	for _, entity := range entities {
+		if !entity.is(nsMetadata, "EntityDescriptor") {
+			continue
+		}
+		idp := entity.element(nsMetadata, "IDPSSODescriptor")
+		if idp == nil {
+			continue
+		}
+		md := &idpMetadata{entityId: entity.attr("entityID")}
+		for _, sso := range idp.elements(nsMetadata, "SingleSignOnService") {
+			if sso.attr("Binding") == bindingRedirect {
+				md.ssoUrl = sso.attr("Location")
+				break
+			}
+		}
+		for _, kd := range idp.elements(nsMetadata, "KeyDescriptor") {
+			if use := kd.attr("use"); use != "" && use != "signing" {
+				continue
+			}
+			keyInfo := kd.element(nsDsig, "KeyInfo")
+			if keyInfo == nil {
+				continue
+			}
+			for _, data := range keyInfo.elements(nsDsig, "X509Data") {
+				for _, c := range data.elements(nsDsig, "X509Certificate") {
+					der, err := decodeBase64(c.text())
+					if err != nil {
+						return nil, fmt.Errorf("invalid certificate in the idp metadata %w", err)
+					}
+					cert, err := x509.ParseCertificate(der)
+					if err != nil {
+						return nil, fmt.Errorf("invalid certificate in the idp metadata %w", err)
+					}
+					md.certs = append(md.certs, cert)
+				}
+			}
+		}
+		switch {
+		case len(md.entityId) == 0:
+			return nil, fmt.Errorf("the idp metadata has no entity id")
+		case len(md.ssoUrl) == 0:
+			return nil, fmt.Errorf("the idp metadata has no single sign-on service with the redirect binding")
+		case len(md.certs) == 0:
+			return nil, fmt.Errorf("the idp metadata has no signing certificate")
+		}
+		return md, nil
+	}
+	return nil, fmt.Errorf("the metadata has no idp")
+}
+
+// spMetadata is the metadata of go-iam as the service provider of an auth provider
+func spMetadata(entityId, acsUrl, nameIdFormat string) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, `<md:EntityDescriptor xmlns:md="%s" entityID="%s">`, nsMetadata, escapeAttr(entityId))
+	fmt.Fprintf(&b, `<md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="%s">`, nsProtocol)
+	if len(nameIdFormat) > 0 {
+		fmt.Fprintf(&b, `<md:NameIDFormat>%s</md:NameIDFormat>`, escapeText(nameIdFormat))
+	}
+	fmt.Fprintf(&b, `<md:AssertionConsumerService Binding="%s" Location="%s" index="0" isDefault="true"/>`, bindingPost, escapeAttr(acsUrl))
+	b.WriteString(`</md:SPSSODescriptor></md:EntityDescriptor>`)
+	return b.Bytes()
+}
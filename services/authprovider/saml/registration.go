@@ -0,0 +1,33 @@
+package saml
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/authprovider/registry"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		AuthProviderTypeInfo: sdk.AuthProviderTypeInfo{
+			Type: sdk.AuthProviderTypeSAML,
+			Name: "SAML 2.0",
+			Icon: "saml",
+			Params: []sdk.AuthProviderParamSpec{
+				{Key: sdk.SamlParamIdpMetadata, Label: "IdP metadata", Description: "Metadata xml of the IdP, with its entity id, single sign-on url and signing certificates", Required: true, Format: sdk.AuthProviderParamFormatXml},
+				{Key: sdk.SamlParamAcsUrl, Label: "ACS URL", Description: "Public url of the assertion consumer service of go-iam", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.SamlParamEntityId, Label: "Entity ID", Description: "Entity id of go-iam for the IdP, the acs url when empty", Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.SamlParamNameIdFormat, Label: "NameID format", Description: "NameID format requested from the IdP", Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.SamlParamAttributeMapping, Label: "Attribute mapping", Description: "Json object mapping email, name, given_name, family_name, phone and picture to the attributes of the IdP", Format: sdk.AuthProviderParamFormatJson},
+			},
+		},
+		New: func(_ context.Context, p sdk.AuthProvider, _ registry.Dependencies) (sdk.ServiceProvider, error) {
+			return NewAuthProvider(p)
+		},
+		// the metadata and the mapping have to be readable for the logins to work
+		Validate: func(p sdk.AuthProvider) error {
+			_, err := NewAuthProvider(p)
+			return err
+		},
+	})
+}
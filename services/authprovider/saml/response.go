@@ -0,0 +1,177 @@
+package saml
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	statusSuccess            = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	confirmationBearer       = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	nameIdFormatEmailAddress = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	// clockSkew is the drift tolerated between the clocks of go-iam and the IdP
+	clockSkew = 2 * time.Minute
+)
+
+// assertion is what go-iam keeps of a verified assertion
+type assertion struct {
+	nameId       string
+	nameIdFormat string
+	attributes   map[string][]string // values by the name and by the friendly name of the attributes
+}
+
+// parseResponse verifies a SAML response answering the authentication request and returns its assertion.
+// Either the response or the assertion has to be signed by the IdP. Only the elements covered by the
+// signature are read, so that wrapping other elements around them has no effect.
+func (a authProvider) parseResponse(b []byte, requestId string, now time.Time) (*assertion, error) {
+	root, err := parseXml(b)
+	if err != nil {
+		return nil, err
+	}
+	if !root.is(nsProtocol, "Response") {
+		return nil, fmt.Errorf("not a saml response")
+	}
+	if root.attr("Version") != "2.0" {
+		return nil, fmt.Errorf("unsupported saml version %s", root.attr("Version"))
+	}
+	if dest := root.attr("Destination"); len(dest) > 0 && dest != a.acsUrl {
+		return nil, fmt.Errorf("the response is for %s", dest)
+	}
+	if root.attr("InResponseTo") != requestId {
+		return nil, fmt.Errorf("the response does not answer the authentication request of the login")
+	}
+	if issuer := root.element(nsAssertion, "Issuer"); issuer != nil && issuer.text() != a.idp.entityId {
+		return nil, fmt.Errorf("the response is issued by %s", issuer.text())
+	}
+	status := ""
+	if s := root.element(nsProtocol, "Status"); s != nil {
+		if code := s.element(nsProtocol, "StatusCode"); code != nil {
+			status = code.attr("Value")
+		}
+	}
+	if status != statusSuccess {
+		return nil, fmt.Errorf("the idp returned the status %s", status)
+	}
+	if len(root.elements(nsAssertion, "EncryptedAssertion")) > 0 {
+		return nil, fmt.Errorf("encrypted assertions are not supported")
+	}
+	assertions := root.elements(nsAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("expected a single assertion, got %d", len(assertions))
+	}
+	as := assertions[0]
+
+	responseSigned, err := verifyEnvelopedSignature(root, a.idp.certs)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying the signature of the response %w", err)
+	}
+	assertionSigned, err := verifyEnvelopedSignature(as, a.idp.certs)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying the signature of the assertion %w", err)
+	}
+	if !responseSigned && !assertionSigned {
+		return nil, fmt.Errorf("neither the response nor the assertion is signed")
+	}
+
+	return a.readAssertion(as, requestId, now)
+}
+
+// readAssertion checks the issuer, the subject confirmation and the conditions of a signed assertion
+func (a authProvider) readAssertion(as *xmlNode, requestId string, now time.Time) (*assertion, error) {
+	issuer := as.element(nsAssertion, "Issuer")
+	if issuer == nil || issuer.text() != a.idp.entityId {
+		return nil, fmt.Errorf("the assertion is not issued by the idp")
+	}
+
+	subject := as.element(nsAssertion, "Subject")
+	if subject == nil {
+		return nil, fmt.Errorf("the assertion has no subject")
+	}
+	nameId := subject.element(nsAssertion, "NameID")
+	if nameId == nil || len(nameId.text()) == 0 {
+		return nil, fmt.Errorf("the assertion has no name id")
+	}
+	confirmed := false
+	for _, sc := range subject.elements(nsAssertion, "SubjectConfirmation") {
+		if sc.attr("Method") != confirmationBearer {
+			continue
+		}
+		data := sc.element(nsAssertion, "SubjectConfirmationData")
+		if data == nil || data.attr("Recipient") != a.acsUrl {
+			continue
+		}
+		if irt := data.attr("InResponseTo"); len(irt) > 0 && irt != requestId {
+			continue
+		}
+		notOnOrAfter, err := parseTime(data.attr("NotOnOrAfter"))
+		if err != nil || !now.Before(notOnOrAfter.Add(clockSkew)) {
+			continue
+		}
+		confirmed = true
+		break
+	}
+	if !confirmed {
+		return nil, fmt.Errorf("the assertion has no valid bearer subject confirmation")
+	}
+
+	conditions := as.element(nsAssertion, "Conditions")
+	if conditions == nil {
+		return nil, fmt.Errorf("the assertion has no conditions")
+	}
+	if v := conditions.attr("NotBefore"); len(v) > 0 {
+		notBefore, err := parseTime(v)
+		if err != nil || now.Add(clockSkew).Before(notBefore) {
+			return nil, fmt.Errorf("the assertion is not valid yet")
+		}
+	}
+	if v := conditions.attr("NotOnOrAfter"); len(v) > 0 {
+		notOnOrAfter, err := parseTime(v)
+		if err != nil || !now.Before(notOnOrAfter.Add(clockSkew)) {
+			return nil, fmt.Errorf("the assertion has expired")
+		}
+	}
+	restrictions := conditions.elements(nsAssertion, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return nil, fmt.Errorf("the assertion has no audience restriction")
+	}
+	for _, r := range restrictions {
+		found := false
+		for _, audience := range r.elements(nsAssertion, "Audience") {
+			if audience.text() == a.entityId {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("the assertion is not meant for %s", a.entityId)
+		}
+	}
+	if as.element(nsAssertion, "AuthnStatement") == nil {
+		return nil, fmt.Errorf("the assertion has no authentication statement")
+	}
+
+	result := &assertion{
+		nameId:       nameId.text(),
+		nameIdFormat: nameId.attr("Format"),
+		attributes:   map[string][]string{},
+	}
+	for _, st := range as.elements(nsAssertion, "AttributeStatement") {
+		for _, attr := range st.elements(nsAssertion, "Attribute") {
+			values := []string{}
+			for _, v := range attr.elements(nsAssertion, "AttributeValue") {
+				values = append(values, v.text())
+			}
+			for _, name := range []string{attr.attr("Name"), attr.attr("FriendlyName")} {
+				if len(name) > 0 {
+					result.attributes[name] = append(result.attributes[name], values...)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// parseTime parses the xs:dateTime values of the assertions
+func parseTime(v string) (time.Time, error) {
+	return time.Parse(time.RFC3339, v)
+}
@@ -0,0 +1,303 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// fields of the users the attributes of the assertions map to
+const (
+	fieldEmail      = "email"
+	fieldName       = "name"
+	fieldGivenName  = "given_name"
+	fieldFamilyName = "family_name"
+	fieldPhone      = "phone"
+	fieldPicture    = "picture"
+)
+
+// defaultAttributes are the attribute names looked up when the mapping of a field is not configured.
+// They cover the claims of ADFS and Azure AD, the LDAP names used by Okta and Shibboleth, and their OIDs.
+var defaultAttributes = map[string][]string{
+	fieldEmail:      {"email", "mail", "emailAddress", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress", "urn:oid:0.9.2342.19200300.100.1.3"},
+	fieldName:       {"displayName", "name", "http://schemas.microsoft.com/identity/claims/displayname", "urn:oid:2.16.840.1.113730.3.1.241"},
+	fieldGivenName:  {"givenName", "firstName", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname", "urn:oid:2.5.4.42"},
+	fieldFamilyName: {"sn", "surname", "lastName", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/surname", "urn:oid:2.5.4.4"},
+	fieldPhone:      {"mobile", "phone", "telephoneNumber", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/mobilephone", "urn:oid:0.9.2342.19200300.100.1.41"},
+	fieldPicture:    {"picture"},
+}
+
+// authProvider implements the SDK ServiceProvider interface for SAML 2.0 IdPs, go-iam being the service provider.
+// The user is sent to the IdP with an authentication request of the redirect binding. The IdP posts the
+// response back to the assertion consumer service, which continues the login with the response as the code.
+// The user details mapped from the verified assertion act as the access token of the provider.
+type authProvider struct {
+	idp          idpMetadata
+	acsUrl       string
+	entityId     string
+	nameIdFormat string
+	mapping      map[string][]string
+}
+
+// NewAuthProvider creates a new SAML provider instance
+// Parameters in the AuthProvider configuration:
+// - @SAML/IDP_METADATA: Metadata xml of the IdP
+// - @SAML/ACS_URL: Public url of the assertion consumer service of go-iam
+// - @SAML/ENTITY_ID: Entity id of go-iam (optional, defaults to the acs url)
+// - @SAML/NAME_ID_FORMAT: NameID format requested from the IdP (optional)
+// - @SAML/ATTRIBUTE_MAPPING: Json object of the attribute names of the user fields (optional)
+func NewAuthProvider(p sdk.AuthProvider) (sdk.ServiceProvider, error) {
+	md, err := parseIdpMetadata([]byte(p.GetParam(sdk.SamlParamIdpMetadata)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading the idp metadata of %s %w", p.Name, err)
+	}
+	acsUrl := p.GetParam(sdk.SamlParamAcsUrl)
+	if len(acsUrl) == 0 {
+		return nil, fmt.Errorf("the acs url of %s is not configured", p.Name)
+	}
+	mapping, err := attributeMapping(p.GetParam(sdk.SamlParamAttributeMapping))
+	if err != nil {
+		return nil, fmt.Errorf("error reading the attribute mapping of %s %w", p.Name, err)
+	}
+	return authProvider{
+		idp:          *md,
+		acsUrl:       acsUrl,
+		entityId:     entityId(p),
+		nameIdFormat: p.GetParam(sdk.SamlParamNameIdFormat),
+		mapping:      mapping,
+	}, nil
+}
+
+// Metadata returns the metadata of go-iam as the service provider of the auth provider, to register it with the IdP.
+// It does not need the metadata of the IdP, which usually is available only once go-iam is registered.
+func Metadata(p sdk.AuthProvider) ([]byte, error) {
+	acsUrl := p.GetParam(sdk.SamlParamAcsUrl)
+	if len(acsUrl) == 0 {
+		return nil, fmt.Errorf("the acs url of %s is not configured", p.Name)
+	}
+	return spMetadata(entityId(p), acsUrl, p.GetParam(sdk.SamlParamNameIdFormat)), nil
+}
+
+// ResponseCode returns the code the login continues with once the IdP posted the response to the
+// assertion consumer service. The state tells which authentication request the response has to answer.
+func ResponseCode(state, samlResponse string) string {
+	return state + "." + samlResponse
+}
+
+func entityId(p sdk.AuthProvider) string {
+	if id := p.GetParam(sdk.SamlParamEntityId); len(id) > 0 {
+		return id
+	}
+	return p.GetParam(sdk.SamlParamAcsUrl)
+}
+
+// attributeMapping merges the configured attribute names of the user fields with the default ones
+func attributeMapping(param string) (map[string][]string, error) {
+	mapping := make(map[string][]string, len(defaultAttributes))
+	for k, v := range defaultAttributes {
+		mapping[k] = v
+	}
+	if len(param) == 0 {
+		return mapping, nil
+	}
+	configured := map[string]string{}
+	err := json.Unmarshal([]byte(param), &configured)
+	if err != nil {
+		return nil, err
+	}
+	for field, attr := range configured {
+		if _, ok := defaultAttributes[field]; !ok {
+			return nil, fmt.Errorf("unknown user field %s", field)
+		}
+		mapping[field] = []string{attr}
+	}
+	return mapping, nil
+}
+
+// requestId is the ID of the authentication request of a login. It is derived from the state,
+// so that the response can be matched with the login without keeping the request around.
+func requestId(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return "_" + hex.EncodeToString(sum[:])
+}
+
+// HasRefreshTokenFlow returns false, the IdP has to be visited again for a new assertion
+func (a authProvider) HasRefreshTokenFlow() bool {
+	return false
+}
+
+// GetAuthCodeUrl returns the single sign-on url of the IdP with the authentication request of the redirect binding
+func (a authProvider) GetAuthCodeUrl(state string) string {
+	var req bytes.Buffer
+	fmt.Fprintf(&req, `<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s">`,
+		nsProtocol, nsAssertion, requestId(state), time.Now().UTC().Format(time.RFC3339), escapeAttr(a.idp.ssoUrl), escapeAttr(a.acsUrl), bindingPost)
+	fmt.Fprintf(&req, `<saml:Issuer>%s</saml:Issuer>`, escapeText(a.entityId))
+	if len(a.nameIdFormat) > 0 {
+		fmt.Fprintf(&req, `<samlp:NameIDPolicy Format="%s" AllowCreate="true"/>`, escapeAttr(a.nameIdFormat))
+	} else {
+		req.WriteString(`<samlp:NameIDPolicy AllowCreate="true"/>`)
+	}
+	req.WriteString(`</samlp:AuthnRequest>`)
+
+	// the redirect binding deflates the request before encoding it
+	var deflated bytes.Buffer
+	w, _ := flate.NewWriter(&deflated, flate.BestCompression)
+	_, _ = w.Write(req.Bytes())
+	_ = w.Close()
+
+	u, err := url.Parse(a.idp.ssoUrl)
+	if err != nil {
+		return a.idp.ssoUrl
+	}
+	q := u.Query()
+	q.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	q.Set("RelayState", state)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// VerifyCode verifies the SAML response of the code and maps its assertion to the details of the user
+func (a authProvider) VerifyCode(ctx context.Context, code string) (*sdk.AuthToken, error) {
+	state, encoded, ok := strings.Cut(code, ".")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed code", sdk.ErrInvalidSamlResponse)
+	}
+	b, err := decodeBase64(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid base64 %w", sdk.ErrInvalidSamlResponse, err)
+	}
+	as, err := a.parseResponse(b, requestId(state), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", sdk.ErrInvalidSamlResponse, err)
+	}
+
+	token, err := json.Marshal(a.mapIdentity(*as))
+	if err != nil {
+		return nil, fmt.Errorf("error encoding the identity %w", err)
+	}
+	return &sdk.AuthToken{
+		AccessToken: base64.RawURLEncoding.EncodeToString(token),
+		ExpiresAt:   time.Now().Add(time.Hour * 24),
+	}, nil
+}
+
+// RefreshToken is not supported by the SAML provider
+func (a authProvider) RefreshToken(refreshToken string) (*sdk.AuthToken, error) {
+	return nil, fmt.Errorf("refresh token flow is not supported by the saml provider")
+}
+
+// identity is the user details mapped from an assertion
+type identity struct {
+	NameId  string `json:"name_id"`
+	Email   string `json:"email,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Picture string `json:"picture,omitempty"`
+}
+
+// mapIdentity picks the user details out of the attributes. The NameID is the email address
+// when the IdP does not send one as an attribute but identifies the user by their email address.
+func (a authProvider) mapIdentity(as assertion) identity {
+	value := func(field string) string {
+		for _, name := range a.mapping[field] {
+			if v := as.attributes[name]; len(v) > 0 && len(v[0]) > 0 {
+				return v[0]
+			}
+		}
+		return ""
+	}
+	id := identity{
+		NameId:  as.nameId,
+		Email:   value(fieldEmail),
+		Name:    value(fieldName),
+		Phone:   value(fieldPhone),
+		Picture: value(fieldPicture),
+	}
+	if len(id.Email) == 0 && (as.nameIdFormat == nameIdFormatEmailAddress || strings.Contains(as.nameId, "@")) {
+		id.Email = as.nameId
+	}
+	if len(id.Name) == 0 {
+		id.Name = strings.TrimSpace(value(fieldGivenName) + " " + value(fieldFamilyName))
+	}
+	return id
+}
+
+// SamlIdentityEmail handles email identity information
+type SamlIdentityEmail struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+func (s SamlIdentityEmail) UpdateUserDetails(user *sdk.User) {
+	user.Email = s.Email
+	user.EmailVerified = s.EmailVerified
+}
+
+// SamlIdentityName handles name identity information
+type SamlIdentityName struct {
+	Name string `json:"name"`
+}
+
+func (s SamlIdentityName) UpdateUserDetails(user *sdk.User) {
+	user.Name = s.Name
+}
+
+// SamlIdentityPhone handles phone identity information
+type SamlIdentityPhone struct {
+	Phone string `json:"phone"`
+}
+
+func (s SamlIdentityPhone) UpdateUserDetails(user *sdk.User) {
+	user.Phone = s.Phone
+}
+
+// SamlIdentityProfilePic handles profile picture identity information
+type SamlIdentityProfilePic struct {
+	ProfilePic string `json:"picture"`
+}
+
+func (s SamlIdentityProfilePic) UpdateUserDetails(user *sdk.User) {
+	user.ProfilePic = s.ProfilePic
+}
+
+// GetIdentity decodes the user details mapped from the assertion
+func (a authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saml access token %w", err)
+	}
+	id := identity{}
+	err = json.Unmarshal(b, &id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saml access token %w", err)
+	}
+
+	var identities []sdk.AuthIdentity
+	if len(id.NameId) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeSubject, Metadata: sdk.AuthIdentitySubject{Subject: id.NameId}})
+	}
+	// the identity provider of the organisation vouches for the email address of the signed assertion
+	if len(id.Email) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeEmail, Metadata: SamlIdentityEmail{Email: id.Email, EmailVerified: true}})
+	}
+	if len(id.Phone) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypePhone, Metadata: SamlIdentityPhone{Phone: id.Phone}})
+	}
+	if len(id.Name) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeEmail, Metadata: SamlIdentityName{Name: id.Name}})
+	}
+	if len(id.Picture) > 0 {
+		identities = append(identities, sdk.AuthIdentity{Type: sdk.AuthIdentityTypeEmail, Metadata: SamlIdentityProfilePic{ProfilePic: id.Picture}})
+	}
+	return identities, nil
+}
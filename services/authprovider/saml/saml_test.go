@@ -0,0 +1,383 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIdpEntityId = "https://idp.example.com/metadata"
+	testSsoUrl      = "https://idp.example.com/sso?tenant=acme"
+	testAcsUrl      = "https://iam.example.com/auth/v1/saml/acs"
+	testEntityId    = "https://iam.example.com/saml"
+	testState       = "4f8b7c1e-5d3a-4b8e-9f2a-0c6d1e7a9b3c"
+)
+
+// testIdp is an IdP with a locally generated keypair, signing the responses the way ADFS and Okta do
+type testIdp struct {
+	key  *rsa.PrivateKey
+	cert []byte
+}
+
+func newTestIdp(t *testing.T) testIdp {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour * 24 * 365),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return testIdp{key: key, cert: cert}
+}
+
+func (i testIdp) metadata() string {
+	return fmt.Sprintf(`<?xml version="1.0"?>
+<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="%s">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+        <ds:X509Data><ds:X509Certificate>
+%s
+        </ds:X509Certificate></ds:X509Data>
+      </ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://idp.example.com/sso/post"/>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="%s"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`, testIdpEntityId, base64.StdEncoding.EncodeToString(i.cert), strings.ReplaceAll(testSsoUrl, "&", "&amp;"))
+}
+
+// sign replaces the placeholder comment of the element with the ID with its enveloped signature
+func (i testIdp) sign(t *testing.T, doc, id string) string {
+	root, err := parseXml([]byte(doc))
+	require.NoError(t, err)
+	el := findById(root, id)
+	require.NotNil(t, el)
+	digest := sha256.Sum256(canonicalize(el, nil, nil))
+
+	signedInfo := fmt.Sprintf(`<ds:SignedInfo xmlns:ds="%s"><ds:CanonicalizationMethod Algorithm="%s"></ds:CanonicalizationMethod><ds:SignatureMethod Algorithm="%s"></ds:SignatureMethod>`+
+		`<ds:Reference URI="#%s"><ds:Transforms><ds:Transform Algorithm="%s"></ds:Transform><ds:Transform Algorithm="%s"></ds:Transform></ds:Transforms>`+
+		`<ds:DigestMethod Algorithm="%s"></ds:DigestMethod><ds:DigestValue>%s</ds:DigestValue></ds:Reference></ds:SignedInfo>`,
+		nsDsig, algExcC14n, algRsaSha256, id, algEnveloped, algExcC14n, algSha256, base64.StdEncoding.EncodeToString(digest[:]))
+	hashed := sha256.Sum256([]byte(signedInfo))
+	value, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, hashed[:])
+	require.NoError(t, err)
+
+	signature := fmt.Sprintf(`<ds:Signature xmlns:ds="%s">%s<ds:SignatureValue>%s</ds:SignatureValue></ds:Signature>`,
+		nsDsig, strings.Replace(signedInfo, ` xmlns:ds="`+nsDsig+`"`, "", 1), base64.StdEncoding.EncodeToString(value))
+	return strings.Replace(doc, "<!--sig:"+id+"-->", signature, 1)
+}
+
+func findById(n *xmlNode, id string) *xmlNode {
+	if n.attr("ID") == id {
+		return n
+	}
+	for _, c := range n.children {
+		if e, ok := c.(*xmlNode); ok {
+			if found := findById(e, id); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// testResponse holds the values of a response, the valid ones by default
+type testResponse struct {
+	state        string
+	status       string
+	issuer       string
+	recipient    string
+	audience     string
+	notOnOrAfter time.Time
+	nameId       string
+	nameIdFormat string
+	attributes   map[string]string
+}
+
+func validResponse() testResponse {
+	return testResponse{
+		state:        testState,
+		status:       statusSuccess,
+		issuer:       testIdpEntityId,
+		recipient:    testAcsUrl,
+		audience:     testEntityId,
+		notOnOrAfter: time.Now().Add(5 * time.Minute),
+		nameId:       "jane@example.com",
+		nameIdFormat: nameIdFormatEmailAddress,
+		attributes: map[string]string{
+			"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress": "jane.doe@example.com",
+			"givenName": "Jane",
+			"sn":        "Doe",
+		},
+	}
+}
+
+func (r testResponse) xml() string {
+	now := time.Now().UTC().Format(time.RFC3339)
+	expiry := r.notOnOrAfter.UTC().Format(time.RFC3339)
+	attrs := ""
+	for name, value := range r.attributes {
+		attrs += fmt.Sprintf(`<saml:Attribute Name="%s"><saml:AttributeValue xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="xs:string">%s</saml:AttributeValue></saml:Attribute>`, name, value)
+	}
+	return fmt.Sprintf(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="_resp" Version="2.0" IssueInstant="%[1]s" Destination="%[2]s" InResponseTo="%[3]s">
+  <saml:Issuer xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion">%[4]s</saml:Issuer><!--sig:_resp-->
+  <samlp:Status><samlp:StatusCode Value="%[5]s"/></samlp:Status>
+  <saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" xmlns:xs="http://www.w3.org/2001/XMLSchema" ID="_assertion" Version="2.0" IssueInstant="%[1]s">
+    <saml:Issuer>%[4]s</saml:Issuer><!--sig:_assertion-->
+    <saml:Subject>
+      <saml:NameID Format="%[6]s">%[7]s</saml:NameID>
+      <saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">
+        <saml:SubjectConfirmationData InResponseTo="%[3]s" NotOnOrAfter="%[8]s" Recipient="%[9]s"/>
+      </saml:SubjectConfirmation>
+    </saml:Subject>
+    <saml:Conditions NotBefore="%[1]s" NotOnOrAfter="%[8]s">
+      <saml:AudienceRestriction><saml:Audience>%[10]s</saml:Audience></saml:AudienceRestriction>
+    </saml:Conditions>
+    <saml:AuthnStatement AuthnInstant="%[1]s"><saml:AuthnContext><saml:AuthnContextClassRef>urn:oasis:names:tc:SAML:2.0:ac:classes:PasswordProtectedTransport</saml:AuthnContextClassRef></saml:AuthnContext></saml:AuthnStatement>
+    <saml:AttributeStatement>%[11]s</saml:AttributeStatement>
+  </saml:Assertion>
+</samlp:Response>`, now, testAcsUrl, requestId(r.state), r.issuer, r.status, r.nameIdFormat, r.nameId, expiry, r.recipient, r.audience, attrs)
+}
+
+func testProvider(t *testing.T, idp testIdp, extra ...sdk.AuthProviderParam) authProvider {
+	p := sdk.AuthProvider{
+		Name:     "Acme SAML",
+		Provider: sdk.AuthProviderTypeSAML,
+		Params: append([]sdk.AuthProviderParam{
+			{Key: sdk.SamlParamIdpMetadata, Value: idp.metadata()},
+			{Key: sdk.SamlParamAcsUrl, Value: testAcsUrl},
+			{Key: sdk.SamlParamEntityId, Value: testEntityId},
+		}, extra...),
+	}
+	sp, err := NewAuthProvider(p)
+	require.NoError(t, err)
+	return sp.(authProvider)
+}
+
+func verify(sp authProvider, doc string) (*sdk.AuthToken, error) {
+	return sp.VerifyCode(context.Background(), ResponseCode(testState, base64.StdEncoding.EncodeToString([]byte(doc))))
+}
+
+func TestNewAuthProvider(t *testing.T) {
+	idp := newTestIdp(t)
+	sp := testProvider(t, idp)
+	assert.Equal(t, testIdpEntityId, sp.idp.entityId)
+	assert.Equal(t, testSsoUrl, sp.idp.ssoUrl)
+	require.Len(t, sp.idp.certs, 1)
+	assert.Equal(t, idp.cert, sp.idp.certs[0].Raw)
+
+	tests := []struct {
+		name   string
+		params []sdk.AuthProviderParam
+	}{
+		{name: "missing metadata", params: []sdk.AuthProviderParam{{Key: sdk.SamlParamAcsUrl, Value: testAcsUrl}}},
+		{name: "missing acs url", params: []sdk.AuthProviderParam{{Key: sdk.SamlParamIdpMetadata, Value: idp.metadata()}}},
+		{name: "metadata without certificate", params: []sdk.AuthProviderParam{
+			{Key: sdk.SamlParamIdpMetadata, Value: `<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="x"><md:IDPSSODescriptor><md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp"/></md:IDPSSODescriptor></md:EntityDescriptor>`},
+			{Key: sdk.SamlParamAcsUrl, Value: testAcsUrl},
+		}},
+		{name: "unknown mapped field", params: []sdk.AuthProviderParam{
+			{Key: sdk.SamlParamIdpMetadata, Value: idp.metadata()},
+			{Key: sdk.SamlParamAcsUrl, Value: testAcsUrl},
+			{Key: sdk.SamlParamAttributeMapping, Value: `{"department": "dept"}`},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAuthProvider(sdk.AuthProvider{Name: "Acme SAML", Params: tt.params})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGetAuthCodeUrl(t *testing.T) {
+	sp := testProvider(t, newTestIdp(t), sdk.AuthProviderParam{Key: sdk.SamlParamNameIdFormat, Value: nameIdFormatEmailAddress})
+
+	u, err := url.Parse(sp.GetAuthCodeUrl(testState))
+	require.NoError(t, err)
+	assert.Equal(t, "idp.example.com", u.Host)
+	assert.Equal(t, "acme", u.Query().Get("tenant"))
+	assert.Equal(t, testState, u.Query().Get("RelayState"))
+
+	deflated, err := base64.StdEncoding.DecodeString(u.Query().Get("SAMLRequest"))
+	require.NoError(t, err)
+	raw, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	require.NoError(t, err)
+	req, err := parseXml(raw)
+	require.NoError(t, err)
+	assert.True(t, req.is(nsProtocol, "AuthnRequest"))
+	assert.Equal(t, requestId(testState), req.attr("ID"))
+	assert.Equal(t, testSsoUrl, req.attr("Destination"))
+	assert.Equal(t, testAcsUrl, req.attr("AssertionConsumerServiceURL"))
+	assert.Equal(t, testEntityId, req.element(nsAssertion, "Issuer").text())
+	assert.Equal(t, nameIdFormatEmailAddress, req.element(nsProtocol, "NameIDPolicy").attr("Format"))
+}
+
+func TestVerifyCode(t *testing.T) {
+	idp := newTestIdp(t)
+	sp := testProvider(t, idp)
+
+	t.Run("signed assertion", func(t *testing.T) {
+		token, err := verify(sp, idp.sign(t, validResponse().xml(), "_assertion"))
+		require.NoError(t, err)
+		identities, err := sp.GetIdentity(token.AccessToken)
+		require.NoError(t, err)
+
+		usr := &sdk.User{}
+		for _, i := range identities {
+			i.UpdateUserDetails(usr)
+		}
+		assert.Equal(t, "jane.doe@example.com", usr.Email)
+		assert.Equal(t, "Jane Doe", usr.Name)
+	})
+
+	t.Run("signed response", func(t *testing.T) {
+		_, err := verify(sp, idp.sign(t, validResponse().xml(), "_resp"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("signed response and assertion", func(t *testing.T) {
+		doc := idp.sign(t, idp.sign(t, validResponse().xml(), "_assertion"), "_resp")
+		_, err := verify(sp, doc)
+		assert.NoError(t, err)
+	})
+
+	t.Run("name id stands for the email address", func(t *testing.T) {
+		r := validResponse()
+		r.attributes = map[string]string{"displayName": "Jane"}
+		token, err := verify(sp, idp.sign(t, r.xml(), "_assertion"))
+		require.NoError(t, err)
+		identities, err := sp.GetIdentity(token.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, []sdk.AuthIdentity{
+			{Type: sdk.AuthIdentityTypeSubject, Metadata: sdk.AuthIdentitySubject{Subject: "jane@example.com"}},
+			{Type: sdk.AuthIdentityTypeEmail, Metadata: SamlIdentityEmail{Email: "jane@example.com", EmailVerified: true}},
+			{Type: sdk.AuthIdentityTypeEmail, Metadata: SamlIdentityName{Name: "Jane"}},
+		}, identities)
+	})
+
+	t.Run("configured attribute mapping", func(t *testing.T) {
+		mapped := testProvider(t, idp, sdk.AuthProviderParam{Key: sdk.SamlParamAttributeMapping, Value: `{"email": "upn", "phone": "cell"}`})
+		r := validResponse()
+		r.attributes = map[string]string{"upn": "jdoe@corp.example.com", "cell": "+919876543210", "mail": "ignored@example.com"}
+		token, err := verify(mapped, idp.sign(t, r.xml(), "_assertion"))
+		require.NoError(t, err)
+		identities, err := mapped.GetIdentity(token.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, []sdk.AuthIdentity{
+			{Type: sdk.AuthIdentityTypeSubject, Metadata: sdk.AuthIdentitySubject{Subject: "jane@example.com"}},
+			{Type: sdk.AuthIdentityTypeEmail, Metadata: SamlIdentityEmail{Email: "jdoe@corp.example.com", EmailVerified: true}},
+			{Type: sdk.AuthIdentityTypePhone, Metadata: SamlIdentityPhone{Phone: "+919876543210"}},
+		}, identities)
+	})
+
+	other := newTestIdp(t)
+	tests := []struct {
+		name   string
+		doc    func() string
+		expect string
+	}{
+		{name: "unsigned", doc: func() string { return validResponse().xml() }, expect: "neither the response nor the assertion is signed"},
+		{name: "signed by another idp", doc: func() string { return other.sign(t, validResponse().xml(), "_assertion") }, expect: "not signed by the IdP"},
+		{name: "tampered after signing", doc: func() string {
+			return strings.Replace(idp.sign(t, validResponse().xml(), "_assertion"), "jane.doe@example.com", "admin@example.com", 1)
+		}, expect: "digest mismatch"},
+		{name: "another login", doc: func() string {
+			r := validResponse()
+			r.state = "another-state"
+			return idp.sign(t, r.xml(), "_assertion")
+		}, expect: "does not answer the authentication request"},
+		{name: "expired", doc: func() string {
+			r := validResponse()
+			r.notOnOrAfter = time.Now().Add(-5 * time.Minute)
+			return idp.sign(t, r.xml(), "_assertion")
+		}, expect: "no valid bearer subject confirmation"},
+		{name: "another audience", doc: func() string {
+			r := validResponse()
+			r.audience = "https://other.example.com"
+			return idp.sign(t, r.xml(), "_assertion")
+		}, expect: "not meant for"},
+		{name: "another recipient", doc: func() string {
+			r := validResponse()
+			r.recipient = "https://other.example.com/acs"
+			return idp.sign(t, r.xml(), "_assertion")
+		}, expect: "no valid bearer subject confirmation"},
+		{name: "another issuer", doc: func() string {
+			r := validResponse()
+			r.issuer = "https://evil.example.com"
+			return idp.sign(t, r.xml(), "_assertion")
+		}, expect: "issued by"},
+		{name: "failed status", doc: func() string {
+			r := validResponse()
+			r.status = "urn:oasis:names:tc:SAML:2.0:status:Requester"
+			return idp.sign(t, r.xml(), "_assertion")
+		}, expect: "status urn:oasis:names:tc:SAML:2.0:status:Requester"},
+		{name: "unsigned assertion next to the signed one", doc: func() string {
+			signed := idp.sign(t, validResponse().xml(), "_assertion")
+			start := strings.Index(signed, "<saml:Assertion")
+			end := strings.Index(signed, "</saml:Assertion>") + len("</saml:Assertion>")
+			evil := strings.Replace(strings.Replace(signed[start:end], "_assertion", "_evil", 1), "jane.doe@example.com", "admin@example.com", 1)
+			return signed[:end] + evil + signed[end:]
+		}, expect: "expected a single assertion"},
+		{name: "signature of another element", doc: func() string {
+			signed := idp.sign(t, validResponse().xml(), "_assertion")
+			return strings.Replace(signed, `ID="_assertion"`, `ID="_other"`, 1)
+		}, expect: "does not cover the element"},
+		{name: "document type declaration", doc: func() string {
+			return `<!DOCTYPE r [<!ENTITY x "y">]>` + idp.sign(t, validResponse().xml(), "_assertion")
+		}, expect: "document type declarations are not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run("rejects "+tt.name, func(t *testing.T) {
+			_, err := verify(sp, tt.doc())
+			require.Error(t, err)
+			assert.ErrorIs(t, err, sdk.ErrInvalidSamlResponse)
+			assert.Contains(t, err.Error(), tt.expect)
+		})
+	}
+
+	t.Run("rejects a malformed code", func(t *testing.T) {
+		_, err := sp.VerifyCode(context.Background(), "no-state")
+		assert.ErrorIs(t, err, sdk.ErrInvalidSamlResponse)
+	})
+}
+
+func TestMetadata(t *testing.T) {
+	md, err := Metadata(sdk.AuthProvider{Params: []sdk.AuthProviderParam{
+		{Key: sdk.SamlParamAcsUrl, Value: testAcsUrl},
+	}})
+	require.NoError(t, err)
+	root, err := parseXml(md)
+	require.NoError(t, err)
+	assert.True(t, root.is(nsMetadata, "EntityDescriptor"))
+	assert.Equal(t, testAcsUrl, root.attr("entityID"))
+	acs := root.element(nsMetadata, "SPSSODescriptor").element(nsMetadata, "AssertionConsumerService")
+	assert.Equal(t, bindingPost, acs.attr("Binding"))
+	assert.Equal(t, testAcsUrl, acs.attr("Location"))
+
+	_, err = Metadata(sdk.AuthProvider{Name: "Acme SAML"})
+	assert.EqualError(t, err, "the acs url of Acme SAML is not configured")
+}
@@ -0,0 +1,294 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const nsXml = "http://www.w3.org/XML/1998/namespace"
+
+// maxXmlDepth bounds the nesting of the documents posted by the IdPs, SAML responses are less than ten levels deep
+const maxXmlDepth = 32
+
+var errInvalidXml = errors.New("invalid xml")
+
+// xmlAttr is an attribute of an element, other than a namespace declaration
+type xmlAttr struct {
+	prefix string
+	local  string
+	value  string
+}
+
+// xmlNode is an element of a parsed document. Unlike encoding/xml it keeps the prefixes and the
+// namespace declarations as written, which the canonicalization of the signed elements needs.
+type xmlNode struct {
+	parent   *xmlNode
+	prefix   string
+	local    string
+	attrs    []xmlAttr
+	ns       map[string]string // namespace declarations of the element, "" is the default namespace
+	children []interface{}     // *xmlNode, xml.CharData or xml.ProcInst, comments are dropped
+}
+
+// parseXml parses a document into a tree. Documents with a DTD are rejected, so are unbalanced ones.
+func parseXml(b []byte) (*xmlNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	var root, current *xmlNode
+	depth := 0
+	for {
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidXml, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if current == nil && root != nil {
+				return nil, fmt.Errorf("%w: more than one root element", errInvalidXml)
+			}
+			depth++
+			if depth > maxXmlDepth {
+				return nil, fmt.Errorf("%w: nested too deep", errInvalidXml)
+			}
+			n := &xmlNode{parent: current, prefix: t.Name.Space, local: t.Name.Local, ns: map[string]string{}}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					n.ns[""] = a.Value
+				case a.Name.Space == "xmlns":
+					n.ns[a.Name.Local] = a.Value
+				default:
+					n.attrs = append(n.attrs, xmlAttr{prefix: a.Name.Space, local: a.Name.Local, value: a.Value})
+				}
+			}
+			if current == nil {
+				root = n
+			} else {
+				current.children = append(current.children, n)
+			}
+			current = n
+		case xml.EndElement:
+			if current == nil || current.prefix != t.Name.Space || current.local != t.Name.Local {
+				return nil, fmt.Errorf("%w: unexpected end element %s", errInvalidXml, t.Name.Local)
+			}
+			depth--
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, t.Copy())
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, fmt.Errorf("%w: text outside of the root element", errInvalidXml)
+			}
+		case xml.ProcInst:
+			if current != nil {
+				current.children = append(current.children, t.Copy())
+			}
+		case xml.Directive:
+			return nil, fmt.Errorf("%w: document type declarations are not allowed", errInvalidXml)
+		}
+	}
+	if root == nil || current != nil {
+		return nil, fmt.Errorf("%w: incomplete document", errInvalidXml)
+	}
+	return root, nil
+}
+
+// lookupNs returns the namespace the prefix is bound to on the element
+func (n *xmlNode) lookupNs(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return nsXml, true
+	}
+	for e := n; e != nil; e = e.parent {
+		if uri, ok := e.ns[prefix]; ok {
+			return uri, true
+		}
+	}
+	return "", false
+}
+
+// space is the namespace of the element
+func (n *xmlNode) space() string {
+	uri, _ := n.lookupNs(n.prefix)
+	return uri
+}
+
+// is tells whether the element has the namespace and the local name
+func (n *xmlNode) is(space, local string) bool {
+	return n.local == local && n.space() == space
+}
+
+// elements returns the child elements with the namespace and the local name
+func (n *xmlNode) elements(space, local string) []*xmlNode {
+	result := []*xmlNode{}
+	for _, c := range n.children {
+		if e, ok := c.(*xmlNode); ok && e.is(space, local) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// element returns the first child element with the namespace and the local name, nil if there is none
+func (n *xmlNode) element(space, local string) *xmlNode {
+	for _, c := range n.children {
+		if e, ok := c.(*xmlNode); ok && e.is(space, local) {
+			return e
+		}
+	}
+	return nil
+}
+
+// attr returns the value of an attribute without a namespace
+func (n *xmlNode) attr(local string) string {
+	for _, a := range n.attrs {
+		if a.prefix == "" && a.local == local {
+			return a.value
+		}
+	}
+	return ""
+}
+
+// text returns the text content of the element, trimmed of the surrounding white space
+func (n *xmlNode) text() string {
+	var b strings.Builder
+	for _, c := range n.children {
+		if t, ok := c.(xml.CharData); ok {
+			b.Write(t)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// canonicalize returns the exclusive XML canonicalization, without comments, of the subtree of the element.
+// The prefixes of the InclusiveNamespaces PrefixList are rendered the way inclusive canonicalization does,
+// "#default" standing for the default namespace. The skip element is left out, it is used to remove
+// the signature for the enveloped signature transform.
+func canonicalize(n *xmlNode, inclusivePrefixes []string, skip *xmlNode) []byte {
+	var b bytes.Buffer
+	writeCanonical(&b, n, map[string]string{}, inclusivePrefixes, skip)
+	return b.Bytes()
+}
+
+// writeCanonical writes an element, rendered holds the namespaces rendered by its output ancestors
+func writeCanonical(b *bytes.Buffer, n *xmlNode, rendered map[string]string, inclusivePrefixes []string, skip *xmlNode) {
+	// the namespaces visibly utilized by the element and its attributes, then the inclusive ones in scope
+	candidates := map[string]bool{n.prefix: true}
+	for _, a := range n.attrs {
+		if a.prefix != "" && a.prefix != "xml" {
+			candidates[a.prefix] = true
+		}
+	}
+	for _, p := range inclusivePrefixes {
+		if p == "#default" {
+			p = ""
+		}
+		if _, ok := n.lookupNs(p); ok {
+			candidates[p] = true
+		}
+	}
+
+	prefixes := []string{}
+	own := map[string]string{}
+	for p := range candidates {
+		uri, ok := n.lookupNs(p)
+		if !ok && p != "" {
+			continue
+		}
+		current, done := rendered[p]
+		if p == "" && !done {
+			current, done = "", true
+		}
+		if done && current == uri {
+			continue
+		}
+		prefixes = append(prefixes, p)
+		own[p] = uri
+	}
+	sort.Strings(prefixes)
+
+	attrs := append([]xmlAttr{}, n.attrs...)
+	attrNs := func(a xmlAttr) string {
+		if a.prefix == "" {
+			return ""
+		}
+		uri, _ := n.lookupNs(a.prefix)
+		return uri
+	}
+	sort.SliceStable(attrs, func(i, j int) bool {
+		si, sj := attrNs(attrs[i]), attrNs(attrs[j])
+		if si != sj {
+			return si < sj
+		}
+		return attrs[i].local < attrs[j].local
+	})
+
+	name := qualifiedName(n.prefix, n.local)
+	b.WriteString("<" + name)
+	for _, p := range prefixes {
+		if p == "" {
+			b.WriteString(` xmlns="`)
+		} else {
+			b.WriteString(" xmlns:" + p + `="`)
+		}
+		b.WriteString(escapeAttr(own[p]))
+		b.WriteString(`"`)
+	}
+	for _, a := range attrs {
+		b.WriteString(" " + qualifiedName(a.prefix, a.local) + `="` + escapeAttr(a.value) + `"`)
+	}
+	b.WriteString(">")
+
+	childRendered := rendered
+	if len(own) > 0 {
+		childRendered = make(map[string]string, len(rendered)+len(own))
+		for k, v := range rendered {
+			childRendered[k] = v
+		}
+		for k, v := range own {
+			childRendered[k] = v
+		}
+	}
+	for _, c := range n.children {
+		switch t := c.(type) {
+		case *xmlNode:
+			if t != skip {
+				writeCanonical(b, t, childRendered, inclusivePrefixes, skip)
+			}
+		case xml.CharData:
+			b.WriteString(escapeText(string(t)))
+		case xml.ProcInst:
+			b.WriteString("<?" + t.Target)
+			if len(t.Inst) > 0 {
+				b.WriteString(" " + string(t.Inst))
+			}
+			b.WriteString("?>")
+		}
+	}
+	b.WriteString("</" + name + ">")
+}
+
+func qualifiedName(prefix, local string) string {
+	if prefix == "" {
+		return local
+	}
+	return prefix + ":" + local
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+
+var attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
@@ -0,0 +1,72 @@
+package saml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseXml(t *testing.T) {
+	t.Run("namespaces are resolved through the ancestors", func(t *testing.T) {
+		root, err := parseXml([]byte(`<a:root xmlns:a="urn:a" xmlns="urn:d"><a:child ID="1"/><plain>text</plain></a:root>`))
+		require.NoError(t, err)
+		assert.True(t, root.is("urn:a", "root"))
+		assert.Equal(t, "1", root.element("urn:a", "child").attr("ID"))
+		assert.Equal(t, "text", root.element("urn:d", "plain").text())
+		assert.Nil(t, root.element("urn:a", "plain"))
+	})
+
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{name: "document type declaration", doc: `<!DOCTYPE r [<!ENTITY x "y">]><r>&x;</r>`},
+		{name: "unbalanced elements", doc: `<a><b></a></b>`},
+		{name: "incomplete document", doc: `<a><b/>`},
+		{name: "two root elements", doc: `<a/><b/>`},
+		{name: "empty document", doc: ``},
+	}
+	for _, tt := range tests {
+		t.Run("rejects "+tt.name, func(t *testing.T) {
+			_, err := parseXml([]byte(tt.doc))
+			assert.ErrorIs(t, err, errInvalidXml)
+		})
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	// the expected output is the one of xmllint --exc-c14n, without the comment
+	doc := `<?xml version="1.0"?>
+<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:unused="urn:unused" xmlns="urn:default" ID="_1" b="2" a='x &amp; "y"&#9;z'><!-- comment --><saml:Issuer xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion">idp &lt;&gt; ok</saml:Issuer>
+  <Plain xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="x" z:b="1" xmlns:z="urn:z" c="3"/>
+  <inner xmlns=""><deep/></inner>
+</samlp:Response>`
+	expected := `<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="_1" a="x &amp; &quot;y&quot;&#x9;z" b="2"><saml:Issuer xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion">idp &lt;&gt; ok</saml:Issuer>
+  <Plain xmlns="urn:default" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:z="urn:z" c="3" xsi:type="x" z:b="1"></Plain>
+  <inner><deep></deep></inner>
+</samlp:Response>`
+
+	root, err := parseXml([]byte(doc))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(canonicalize(root, nil, nil)))
+
+	t.Run("subtrees render the namespaces declared by their ancestors", func(t *testing.T) {
+		issuer := root.element("urn:oasis:names:tc:SAML:2.0:assertion", "Issuer")
+		plain := root.element("urn:default", "Plain")
+		assert.Equal(t, `<saml:Issuer xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion">idp &lt;&gt; ok</saml:Issuer>`, string(canonicalize(issuer, nil, nil)))
+		assert.Equal(t, `<Plain xmlns="urn:default" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:z="urn:z" c="3" xsi:type="x" z:b="1"></Plain>`, string(canonicalize(plain, nil, nil)))
+	})
+
+	t.Run("inclusive prefixes are rendered when in scope", func(t *testing.T) {
+		issuer := root.element("urn:oasis:names:tc:SAML:2.0:assertion", "Issuer")
+		assert.Equal(t, `<saml:Issuer xmlns="urn:default" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" xmlns:unused="urn:unused">idp &lt;&gt; ok</saml:Issuer>`,
+			string(canonicalize(issuer, []string{"#default", "unused", "missing"}, nil)))
+	})
+
+	t.Run("the skipped element is left out", func(t *testing.T) {
+		parsed, err := parseXml([]byte(`<a ID="1"><sig>x</sig><b/></a>`))
+		require.NoError(t, err)
+		assert.Equal(t, `<a ID="1"><b></b></a>`, string(canonicalize(parsed, nil, parsed.element("", "sig"))))
+	})
+}
@@ -12,4 +12,5 @@ type Service interface {
 	Create(ctx context.Context, provider *sdk.AuthProvider) error
 	Update(ctx context.Context, provider *sdk.AuthProvider) error
 	GetProvider(ctx context.Context, v sdk.AuthProvider) (sdk.ServiceProvider, error)
+	GetTypes(ctx context.Context) []sdk.AuthProviderTypeInfo
 }
@@ -2,27 +2,35 @@ package authprovider
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/melvinodsa/go-iam/middlewares"
 	"github.com/melvinodsa/go-iam/sdk"
-	"github.com/melvinodsa/go-iam/services/authprovider/github"
-	"github.com/melvinodsa/go-iam/services/authprovider/google"
-	"github.com/melvinodsa/go-iam/services/authprovider/microsoft"
-	"github.com/melvinodsa/go-iam/services/authprovider/oidc"
+	"github.com/melvinodsa/go-iam/services/authprovider/ldap"
+	"github.com/melvinodsa/go-iam/services/authprovider/passkey"
+	"github.com/melvinodsa/go-iam/services/authprovider/password"
+	"github.com/melvinodsa/go-iam/services/authprovider/passwordless"
+	"github.com/melvinodsa/go-iam/services/authprovider/registry"
 	"github.com/melvinodsa/go-iam/services/project"
 	"github.com/melvinodsa/go-iam/utils"
 )
 
 type service struct {
-	s Store
-	p project.Service
+	s           Store
+	p           project.Service
+	credentials password.CredentialService
+	codes       passwordless.CodeService
+	passkeys    passkey.CodeService
+	directories ldap.CodeService
 }
 
-func NewService(s Store, p project.Service) Service {
+func NewService(s Store, p project.Service, credentials password.CredentialService, codes passwordless.CodeService, passkeys passkey.CodeService, directories ldap.CodeService) Service {
 	return &service{
-		s: s,
-		p: p,
+		s:           s,
+		p:           p,
+		credentials: credentials,
+		codes:       codes,
+		passkeys:    passkeys,
+		directories: directories,
 	}
 }
 
@@ -53,6 +61,10 @@ func (s service) Create(ctx context.Context, provider *sdk.AuthProvider) error {
 	if _, ok := projectIdsMap[provider.ProjectId]; !ok {
 		return sdk.ErrProjectNotFound
 	}
+	err := registry.Validate(provider)
+	if err != nil {
+		return err
+	}
 	return s.s.Create(ctx, provider)
 }
 func (s service) Update(ctx context.Context, provider *sdk.AuthProvider) error {
@@ -61,20 +73,22 @@ func (s service) Update(ctx context.Context, provider *sdk.AuthProvider) error {
 	if _, ok := projectIdsMap[provider.ProjectId]; !ok {
 		return sdk.ErrProjectNotFound
 	}
+	err := registry.Validate(provider)
+	if err != nil {
+		return err
+	}
 	return s.s.Update(ctx, provider)
 }
 
 func (s service) GetProvider(ctx context.Context, v sdk.AuthProvider) (sdk.ServiceProvider, error) {
-	switch v.Provider {
-	case sdk.AuthProviderTypeGoogle:
-		return google.NewAuthProvider(v), nil
-	case sdk.AuthProviderTypeMicrosoft:
-		return microsoft.NewAuthProvider(v), nil
-	case sdk.AuthProviderTypeGitHub:
-		return github.NewAuthProvider(v), nil
-	case sdk.AuthProviderTypeOIDC:
-		return oidc.NewAuthProvider(v), nil
-	default:
-		return nil, fmt.Errorf("unknown auth provider: %s", v.Provider)
-	}
+	return registry.New(ctx, v, registry.Dependencies{
+		Credentials: s.credentials,
+		Codes:       s.codes,
+		Passkeys:    s.passkeys,
+		Directories: s.directories,
+	})
+}
+
+func (s service) GetTypes(ctx context.Context) []sdk.AuthProviderTypeInfo {
+	return registry.Types()
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/melvinodsa/go-iam/services/project"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockStore implements Store interface for testing
@@ -90,7 +91,7 @@ func TestNewService(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := NewService(tt.store, tt.project)
+			result := NewService(tt.store, tt.project, nil, nil, nil, nil)
 
 			// Check that the service is not nil
 			assert.NotNil(t, result)
@@ -223,7 +224,7 @@ func TestService_GetAll(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockStore := &MockStore{}
 			mockProject := &MockProjectService{}
-			svc := NewService(mockStore, mockProject)
+			svc := NewService(mockStore, mockProject, nil, nil, nil, nil)
 
 			ctx := tt.contextSetup()
 			tt.mockSetup(mockStore)
@@ -384,7 +385,7 @@ func TestService_Get(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockStore := &MockStore{}
 			mockProject := &MockProjectService{}
-			svc := NewService(mockStore, mockProject)
+			svc := NewService(mockStore, mockProject, nil, nil, nil, nil)
 
 			ctx := tt.contextSetup()
 			tt.mockSetup(mockStore)
@@ -423,6 +424,8 @@ func TestService_Create(t *testing.T) {
 			provider: &sdk.AuthProvider{
 				Id:        "ap1",
 				Name:      "Provider 1",
+				Provider:  sdk.AuthProviderTypePassword,
+				Params:    []sdk.AuthProviderParam{{Key: sdk.PasswordParamLoginUrl, Value: "http://localhost:4173/login"}},
 				ProjectId: "project1",
 			},
 			mockSetup: func(m *MockStore) {
@@ -443,6 +446,8 @@ func TestService_Create(t *testing.T) {
 			provider: &sdk.AuthProvider{
 				Id:        "ap1",
 				Name:      "Provider 1",
+				Provider:  sdk.AuthProviderTypePassword,
+				Params:    []sdk.AuthProviderParam{{Key: sdk.PasswordParamLoginUrl, Value: "http://localhost:4173/login"}},
 				ProjectId: "project1",
 			},
 			mockSetup:     func(m *MockStore) {},
@@ -459,6 +464,8 @@ func TestService_Create(t *testing.T) {
 			provider: &sdk.AuthProvider{
 				Id:        "ap1",
 				Name:      "Provider 1",
+				Provider:  sdk.AuthProviderTypePassword,
+				Params:    []sdk.AuthProviderParam{{Key: sdk.PasswordParamLoginUrl, Value: "http://localhost:4173/login"}},
 				ProjectId: "project1",
 			},
 			mockSetup:     func(m *MockStore) {},
@@ -475,11 +482,65 @@ func TestService_Create(t *testing.T) {
 			provider: &sdk.AuthProvider{
 				Id:        "ap1",
 				Name:      "Provider 1",
+				Provider:  sdk.AuthProviderTypePassword,
+				Params:    []sdk.AuthProviderParam{{Key: sdk.PasswordParamLoginUrl, Value: "http://localhost:4173/login"}},
 				ProjectId: "project1",
 			},
 			mockSetup:     func(m *MockStore) {},
 			expectedError: sdk.ErrProjectNotFound,
 		},
+		{
+			name: "error_config_failing_the_schema",
+			contextSetup: func() context.Context {
+				return middlewares.AddMetadata(context.Background(), sdk.Metadata{ProjectIds: []string{"project1"}})
+			},
+			provider: &sdk.AuthProvider{
+				Id:        "ap1",
+				Name:      "Provider 1",
+				Provider:  sdk.AuthProviderTypePassword,
+				Params:    []sdk.AuthProviderParam{{Key: sdk.PasswordParamSignupEnabled, Value: "yes"}},
+				ProjectId: "project1",
+			},
+			mockSetup:     func(m *MockStore) {},
+			expectedError: errors.New("invalid auth provider configuration: @PASSWORD/SIGNUP_ENABLED has to be true or false"),
+		},
+		{
+			name: "error_unknown_type",
+			contextSetup: func() context.Context {
+				return middlewares.AddMetadata(context.Background(), sdk.Metadata{ProjectIds: []string{"project1"}})
+			},
+			provider: &sdk.AuthProvider{
+				Id:        "ap1",
+				Name:      "Provider 1",
+				Provider:  "UNKNOWN",
+				ProjectId: "project1",
+			},
+			mockSetup:     func(m *MockStore) {},
+			expectedError: errors.New(`invalid auth provider configuration: unknown auth provider type "UNKNOWN"`),
+		},
+		{
+			name: "success_secrets_flagged_from_the_schema",
+			contextSetup: func() context.Context {
+				return middlewares.AddMetadata(context.Background(), sdk.Metadata{ProjectIds: []string{"project1"}})
+			},
+			provider: &sdk.AuthProvider{
+				Id:       "ap1",
+				Name:     "Google",
+				Provider: sdk.AuthProviderTypeGoogle,
+				Params: []sdk.AuthProviderParam{
+					{Key: "@GOOGLE/CLIENT_ID", Value: "client123"},
+					{Key: "@GOOGLE/CLIENT_SECRET", Value: "secret123"},
+					{Key: "@GOOGLE/REDIRECT_URL", Value: "http://localhost:8080/callback"},
+				},
+				ProjectId: "project1",
+			},
+			mockSetup: func(m *MockStore) {
+				m.On("Create", mock.Anything, mock.MatchedBy(func(p *sdk.AuthProvider) bool {
+					return !p.Params[0].IsSecret && p.Params[1].IsSecret && !p.Params[2].IsSecret
+				})).Return(nil)
+			},
+			expectedError: nil,
+		},
 		{
 			name: "error_from_store",
 			contextSetup: func() context.Context {
@@ -491,6 +552,8 @@ func TestService_Create(t *testing.T) {
 			provider: &sdk.AuthProvider{
 				Id:        "ap1",
 				Name:      "Provider 1",
+				Provider:  sdk.AuthProviderTypePassword,
+				Params:    []sdk.AuthProviderParam{{Key: sdk.PasswordParamLoginUrl, Value: "http://localhost:4173/login"}},
 				ProjectId: "project1",
 			},
 			mockSetup: func(m *MockStore) {
@@ -506,7 +569,7 @@ func TestService_Create(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockStore := &MockStore{}
 			mockProject := &MockProjectService{}
-			svc := NewService(mockStore, mockProject)
+			svc := NewService(mockStore, mockProject, nil, nil, nil, nil)
 
 			ctx := tt.contextSetup()
 			tt.mockSetup(mockStore)
@@ -544,6 +607,8 @@ func TestService_Update(t *testing.T) {
 			provider: &sdk.AuthProvider{
 				Id:        "ap1",
 				Name:      "Provider 1 Updated",
+				Provider:  sdk.AuthProviderTypePassword,
+				Params:    []sdk.AuthProviderParam{{Key: sdk.PasswordParamLoginUrl, Value: "http://localhost:4173/login"}},
 				ProjectId: "project1",
 			},
 			mockSetup: func(m *MockStore) {
@@ -564,6 +629,8 @@ func TestService_Update(t *testing.T) {
 			provider: &sdk.AuthProvider{
 				Id:        "ap1",
 				Name:      "Provider 1 Updated",
+				Provider:  sdk.AuthProviderTypePassword,
+				Params:    []sdk.AuthProviderParam{{Key: sdk.PasswordParamLoginUrl, Value: "http://localhost:4173/login"}},
 				ProjectId: "project1",
 			},
 			mockSetup:     func(m *MockStore) {},
@@ -580,6 +647,8 @@ func TestService_Update(t *testing.T) {
 			provider: &sdk.AuthProvider{
 				Id:        "ap1",
 				Name:      "Provider 1 Updated",
+				Provider:  sdk.AuthProviderTypePassword,
+				Params:    []sdk.AuthProviderParam{{Key: sdk.PasswordParamLoginUrl, Value: "http://localhost:4173/login"}},
 				ProjectId: "project1",
 			},
 			mockSetup:     func(m *MockStore) {},
@@ -596,6 +665,8 @@ func TestService_Update(t *testing.T) {
 			provider: &sdk.AuthProvider{
 				Id:        "ap1",
 				Name:      "Provider 1 Updated",
+				Provider:  sdk.AuthProviderTypePassword,
+				Params:    []sdk.AuthProviderParam{{Key: sdk.PasswordParamLoginUrl, Value: "http://localhost:4173/login"}},
 				ProjectId: "project1",
 			},
 			mockSetup:     func(m *MockStore) {},
@@ -612,6 +683,8 @@ func TestService_Update(t *testing.T) {
 			provider: &sdk.AuthProvider{
 				Id:        "ap1",
 				Name:      "Provider 1 Updated",
+				Provider:  sdk.AuthProviderTypePassword,
+				Params:    []sdk.AuthProviderParam{{Key: sdk.PasswordParamLoginUrl, Value: "http://localhost:4173/login"}},
 				ProjectId: "project1",
 			},
 			mockSetup: func(m *MockStore) {
@@ -627,7 +700,7 @@ func TestService_Update(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockStore := &MockStore{}
 			mockProject := &MockProjectService{}
-			svc := NewService(mockStore, mockProject)
+			svc := NewService(mockStore, mockProject, nil, nil, nil, nil)
 
 			ctx := tt.contextSetup()
 			tt.mockSetup(mockStore)
@@ -701,6 +774,91 @@ func TestService_GetProvider(t *testing.T) {
 			expectedResult: nil, // We can't easily compare the GitHub provider instance
 			expectedError:  nil,
 		},
+		{
+			name: "success_password_provider",
+			authProvider: sdk.AuthProvider{
+				Id:       "ap4",
+				Name:     "Password Provider",
+				Provider: sdk.AuthProviderTypePassword,
+				Params: []sdk.AuthProviderParam{
+					{Key: "@PASSWORD/LOGIN_URL", Value: "http://localhost:4173/login"},
+				},
+				ProjectId: "project1",
+			},
+			expectedResult: nil, // We can't easily compare the password provider instance
+			expectedError:  nil,
+		},
+		{
+			name: "success_passwordless_provider",
+			authProvider: sdk.AuthProvider{
+				Id:       "ap5",
+				Name:     "Passwordless Provider",
+				Provider: sdk.AuthProviderTypePasswordless,
+				Params: []sdk.AuthProviderParam{
+					{Key: "@PASSWORDLESS/LOGIN_URL", Value: "http://localhost:4173/login"},
+				},
+				ProjectId: "project1",
+			},
+			expectedResult: nil, // We can't easily compare the passwordless provider instance
+			expectedError:  nil,
+		},
+		{
+			name: "success_sms_provider",
+			authProvider: sdk.AuthProvider{
+				Id:       "ap6",
+				Name:     "Sms Provider",
+				Provider: sdk.AuthProviderTypeSms,
+				Params: []sdk.AuthProviderParam{
+					{Key: "@SMS/LOGIN_URL", Value: "http://localhost:4173/phone-login"},
+				},
+				ProjectId: "project1",
+			},
+			expectedResult: nil, // We can't easily compare the sms provider instance
+			expectedError:  nil,
+		},
+		{
+			name: "success_passkey_provider",
+			authProvider: sdk.AuthProvider{
+				Id:       "ap7",
+				Name:     "Passkey Provider",
+				Provider: sdk.AuthProviderTypePasskey,
+				Params: []sdk.AuthProviderParam{
+					{Key: "@PASSKEY/LOGIN_URL", Value: "http://localhost:4173/passkey-login"},
+				},
+				ProjectId: "project1",
+			},
+			expectedResult: nil, // We can't easily compare the passkey provider instance
+			expectedError:  nil,
+		},
+		{
+			name: "success_ldap_provider",
+			authProvider: sdk.AuthProvider{
+				Id:       "ap9",
+				Name:     "Ldap Provider",
+				Provider: sdk.AuthProviderTypeLDAP,
+				Params: []sdk.AuthProviderParam{
+					{Key: "@LDAP/LOGIN_URL", Value: "http://localhost:4173/ldap-login"},
+					{Key: "@LDAP/URL", Value: "ldaps://ldap.example.com"},
+				},
+				ProjectId: "project1",
+			},
+			expectedResult: nil, // We can't easily compare the ldap provider instance
+			expectedError:  nil,
+		},
+		{
+			name: "error_saml_provider_without_metadata",
+			authProvider: sdk.AuthProvider{
+				Id:       "ap8",
+				Name:     "SAML Provider",
+				Provider: sdk.AuthProviderTypeSAML,
+				Params: []sdk.AuthProviderParam{
+					{Key: "@SAML/ACS_URL", Value: "http://localhost:3000/auth/v1/saml/acs"},
+				},
+				ProjectId: "project1",
+			},
+			expectedResult: nil,
+			expectedError:  errors.New("error reading the idp metadata of SAML Provider invalid xml: incomplete document"),
+		},
 		{
 			name: "error_unknown_provider",
 			authProvider: sdk.AuthProvider{
@@ -730,7 +888,7 @@ func TestService_GetProvider(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockStore := &MockStore{}
 			mockProject := &MockProjectService{}
-			svc := NewService(mockStore, mockProject)
+			svc := NewService(mockStore, mockProject, nil, nil, nil, nil)
 
 			result, err := svc.GetProvider(context.Background(), tt.authProvider)
 
@@ -744,7 +902,12 @@ func TestService_GetProvider(t *testing.T) {
 				// since comparing the actual instance is complex
 				if tt.authProvider.Provider == sdk.AuthProviderTypeGoogle ||
 					tt.authProvider.Provider == sdk.AuthProviderTypeMicrosoft ||
-					tt.authProvider.Provider == sdk.AuthProviderTypeGitHub {
+					tt.authProvider.Provider == sdk.AuthProviderTypeGitHub ||
+					tt.authProvider.Provider == sdk.AuthProviderTypePassword ||
+					tt.authProvider.Provider == sdk.AuthProviderTypePasswordless ||
+					tt.authProvider.Provider == sdk.AuthProviderTypeSms ||
+					tt.authProvider.Provider == sdk.AuthProviderTypePasskey ||
+					tt.authProvider.Provider == sdk.AuthProviderTypeLDAP {
 					assert.NotNil(t, result)
 				} else {
 					assert.Equal(t, tt.expectedResult, result)
@@ -753,3 +916,27 @@ func TestService_GetProvider(t *testing.T) {
 		})
 	}
 }
+
+func TestService_GetTypes(t *testing.T) {
+	svc := NewService(&MockStore{}, &MockProjectService{}, nil, nil, nil, nil)
+
+	types := svc.GetTypes(context.Background())
+
+	registered := map[sdk.AuthProviderType]sdk.AuthProviderTypeInfo{}
+	for _, info := range types {
+		registered[info.Type] = info
+	}
+	for _, typ := range []sdk.AuthProviderType{
+		sdk.AuthProviderTypeGoogle, sdk.AuthProviderTypeMicrosoft, sdk.AuthProviderTypeGitHub, sdk.AuthProviderTypeOIDC,
+		sdk.AuthProviderTypePassword, sdk.AuthProviderTypePasswordless, sdk.AuthProviderTypeSms, sdk.AuthProviderTypePasskey,
+		sdk.AuthProviderTypeSAML, sdk.AuthProviderTypeLDAP,
+	} {
+		info, ok := registered[typ]
+		require.True(t, ok, "%s is not registered", typ)
+		assert.NotEmpty(t, info.Name)
+		assert.NotEmpty(t, info.Params)
+	}
+	for _, spec := range registered[sdk.AuthProviderTypeOIDC].Params {
+		assert.Equal(t, spec.Key == sdk.OidcParamClientSecret, spec.IsSecret, spec.Key)
+	}
+}
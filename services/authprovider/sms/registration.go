@@ -0,0 +1,27 @@
+package sms
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/authprovider/registry"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		AuthProviderTypeInfo: sdk.AuthProviderTypeInfo{
+			Type: sdk.AuthProviderTypeSms,
+			Name: "SMS code",
+			Icon: "sms",
+			Params: []sdk.AuthProviderParamSpec{
+				{Key: sdk.SmsParamLoginUrl, Label: "Login page", Description: "Page asking for the phone number, it receives the state of the login in the query", Required: true, Format: sdk.AuthProviderParamFormatUrl},
+				{Key: sdk.SmsParamDefaultCountryCode, Label: "Default country code", Description: "Country calling code added to the numbers given without one, like 91", Format: sdk.AuthProviderParamFormatText},
+				{Key: sdk.SmsParamMessageTemplate, Label: "Message template", Description: "text/template of the message with .Code, .Phone and .ExpiresInMinutes", Format: sdk.AuthProviderParamFormatText},
+			},
+		},
+		New: func(_ context.Context, p sdk.AuthProvider, deps registry.Dependencies) (sdk.ServiceProvider, error) {
+			codes, _ := deps.Codes.(CodeService)
+			return NewAuthProvider(p, codes), nil
+		},
+	})
+}
@@ -0,0 +1,89 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// CodeService is the part of the passwordless service the provider needs to resolve the logins
+type CodeService interface {
+	ExchangeCode(ctx context.Context, code string) (string, error)
+}
+
+// authProvider implements the SDK ServiceProvider interface for the phone number login.
+// The user proves they own the phone number with a code sent by sms, after which go-iam
+// continues the code flow with a single use code. The verified phone number in E.164
+// format acts as the access token of the provider.
+type authProvider struct {
+	loginUrl string
+	codes    CodeService
+}
+
+// NewAuthProvider creates a new sms provider instance
+// Parameters in the AuthProvider configuration:
+// - @SMS/LOGIN_URL: Login page receiving the state of the login
+// - @SMS/DEFAULT_COUNTRY_CODE: Country code added to numbers entered without one, eg. +91 (optional)
+// - @SMS/MESSAGE_TEMPLATE: Template of the text message (optional)
+func NewAuthProvider(p sdk.AuthProvider, codes CodeService) sdk.ServiceProvider {
+	return authProvider{
+		loginUrl: p.GetParam(sdk.SmsParamLoginUrl),
+		codes:    codes,
+	}
+}
+
+// HasRefreshTokenFlow returns false, the verified phone number never expires on the provider side
+func (a authProvider) HasRefreshTokenFlow() bool {
+	return false
+}
+
+// GetAuthCodeUrl returns the login page with the state in its query
+func (a authProvider) GetAuthCodeUrl(state string) string {
+	u, err := url.Parse(a.loginUrl)
+	if err != nil {
+		return a.loginUrl
+	}
+	q := u.Query()
+	q.Set("state", state)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// VerifyCode exchanges the single use code issued on verification for the phone number
+func (a authProvider) VerifyCode(ctx context.Context, code string) (*sdk.AuthToken, error) {
+	phone, err := a.codes.ExchangeCode(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying the sms login code. %w", err)
+	}
+	return &sdk.AuthToken{
+		AccessToken: phone,
+		ExpiresAt:   time.Now().Add(time.Hour * 24),
+	}, nil
+}
+
+// RefreshToken is not supported by the sms provider
+func (a authProvider) RefreshToken(refreshToken string) (*sdk.AuthToken, error) {
+	return nil, fmt.Errorf("refresh token flow is not supported by the sms provider")
+}
+
+// SmsIdentityPhone handles phone identity information
+type SmsIdentityPhone struct {
+	Phone string `json:"phone"`
+}
+
+func (p SmsIdentityPhone) UpdateUserDetails(user *sdk.User) {
+	user.Phone = p.Phone
+}
+
+// GetIdentity returns the verified phone number
+func (a authProvider) GetIdentity(token string) ([]sdk.AuthIdentity, error) {
+	if len(token) == 0 {
+		return nil, fmt.Errorf("empty sms access token")
+	}
+	return []sdk.AuthIdentity{
+		{Type: sdk.AuthIdentityTypePhone, Metadata: SmsIdentityPhone{Phone: token}},
+	}, nil
+}
@@ -0,0 +1,57 @@
+package sms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCodes resolves the code "code-1" to a single phone number
+type fakeCodes struct{}
+
+func (f fakeCodes) ExchangeCode(ctx context.Context, code string) (string, error) {
+	if code != "code-1" {
+		return "", sdk.ErrInvalidOtp
+	}
+	return "+919876543210", nil
+}
+
+func createSmsProvider(loginUrl string) sdk.ServiceProvider {
+	p := sdk.AuthProvider{
+		Id:       "sms-test-id",
+		Provider: sdk.AuthProviderTypeSms,
+		Params:   []sdk.AuthProviderParam{{Key: "@SMS/LOGIN_URL", Value: loginUrl}},
+	}
+	return NewAuthProvider(p, fakeCodes{})
+}
+
+func TestGetAuthCodeUrl(t *testing.T) {
+	provider := createSmsProvider("https://app.example.com/phone-login")
+
+	assert.Equal(t, "https://app.example.com/phone-login?state=state-1", provider.GetAuthCodeUrl("state-1"))
+	assert.False(t, provider.HasRefreshTokenFlow())
+}
+
+func TestVerifyCodeAndGetIdentity(t *testing.T) {
+	provider := createSmsProvider("https://app.example.com/phone-login")
+
+	token, err := provider.VerifyCode(context.Background(), "code-1")
+	require.NoError(t, err)
+
+	identities, err := provider.GetIdentity(token.AccessToken)
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	assert.Equal(t, sdk.AuthIdentityTypePhone, identities[0].Type)
+	user := &sdk.User{}
+	identities[0].UpdateUserDetails(user)
+	assert.Equal(t, "+919876543210", user.Phone)
+
+	_, err = provider.VerifyCode(context.Background(), "wrong-code")
+	assert.ErrorIs(t, err, sdk.ErrInvalidOtp)
+
+	_, err = provider.GetIdentity("")
+	assert.Error(t, err)
+}
@@ -67,6 +67,29 @@ func (r *RedisService) Get(ctx context.Context, key string) (string, error) {
 	return value, nil
 }
 
+// GetDel retrieves the value for a given key and removes it from the Redis service.
+// It returns an error if the key does not exist or has expired.
+func (r *RedisService) GetDel(ctx context.Context, key string) (string, error) {
+	if r == nil {
+		return "", errors.New("redis service is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	value, exists := r.data[key]
+	expiry, hasTTL := r.ttl[key]
+	delete(r.data, key)
+	delete(r.ttl, key)
+	if !exists {
+		return "", errors.New("key not found")
+	}
+	if hasTTL && time.Now().After(expiry) {
+		return "", errors.New("key not found (expired)")
+	}
+	return value, nil
+}
+
 // Delete removes a key-value pair from the Redis service.
 func (r *RedisService) Delete(ctx context.Context, key string) error {
 	if r == nil {
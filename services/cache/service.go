@@ -8,6 +8,8 @@ import (
 type Service interface {
 	Set(ctx context.Context, key string, value string, ttl time.Duration) error
 	Get(ctx context.Context, key string) (string, error)
+	// GetDel returns the value of the key and deletes it at once, so that only one caller gets the value
+	GetDel(ctx context.Context, key string) (string, error)
 	Delete(ctx context.Context, key string) error
 	Expire(ctx context.Context, key string, ttl time.Duration) error
 }
@@ -29,6 +29,10 @@ func (s redisService) Get(ctx context.Context, key string) (string, error) {
 	res := s.client.Get(ctx, key)
 	return res.Val(), res.Err()
 }
+func (s redisService) GetDel(ctx context.Context, key string) (string, error) {
+	res := s.client.GetDel(ctx, key)
+	return res.Val(), res.Err()
+}
 func (s redisService) Delete(ctx context.Context, key string) error {
 	res := s.client.Del(ctx, key)
 	return res.Err()
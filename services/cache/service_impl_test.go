@@ -55,6 +55,19 @@ func TestRedisService_Methods(t *testing.T) {
 		err = service.Expire(ctx, key, ttl)
 		assert.NoError(t, err)
 	})
+
+	t.Run("GetDel_method", func(t *testing.T) {
+		err := service.Set(ctx, key, value, ttl)
+		assert.NoError(t, err)
+
+		result, err := service.GetDel(ctx, key)
+		assert.NoError(t, err)
+		assert.Equal(t, value, result)
+
+		// the key is gone after the first read
+		_, err = service.GetDel(ctx, key)
+		assert.Error(t, err)
+	})
 }
 
 func TestRedisService_WithMockService(t *testing.T) {
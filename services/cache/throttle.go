@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// FailedAttemptWindow is the window of the failed attempts, a key that reaches its limit waits until the window ends
+	FailedAttemptWindow = time.Minute * 15
+	// MaxFailedAttemptsPerAccount is the number of failed attempts of an account within the window
+	MaxFailedAttemptsPerAccount = 5
+	// MaxFailedAttemptsPerIp is the number of failed attempts from an IP address within the window
+	MaxFailedAttemptsPerIp = 20
+)
+
+// attempts counts the failed attempts within a fixed window
+type attempts struct {
+	Count   int       `json:"count"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// Throttle counts the failed attempts against keys, like the email or the ip address of a login,
+// so that guessing credentials can be stopped once a key reaches its limit within the window.
+type Throttle struct {
+	cacheSvc Service
+	window   time.Duration
+}
+
+// NewThrottle creates a throttle keeping its counts in the cache for the window
+func NewThrottle(cacheSvc Service, window time.Duration) Throttle {
+	return Throttle{cacheSvc: cacheSvc, window: window}
+}
+
+// Limited returns the failed attempts counted against each key of the limits and whether any of them reached its limit
+func (t Throttle) Limited(ctx context.Context, limits map[string]int) (map[string]int, bool) {
+	failed := map[string]int{}
+	for key, limit := range limits {
+		failed[key] = t.get(ctx, key).Count
+		if failed[key] >= limit {
+			return failed, true
+		}
+	}
+	return failed, false
+}
+
+// Fail counts a failed attempt against each key of the limits
+func (t Throttle) Fail(ctx context.Context, limits map[string]int) error {
+	for key := range limits {
+		a := t.get(ctx, key)
+		a.Count++
+		b, err := json.Marshal(a)
+		if err != nil {
+			return fmt.Errorf("error encoding the failed attempts %w", err)
+		}
+		err = t.cacheSvc.Set(ctx, key, string(b), time.Until(a.ResetAt))
+		if err != nil {
+			return fmt.Errorf("error saving the failed attempts %w", err)
+		}
+	}
+	return nil
+}
+
+// Clear forgets the failed attempts of the key
+func (t Throttle) Clear(ctx context.Context, key string) error {
+	err := t.cacheSvc.Delete(ctx, key)
+	if err != nil {
+		return fmt.Errorf("error clearing the failed attempts %w", err)
+	}
+	return nil
+}
+
+// get returns the failed attempts counted against the key in the current window
+func (t Throttle) get(ctx context.Context, key string) attempts {
+	now := time.Now()
+	val, err := t.cacheSvc.Get(ctx, key)
+	if err != nil {
+		return attempts{ResetAt: now.Add(t.window)}
+	}
+	var cached attempts
+	if json.Unmarshal([]byte(val), &cached) != nil || !cached.ResetAt.After(now) {
+		return attempts{ResetAt: now.Add(t.window)}
+	}
+	return cached
+}
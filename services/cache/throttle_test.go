@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottle(t *testing.T) {
+	ctx := context.Background()
+	limits := map[string]int{"attempts-account": 2, "attempts-ip": 3}
+
+	t.Run("limited once a key reaches its limit", func(t *testing.T) {
+		throttle := NewThrottle(NewMockService(), time.Minute)
+		for i := 0; i < 2; i++ {
+			_, limited := throttle.Limited(ctx, limits)
+			assert.False(t, limited)
+			require.NoError(t, throttle.Fail(ctx, limits))
+		}
+		failed, limited := throttle.Limited(ctx, limits)
+		assert.True(t, limited)
+		assert.Equal(t, 2, failed["attempts-account"])
+	})
+
+	t.Run("clear forgets the failed attempts of a key", func(t *testing.T) {
+		throttle := NewThrottle(NewMockService(), time.Minute)
+		require.NoError(t, throttle.Fail(ctx, limits))
+		require.NoError(t, throttle.Fail(ctx, limits))
+		require.NoError(t, throttle.Clear(ctx, "attempts-account"))
+
+		failed, limited := throttle.Limited(ctx, limits)
+		assert.False(t, limited)
+		assert.Equal(t, 0, failed["attempts-account"])
+		assert.Equal(t, 2, failed["attempts-ip"])
+	})
+
+	t.Run("the counts start over after the window", func(t *testing.T) {
+		throttle := NewThrottle(NewMockService(), time.Millisecond*50)
+		require.NoError(t, throttle.Fail(ctx, limits))
+		require.NoError(t, throttle.Fail(ctx, limits))
+		time.Sleep(time.Millisecond * 100)
+
+		_, limited := throttle.Limited(ctx, limits)
+		assert.False(t, limited)
+	})
+}
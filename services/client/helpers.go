@@ -20,45 +20,59 @@ func fromModelListToSdk(clients []models.Client) []sdk.Client {
 
 func fromModelToSdk(client *models.Client) *sdk.Client {
 	return &sdk.Client{
-		Id:                    client.Id,
-		Name:                  client.Name,
-		Description:           client.Description,
-		Secret:                client.Secret,
-		Tags:                  client.Tags,
-		RedirectURLs:          client.RedirectURLs,
-		DefaultAuthProviderId: client.DefaultAuthProviderId,
-		GoIamClient:           client.GoIamClient,
-		ProjectId:             client.ProjectId,
-		Scopes:                client.Scopes,
-		LinkedUserId:          client.LinkedUserId,
-		ServiceAccountEmail:   client.ServiceAccountEmail,
-		Enabled:               client.Enabled,
-		CreatedAt:             client.CreatedAt,
-		CreatedBy:             client.CreatedBy,
-		UpdatedAt:             client.UpdatedAt,
-		UpdatedBy:             client.UpdatedBy,
+		Id:                     client.Id,
+		Name:                   client.Name,
+		Description:            client.Description,
+		Secret:                 client.Secret,
+		Tags:                   client.Tags,
+		RedirectURLs:           client.RedirectURLs,
+		PostLogoutRedirectURLs: client.PostLogoutRedirectURLs,
+		DefaultAuthProviderId:  client.DefaultAuthProviderId,
+		GoIamClient:            client.GoIamClient,
+		ProjectId:              client.ProjectId,
+		Scopes:                 client.Scopes,
+		LinkedUserId:           client.LinkedUserId,
+		ServiceAccountEmail:    client.ServiceAccountEmail,
+		Enabled:                client.Enabled,
+		Public:                 client.Public,
+		PkceRequired:           client.PkceRequired,
+		PkceAllowPlain:         client.PkceAllowPlain,
+		AccessTokenFormat:      client.AccessTokenFormat,
+		AccessTokenClaims:      client.AccessTokenClaims,
+		ThirdParty:             client.ThirdParty,
+		CreatedAt:              client.CreatedAt,
+		CreatedBy:              client.CreatedBy,
+		UpdatedAt:              client.UpdatedAt,
+		UpdatedBy:              client.UpdatedBy,
 	}
 }
 
 func fromSdkToModel(client sdk.Client) models.Client {
 	return models.Client{
-		Id:                    client.Id,
-		Name:                  client.Name,
-		Description:           client.Description,
-		Secret:                client.Secret,
-		Tags:                  client.Tags,
-		RedirectURLs:          client.RedirectURLs,
-		ProjectId:             client.ProjectId,
-		DefaultAuthProviderId: client.DefaultAuthProviderId,
-		GoIamClient:           client.GoIamClient,
-		ServiceAccountEmail:   client.ServiceAccountEmail,
-		Scopes:                client.Scopes,
-		LinkedUserId:          client.LinkedUserId,
-		Enabled:               client.Enabled,
-		CreatedAt:             client.CreatedAt,
-		CreatedBy:             client.CreatedBy,
-		UpdatedAt:             client.UpdatedAt,
-		UpdatedBy:             client.UpdatedBy,
+		Id:                     client.Id,
+		Name:                   client.Name,
+		Description:            client.Description,
+		Secret:                 client.Secret,
+		Tags:                   client.Tags,
+		RedirectURLs:           client.RedirectURLs,
+		PostLogoutRedirectURLs: client.PostLogoutRedirectURLs,
+		ProjectId:              client.ProjectId,
+		DefaultAuthProviderId:  client.DefaultAuthProviderId,
+		GoIamClient:            client.GoIamClient,
+		ServiceAccountEmail:    client.ServiceAccountEmail,
+		Scopes:                 client.Scopes,
+		LinkedUserId:           client.LinkedUserId,
+		Enabled:                client.Enabled,
+		Public:                 client.Public,
+		PkceRequired:           client.PkceRequired,
+		PkceAllowPlain:         client.PkceAllowPlain,
+		AccessTokenFormat:      client.AccessTokenFormat,
+		AccessTokenClaims:      client.AccessTokenClaims,
+		ThirdParty:             client.ThirdParty,
+		CreatedAt:              client.CreatedAt,
+		CreatedBy:              client.CreatedBy,
+		UpdatedAt:              client.UpdatedAt,
+		UpdatedBy:              client.UpdatedBy,
 	}
 }
 
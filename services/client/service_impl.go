@@ -68,6 +68,9 @@ func (s service) Create(ctx context.Context, client *sdk.Client) error {
 	if _, ok := projectIdsMap[client.ProjectId]; !ok {
 		return sdk.ErrProjectNotFound
 	}
+	if err := s.checkScopes(ctx, *client); err != nil {
+		return err
+	}
 	if client.DefaultAuthProviderId != "" {
 		// verifying if auth provider exists
 		_, err := s.authP.Get(ctx, client.DefaultAuthProviderId, true)
@@ -104,6 +107,9 @@ func (s service) Update(ctx context.Context, client *sdk.Client) error {
 	if _, ok := projectIdsMap[client.ProjectId]; !ok {
 		return sdk.ErrProjectNotFound
 	}
+	if err := s.checkScopes(ctx, *client); err != nil {
+		return err
+	}
 	err := s.s.Update(ctx, client)
 	if err != nil {
 		return fmt.Errorf("error while updating client: %w", err)
@@ -157,6 +163,18 @@ func (s service) RegenerateSecret(ctx context.Context, clientId string) (*sdk.Cl
 	return client, nil
 }
 
+// checkScopes validates the scopes allowed for the client against the ones defined in its project
+func (s service) checkScopes(ctx context.Context, client sdk.Client) error {
+	if len(client.Scopes) == 0 {
+		return nil
+	}
+	p, err := s.p.Get(ctx, client.ProjectId)
+	if err != nil {
+		return fmt.Errorf("failed to get project: %w", err)
+	}
+	return p.ValidateClientScopes(client.Scopes)
+}
+
 func (s service) createAndLinkServiceAccountUser(ctx context.Context, client *sdk.Client, email string) error {
 	user, err := s.createServiceAccountUser(ctx, client, email)
 	if err != nil {
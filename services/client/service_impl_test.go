@@ -239,6 +239,28 @@ func TestService_Create_ProjectNotFound(t *testing.T) {
 	mockStore.AssertNotCalled(t, "Create")
 }
 
+func TestService_Create_ScopeNotDefinedInProject(t *testing.T) {
+	mockStore := &MockStore{}
+	mockProjectService := &services.MockProjectService{}
+	mockAuthService := &services.MockAuthProviderService{}
+	mockUserService := &services.MockUserService{}
+	service := NewService(mockStore, mockProjectService, mockAuthService, mockUserService)
+
+	ctx := createContextWithProjects([]string{"project1"})
+	mockProjectService.On("Get", ctx, "project1").Return(&sdk.Project{Id: "project1", Scopes: []sdk.ProjectScope{{Name: "orders:read"}}}, nil)
+
+	client := &sdk.Client{
+		Name:      "Test Client",
+		ProjectId: "project1",
+		Scopes:    []string{"orders:read", "orders:write"},
+	}
+
+	err := service.Create(ctx, client)
+
+	assert.ErrorIs(t, err, sdk.ErrInvalidScope)
+	mockStore.AssertNotCalled(t, "Create")
+}
+
 func TestService_Update_Success(t *testing.T) {
 	mockStore := &MockStore{}
 	mockProjectService := &services.MockProjectService{}
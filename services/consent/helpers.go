@@ -0,0 +1,39 @@
+package consent
+
+import (
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+func fromSdkToModel(consent sdk.Consent) models.Consent {
+	return models.Consent{
+		Id:        consent.Id,
+		UserId:    consent.UserId,
+		ClientId:  consent.ClientId,
+		ProjectId: consent.ProjectId,
+		Scopes:    consent.Scopes,
+		CreatedAt: consent.CreatedAt,
+		UpdatedAt: consent.UpdatedAt,
+	}
+}
+
+func fromModelToSdk(consent models.Consent) sdk.Consent {
+	scopes := consent.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return sdk.Consent{
+		Id:        consent.Id,
+		UserId:    consent.UserId,
+		ClientId:  consent.ClientId,
+		ProjectId: consent.ProjectId,
+		Scopes:    scopes,
+		CreatedAt: consent.CreatedAt,
+		UpdatedAt: consent.UpdatedAt,
+	}
+}
+
+// mergeScopes adds the new scopes to the granted ones, keeping the order in which they were granted
+func mergeScopes(granted, scopes []string) []string {
+	return sdk.ParseScope(sdk.FormatScope(append(append([]string{}, granted...), scopes...)))
+}
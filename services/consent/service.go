@@ -0,0 +1,20 @@
+package consent
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+type Service interface {
+	// Get returns the consent the user granted to the client or sdk.ErrConsentNotFound.
+	Get(ctx context.Context, userId, clientId string) (*sdk.Consent, error)
+	// List returns every consent granted by the user.
+	List(ctx context.Context, userId string) ([]sdk.Consent, error)
+	// Grant records the consent of the user for the scopes. The scopes are added to
+	// the ones already granted to the client.
+	Grant(ctx context.Context, userId string, client sdk.Client, scopes []string) (*sdk.Consent, error)
+	// Revoke deletes the consent the user granted to the client.
+	// It returns sdk.ErrConsentNotFound if there is none.
+	Revoke(ctx context.Context, userId, clientId string) error
+}
@@ -0,0 +1,79 @@
+package consent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+type service struct {
+	store Store
+}
+
+// NewService creates the consent service.
+func NewService(store Store) Service {
+	return service{store: store}
+}
+
+func (s service) Get(ctx context.Context, userId, clientId string) (*sdk.Consent, error) {
+	return s.store.Get(ctx, userId, clientId)
+}
+
+func (s service) List(ctx context.Context, userId string) ([]sdk.Consent, error) {
+	consents, err := s.store.List(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the consents: %w", err)
+	}
+	return consents, nil
+}
+
+func (s service) Grant(ctx context.Context, userId string, client sdk.Client, scopes []string) (*sdk.Consent, error) {
+	/*
+	 * extend the existing consent of the user with the new scopes
+	 * otherwise record a new consent
+	 */
+	now := time.Now()
+	existing, err := s.store.Get(ctx, userId, client.Id)
+	if err != nil && !errors.Is(err, sdk.ErrConsentNotFound) {
+		return nil, fmt.Errorf("error fetching the consent: %w", err)
+	}
+	if existing != nil {
+		existing.Scopes = mergeScopes(existing.Scopes, scopes)
+		existing.UpdatedAt = &now
+		err = s.store.UpdateScopes(ctx, existing)
+		if err != nil {
+			return nil, fmt.Errorf("error extending the consent: %w", err)
+		}
+		return existing, nil
+	}
+
+	consent := &sdk.Consent{
+		Id:        uuid.NewString(),
+		UserId:    userId,
+		ClientId:  client.Id,
+		ProjectId: client.ProjectId,
+		Scopes:    mergeScopes(nil, scopes),
+		CreatedAt: &now,
+		UpdatedAt: &now,
+	}
+	err = s.store.Create(ctx, consent)
+	if err != nil {
+		return nil, fmt.Errorf("error saving the consent: %w", err)
+	}
+	return consent, nil
+}
+
+func (s service) Revoke(ctx context.Context, userId, clientId string) error {
+	err := s.store.Delete(ctx, userId, clientId)
+	if err != nil {
+		if errors.Is(err, sdk.ErrConsentNotFound) {
+			return err
+		}
+		return fmt.Errorf("error revoking the consent: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package consent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockStore implements Store interface for testing
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) Get(ctx context.Context, userId, clientId string) (*sdk.Consent, error) {
+	args := m.Called(ctx, userId, clientId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.Consent), args.Error(1)
+}
+
+func (m *MockStore) List(ctx context.Context, userId string) ([]sdk.Consent, error) {
+	args := m.Called(ctx, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]sdk.Consent), args.Error(1)
+}
+
+func (m *MockStore) Create(ctx context.Context, consent *sdk.Consent) error {
+	args := m.Called(ctx, consent)
+	return args.Error(0)
+}
+
+func (m *MockStore) UpdateScopes(ctx context.Context, consent *sdk.Consent) error {
+	args := m.Called(ctx, consent)
+	return args.Error(0)
+}
+
+func (m *MockStore) Delete(ctx context.Context, userId, clientId string) error {
+	args := m.Called(ctx, userId, clientId)
+	return args.Error(0)
+}
+
+func TestNewService(t *testing.T) {
+	svc := NewService(&MockStore{})
+
+	assert.NotNil(t, svc)
+	assert.Implements(t, (*Service)(nil), svc)
+}
+
+func TestService_Grant(t *testing.T) {
+	ctx := context.Background()
+	client := sdk.Client{Id: "client-1", ProjectId: "project-1"}
+
+	t.Run("records a new consent", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("Get", ctx, "user-1", "client-1").Return(nil, sdk.ErrConsentNotFound)
+		mockStore.On("Create", ctx, mock.MatchedBy(func(c *sdk.Consent) bool {
+			return c.UserId == "user-1" && c.ClientId == "client-1" && c.ProjectId == "project-1" && len(c.Id) > 0
+		})).Return(nil)
+
+		consent, err := NewService(mockStore).Grant(ctx, "user-1", client, []string{"openid", "orders:read", "openid"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"openid", "orders:read"}, consent.Scopes)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("extends the existing consent", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("Get", ctx, "user-1", "client-1").Return(&sdk.Consent{Id: "consent-1", Scopes: []string{"openid", "orders:read"}}, nil)
+		mockStore.On("UpdateScopes", ctx, mock.MatchedBy(func(c *sdk.Consent) bool {
+			return c.Id == "consent-1" && c.UpdatedAt != nil
+		})).Return(nil)
+
+		consent, err := NewService(mockStore).Grant(ctx, "user-1", client, []string{"orders:read", "orders:write"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"openid", "orders:read", "orders:write"}, consent.Scopes)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("Get", ctx, "user-1", "client-1").Return(nil, errors.New("database error"))
+
+		consent, err := NewService(mockStore).Grant(ctx, "user-1", client, []string{"openid"})
+		assert.Error(t, err)
+		assert.Nil(t, consent)
+		assert.Contains(t, err.Error(), "error fetching the consent")
+	})
+}
+
+func TestService_List(t *testing.T) {
+	ctx := context.Background()
+	mockStore := &MockStore{}
+	mockStore.On("List", ctx, "user-1").Return([]sdk.Consent{{Id: "consent-1"}}, nil)
+
+	consents, err := NewService(mockStore).List(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, consents, 1)
+}
+
+func TestService_Revoke(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("Delete", ctx, "user-1", "client-1").Return(nil)
+
+		err := NewService(mockStore).Revoke(ctx, "user-1", "client-1")
+		assert.NoError(t, err)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("Delete", ctx, "user-1", "client-1").Return(sdk.ErrConsentNotFound)
+
+		err := NewService(mockStore).Revoke(ctx, "user-1", "client-1")
+		assert.ErrorIs(t, err, sdk.ErrConsentNotFound)
+	})
+}
@@ -0,0 +1,16 @@
+package consent
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+type Store interface {
+	Get(ctx context.Context, userId, clientId string) (*sdk.Consent, error)
+	List(ctx context.Context, userId string) ([]sdk.Consent, error)
+	Create(ctx context.Context, consent *sdk.Consent) error
+	// UpdateScopes replaces the scopes of the consent
+	UpdateScopes(ctx context.Context, consent *sdk.Consent) error
+	Delete(ctx context.Context, userId, clientId string) error
+}
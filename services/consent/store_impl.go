@@ -0,0 +1,92 @@
+package consent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/melvinodsa/go-iam/db"
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type store struct {
+	db db.DB
+}
+
+// NewStore creates a consent store backed by mongo.
+func NewStore(db db.DB) Store {
+	return store{db: db}
+}
+
+func (s store) Get(ctx context.Context, userId, clientId string) (*sdk.Consent, error) {
+	md := models.GetConsentModel()
+	var consent models.Consent
+	filter := bson.D{{Key: md.UserIdKey, Value: userId}, {Key: md.ClientIdKey, Value: clientId}}
+	err := s.db.FindOne(ctx, md, filter).Decode(&consent)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, sdk.ErrConsentNotFound
+		}
+		return nil, fmt.Errorf("error finding consent: %w", err)
+	}
+	result := fromModelToSdk(consent)
+	return &result, nil
+}
+
+func (s store) List(ctx context.Context, userId string) ([]sdk.Consent, error) {
+	md := models.GetConsentModel()
+	cursor, err := s.db.Find(ctx, md, bson.D{{Key: md.UserIdKey, Value: userId}})
+	if err != nil {
+		return nil, fmt.Errorf("error finding consents: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var consents []models.Consent
+	err = cursor.All(ctx, &consents)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding consents: %w", err)
+	}
+	result := make([]sdk.Consent, 0, len(consents))
+	for _, c := range consents {
+		result = append(result, fromModelToSdk(c))
+	}
+	return result, nil
+}
+
+func (s store) Create(ctx context.Context, consent *sdk.Consent) error {
+	md := models.GetConsentModel()
+	_, err := s.db.InsertOne(ctx, md, fromSdkToModel(*consent))
+	if err != nil {
+		return fmt.Errorf("error creating consent: %w", err)
+	}
+	return nil
+}
+
+func (s store) UpdateScopes(ctx context.Context, consent *sdk.Consent) error {
+	md := models.GetConsentModel()
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: md.ScopesKey, Value: consent.Scopes},
+		{Key: md.UpdatedAtKey, Value: consent.UpdatedAt},
+	}}}
+	_, err := s.db.UpdateOne(ctx, md, bson.D{{Key: md.IdKey, Value: consent.Id}}, update)
+	if err != nil {
+		return fmt.Errorf("error updating consent: %w", err)
+	}
+	return nil
+}
+
+func (s store) Delete(ctx context.Context, userId, clientId string) error {
+	md := models.GetConsentModel()
+	filter := bson.D{{Key: md.UserIdKey, Value: userId}, {Key: md.ClientIdKey, Value: clientId}}
+	res, err := s.db.DeleteOne(ctx, md, filter)
+	if err != nil {
+		return fmt.Errorf("error deleting consent: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return sdk.ErrConsentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package consent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewStore(t *testing.T) {
+	store := NewStore(test.SetupMockDB())
+
+	assert.NotNil(t, store)
+	assert.Implements(t, (*Store)(nil), store)
+}
+
+func TestStore_Get(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetConsentModel()
+	filter := bson.D{{Key: md.UserIdKey, Value: "user-1"}, {Key: md.ClientIdKey, Value: "client-1"}}
+
+	t.Run("success", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		document := bson.D{
+			{Key: md.IdKey, Value: "consent-1"},
+			{Key: md.UserIdKey, Value: "user-1"},
+			{Key: md.ClientIdKey, Value: "client-1"},
+			{Key: md.ScopesKey, Value: bson.A{"openid", "orders:read"}},
+		}
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(document, nil, nil))
+
+		consent, err := NewStore(mockDB).Get(ctx, "user-1", "client-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "consent-1", consent.Id)
+		assert.Equal(t, []string{"openid", "orders:read"}, consent.Scopes)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil))
+
+		consent, err := NewStore(mockDB).Get(ctx, "user-1", "client-1")
+		assert.ErrorIs(t, err, sdk.ErrConsentNotFound)
+		assert.Nil(t, consent)
+	})
+}
+
+func TestStore_List(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetConsentModel()
+	filter := bson.D{{Key: md.UserIdKey, Value: "user-1"}}
+
+	t.Run("success", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		cursor, _ := mongo.NewCursorFromDocuments([]interface{}{
+			models.Consent{Id: "consent-1", UserId: "user-1", ClientId: "client-1", Scopes: []string{"openid"}},
+			models.Consent{Id: "consent-2", UserId: "user-1", ClientId: "client-2"},
+		}, nil, nil)
+		mockDB.On("Find", ctx, md, filter, mock.Anything).Return(cursor, nil)
+
+		consents, err := NewStore(mockDB).List(ctx, "user-1")
+		assert.NoError(t, err)
+		assert.Len(t, consents, 2)
+		assert.Equal(t, "client-2", consents[1].ClientId)
+		assert.Equal(t, []string{}, consents[1].Scopes)
+	})
+
+	t.Run("find error", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("Find", ctx, md, filter, mock.Anything).Return(nil, errors.New("database error"))
+
+		consents, err := NewStore(mockDB).List(ctx, "user-1")
+		assert.Error(t, err)
+		assert.Nil(t, consents)
+	})
+}
+
+func TestStore_Create(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetConsentModel()
+	consent := &sdk.Consent{Id: "consent-1", UserId: "user-1", ClientId: "client-1", Scopes: []string{"openid"}}
+
+	t.Run("success", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("InsertOne", ctx, md, fromSdkToModel(*consent), mock.Anything).Return(&mongo.InsertOneResult{}, nil)
+
+		err := NewStore(mockDB).Create(ctx, consent)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("insert error", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("InsertOne", ctx, md, mock.Anything, mock.Anything).Return((*mongo.InsertOneResult)(nil), errors.New("database error"))
+
+		err := NewStore(mockDB).Create(ctx, consent)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error creating consent")
+	})
+}
+
+func TestStore_UpdateScopes(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetConsentModel()
+	now := time.Now()
+	consent := &sdk.Consent{Id: "consent-1", Scopes: []string{"openid", "email"}, UpdatedAt: &now}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: md.ScopesKey, Value: consent.Scopes},
+		{Key: md.UpdatedAtKey, Value: consent.UpdatedAt},
+	}}}
+
+	mockDB := test.SetupMockDB()
+	mockDB.On("UpdateOne", ctx, md, bson.D{{Key: md.IdKey, Value: "consent-1"}}, update, mock.Anything).Return(&mongo.UpdateResult{ModifiedCount: 1}, nil)
+
+	err := NewStore(mockDB).UpdateScopes(ctx, consent)
+	assert.NoError(t, err)
+	mockDB.AssertExpectations(t)
+}
+
+func TestStore_Delete(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetConsentModel()
+	filter := bson.D{{Key: md.UserIdKey, Value: "user-1"}, {Key: md.ClientIdKey, Value: "client-1"}}
+
+	tests := []struct {
+		name       string
+		result     *mongo.DeleteResult
+		err        error
+		expectedIs error
+		wantErr    bool
+	}{
+		{name: "deleted", result: &mongo.DeleteResult{DeletedCount: 1}},
+		{name: "not found", result: &mongo.DeleteResult{DeletedCount: 0}, expectedIs: sdk.ErrConsentNotFound, wantErr: true},
+		{name: "delete error", result: nil, err: errors.New("database error"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := test.SetupMockDB()
+			mockDB.On("DeleteOne", ctx, md, filter, mock.Anything).Return(tt.result, tt.err)
+
+			err := NewStore(mockDB).Delete(ctx, "user-1", "client-1")
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			if tt.expectedIs != nil {
+				assert.ErrorIs(t, err, tt.expectedIs)
+			}
+		})
+	}
+}
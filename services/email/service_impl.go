@@ -0,0 +1,69 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+type service struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewService returns an email service sending plain text emails through the given SMTP server.
+// The server is authenticated with PLAIN auth when a username is given.
+func NewService(host string, port int, username, password, from string) Service {
+	var auth smtp.Auth
+	if len(username) > 0 {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return service{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (s service) Send(ctx context.Context, to, subject, body string) error {
+	err := smtp.SendMail(s.addr, s.auth, s.from, []string{to}, buildMessage(s.from, to, subject, body))
+	if err != nil {
+		return fmt.Errorf("error sending the email %w", err)
+	}
+	return nil
+}
+
+type logService struct{}
+
+// NewLogService returns an email service that only logs the emails.
+// It is used when no SMTP server is configured, so that local setups keep working.
+func NewLogService() Service {
+	return logService{}
+}
+
+func (l logService) Send(ctx context.Context, to, subject, body string) error {
+	log.Warnw("smtp is not configured, logging the email instead of sending it", "to", to, "subject", subject)
+	log.Debugw("email body", "to", to, "body", body)
+	return nil
+}
+
+// buildMessage formats a plain text email with the headers required by RFC 5322.
+// Line breaks are stripped from the header values to prevent header injection.
+func buildMessage(from, to, subject, body string) []byte {
+	clean := strings.NewReplacer("\r", "", "\n", "")
+	b := strings.Builder{}
+	b.WriteString("From: " + clean.Replace(from) + "\r\n")
+	b.WriteString("To: " + clean.Replace(to) + "\r\n")
+	b.WriteString("Subject: " + clean.Replace(subject) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(body)
+	return []byte(b.String())
+}
@@ -0,0 +1,116 @@
+package email
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpSink is a local SMTP server that accepts a single email and hands over its data
+func smtpSink(t *testing.T) (string, int, <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	received := make(chan string, 1)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		_ = tp.PrintfLine("220 localhost ESMTP sink")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO", "HELO":
+				_ = tp.PrintfLine("250 localhost")
+			case "MAIL", "RCPT", "RSET", "NOOP":
+				_ = tp.PrintfLine("250 OK")
+			case "DATA":
+				_ = tp.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+				data, err := tp.ReadDotLines()
+				if err != nil {
+					return
+				}
+				received <- strings.Join(data, "\n")
+				_ = tp.PrintfLine("250 OK")
+			case "QUIT":
+				_ = tp.PrintfLine("221 bye")
+				return
+			default:
+				_ = tp.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+
+	host, portStr, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+	return host, port, received
+}
+
+func TestBuildMessage(t *testing.T) {
+	msg := string(buildMessage("iam@example.com", "user@example.com", "Reset your password", "Open the link"))
+
+	assert.True(t, strings.HasPrefix(msg, "From: iam@example.com\r\nTo: user@example.com\r\nSubject: Reset your password\r\n"))
+	assert.True(t, strings.HasSuffix(msg, "\r\n\r\nOpen the link"))
+}
+
+func TestBuildMessage_StripsHeaderInjection(t *testing.T) {
+	msg := string(buildMessage("iam@example.com", "user@example.com\r\nBcc: attacker@example.com", "Hi", "body"))
+
+	assert.NotContains(t, msg, "\r\nBcc:")
+	assert.Contains(t, msg, "To: user@example.comBcc: attacker@example.com\r\n")
+}
+
+func TestNewService(t *testing.T) {
+	svc := NewService("smtp.example.com", 587, "mailer", "secret", "iam@example.com").(service)
+	assert.Equal(t, "smtp.example.com:587", svc.addr)
+	assert.NotNil(t, svc.auth)
+	assert.Equal(t, "iam@example.com", svc.from)
+
+	svc = NewService("localhost", 25, "", "", "iam@example.com").(service)
+	assert.Nil(t, svc.auth)
+}
+
+func TestLogService(t *testing.T) {
+	err := NewLogService().Send(context.Background(), "user@example.com", "Hi", "body")
+	assert.NoError(t, err)
+}
+
+func TestService_SendsThroughSmtp(t *testing.T) {
+	host, port, received := smtpSink(t)
+	svc := NewService(host, port, "", "", "iam@example.com")
+
+	err := svc.Send(context.Background(), "user@example.com", "Your login code", "Your login code is 123456")
+	require.NoError(t, err)
+
+	data := <-received
+	assert.Contains(t, data, "To: user@example.com")
+	assert.Contains(t, data, "Subject: Your login code")
+	assert.True(t, strings.HasSuffix(data, "Your login code is 123456"))
+}
+
+func TestService_SendFails(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().(*net.TCPAddr)
+	require.NoError(t, l.Close())
+
+	svc := NewService("127.0.0.1", addr.Port, "", "", "iam@example.com")
+	err = svc.Send(context.Background(), "user@example.com", "Hi", "body")
+	assert.ErrorContains(t, err, "error sending the email")
+}
@@ -0,0 +1,36 @@
+package identity
+
+import (
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+func fromSdkToModel(identity sdk.UserIdentity) models.UserIdentity {
+	return models.UserIdentity{
+		Id:             identity.Id,
+		UserId:         identity.UserId,
+		ProjectId:      identity.ProjectId,
+		AuthProviderId: identity.AuthProviderId,
+		Subject:        identity.Subject,
+		Email:          identity.Email,
+		EmailVerified:  identity.EmailVerified,
+		LastLoginAt:    identity.LastLoginAt,
+		CreatedAt:      identity.CreatedAt,
+		UnlinkedAt:     identity.UnlinkedAt,
+	}
+}
+
+func fromModelToSdk(identity models.UserIdentity) sdk.UserIdentity {
+	return sdk.UserIdentity{
+		Id:             identity.Id,
+		UserId:         identity.UserId,
+		ProjectId:      identity.ProjectId,
+		AuthProviderId: identity.AuthProviderId,
+		Subject:        identity.Subject,
+		Email:          identity.Email,
+		EmailVerified:  identity.EmailVerified,
+		LastLoginAt:    identity.LastLoginAt,
+		CreatedAt:      identity.CreatedAt,
+		UnlinkedAt:     identity.UnlinkedAt,
+	}
+}
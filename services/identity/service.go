@@ -0,0 +1,28 @@
+package identity
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// Service keeps the identities of the users at the auth providers. An identity is keyed by the auth
+// provider and the subject the provider gives the user, so that logins find the user even after
+// their email address changed at the provider.
+type Service interface {
+	// Get returns the identity of the subject at the auth provider, sdk.ErrUserIdentityNotFound if it was never linked.
+	// An identity the user unlinked is returned with its UnlinkedAt set.
+	Get(ctx context.Context, authProviderId, subject string) (*sdk.UserIdentity, error)
+	// List returns the identities linked to the user, oldest first
+	List(ctx context.Context, userId string) ([]sdk.UserIdentity, error)
+	// Link links the identity to its user as logged in now. An identity already linked to the user is updated,
+	// one linked to another user is refused with sdk.ErrUserIdentityLinkedToAnotherUser. An unlinked identity is
+	// linked again, to whichever user links it.
+	Link(ctx context.Context, identity *sdk.UserIdentity) error
+	// RecordLogin saves the email address reported by the auth provider and the time of the login
+	RecordLogin(ctx context.Context, identity *sdk.UserIdentity) error
+	// Unlink unlinks the identity of the user, sdk.ErrUserIdentityNotFound if the user has no such linked identity.
+	// The identity is kept as unlinked, so that its logins are not linked to the user by email address again.
+	// The roles the user had only through the groups of the identity are removed.
+	Unlink(ctx context.Context, userId, id string) error
+}
@@ -0,0 +1,105 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/user"
+)
+
+type service struct {
+	store   Store
+	userSvc user.Service
+}
+
+// NewService creates the user identity service.
+func NewService(store Store, userSvc user.Service) Service {
+	return service{store: store, userSvc: userSvc}
+}
+
+func (s service) Get(ctx context.Context, authProviderId, subject string) (*sdk.UserIdentity, error) {
+	return s.store.Get(ctx, authProviderId, subject)
+}
+
+func (s service) List(ctx context.Context, userId string) ([]sdk.UserIdentity, error) {
+	identities, err := s.store.List(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the user identities: %w", err)
+	}
+	return identities, nil
+}
+
+func (s service) Link(ctx context.Context, identity *sdk.UserIdentity) error {
+	/*
+	 * an identity can log in only one user
+	 * an unlinked identity is linked again to the user linking it
+	 * linking it again to its user records the login
+	 * otherwise it is linked to the user
+	 */
+	existing, err := s.store.Get(ctx, identity.AuthProviderId, identity.Subject)
+	if err != nil && !errors.Is(err, sdk.ErrUserIdentityNotFound) {
+		return fmt.Errorf("error fetching the user identity: %w", err)
+	}
+	if existing != nil && existing.UnlinkedAt != nil {
+		now := time.Now()
+		identity.Id = existing.Id
+		identity.CreatedAt = existing.CreatedAt
+		identity.LastLoginAt = &now
+		identity.UnlinkedAt = nil
+		err = s.store.Relink(ctx, identity)
+		if err != nil {
+			return fmt.Errorf("error linking the user identity again: %w", err)
+		}
+		return nil
+	}
+	if existing != nil {
+		if existing.UserId != identity.UserId {
+			return sdk.ErrUserIdentityLinkedToAnotherUser
+		}
+		existing.Email = identity.Email
+		existing.EmailVerified = identity.EmailVerified
+		err = s.RecordLogin(ctx, existing)
+		if err != nil {
+			return err
+		}
+		*identity = *existing
+		return nil
+	}
+
+	now := time.Now()
+	identity.Id = uuid.NewString()
+	identity.CreatedAt = &now
+	identity.LastLoginAt = &now
+	err = s.store.Create(ctx, identity)
+	if err != nil {
+		return fmt.Errorf("error linking the user identity: %w", err)
+	}
+	return nil
+}
+
+func (s service) RecordLogin(ctx context.Context, identity *sdk.UserIdentity) error {
+	now := time.Now()
+	identity.LastLoginAt = &now
+	err := s.store.UpdateLogin(ctx, identity)
+	if err != nil {
+		return fmt.Errorf("error recording the login of the user identity: %w", err)
+	}
+	return nil
+}
+
+func (s service) Unlink(ctx context.Context, userId, id string) error {
+	err := s.store.Unlink(ctx, userId, id, time.Now())
+	if err != nil {
+		return err
+	}
+	// the roles granted through the groups of the identity go with it
+	_, err = s.userSvc.SyncGroupRoles(ctx, userId, id, nil)
+	if err != nil {
+		return fmt.Errorf("error removing the group roles of the user identity: %w", err)
+	}
+	return nil
+}
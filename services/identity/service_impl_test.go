@@ -0,0 +1,165 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockStore implements Store interface for testing
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) Get(ctx context.Context, authProviderId, subject string) (*sdk.UserIdentity, error) {
+	args := m.Called(ctx, authProviderId, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.UserIdentity), args.Error(1)
+}
+
+func (m *MockStore) List(ctx context.Context, userId string) ([]sdk.UserIdentity, error) {
+	args := m.Called(ctx, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]sdk.UserIdentity), args.Error(1)
+}
+
+func (m *MockStore) Create(ctx context.Context, identity *sdk.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockStore) Relink(ctx context.Context, identity *sdk.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockStore) UpdateLogin(ctx context.Context, identity *sdk.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockStore) Unlink(ctx context.Context, userId, id string, unlinkedAt time.Time) error {
+	args := m.Called(ctx, userId, id, unlinkedAt)
+	return args.Error(0)
+}
+
+func TestNewService(t *testing.T) {
+	svc := NewService(&MockStore{}, nil)
+
+	assert.NotNil(t, svc)
+	assert.Implements(t, (*Service)(nil), svc)
+}
+
+func TestService_Link(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("links a new identity", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("Get", ctx, "provider-1", "subject-1").Return(nil, sdk.ErrUserIdentityNotFound)
+		mockStore.On("Create", ctx, mock.MatchedBy(func(i *sdk.UserIdentity) bool {
+			return len(i.Id) > 0 && i.UserId == "user-1" && i.CreatedAt != nil && i.LastLoginAt != nil
+		})).Return(nil)
+
+		identity := &sdk.UserIdentity{UserId: "user-1", AuthProviderId: "provider-1", Subject: "subject-1", Email: "a@example.com"}
+		err := NewService(mockStore, nil).Link(ctx, identity)
+		require.NoError(t, err)
+		assert.NotEmpty(t, identity.Id)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("records the login of an identity linked to the user", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("Get", ctx, "provider-1", "subject-1").Return(&sdk.UserIdentity{Id: "identity-1", UserId: "user-1", Email: "old@example.com"}, nil)
+		mockStore.On("UpdateLogin", ctx, mock.MatchedBy(func(i *sdk.UserIdentity) bool {
+			return i.Id == "identity-1" && i.Email == "new@example.com" && i.LastLoginAt != nil
+		})).Return(nil)
+
+		identity := &sdk.UserIdentity{UserId: "user-1", AuthProviderId: "provider-1", Subject: "subject-1", Email: "new@example.com"}
+		err := NewService(mockStore, nil).Link(ctx, identity)
+		require.NoError(t, err)
+		assert.Equal(t, "identity-1", identity.Id)
+		mockStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("refuses an identity linked to another user", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("Get", ctx, "provider-1", "subject-1").Return(&sdk.UserIdentity{Id: "identity-1", UserId: "user-2"}, nil)
+
+		err := NewService(mockStore, nil).Link(ctx, &sdk.UserIdentity{UserId: "user-1", AuthProviderId: "provider-1", Subject: "subject-1"})
+		assert.ErrorIs(t, err, sdk.ErrUserIdentityLinkedToAnotherUser)
+		mockStore.AssertNotCalled(t, "UpdateLogin", mock.Anything, mock.Anything)
+	})
+
+	t.Run("links an unlinked identity again", func(t *testing.T) {
+		mockStore := &MockStore{}
+		unlinkedAt := time.Now().Add(-time.Hour)
+		mockStore.On("Get", ctx, "provider-1", "subject-1").Return(&sdk.UserIdentity{Id: "identity-1", UserId: "user-2", UnlinkedAt: &unlinkedAt}, nil)
+		mockStore.On("Relink", ctx, mock.MatchedBy(func(i *sdk.UserIdentity) bool {
+			return i.Id == "identity-1" && i.UserId == "user-1" && i.UnlinkedAt == nil && i.LastLoginAt != nil
+		})).Return(nil)
+
+		identity := &sdk.UserIdentity{UserId: "user-1", AuthProviderId: "provider-1", Subject: "subject-1"}
+		err := NewService(mockStore, nil).Link(ctx, identity)
+		require.NoError(t, err)
+		assert.Equal(t, "identity-1", identity.Id)
+		mockStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("Get", ctx, "provider-1", "subject-1").Return(nil, errors.New("db down"))
+
+		err := NewService(mockStore, nil).Link(ctx, &sdk.UserIdentity{UserId: "user-1", AuthProviderId: "provider-1", Subject: "subject-1"})
+		assert.ErrorContains(t, err, "db down")
+	})
+}
+
+func TestService_List(t *testing.T) {
+	ctx := context.Background()
+	mockStore := &MockStore{}
+	mockStore.On("List", ctx, "user-1").Return([]sdk.UserIdentity{{Id: "identity-1"}}, nil)
+	mockStore.On("List", ctx, "user-2").Return(nil, errors.New("db down"))
+
+	identities, err := NewService(mockStore, nil).List(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, identities, 1)
+
+	_, err = NewService(mockStore, nil).List(ctx, "user-2")
+	assert.ErrorContains(t, err, "error fetching the user identities")
+}
+
+func TestService_Unlink(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("removes the roles granted through the groups of the identity", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockUser := &services.MockUserService{}
+		mockStore.On("Unlink", ctx, "user-1", "identity-1", mock.AnythingOfType("time.Time")).Return(nil)
+		mockUser.On("SyncGroupRoles", ctx, "user-1", "identity-1", []string(nil)).Return(&sdk.User{Id: "user-1"}, nil)
+
+		err := NewService(mockStore, mockUser).Unlink(ctx, "user-1", "identity-1")
+		require.NoError(t, err)
+		mockUser.AssertExpectations(t)
+	})
+
+	t.Run("identity not linked to the user", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockUser := &services.MockUserService{}
+		mockStore.On("Unlink", ctx, "user-1", "identity-2", mock.AnythingOfType("time.Time")).Return(sdk.ErrUserIdentityNotFound)
+
+		err := NewService(mockStore, mockUser).Unlink(ctx, "user-1", "identity-2")
+		assert.ErrorIs(t, err, sdk.ErrUserIdentityNotFound)
+		mockUser.AssertNotCalled(t, "SyncGroupRoles", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
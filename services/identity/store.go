@@ -0,0 +1,22 @@
+package identity
+
+import (
+	"context"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+type Store interface {
+	// Get returns the identity of the subject at the auth provider, sdk.ErrUserIdentityNotFound if there is none
+	Get(ctx context.Context, authProviderId, subject string) (*sdk.UserIdentity, error)
+	// List returns the identities linked to the user, oldest first
+	List(ctx context.Context, userId string) ([]sdk.UserIdentity, error)
+	Create(ctx context.Context, identity *sdk.UserIdentity) error
+	// Relink links an unlinked identity to the user of the identity again, as logged in now
+	Relink(ctx context.Context, identity *sdk.UserIdentity) error
+	// UpdateLogin saves the email address and the last login time of the identity
+	UpdateLogin(ctx context.Context, identity *sdk.UserIdentity) error
+	// Unlink marks the identity of the user unlinked, sdk.ErrUserIdentityNotFound if the user has no such linked identity
+	Unlink(ctx context.Context, userId, id string, unlinkedAt time.Time) error
+}
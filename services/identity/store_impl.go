@@ -0,0 +1,136 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/db"
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type store struct {
+	db db.DB
+}
+
+// NewStore creates a user identity store backed by mongo.
+func NewStore(db db.DB) Store {
+	return store{db: db}
+}
+
+func (s store) Get(ctx context.Context, authProviderId, subject string) (*sdk.UserIdentity, error) {
+	md := models.GetUserIdentityModel()
+	filter := bson.D{
+		{Key: md.AuthProviderIdKey, Value: authProviderId},
+		{Key: md.SubjectKey, Value: subject},
+	}
+	var identity models.UserIdentity
+	err := s.db.FindOne(ctx, md, filter).Decode(&identity)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, sdk.ErrUserIdentityNotFound
+		}
+		return nil, fmt.Errorf("error finding user identity: %w", err)
+	}
+	result := fromModelToSdk(identity)
+	return &result, nil
+}
+
+func (s store) List(ctx context.Context, userId string) ([]sdk.UserIdentity, error) {
+	md := models.GetUserIdentityModel()
+	opts := options.Find().SetSort(bson.D{{Key: md.CreatedAtKey, Value: 1}})
+	filter := bson.D{
+		{Key: md.UserIdKey, Value: userId},
+		{Key: md.UnlinkedAtKey, Value: nil},
+	}
+	cursor, err := s.db.Find(ctx, md, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding user identities: %w", err)
+	}
+	defer func() {
+		err := cursor.Close(ctx)
+		if err != nil {
+			log.Errorw("error closing cursor after reading user identities", "error", err)
+		}
+	}()
+	var identities []models.UserIdentity
+	err = cursor.All(ctx, &identities)
+	if err != nil {
+		return nil, fmt.Errorf("error reading user identities: %w", err)
+	}
+	result := make([]sdk.UserIdentity, 0, len(identities))
+	for _, identity := range identities {
+		result = append(result, fromModelToSdk(identity))
+	}
+	return result, nil
+}
+
+func (s store) Create(ctx context.Context, identity *sdk.UserIdentity) error {
+	md := models.GetUserIdentityModel()
+	_, err := s.db.InsertOne(ctx, md, fromSdkToModel(*identity))
+	if err != nil {
+		return fmt.Errorf("error creating user identity: %w", err)
+	}
+	return nil
+}
+
+func (s store) Relink(ctx context.Context, identity *sdk.UserIdentity) error {
+	md := models.GetUserIdentityModel()
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: md.UserIdKey, Value: identity.UserId},
+		{Key: md.ProjectIdKey, Value: identity.ProjectId},
+		{Key: md.EmailKey, Value: identity.Email},
+		{Key: md.EmailVerifiedKey, Value: identity.EmailVerified},
+		{Key: md.LastLoginAtKey, Value: identity.LastLoginAt},
+		{Key: md.UnlinkedAtKey, Value: nil},
+	}}}
+	res, err := s.db.UpdateOne(ctx, md, bson.D{{Key: md.IdKey, Value: identity.Id}}, update)
+	if err != nil {
+		return fmt.Errorf("error relinking user identity: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return sdk.ErrUserIdentityNotFound
+	}
+	return nil
+}
+
+func (s store) UpdateLogin(ctx context.Context, identity *sdk.UserIdentity) error {
+	md := models.GetUserIdentityModel()
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: md.EmailKey, Value: identity.Email},
+		{Key: md.EmailVerifiedKey, Value: identity.EmailVerified},
+		{Key: md.LastLoginAtKey, Value: identity.LastLoginAt},
+	}}}
+	res, err := s.db.UpdateOne(ctx, md, bson.D{{Key: md.IdKey, Value: identity.Id}}, update)
+	if err != nil {
+		return fmt.Errorf("error updating user identity: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return sdk.ErrUserIdentityNotFound
+	}
+	return nil
+}
+
+func (s store) Unlink(ctx context.Context, userId, id string, unlinkedAt time.Time) error {
+	md := models.GetUserIdentityModel()
+	filter := bson.D{
+		{Key: md.IdKey, Value: id},
+		{Key: md.UserIdKey, Value: userId},
+		{Key: md.UnlinkedAtKey, Value: nil},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: md.UnlinkedAtKey, Value: unlinkedAt}}}}
+	res, err := s.db.UpdateOne(ctx, md, filter, update)
+	if err != nil {
+		return fmt.Errorf("error unlinking user identity: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return sdk.ErrUserIdentityNotFound
+	}
+	return nil
+}
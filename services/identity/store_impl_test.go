@@ -0,0 +1,101 @@
+package identity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestStore_Get(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetUserIdentityModel()
+	filter := bson.D{{Key: md.AuthProviderIdKey, Value: "provider-1"}, {Key: md.SubjectKey, Value: "subject-1"}}
+
+	t.Run("found", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		document := bson.D{{Key: md.IdKey, Value: "identity-1"}, {Key: md.UserIdKey, Value: "user-1"}, {Key: md.EmailVerifiedKey, Value: true}}
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(document, nil, nil))
+
+		identity, err := NewStore(mockDB).Get(ctx, "provider-1", "subject-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "identity-1", identity.Id)
+		assert.Equal(t, "user-1", identity.UserId)
+		assert.True(t, identity.EmailVerified)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil))
+
+		_, err := NewStore(mockDB).Get(ctx, "provider-1", "subject-1")
+		assert.ErrorIs(t, err, sdk.ErrUserIdentityNotFound)
+	})
+}
+
+func TestStore_List(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetUserIdentityModel()
+	mockDB := test.SetupMockDB()
+	documents := []interface{}{
+		bson.D{{Key: md.IdKey, Value: "identity-1"}, {Key: md.UserIdKey, Value: "user-1"}, {Key: md.SubjectKey, Value: "subject-1"}},
+		bson.D{{Key: md.IdKey, Value: "identity-2"}, {Key: md.UserIdKey, Value: "user-1"}, {Key: md.SubjectKey, Value: "subject-2"}},
+	}
+	cursor, _ := mongo.NewCursorFromDocuments(documents, nil, nil)
+	mockDB.On("Find", ctx, md, bson.D{{Key: md.UserIdKey, Value: "user-1"}, {Key: md.UnlinkedAtKey, Value: nil}}, mock.Anything).Return(cursor, nil)
+
+	identities, err := NewStore(mockDB).List(ctx, "user-1")
+	assert.NoError(t, err)
+	assert.Len(t, identities, 2)
+	assert.Equal(t, "subject-2", identities[1].Subject)
+}
+
+func TestStore_UpdateLogin(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetUserIdentityModel()
+	mockDB := test.SetupMockDB()
+	mockDB.On("UpdateOne", ctx, md, bson.D{{Key: md.IdKey, Value: "identity-1"}}, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+
+	err := NewStore(mockDB).UpdateLogin(ctx, &sdk.UserIdentity{Id: "identity-1"})
+	assert.ErrorIs(t, err, sdk.ErrUserIdentityNotFound)
+}
+
+func TestStore_Relink(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetUserIdentityModel()
+	mockDB := test.SetupMockDB()
+	mockDB.On("UpdateOne", ctx, md, bson.D{{Key: md.IdKey, Value: "identity-1"}}, mock.MatchedBy(func(update bson.D) bool {
+		set := update[0].Value.(bson.D)
+		return set[0].Key == md.UserIdKey && set[0].Value == "user-1" && set[len(set)-1].Key == md.UnlinkedAtKey && set[len(set)-1].Value == nil
+	}), mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+	err := NewStore(mockDB).Relink(ctx, &sdk.UserIdentity{Id: "identity-1", UserId: "user-1"})
+	assert.NoError(t, err)
+}
+
+func TestStore_Unlink(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetUserIdentityModel()
+	filter := bson.D{{Key: md.IdKey, Value: "identity-1"}, {Key: md.UserIdKey, Value: "user-1"}, {Key: md.UnlinkedAtKey, Value: nil}}
+	unlinkedAt := time.Now()
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: md.UnlinkedAtKey, Value: unlinkedAt}}}}
+
+	t.Run("unlinked", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("UpdateOne", ctx, md, filter, update, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		assert.NoError(t, NewStore(mockDB).Unlink(ctx, "user-1", "identity-1", unlinkedAt))
+	})
+
+	t.Run("not found or already unlinked", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("UpdateOne", ctx, md, filter, update, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+		assert.ErrorIs(t, NewStore(mockDB).Unlink(ctx, "user-1", "identity-1", unlinkedAt), sdk.ErrUserIdentityNotFound)
+	})
+}
@@ -0,0 +1,222 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	jwtp "github.com/golang-jwt/jwt/v4"
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// generatePrivateKey creates a new private key for the given signing algorithm
+func generatePrivateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case sdk.SigningAlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case sdk.SigningAlgorithmES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case sdk.SigningAlgorithmEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm %s", algorithm)
+}
+
+// parsePrivateKey decodes a PEM encoded PKCS1, SEC1 or PKCS8 private key
+func parsePrivateKey(pemKey []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, errors.New("no pem block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key cannot be used for signing")
+	}
+	return signer, nil
+}
+
+// encodePrivateKey encodes the private key as a PKCS8 PEM block
+func encodePrivateKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding private key %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// keyAlgorithm returns the signing algorithm matching the private key type
+func keyAlgorithm(key crypto.Signer) (string, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return sdk.SigningAlgorithmRS256, nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return "", errors.New("only P-256 ecdsa keys are supported")
+		}
+		return sdk.SigningAlgorithmES256, nil
+	case ed25519.PrivateKey:
+		return sdk.SigningAlgorithmEdDSA, nil
+	}
+	return "", fmt.Errorf("unsupported private key type %T", key)
+}
+
+func signingMethod(algorithm string) (jwtp.SigningMethod, error) {
+	switch algorithm {
+	case sdk.SigningAlgorithmRS256:
+		return jwtp.SigningMethodRS256, nil
+	case sdk.SigningAlgorithmES256:
+		return jwtp.SigningMethodES256, nil
+	case sdk.SigningAlgorithmEdDSA:
+		return jwtp.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm %s", algorithm)
+}
+
+// publicJwk returns the public part of the key as a JSON Web Key with the
+// RFC 7638 thumbprint as its key id
+func publicJwk(key crypto.Signer, algorithm string) (sdk.Jwk, error) {
+	var jwk sdk.Jwk
+	var thumbprint string
+	switch k := key.Public().(type) {
+	case *rsa.PublicKey:
+		jwk = sdk.Jwk{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}
+		thumbprint = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	case *ecdsa.PublicKey:
+		jwk = sdk.Jwk{
+			Kty: "EC",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, 32))),
+			Y:   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, 32))),
+		}
+		thumbprint = fmt.Sprintf(`{"crv":"P-256","kty":"EC","x":"%s","y":"%s"}`, jwk.X, jwk.Y)
+	case ed25519.PublicKey:
+		jwk = sdk.Jwk{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}
+		thumbprint = fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, jwk.X)
+	default:
+		return sdk.Jwk{}, fmt.Errorf("unsupported public key type %T", k)
+	}
+	hash := sha256.Sum256([]byte(thumbprint))
+	jwk.Kid = base64.RawURLEncoding.EncodeToString(hash[:])
+	jwk.Use = "sig"
+	jwk.Alg = algorithm
+	return jwk, nil
+}
+
+func fromModelToSdk(key *models.SigningKey) *sdk.SigningKey {
+	return &sdk.SigningKey{
+		Id:         key.Id,
+		Algorithm:  key.Algorithm,
+		Status:     sdk.SigningKeyStatus(key.Status),
+		PrivateKey: sdk.MaskedBytes(key.PrivateKey),
+		CreatedAt:  key.CreatedAt,
+		RotatedAt:  key.RotatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		RetiredAt:  key.RetiredAt,
+	}
+}
+
+func fromSdkToModel(key sdk.SigningKey) models.SigningKey {
+	return models.SigningKey{
+		Id:         key.Id,
+		Algorithm:  key.Algorithm,
+		Status:     string(key.Status),
+		PrivateKey: string(key.PrivateKey),
+		CreatedAt:  key.CreatedAt,
+		RotatedAt:  key.RotatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		RetiredAt:  key.RetiredAt,
+	}
+}
+
+func newSigningKey(signer crypto.Signer, algorithm string) (*signingKey, error) {
+	method, err := signingMethod(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := publicJwk(signer, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	pemKey, err := encodePrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	return &signingKey{
+		SigningKey: sdk.SigningKey{
+			Id:         jwk.Kid,
+			Algorithm:  algorithm,
+			Status:     sdk.SigningKeyStatusActive,
+			PublicKey:  jwk,
+			PrivateKey: sdk.MaskedBytes(pemKey),
+		},
+		signer: signer,
+		method: method,
+	}, nil
+}
+
+func parseSigningKey(key sdk.SigningKey) (*signingKey, error) {
+	signer, err := parsePrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	method, err := signingMethod(key.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := publicJwk(signer, key.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	jwk.Kid = key.Id
+	key.PublicKey = jwk
+	return &signingKey{SigningKey: key, signer: signer, method: method}, nil
+}
+
+// withPublicKey fills the public key of the signing key and drops the private key
+func withPublicKey(key sdk.SigningKey) (*sdk.SigningKey, error) {
+	parsed, err := parseSigningKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the signing key %s %w", key.Id, err)
+	}
+	result := parsed.SigningKey
+	result.PrivateKey = nil
+	return &result, nil
+}
+
+func createdAt(key sdk.SigningKey) time.Time {
+	if key.CreatedAt == nil {
+		return time.Time{}
+	}
+	return *key.CreatedAt
+}
@@ -1,6 +1,28 @@
 package jwt
 
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
 type Service interface {
 	GenerateToken(claims map[string]interface{}, expiryTimeInSeconds int64) (string, error)
 	ValidateToken(token string) (map[string]interface{}, error)
+	// GenerateIdToken signs the claims with the asymmetric signing key so that
+	// relying parties can verify the token using the published JWKS.
+	GenerateIdToken(claims map[string]interface{}, expiryTimeInSeconds int64) (string, error)
+	// Jwks returns the public keys that can be used to verify the tokens.
+	Jwks() sdk.Jwks
+
+	// GetKeys returns every key of the signing keyset including the retired ones.
+	GetKeys(ctx context.Context) ([]sdk.SigningKey, error)
+	// RotateKey generates a new active key. The previous active keys keep
+	// verifying tokens until the overlap window ends.
+	RotateKey(ctx context.Context) (*sdk.SigningKey, error)
+	// RetireKey stops trusting the tokens signed with the given key.
+	RetireKey(ctx context.Context, id string) (*sdk.SigningKey, error)
+	// RunKeyRotation periodically syncs the keyset with the store and rotates
+	// the active key when it is due. It blocks until the context is done.
+	RunKeyRotation(ctx context.Context)
 }
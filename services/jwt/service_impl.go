@@ -1,46 +1,128 @@
 package jwt
 
 import (
+	"context"
+	"crypto"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/gofiber/fiber/v2/log"
 	jwtp "github.com/golang-jwt/jwt/v4"
 	"github.com/melvinodsa/go-iam/sdk"
 )
 
+const (
+	// accessTokenType is the typ header of asymmetric access tokens (RFC 9068).
+	// It keeps ID tokens, which share the keyset, from being accepted as access tokens.
+	accessTokenType = "at+jwt"
+	// legacyKeyId is the kid header of the tokens signed with the shared secret
+	legacyKeyId = "legacy-hs256"
+	// keyRefreshInterval is how often the keyset is synced with the store
+	keyRefreshInterval = 5 * time.Minute
+	// keyReloadCooldown limits how often an unknown kid triggers a reload of the keyset
+	keyReloadCooldown = 30 * time.Second
+)
+
+// Options configure the token signing algorithm and the key rotation schedule.
+type Options struct {
+	Algorithm        string          // Algorithm for signing access tokens. HS256 keeps the legacy shared secret mode
+	Secret           sdk.MaskedBytes // Shared secret used in the legacy HS256 mode
+	SeedKey          sdk.MaskedBytes // PEM encoded private key imported when the keyset is empty
+	RotationInterval time.Duration   // Age after which the active key is rotated. 0 disables scheduled rotation
+	OverlapWindow    time.Duration   // Duration for which a rotated key still verifies tokens
+}
+
+type signingKey struct {
+	sdk.SigningKey
+	signer crypto.Signer
+	method jwtp.SigningMethod
+}
+
 type service struct {
-	secret []byte
+	store Store
+	opts  Options
+
+	rotateMu sync.Mutex
+	mu       sync.RWMutex
+	keys     map[string]*signingKey
+	active   *signingKey
+	loadedAt time.Time
 }
 
+// NewService creates a jwt service in the legacy HS256 mode. Access tokens are signed
+// with the shared secret while ID tokens use an in-memory RSA key which does not
+// survive a restart.
 func NewService(secret sdk.MaskedBytes) Service {
-	return &service{secret: []byte(secret)}
+	svc, err := NewServiceWithStore(context.Background(), newMemoryStore(), Options{
+		Algorithm: sdk.SigningAlgorithmHS256,
+		Secret:    secret,
+	})
+	if err != nil {
+		// key generation only fails if the random source is broken
+		panic(fmt.Errorf("error creating the jwt service %w", err))
+	}
+	return svc
+}
+
+// NewServiceWithStore creates a jwt service which signs tokens with the keyset persisted in the store.
+// A new key is generated (or the seed key imported) if the keyset has no active key for the algorithm.
+func NewServiceWithStore(ctx context.Context, store Store, opts Options) (Service, error) {
+	if opts.Algorithm == "" {
+		opts.Algorithm = sdk.SigningAlgorithmRS256
+	}
+	if opts.Algorithm != sdk.SigningAlgorithmHS256 {
+		if _, err := signingMethod(opts.Algorithm); err != nil {
+			return nil, err
+		}
+	}
+	s := &service{store: store, opts: opts, keys: map[string]*signingKey{}}
+	if err := s.load(ctx); err != nil {
+		return nil, fmt.Errorf("error loading the signing keys %w", err)
+	}
+	if err := s.bootstrap(ctx); err != nil {
+		return nil, fmt.Errorf("error initializing the signing keys %w", err)
+	}
+	return s, nil
 }
 
 // GenerateToken generates a JWT token with the given claims
-func (s service) GenerateToken(claims map[string]interface{}, expiryTimeInSeconds int64) (string, error) {
+func (s *service) GenerateToken(claims map[string]interface{}, expiryTimeInSeconds int64) (string, error) {
 	jwtClaims := jwtp.MapClaims{}
 	for k, v := range claims {
 		jwtClaims[k] = v
 	}
 	jwtClaims["exp"] = expiryTimeInSeconds
-	token := jwtp.NewWithClaims(jwtp.SigningMethodHS256, jwtClaims)
 
-	tokenString, err := token.SignedString(s.secret)
+	if s.legacy() {
+		token := jwtp.NewWithClaims(jwtp.SigningMethodHS256, jwtClaims)
+		token.Header["kid"] = legacyKeyId
+		tokenString, err := token.SignedString([]byte(s.opts.Secret))
+		if err != nil {
+			return "", fmt.Errorf("error signing jwt token with secret %w", err)
+		}
+		return tokenString, nil
+	}
+
+	key, err := s.activeKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwtp.NewWithClaims(key.method, jwtClaims)
+	token.Header["kid"] = key.Id
+	token.Header["typ"] = accessTokenType
+	tokenString, err := token.SignedString(key.signer)
 	if err != nil {
-		return "", fmt.Errorf("error signing jwt token with secret %w", err)
+		return "", fmt.Errorf("error signing jwt token with key %s %w", key.Id, err)
 	}
 	return tokenString, nil
 }
 
 // ValidateToken validates the given JWT token and returns the claims
-func (s service) ValidateToken(tokenString string) (map[string]interface{}, error) {
-	token, err := jwtp.Parse(tokenString, func(token *jwtp.Token) (interface{}, error) {
-		// Validate the signing method
-		if _, ok := token.Method.(*jwtp.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.secret, nil
-	})
-
+func (s *service) ValidateToken(tokenString string) (map[string]interface{}, error) {
+	token, err := jwtp.Parse(tokenString, s.verificationKey)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing jwt token %w", err)
 	}
@@ -56,3 +138,333 @@ func (s service) ValidateToken(tokenString string) (map[string]interface{}, erro
 	}
 	return result, nil
 }
+
+// GenerateIdToken generates a JWT signed with the active asymmetric key and the kid header set
+func (s *service) GenerateIdToken(claims map[string]interface{}, expiryTimeInSeconds int64) (string, error) {
+	key, err := s.activeKey()
+	if err != nil {
+		return "", err
+	}
+	jwtClaims := jwtp.MapClaims{}
+	for k, v := range claims {
+		jwtClaims[k] = v
+	}
+	jwtClaims["exp"] = expiryTimeInSeconds
+	token := jwtp.NewWithClaims(key.method, jwtClaims)
+	token.Header["kid"] = key.Id
+
+	tokenString, err := token.SignedString(key.signer)
+	if err != nil {
+		return "", fmt.Errorf("error signing id token %w", err)
+	}
+	return tokenString, nil
+}
+
+// Jwks returns the public part of every key that can still verify tokens, the active key first
+func (s *service) Jwks() sdk.Jwks {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	keys := []*signingKey{}
+	for _, k := range s.keys {
+		if k.CanVerify(now) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return createdAt(keys[i].SigningKey).After(createdAt(keys[j].SigningKey))
+	})
+	jwks := sdk.Jwks{Keys: []sdk.Jwk{}}
+	for _, k := range keys {
+		jwks.Keys = append(jwks.Keys, k.PublicKey)
+	}
+	return jwks
+}
+
+// GetKeys returns every key of the keyset, the newest first
+func (s *service) GetKeys(ctx context.Context) ([]sdk.SigningKey, error) {
+	keys, err := s.store.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the signing keys %w", err)
+	}
+	result := make([]sdk.SigningKey, 0, len(keys))
+	for _, k := range keys {
+		key, err := withPublicKey(k)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *key)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return createdAt(result[i]).After(createdAt(result[j]))
+	})
+	return result, nil
+}
+
+// RotateKey generates a new active key and moves the previous active keys into the overlap window
+func (s *service) RotateKey(ctx context.Context) (*sdk.SigningKey, error) {
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+
+	algorithm := s.keyAlgorithm()
+	signer, err := generatePrivateKey(algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("error generating the signing key %w", err)
+	}
+	key, err := newSigningKey(signer, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	// the new key is saved before demoting the old ones so that the keyset always has an active key.
+	// rotateMu only guards this instance, so only the keys created before the new one are demoted.
+	// When instances rotate at the same time, the newest key stays active and demotes the others.
+	if err := s.store.Create(ctx, &key.SigningKey); err != nil {
+		return nil, fmt.Errorf("error saving the signing key %w", err)
+	}
+	now := time.Now()
+	if err := s.store.Demote(ctx, createdAt(key.SigningKey), now, now.Add(s.opts.OverlapWindow)); err != nil {
+		return nil, fmt.Errorf("error rotating the previous signing keys %w", err)
+	}
+
+	if err := s.load(ctx); err != nil {
+		return nil, fmt.Errorf("error reloading the signing keys %w", err)
+	}
+	log.Infow("rotated the jwt signing key", "kid", key.Id, "algorithm", key.Algorithm)
+	return withPublicKey(key.SigningKey)
+}
+
+// RetireKey stops trusting the given key. The active key cannot be retired
+func (s *service) RetireKey(ctx context.Context, id string) (*sdk.SigningKey, error) {
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+
+	key, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the signing key %w", err)
+	}
+	if key.Status == sdk.SigningKeyStatusActive {
+		return nil, sdk.ErrActiveSigningKey
+	}
+	if key.Status != sdk.SigningKeyStatusRetired {
+		now := time.Now()
+		key.Status = sdk.SigningKeyStatusRetired
+		key.RetiredAt = &now
+		if err := s.store.Update(ctx, key); err != nil {
+			return nil, fmt.Errorf("error retiring the signing key %w", err)
+		}
+		if err := s.load(ctx); err != nil {
+			return nil, fmt.Errorf("error reloading the signing keys %w", err)
+		}
+		log.Infow("retired the jwt signing key", "kid", key.Id)
+	}
+	return withPublicKey(*key)
+}
+
+// RunKeyRotation syncs the keyset every keyRefreshInterval until the context is done
+func (s *service) RunKeyRotation(ctx context.Context) {
+	ticker := time.NewTicker(keyRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.syncKeys(ctx); err != nil {
+				log.Errorw("error syncing the jwt signing keys", "error", err)
+			}
+		}
+	}
+}
+
+// syncKeys picks up keys rotated by other instances, retires the keys whose
+// overlap window is over and rotates the active key when it is due
+func (s *service) syncKeys(ctx context.Context) error {
+	keys, err := s.store.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching the signing keys %w", err)
+	}
+	now := time.Now()
+	for _, k := range keys {
+		if k.Status != sdk.SigningKeyStatusRotated || k.CanVerify(now) {
+			continue
+		}
+		k.Status = sdk.SigningKeyStatusRetired
+		k.RetiredAt = &now
+		if err := s.store.Update(ctx, &k); err != nil {
+			return fmt.Errorf("error retiring the signing key %s %w", k.Id, err)
+		}
+	}
+	if err := s.load(ctx); err != nil {
+		return fmt.Errorf("error reloading the signing keys %w", err)
+	}
+
+	key, err := s.activeKey()
+	if err != nil {
+		// a keyset left without an active key cannot sign tokens, a new one recovers it
+		log.Warnw("no active jwt signing key, rotating to a new one", "error", err)
+		_, err = s.RotateKey(ctx)
+		return err
+	}
+	if s.opts.RotationInterval <= 0 {
+		return nil
+	}
+	if now.Before(createdAt(key.SigningKey).Add(s.opts.RotationInterval)) {
+		return nil
+	}
+	_, err = s.RotateKey(ctx)
+	return err
+}
+
+// verificationKey is the jwt key func. It picks the verification key using the kid header
+func (s *service) verificationKey(token *jwtp.Token) (interface{}, error) {
+	if s.legacy() {
+		if _, ok := token.Method.(*jwtp.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.opts.Secret), nil
+	}
+
+	switch token.Method.(type) {
+	case *jwtp.SigningMethodRSA, *jwtp.SigningMethodECDSA, *jwtp.SigningMethodEd25519:
+	default:
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	if typ, _ := token.Header["typ"].(string); typ != accessTokenType {
+		return nil, fmt.Errorf("unexpected token type: %v", token.Header["typ"])
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, err := s.lookupKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.Algorithm != token.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.signer.Public(), nil
+}
+
+// lookupKey finds the verification key by kid. An unknown kid could be a key
+// rotated by another instance, so the keyset is reloaded before giving up.
+func (s *service) lookupKey(kid string) (*signingKey, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	stale := time.Since(s.loadedAt) > keyReloadCooldown
+	s.mu.RUnlock()
+
+	if !ok && kid != "" && stale {
+		if err := s.load(context.Background()); err != nil {
+			log.Errorw("error reloading the jwt signing keys", "error", err)
+		}
+		s.mu.RLock()
+		key, ok = s.keys[kid]
+		s.mu.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %s", kid)
+	}
+	if !key.CanVerify(time.Now()) {
+		return nil, fmt.Errorf("signing key %s is no longer trusted", kid)
+	}
+	return key, nil
+}
+
+// load replaces the in-memory keyset with the keys from the store
+func (s *service) load(ctx context.Context) error {
+	s.mu.Lock()
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	keys, err := s.store.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching the signing keys %w", err)
+	}
+	parsed := map[string]*signingKey{}
+	var active *signingKey
+	for _, k := range keys {
+		if k.Status == sdk.SigningKeyStatusRetired {
+			continue
+		}
+		key, err := parseSigningKey(k)
+		if err != nil {
+			return fmt.Errorf("error parsing the signing key %s %w", k.Id, err)
+		}
+		parsed[k.Id] = key
+		if k.Status == sdk.SigningKeyStatusActive && (active == nil || createdAt(k).After(createdAt(active.SigningKey))) {
+			active = key
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = parsed
+	s.active = active
+	return nil
+}
+
+// bootstrap makes sure there is an active key for the configured algorithm
+func (s *service) bootstrap(ctx context.Context) error {
+	s.mu.RLock()
+	active := s.active
+	empty := len(s.keys) == 0
+	s.mu.RUnlock()
+
+	algorithm := s.keyAlgorithm()
+	if active != nil && active.Algorithm == algorithm {
+		return nil
+	}
+	if empty && len(s.opts.SeedKey) > 0 {
+		return s.importKey(ctx, s.opts.SeedKey)
+	}
+	if active != nil {
+		log.Infow("rotating the jwt signing key to match the configured algorithm", "kid", active.Id, "algorithm", algorithm)
+	}
+	_, err := s.RotateKey(ctx)
+	return err
+}
+
+// importKey saves the PEM encoded private key as the active key
+func (s *service) importKey(ctx context.Context, pemKey []byte) error {
+	signer, err := parsePrivateKey(pemKey)
+	if err != nil {
+		return fmt.Errorf("error parsing the jwt signing key %w", err)
+	}
+	algorithm, err := keyAlgorithm(signer)
+	if err != nil {
+		return err
+	}
+	if algorithm != s.keyAlgorithm() {
+		return fmt.Errorf("jwt signing key is a %s key but %s is configured", algorithm, s.keyAlgorithm())
+	}
+	key, err := newSigningKey(signer, algorithm)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Create(ctx, &key.SigningKey); err != nil {
+		return fmt.Errorf("error saving the signing key %w", err)
+	}
+	log.Infow("imported the jwt signing key", "kid", key.Id, "algorithm", algorithm)
+	return s.load(ctx)
+}
+
+func (s *service) activeKey() (*signingKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.active == nil {
+		return nil, errors.New("no active signing key")
+	}
+	return s.active, nil
+}
+
+// legacy reports whether access tokens are signed with the shared secret
+func (s *service) legacy() bool {
+	return s.opts.Algorithm == sdk.SigningAlgorithmHS256
+}
+
+// keyAlgorithm returns the algorithm of the keys in the keyset. The legacy
+// mode still needs an asymmetric key for signing ID tokens.
+func (s *service) keyAlgorithm() string {
+	if s.legacy() {
+		return sdk.SigningAlgorithmRS256
+	}
+	return s.opts.Algorithm
+}
@@ -1,8 +1,16 @@
 package jwt
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -748,3 +756,438 @@ func TestService_ValidateToken_ClaimsTypeAssertionFails(t *testing.T) {
 	assert.Contains(t, err.Error(), "error parsing jwt token")
 	assert.Empty(t, result)
 }
+
+func TestService_GenerateIdToken(t *testing.T) {
+	svc := NewService(sdk.MaskedBytes("test-secret-key-for-jwt"))
+
+	t.Run("signs_with_rs256_and_kid", func(t *testing.T) {
+		claims := map[string]interface{}{"sub": "user-123", "aud": "client-1"}
+		tokenString, err := svc.GenerateIdToken(claims, time.Now().Add(time.Hour).Unix())
+		assert.NoError(t, err)
+
+		jwks := svc.Jwks()
+		assert.Len(t, jwks.Keys, 1)
+		key := jwks.Keys[0]
+		assert.Equal(t, "RSA", key.Kty)
+		assert.Equal(t, "RS256", key.Alg)
+		assert.Equal(t, "sig", key.Use)
+		assert.NotEmpty(t, key.Kid)
+
+		impl := svc.(*service)
+		parsed, err := jwtp.Parse(tokenString, func(token *jwtp.Token) (interface{}, error) {
+			assert.Equal(t, key.Kid, token.Header["kid"])
+			return impl.active.signer.Public(), nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "RS256", parsed.Method.Alg())
+		assert.Equal(t, "user-123", parsed.Claims.(jwtp.MapClaims)["sub"])
+	})
+
+	t.Run("id_token_is_not_accepted_as_access_token", func(t *testing.T) {
+		tokenString, err := svc.GenerateIdToken(map[string]interface{}{"id": "x"}, time.Now().Add(time.Hour).Unix())
+		assert.NoError(t, err)
+
+		_, err = svc.ValidateToken(tokenString)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected signing method")
+	})
+
+	t.Run("legacy_tokens_carry_kid", func(t *testing.T) {
+		tokenString, err := svc.GenerateToken(map[string]interface{}{"id": "x"}, time.Now().Add(time.Hour).Unix())
+		assert.NoError(t, err)
+
+		parsed, _, err := new(jwtp.Parser).ParseUnverified(tokenString, jwtp.MapClaims{})
+		assert.NoError(t, err)
+		assert.Equal(t, "HS256", parsed.Method.Alg())
+		assert.Equal(t, legacyKeyId, parsed.Header["kid"])
+	})
+}
+
+func newTestService(t *testing.T, store Store, opts Options) *service {
+	t.Helper()
+	svc, err := NewServiceWithStore(context.Background(), store, opts)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return svc.(*service)
+}
+
+func TestNewServiceWithStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("generates_key_when_keyset_is_empty", func(t *testing.T) {
+		store := newMemoryStore()
+		svc := newTestService(t, store, Options{})
+
+		keys, err := store.GetAll(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 1)
+		assert.Equal(t, sdk.SigningKeyStatusActive, keys[0].Status)
+		assert.Equal(t, "RS256", keys[0].Algorithm)
+		assert.Equal(t, keys[0].Id, svc.Jwks().Keys[0].Kid)
+	})
+
+	t.Run("reuses_active_key_from_store", func(t *testing.T) {
+		store := newMemoryStore()
+		first := newTestService(t, store, Options{Algorithm: "ES256"})
+		second := newTestService(t, store, Options{Algorithm: "ES256"})
+
+		keys, err := store.GetAll(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 1)
+		assert.Equal(t, first.Jwks(), second.Jwks())
+	})
+
+	t.Run("rotates_when_algorithm_changes", func(t *testing.T) {
+		store := newMemoryStore()
+		old := newTestService(t, store, Options{Algorithm: "RS256", OverlapWindow: time.Hour})
+		token, err := old.GenerateToken(map[string]interface{}{"id": "x"}, time.Now().Add(time.Hour).Unix())
+		assert.NoError(t, err)
+
+		svc := newTestService(t, store, Options{Algorithm: "EdDSA", OverlapWindow: time.Hour})
+		jwks := svc.Jwks()
+		assert.Len(t, jwks.Keys, 2)
+		assert.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+		assert.Equal(t, "RS256", jwks.Keys[1].Alg)
+
+		_, err = svc.ValidateToken(token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("imports_pkcs1_seed_key", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.NoError(t, err)
+		pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+		svc := newTestService(t, newMemoryStore(), Options{SeedKey: sdk.MaskedBytes(pemKey)})
+		expected, err := publicJwk(key, "RS256")
+		assert.NoError(t, err)
+		assert.Equal(t, expected.Kid, svc.Jwks().Keys[0].Kid)
+	})
+
+	t.Run("imports_sec1_seed_key", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		der, err := x509.MarshalECPrivateKey(key)
+		assert.NoError(t, err)
+		pemKey := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+		svc := newTestService(t, newMemoryStore(), Options{Algorithm: "ES256", SeedKey: sdk.MaskedBytes(pemKey)})
+		expected, err := publicJwk(key, "ES256")
+		assert.NoError(t, err)
+		assert.Equal(t, expected.Kid, svc.Jwks().Keys[0].Kid)
+	})
+
+	t.Run("seed_key_is_ignored_when_keyset_exists", func(t *testing.T) {
+		store := newMemoryStore()
+		existing := newTestService(t, store, Options{})
+
+		svc := newTestService(t, store, Options{SeedKey: sdk.MaskedBytes("not-a-pem")})
+		assert.Equal(t, existing.Jwks(), svc.Jwks())
+	})
+
+	t.Run("rejects_invalid_seed_key", func(t *testing.T) {
+		svc, err := NewServiceWithStore(ctx, newMemoryStore(), Options{SeedKey: sdk.MaskedBytes("not-a-pem")})
+		assert.Error(t, err)
+		assert.Nil(t, svc)
+		assert.Contains(t, err.Error(), "no pem block found")
+	})
+
+	t.Run("rejects_seed_key_of_another_algorithm", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		assert.NoError(t, err)
+		pemKey := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+		_, err = NewServiceWithStore(ctx, newMemoryStore(), Options{Algorithm: "RS256", SeedKey: sdk.MaskedBytes(pemKey)})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "jwt signing key is a ES256 key but RS256 is configured")
+	})
+
+	t.Run("rejects_unsupported_algorithm", func(t *testing.T) {
+		_, err := NewServiceWithStore(ctx, newMemoryStore(), Options{Algorithm: "none"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported signing algorithm")
+	})
+}
+
+func TestService_AsymmetricTokens(t *testing.T) {
+	cases := []struct {
+		algorithm string
+		kty       string
+	}{
+		{algorithm: "RS256", kty: "RSA"},
+		{algorithm: "ES256", kty: "EC"},
+		{algorithm: "EdDSA", kty: "OKP"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			svc := newTestService(t, newMemoryStore(), Options{Algorithm: tc.algorithm})
+			jwk := svc.Jwks().Keys[0]
+			assert.Equal(t, tc.kty, jwk.Kty)
+			assert.Equal(t, tc.algorithm, jwk.Alg)
+
+			tokenString, err := svc.GenerateToken(map[string]interface{}{"id": "user-123"}, time.Now().Add(time.Hour).Unix())
+			assert.NoError(t, err)
+
+			parsed, _, err := new(jwtp.Parser).ParseUnverified(tokenString, jwtp.MapClaims{})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.algorithm, parsed.Method.Alg())
+			assert.Equal(t, jwk.Kid, parsed.Header["kid"])
+			assert.Equal(t, "at+jwt", parsed.Header["typ"])
+
+			claims, err := svc.ValidateToken(tokenString)
+			assert.NoError(t, err)
+			assert.Equal(t, "user-123", claims["id"])
+
+			idToken, err := svc.GenerateIdToken(map[string]interface{}{"sub": "user-123"}, time.Now().Add(time.Hour).Unix())
+			assert.NoError(t, err)
+			_, err = svc.ValidateToken(idToken)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "unexpected token type")
+		})
+	}
+
+	svc := newTestService(t, newMemoryStore(), Options{})
+
+	t.Run("rejects_hs256_tokens", func(t *testing.T) {
+		legacy := NewService(sdk.MaskedBytes("secret"))
+		tokenString, err := legacy.GenerateToken(map[string]interface{}{"id": "x"}, time.Now().Add(time.Hour).Unix())
+		assert.NoError(t, err)
+
+		_, err = svc.ValidateToken(tokenString)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected signing method")
+	})
+
+	t.Run("rejects_unknown_kid", func(t *testing.T) {
+		other := newTestService(t, newMemoryStore(), Options{})
+		tokenString, err := other.GenerateToken(map[string]interface{}{"id": "x"}, time.Now().Add(time.Hour).Unix())
+		assert.NoError(t, err)
+
+		_, err = svc.ValidateToken(tokenString)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown signing key")
+	})
+
+	t.Run("rejects_algorithm_mismatching_the_key", func(t *testing.T) {
+		other := newTestService(t, newMemoryStore(), Options{Algorithm: "ES256"})
+		token := jwtp.NewWithClaims(jwtp.SigningMethodES256, jwtp.MapClaims{"exp": time.Now().Add(time.Hour).Unix()})
+		token.Header["kid"] = svc.Jwks().Keys[0].Kid
+		token.Header["typ"] = "at+jwt"
+		tokenString, err := token.SignedString(other.active.signer)
+		assert.NoError(t, err)
+
+		_, err = svc.ValidateToken(tokenString)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected signing method")
+	})
+
+	t.Run("picks_up_keys_rotated_by_another_instance", func(t *testing.T) {
+		store := newMemoryStore()
+		first := newTestService(t, store, Options{OverlapWindow: time.Hour})
+		second := newTestService(t, store, Options{OverlapWindow: time.Hour})
+		_, err := second.RotateKey(context.Background())
+		assert.NoError(t, err)
+		tokenString, err := second.GenerateToken(map[string]interface{}{"id": "x"}, time.Now().Add(time.Hour).Unix())
+		assert.NoError(t, err)
+
+		// reloads are rate limited
+		_, err = first.ValidateToken(tokenString)
+		assert.Error(t, err)
+
+		first.loadedAt = time.Now().Add(-time.Minute)
+		claims, err := first.ValidateToken(tokenString)
+		assert.NoError(t, err)
+		assert.Equal(t, "x", claims["id"])
+	})
+}
+
+func TestService_RotateKey(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("old_key_verifies_during_overlap", func(t *testing.T) {
+		svc := newTestService(t, newMemoryStore(), Options{OverlapWindow: time.Hour})
+		oldKid := svc.Jwks().Keys[0].Kid
+		tokenString, err := svc.GenerateToken(map[string]interface{}{"id": "x"}, time.Now().Add(time.Hour).Unix())
+		assert.NoError(t, err)
+
+		key, err := svc.RotateKey(ctx)
+		assert.NoError(t, err)
+		assert.NotEqual(t, oldKid, key.Id)
+		assert.Equal(t, sdk.SigningKeyStatusActive, key.Status)
+		assert.Empty(t, key.PrivateKey)
+		assert.Equal(t, key.Id, key.PublicKey.Kid)
+
+		jwks := svc.Jwks()
+		assert.Len(t, jwks.Keys, 2)
+		assert.Equal(t, key.Id, jwks.Keys[0].Kid)
+		assert.Equal(t, oldKid, jwks.Keys[1].Kid)
+
+		_, err = svc.ValidateToken(tokenString)
+		assert.NoError(t, err)
+
+		keys, err := svc.GetKeys(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 2)
+		assert.Equal(t, sdk.SigningKeyStatusRotated, keys[1].Status)
+		assert.NotNil(t, keys[1].RotatedAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *keys[1].ExpiresAt, time.Minute)
+	})
+
+	t.Run("old_key_stops_verifying_after_overlap", func(t *testing.T) {
+		svc := newTestService(t, newMemoryStore(), Options{})
+		tokenString, err := svc.GenerateToken(map[string]interface{}{"id": "x"}, time.Now().Add(time.Hour).Unix())
+		assert.NoError(t, err)
+
+		_, err = svc.RotateKey(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, svc.Jwks().Keys, 1)
+
+		_, err = svc.ValidateToken(tokenString)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no longer trusted")
+	})
+
+	t.Run("concurrent_rotations_keep_the_newest_key_active", func(t *testing.T) {
+		store := newMemoryStore()
+		instances := []*service{
+			newTestService(t, store, Options{OverlapWindow: time.Hour}),
+			newTestService(t, store, Options{OverlapWindow: time.Hour}),
+			newTestService(t, store, Options{OverlapWindow: time.Hour}),
+		}
+		var wg sync.WaitGroup
+		for _, svc := range instances {
+			wg.Add(1)
+			go func(svc *service) {
+				defer wg.Done()
+				for i := 0; i < 5; i++ {
+					_, err := svc.RotateKey(ctx)
+					assert.NoError(t, err)
+				}
+			}(svc)
+		}
+		wg.Wait()
+
+		keys, err := store.GetAll(ctx)
+		assert.NoError(t, err)
+		active := []sdk.SigningKey{}
+		newest := keys[0]
+		for _, k := range keys {
+			if k.Status == sdk.SigningKeyStatusActive {
+				active = append(active, k)
+			}
+			if createdAt(k).After(createdAt(newest)) {
+				newest = k
+			}
+		}
+		assert.NotEmpty(t, active)
+		assert.Equal(t, sdk.SigningKeyStatusActive, newest.Status)
+	})
+}
+
+func TestService_RetireKey(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, newMemoryStore(), Options{OverlapWindow: time.Hour})
+	oldKid := svc.Jwks().Keys[0].Kid
+	tokenString, err := svc.GenerateToken(map[string]interface{}{"id": "x"}, time.Now().Add(time.Hour).Unix())
+	assert.NoError(t, err)
+	active, err := svc.RotateKey(ctx)
+	assert.NoError(t, err)
+
+	t.Run("active_key_cannot_be_retired", func(t *testing.T) {
+		_, err := svc.RetireKey(ctx, active.Id)
+		assert.ErrorIs(t, err, sdk.ErrActiveSigningKey)
+	})
+
+	t.Run("unknown_key", func(t *testing.T) {
+		_, err := svc.RetireKey(ctx, "unknown")
+		assert.ErrorIs(t, err, sdk.ErrSigningKeyNotFound)
+	})
+
+	t.Run("retired_key_no_longer_verifies", func(t *testing.T) {
+		key, err := svc.RetireKey(ctx, oldKid)
+		assert.NoError(t, err)
+		assert.Equal(t, sdk.SigningKeyStatusRetired, key.Status)
+		assert.NotNil(t, key.RetiredAt)
+		assert.Empty(t, key.PrivateKey)
+
+		assert.Len(t, svc.Jwks().Keys, 1)
+		_, err = svc.ValidateToken(tokenString)
+		assert.Error(t, err)
+	})
+
+	t.Run("retiring_twice_is_a_no_op", func(t *testing.T) {
+		key, err := svc.RetireKey(ctx, oldKid)
+		assert.NoError(t, err)
+		assert.Equal(t, sdk.SigningKeyStatusRetired, key.Status)
+	})
+}
+
+func TestService_syncKeys(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rotates_when_due", func(t *testing.T) {
+		svc := newTestService(t, newMemoryStore(), Options{RotationInterval: time.Nanosecond, OverlapWindow: time.Hour})
+		oldKid := svc.Jwks().Keys[0].Kid
+
+		assert.NoError(t, svc.syncKeys(ctx))
+		jwks := svc.Jwks()
+		assert.Len(t, jwks.Keys, 2)
+		assert.NotEqual(t, oldKid, jwks.Keys[0].Kid)
+	})
+
+	t.Run("recovers_a_keyset_without_active_key", func(t *testing.T) {
+		store := newMemoryStore()
+		svc := newTestService(t, store, Options{})
+		oldKid := svc.Jwks().Keys[0].Kid
+		now := time.Now()
+		assert.NoError(t, store.Demote(ctx, now.Add(time.Second), now, now.Add(time.Hour)))
+		assert.NoError(t, svc.load(ctx))
+		_, err := svc.activeKey()
+		assert.Error(t, err)
+
+		assert.NoError(t, svc.syncKeys(ctx))
+		key, err := svc.activeKey()
+		assert.NoError(t, err)
+		assert.NotEqual(t, oldKid, key.Id)
+	})
+
+	t.Run("keeps_key_when_not_due", func(t *testing.T) {
+		svc := newTestService(t, newMemoryStore(), Options{RotationInterval: time.Hour})
+		assert.NoError(t, svc.syncKeys(ctx))
+		assert.Len(t, svc.Jwks().Keys, 1)
+	})
+
+	t.Run("retires_keys_after_overlap", func(t *testing.T) {
+		store := newMemoryStore()
+		svc := newTestService(t, store, Options{})
+		_, err := svc.RotateKey(ctx)
+		assert.NoError(t, err)
+
+		assert.NoError(t, svc.syncKeys(ctx))
+		keys, err := svc.GetKeys(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 2)
+		assert.Equal(t, sdk.SigningKeyStatusActive, keys[0].Status)
+		assert.Equal(t, sdk.SigningKeyStatusRetired, keys[1].Status)
+	})
+}
+
+func TestService_RunKeyRotation(t *testing.T) {
+	svc := newTestService(t, newMemoryStore(), Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.RunKeyRotation(ctx)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("key rotation did not stop after the context was cancelled")
+	}
+}
@@ -0,0 +1,18 @@
+package jwt
+
+import (
+	"context"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+type Store interface {
+	GetAll(ctx context.Context) ([]sdk.SigningKey, error)
+	Get(ctx context.Context, id string) (*sdk.SigningKey, error)
+	Create(ctx context.Context, key *sdk.SigningKey) error
+	Update(ctx context.Context, key *sdk.SigningKey) error
+	// Demote moves the active keys created before the given time into the overlap window.
+	// Keys created later are left active, so concurrent rotations always keep the newest key.
+	Demote(ctx context.Context, createdBefore, rotatedAt, expiresAt time.Time) error
+}
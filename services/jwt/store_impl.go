@@ -0,0 +1,209 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/db"
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/encrypt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type store struct {
+	enc encrypt.Service
+	db  db.DB
+}
+
+// NewStore creates a signing key store backed by mongo.
+// Private keys are encrypted before they are written to the database.
+func NewStore(enc encrypt.Service, db db.DB) Store {
+	return store{enc: enc, db: db}
+}
+
+func (s store) GetAll(ctx context.Context) ([]sdk.SigningKey, error) {
+	md := models.GetSigningKeyModel()
+	keys := []models.SigningKey{}
+	cursor, err := s.db.Find(ctx, md, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("error finding all signing keys: %w", err)
+	}
+	defer func() {
+		err := cursor.Close(ctx)
+		if err != nil {
+			log.Errorw(
+				"error closing cursor after reading all signing keys",
+				"error", err)
+		}
+	}()
+	err = cursor.All(ctx, &keys)
+	if err != nil {
+		return nil, fmt.Errorf("error reading all signing keys: %w", err)
+	}
+
+	result := make([]sdk.SigningKey, 0, len(keys))
+	for i := range keys {
+		key, err := s.decrypt(&keys[i])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *key)
+	}
+	return result, nil
+}
+
+func (s store) Get(ctx context.Context, id string) (*sdk.SigningKey, error) {
+	md := models.GetSigningKeyModel()
+	var key models.SigningKey
+	err := s.db.FindOne(ctx, md, bson.D{{Key: md.IdKey, Value: id}}).Decode(&key)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, sdk.ErrSigningKeyNotFound
+		}
+		return nil, fmt.Errorf("error finding signing key: %w", err)
+	}
+	return s.decrypt(&key)
+}
+
+func (s store) Create(ctx context.Context, key *sdk.SigningKey) error {
+	if key.CreatedAt == nil {
+		t := time.Now()
+		key.CreatedAt = &t
+	}
+	d, err := s.encrypt(*key)
+	if err != nil {
+		return err
+	}
+	md := models.GetSigningKeyModel()
+	_, err = s.db.InsertOne(ctx, md, d)
+	if err != nil {
+		return fmt.Errorf("error creating signing key: %w", err)
+	}
+	return nil
+}
+
+func (s store) Update(ctx context.Context, key *sdk.SigningKey) error {
+	if key.Id == "" {
+		return sdk.ErrSigningKeyNotFound
+	}
+	d, err := s.encrypt(*key)
+	if err != nil {
+		return err
+	}
+	md := models.GetSigningKeyModel()
+	_, err = s.db.UpdateOne(ctx, md, bson.D{{Key: md.IdKey, Value: key.Id}}, bson.D{{Key: "$set", Value: d}})
+	if err != nil {
+		return fmt.Errorf("error updating signing key: %w", err)
+	}
+	return nil
+}
+
+func (s store) Demote(ctx context.Context, createdBefore, rotatedAt, expiresAt time.Time) error {
+	md := models.GetSigningKeyModel()
+	filter := bson.D{
+		{Key: md.StatusKey, Value: sdk.SigningKeyStatusActive},
+		{Key: md.CreatedAtKey, Value: bson.D{{Key: "$lt", Value: createdBefore}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: md.StatusKey, Value: sdk.SigningKeyStatusRotated},
+		{Key: md.RotatedAtKey, Value: rotatedAt},
+		{Key: md.ExpiresAtKey, Value: expiresAt},
+	}}}
+	_, err := s.db.UpdateMany(ctx, md, filter, update)
+	if err != nil {
+		return fmt.Errorf("error demoting signing keys: %w", err)
+	}
+	return nil
+}
+
+func (s store) encrypt(key sdk.SigningKey) (*models.SigningKey, error) {
+	d := fromSdkToModel(key)
+	privateKey, err := s.enc.Encrypt(d.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting signing key: %w", err)
+	}
+	d.PrivateKey = privateKey
+	return &d, nil
+}
+
+func (s store) decrypt(key *models.SigningKey) (*sdk.SigningKey, error) {
+	privateKey, err := s.enc.Decrypt(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting signing key %s: %w", key.Id, err)
+	}
+	key.PrivateKey = privateKey
+	return fromModelToSdk(key), nil
+}
+
+// memoryStore keeps the keyset in process memory. It backs the services
+// created with NewService where no database is available.
+type memoryStore struct {
+	mu   sync.Mutex
+	keys []sdk.SigningKey
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{}
+}
+
+func (m *memoryStore) GetAll(_ context.Context) ([]sdk.SigningKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]sdk.SigningKey, len(m.keys))
+	copy(keys, m.keys)
+	return keys, nil
+}
+
+func (m *memoryStore) Get(_ context.Context, id string) (*sdk.SigningKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range m.keys {
+		if k.Id == id {
+			return &k, nil
+		}
+	}
+	return nil, sdk.ErrSigningKeyNotFound
+}
+
+func (m *memoryStore) Create(_ context.Context, key *sdk.SigningKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if key.CreatedAt == nil {
+		t := time.Now()
+		key.CreatedAt = &t
+	}
+	m.keys = append(m.keys, *key)
+	return nil
+}
+
+func (m *memoryStore) Update(_ context.Context, key *sdk.SigningKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, k := range m.keys {
+		if k.Id == key.Id {
+			m.keys[i] = *key
+			return nil
+		}
+	}
+	return sdk.ErrSigningKeyNotFound
+}
+
+func (m *memoryStore) Demote(_ context.Context, createdBefore, rotatedAt, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, k := range m.keys {
+		if k.Status != sdk.SigningKeyStatusActive || !createdAt(k).Before(createdBefore) {
+			continue
+		}
+		m.keys[i].Status = sdk.SigningKeyStatusRotated
+		m.keys[i].RotatedAt = &rotatedAt
+		m.keys[i].ExpiresAt = &expiresAt
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MockEncryptService implements encrypt.Service interface for testing
+type MockEncryptService struct {
+	mock.Mock
+}
+
+func (m *MockEncryptService) Encrypt(rawMessage string) (string, error) {
+	args := m.Called(rawMessage)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockEncryptService) Decrypt(encryptedMessage string) (string, error) {
+	args := m.Called(encryptedMessage)
+	return args.String(0), args.Error(1)
+}
+
+func signingKeyDocument(id, status, privateKey string) bson.D {
+	md := models.GetSigningKeyModel()
+	return bson.D{
+		{Key: md.IdKey, Value: id},
+		{Key: "algorithm", Value: "RS256"},
+		{Key: md.StatusKey, Value: status},
+		{Key: "private_key", Value: privateKey},
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	store := NewStore(&MockEncryptService{}, test.SetupMockDB())
+
+	assert.NotNil(t, store)
+	assert.Implements(t, (*Store)(nil), store)
+}
+
+func TestStore_GetAll(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetSigningKeyModel()
+
+	t.Run("success", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		cursor, err := mongo.NewCursorFromDocuments([]interface{}{
+			signingKeyDocument("kid-1", "active", "encrypted-1"),
+			signingKeyDocument("kid-2", "rotated", "encrypted-2"),
+		}, nil, nil)
+		assert.NoError(t, err)
+		mockDB.On("Find", ctx, md, bson.D{}, mock.Anything).Return(cursor, nil)
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Decrypt", "encrypted-1").Return("pem-1", nil)
+		mockEnc.On("Decrypt", "encrypted-2").Return("pem-2", nil)
+
+		keys, err := NewStore(mockEnc, mockDB).GetAll(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 2)
+		assert.Equal(t, "kid-1", keys[0].Id)
+		assert.Equal(t, sdk.SigningKeyStatusActive, keys[0].Status)
+		assert.Equal(t, sdk.MaskedBytes("pem-1"), keys[0].PrivateKey)
+		assert.Equal(t, sdk.SigningKeyStatusRotated, keys[1].Status)
+		mockDB.AssertExpectations(t)
+		mockEnc.AssertExpectations(t)
+	})
+
+	t.Run("find error", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("Find", ctx, md, bson.D{}, mock.Anything).Return(nil, errors.New("database error"))
+
+		keys, err := NewStore(&MockEncryptService{}, mockDB).GetAll(ctx)
+		assert.Error(t, err)
+		assert.Nil(t, keys)
+		assert.Contains(t, err.Error(), "error finding all signing keys")
+	})
+
+	t.Run("decrypt error", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		cursor, err := mongo.NewCursorFromDocuments([]interface{}{
+			signingKeyDocument("kid-1", "active", "encrypted-1"),
+		}, nil, nil)
+		assert.NoError(t, err)
+		mockDB.On("Find", ctx, md, bson.D{}, mock.Anything).Return(cursor, nil)
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Decrypt", "encrypted-1").Return("", errors.New("decryption failed"))
+
+		keys, err := NewStore(mockEnc, mockDB).GetAll(ctx)
+		assert.Error(t, err)
+		assert.Nil(t, keys)
+		assert.Contains(t, err.Error(), "error decrypting signing key kid-1")
+	})
+}
+
+func TestStore_Get(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetSigningKeyModel()
+
+	t.Run("success", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		result := mongo.NewSingleResultFromDocument(signingKeyDocument("kid-1", "active", "encrypted-1"), nil, nil)
+		mockDB.On("FindOne", ctx, md, bson.D{{Key: md.IdKey, Value: "kid-1"}}, mock.Anything).Return(result)
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Decrypt", "encrypted-1").Return("pem-1", nil)
+
+		key, err := NewStore(mockEnc, mockDB).Get(ctx, "kid-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "kid-1", key.Id)
+		assert.Equal(t, "RS256", key.Algorithm)
+		assert.Equal(t, sdk.MaskedBytes("pem-1"), key.PrivateKey)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		result := mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+		mockDB.On("FindOne", ctx, md, bson.D{{Key: md.IdKey, Value: "unknown"}}, mock.Anything).Return(result)
+
+		key, err := NewStore(&MockEncryptService{}, mockDB).Get(ctx, "unknown")
+		assert.ErrorIs(t, err, sdk.ErrSigningKeyNotFound)
+		assert.Nil(t, key)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		result := mongo.NewSingleResultFromDocument(bson.D{}, errors.New("custom error"), nil)
+		mockDB.On("FindOne", ctx, md, bson.D{{Key: md.IdKey, Value: "kid-1"}}, mock.Anything).Return(result)
+
+		key, err := NewStore(&MockEncryptService{}, mockDB).Get(ctx, "kid-1")
+		assert.Error(t, err)
+		assert.Nil(t, key)
+		assert.Contains(t, err.Error(), "error finding signing key")
+	})
+}
+
+func TestStore_Create(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("encrypts the private key", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("InsertOne", ctx, models.GetSigningKeyModel(), mock.MatchedBy(func(d *models.SigningKey) bool {
+			return d.Id == "kid-1" && d.PrivateKey == "encrypted-1" && d.CreatedAt != nil
+		}), mock.Anything).Return(&mongo.InsertOneResult{InsertedID: "kid-1"}, nil)
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Encrypt", "pem-1").Return("encrypted-1", nil)
+
+		key := &sdk.SigningKey{Id: "kid-1", Algorithm: "RS256", Status: sdk.SigningKeyStatusActive, PrivateKey: sdk.MaskedBytes("pem-1")}
+		err := NewStore(mockEnc, mockDB).Create(ctx, key)
+		assert.NoError(t, err)
+		assert.NotNil(t, key.CreatedAt)
+		mockDB.AssertExpectations(t)
+		mockEnc.AssertExpectations(t)
+	})
+
+	t.Run("encrypt error", func(t *testing.T) {
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Encrypt", "pem-1").Return("", errors.New("encryption failed"))
+
+		err := NewStore(mockEnc, test.SetupMockDB()).Create(ctx, &sdk.SigningKey{Id: "kid-1", PrivateKey: sdk.MaskedBytes("pem-1")})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error encrypting signing key")
+	})
+
+	t.Run("insert error", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("InsertOne", ctx, mock.Anything, mock.Anything, mock.Anything).Return(&mongo.InsertOneResult{}, errors.New("insert error"))
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Encrypt", "pem-1").Return("encrypted-1", nil)
+
+		err := NewStore(mockEnc, mockDB).Create(ctx, &sdk.SigningKey{Id: "kid-1", PrivateKey: sdk.MaskedBytes("pem-1")})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error creating signing key")
+	})
+}
+
+func TestStore_Demote(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetSigningKeyModel()
+	createdBefore := time.Now()
+	rotatedAt := createdBefore.Add(time.Second)
+	expiresAt := rotatedAt.Add(time.Hour)
+
+	t.Run("demotes only the older active keys", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		filter := bson.D{
+			{Key: md.StatusKey, Value: sdk.SigningKeyStatusActive},
+			{Key: md.CreatedAtKey, Value: bson.D{{Key: "$lt", Value: createdBefore}}},
+		}
+		mockDB.On("UpdateMany", ctx, md, filter, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{ModifiedCount: 1}, nil)
+
+		err := NewStore(&MockEncryptService{}, mockDB).Demote(ctx, createdBefore, rotatedAt, expiresAt)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("update error", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("UpdateMany", ctx, md, mock.Anything, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{}, errors.New("update error"))
+
+		err := NewStore(&MockEncryptService{}, mockDB).Demote(ctx, createdBefore, rotatedAt, expiresAt)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error demoting signing keys")
+	})
+}
+
+func TestStore_Update(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetSigningKeyModel()
+	now := time.Now()
+
+	t.Run("success", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("UpdateOne", ctx, md, bson.D{{Key: md.IdKey, Value: "kid-1"}}, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Encrypt", "pem-1").Return("encrypted-1", nil)
+
+		err := NewStore(mockEnc, mockDB).Update(ctx, &sdk.SigningKey{Id: "kid-1", Status: sdk.SigningKeyStatusRetired, RetiredAt: &now, PrivateKey: sdk.MaskedBytes("pem-1")})
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("missing id", func(t *testing.T) {
+		err := NewStore(&MockEncryptService{}, test.SetupMockDB()).Update(ctx, &sdk.SigningKey{})
+		assert.ErrorIs(t, err, sdk.ErrSigningKeyNotFound)
+	})
+
+	t.Run("update error", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("UpdateOne", ctx, md, mock.Anything, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{}, errors.New("update error"))
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Encrypt", "pem-1").Return("encrypted-1", nil)
+
+		err := NewStore(mockEnc, mockDB).Update(ctx, &sdk.SigningKey{Id: "kid-1", PrivateKey: sdk.MaskedBytes("pem-1")})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error updating signing key")
+	})
+}
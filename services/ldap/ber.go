@@ -0,0 +1,189 @@
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// identifier octets of the BER elements used by LDAP. Only the low tag numbers are needed,
+// so the class, the constructed bit and the tag number always fit in one octet.
+const (
+	classApplication = 0x40
+	classContext     = 0x80
+	constructed      = 0x20
+
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x10 | constructed
+	tagSet         = 0x11 | constructed
+)
+
+const (
+	// maxPacketSize caps the size of the messages read from the directory
+	maxPacketSize = 1 << 20
+	// maxPacketDepth caps the nesting of the elements of a message
+	maxPacketDepth = 16
+)
+
+// packet is a decoded BER element. The children of the constructed elements are decoded too.
+type packet struct {
+	tag      byte
+	value    []byte
+	children []*packet
+}
+
+func (p *packet) isConstructed() bool {
+	return p.tag&constructed != 0
+}
+
+// child returns the i-th child of the element, failing when there are not that many
+func (p *packet) child(i int) (*packet, error) {
+	if i >= len(p.children) {
+		return nil, fmt.Errorf("malformed message: element 0x%x has %d children", p.tag, len(p.children))
+	}
+	return p.children[i], nil
+}
+
+// int decodes an integer or an enumerated value
+func (p *packet) int() (int64, error) {
+	if len(p.value) == 0 || len(p.value) > 8 {
+		return 0, fmt.Errorf("malformed message: integer of %d bytes", len(p.value))
+	}
+	v := int64(int8(p.value[0]))
+	for _, b := range p.value[1:] {
+		v = v<<8 | int64(b)
+	}
+	return v, nil
+}
+
+func (p *packet) string() string {
+	return string(p.value)
+}
+
+// encode returns the element with the identifier and the content
+func encode(tag byte, content ...[]byte) []byte {
+	value := bytes.Join(content, nil)
+	b := append([]byte{tag}, encodeLength(len(value))...)
+	return append(b, value...)
+}
+
+// encodeLength uses the short form below 128 bytes and the long form above
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// encodeInt encodes the value in the fewest two's complement bytes
+func encodeInt(tag byte, v int64) []byte {
+	b := []byte{byte(v)}
+	for v > 0x7f || v < -0x80 {
+		v >>= 8
+		b = append([]byte{byte(v)}, b...)
+	}
+	return encode(tag, b)
+}
+
+func encodeBool(v bool) []byte {
+	if v {
+		return encode(tagBoolean, []byte{0xff})
+	}
+	return encode(tagBoolean, []byte{0x00})
+}
+
+func encodeString(tag byte, s string) []byte {
+	return encode(tag, []byte(s))
+}
+
+// readPacket reads the next element from the connection
+func readPacket(r *bufio.Reader) (*packet, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	length := int(first)
+	if first&0x80 != 0 {
+		n := int(first & 0x7f)
+		if n == 0 || n > 4 {
+			return nil, fmt.Errorf("malformed message: unsupported length of %d bytes", n)
+		}
+		length = 0
+		for i := 0; i < n; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxPacketSize {
+		return nil, fmt.Errorf("message of %d bytes is too large", length)
+	}
+	value := make([]byte, length)
+	_, err = io.ReadFull(r, value)
+	if err != nil {
+		return nil, err
+	}
+	p := &packet{tag: tag, value: value}
+	if p.isConstructed() {
+		p.children, err = decodeChildren(value, 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// decodeChildren decodes the elements making up the content of a constructed element
+func decodeChildren(b []byte, depth int) ([]*packet, error) {
+	if depth > maxPacketDepth {
+		return nil, fmt.Errorf("malformed message: elements nested too deep")
+	}
+	var children []*packet
+	for len(b) > 0 {
+		if len(b) < 2 {
+			return nil, fmt.Errorf("malformed message: truncated element")
+		}
+		tag := b[0]
+		length := int(b[1])
+		offset := 2
+		if b[1]&0x80 != 0 {
+			n := int(b[1] & 0x7f)
+			if n == 0 || n > 4 || len(b) < 2+n {
+				return nil, fmt.Errorf("malformed message: invalid length")
+			}
+			length = 0
+			for _, l := range b[2 : 2+n] {
+				length = length<<8 | int(l)
+			}
+			offset += n
+		}
+		if length > len(b)-offset {
+			return nil, fmt.Errorf("malformed message: truncated element")
+		}
+		p := &packet{tag: tag, value: b[offset : offset+length]}
+		if p.isConstructed() {
+			var err error
+			p.children, err = decodeChildren(p.value, depth+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		children = append(children, p)
+		b = b[offset+length:]
+	}
+	return children, nil
+}
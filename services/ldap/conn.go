@@ -0,0 +1,328 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// protocol operations of LDAPv3 (RFC 4511) used for the logins
+const (
+	opBindRequest       = classApplication | constructed | 0
+	opBindResponse      = classApplication | constructed | 1
+	opUnbindRequest     = classApplication | 2
+	opSearchRequest     = classApplication | constructed | 3
+	opSearchEntry       = classApplication | constructed | 4
+	opSearchDone        = classApplication | constructed | 5
+	opSearchReference   = classApplication | constructed | 19
+	opExtendedRequest   = classApplication | constructed | 23
+	opExtendedResponse  = classApplication | constructed | 24
+	authSimple          = classContext | 0
+	extendedRequestName = classContext | 0
+)
+
+const (
+	resultSuccess            = 0
+	resultSizeLimitExceeded  = 4
+	resultInvalidCredentials = 49
+
+	scopeWholeSubtree  = 2
+	derefNever         = 0
+	startTlsOid        = "1.3.6.1.4.1.1466.20037"
+	protocolVersion    = 3
+	defaultLdapPort    = "389"
+	defaultLdapsPort   = "636"
+	searchTimeLimitSec = 10
+)
+
+// requestTimeout bounds a whole login against the directory
+const requestTimeout = time.Second * 15
+
+// resultError is an LDAPResult other than success
+type resultError struct {
+	code    int64
+	message string
+}
+
+func (e resultError) Error() string {
+	if len(e.message) == 0 {
+		return fmt.Sprintf("ldap result code %d", e.code)
+	}
+	return fmt.Sprintf("ldap result code %d: %s", e.code, e.message)
+}
+
+func isResult(err error, code int64) bool {
+	var re resultError
+	return errors.As(err, &re) && re.code == code
+}
+
+// entry is a search result, the attribute names are lower cased as they are case insensitive
+type entry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+// first returns the first value of the attribute
+func (e entry) first(attr string) string {
+	if v := e.attributes[strings.ToLower(attr)]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// conn is a connection to the directory. Requests are sent one at a time.
+type conn struct {
+	c      net.Conn
+	r      *bufio.Reader
+	lastId int64
+}
+
+// dial connects to the directory of the url, over tls for ldaps urls or after StartTLS when asked to
+func dial(ctx context.Context, rawUrl string, startTls bool, tlsConfig *tls.Config) (*conn, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ldap url %w", err)
+	}
+	port := defaultLdapPort
+	switch u.Scheme {
+	case "ldap":
+	case "ldaps":
+		port = defaultLdapsPort
+	default:
+		return nil, fmt.Errorf("unsupported ldap url scheme %s", u.Scheme)
+	}
+	if len(u.Port()) > 0 {
+		port = u.Port()
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+
+	deadline := time.Now().Add(requestTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	nc, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s %w", addr, err)
+	}
+	err = nc.SetDeadline(deadline)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if tlsConfig != nil {
+		cfg = tlsConfig.Clone()
+	}
+	cfg.ServerName = u.Hostname()
+	if u.Scheme == "ldaps" {
+		tc := tls.Client(nc, cfg)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("error in the tls handshake with %s %w", addr, err)
+		}
+		nc = tc
+	}
+	c := &conn{c: nc, r: bufio.NewReader(nc)}
+	if startTls && u.Scheme == "ldap" {
+		if err := c.startTls(ctx, cfg); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// startTls upgrades the connection with the StartTLS extended operation
+func (c *conn) startTls(ctx context.Context, cfg *tls.Config) error {
+	resp, err := c.request(encode(opExtendedRequest, encodeString(extendedRequestName, startTlsOid)), opExtendedResponse)
+	if err != nil {
+		return fmt.Errorf("error starting tls %w", err)
+	}
+	if err := ldapResult(resp); err != nil {
+		return fmt.Errorf("error starting tls %w", err)
+	}
+	tc := tls.Client(c.c, cfg)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		return fmt.Errorf("error in the tls handshake %w", err)
+	}
+	c.c = tc
+	c.r = bufio.NewReader(tc)
+	return nil
+}
+
+// bind authenticates the connection with the password of the dn
+func (c *conn) bind(dn, password string) error {
+	req := encode(opBindRequest,
+		encodeInt(tagInteger, protocolVersion),
+		encodeString(tagOctetString, dn),
+		encodeString(authSimple, password),
+	)
+	resp, err := c.request(req, opBindResponse)
+	if err != nil {
+		return err
+	}
+	return ldapResult(resp)
+}
+
+// search returns the entries matching the filter in the subtree of the base, with the attributes asked for.
+// At most sizeLimit entries are returned, more matches fail with a size limit exceeded result.
+func (c *conn) search(base, filter string, attrs []string, sizeLimit int64) ([]entry, error) {
+	f, err := compileFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	attributes := make([][]byte, 0, len(attrs))
+	for _, a := range attrs {
+		attributes = append(attributes, encodeString(tagOctetString, a))
+	}
+	req := encode(opSearchRequest,
+		encodeString(tagOctetString, base),
+		encodeInt(tagEnumerated, scopeWholeSubtree),
+		encodeInt(tagEnumerated, derefNever),
+		encodeInt(tagInteger, sizeLimit),
+		encodeInt(tagInteger, searchTimeLimitSec),
+		encodeBool(false),
+		f,
+		encode(tagSequence, attributes...),
+	)
+	id, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	for {
+		op, err := c.receive(id)
+		if err != nil {
+			return nil, err
+		}
+		switch op.tag {
+		case opSearchEntry:
+			e, err := readEntry(op)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, *e)
+		case opSearchReference:
+			// referrals to other directories are not followed
+		case opSearchDone:
+			if err := ldapResult(op); err != nil {
+				return nil, err
+			}
+			return entries, nil
+		default:
+			return nil, fmt.Errorf("unexpected response 0x%x to the search", op.tag)
+		}
+	}
+}
+
+// close unbinds and closes the connection
+func (c *conn) close() {
+	_, _ = c.send(encode(opUnbindRequest))
+	_ = c.c.Close()
+}
+
+// request sends an operation and reads its single response
+func (c *conn) request(op []byte, responseTag byte) (*packet, error) {
+	id, err := c.send(op)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.receive(id)
+	if err != nil {
+		return nil, err
+	}
+	if resp.tag != responseTag {
+		return nil, fmt.Errorf("unexpected response 0x%x", resp.tag)
+	}
+	return resp, nil
+}
+
+// send wraps the operation in a message with the next message id
+func (c *conn) send(op []byte) (int64, error) {
+	c.lastId++
+	_, err := c.c.Write(encode(tagSequence, encodeInt(tagInteger, c.lastId), op))
+	if err != nil {
+		return 0, fmt.Errorf("error writing to the directory %w", err)
+	}
+	return c.lastId, nil
+}
+
+// receive reads the next message and returns its protocol operation.
+// Unsolicited notifications, like the notice of disconnection, end the exchange.
+func (c *conn) receive(id int64) (*packet, error) {
+	msg, err := readPacket(c.r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading from the directory %w", err)
+	}
+	if msg.tag != tagSequence || len(msg.children) < 2 {
+		return nil, fmt.Errorf("malformed message")
+	}
+	msgId, err := msg.children[0].int()
+	if err != nil {
+		return nil, err
+	}
+	op := msg.children[1]
+	if msgId == 0 && op.tag == opExtendedResponse {
+		return nil, fmt.Errorf("the directory ended the connection: %v", ldapResult(op))
+	}
+	if msgId != id {
+		return nil, fmt.Errorf("unexpected message id %d", msgId)
+	}
+	return op, nil
+}
+
+// ldapResult returns the error of an LDAPResult, nil on success
+func ldapResult(op *packet) error {
+	codeEl, err := op.child(0)
+	if err != nil {
+		return err
+	}
+	code, err := codeEl.int()
+	if err != nil {
+		return err
+	}
+	if code == resultSuccess {
+		return nil
+	}
+	message := ""
+	if len(op.children) > 2 {
+		message = op.children[2].string()
+	}
+	return resultError{code: code, message: message}
+}
+
+func readEntry(op *packet) (*entry, error) {
+	dn, err := op.child(0)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := op.child(1)
+	if err != nil {
+		return nil, err
+	}
+	e := &entry{dn: dn.string(), attributes: map[string][]string{}}
+	for _, attr := range attrs.children {
+		name, err := attr.child(0)
+		if err != nil {
+			return nil, err
+		}
+		vals, err := attr.child(1)
+		if err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(name.string())
+		for _, v := range vals.children {
+			e.attributes[key] = append(e.attributes[key], v.string())
+		}
+	}
+	return e, nil
+}
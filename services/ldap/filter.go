@@ -0,0 +1,212 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// context specific tags of the search filter choices
+const (
+	filterAnd            = classContext | constructed | 0
+	filterOr             = classContext | constructed | 1
+	filterNot            = classContext | constructed | 2
+	filterEqualityMatch  = classContext | constructed | 3
+	filterSubstrings     = classContext | constructed | 4
+	filterGreaterOrEqual = classContext | constructed | 5
+	filterLessOrEqual    = classContext | constructed | 6
+	filterPresent        = classContext | 7
+	filterApproxMatch    = classContext | constructed | 8
+
+	substringInitial = classContext | 0
+	substringAny     = classContext | 1
+	substringFinal   = classContext | 2
+)
+
+// usernamePlaceholder is replaced with the escaped username in the user filter
+const usernamePlaceholder = "{username}"
+
+// userFilter returns the filter finding the entry of the username
+func userFilter(template, username string) string {
+	return strings.ReplaceAll(template, usernamePlaceholder, escapeFilter(username))
+}
+
+// escapeFilter escapes the characters with a meaning in the filters, as in RFC 4515.
+// The username cannot add wildcards or conditions to the filter of the users.
+func escapeFilter(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '*', '(', ')', '\\', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// compileFilter encodes the string representation of a search filter of RFC 4515.
+// Extensible matches are not supported.
+func compileFilter(filter string) ([]byte, error) {
+	f := filterParser{s: filter}
+	b, err := f.filter(0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %s: %w", filter, err)
+	}
+	if f.pos != len(f.s) {
+		return nil, fmt.Errorf("invalid filter %s: unexpected characters after the filter", filter)
+	}
+	return b, nil
+}
+
+type filterParser struct {
+	s   string
+	pos int
+}
+
+func (f *filterParser) filter(depth int) ([]byte, error) {
+	if depth > maxPacketDepth {
+		return nil, fmt.Errorf("filter nested too deep")
+	}
+	if f.pos >= len(f.s) || f.s[f.pos] != '(' {
+		return nil, fmt.Errorf("expected ( at %d", f.pos)
+	}
+	f.pos++
+	if f.pos >= len(f.s) {
+		return nil, fmt.Errorf("unexpected end of the filter")
+	}
+	var b []byte
+	var err error
+	switch f.s[f.pos] {
+	case '&':
+		f.pos++
+		b, err = f.list(filterAnd, depth)
+	case '|':
+		f.pos++
+		b, err = f.list(filterOr, depth)
+	case '!':
+		f.pos++
+		var inner []byte
+		inner, err = f.filter(depth + 1)
+		b = encode(filterNot, inner)
+	default:
+		b, err = f.item()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.pos >= len(f.s) || f.s[f.pos] != ')' {
+		return nil, fmt.Errorf("expected ) at %d", f.pos)
+	}
+	f.pos++
+	return b, nil
+}
+
+func (f *filterParser) list(tag byte, depth int) ([]byte, error) {
+	var filters [][]byte
+	for f.pos < len(f.s) && f.s[f.pos] == '(' {
+		b, err := f.filter(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, b)
+	}
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("empty filter list at %d", f.pos)
+	}
+	return encode(tag, filters...), nil
+}
+
+// item encodes the comparison of an attribute with a value
+func (f *filterParser) item() ([]byte, error) {
+	end := strings.IndexByte(f.s[f.pos:], ')')
+	if end < 0 {
+		return nil, fmt.Errorf("expected ) after %d", f.pos)
+	}
+	item := f.s[f.pos : f.pos+end]
+	f.pos += end
+
+	eq := strings.IndexByte(item, '=')
+	if eq <= 0 {
+		return nil, fmt.Errorf("invalid comparison %s", item)
+	}
+	attr, value := item[:eq], item[eq+1:]
+	tag := byte(filterEqualityMatch)
+	switch attr[len(attr)-1] {
+	case '>':
+		tag, attr = filterGreaterOrEqual, attr[:len(attr)-1]
+	case '<':
+		tag, attr = filterLessOrEqual, attr[:len(attr)-1]
+	case '~':
+		tag, attr = filterApproxMatch, attr[:len(attr)-1]
+	case ':':
+		return nil, fmt.Errorf("extensible matches are not supported")
+	}
+	if len(attr) == 0 || strings.ContainsAny(attr, "()*\\ ") {
+		return nil, fmt.Errorf("invalid attribute %q", attr)
+	}
+
+	if tag == filterEqualityMatch && value == "*" {
+		return encodeString(filterPresent, attr), nil
+	}
+	if tag == filterEqualityMatch && strings.Contains(value, "*") {
+		return substrings(attr, value)
+	}
+	v, err := unescapeFilter(value)
+	if err != nil {
+		return nil, err
+	}
+	return encode(tag, encodeString(tagOctetString, attr), encodeString(tagOctetString, v)), nil
+}
+
+// substrings encodes a value with wildcards into its initial, any and final parts
+func substrings(attr, value string) ([]byte, error) {
+	parts := strings.Split(value, "*")
+	var encoded [][]byte
+	for i, part := range parts {
+		if len(part) == 0 {
+			continue
+		}
+		v, err := unescapeFilter(part)
+		if err != nil {
+			return nil, err
+		}
+		tag := byte(substringAny)
+		switch i {
+		case 0:
+			tag = substringInitial
+		case len(parts) - 1:
+			tag = substringFinal
+		}
+		encoded = append(encoded, encodeString(tag, v))
+	}
+	if len(encoded) == 0 {
+		return nil, fmt.Errorf("invalid substring value %s", value)
+	}
+	return encode(filterSubstrings, encodeString(tagOctetString, attr), encode(tagSequence, encoded...)), nil
+}
+
+// unescapeFilter decodes the \XX escapes of a value
+func unescapeFilter(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+3 > len(s) {
+			return "", fmt.Errorf("invalid escape in %s", s)
+		}
+		c, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %s", s)
+		}
+		b.Write(c)
+		i += 2
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,82 @@
+package ldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeFilter(t *testing.T) {
+	assert.Equal(t, "jdoe", escapeFilter("jdoe"))
+	assert.Equal(t, `\2a`, escapeFilter("*"))
+	assert.Equal(t, `jdoe\29\28uid=\2a`, escapeFilter("jdoe)(uid=*"))
+	assert.Equal(t, `a\5cb\00`, escapeFilter("a\\b\x00"))
+	assert.Equal(t, `(uid=\2a\29\28cn=x)`, userFilter("(uid={username})", "*)(cn=x"))
+}
+
+func TestCompileFilter(t *testing.T) {
+	eq := func(attr, value string) []byte {
+		return encode(filterEqualityMatch, encodeString(tagOctetString, attr), encodeString(tagOctetString, value))
+	}
+	tests := []struct {
+		name     string
+		filter   string
+		expected []byte
+	}{
+		{
+			name:     "equality",
+			filter:   "(uid=jdoe)",
+			expected: eq("uid", "jdoe"),
+		},
+		{
+			name:     "escaped value",
+			filter:   `(uid=\2a\29)`,
+			expected: eq("uid", "*)"),
+		},
+		{
+			name:     "presence",
+			filter:   "(mail=*)",
+			expected: encodeString(filterPresent, "mail"),
+		},
+		{
+			name:   "and, or and not",
+			filter: "(&(objectClass=person)(|(uid=jdoe)(mail=jdoe))(!(disabled=TRUE)))",
+			expected: encode(filterAnd,
+				eq("objectClass", "person"),
+				encode(filterOr, eq("uid", "jdoe"), eq("mail", "jdoe")),
+				encode(filterNot, eq("disabled", "TRUE")),
+			),
+		},
+		{
+			name:   "substrings",
+			filter: "(cn=jo*n*doe)",
+			expected: encode(filterSubstrings, encodeString(tagOctetString, "cn"), encode(tagSequence,
+				encodeString(substringInitial, "jo"),
+				encodeString(substringAny, "n"),
+				encodeString(substringFinal, "doe"),
+			)),
+		},
+		{
+			name:   "ordering and approximate",
+			filter: "(&(age>=18)(age<=65)(cn~=jon))",
+			expected: encode(filterAnd,
+				encode(filterGreaterOrEqual, encodeString(tagOctetString, "age"), encodeString(tagOctetString, "18")),
+				encode(filterLessOrEqual, encodeString(tagOctetString, "age"), encodeString(tagOctetString, "65")),
+				encode(filterApproxMatch, encodeString(tagOctetString, "cn"), encodeString(tagOctetString, "jon")),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := compileFilter(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, b)
+		})
+	}
+
+	for _, invalid := range []string{"", "uid=jdoe", "(uid=jdoe", "(uid=jdoe))", "(&)", "(=jdoe)", `(uid=\zz)`, `(uid=\2)`, "(uid:dn:=jdoe)"} {
+		_, err := compileFilter(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
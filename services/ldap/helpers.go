@@ -0,0 +1,159 @@
+package ldap
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// fields of the users the attributes of the entries map to
+const (
+	fieldEmail  = "email"
+	fieldName   = "name"
+	fieldPhone  = "phone"
+	fieldGroups = "groups"
+)
+
+// defaultAttributes are the attributes read when the mapping of a field is not configured.
+// The first one with a value is used. They are available in both OpenLDAP and Active Directory.
+var defaultAttributes = map[string][]string{
+	fieldEmail:  {"mail"},
+	fieldName:   {"displayName", "cn"},
+	fieldPhone:  {"mobile", "telephoneNumber"},
+	fieldGroups: {"memberOf"},
+}
+
+// defaultUserFilter finds the users by their uid, Active Directory uses (sAMAccountName={username})
+const defaultUserFilter = "(uid={username})"
+
+// config is the directory configuration of an auth provider
+type config struct {
+	url          string
+	startTls     bool
+	tlsConfig    *tls.Config
+	bindDn       string
+	bindPassword string
+	searchBase   string
+	userFilter   string
+	mapping      map[string][]string
+}
+
+func readConfig(p sdk.AuthProvider) (*config, error) {
+	cnf := &config{
+		url:          p.GetParam(sdk.LdapParamUrl),
+		startTls:     p.GetParam(sdk.LdapParamStartTls) == "true",
+		tlsConfig:    &tls.Config{MinVersion: tls.VersionTLS12},
+		bindDn:       p.GetParam(sdk.LdapParamBindDn),
+		bindPassword: p.GetParam(sdk.LdapParamBindPassword),
+		searchBase:   p.GetParam(sdk.LdapParamUserSearchBase),
+		userFilter:   p.GetParam(sdk.LdapParamUserFilter),
+	}
+	if len(cnf.url) == 0 {
+		return nil, fmt.Errorf("the url of %s is not configured", p.Name)
+	}
+	if len(cnf.searchBase) == 0 {
+		return nil, fmt.Errorf("the user search base of %s is not configured", p.Name)
+	}
+	if len(cnf.userFilter) == 0 {
+		cnf.userFilter = defaultUserFilter
+	}
+	if !strings.Contains(cnf.userFilter, usernamePlaceholder) {
+		return nil, fmt.Errorf("the user filter of %s does not contain %s", p.Name, usernamePlaceholder)
+	}
+	if pem := p.GetParam(sdk.LdapParamCaCert); len(pem) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(pem)) {
+			return nil, fmt.Errorf("the ca certificate of %s is invalid", p.Name)
+		}
+		cnf.tlsConfig.RootCAs = pool
+	}
+	mapping, err := attributeMapping(p.GetParam(sdk.LdapParamAttributeMapping))
+	if err != nil {
+		return nil, fmt.Errorf("error reading the attribute mapping of %s %w", p.Name, err)
+	}
+	cnf.mapping = mapping
+	return cnf, nil
+}
+
+// attributeMapping merges the configured attributes of the user fields with the default ones
+func attributeMapping(param string) (map[string][]string, error) {
+	mapping := make(map[string][]string, len(defaultAttributes))
+	for k, v := range defaultAttributes {
+		mapping[k] = v
+	}
+	if len(param) == 0 {
+		return mapping, nil
+	}
+	configured := map[string]string{}
+	err := json.Unmarshal([]byte(param), &configured)
+	if err != nil {
+		return nil, err
+	}
+	for field, attr := range configured {
+		if _, ok := defaultAttributes[field]; !ok {
+			return nil, fmt.Errorf("unknown user field %s", field)
+		}
+		mapping[field] = []string{attr}
+	}
+	return mapping, nil
+}
+
+// attributes lists the attributes the search has to return
+func (c config) attributes() []string {
+	var attrs []string
+	for _, names := range c.mapping {
+		attrs = append(attrs, names...)
+	}
+	return attrs
+}
+
+// identity maps the entry of the user to the fields of the go-iam user
+func (c config) identity(e entry) sdk.LdapIdentity {
+	value := func(field string) string {
+		for _, name := range c.mapping[field] {
+			if v := e.first(name); len(v) > 0 {
+				return v
+			}
+		}
+		return ""
+	}
+	id := sdk.LdapIdentity{
+		Dn:    e.dn,
+		Email: value(fieldEmail),
+		Name:  value(fieldName),
+		Phone: value(fieldPhone),
+	}
+	for _, name := range c.mapping[fieldGroups] {
+		id.Groups = append(id.Groups, e.attributes[strings.ToLower(name)]...)
+	}
+	return id
+}
+
+// randomToken returns a url safe token with 256 bits of entropy
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("error generating the token %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func loginCodeKey(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return fmt.Sprintf("ldap-code-%s", hex.EncodeToString(sum[:]))
+}
+
+// usernameAttemptKey is the cache key of the failed logins of a username, directories match usernames case insensitively
+func usernameAttemptKey(providerId, username string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(username))))
+	return fmt.Sprintf("ldap-attempts-username-%s-%s", providerId, hex.EncodeToString(sum[:]))
+}
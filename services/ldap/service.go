@@ -0,0 +1,17 @@
+package ldap
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// Service checks the credentials of the users of the LDAP auth providers against their directory.
+// Login searches the entry of the username with the service account of the provider and binds
+// as that entry with the password. It returns a single use code that the provider exchanges
+// for the identity read from the entry when the auth service verifies it. The failed logins of a
+// username and of the ip address of the client are throttled like the ones of the password provider.
+type Service interface {
+	Login(ctx context.Context, provider sdk.AuthProvider, username, password, ip string) (string, error)
+	ExchangeCode(ctx context.Context, code string) (*sdk.LdapIdentity, error)
+}
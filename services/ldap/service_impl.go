@@ -0,0 +1,145 @@
+package ldap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+)
+
+// loginCodeTTL is the time within which the auth service has to exchange a login code
+const loginCodeTTL = time.Minute * 5
+
+type service struct {
+	cacheSvc cache.Service
+	throttle cache.Throttle
+}
+
+// NewService creates the ldap service. The directories are configured on the auth providers.
+func NewService(cacheSvc cache.Service) Service {
+	return service{
+		cacheSvc: cacheSvc,
+		throttle: cache.NewThrottle(cacheSvc, cache.FailedAttemptWindow),
+	}
+}
+
+func (s service) Login(ctx context.Context, provider sdk.AuthProvider, username, password, ip string) (string, error) {
+	/*
+	 * an empty password would be an unauthenticated bind, which directories accept
+	 * a username or ip address with too many failed logins waits until the window ends, before anything is sent to the directory
+	 * connect to the directory, over tls for ldaps urls or after StartTLS
+	 * bind as the service account, or search anonymously without one
+	 * the filter has to match exactly one entry
+	 * bind as the entry with the password of the user, an unknown username or a wrong password counts as a failed login
+	 * and the right one clears the failed logins of the username
+	 * issue a single use code for the identity read from the entry
+	 */
+	if provider.Provider != sdk.AuthProviderTypeLDAP {
+		return "", sdk.ErrNotLdapProvider
+	}
+	if len(username) == 0 || len(password) == 0 {
+		return "", sdk.ErrInvalidLdapCredentials
+	}
+	usernameKey := usernameAttemptKey(provider.Id, username)
+	attemptKeys := map[string]int{usernameKey: cache.MaxFailedAttemptsPerAccount}
+	if len(ip) > 0 {
+		attemptKeys[fmt.Sprintf("ldap-attempts-ip-%s", ip)] = cache.MaxFailedAttemptsPerIp
+	}
+	failed, limited := s.throttle.Limited(ctx, attemptKeys)
+	if limited {
+		return "", sdk.ErrTooManyLoginAttempts
+	}
+
+	cnf, err := readConfig(provider)
+	if err != nil {
+		return "", err
+	}
+
+	c, err := dial(ctx, cnf.url, cnf.startTls, cnf.tlsConfig)
+	if err != nil {
+		return "", err
+	}
+	defer c.close()
+
+	if len(cnf.bindDn) > 0 {
+		err = c.bind(cnf.bindDn, cnf.bindPassword)
+		if err != nil {
+			return "", fmt.Errorf("error binding as the service account %w", err)
+		}
+	}
+	entries, err := c.search(cnf.searchBase, userFilter(cnf.userFilter, username), cnf.attributes(), 2)
+	if isResult(err, resultSizeLimitExceeded) || len(entries) > 1 {
+		return "", fmt.Errorf("the user filter matches more than one entry")
+	}
+	if err != nil {
+		return "", fmt.Errorf("error searching the user %w", err)
+	}
+	if len(entries) == 0 {
+		return "", s.failLogin(ctx, attemptKeys)
+	}
+
+	err = c.bind(entries[0].dn, password)
+	if isResult(err, resultInvalidCredentials) {
+		return "", s.failLogin(ctx, attemptKeys)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error binding as the user %w", err)
+	}
+	if failed[usernameKey] > 0 {
+		err = s.throttle.Clear(ctx, usernameKey)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	identity := cnf.identity(entries[0])
+	if len(identity.Email) == 0 && len(identity.Phone) == 0 {
+		return "", fmt.Errorf("the entry %s has no email or phone", identity.Dn)
+	}
+	b, err := json.Marshal(identity)
+	if err != nil {
+		return "", fmt.Errorf("error encoding the identity %w", err)
+	}
+	code, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.cacheSvc.Set(ctx, loginCodeKey(code), string(b), loginCodeTTL)
+	if err != nil {
+		return "", fmt.Errorf("error caching the login code %w", err)
+	}
+	return code, nil
+}
+
+func (s service) ExchangeCode(ctx context.Context, code string) (*sdk.LdapIdentity, error) {
+	/*
+	 * the code is single use, it is removed before the identity is returned
+	 */
+	key := loginCodeKey(code)
+	v, err := s.cacheSvc.Get(ctx, key)
+	if err != nil || len(v) == 0 {
+		return nil, fmt.Errorf("%w: unknown or expired login code", sdk.ErrInvalidLdapCredentials)
+	}
+	err = s.cacheSvc.Delete(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("error invalidating the login code %w", err)
+	}
+	identity := sdk.LdapIdentity{}
+	err = json.Unmarshal([]byte(v), &identity)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the identity %w", err)
+	}
+	return &identity, nil
+}
+
+// failLogin counts a failed login against the keys and returns the error of the wrong credentials
+func (s service) failLogin(ctx context.Context, attemptKeys map[string]int) error {
+	err := s.throttle.Fail(ctx, attemptKeys)
+	if err != nil {
+		return err
+	}
+	return sdk.ErrInvalidLdapCredentials
+}
@@ -0,0 +1,481 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	resultInsufficientAccessRights = 50
+
+	serviceDn       = "cn=go-iam,ou=services,dc=example,dc=com"
+	servicePassword = "service-secret"
+)
+
+// stubEntry is an entry of the directory of the stub
+type stubEntry struct {
+	dn       string
+	password string
+	attrs    map[string][]string
+}
+
+// directoryStub is an in-process LDAP server answering the operations of the logins.
+// Like most directories it accepts unauthenticated binds, a dn with an empty password.
+type directoryStub struct {
+	ln      net.Listener
+	tls     *tls.Config
+	entries []stubEntry
+
+	mu             sync.Mutex
+	plaintextBinds int
+}
+
+func newDirectoryStub(t *testing.T, ldaps bool, cert *tls.Certificate) *directoryStub {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	d := &directoryStub{
+		ln: ln,
+		entries: []stubEntry{
+			{
+				dn:       "uid=jdoe,ou=people,dc=example,dc=com",
+				password: "jdoe-secret",
+				attrs: map[string][]string{
+					"objectClass":     {"inetOrgPerson"},
+					"uid":             {"jdoe"},
+					"cn":              {"John Doe"},
+					"displayName":     {"Johnny Doe"},
+					"mail":            {"jdoe@example.com"},
+					"mobile":          {"+15550100"},
+					"sAMAccountName":  {"john.doe"},
+					"memberOf":        {"cn=admins,ou=groups,dc=example,dc=com", "cn=staff,ou=groups,dc=example,dc=com"},
+					"telephoneNumber": {"+15550199"},
+				},
+			},
+			{
+				dn:       "uid=asmith,ou=people,dc=example,dc=com",
+				password: "asmith-secret",
+				attrs: map[string][]string{
+					"objectClass": {"inetOrgPerson"},
+					"uid":         {"asmith"},
+					"cn":          {"Alice Smith"},
+				},
+			},
+			{
+				dn:       "uid=jdoe,ou=contractors,dc=other,dc=com",
+				password: "other-secret",
+				attrs: map[string][]string{
+					"objectClass": {"inetOrgPerson"},
+					"uid":         {"jdoe"},
+					"mail":        {"jdoe@other.com"},
+				},
+			},
+		},
+	}
+	if cert != nil {
+		d.tls = &tls.Config{Certificates: []tls.Certificate{*cert}}
+		if ldaps {
+			d.ln = tls.NewListener(ln, d.tls)
+		}
+	}
+	t.Cleanup(func() { _ = d.ln.Close() })
+	go d.serve()
+	return d
+}
+
+func (d *directoryStub) url(scheme string) string {
+	return scheme + "://" + d.ln.Addr().String()
+}
+
+func (d *directoryStub) serve() {
+	for {
+		c, err := d.ln.Accept()
+		if err != nil {
+			return
+		}
+		go d.handle(c)
+	}
+}
+
+func (d *directoryStub) handle(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	bound := ""
+	for {
+		msg, err := readPacket(r)
+		if err != nil || len(msg.children) < 2 {
+			return
+		}
+		id, _ := msg.children[0].int()
+		op := msg.children[1]
+		switch op.tag {
+		case opBindRequest:
+			if _, ok := c.(*tls.Conn); !ok {
+				d.mu.Lock()
+				d.plaintextBinds++
+				d.mu.Unlock()
+			}
+			dn, password := op.children[1].string(), op.children[2].string()
+			bound = d.bind(dn, password)
+			code := int64(resultSuccess)
+			if len(password) > 0 && len(bound) == 0 {
+				code = resultInvalidCredentials
+			}
+			d.write(c, id, result(opBindResponse, code))
+		case opSearchRequest:
+			if bound != serviceDn {
+				d.write(c, id, result(opSearchDone, resultInsufficientAccessRights))
+				continue
+			}
+			d.search(c, id, op)
+		case opExtendedRequest:
+			if d.tls == nil || op.children[0].string() != startTlsOid {
+				d.write(c, id, result(opExtendedResponse, 2))
+				continue
+			}
+			d.write(c, id, result(opExtendedResponse, resultSuccess))
+			tc := tls.Server(c, d.tls)
+			if tc.Handshake() != nil {
+				return
+			}
+			c = tc
+			r = bufio.NewReader(tc)
+		case opUnbindRequest:
+			return
+		}
+	}
+}
+
+// bind returns the dn the connection is bound as, empty for a wrong password or an unauthenticated bind
+func (d *directoryStub) bind(dn, password string) string {
+	if len(password) == 0 {
+		return ""
+	}
+	if dn == serviceDn && password == servicePassword {
+		return dn
+	}
+	for _, e := range d.entries {
+		if e.dn == dn && e.password == password {
+			return dn
+		}
+	}
+	return ""
+}
+
+func (d *directoryStub) search(c net.Conn, id int64, op *packet) {
+	base := strings.ToLower(op.children[0].string())
+	sizeLimit, _ := op.children[3].int()
+	filter := op.children[6]
+	var matches []stubEntry
+	for _, e := range d.entries {
+		if strings.HasSuffix(strings.ToLower(e.dn), base) && e.matches(filter) {
+			matches = append(matches, e)
+		}
+	}
+	for i, e := range matches {
+		if sizeLimit > 0 && int64(i) >= sizeLimit {
+			d.write(c, id, result(opSearchDone, resultSizeLimitExceeded))
+			return
+		}
+		var attrs [][]byte
+		for _, requested := range op.children[7].children {
+			for name, values := range e.attrs {
+				if !strings.EqualFold(name, requested.string()) {
+					continue
+				}
+				var vals [][]byte
+				for _, v := range values {
+					vals = append(vals, encodeString(tagOctetString, v))
+				}
+				attrs = append(attrs, encode(tagSequence, encodeString(tagOctetString, name), encode(tagSet, vals...)))
+			}
+		}
+		d.write(c, id, encode(opSearchEntry, encodeString(tagOctetString, e.dn), encode(tagSequence, attrs...)))
+	}
+	d.write(c, id, result(opSearchDone, resultSuccess))
+}
+
+func (d *directoryStub) write(c net.Conn, id int64, op []byte) {
+	_, _ = c.Write(encode(tagSequence, encodeInt(tagInteger, id), op))
+}
+
+func result(tag byte, code int64) []byte {
+	return encode(tag, encodeInt(tagEnumerated, code), encodeString(tagOctetString, ""), encodeString(tagOctetString, ""))
+}
+
+// matches evaluates the and, or, not, equality and presence filters
+func (e stubEntry) matches(f *packet) bool {
+	values := func(attr string) []string {
+		for name, v := range e.attrs {
+			if strings.EqualFold(name, attr) {
+				return v
+			}
+		}
+		return nil
+	}
+	switch f.tag {
+	case filterAnd:
+		for _, c := range f.children {
+			if !e.matches(c) {
+				return false
+			}
+		}
+		return true
+	case filterOr:
+		for _, c := range f.children {
+			if e.matches(c) {
+				return true
+			}
+		}
+		return false
+	case filterNot:
+		return !e.matches(f.children[0])
+	case filterEqualityMatch:
+		for _, v := range values(f.children[0].string()) {
+			if strings.EqualFold(v, f.children[1].string()) {
+				return true
+			}
+		}
+		return false
+	case filterPresent:
+		return len(values(f.string())) > 0
+	}
+	return false
+}
+
+// newTestCertificate returns a self signed certificate of 127.0.0.1 and its pem encoding
+func newTestCertificate(t *testing.T) (*tls.Certificate, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ldap.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert := &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	return cert, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// ldapProvider returns a provider of the stub, the params given take precedence over the default ones
+func ldapProvider(url string, params ...sdk.AuthProviderParam) sdk.AuthProvider {
+	return sdk.AuthProvider{
+		Id:       "provider-1",
+		Name:     "Corp Directory",
+		Provider: sdk.AuthProviderTypeLDAP,
+		Params: append(params,
+			sdk.AuthProviderParam{Key: sdk.LdapParamUrl, Value: url},
+			sdk.AuthProviderParam{Key: sdk.LdapParamBindDn, Value: serviceDn},
+			sdk.AuthProviderParam{Key: sdk.LdapParamBindPassword, Value: servicePassword, IsSecret: true},
+			sdk.AuthProviderParam{Key: sdk.LdapParamUserSearchBase, Value: "ou=people,dc=example,dc=com"},
+		),
+	}
+}
+
+func TestLogin(t *testing.T) {
+	ctx := context.Background()
+	d := newDirectoryStub(t, false, nil)
+	svc := NewService(cache.NewMockService())
+
+	t.Run("search then bind returns a code for the identity of the entry", func(t *testing.T) {
+		code, err := svc.Login(ctx, ldapProvider(d.url("ldap")), "jdoe", "jdoe-secret", "10.0.0.1")
+		require.NoError(t, err)
+
+		identity, err := svc.ExchangeCode(ctx, code)
+		require.NoError(t, err)
+		assert.Equal(t, &sdk.LdapIdentity{
+			Dn:     "uid=jdoe,ou=people,dc=example,dc=com",
+			Email:  "jdoe@example.com",
+			Name:   "Johnny Doe",
+			Phone:  "+15550100",
+			Groups: []string{"cn=admins,ou=groups,dc=example,dc=com", "cn=staff,ou=groups,dc=example,dc=com"},
+		}, identity)
+
+		_, err = svc.ExchangeCode(ctx, code)
+		assert.ErrorIs(t, err, sdk.ErrInvalidLdapCredentials, "the code is single use")
+	})
+
+	t.Run("active directory style filter and attribute mapping", func(t *testing.T) {
+		p := ldapProvider(d.url("ldap"),
+			sdk.AuthProviderParam{Key: sdk.LdapParamUserFilter, Value: "(&(objectClass=inetOrgPerson)(sAMAccountName={username}))"},
+			sdk.AuthProviderParam{Key: sdk.LdapParamAttributeMapping, Value: `{"name":"cn","phone":"telephoneNumber"}`},
+		)
+		code, err := svc.Login(ctx, p, "john.doe", "jdoe-secret", "10.0.0.1")
+		require.NoError(t, err)
+
+		identity, err := svc.ExchangeCode(ctx, code)
+		require.NoError(t, err)
+		assert.Equal(t, "John Doe", identity.Name)
+		assert.Equal(t, "+15550199", identity.Phone)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		_, err := svc.Login(ctx, ldapProvider(d.url("ldap")), "jdoe", "wrong", "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrInvalidLdapCredentials)
+	})
+
+	t.Run("unknown username", func(t *testing.T) {
+		_, err := svc.Login(ctx, ldapProvider(d.url("ldap")), "nobody", "jdoe-secret", "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrInvalidLdapCredentials)
+	})
+
+	t.Run("empty password is not an unauthenticated bind", func(t *testing.T) {
+		_, err := svc.Login(ctx, ldapProvider(d.url("ldap")), "jdoe", "", "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrInvalidLdapCredentials)
+	})
+
+	t.Run("the username cannot change the filter", func(t *testing.T) {
+		for _, username := range []string{"*", "j*", "jdoe)(uid=*", "*)(|(uid=*"} {
+			_, err := svc.Login(ctx, ldapProvider(d.url("ldap")), username, "jdoe-secret", "10.0.0.1")
+			assert.ErrorIs(t, err, sdk.ErrInvalidLdapCredentials, username)
+		}
+	})
+
+	t.Run("entries without email or phone cannot log in", func(t *testing.T) {
+		_, err := svc.Login(ctx, ldapProvider(d.url("ldap")), "asmith", "asmith-secret", "10.0.0.1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has no email or phone")
+	})
+
+	t.Run("filter matching several entries", func(t *testing.T) {
+		p := ldapProvider(d.url("ldap"),
+			sdk.AuthProviderParam{Key: sdk.LdapParamUserSearchBase, Value: "dc=com"},
+		)
+		_, err := svc.Login(ctx, p, "jdoe", "jdoe-secret", "10.0.0.1")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, sdk.ErrInvalidLdapCredentials)
+		assert.Contains(t, err.Error(), "more than one entry")
+	})
+
+	t.Run("wrong service account password", func(t *testing.T) {
+		p := ldapProvider(d.url("ldap"), sdk.AuthProviderParam{Key: sdk.LdapParamBindPassword, Value: "wrong"})
+		_, err := svc.Login(ctx, p, "jdoe", "jdoe-secret", "10.0.0.1")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, sdk.ErrInvalidLdapCredentials)
+		assert.Contains(t, err.Error(), "error binding as the service account")
+	})
+
+	t.Run("not an ldap provider", func(t *testing.T) {
+		p := ldapProvider(d.url("ldap"))
+		p.Provider = sdk.AuthProviderTypePassword
+		_, err := svc.Login(ctx, p, "jdoe", "jdoe-secret", "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrNotLdapProvider)
+	})
+
+	t.Run("missing configuration", func(t *testing.T) {
+		_, err := svc.Login(ctx, sdk.AuthProvider{Name: "Corp Directory", Provider: sdk.AuthProviderTypeLDAP}, "jdoe", "jdoe-secret", "10.0.0.1")
+		assert.EqualError(t, err, "the url of Corp Directory is not configured")
+	})
+
+	t.Run("unknown code", func(t *testing.T) {
+		_, err := svc.ExchangeCode(ctx, "unknown")
+		assert.ErrorIs(t, err, sdk.ErrInvalidLdapCredentials)
+	})
+}
+
+func TestLoginThrottle(t *testing.T) {
+	ctx := context.Background()
+	d := newDirectoryStub(t, false, nil)
+
+	t.Run("too many failed logins of a username", func(t *testing.T) {
+		svc := NewService(cache.NewMockService())
+		for i := 0; i < cache.MaxFailedAttemptsPerAccount; i++ {
+			_, err := svc.Login(ctx, ldapProvider(d.url("ldap")), "jdoe", "wrong", fmt.Sprintf("10.0.0.%d", i))
+			assert.ErrorIs(t, err, sdk.ErrInvalidLdapCredentials)
+		}
+		_, err := svc.Login(ctx, ldapProvider(d.url("ldap")), " JDoe ", "jdoe-secret", "10.0.1.1")
+		assert.ErrorIs(t, err, sdk.ErrTooManyLoginAttempts)
+
+		// the directory is not contacted once the limit is reached
+		_, err = svc.Login(ctx, ldapProvider("ldap://127.0.0.1:1"), "jdoe", "jdoe-secret", "10.0.1.1")
+		assert.ErrorIs(t, err, sdk.ErrTooManyLoginAttempts)
+	})
+
+	t.Run("too many failed logins from an ip address", func(t *testing.T) {
+		svc := NewService(cache.NewMockService())
+		for i := 0; i < cache.MaxFailedAttemptsPerIp; i++ {
+			_, err := svc.Login(ctx, ldapProvider(d.url("ldap")), fmt.Sprintf("user-%d", i), "whatever", "10.0.0.1")
+			assert.ErrorIs(t, err, sdk.ErrInvalidLdapCredentials)
+		}
+		_, err := svc.Login(ctx, ldapProvider(d.url("ldap")), "jdoe", "jdoe-secret", "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrTooManyLoginAttempts)
+	})
+
+	t.Run("successful login clears the failed logins of the username", func(t *testing.T) {
+		svc := NewService(cache.NewMockService())
+		for i := 0; i < cache.MaxFailedAttemptsPerAccount-1; i++ {
+			_, err := svc.Login(ctx, ldapProvider(d.url("ldap")), "jdoe", "wrong", "")
+			assert.ErrorIs(t, err, sdk.ErrInvalidLdapCredentials)
+		}
+		_, err := svc.Login(ctx, ldapProvider(d.url("ldap")), "jdoe", "jdoe-secret", "")
+		require.NoError(t, err)
+		_, err = svc.Login(ctx, ldapProvider(d.url("ldap")), "jdoe", "wrong", "")
+		assert.ErrorIs(t, err, sdk.ErrInvalidLdapCredentials)
+	})
+}
+
+func TestLoginOverTls(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(cache.NewMockService())
+	cert, caPem := newTestCertificate(t)
+
+	t.Run("ldaps", func(t *testing.T) {
+		d := newDirectoryStub(t, true, cert)
+		p := ldapProvider(d.url("ldaps"), sdk.AuthProviderParam{Key: sdk.LdapParamCaCert, Value: caPem})
+		_, err := svc.Login(ctx, p, "jdoe", "jdoe-secret", "10.0.0.1")
+		require.NoError(t, err)
+	})
+
+	t.Run("ldaps with an untrusted certificate", func(t *testing.T) {
+		d := newDirectoryStub(t, true, cert)
+		_, err := svc.Login(ctx, ldapProvider(d.url("ldaps")), "jdoe", "jdoe-secret", "10.0.0.1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tls handshake")
+	})
+
+	t.Run("start tls before binding", func(t *testing.T) {
+		d := newDirectoryStub(t, false, cert)
+		p := ldapProvider(d.url("ldap"),
+			sdk.AuthProviderParam{Key: sdk.LdapParamStartTls, Value: "true"},
+			sdk.AuthProviderParam{Key: sdk.LdapParamCaCert, Value: caPem},
+		)
+		_, err := svc.Login(ctx, p, "jdoe", "jdoe-secret", "10.0.0.1")
+		require.NoError(t, err)
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		assert.Zero(t, d.plaintextBinds, "no credentials are sent before tls is started")
+	})
+
+	t.Run("start tls refused by the directory", func(t *testing.T) {
+		d := newDirectoryStub(t, false, nil)
+		p := ldapProvider(d.url("ldap"), sdk.AuthProviderParam{Key: sdk.LdapParamStartTls, Value: "true"})
+		_, err := svc.Login(ctx, p, "jdoe", "jdoe-secret", "10.0.0.1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error starting tls")
+	})
+}
@@ -0,0 +1,177 @@
+package mfa
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+const (
+	// totpPeriod is the time step of the codes, the default of RFC 6238 that authenticator apps assume
+	totpPeriod = 30
+	// totpDigits is the length of the codes
+	totpDigits = 6
+	// totpSkew is the number of steps before and after the current one that are accepted, for clock drift
+	totpSkew = 1
+	// secretSize is the size of the secrets in bytes, 160 bits as recommended for HMAC-SHA1
+	secretSize = 20
+	// recoveryCodeCount is the number of recovery codes issued at a time
+	recoveryCodeCount = 10
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateSecret returns a new base32 encoded TOTP secret
+func generateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("error generating the mfa secret %w", err)
+	}
+	return base32NoPadding.EncodeToString(b), nil
+}
+
+// totpCode computes the code of the secret for the time step as per RFC 4226 and RFC 6238
+func totpCode(secret string, step int64) (string, error) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("error decoding the mfa secret %w", err)
+	}
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%uint32(math.Pow10(totpDigits))), nil
+}
+
+// timeStep returns the TOTP time step of the time
+func timeStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// matchTotp checks the code against the steps around now and returns the matching step.
+// Steps up to lastUsedStep are skipped, so that a code cannot be used twice.
+func matchTotp(secret, code string, now time.Time, lastUsedStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := timeStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+		expected, err := totpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// provisioningUri builds the otpauth uri of the key uri format understood by the authenticator apps
+func provisioningUri(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, q.Encode())
+}
+
+// generateRecoveryCodes returns new recovery codes along with their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error generating the recovery codes %w", err)
+		}
+		raw := strings.ToLower(base32NoPadding.EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes the code ignoring the case, spaces and dashes the user might type
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+	h := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(h[:])
+}
+
+// useRecoveryCode removes the code from the unused ones and tells whether it was one of them
+func useRecoveryCode(credential *sdk.MfaCredential, code string) bool {
+	hash := hashRecoveryCode(code)
+	for i, h := range credential.RecoveryCodeHashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			credential.RecoveryCodeHashes = append(credential.RecoveryCodeHashes[:i:i], credential.RecoveryCodeHashes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// accountName is the label of the user shown in the authenticator app
+func accountName(usr sdk.User) string {
+	if len(usr.Email) > 0 {
+		return usr.Email
+	}
+	if len(usr.Phone) > 0 {
+		return usr.Phone
+	}
+	return usr.Id
+}
+
+func fromSdkToModel(credential sdk.MfaCredential) models.MfaCredential {
+	return models.MfaCredential{
+		Id:                 credential.Id,
+		UserId:             credential.UserId,
+		ProjectId:          credential.ProjectId,
+		Secret:             credential.Secret,
+		RecoveryCodeHashes: credential.RecoveryCodeHashes,
+		LastUsedStep:       credential.LastUsedStep,
+		EnabledAt:          credential.EnabledAt,
+		CreatedAt:          credential.CreatedAt,
+		UpdatedAt:          credential.UpdatedAt,
+	}
+}
+
+func fromModelToSdk(credential models.MfaCredential) sdk.MfaCredential {
+	return sdk.MfaCredential{
+		Id:                 credential.Id,
+		UserId:             credential.UserId,
+		ProjectId:          credential.ProjectId,
+		Secret:             credential.Secret,
+		RecoveryCodeHashes: credential.RecoveryCodeHashes,
+		LastUsedStep:       credential.LastUsedStep,
+		EnabledAt:          credential.EnabledAt,
+		CreatedAt:          credential.CreatedAt,
+		UpdatedAt:          credential.UpdatedAt,
+	}
+}
@@ -0,0 +1,21 @@
+package mfa
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// Service manages the TOTP second factor of the users and the recovery codes standing in for it.
+// An enrollment only takes effect once the user confirms it with a code from their authenticator.
+// Verify, RegenerateRecoveryCodes and Disable accept either an authenticator or a recovery code,
+// and refuse the users with too many wrong codes for a while.
+type Service interface {
+	// Status tells whether the user has a second factor and whether the mfa policy of their project requires one
+	Status(ctx context.Context, usr sdk.User) (*sdk.MfaStatus, error)
+	Enroll(ctx context.Context, usr sdk.User) (*sdk.MfaEnrollment, error)
+	Confirm(ctx context.Context, usr sdk.User, code string) ([]string, error)
+	Verify(ctx context.Context, usr sdk.User, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, usr sdk.User, code string) ([]string, error)
+	Disable(ctx context.Context, usr sdk.User, code string) error
+}
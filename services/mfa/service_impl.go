@@ -0,0 +1,203 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/services/project"
+)
+
+type service struct {
+	store      Store
+	projectSvc project.Service
+	throttle   cache.Throttle
+}
+
+// NewService creates the mfa service. The name of the project of the user is shown as the issuer in the authenticator apps.
+// The wrong codes of the users are counted in the cache.
+func NewService(store Store, projectSvc project.Service, cacheSvc cache.Service) Service {
+	return service{
+		store:      store,
+		projectSvc: projectSvc,
+		throttle:   cache.NewThrottle(cacheSvc, cache.FailedAttemptWindow),
+	}
+}
+
+func (s service) Status(ctx context.Context, usr sdk.User) (*sdk.MfaStatus, error) {
+	p, err := s.projectSvc.Get(ctx, usr.ProjectId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the project of the user %w", err)
+	}
+	status := &sdk.MfaStatus{Required: p.GetMfaPolicy().RequiresMfa(usr)}
+	credential, err := s.store.Get(ctx, usr.Id)
+	if errors.Is(err, sdk.ErrMfaNotEnrolled) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the mfa credential %w", err)
+	}
+	status.Enabled = credential.Enabled()
+	if status.Enabled {
+		status.RecoveryCodesLeft = len(credential.RecoveryCodeHashes)
+	}
+	return status, nil
+}
+
+func (s service) Enroll(ctx context.Context, usr sdk.User) (*sdk.MfaEnrollment, error) {
+	/*
+	 * users with a confirmed authenticator have to disable it before enrolling again
+	 * a pending enrollment is replaced with a new secret
+	 * the secret is returned along with the provisioning uri for the QR code
+	 */
+	p, err := s.projectSvc.Get(ctx, usr.ProjectId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the project of the user %w", err)
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	credential, err := s.store.Get(ctx, usr.Id)
+	switch {
+	case errors.Is(err, sdk.ErrMfaNotEnrolled):
+		err = s.store.Create(ctx, &sdk.MfaCredential{
+			Id:        uuid.NewString(),
+			UserId:    usr.Id,
+			ProjectId: usr.ProjectId,
+			Secret:    secret,
+			CreatedAt: &now,
+			UpdatedAt: &now,
+		})
+	case err != nil:
+		return nil, fmt.Errorf("error fetching the mfa credential %w", err)
+	case credential.Enabled():
+		return nil, sdk.ErrMfaAlreadyEnrolled
+	default:
+		credential.Secret = secret
+		credential.LastUsedStep = 0
+		credential.UpdatedAt = &now
+		err = s.store.Update(ctx, credential)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error saving the mfa credential %w", err)
+	}
+	return &sdk.MfaEnrollment{
+		Secret:          secret,
+		ProvisioningUri: provisioningUri(p.Name, accountName(usr), secret),
+	}, nil
+}
+
+func (s service) Confirm(ctx context.Context, usr sdk.User, code string) ([]string, error) {
+	/*
+	 * the pending enrollment is confirmed with a code from the authenticator
+	 * the second factor is enabled and the recovery codes are issued
+	 */
+	credential, err := s.store.Get(ctx, usr.Id)
+	if err != nil {
+		return nil, err
+	}
+	if credential.Enabled() {
+		return nil, sdk.ErrMfaAlreadyEnrolled
+	}
+	now := time.Now()
+	step, ok := matchTotp(credential.Secret, code, now, credential.LastUsedStep)
+	if !ok {
+		return nil, sdk.ErrInvalidMfaCode
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	credential.LastUsedStep = step
+	credential.RecoveryCodeHashes = hashes
+	credential.EnabledAt = &now
+	credential.UpdatedAt = &now
+	err = s.store.Update(ctx, credential)
+	if err != nil {
+		return nil, fmt.Errorf("error enabling the mfa credential %w", err)
+	}
+	return codes, nil
+}
+
+func (s service) Verify(ctx context.Context, usr sdk.User, code string) error {
+	_, err := s.verify(ctx, usr, code)
+	return err
+}
+
+func (s service) RegenerateRecoveryCodes(ctx context.Context, usr sdk.User, code string) ([]string, error) {
+	credential, err := s.verify(ctx, usr, code)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	credential.RecoveryCodeHashes = hashes
+	err = s.store.Update(ctx, credential)
+	if err != nil {
+		return nil, fmt.Errorf("error saving the recovery codes %w", err)
+	}
+	return codes, nil
+}
+
+func (s service) Disable(ctx context.Context, usr sdk.User, code string) error {
+	_, err := s.verify(ctx, usr, code)
+	if err != nil {
+		return err
+	}
+	err = s.store.Delete(ctx, usr.Id)
+	if err != nil {
+		return fmt.Errorf("error removing the mfa credential %w", err)
+	}
+	return nil
+}
+
+// verify checks an authenticator or recovery code of an enabled credential.
+// The accepted code is recorded, so that it cannot be used again. A user with too many
+// wrong codes within the window is refused until it ends, whether logging in or managing the second factor.
+func (s service) verify(ctx context.Context, usr sdk.User, code string) (*sdk.MfaCredential, error) {
+	attemptKeys := map[string]int{fmt.Sprintf("mfa-attempts-user-%s", usr.Id): cache.MaxFailedAttemptsPerAccount}
+	failed, limited := s.throttle.Limited(ctx, attemptKeys)
+	if limited {
+		return nil, sdk.ErrTooManyMfaAttempts
+	}
+	credential, err := s.store.Get(ctx, usr.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !credential.Enabled() {
+		return nil, sdk.ErrMfaNotEnrolled
+	}
+	now := time.Now()
+	if step, ok := matchTotp(credential.Secret, code, now, credential.LastUsedStep); ok {
+		credential.LastUsedStep = step
+	} else if !useRecoveryCode(credential, code) {
+		err = s.throttle.Fail(ctx, attemptKeys)
+		if err != nil {
+			return nil, err
+		}
+		return nil, sdk.ErrInvalidMfaCode
+	}
+	for key, count := range failed {
+		if count > 0 {
+			err = s.throttle.Clear(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	credential.UpdatedAt = &now
+	err = s.store.Update(ctx, credential)
+	if err != nil {
+		return nil, fmt.Errorf("error saving the mfa credential %w", err)
+	}
+	return credential, nil
+}
@@ -0,0 +1,247 @@
+package mfa
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore keeps the credentials in a map keyed by the user id
+type memoryStore struct {
+	credentials map[string]sdk.MfaCredential
+}
+
+func (m *memoryStore) Get(ctx context.Context, userId string) (*sdk.MfaCredential, error) {
+	c, ok := m.credentials[userId]
+	if !ok {
+		return nil, sdk.ErrMfaNotEnrolled
+	}
+	c.RecoveryCodeHashes = append([]string{}, c.RecoveryCodeHashes...)
+	return &c, nil
+}
+
+func (m *memoryStore) Create(ctx context.Context, credential *sdk.MfaCredential) error {
+	m.credentials[credential.UserId] = *credential
+	return nil
+}
+
+func (m *memoryStore) Update(ctx context.Context, credential *sdk.MfaCredential) error {
+	m.credentials[credential.UserId] = *credential
+	return nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, userId string) error {
+	delete(m.credentials, userId)
+	return nil
+}
+
+var testUser = sdk.User{Id: "user-1", ProjectId: "project-1", Email: "user@example.com"}
+
+func setupTestService(policy *sdk.MfaPolicy) (service, *memoryStore) {
+	store := &memoryStore{credentials: map[string]sdk.MfaCredential{}}
+	projectSvc := &services.MockProjectService{}
+	projectSvc.On("Get", mock.Anything, "project-1").Return(&sdk.Project{Id: "project-1", Name: "Orders", MfaPolicy: policy}, nil)
+	return NewService(store, projectSvc, cache.NewMockService()).(service), store
+}
+
+// currentCode returns the authenticator code of the secret for the given time
+func currentCode(t *testing.T, secret string, at time.Time) string {
+	code, err := totpCode(secret, timeStep(at))
+	require.NoError(t, err)
+	return code
+}
+
+// enroll enables the second factor of the test user and returns its secret and recovery codes
+func enroll(t *testing.T, svc service) (string, []string) {
+	ctx := context.Background()
+	enrollment, err := svc.Enroll(ctx, testUser)
+	require.NoError(t, err)
+	// the previous step keeps the current one unused for the assertions that follow
+	codes, err := svc.Confirm(ctx, testUser, currentCode(t, enrollment.Secret, time.Now().Add(-totpPeriod*time.Second)))
+	require.NoError(t, err)
+	return enrollment.Secret, codes
+}
+
+func TestTotpCode(t *testing.T) {
+	// test vectors of RFC 6238 for the SHA1 key, truncated to 6 digits
+	secret := base32NoPadding.EncodeToString([]byte("12345678901234567890"))
+	tests := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, expected := range tests {
+		code, err := totpCode(secret, timeStep(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code, "time %d", unix)
+	}
+}
+
+func TestMatchTotp(t *testing.T) {
+	secret, err := generateSecret()
+	require.NoError(t, err)
+	now := time.Now()
+
+	step, ok := matchTotp(secret, currentCode(t, secret, now.Add(-totpPeriod*time.Second)), now, 0)
+	assert.True(t, ok, "the previous step is accepted for clock drift")
+	assert.Equal(t, timeStep(now)-1, step)
+
+	_, ok = matchTotp(secret, currentCode(t, secret, now), now, timeStep(now))
+	assert.False(t, ok, "a used step is not accepted again")
+
+	_, ok = matchTotp(secret, currentCode(t, secret, now.Add(-3*totpPeriod*time.Second)), now, 0)
+	assert.False(t, ok, "old codes are rejected")
+
+	_, ok = matchTotp(secret, "12345", now, 0)
+	assert.False(t, ok)
+}
+
+func TestService_Enroll(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("pending until confirmed", func(t *testing.T) {
+		svc, _ := setupTestService(nil)
+
+		enrollment, err := svc.Enroll(ctx, testUser)
+		require.NoError(t, err)
+		u, err := url.Parse(enrollment.ProvisioningUri)
+		require.NoError(t, err)
+		assert.Equal(t, "otpauth", u.Scheme)
+		assert.Equal(t, "totp", u.Host)
+		assert.Equal(t, "/Orders:user@example.com", u.Path)
+		assert.Equal(t, enrollment.Secret, u.Query().Get("secret"))
+		assert.Equal(t, "Orders", u.Query().Get("issuer"))
+
+		status, err := svc.Status(ctx, testUser)
+		require.NoError(t, err)
+		assert.False(t, status.Enabled)
+		assert.ErrorIs(t, svc.Verify(ctx, testUser, currentCode(t, enrollment.Secret, time.Now())), sdk.ErrMfaNotEnrolled)
+	})
+
+	t.Run("confirm enables it and issues the recovery codes", func(t *testing.T) {
+		svc, _ := setupTestService(nil)
+		_, codes := enroll(t, svc)
+
+		assert.Len(t, codes, recoveryCodeCount)
+		status, err := svc.Status(ctx, testUser)
+		require.NoError(t, err)
+		assert.True(t, status.Enabled)
+		assert.Equal(t, recoveryCodeCount, status.RecoveryCodesLeft)
+
+		_, err = svc.Enroll(ctx, testUser)
+		assert.ErrorIs(t, err, sdk.ErrMfaAlreadyEnrolled)
+	})
+
+	t.Run("confirm with a wrong code", func(t *testing.T) {
+		svc, _ := setupTestService(nil)
+		_, err := svc.Enroll(ctx, testUser)
+		require.NoError(t, err)
+
+		_, err = svc.Confirm(ctx, testUser, "000000")
+		assert.ErrorIs(t, err, sdk.ErrInvalidMfaCode)
+	})
+}
+
+func TestService_Verify(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("authenticator code is accepted once", func(t *testing.T) {
+		svc, _ := setupTestService(nil)
+		secret, _ := enroll(t, svc)
+		code := currentCode(t, secret, time.Now())
+
+		require.NoError(t, svc.Verify(ctx, testUser, code))
+		assert.ErrorIs(t, svc.Verify(ctx, testUser, code), sdk.ErrInvalidMfaCode)
+	})
+
+	t.Run("recovery code is accepted once", func(t *testing.T) {
+		svc, _ := setupTestService(nil)
+		_, codes := enroll(t, svc)
+
+		require.NoError(t, svc.Verify(ctx, testUser, strings.ToUpper(codes[0])))
+		assert.ErrorIs(t, svc.Verify(ctx, testUser, codes[0]), sdk.ErrInvalidMfaCode)
+		status, err := svc.Status(ctx, testUser)
+		require.NoError(t, err)
+		assert.Equal(t, recoveryCodeCount-1, status.RecoveryCodesLeft)
+	})
+
+	t.Run("regenerating replaces the recovery codes", func(t *testing.T) {
+		svc, _ := setupTestService(nil)
+		secret, old := enroll(t, svc)
+
+		codes, err := svc.RegenerateRecoveryCodes(ctx, testUser, currentCode(t, secret, time.Now()))
+		require.NoError(t, err)
+		assert.Len(t, codes, recoveryCodeCount)
+		assert.ErrorIs(t, svc.Verify(ctx, testUser, old[1]), sdk.ErrInvalidMfaCode)
+		assert.NoError(t, svc.Verify(ctx, testUser, codes[1]))
+	})
+
+	t.Run("disable needs a valid code", func(t *testing.T) {
+		svc, store := setupTestService(nil)
+		_, codes := enroll(t, svc)
+
+		assert.ErrorIs(t, svc.Disable(ctx, testUser, "000000"), sdk.ErrInvalidMfaCode)
+		require.NoError(t, svc.Disable(ctx, testUser, codes[0]))
+		assert.Empty(t, store.credentials)
+	})
+
+	t.Run("too many wrong codes of a user", func(t *testing.T) {
+		svc, store := setupTestService(nil)
+		_, codes := enroll(t, svc)
+
+		for i := 0; i < cache.MaxFailedAttemptsPerAccount; i++ {
+			assert.ErrorIs(t, svc.Verify(ctx, testUser, "000000"), sdk.ErrInvalidMfaCode)
+		}
+		assert.ErrorIs(t, svc.Verify(ctx, testUser, codes[0]), sdk.ErrTooManyMfaAttempts)
+		_, err := svc.RegenerateRecoveryCodes(ctx, testUser, codes[0])
+		assert.ErrorIs(t, err, sdk.ErrTooManyMfaAttempts)
+		assert.ErrorIs(t, svc.Disable(ctx, testUser, codes[0]), sdk.ErrTooManyMfaAttempts)
+		assert.NotEmpty(t, store.credentials)
+
+		other := sdk.User{Id: "user-2", ProjectId: "project-1"}
+		assert.ErrorIs(t, svc.Verify(ctx, other, "000000"), sdk.ErrMfaNotEnrolled, "the other users are not affected")
+	})
+
+	t.Run("a valid code clears the wrong ones", func(t *testing.T) {
+		svc, _ := setupTestService(nil)
+		_, codes := enroll(t, svc)
+
+		for i := 0; i < cache.MaxFailedAttemptsPerAccount-1; i++ {
+			assert.ErrorIs(t, svc.Verify(ctx, testUser, "000000"), sdk.ErrInvalidMfaCode)
+		}
+		require.NoError(t, svc.Verify(ctx, testUser, codes[0]))
+		assert.ErrorIs(t, svc.Verify(ctx, testUser, "000000"), sdk.ErrInvalidMfaCode)
+		require.NoError(t, svc.Verify(ctx, testUser, codes[1]))
+	})
+}
+
+func TestService_Status(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("required for the roles of the policy", func(t *testing.T) {
+		svc, _ := setupTestService(&sdk.MfaPolicy{Mode: sdk.MfaModeRoles, RoleIds: []string{"role-admin"}})
+
+		status, err := svc.Status(ctx, testUser)
+		require.NoError(t, err)
+		assert.False(t, status.Required)
+
+		admin := testUser
+		admin.Roles = map[string]sdk.UserRole{"role-admin": {Id: "role-admin", Name: "admin"}}
+		status, err = svc.Status(ctx, admin)
+		require.NoError(t, err)
+		assert.True(t, status.Required)
+		assert.False(t, status.Enabled)
+	})
+}
@@ -0,0 +1,16 @@
+package mfa
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+type Store interface {
+	// Get returns the credential of the user, sdk.ErrMfaNotEnrolled if they have none
+	Get(ctx context.Context, userId string) (*sdk.MfaCredential, error)
+	Create(ctx context.Context, credential *sdk.MfaCredential) error
+	// Update saves the secret, the recovery codes, the last used step and the enabled time of the credential
+	Update(ctx context.Context, credential *sdk.MfaCredential) error
+	Delete(ctx context.Context, userId string) error
+}
@@ -0,0 +1,90 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/melvinodsa/go-iam/db"
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/encrypt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type store struct {
+	enc encrypt.Service
+	db  db.DB
+}
+
+// NewStore creates an mfa credential store backed by mongo.
+// The TOTP secrets are encrypted before they are written to the database.
+func NewStore(enc encrypt.Service, db db.DB) Store {
+	return store{enc: enc, db: db}
+}
+
+func (s store) Get(ctx context.Context, userId string) (*sdk.MfaCredential, error) {
+	md := models.GetMfaCredentialModel()
+	var credential models.MfaCredential
+	err := s.db.FindOne(ctx, md, bson.D{{Key: md.UserIdKey, Value: userId}}).Decode(&credential)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, sdk.ErrMfaNotEnrolled
+		}
+		return nil, fmt.Errorf("error finding mfa credential: %w", err)
+	}
+	credential.Secret, err = s.enc.Decrypt(credential.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting mfa secret: %w", err)
+	}
+	result := fromModelToSdk(credential)
+	return &result, nil
+}
+
+func (s store) Create(ctx context.Context, credential *sdk.MfaCredential) error {
+	d := fromSdkToModel(*credential)
+	var err error
+	d.Secret, err = s.enc.Encrypt(d.Secret)
+	if err != nil {
+		return fmt.Errorf("error encrypting mfa secret: %w", err)
+	}
+	md := models.GetMfaCredentialModel()
+	_, err = s.db.InsertOne(ctx, md, d)
+	if err != nil {
+		return fmt.Errorf("error creating mfa credential: %w", err)
+	}
+	return nil
+}
+
+func (s store) Update(ctx context.Context, credential *sdk.MfaCredential) error {
+	secret, err := s.enc.Encrypt(credential.Secret)
+	if err != nil {
+		return fmt.Errorf("error encrypting mfa secret: %w", err)
+	}
+	md := models.GetMfaCredentialModel()
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: md.SecretKey, Value: secret},
+		{Key: md.RecoveryCodeHashesKey, Value: credential.RecoveryCodeHashes},
+		{Key: md.LastUsedStepKey, Value: credential.LastUsedStep},
+		{Key: md.EnabledAtKey, Value: credential.EnabledAt},
+		{Key: md.UpdatedAtKey, Value: credential.UpdatedAt},
+	}}}
+	res, err := s.db.UpdateOne(ctx, md, bson.D{{Key: md.IdKey, Value: credential.Id}}, update)
+	if err != nil {
+		return fmt.Errorf("error updating mfa credential: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return sdk.ErrMfaNotEnrolled
+	}
+	return nil
+}
+
+func (s store) Delete(ctx context.Context, userId string) error {
+	md := models.GetMfaCredentialModel()
+	_, err := s.db.DeleteOne(ctx, md, bson.D{{Key: md.UserIdKey, Value: userId}})
+	if err != nil {
+		return fmt.Errorf("error deleting mfa credential: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package mfa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestStore_Get(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetMfaCredentialModel()
+	filter := bson.D{{Key: md.UserIdKey, Value: "user-1"}}
+
+	t.Run("decrypts the secret", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		enc := &services.MockEncryptService{}
+		enc.On("Decrypt", "encrypted-secret").Return("SECRET", nil)
+		document := bson.D{
+			{Key: md.IdKey, Value: "mfa-1"},
+			{Key: md.UserIdKey, Value: "user-1"},
+			{Key: md.SecretKey, Value: "encrypted-secret"},
+			{Key: md.RecoveryCodeHashesKey, Value: bson.A{"hash-1"}},
+		}
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(document, nil, nil))
+
+		credential, err := NewStore(enc, mockDB).Get(ctx, "user-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "SECRET", credential.Secret)
+		assert.Equal(t, []string{"hash-1"}, credential.RecoveryCodeHashes)
+		assert.False(t, credential.Enabled())
+	})
+
+	t.Run("not enrolled", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil))
+
+		_, err := NewStore(&services.MockEncryptService{}, mockDB).Get(ctx, "user-1")
+		assert.ErrorIs(t, err, sdk.ErrMfaNotEnrolled)
+	})
+}
+
+func TestStore_Create(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetMfaCredentialModel()
+	mockDB := test.SetupMockDB()
+	enc := &services.MockEncryptService{}
+	enc.On("Encrypt", "SECRET").Return("encrypted-secret", nil)
+	mockDB.On("InsertOne", ctx, md, mock.MatchedBy(func(c models.MfaCredential) bool {
+		return c.UserId == "user-1" && c.Secret == "encrypted-secret"
+	}), mock.Anything).Return(&mongo.InsertOneResult{}, nil)
+
+	err := NewStore(enc, mockDB).Create(ctx, &sdk.MfaCredential{Id: "mfa-1", UserId: "user-1", Secret: "SECRET"})
+	assert.NoError(t, err)
+	mockDB.AssertExpectations(t)
+}
+
+func TestStore_Update(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetMfaCredentialModel()
+	mockDB := test.SetupMockDB()
+	enc := &services.MockEncryptService{}
+	enc.On("Encrypt", "SECRET").Return("encrypted-secret", nil)
+	mockDB.On("UpdateOne", ctx, md, bson.D{{Key: md.IdKey, Value: "mfa-1"}}, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+
+	err := NewStore(enc, mockDB).Update(ctx, &sdk.MfaCredential{Id: "mfa-1", Secret: "SECRET"})
+	assert.ErrorIs(t, err, sdk.ErrMfaNotEnrolled)
+}
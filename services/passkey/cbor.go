@@ -0,0 +1,140 @@
+package passkey
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxCborDepth bounds the nesting of the CBOR items, the attestation objects and COSE keys are at most three levels deep
+const maxCborDepth = 8
+
+var errInvalidCbor = errors.New("invalid cbor")
+
+// decodeCbor decodes the first CBOR item of b and returns it with the number of bytes it took.
+// Only the subset of RFC 8949 used by WebAuthn is supported: integers, byte and text strings,
+// arrays, maps with integer or text keys, booleans and null. Integers are returned as int64,
+// maps as map[interface{}]interface{} and arrays as []interface{}.
+func decodeCbor(b []byte) (interface{}, int, error) {
+	return decodeCborItem(b, 0)
+}
+
+func decodeCborItem(b []byte, depth int) (interface{}, int, error) {
+	if depth > maxCborDepth {
+		return nil, 0, fmt.Errorf("%w: nested too deep", errInvalidCbor)
+	}
+	if len(b) == 0 {
+		return nil, 0, fmt.Errorf("%w: unexpected end of data", errInvalidCbor)
+	}
+	major := b[0] >> 5
+	if major == 7 {
+		switch b[0] & 0x1f {
+		case 20:
+			return false, 1, nil
+		case 21:
+			return true, 1, nil
+		case 22:
+			return nil, 1, nil
+		}
+		return nil, 0, fmt.Errorf("%w: unsupported simple value %d", errInvalidCbor, b[0]&0x1f)
+	}
+	arg, n, err := decodeCborArgument(b)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, 0, fmt.Errorf("%w: integer overflow", errInvalidCbor)
+		}
+		return int64(arg), n, nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, 0, fmt.Errorf("%w: integer overflow", errInvalidCbor)
+		}
+		return -1 - int64(arg), n, nil
+	case 2, 3:
+		if arg > uint64(len(b)-n) {
+			return nil, 0, fmt.Errorf("%w: string longer than the data", errInvalidCbor)
+		}
+		end := n + int(arg)
+		if major == 2 {
+			return append([]byte{}, b[n:end]...), end, nil
+		}
+		return string(b[n:end]), end, nil
+	case 4:
+		// every item takes at least a byte, which bounds the allocation
+		if arg > uint64(len(b)-n) {
+			return nil, 0, fmt.Errorf("%w: array longer than the data", errInvalidCbor)
+		}
+		items := make([]interface{}, 0, int(arg))
+		for i := uint64(0); i < arg; i++ {
+			item, size, err := decodeCborItem(b[n:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, item)
+			n += size
+		}
+		return items, n, nil
+	case 5:
+		if arg > uint64(len(b)-n) {
+			return nil, 0, fmt.Errorf("%w: map longer than the data", errInvalidCbor)
+		}
+		items := make(map[interface{}]interface{}, int(arg))
+		for i := uint64(0); i < arg; i++ {
+			key, size, err := decodeCborItem(b[n:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, 0, fmt.Errorf("%w: unsupported map key", errInvalidCbor)
+			}
+			n += size
+			value, size, err := decodeCborItem(b[n:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			items[key] = value
+			n += size
+		}
+		return items, n, nil
+	}
+	return nil, 0, fmt.Errorf("%w: unsupported major type %d", errInvalidCbor, major)
+}
+
+// decodeCborArgument returns the argument of the initial byte of the item and the size of the head
+func decodeCborArgument(b []byte) (uint64, int, error) {
+	info := b[0] & 0x1f
+	switch {
+	case info < 24:
+		return uint64(info), 1, nil
+	case info == 24:
+		if len(b) < 2 {
+			break
+		}
+		return uint64(b[1]), 2, nil
+	case info == 25:
+		if len(b) < 3 {
+			break
+		}
+		return uint64(binary.BigEndian.Uint16(b[1:3])), 3, nil
+	case info == 26:
+		if len(b) < 5 {
+			break
+		}
+		return uint64(binary.BigEndian.Uint32(b[1:5])), 5, nil
+	case info == 27:
+		if len(b) < 9 {
+			break
+		}
+		return binary.BigEndian.Uint64(b[1:9]), 9, nil
+	default:
+		return 0, 0, fmt.Errorf("%w: indefinite lengths are not supported", errInvalidCbor)
+	}
+	return 0, 0, fmt.Errorf("%w: unexpected end of data", errInvalidCbor)
+}
@@ -0,0 +1,101 @@
+package passkey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// ceremony is a registration or login that was started, cached until it is finished or expires
+type ceremony struct {
+	Challenge string `json:"challenge"`
+	ProjectId string `json:"project_id"`
+	UserId    string `json:"user_id,omitempty"` // User the passkey has to belong to, empty for discoverable logins
+}
+
+// randomChallenge returns a base64url challenge of 32 random bytes
+func randomChallenge() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("error generating the passkey challenge %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// userHandle is the id of the user given to the authenticators, returned back on discoverable logins
+func userHandle(userId string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(userId))
+}
+
+func registrationKey(userId string) string {
+	return fmt.Sprintf("passkey-register-%s", userId)
+}
+
+func sessionKey(session string) string {
+	return fmt.Sprintf("passkey-session-%s", hashToken(session))
+}
+
+func loginCodeKey(code string) string {
+	return fmt.Sprintf("passkey-login-%s", hashToken(code))
+}
+
+// accountName is the name of the user shown by the authenticator when picking a passkey
+func accountName(usr sdk.User) string {
+	if len(usr.Email) > 0 {
+		return usr.Email
+	}
+	if len(usr.Phone) > 0 {
+		return usr.Phone
+	}
+	return usr.Id
+}
+
+// descriptors lists the passkeys for the exclude and allow lists of the ceremonies
+func descriptors(passkeys []sdk.Passkey) []sdk.WebAuthnCredentialDescriptor {
+	result := make([]sdk.WebAuthnCredentialDescriptor, 0, len(passkeys))
+	for _, p := range passkeys {
+		result = append(result, sdk.WebAuthnCredentialDescriptor{Type: "public-key", Id: p.CredentialId, Transports: p.Transports})
+	}
+	return result
+}
+
+func fromSdkToModel(passkey sdk.Passkey) models.Passkey {
+	return models.Passkey{
+		Id:           passkey.Id,
+		UserId:       passkey.UserId,
+		ProjectId:    passkey.ProjectId,
+		CredentialId: passkey.CredentialId,
+		PublicKey:    passkey.PublicKey,
+		SignCount:    passkey.SignCount,
+		Transports:   passkey.Transports,
+		Name:         passkey.Name,
+		CreatedAt:    passkey.CreatedAt,
+		LastUsedAt:   passkey.LastUsedAt,
+	}
+}
+
+func fromModelToSdk(passkey models.Passkey) sdk.Passkey {
+	return sdk.Passkey{
+		Id:           passkey.Id,
+		UserId:       passkey.UserId,
+		ProjectId:    passkey.ProjectId,
+		CredentialId: passkey.CredentialId,
+		PublicKey:    passkey.PublicKey,
+		SignCount:    passkey.SignCount,
+		Transports:   passkey.Transports,
+		Name:         passkey.Name,
+		CreatedAt:    passkey.CreatedAt,
+		LastUsedAt:   passkey.LastUsedAt,
+	}
+}
@@ -0,0 +1,25 @@
+package passkey
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// Service registers the passkeys of the users and runs the WebAuthn ceremonies to log in with them.
+// Every ceremony has a Begin step returning the options for the browser and a Finish step verifying
+// the response of the authenticator against the challenge cached by the Begin step.
+// Logins are keyed by a session, like the state of the login or the mfa challenge, and may be
+// restricted to the passkeys of a user. A successful passkey login of the passkey auth provider
+// gets a single use login code that the provider exchanges for the email address or phone number.
+type Service interface {
+	BeginRegistration(ctx context.Context, usr sdk.User) (*sdk.WebAuthnCreationOptions, error)
+	FinishRegistration(ctx context.Context, usr sdk.User, name string, credential sdk.WebAuthnAttestation) (*sdk.Passkey, error)
+	// BeginLogin starts a login for the passkeys of usr, or for any passkey of the project when usr is nil
+	BeginLogin(ctx context.Context, projectId, session string, usr *sdk.User) (*sdk.WebAuthnRequestOptions, error)
+	FinishLogin(ctx context.Context, projectId, session string, credential sdk.WebAuthnAssertion) (*sdk.Passkey, error)
+	List(ctx context.Context, userId string) ([]sdk.Passkey, error)
+	Delete(ctx context.Context, userId, id string) error
+	IssueLoginCode(ctx context.Context, address string) (string, error)
+	ExchangeCode(ctx context.Context, code string) (string, error)
+}
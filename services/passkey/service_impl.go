@@ -0,0 +1,354 @@
+package passkey
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/services/project"
+)
+
+const (
+	// ceremonyTimeout is the time the user has to complete a ceremony with their authenticator
+	ceremonyTimeout = time.Minute * 5
+	// loginCodeTTL is the time within which the auth service has to exchange a login code
+	loginCodeTTL = time.Minute * 5
+	// defaultPasskeyName is the name of the passkeys registered without one
+	defaultPasskeyName = "Passkey"
+)
+
+type service struct {
+	store      Store
+	cacheSvc   cache.Service
+	projectSvc project.Service
+}
+
+// NewService creates the passkey service. The relying party of the passkeys is taken from the project of the user.
+func NewService(store Store, cacheSvc cache.Service, projectSvc project.Service) Service {
+	return service{
+		store:      store,
+		cacheSvc:   cacheSvc,
+		projectSvc: projectSvc,
+	}
+}
+
+func (s service) BeginRegistration(ctx context.Context, usr sdk.User) (*sdk.WebAuthnCreationOptions, error) {
+	/*
+	 * get the relying party of the project of the user
+	 * cache a challenge for the user, a new registration replaces the one in progress
+	 * the passkeys the user already has are excluded so that an authenticator is not registered twice
+	 */
+	p, err := s.getRelyingParty(ctx, usr.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	passkeys, err := s.store.List(ctx, usr.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the passkeys of the user %w", err)
+	}
+	challenge, err := s.startCeremony(ctx, registrationKey(usr.Id), ceremony{ProjectId: usr.ProjectId, UserId: usr.Id})
+	if err != nil {
+		return nil, err
+	}
+	displayName := usr.Name
+	if len(displayName) == 0 {
+		displayName = accountName(usr)
+	}
+	return &sdk.WebAuthnCreationOptions{
+		Challenge: challenge,
+		Rp:        sdk.WebAuthnRelyingParty{Id: p.WebAuthn.RpId, Name: rpName(*p)},
+		User:      sdk.WebAuthnUser{Id: userHandle(usr.Id), Name: accountName(usr), DisplayName: displayName},
+		PubKeyCredParams: []sdk.WebAuthnCredentialParam{
+			{Type: "public-key", Alg: coseAlgES256},
+			{Type: "public-key", Alg: coseAlgEdDSA},
+			{Type: "public-key", Alg: coseAlgRS256},
+		},
+		Timeout:                ceremonyTimeout.Milliseconds(),
+		ExcludeCredentials:     descriptors(passkeys),
+		AuthenticatorSelection: sdk.WebAuthnAuthenticatorSelection{ResidentKey: "required", UserVerification: "required"},
+		Attestation:            "none",
+	}, nil
+}
+
+func (s service) FinishRegistration(ctx context.Context, usr sdk.User, name string, credential sdk.WebAuthnAttestation) (*sdk.Passkey, error) {
+	/*
+	 * the challenge of the registration is single use
+	 * verify the client data and the authenticator data against the relying party
+	 * the public key has to be of an accepted algorithm
+	 * a credential can only be registered once in a project
+	 * save the passkey
+	 */
+	ch, err := s.finishCeremony(ctx, registrationKey(usr.Id))
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.getRelyingParty(ctx, usr.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	clientDataJSON, err := decodeBase64Url(credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyClientData(clientDataJSON, ceremonyCreate, ch.Challenge, p.WebAuthn.Origins)
+	if err != nil {
+		return nil, err
+	}
+	attestationObject, err := decodeBase64Url(credential.Response.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+	authData, err := parseAttestationObject(attestationObject)
+	if err != nil {
+		return nil, err
+	}
+	err = authData.verify(p.WebAuthn.RpId)
+	if err != nil {
+		return nil, err
+	}
+	_, _, err = parsePublicKey(authData.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialId := base64.RawURLEncoding.EncodeToString(authData.credentialId)
+	if len(credential.Id) > 0 && strings.TrimRight(credential.Id, "=") != credentialId {
+		return nil, fmt.Errorf("%w: credential id mismatch", sdk.ErrInvalidPasskey)
+	}
+	_, err = s.store.GetByCredentialId(ctx, usr.ProjectId, credentialId)
+	if err == nil {
+		return nil, fmt.Errorf("%w: the passkey is already registered", sdk.ErrInvalidPasskey)
+	}
+	if !errors.Is(err, sdk.ErrPasskeyNotFound) {
+		return nil, fmt.Errorf("error checking the passkey %w", err)
+	}
+
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		name = defaultPasskeyName
+	}
+	now := time.Now()
+	passkey := &sdk.Passkey{
+		Id:           uuid.NewString(),
+		UserId:       usr.Id,
+		ProjectId:    usr.ProjectId,
+		CredentialId: credentialId,
+		PublicKey:    authData.publicKey,
+		SignCount:    authData.signCount,
+		Transports:   credential.Response.Transports,
+		Name:         name,
+		CreatedAt:    &now,
+	}
+	err = s.store.Create(ctx, passkey)
+	if err != nil {
+		return nil, fmt.Errorf("error saving the passkey %w", err)
+	}
+	return passkey, nil
+}
+
+func (s service) BeginLogin(ctx context.Context, projectId, session string, usr *sdk.User) (*sdk.WebAuthnRequestOptions, error) {
+	/*
+	 * get the relying party of the project
+	 * a login of a known user is limited to their passkeys, otherwise the user picks any passkey of the site
+	 * cache a challenge for the session
+	 */
+	p, err := s.getRelyingParty(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	ch := ceremony{ProjectId: projectId}
+	var allowed []sdk.WebAuthnCredentialDescriptor
+	if usr != nil {
+		passkeys, err := s.store.List(ctx, usr.Id)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching the passkeys of the user %w", err)
+		}
+		if len(passkeys) == 0 {
+			return nil, sdk.ErrPasskeyNotFound
+		}
+		ch.UserId = usr.Id
+		allowed = descriptors(passkeys)
+	}
+	challenge, err := s.startCeremony(ctx, sessionKey(session), ch)
+	if err != nil {
+		return nil, err
+	}
+	return &sdk.WebAuthnRequestOptions{
+		Challenge:        challenge,
+		RpId:             p.WebAuthn.RpId,
+		Timeout:          ceremonyTimeout.Milliseconds(),
+		AllowCredentials: allowed,
+		UserVerification: "required",
+	}, nil
+}
+
+func (s service) FinishLogin(ctx context.Context, projectId, session string, credential sdk.WebAuthnAssertion) (*sdk.Passkey, error) {
+	/*
+	 * the challenge of the login is single use and bound to the project
+	 * verify the client data and the authenticator data against the relying party
+	 * find the passkey, it has to belong to the user the login was started for
+	 * verify the signature and the signature counter
+	 * save the new signature counter
+	 */
+	ch, err := s.finishCeremony(ctx, sessionKey(session))
+	if err != nil {
+		return nil, err
+	}
+	if ch.ProjectId != projectId {
+		return nil, sdk.ErrInvalidPasskeyChallenge
+	}
+	p, err := s.getRelyingParty(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	clientDataJSON, err := decodeBase64Url(credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyClientData(clientDataJSON, ceremonyGet, ch.Challenge, p.WebAuthn.Origins)
+	if err != nil {
+		return nil, err
+	}
+	rawAuthData, err := decodeBase64Url(credential.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	err = authData.verify(p.WebAuthn.RpId)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey, err := s.store.GetByCredentialId(ctx, projectId, strings.TrimRight(credential.Id, "="))
+	if errors.Is(err, sdk.ErrPasskeyNotFound) {
+		return nil, fmt.Errorf("%w: unknown passkey", sdk.ErrInvalidPasskey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the passkey %w", err)
+	}
+	if len(ch.UserId) > 0 && passkey.UserId != ch.UserId {
+		return nil, fmt.Errorf("%w: the passkey belongs to another user", sdk.ErrInvalidPasskey)
+	}
+	if len(credential.Response.UserHandle) > 0 && strings.TrimRight(credential.Response.UserHandle, "=") != userHandle(passkey.UserId) {
+		return nil, fmt.Errorf("%w: user handle mismatch", sdk.ErrInvalidPasskey)
+	}
+	signature, err := decodeBase64Url(credential.Response.Signature)
+	if err != nil {
+		return nil, err
+	}
+	err = verifySignature(passkey.PublicKey, authData.raw, clientDataJSON, signature)
+	if err != nil {
+		return nil, err
+	}
+	err = checkSignCount(passkey.SignCount, authData.signCount)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	passkey.SignCount = authData.signCount
+	passkey.LastUsedAt = &now
+	err = s.store.Update(ctx, passkey)
+	if err != nil {
+		return nil, fmt.Errorf("error updating the passkey %w", err)
+	}
+	return passkey, nil
+}
+
+func (s service) List(ctx context.Context, userId string) ([]sdk.Passkey, error) {
+	return s.store.List(ctx, userId)
+}
+
+func (s service) Delete(ctx context.Context, userId, id string) error {
+	return s.store.Delete(ctx, userId, id)
+}
+
+func (s service) IssueLoginCode(ctx context.Context, address string) (string, error) {
+	code, err := randomChallenge()
+	if err != nil {
+		return "", err
+	}
+	err = s.cacheSvc.Set(ctx, loginCodeKey(code), address, loginCodeTTL)
+	if err != nil {
+		return "", fmt.Errorf("error caching the login code %w", err)
+	}
+	return code, nil
+}
+
+func (s service) ExchangeCode(ctx context.Context, code string) (string, error) {
+	key := loginCodeKey(code)
+	address, err := s.cacheSvc.Get(ctx, key)
+	if err != nil || len(address) == 0 {
+		return "", sdk.ErrInvalidPasskeyChallenge
+	}
+	err = s.cacheSvc.Delete(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("error invalidating the login code %w", err)
+	}
+	return address, nil
+}
+
+// getRelyingParty returns the project, failing when it has no relying party for the passkeys
+func (s service) getRelyingParty(ctx context.Context, projectId string) (*sdk.Project, error) {
+	p, err := s.projectSvc.Get(ctx, projectId)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the project %w", err)
+	}
+	if p.WebAuthn == nil || len(p.WebAuthn.RpId) == 0 {
+		return nil, sdk.ErrPasskeysNotConfigured
+	}
+	return p, nil
+}
+
+func rpName(p sdk.Project) string {
+	if len(p.WebAuthn.RpName) > 0 {
+		return p.WebAuthn.RpName
+	}
+	return p.Name
+}
+
+// startCeremony caches a new challenge under the key and returns it
+func (s service) startCeremony(ctx context.Context, key string, ch ceremony) (string, error) {
+	var err error
+	ch.Challenge, err = randomChallenge()
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(ch)
+	if err != nil {
+		return "", fmt.Errorf("error encoding the passkey challenge %w", err)
+	}
+	err = s.cacheSvc.Set(ctx, key, string(b), ceremonyTimeout)
+	if err != nil {
+		return "", fmt.Errorf("error caching the passkey challenge %w", err)
+	}
+	return ch.Challenge, nil
+}
+
+// finishCeremony returns the ceremony cached under the key and drops it, so that a challenge is only answered once
+func (s service) finishCeremony(ctx context.Context, key string) (*ceremony, error) {
+	val, err := s.cacheSvc.Get(ctx, key)
+	if err != nil || len(val) == 0 {
+		return nil, sdk.ErrInvalidPasskeyChallenge
+	}
+	err = s.cacheSvc.Delete(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("error invalidating the passkey challenge %w", err)
+	}
+	ch := ceremony{}
+	err = json.Unmarshal([]byte(val), &ch)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the passkey challenge %w", err)
+	}
+	return &ch, nil
+}
@@ -0,0 +1,496 @@
+package passkey
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore keeps the passkeys in a map keyed by their id
+type memoryStore struct {
+	passkeys map[string]sdk.Passkey
+}
+
+func (m *memoryStore) List(ctx context.Context, userId string) ([]sdk.Passkey, error) {
+	result := []sdk.Passkey{}
+	for _, p := range m.passkeys {
+		if p.UserId == userId {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryStore) GetByCredentialId(ctx context.Context, projectId, credentialId string) (*sdk.Passkey, error) {
+	for _, p := range m.passkeys {
+		if p.ProjectId == projectId && p.CredentialId == credentialId {
+			return &p, nil
+		}
+	}
+	return nil, sdk.ErrPasskeyNotFound
+}
+
+func (m *memoryStore) Create(ctx context.Context, passkey *sdk.Passkey) error {
+	m.passkeys[passkey.Id] = *passkey
+	return nil
+}
+
+func (m *memoryStore) Update(ctx context.Context, passkey *sdk.Passkey) error {
+	m.passkeys[passkey.Id] = *passkey
+	return nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, userId, id string) error {
+	p, ok := m.passkeys[id]
+	if !ok || p.UserId != userId {
+		return sdk.ErrPasskeyNotFound
+	}
+	delete(m.passkeys, id)
+	return nil
+}
+
+const testOrigin = "https://login.example.com"
+
+var testUser = sdk.User{Id: "user-1", ProjectId: "project-1", Name: "Test User", Email: "user@example.com"}
+
+func setupTestService() (service, *memoryStore) {
+	store := &memoryStore{passkeys: map[string]sdk.Passkey{}}
+	projectSvc := &services.MockProjectService{}
+	projectSvc.On("Get", mock.Anything, "project-1").Return(&sdk.Project{
+		Id:       "project-1",
+		Name:     "Orders",
+		WebAuthn: &sdk.WebAuthnConfig{RpId: "example.com", Origins: []string{testOrigin}},
+	}, nil)
+	projectSvc.On("Get", mock.Anything, "project-2").Return(&sdk.Project{Id: "project-2", Name: "Billing"}, nil)
+	return NewService(store, cache.NewMockService(), projectSvc).(service), store
+}
+
+// cborPair is an entry of a map for encodeCbor, kept in order so that the encoding is deterministic
+type cborPair struct {
+	key, value interface{}
+}
+
+// encodeCbor encodes the subset of CBOR the fake authenticator needs
+func encodeCbor(v interface{}) []byte {
+	switch v := v.(type) {
+	case int:
+		if v >= 0 {
+			return cborHead(0, uint64(v))
+		}
+		return cborHead(1, uint64(-1-v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case []cborPair:
+		b := cborHead(5, uint64(len(v)))
+		for _, p := range v {
+			b = append(b, encodeCbor(p.key)...)
+			b = append(b, encodeCbor(p.value)...)
+		}
+		return b
+	}
+	panic("unsupported cbor value")
+}
+
+func cborHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+	}
+	return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+}
+
+// authenticator is a fake ES256 platform authenticator
+type authenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialId []byte
+	signCount    uint32
+	origin       string
+	rpId         string
+}
+
+func newAuthenticator(t *testing.T) *authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	require.NoError(t, err)
+	return &authenticator{key: key, credentialId: id, origin: testOrigin, rpId: "example.com"}
+}
+
+func (a *authenticator) id() string {
+	return base64.RawURLEncoding.EncodeToString(a.credentialId)
+}
+
+func (a *authenticator) coseKey() []byte {
+	return encodeCbor([]cborPair{
+		{1, 2},
+		{3, coseAlgES256},
+		{-1, 1},
+		{-2, a.key.X.FillBytes(make([]byte, 32))},
+		{-3, a.key.Y.FillBytes(make([]byte, 32))},
+	})
+}
+
+func (a *authenticator) authData(flags byte, attested bool) []byte {
+	rpIdHash := sha256.Sum256([]byte(a.rpId))
+	b := append(rpIdHash[:], flags)
+	b = binary.BigEndian.AppendUint32(b, a.signCount)
+	if attested {
+		b = append(b, make([]byte, 16)...)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(a.credentialId)))
+		b = append(b, a.credentialId...)
+		b = append(b, a.coseKey()...)
+	}
+	return b
+}
+
+func (a *authenticator) clientData(t *testing.T, ceremonyType, challenge string) []byte {
+	b, err := json.Marshal(clientData{Type: ceremonyType, Challenge: challenge, Origin: a.origin})
+	require.NoError(t, err)
+	return b
+}
+
+func (a *authenticator) register(t *testing.T, options *sdk.WebAuthnCreationOptions) sdk.WebAuthnAttestation {
+	attestationObject := encodeCbor([]cborPair{
+		{"fmt", "none"},
+		{"attStmt", []cborPair{}},
+		{"authData", a.authData(flagUserPresent|flagUserVerified|flagAttestedData, true)},
+	})
+	return sdk.WebAuthnAttestation{
+		Id:   a.id(),
+		Type: "public-key",
+		Response: sdk.WebAuthnAttestationResponse{
+			ClientDataJSON:    base64.RawURLEncoding.EncodeToString(a.clientData(t, ceremonyCreate, options.Challenge)),
+			AttestationObject: base64.RawURLEncoding.EncodeToString(attestationObject),
+			Transports:        []string{"internal"},
+		},
+	}
+}
+
+func (a *authenticator) login(t *testing.T, options *sdk.WebAuthnRequestOptions, userId string) sdk.WebAuthnAssertion {
+	a.signCount++
+	authData := a.authData(flagUserPresent|flagUserVerified, false)
+	clientDataJSON := a.clientData(t, ceremonyGet, options.Challenge)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+	return sdk.WebAuthnAssertion{
+		Id:   a.id(),
+		Type: "public-key",
+		Response: sdk.WebAuthnAssertionResponse{
+			ClientDataJSON:    base64.RawURLEncoding.EncodeToString(clientDataJSON),
+			AuthenticatorData: base64.RawURLEncoding.EncodeToString(authData),
+			Signature:         base64.RawURLEncoding.EncodeToString(signature),
+			UserHandle:        userHandle(userId),
+		},
+	}
+}
+
+// registerPasskey registers a passkey of the authenticator for the test user
+func registerPasskey(t *testing.T, svc service, a *authenticator) *sdk.Passkey {
+	ctx := context.Background()
+	options, err := svc.BeginRegistration(ctx, testUser)
+	require.NoError(t, err)
+	passkey, err := svc.FinishRegistration(ctx, testUser, "Laptop", a.register(t, options))
+	require.NoError(t, err)
+	return passkey
+}
+
+func TestBeginRegistration(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns the options of the relying party", func(t *testing.T) {
+		svc, _ := setupTestService()
+		registerPasskey(t, svc, newAuthenticator(t))
+
+		options, err := svc.BeginRegistration(ctx, testUser)
+		require.NoError(t, err)
+		assert.Equal(t, sdk.WebAuthnRelyingParty{Id: "example.com", Name: "Orders"}, options.Rp)
+		assert.Equal(t, sdk.WebAuthnUser{Id: userHandle("user-1"), Name: "user@example.com", DisplayName: "Test User"}, options.User)
+		assert.Equal(t, coseAlgES256, options.PubKeyCredParams[0].Alg)
+		assert.Len(t, options.ExcludeCredentials, 1)
+		assert.Equal(t, "required", options.AuthenticatorSelection.UserVerification)
+		assert.NotEmpty(t, options.Challenge)
+	})
+
+	t.Run("passkeys not configured", func(t *testing.T) {
+		svc, _ := setupTestService()
+		_, err := svc.BeginRegistration(ctx, sdk.User{Id: "user-2", ProjectId: "project-2"})
+		assert.ErrorIs(t, err, sdk.ErrPasskeysNotConfigured)
+	})
+}
+
+func TestFinishRegistration(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("saves the passkey", func(t *testing.T) {
+		svc, store := setupTestService()
+		a := newAuthenticator(t)
+		passkey := registerPasskey(t, svc, a)
+		assert.Equal(t, a.id(), passkey.CredentialId)
+		assert.Equal(t, "Laptop", passkey.Name)
+		assert.Equal(t, []string{"internal"}, passkey.Transports)
+		assert.Equal(t, a.coseKey(), passkey.PublicKey)
+		assert.Contains(t, store.passkeys, passkey.Id)
+	})
+
+	t.Run("the challenge is single use", func(t *testing.T) {
+		svc, _ := setupTestService()
+		a := newAuthenticator(t)
+		options, err := svc.BeginRegistration(ctx, testUser)
+		require.NoError(t, err)
+		credential := a.register(t, options)
+		_, err = svc.FinishRegistration(ctx, testUser, "", credential)
+		require.NoError(t, err)
+		_, err = svc.FinishRegistration(ctx, testUser, "", credential)
+		assert.ErrorIs(t, err, sdk.ErrInvalidPasskeyChallenge)
+	})
+
+	t.Run("default name", func(t *testing.T) {
+		svc, _ := setupTestService()
+		options, err := svc.BeginRegistration(ctx, testUser)
+		require.NoError(t, err)
+		passkey, err := svc.FinishRegistration(ctx, testUser, "  ", newAuthenticator(t).register(t, options))
+		require.NoError(t, err)
+		assert.Equal(t, defaultPasskeyName, passkey.Name)
+	})
+
+	t.Run("rejects", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			modify func(a *authenticator)
+		}{
+			{name: "origin not allowed", modify: func(a *authenticator) { a.origin = "https://evil.example.net" }},
+			{name: "other relying party", modify: func(a *authenticator) { a.rpId = "evil.example.net" }},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				svc, store := setupTestService()
+				a := newAuthenticator(t)
+				tt.modify(a)
+				options, err := svc.BeginRegistration(ctx, testUser)
+				require.NoError(t, err)
+				_, err = svc.FinishRegistration(ctx, testUser, "", a.register(t, options))
+				assert.ErrorIs(t, err, sdk.ErrInvalidPasskey)
+				assert.Empty(t, store.passkeys)
+			})
+		}
+	})
+
+	t.Run("rejects a passkey registered twice", func(t *testing.T) {
+		svc, _ := setupTestService()
+		a := newAuthenticator(t)
+		registerPasskey(t, svc, a)
+		options, err := svc.BeginRegistration(ctx, testUser)
+		require.NoError(t, err)
+		_, err = svc.FinishRegistration(ctx, testUser, "", a.register(t, options))
+		assert.ErrorIs(t, err, sdk.ErrInvalidPasskey)
+	})
+}
+
+func TestLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("discoverable login", func(t *testing.T) {
+		svc, store := setupTestService()
+		a := newAuthenticator(t)
+		registered := registerPasskey(t, svc, a)
+
+		options, err := svc.BeginLogin(ctx, "project-1", "state-1", nil)
+		require.NoError(t, err)
+		assert.Empty(t, options.AllowCredentials)
+		assert.Equal(t, "example.com", options.RpId)
+
+		passkey, err := svc.FinishLogin(ctx, "project-1", "state-1", a.login(t, options, "user-1"))
+		require.NoError(t, err)
+		assert.Equal(t, registered.Id, passkey.Id)
+		assert.Equal(t, uint32(1), store.passkeys[registered.Id].SignCount)
+		assert.NotNil(t, store.passkeys[registered.Id].LastUsedAt)
+	})
+
+	t.Run("login of a user", func(t *testing.T) {
+		svc, _ := setupTestService()
+		a := newAuthenticator(t)
+		registerPasskey(t, svc, a)
+
+		options, err := svc.BeginLogin(ctx, "project-1", "mfa-1", &testUser)
+		require.NoError(t, err)
+		assert.Equal(t, a.id(), options.AllowCredentials[0].Id)
+		_, err = svc.FinishLogin(ctx, "project-1", "mfa-1", a.login(t, options, "user-1"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("user without passkeys", func(t *testing.T) {
+		svc, _ := setupTestService()
+		_, err := svc.BeginLogin(ctx, "project-1", "mfa-1", &testUser)
+		assert.ErrorIs(t, err, sdk.ErrPasskeyNotFound)
+	})
+
+	t.Run("passkey of another user", func(t *testing.T) {
+		svc, _ := setupTestService()
+		a := newAuthenticator(t)
+		registerPasskey(t, svc, a)
+
+		other := sdk.User{Id: "user-2", ProjectId: "project-1", Email: "other@example.com"}
+		otherOptions, err := svc.BeginRegistration(ctx, other)
+		require.NoError(t, err)
+		_, err = svc.FinishRegistration(ctx, other, "", newAuthenticator(t).register(t, otherOptions))
+		require.NoError(t, err)
+
+		options, err := svc.BeginLogin(ctx, "project-1", "mfa-2", &other)
+		require.NoError(t, err)
+		_, err = svc.FinishLogin(ctx, "project-1", "mfa-2", a.login(t, options, "user-1"))
+		assert.ErrorIs(t, err, sdk.ErrInvalidPasskey)
+	})
+
+	t.Run("the challenge is single use and bound to the project", func(t *testing.T) {
+		svc, _ := setupTestService()
+		a := newAuthenticator(t)
+		registerPasskey(t, svc, a)
+
+		options, err := svc.BeginLogin(ctx, "project-1", "state-1", nil)
+		require.NoError(t, err)
+		_, err = svc.FinishLogin(ctx, "project-2", "state-1", a.login(t, options, "user-1"))
+		assert.ErrorIs(t, err, sdk.ErrInvalidPasskeyChallenge)
+		_, err = svc.FinishLogin(ctx, "project-1", "state-1", a.login(t, options, "user-1"))
+		assert.ErrorIs(t, err, sdk.ErrInvalidPasskeyChallenge)
+	})
+
+	t.Run("cloned authenticator", func(t *testing.T) {
+		svc, _ := setupTestService()
+		a := newAuthenticator(t)
+		registerPasskey(t, svc, a)
+		options, err := svc.BeginLogin(ctx, "project-1", "state-1", nil)
+		require.NoError(t, err)
+		_, err = svc.FinishLogin(ctx, "project-1", "state-1", a.login(t, options, "user-1"))
+		require.NoError(t, err)
+
+		// a copy of the authenticator still counting from the old value
+		a.signCount--
+		options, err = svc.BeginLogin(ctx, "project-1", "state-2", nil)
+		require.NoError(t, err)
+		_, err = svc.FinishLogin(ctx, "project-1", "state-2", a.login(t, options, "user-1"))
+		assert.ErrorIs(t, err, sdk.ErrInvalidPasskey)
+	})
+
+	t.Run("rejects", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			modify func(a *authenticator, assertion *sdk.WebAuthnAssertion)
+		}{
+			{name: "bad signature", modify: func(a *authenticator, assertion *sdk.WebAuthnAssertion) {
+				assertion.Response.Signature = base64.RawURLEncoding.EncodeToString([]byte("not a signature"))
+			}},
+			{name: "unknown passkey", modify: func(a *authenticator, assertion *sdk.WebAuthnAssertion) {
+				assertion.Id = base64.RawURLEncoding.EncodeToString([]byte("unknown"))
+			}},
+			{name: "user handle mismatch", modify: func(a *authenticator, assertion *sdk.WebAuthnAssertion) {
+				assertion.Response.UserHandle = userHandle("user-2")
+			}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				svc, _ := setupTestService()
+				a := newAuthenticator(t)
+				registerPasskey(t, svc, a)
+				options, err := svc.BeginLogin(ctx, "project-1", "state-1", nil)
+				require.NoError(t, err)
+				assertion := a.login(t, options, "user-1")
+				tt.modify(a, &assertion)
+				_, err = svc.FinishLogin(ctx, "project-1", "state-1", assertion)
+				assert.ErrorIs(t, err, sdk.ErrInvalidPasskey)
+			})
+		}
+	})
+}
+
+func TestSignCount(t *testing.T) {
+	assert.NoError(t, checkSignCount(0, 0))
+	assert.NoError(t, checkSignCount(0, 1))
+	assert.NoError(t, checkSignCount(4, 5))
+	assert.ErrorIs(t, checkSignCount(5, 5), sdk.ErrInvalidPasskey)
+	assert.ErrorIs(t, checkSignCount(5, 0), sdk.ErrInvalidPasskey)
+}
+
+func TestVerifySignatureEdDSA(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	coseKey := encodeCbor([]cborPair{{1, 1}, {3, coseAlgEdDSA}, {-1, 6}, {-2, []byte(pub)}})
+	authData := []byte("authenticator data")
+	clientDataJSON := []byte(`{"type":"webauthn.get"}`)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signature := ed25519.Sign(priv, append(append([]byte{}, authData...), clientDataHash[:]...))
+
+	assert.NoError(t, verifySignature(coseKey, authData, clientDataJSON, signature))
+	assert.ErrorIs(t, verifySignature(coseKey, []byte("other data"), clientDataJSON, signature), sdk.ErrInvalidPasskey)
+}
+
+func TestDecodeCbor(t *testing.T) {
+	t.Run("decodes nested values", func(t *testing.T) {
+		b := encodeCbor([]cborPair{{"authData", []byte{1, 2}}, {-2, 300}, {"fmt", "none"}})
+		v, n, err := decodeCbor(append(b, 0xff))
+		require.NoError(t, err)
+		assert.Equal(t, len(b), n)
+		assert.Equal(t, map[interface{}]interface{}{"authData": []byte{1, 2}, int64(-2): int64(300), "fmt": "none"}, v)
+	})
+
+	t.Run("rejects malformed data", func(t *testing.T) {
+		for name, b := range map[string][]byte{
+			"empty":              {},
+			"truncated string":   {0x45, 1, 2},
+			"truncated argument": {0x19, 1},
+			"indefinite length":  {0x5f},
+			"oversized map":      {0xbb, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			"array map key":      {0xa1, 0x80, 0x01},
+			"float":              {0xf9, 0, 0},
+		} {
+			_, _, err := decodeCbor(b)
+			assert.ErrorIs(t, err, errInvalidCbor, name)
+		}
+	})
+}
+
+func TestLoginCodes(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := setupTestService()
+
+	code, err := svc.IssueLoginCode(ctx, "user@example.com")
+	require.NoError(t, err)
+	address, err := svc.ExchangeCode(ctx, code)
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", address)
+
+	_, err = svc.ExchangeCode(ctx, code)
+	assert.ErrorIs(t, err, sdk.ErrInvalidPasskeyChallenge)
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	svc, store := setupTestService()
+	passkey := registerPasskey(t, svc, newAuthenticator(t))
+
+	assert.ErrorIs(t, svc.Delete(ctx, "user-2", passkey.Id), sdk.ErrPasskeyNotFound)
+	assert.NoError(t, svc.Delete(ctx, "user-1", passkey.Id))
+	assert.Empty(t, store.passkeys)
+}
@@ -0,0 +1,19 @@
+package passkey
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+type Store interface {
+	// List returns the passkeys of the user, oldest first
+	List(ctx context.Context, userId string) ([]sdk.Passkey, error)
+	// GetByCredentialId returns the passkey with the credential id in the project, sdk.ErrPasskeyNotFound if there is none
+	GetByCredentialId(ctx context.Context, projectId, credentialId string) (*sdk.Passkey, error)
+	Create(ctx context.Context, passkey *sdk.Passkey) error
+	// Update saves the sign count and the last used time of the passkey
+	Update(ctx context.Context, passkey *sdk.Passkey) error
+	// Delete removes the passkey of the user, sdk.ErrPasskeyNotFound if the user has no such passkey
+	Delete(ctx context.Context, userId, id string) error
+}
@@ -0,0 +1,108 @@
+package passkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/melvinodsa/go-iam/db"
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type store struct {
+	db db.DB
+}
+
+// NewStore creates a passkey store backed by mongo.
+func NewStore(db db.DB) Store {
+	return store{db: db}
+}
+
+func (s store) List(ctx context.Context, userId string) ([]sdk.Passkey, error) {
+	md := models.GetPasskeyModel()
+	opts := options.Find().SetSort(bson.D{{Key: md.CreatedAtKey, Value: 1}})
+	cursor, err := s.db.Find(ctx, md, bson.D{{Key: md.UserIdKey, Value: userId}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding passkeys: %w", err)
+	}
+	defer func() {
+		err := cursor.Close(ctx)
+		if err != nil {
+			log.Errorw("error closing cursor after reading passkeys", "error", err)
+		}
+	}()
+	var passkeys []models.Passkey
+	err = cursor.All(ctx, &passkeys)
+	if err != nil {
+		return nil, fmt.Errorf("error reading passkeys: %w", err)
+	}
+	result := make([]sdk.Passkey, 0, len(passkeys))
+	for _, p := range passkeys {
+		result = append(result, fromModelToSdk(p))
+	}
+	return result, nil
+}
+
+func (s store) GetByCredentialId(ctx context.Context, projectId, credentialId string) (*sdk.Passkey, error) {
+	md := models.GetPasskeyModel()
+	filter := bson.D{
+		{Key: md.ProjectIdKey, Value: projectId},
+		{Key: md.CredentialIdKey, Value: credentialId},
+	}
+	var passkey models.Passkey
+	err := s.db.FindOne(ctx, md, filter).Decode(&passkey)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, sdk.ErrPasskeyNotFound
+		}
+		return nil, fmt.Errorf("error finding passkey: %w", err)
+	}
+	result := fromModelToSdk(passkey)
+	return &result, nil
+}
+
+func (s store) Create(ctx context.Context, passkey *sdk.Passkey) error {
+	md := models.GetPasskeyModel()
+	_, err := s.db.InsertOne(ctx, md, fromSdkToModel(*passkey))
+	if err != nil {
+		return fmt.Errorf("error creating passkey: %w", err)
+	}
+	return nil
+}
+
+func (s store) Update(ctx context.Context, passkey *sdk.Passkey) error {
+	md := models.GetPasskeyModel()
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: md.SignCountKey, Value: passkey.SignCount},
+		{Key: md.LastUsedAtKey, Value: passkey.LastUsedAt},
+	}}}
+	res, err := s.db.UpdateOne(ctx, md, bson.D{{Key: md.IdKey, Value: passkey.Id}}, update)
+	if err != nil {
+		return fmt.Errorf("error updating passkey: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return sdk.ErrPasskeyNotFound
+	}
+	return nil
+}
+
+func (s store) Delete(ctx context.Context, userId, id string) error {
+	md := models.GetPasskeyModel()
+	filter := bson.D{
+		{Key: md.IdKey, Value: id},
+		{Key: md.UserIdKey, Value: userId},
+	}
+	res, err := s.db.DeleteOne(ctx, md, filter)
+	if err != nil {
+		return fmt.Errorf("error deleting passkey: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return sdk.ErrPasskeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package passkey
+
+import (
+	"context"
+	"testing"
+
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestStore_List(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetPasskeyModel()
+	mockDB := test.SetupMockDB()
+	documents := []interface{}{
+		bson.D{{Key: md.IdKey, Value: "passkey-1"}, {Key: md.UserIdKey, Value: "user-1"}, {Key: md.CredentialIdKey, Value: "cred-1"}, {Key: "public_key", Value: []byte{1, 2}}},
+		bson.D{{Key: md.IdKey, Value: "passkey-2"}, {Key: md.UserIdKey, Value: "user-1"}, {Key: md.CredentialIdKey, Value: "cred-2"}},
+	}
+	cursor, _ := mongo.NewCursorFromDocuments(documents, nil, nil)
+	mockDB.On("Find", ctx, md, bson.D{{Key: md.UserIdKey, Value: "user-1"}}, mock.Anything).Return(cursor, nil)
+
+	passkeys, err := NewStore(mockDB).List(ctx, "user-1")
+	assert.NoError(t, err)
+	assert.Len(t, passkeys, 2)
+	assert.Equal(t, "cred-1", passkeys[0].CredentialId)
+	assert.Equal(t, []byte{1, 2}, passkeys[0].PublicKey)
+}
+
+func TestStore_GetByCredentialId(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetPasskeyModel()
+	filter := bson.D{{Key: md.ProjectIdKey, Value: "project-1"}, {Key: md.CredentialIdKey, Value: "cred-1"}}
+
+	t.Run("found", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		document := bson.D{{Key: md.IdKey, Value: "passkey-1"}, {Key: md.CredentialIdKey, Value: "cred-1"}, {Key: md.SignCountKey, Value: int64(3)}}
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(document, nil, nil))
+
+		passkey, err := NewStore(mockDB).GetByCredentialId(ctx, "project-1", "cred-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "passkey-1", passkey.Id)
+		assert.Equal(t, uint32(3), passkey.SignCount)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil))
+
+		_, err := NewStore(mockDB).GetByCredentialId(ctx, "project-1", "cred-1")
+		assert.ErrorIs(t, err, sdk.ErrPasskeyNotFound)
+	})
+}
+
+func TestStore_Update(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetPasskeyModel()
+	mockDB := test.SetupMockDB()
+	mockDB.On("UpdateOne", ctx, md, bson.D{{Key: md.IdKey, Value: "passkey-1"}}, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+
+	err := NewStore(mockDB).Update(ctx, &sdk.Passkey{Id: "passkey-1", SignCount: 4})
+	assert.ErrorIs(t, err, sdk.ErrPasskeyNotFound)
+}
+
+func TestStore_Delete(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetPasskeyModel()
+	filter := bson.D{{Key: md.IdKey, Value: "passkey-1"}, {Key: md.UserIdKey, Value: "user-1"}}
+
+	t.Run("deleted", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("DeleteOne", ctx, md, filter, mock.Anything).Return(&mongo.DeleteResult{DeletedCount: 1}, nil)
+		assert.NoError(t, NewStore(mockDB).Delete(ctx, "user-1", "passkey-1"))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("DeleteOne", ctx, md, filter, mock.Anything).Return(&mongo.DeleteResult{DeletedCount: 0}, nil)
+		assert.ErrorIs(t, NewStore(mockDB).Delete(ctx, "user-1", "passkey-1"), sdk.ErrPasskeyNotFound)
+	})
+}
@@ -0,0 +1,242 @@
+package passkey
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// COSE algorithms accepted for the passkeys, by preference
+const (
+	coseAlgES256 = -7
+	coseAlgEdDSA = -8
+	coseAlgRS256 = -257
+)
+
+// flags of the authenticator data
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+	// minRsaKeyBits is the smallest RSA key accepted for RS256 passkeys
+	minRsaKeyBits = 2048
+)
+
+// decodeBase64Url decodes the binary values sent by the pages, which may or may not be padded
+func decodeBase64Url(s string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid base64url value %w", sdk.ErrInvalidPasskey, err)
+	}
+	return b, nil
+}
+
+// clientData is the part of the client data json the relying party checks
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// verifyClientData checks the client data collected by the browser against the ceremony that was started
+func verifyClientData(raw []byte, ceremony, challenge string, origins []string) error {
+	cd := clientData{}
+	err := json.Unmarshal(raw, &cd)
+	if err != nil {
+		return fmt.Errorf("%w: invalid client data %w", sdk.ErrInvalidPasskey, err)
+	}
+	if cd.Type != ceremony {
+		return fmt.Errorf("%w: expected a %s ceremony, got %s", sdk.ErrInvalidPasskey, ceremony, cd.Type)
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimRight(cd.Challenge, "=")), []byte(challenge)) != 1 {
+		return fmt.Errorf("%w: challenge mismatch", sdk.ErrInvalidPasskey)
+	}
+	if !slices.Contains(origins, cd.Origin) {
+		return fmt.Errorf("%w: origin %s is not allowed", sdk.ErrInvalidPasskey, cd.Origin)
+	}
+	return nil
+}
+
+// authenticatorData is the parsed authenticator data of a ceremony
+type authenticatorData struct {
+	raw          []byte
+	rpIdHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialId []byte // only on registration
+	publicKey    []byte // COSE key of the credential, only on registration
+}
+
+// parseAuthenticatorData parses the authenticator data as laid out in the WebAuthn spec:
+// the rp id hash, the flags, the sign count and on registration the attested credential data
+func parseAuthenticatorData(b []byte) (*authenticatorData, error) {
+	if len(b) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data too short", sdk.ErrInvalidPasskey)
+	}
+	data := &authenticatorData{
+		raw:       b,
+		rpIdHash:  b[:32],
+		flags:     b[32],
+		signCount: binary.BigEndian.Uint32(b[33:37]),
+	}
+	if data.flags&flagAttestedData == 0 {
+		return data, nil
+	}
+	// aaguid (16 bytes), length of the credential id (2 bytes), the credential id and the COSE key
+	rest := b[37:]
+	if len(rest) < 18 {
+		return nil, fmt.Errorf("%w: attested credential data too short", sdk.ErrInvalidPasskey)
+	}
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLen {
+		return nil, fmt.Errorf("%w: credential id longer than the data", sdk.ErrInvalidPasskey)
+	}
+	data.credentialId = rest[:idLen]
+	_, keyLen, err := decodeCbor(rest[idLen:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid credential public key %w", sdk.ErrInvalidPasskey, err)
+	}
+	data.publicKey = rest[idLen : idLen+keyLen]
+	return data, nil
+}
+
+// verify checks that the ceremony ran for the relying party and that the user was verified by the authenticator
+func (a authenticatorData) verify(rpId string) error {
+	expected := sha256.Sum256([]byte(rpId))
+	if subtle.ConstantTimeCompare(a.rpIdHash, expected[:]) != 1 {
+		return fmt.Errorf("%w: rp id mismatch", sdk.ErrInvalidPasskey)
+	}
+	if a.flags&flagUserPresent == 0 {
+		return fmt.Errorf("%w: user not present", sdk.ErrInvalidPasskey)
+	}
+	if a.flags&flagUserVerified == 0 {
+		return fmt.Errorf("%w: user not verified", sdk.ErrInvalidPasskey)
+	}
+	return nil
+}
+
+// parseAttestationObject returns the authenticator data of the attestation object.
+// Attestation "none" is requested, so the attestation statement is not verified.
+func parseAttestationObject(b []byte) (*authenticatorData, error) {
+	obj, _, err := decodeCbor(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid attestation object %w", sdk.ErrInvalidPasskey, err)
+	}
+	m, ok := obj.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: attestation object is not a map", sdk.ErrInvalidPasskey)
+	}
+	raw, ok := m["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: attestation object has no authenticator data", sdk.ErrInvalidPasskey)
+	}
+	data, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return nil, err
+	}
+	if data.flags&flagAttestedData == 0 {
+		return nil, fmt.Errorf("%w: attestation has no credential", sdk.ErrInvalidPasskey)
+	}
+	return data, nil
+}
+
+// parsePublicKey decodes a COSE key of one of the accepted algorithms
+func parsePublicKey(coseKey []byte) (int64, crypto.PublicKey, error) {
+	obj, _, err := decodeCbor(coseKey)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: invalid public key %w", sdk.ErrInvalidPasskey, err)
+	}
+	m, ok := obj.(map[interface{}]interface{})
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: public key is not a map", sdk.ErrInvalidPasskey)
+	}
+	// labels of RFC 9053: 1 kty, 3 alg, -1 crv or n, -2 x or e, -3 y
+	kty, _ := m[int64(1)].(int64)
+	alg, _ := m[int64(3)].(int64)
+	switch {
+	case alg == coseAlgES256 && kty == 2:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		y, _ := m[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			break
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			break
+		}
+		return alg, key, nil
+	case alg == coseAlgEdDSA && kty == 1:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		if crv != 6 || len(x) != ed25519.PublicKeySize {
+			break
+		}
+		return alg, ed25519.PublicKey(x), nil
+	case alg == coseAlgRS256 && kty == 3:
+		n, _ := m[int64(-1)].([]byte)
+		e, _ := m[int64(-2)].([]byte)
+		if len(e) == 0 || len(e) > 4 {
+			break
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRsaKeyBits {
+			break
+		}
+		return alg, key, nil
+	}
+	return 0, nil, fmt.Errorf("%w: unsupported public key (kty %d, alg %d)", sdk.ErrInvalidPasskey, kty, alg)
+}
+
+// verifySignature checks the signature of an assertion over the authenticator data and the hash of the client data
+func verifySignature(coseKey, authData, clientDataJSON, signature []byte) error {
+	alg, key, err := parsePublicKey(coseKey)
+	if err != nil {
+		return err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := bytes.Join([][]byte{authData, clientDataHash[:]}, nil)
+	digest := sha256.Sum256(signed)
+
+	valid := false
+	switch alg {
+	case coseAlgES256:
+		valid = ecdsa.VerifyASN1(key.(*ecdsa.PublicKey), digest[:], signature)
+	case coseAlgEdDSA:
+		valid = ed25519.Verify(key.(ed25519.PublicKey), signed, signature)
+	case coseAlgRS256:
+		valid = rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	}
+	if !valid {
+		return fmt.Errorf("%w: invalid signature", sdk.ErrInvalidPasskey)
+	}
+	return nil
+}
+
+// checkSignCount detects cloned authenticators. Authenticators that do not count report zero every time.
+func checkSignCount(stored, received uint32) error {
+	if (stored > 0 || received > 0) && received <= stored {
+		return fmt.Errorf("%w: signature counter did not increase", sdk.ErrInvalidPasskey)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptCost is the work factor of the password hashes. Tests lower it to keep them fast.
+var bcryptCost = 12
+
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+func hashPassword(password string) (string, error) {
+	h, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing the password %w", err)
+	}
+	return string(h), nil
+}
+
+func checkPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// spendHashTime compares the password against a throwaway hash, so that
+// logins for unknown emails take as long as the ones with a wrong password
+func spendHashTime(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("go-iam"), bcryptCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// randomToken returns a url safe token with 256 bits of entropy
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("error generating the token %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is used as the cache key of the tokens sent by email, so that the raw tokens are not stored
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// buildLink adds the token to the query of the page url
+func buildLink(pageUrl, token string) (string, error) {
+	u, err := url.Parse(pageUrl)
+	if err != nil {
+		return "", fmt.Errorf("error parsing the page url %w", err)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func fromSdkToModel(credential sdk.PasswordCredential) models.PasswordCredential {
+	return models.PasswordCredential{
+		Id:             credential.Id,
+		ProjectId:      credential.ProjectId,
+		AuthProviderId: credential.AuthProviderId,
+		Email:          credential.Email,
+		Name:           credential.Name,
+		PasswordHash:   credential.PasswordHash,
+		VerifiedAt:     credential.VerifiedAt,
+		CreatedAt:      credential.CreatedAt,
+		UpdatedAt:      credential.UpdatedAt,
+	}
+}
+
+func fromModelToSdk(credential models.PasswordCredential) sdk.PasswordCredential {
+	return sdk.PasswordCredential{
+		Id:             credential.Id,
+		ProjectId:      credential.ProjectId,
+		AuthProviderId: credential.AuthProviderId,
+		Email:          credential.Email,
+		Name:           credential.Name,
+		PasswordHash:   credential.PasswordHash,
+		VerifiedAt:     credential.VerifiedAt,
+		CreatedAt:      credential.CreatedAt,
+		UpdatedAt:      credential.UpdatedAt,
+	}
+}
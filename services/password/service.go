@@ -0,0 +1,22 @@
+package password
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// Service manages the credentials of the password auth provider.
+// Login returns a single use code that the provider exchanges for the
+// credential when the auth service verifies it. Signup and Forgot email a
+// link to the user instead. Reset signs the user of the credential out everywhere.
+type Service interface {
+	Login(ctx context.Context, provider sdk.AuthProvider, email, password, ip string) (string, error)
+	Signup(ctx context.Context, provider sdk.AuthProvider, req sdk.PasswordSignupRequest) error
+	Verify(ctx context.Context, token string) error
+	ExchangeCode(ctx context.Context, code string) (*sdk.PasswordCredential, error)
+	Get(ctx context.Context, id string) (*sdk.PasswordCredential, error)
+	Change(ctx context.Context, projectId, email string, req sdk.PasswordChangeRequest) error
+	Forgot(ctx context.Context, provider sdk.AuthProvider, email string) error
+	Reset(ctx context.Context, req sdk.PasswordResetRequest) error
+}
@@ -0,0 +1,380 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/services/email"
+	"github.com/melvinodsa/go-iam/services/identity"
+	"github.com/melvinodsa/go-iam/services/project"
+	"github.com/melvinodsa/go-iam/services/refreshtoken"
+)
+
+const (
+	// loginCodeTTL is the time within which the auth service has to exchange a login code
+	loginCodeTTL = time.Minute * 5
+)
+
+var errInvalidToken = errors.New("unknown, expired or used token")
+
+type service struct {
+	store         Store
+	projectSvc    project.Service
+	cacheSvc      cache.Service
+	emailSvc      email.Service
+	refreshSvc    refreshtoken.Service
+	identitySvc   identity.Service
+	throttle      cache.Throttle
+	tokenTTL      time.Duration
+	revocationTTL time.Duration
+}
+
+// NewService creates the password service. tokenTTL is the validity of the links sent by email.
+// revocationTTL is how long the sessions revoked by a password reset are remembered, until every access token issued before it has expired.
+func NewService(store Store, projectSvc project.Service, cacheSvc cache.Service, emailSvc email.Service, refreshSvc refreshtoken.Service, identitySvc identity.Service, tokenTTL, revocationTTL time.Duration) Service {
+	return service{
+		store:         store,
+		projectSvc:    projectSvc,
+		cacheSvc:      cacheSvc,
+		emailSvc:      emailSvc,
+		refreshSvc:    refreshSvc,
+		identitySvc:   identitySvc,
+		throttle:      cache.NewThrottle(cacheSvc, cache.FailedAttemptWindow),
+		tokenTTL:      tokenTTL,
+		revocationTTL: revocationTTL,
+	}
+}
+
+func (s service) Login(ctx context.Context, provider sdk.AuthProvider, email, password, ip string) (string, error) {
+	/*
+	 * an email or ip address with too many failed logins waits until the window ends
+	 * find the credential of the email in the project of the provider
+	 * compare the password with its hash, a wrong password or an unknown email counts as a failed login
+	 * and the right one clears the failed logins of the email
+	 * only verified email addresses can login
+	 * issue a single use code for the auth provider
+	 */
+	if provider.Provider != sdk.AuthProviderTypePassword {
+		return "", sdk.ErrNotPasswordProvider
+	}
+	emailId := normalizeEmail(email)
+	emailKey := fmt.Sprintf("password-attempts-email-%s-%s", provider.ProjectId, hashToken(emailId))
+	attemptKeys := map[string]int{emailKey: cache.MaxFailedAttemptsPerAccount}
+	if len(ip) > 0 {
+		attemptKeys[fmt.Sprintf("password-attempts-ip-%s", ip)] = cache.MaxFailedAttemptsPerIp
+	}
+	failed, limited := s.throttle.Limited(ctx, attemptKeys)
+	if limited {
+		return "", sdk.ErrTooManyLoginAttempts
+	}
+
+	credential, err := s.store.GetByEmail(ctx, provider.ProjectId, emailId)
+	if errors.Is(err, sdk.ErrPasswordCredentialNotFound) {
+		spendHashTime(password)
+		return "", s.failLogin(ctx, attemptKeys)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching the credential %w", err)
+	}
+	if !checkPassword(credential.PasswordHash, password) {
+		return "", s.failLogin(ctx, attemptKeys)
+	}
+	if failed[emailKey] > 0 {
+		err = s.throttle.Clear(ctx, emailKey)
+		if err != nil {
+			return "", err
+		}
+	}
+	if credential.VerifiedAt == nil {
+		return "", sdk.ErrEmailNotVerified
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.cacheSvc.Set(ctx, fmt.Sprintf("password-code-%s", hashToken(code)), credential.Id, loginCodeTTL)
+	if err != nil {
+		return "", fmt.Errorf("error caching the login code %w", err)
+	}
+	return code, nil
+}
+
+func (s service) Signup(ctx context.Context, provider sdk.AuthProvider, req sdk.PasswordSignupRequest) error {
+	/*
+	 * the provider has to allow signups
+	 * validate the email and the password against the policy of the project
+	 * an existing account only gets a notice by email, the response does not tell it apart from a new signup
+	 * save the credential as unverified
+	 * email the verification link. a user cannot login with the password of
+	 * someone else's email address, since the users are matched by email
+	 */
+	if provider.Provider != sdk.AuthProviderTypePassword {
+		return sdk.ErrNotPasswordProvider
+	}
+	if provider.GetParam(sdk.PasswordParamSignupEnabled) != "true" {
+		return sdk.ErrSignupDisabled
+	}
+	addr, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		return fmt.Errorf("%w: %w", sdk.ErrInvalidEmail, err)
+	}
+	emailId := normalizeEmail(addr.Address)
+	err = s.checkPolicy(ctx, provider.ProjectId, req.Password)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.store.GetByEmail(ctx, provider.ProjectId, emailId)
+	if err == nil {
+		// the signup looks the same as a new one, the owner of the account is told about it by email
+		spendHashTime(req.Password)
+		return s.sendNotice(ctx, existing.Email, "Sign up attempt for your account",
+			"Someone tried to sign up with your email address, which already has an account. Log in with your password, or reset it from the login page if you forgot it. Ignore this email if it was not you.")
+	}
+	if !errors.Is(err, sdk.ErrPasswordCredentialNotFound) {
+		return fmt.Errorf("error fetching the credential %w", err)
+	}
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	credential := &sdk.PasswordCredential{
+		Id:             uuid.NewString(),
+		ProjectId:      provider.ProjectId,
+		AuthProviderId: provider.Id,
+		Email:          emailId,
+		Name:           req.Name,
+		PasswordHash:   hash,
+		CreatedAt:      &now,
+		UpdatedAt:      &now,
+	}
+	err = s.store.Create(ctx, credential)
+	if err != nil {
+		return fmt.Errorf("error saving the credential %w", err)
+	}
+
+	return s.sendLink(ctx, "verify", provider.GetParam(sdk.PasswordParamVerifyUrl), *credential,
+		"Verify your email address",
+		"Open the link below to verify your email address.\n\n%s\n\nThe link expires in %d minutes.")
+}
+
+func (s service) Verify(ctx context.Context, token string) error {
+	credential, err := s.consumeToken(ctx, "verify", token)
+	if err != nil {
+		if errors.Is(err, errInvalidToken) {
+			return fmt.Errorf("%w: %w", sdk.ErrInvalidVerificationToken, err)
+		}
+		return err
+	}
+	if credential.VerifiedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	credential.VerifiedAt = &now
+	credential.UpdatedAt = &now
+	err = s.store.Update(ctx, credential)
+	if err != nil {
+		return fmt.Errorf("error verifying the credential %w", err)
+	}
+	return nil
+}
+
+func (s service) ExchangeCode(ctx context.Context, code string) (*sdk.PasswordCredential, error) {
+	/*
+	 * the code is single use, it is removed before the credential is returned
+	 */
+	key := fmt.Sprintf("password-code-%s", hashToken(code))
+	id, err := s.cacheSvc.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown or expired login code", sdk.ErrInvalidCredentials)
+	}
+	err = s.cacheSvc.Delete(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("error invalidating the login code %w", err)
+	}
+	return s.store.Get(ctx, id)
+}
+
+func (s service) Get(ctx context.Context, id string) (*sdk.PasswordCredential, error) {
+	return s.store.Get(ctx, id)
+}
+
+func (s service) Change(ctx context.Context, projectId, email string, req sdk.PasswordChangeRequest) error {
+	/*
+	 * users who never set a password cannot change it
+	 * check the current password
+	 * validate the new password against the policy of the project
+	 * save the new hash
+	 */
+	credential, err := s.store.GetByEmail(ctx, projectId, normalizeEmail(email))
+	if err != nil {
+		return err
+	}
+	if !checkPassword(credential.PasswordHash, req.CurrentPassword) {
+		return sdk.ErrInvalidCredentials
+	}
+	return s.setPassword(ctx, credential, req.NewPassword)
+}
+
+func (s service) Forgot(ctx context.Context, provider sdk.AuthProvider, email string) error {
+	/*
+	 * find the credential of the email in the project of the provider
+	 * unknown emails are ignored so that accounts cannot be enumerated
+	 * email the password reset link
+	 */
+	if provider.Provider != sdk.AuthProviderTypePassword {
+		return sdk.ErrNotPasswordProvider
+	}
+	credential, err := s.store.GetByEmail(ctx, provider.ProjectId, normalizeEmail(email))
+	if errors.Is(err, sdk.ErrPasswordCredentialNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching the credential %w", err)
+	}
+	return s.sendLink(ctx, "reset", provider.GetParam(sdk.PasswordParamResetUrl), *credential,
+		"Reset your password",
+		"Open the link below to choose a new password.\n\n%s\n\nThe link expires in %d minutes. Ignore this email if you did not ask for it.")
+}
+
+func (s service) Reset(ctx context.Context, req sdk.PasswordResetRequest) error {
+	/*
+	 * consume the reset token
+	 * validate the new password against the policy of the project
+	 * save the new hash. the reset link proves the email address, so the credential gets verified
+	 * sign the user of the credential out everywhere, whoever knew the old password loses their sessions
+	 */
+	credential, err := s.consumeToken(ctx, "reset", req.Token)
+	if err != nil {
+		if errors.Is(err, errInvalidToken) {
+			return fmt.Errorf("%w: %w", sdk.ErrInvalidPasswordResetToken, err)
+		}
+		return err
+	}
+	if credential.VerifiedAt == nil {
+		now := time.Now()
+		credential.VerifiedAt = &now
+	}
+	err = s.setPassword(ctx, credential, req.Password)
+	if err != nil {
+		return err
+	}
+	return s.revokeSessions(ctx, *credential)
+}
+
+// revokeSessions revokes the refresh tokens of the user logging in with the credential and records the
+// revocation time, access tokens issued before it are rejected. A credential that never logged in has no user.
+func (s service) revokeSessions(ctx context.Context, credential sdk.PasswordCredential) error {
+	linked, err := s.identitySvc.Get(ctx, credential.AuthProviderId, credential.Email)
+	if errors.Is(err, sdk.ErrUserIdentityNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching the user of the credential %w", err)
+	}
+	err = s.refreshSvc.RevokeUser(ctx, linked.UserId)
+	if err != nil {
+		return fmt.Errorf("error revoking the refresh tokens %w", err)
+	}
+	err = s.cacheSvc.Set(ctx, fmt.Sprintf("revoked-user-%s", linked.UserId), strconv.FormatInt(time.Now().Unix(), 10), s.revocationTTL)
+	if err != nil {
+		return fmt.Errorf("error saving the revocation %w", err)
+	}
+	return nil
+}
+
+func (s service) setPassword(ctx context.Context, credential *sdk.PasswordCredential, password string) error {
+	err := s.checkPolicy(ctx, credential.ProjectId, password)
+	if err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	credential.PasswordHash = hash
+	credential.UpdatedAt = &now
+	err = s.store.Update(ctx, credential)
+	if err != nil {
+		return fmt.Errorf("error saving the password %w", err)
+	}
+	return nil
+}
+
+func (s service) checkPolicy(ctx context.Context, projectId, password string) error {
+	p, err := s.projectSvc.Get(ctx, projectId)
+	if err != nil {
+		return fmt.Errorf("error fetching the project %w", err)
+	}
+	return p.GetPasswordPolicy().Check(password)
+}
+
+// sendNotice emails a message without a link to the user
+func (s service) sendNotice(ctx context.Context, to, subject, message string) error {
+	err := s.emailSvc.Send(ctx, to, subject, message)
+	if err != nil {
+		return fmt.Errorf("error sending the notice email %w", err)
+	}
+	return nil
+}
+
+// failLogin counts a failed login against the keys and returns the error of the wrong credentials
+func (s service) failLogin(ctx context.Context, attemptKeys map[string]int) error {
+	err := s.throttle.Fail(ctx, attemptKeys)
+	if err != nil {
+		return err
+	}
+	return sdk.ErrInvalidCredentials
+}
+
+// sendLink emails a single use link to the page. The message is a format string taking the link and its validity in minutes.
+func (s service) sendLink(ctx context.Context, purpose, pageUrl string, credential sdk.PasswordCredential, subject, message string) error {
+	if len(pageUrl) == 0 {
+		return fmt.Errorf("the %s page url is not configured for the auth provider", purpose)
+	}
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	link, err := buildLink(pageUrl, token)
+	if err != nil {
+		return err
+	}
+	err = s.cacheSvc.Set(ctx, fmt.Sprintf("password-%s-%s", purpose, hashToken(token)), credential.Id, s.tokenTTL)
+	if err != nil {
+		return fmt.Errorf("error caching the %s token %w", purpose, err)
+	}
+	err = s.emailSvc.Send(ctx, credential.Email, subject, fmt.Sprintf(message, link, int(s.tokenTTL.Minutes())))
+	if err != nil {
+		return fmt.Errorf("error sending the %s email %w", purpose, err)
+	}
+	return nil
+}
+
+// consumeToken returns the credential of a token sent by email and invalidates the token
+func (s service) consumeToken(ctx context.Context, purpose, token string) (*sdk.PasswordCredential, error) {
+	key := fmt.Sprintf("password-%s-%s", purpose, hashToken(token))
+	id, err := s.cacheSvc.Get(ctx, key)
+	if err != nil || len(id) == 0 {
+		return nil, errInvalidToken
+	}
+	err = s.cacheSvc.Delete(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("error invalidating the %s token %w", purpose, err)
+	}
+	credential, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the credential %w", err)
+	}
+	return credential, nil
+}
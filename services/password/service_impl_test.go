@@ -0,0 +1,418 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/utils/test/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func init() {
+	bcryptCost = bcrypt.MinCost
+}
+
+// MockStore implements Store interface for testing
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) Get(ctx context.Context, id string) (*sdk.PasswordCredential, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.PasswordCredential), args.Error(1)
+}
+
+func (m *MockStore) GetByEmail(ctx context.Context, projectId, email string) (*sdk.PasswordCredential, error) {
+	args := m.Called(ctx, projectId, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.PasswordCredential), args.Error(1)
+}
+
+func (m *MockStore) Create(ctx context.Context, credential *sdk.PasswordCredential) error {
+	args := m.Called(ctx, credential)
+	return args.Error(0)
+}
+
+func (m *MockStore) Update(ctx context.Context, credential *sdk.PasswordCredential) error {
+	args := m.Called(ctx, credential)
+	return args.Error(0)
+}
+
+// recordingEmailService keeps the emails instead of sending them
+type recordingEmailService struct {
+	to, subject, body string
+}
+
+func (r *recordingEmailService) Send(ctx context.Context, to, subject, body string) error {
+	r.to, r.subject, r.body = to, subject, body
+	return nil
+}
+
+var tokenInLink = regexp.MustCompile(`token=(\S+)`)
+
+// linkToken returns the token of the link in the last email
+func (r *recordingEmailService) linkToken(t *testing.T) string {
+	m := tokenInLink.FindStringSubmatch(r.body)
+	require.Len(t, m, 2)
+	token, err := url.QueryUnescape(m[1])
+	require.NoError(t, err)
+	return token
+}
+
+var passwordProvider = sdk.AuthProvider{
+	Id:        "provider-1",
+	Provider:  sdk.AuthProviderTypePassword,
+	ProjectId: "project-1",
+	Params: []sdk.AuthProviderParam{
+		{Key: sdk.PasswordParamSignupEnabled, Value: "true"},
+		{Key: sdk.PasswordParamVerifyUrl, Value: "https://app.example.com/verify"},
+		{Key: sdk.PasswordParamResetUrl, Value: "https://app.example.com/reset"},
+	},
+}
+
+func setupTestService() (service, *MockStore, *services.MockProjectService, *recordingEmailService) {
+	store := &MockStore{}
+	projectSvc := &services.MockProjectService{}
+	projectSvc.On("Get", mock.Anything, "project-1").Return(&sdk.Project{Id: "project-1"}, nil)
+	emailSvc := &recordingEmailService{}
+	identitySvc := &services.MockIdentityService{}
+	identitySvc.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(nil, sdk.ErrUserIdentityNotFound).Maybe()
+	svc := NewService(store, projectSvc, cache.NewMockService(), emailSvc, &services.MockRefreshTokenService{}, identitySvc, time.Minute*30, time.Hour).(service)
+	return svc, store, projectSvc, emailSvc
+}
+
+func verifiedCredential(t *testing.T, password string) *sdk.PasswordCredential {
+	hash, err := hashPassword(password)
+	require.NoError(t, err)
+	now := time.Now()
+	return &sdk.PasswordCredential{Id: "cred-1", ProjectId: "project-1", Email: "user@example.com", PasswordHash: hash, VerifiedAt: &now}
+}
+
+func TestNewService(t *testing.T) {
+	svc := NewService(&MockStore{}, &services.MockProjectService{}, cache.NewMockService(), &recordingEmailService{}, &services.MockRefreshTokenService{}, &services.MockIdentityService{}, time.Minute, time.Hour)
+
+	assert.NotNil(t, svc)
+	assert.Implements(t, (*Service)(nil), svc)
+}
+
+func TestService_Login(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success issues a single use code", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+		credential := verifiedCredential(t, "correct-horse")
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(credential, nil)
+		store.On("Get", ctx, "cred-1").Return(credential, nil)
+
+		code, err := svc.Login(ctx, passwordProvider, " User@Example.com ", "correct-horse", "10.0.0.1")
+		require.NoError(t, err)
+
+		exchanged, err := svc.ExchangeCode(ctx, code)
+		require.NoError(t, err)
+		assert.Equal(t, "cred-1", exchanged.Id)
+
+		_, err = svc.ExchangeCode(ctx, code)
+		assert.ErrorIs(t, err, sdk.ErrInvalidCredentials)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(verifiedCredential(t, "correct-horse"), nil)
+
+		_, err := svc.Login(ctx, passwordProvider, "user@example.com", "wrong", "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrInvalidCredentials)
+	})
+
+	t.Run("unknown email looks like a wrong password", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", "nobody@example.com").Return(nil, sdk.ErrPasswordCredentialNotFound)
+
+		_, err := svc.Login(ctx, passwordProvider, "nobody@example.com", "whatever", "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrInvalidCredentials)
+	})
+
+	t.Run("too many failed logins of an email", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(verifiedCredential(t, "correct-horse"), nil)
+
+		for i := 0; i < cache.MaxFailedAttemptsPerAccount; i++ {
+			_, err := svc.Login(ctx, passwordProvider, "user@example.com", "wrong", fmt.Sprintf("10.0.0.%d", i))
+			assert.ErrorIs(t, err, sdk.ErrInvalidCredentials)
+		}
+		_, err := svc.Login(ctx, passwordProvider, "User@Example.com", "correct-horse", "10.0.1.1")
+		assert.ErrorIs(t, err, sdk.ErrTooManyLoginAttempts)
+		store.AssertNumberOfCalls(t, "GetByEmail", cache.MaxFailedAttemptsPerAccount)
+	})
+
+	t.Run("too many failed logins from an ip address", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", mock.AnythingOfType("string")).Return(nil, sdk.ErrPasswordCredentialNotFound)
+
+		for i := 0; i < cache.MaxFailedAttemptsPerIp; i++ {
+			_, err := svc.Login(ctx, passwordProvider, fmt.Sprintf("user-%d@example.com", i), "whatever", "10.0.0.1")
+			assert.ErrorIs(t, err, sdk.ErrInvalidCredentials)
+		}
+		_, err := svc.Login(ctx, passwordProvider, "other@example.com", "whatever", "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrTooManyLoginAttempts)
+	})
+
+	t.Run("successful login clears the failed logins of the email", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(verifiedCredential(t, "correct-horse"), nil)
+
+		for i := 0; i < cache.MaxFailedAttemptsPerAccount-1; i++ {
+			_, err := svc.Login(ctx, passwordProvider, "user@example.com", "wrong", "")
+			assert.ErrorIs(t, err, sdk.ErrInvalidCredentials)
+		}
+		_, err := svc.Login(ctx, passwordProvider, "user@example.com", "correct-horse", "")
+		require.NoError(t, err)
+		_, err = svc.Login(ctx, passwordProvider, "user@example.com", "wrong", "")
+		assert.ErrorIs(t, err, sdk.ErrInvalidCredentials)
+	})
+
+	t.Run("unverified email", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+		credential := verifiedCredential(t, "correct-horse")
+		credential.VerifiedAt = nil
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(credential, nil)
+
+		_, err := svc.Login(ctx, passwordProvider, "user@example.com", "correct-horse", "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrEmailNotVerified)
+	})
+
+	t.Run("other provider types", func(t *testing.T) {
+		svc, _, _, _ := setupTestService()
+
+		_, err := svc.Login(ctx, sdk.AuthProvider{Provider: sdk.AuthProviderTypeGoogle}, "user@example.com", "correct-horse", "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrNotPasswordProvider)
+	})
+}
+
+func TestService_Signup(t *testing.T) {
+	ctx := context.Background()
+	req := sdk.PasswordSignupRequest{Email: "New.User@example.com", Name: "New User", Password: "correct-horse"}
+
+	t.Run("success saves an unverified credential and emails the verification link", func(t *testing.T) {
+		svc, store, _, emailSvc := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", "new.user@example.com").Return(nil, sdk.ErrPasswordCredentialNotFound)
+		var created *sdk.PasswordCredential
+		store.On("Create", ctx, mock.MatchedBy(func(c *sdk.PasswordCredential) bool {
+			created = c
+			return c.Email == "new.user@example.com" && c.VerifiedAt == nil && checkPassword(c.PasswordHash, "correct-horse")
+		})).Return(nil)
+
+		err := svc.Signup(ctx, passwordProvider, req)
+		require.NoError(t, err)
+		assert.Equal(t, "new.user@example.com", emailSvc.to)
+		assert.Contains(t, emailSvc.body, "https://app.example.com/verify?token=")
+
+		// the link verifies the email address once
+		store.On("Get", ctx, created.Id).Return(created, nil)
+		store.On("Update", ctx, mock.MatchedBy(func(c *sdk.PasswordCredential) bool { return c.VerifiedAt != nil })).Return(nil).Once()
+		token := emailSvc.linkToken(t)
+		require.NoError(t, svc.Verify(ctx, token))
+		assert.ErrorIs(t, svc.Verify(ctx, token), sdk.ErrInvalidVerificationToken)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		svc, _, _, _ := setupTestService()
+		provider := passwordProvider
+		provider.Params = nil
+
+		err := svc.Signup(ctx, provider, req)
+		assert.ErrorIs(t, err, sdk.ErrSignupDisabled)
+	})
+
+	t.Run("weak password", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+
+		err := svc.Signup(ctx, passwordProvider, sdk.PasswordSignupRequest{Email: "new@example.com", Password: "short"})
+		assert.ErrorIs(t, err, sdk.ErrWeakPassword)
+		store.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid email", func(t *testing.T) {
+		svc, _, _, _ := setupTestService()
+
+		err := svc.Signup(ctx, passwordProvider, sdk.PasswordSignupRequest{Email: "not an email", Password: "correct-horse"})
+		assert.ErrorIs(t, err, sdk.ErrInvalidEmail)
+	})
+
+	t.Run("existing account looks like a new signup", func(t *testing.T) {
+		svc, store, _, emailSvc := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", "new.user@example.com").Return(&sdk.PasswordCredential{Id: "cred-1", Email: "new.user@example.com"}, nil)
+
+		err := svc.Signup(ctx, passwordProvider, req)
+		require.NoError(t, err)
+		assert.Equal(t, "new.user@example.com", emailSvc.to)
+		assert.NotContains(t, emailSvc.body, "token=")
+		store.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_Change(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(verifiedCredential(t, "correct-horse"), nil)
+		store.On("Update", ctx, mock.MatchedBy(func(c *sdk.PasswordCredential) bool {
+			return checkPassword(c.PasswordHash, "battery-staple")
+		})).Return(nil)
+
+		err := svc.Change(ctx, "project-1", "user@example.com", sdk.PasswordChangeRequest{CurrentPassword: "correct-horse", NewPassword: "battery-staple"})
+		assert.NoError(t, err)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("wrong current password", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(verifiedCredential(t, "correct-horse"), nil)
+
+		err := svc.Change(ctx, "project-1", "user@example.com", sdk.PasswordChangeRequest{CurrentPassword: "wrong", NewPassword: "battery-staple"})
+		assert.ErrorIs(t, err, sdk.ErrInvalidCredentials)
+		store.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("new password breaks the project policy", func(t *testing.T) {
+		svc, store, projectSvc, _ := setupTestService()
+		projectSvc.ExpectedCalls = nil
+		projectSvc.On("Get", mock.Anything, "project-1").Return(&sdk.Project{Id: "project-1", PasswordPolicy: &sdk.PasswordPolicy{MinLength: 8, RequireNumber: true}}, nil)
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(verifiedCredential(t, "correct-horse"), nil)
+
+		err := svc.Change(ctx, "project-1", "user@example.com", sdk.PasswordChangeRequest{CurrentPassword: "correct-horse", NewPassword: "battery-staple"})
+		assert.ErrorIs(t, err, sdk.ErrWeakPassword)
+	})
+
+	t.Run("user without a password", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(nil, sdk.ErrPasswordCredentialNotFound)
+
+		err := svc.Change(ctx, "project-1", "user@example.com", sdk.PasswordChangeRequest{CurrentPassword: "x", NewPassword: "battery-staple"})
+		assert.ErrorIs(t, err, sdk.ErrPasswordCredentialNotFound)
+	})
+}
+
+func TestService_ForgotAndReset(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reset link sets the password once", func(t *testing.T) {
+		svc, store, _, emailSvc := setupTestService()
+		credential := verifiedCredential(t, "correct-horse")
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(credential, nil)
+		store.On("Get", ctx, "cred-1").Return(credential, nil)
+		store.On("Update", ctx, mock.MatchedBy(func(c *sdk.PasswordCredential) bool {
+			return checkPassword(c.PasswordHash, "battery-staple")
+		})).Return(nil).Once()
+
+		err := svc.Forgot(ctx, passwordProvider, "user@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "Reset your password", emailSvc.subject)
+		token := emailSvc.linkToken(t)
+
+		err = svc.Reset(ctx, sdk.PasswordResetRequest{Token: token, Password: "battery-staple"})
+		require.NoError(t, err)
+		err = svc.Reset(ctx, sdk.PasswordResetRequest{Token: token, Password: "battery-staple"})
+		assert.ErrorIs(t, err, sdk.ErrInvalidPasswordResetToken)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("reset signs the user out everywhere", func(t *testing.T) {
+		svc, store, _, emailSvc := setupTestService()
+		credential := verifiedCredential(t, "correct-horse")
+		credential.AuthProviderId = "provider-1"
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(credential, nil)
+		store.On("Get", ctx, "cred-1").Return(credential, nil)
+		store.On("Update", ctx, mock.Anything).Return(nil)
+		identitySvc := svc.identitySvc.(*services.MockIdentityService)
+		identitySvc.ExpectedCalls = nil
+		identitySvc.On("Get", ctx, "provider-1", "user@example.com").Return(&sdk.UserIdentity{UserId: "user-1"}, nil)
+		refreshSvc := svc.refreshSvc.(*services.MockRefreshTokenService)
+		refreshSvc.On("RevokeUser", ctx, "user-1").Return(nil).Once()
+
+		before := time.Now().Unix()
+		require.NoError(t, svc.Forgot(ctx, passwordProvider, "user@example.com"))
+		err := svc.Reset(ctx, sdk.PasswordResetRequest{Token: emailSvc.linkToken(t), Password: "battery-staple"})
+		require.NoError(t, err)
+
+		refreshSvc.AssertExpectations(t)
+		revokedAt, err := svc.cacheSvc.Get(ctx, "revoked-user-user-1")
+		require.NoError(t, err)
+		at, err := strconv.ParseInt(revokedAt, 10, 64)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, at, before)
+	})
+
+	t.Run("reset fails when the sessions cannot be revoked", func(t *testing.T) {
+		svc, store, _, emailSvc := setupTestService()
+		credential := verifiedCredential(t, "correct-horse")
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(credential, nil)
+		store.On("Get", ctx, "cred-1").Return(credential, nil)
+		store.On("Update", ctx, mock.Anything).Return(nil)
+		identitySvc := svc.identitySvc.(*services.MockIdentityService)
+		identitySvc.ExpectedCalls = nil
+		identitySvc.On("Get", ctx, "", "user@example.com").Return(&sdk.UserIdentity{UserId: "user-1"}, nil)
+		svc.refreshSvc.(*services.MockRefreshTokenService).On("RevokeUser", ctx, "user-1").Return(errors.New("connection reset"))
+
+		require.NoError(t, svc.Forgot(ctx, passwordProvider, "user@example.com"))
+		err := svc.Reset(ctx, sdk.PasswordResetRequest{Token: emailSvc.linkToken(t), Password: "battery-staple"})
+		assert.ErrorContains(t, err, "error revoking the refresh tokens")
+	})
+
+	t.Run("unknown email is ignored", func(t *testing.T) {
+		svc, store, _, emailSvc := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", "nobody@example.com").Return(nil, sdk.ErrPasswordCredentialNotFound)
+
+		err := svc.Forgot(ctx, passwordProvider, "nobody@example.com")
+		assert.NoError(t, err)
+		assert.Empty(t, emailSvc.to)
+	})
+
+	t.Run("reset page not configured", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(verifiedCredential(t, "correct-horse"), nil)
+		provider := passwordProvider
+		provider.Params = nil
+
+		err := svc.Forgot(ctx, provider, "user@example.com")
+		assert.ErrorContains(t, err, "reset page url is not configured")
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		svc, _, _, _ := setupTestService()
+
+		err := svc.Reset(ctx, sdk.PasswordResetRequest{Token: "made-up", Password: "battery-staple"})
+		assert.ErrorIs(t, err, sdk.ErrInvalidPasswordResetToken)
+	})
+
+	t.Run("store failure", func(t *testing.T) {
+		svc, store, _, _ := setupTestService()
+		store.On("GetByEmail", ctx, "project-1", "user@example.com").Return(nil, errors.New("connection reset"))
+
+		err := svc.Forgot(ctx, passwordProvider, "user@example.com")
+		assert.ErrorContains(t, err, "error fetching the credential")
+	})
+}
+
+func TestBuildLink(t *testing.T) {
+	link, err := buildLink("https://app.example.com/reset?lang=en", "a-b_c")
+	require.NoError(t, err)
+	assert.Equal(t, "https://app.example.com/reset?lang=en&token=a-b_c", link)
+}
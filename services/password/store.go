@@ -0,0 +1,15 @@
+package password
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+type Store interface {
+	Get(ctx context.Context, id string) (*sdk.PasswordCredential, error)
+	GetByEmail(ctx context.Context, projectId, email string) (*sdk.PasswordCredential, error)
+	Create(ctx context.Context, credential *sdk.PasswordCredential) error
+	// Update saves the password hash and the verification time of the credential
+	Update(ctx context.Context, credential *sdk.PasswordCredential) error
+}
@@ -0,0 +1,72 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/melvinodsa/go-iam/db"
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type store struct {
+	db db.DB
+}
+
+// NewStore creates a password credential store backed by mongo.
+func NewStore(db db.DB) Store {
+	return store{db: db}
+}
+
+func (s store) Get(ctx context.Context, id string) (*sdk.PasswordCredential, error) {
+	md := models.GetPasswordCredentialModel()
+	return s.findOne(ctx, bson.D{{Key: md.IdKey, Value: id}})
+}
+
+func (s store) GetByEmail(ctx context.Context, projectId, email string) (*sdk.PasswordCredential, error) {
+	md := models.GetPasswordCredentialModel()
+	return s.findOne(ctx, bson.D{{Key: md.ProjectIdKey, Value: projectId}, {Key: md.EmailKey, Value: email}})
+}
+
+func (s store) findOne(ctx context.Context, filter bson.D) (*sdk.PasswordCredential, error) {
+	md := models.GetPasswordCredentialModel()
+	var credential models.PasswordCredential
+	err := s.db.FindOne(ctx, md, filter).Decode(&credential)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, sdk.ErrPasswordCredentialNotFound
+		}
+		return nil, fmt.Errorf("error finding password credential: %w", err)
+	}
+	result := fromModelToSdk(credential)
+	return &result, nil
+}
+
+func (s store) Create(ctx context.Context, credential *sdk.PasswordCredential) error {
+	md := models.GetPasswordCredentialModel()
+	_, err := s.db.InsertOne(ctx, md, fromSdkToModel(*credential))
+	if err != nil {
+		return fmt.Errorf("error creating password credential: %w", err)
+	}
+	return nil
+}
+
+func (s store) Update(ctx context.Context, credential *sdk.PasswordCredential) error {
+	md := models.GetPasswordCredentialModel()
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: md.PasswordHashKey, Value: credential.PasswordHash},
+		{Key: md.VerifiedAtKey, Value: credential.VerifiedAt},
+		{Key: md.UpdatedAtKey, Value: credential.UpdatedAt},
+	}}}
+	res, err := s.db.UpdateOne(ctx, md, bson.D{{Key: md.IdKey, Value: credential.Id}}, update)
+	if err != nil {
+		return fmt.Errorf("error updating password credential: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return sdk.ErrPasswordCredentialNotFound
+	}
+	return nil
+}
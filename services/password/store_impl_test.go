@@ -0,0 +1,111 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewStore(t *testing.T) {
+	store := NewStore(test.SetupMockDB())
+
+	assert.NotNil(t, store)
+	assert.Implements(t, (*Store)(nil), store)
+}
+
+func TestStore_GetByEmail(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetPasswordCredentialModel()
+	filter := bson.D{{Key: md.ProjectIdKey, Value: "project-1"}, {Key: md.EmailKey, Value: "user@example.com"}}
+
+	t.Run("success", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		document := bson.D{
+			{Key: md.IdKey, Value: "cred-1"},
+			{Key: md.ProjectIdKey, Value: "project-1"},
+			{Key: md.EmailKey, Value: "user@example.com"},
+			{Key: md.PasswordHashKey, Value: "hash"},
+		}
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(document, nil, nil))
+
+		credential, err := NewStore(mockDB).GetByEmail(ctx, "project-1", "user@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "cred-1", credential.Id)
+		assert.Equal(t, "hash", credential.PasswordHash)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil))
+
+		credential, err := NewStore(mockDB).GetByEmail(ctx, "project-1", "user@example.com")
+		assert.ErrorIs(t, err, sdk.ErrPasswordCredentialNotFound)
+		assert.Nil(t, credential)
+	})
+}
+
+func TestStore_Get(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetPasswordCredentialModel()
+	mockDB := test.SetupMockDB()
+	mockDB.On("FindOne", ctx, md, bson.D{{Key: md.IdKey, Value: "cred-1"}}, mock.Anything).
+		Return(mongo.NewSingleResultFromDocument(bson.D{}, errors.New("connection reset"), nil))
+
+	credential, err := NewStore(mockDB).Get(ctx, "cred-1")
+	assert.ErrorContains(t, err, "error finding password credential")
+	assert.Nil(t, credential)
+}
+
+func TestStore_Create(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetPasswordCredentialModel()
+	mockDB := test.SetupMockDB()
+	mockDB.On("InsertOne", ctx, md, mock.MatchedBy(func(c models.PasswordCredential) bool {
+		return c.Id == "cred-1" && c.PasswordHash == "hash"
+	}), mock.Anything).Return(&mongo.InsertOneResult{}, nil)
+
+	err := NewStore(mockDB).Create(ctx, &sdk.PasswordCredential{Id: "cred-1", PasswordHash: "hash"})
+	assert.NoError(t, err)
+	mockDB.AssertExpectations(t)
+}
+
+func TestStore_Update(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetPasswordCredentialModel()
+	now := time.Now()
+	credential := &sdk.PasswordCredential{Id: "cred-1", PasswordHash: "new-hash", VerifiedAt: &now, UpdatedAt: &now}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: md.PasswordHashKey, Value: "new-hash"},
+		{Key: md.VerifiedAtKey, Value: &now},
+		{Key: md.UpdatedAtKey, Value: &now},
+	}}}
+
+	t.Run("success", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("UpdateOne", ctx, md, bson.D{{Key: md.IdKey, Value: "cred-1"}}, update, mock.Anything).
+			Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+		err := NewStore(mockDB).Update(ctx, credential)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("UpdateOne", ctx, md, bson.D{{Key: md.IdKey, Value: "cred-1"}}, update, mock.Anything).
+			Return(&mongo.UpdateResult{}, nil)
+
+		err := NewStore(mockDB).Update(ctx, credential)
+		assert.ErrorIs(t, err, sdk.ErrPasswordCredentialNotFound)
+	})
+}
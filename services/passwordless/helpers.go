@@ -0,0 +1,132 @@
+package passwordless
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+const (
+	defaultCodeSubject  = "Your login code"
+	defaultLinkSubject  = "Your login link"
+	defaultCodeTemplate = "Your login code is {{.Code}}\n\nIt expires in {{.ExpiresInMinutes}} minutes. Ignore this email if you did not try to log in."
+	defaultLinkTemplate = "Open the link below to log in.\n\n{{.Link}}\n\nThe link expires in {{.ExpiresInMinutes}} minutes. Ignore this email if you did not try to log in."
+	defaultSmsTemplate  = "{{.Code}} is your login code. It expires in {{.ExpiresInMinutes}} minutes."
+)
+
+// challenge is a pending passwordless login, cached until it is verified or expires
+type challenge struct {
+	Address   string    `json:"address"` // Email address or phone number the code was sent to
+	State     string    `json:"state"`
+	OtpHash   string    `json:"otp_hash,omitempty"`
+	Attempts  int       `json:"attempts"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// rateWindow counts the login codes requested within a fixed window
+type rateWindow struct {
+	Count   int       `json:"count"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// messageData is passed to the email and sms templates
+type messageData struct {
+	Email            string
+	Phone            string
+	Code             string
+	Link             string
+	ExpiresInMinutes int
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// randomOtp returns a 6 digit code
+func randomOtp() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("error generating the login code %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// randomToken returns a url safe token with 256 bits of entropy
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("error generating the token %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is used in place of the codes and tokens in the cache, so that the raw values are not stored
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// buildLink adds the token to the query of the page url
+func buildLink(pageUrl, token string) (string, error) {
+	u, err := url.Parse(pageUrl)
+	if err != nil {
+		return "", fmt.Errorf("error parsing the magic link page url %w", err)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// renderEmail returns the subject and the body of the email, using the templates of the provider when it has them
+func renderEmail(provider sdk.AuthProvider, method string, data messageData) (string, string, error) {
+	subject, body := defaultCodeSubject, defaultCodeTemplate
+	if method == sdk.PasswordlessMethodLink {
+		subject, body = defaultLinkSubject, defaultLinkTemplate
+	}
+	if s := provider.GetParam(sdk.PasswordlessParamEmailSubject); len(s) > 0 {
+		subject = s
+	}
+	if b := provider.GetParam(sdk.PasswordlessParamEmailTemplate); len(b) > 0 {
+		body = b
+	}
+
+	body, err := renderTemplate("email", body, data)
+	if err != nil {
+		return "", "", err
+	}
+	return subject, body, nil
+}
+
+// renderSms returns the text message, using the template of the provider when it has one
+func renderSms(provider sdk.AuthProvider, data messageData) (string, error) {
+	text := defaultSmsTemplate
+	if t := provider.GetParam(sdk.SmsParamMessageTemplate); len(t) > 0 {
+		text = t
+	}
+	return renderTemplate("sms", text, data)
+}
+
+func renderTemplate(name, text string, data messageData) (string, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("error parsing the %s template %w", name, err)
+	}
+	buf := bytes.Buffer{}
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", fmt.Errorf("error rendering the %s template %w", name, err)
+	}
+	return buf.String(), nil
+}
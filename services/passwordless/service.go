@@ -0,0 +1,18 @@
+package passwordless
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// Service sends single use login codes and magic links for the passwordless and sms auth providers.
+// Codes go out by email for the passwordless provider and by text message for the sms provider.
+// A successful verification returns a login code that the provider exchanges for the
+// email address or phone number when the auth service verifies it.
+type Service interface {
+	Start(ctx context.Context, provider sdk.AuthProvider, state, address, ip string) error
+	VerifyOtp(ctx context.Context, state, otp string) (string, error)
+	VerifyLink(ctx context.Context, token string) (loginCode string, state string, err error)
+	ExchangeCode(ctx context.Context, code string) (string, error)
+}
@@ -0,0 +1,326 @@
+package passwordless
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/melvinodsa/go-iam/services/email"
+	"github.com/melvinodsa/go-iam/services/sms"
+)
+
+const (
+	// loginCodeTTL is the time within which the auth service has to exchange a login code
+	loginCodeTTL = time.Minute * 5
+	// maxOtpAttempts is the number of wrong guesses after which a code stops working
+	maxOtpAttempts = 5
+	// rateLimitWindow is the window of the limits on the requested codes
+	rateLimitWindow = time.Minute * 15
+	// maxRequestsPerAddress is the number of codes an email address or phone number can get within the window
+	maxRequestsPerAddress = 5
+	// maxRequestsPerIp is the number of codes that can be requested from an IP address within the window
+	maxRequestsPerIp = 20
+	// smsResendCooldown is the time a phone number has to wait before another code is texted to it
+	smsResendCooldown = time.Minute
+)
+
+type service struct {
+	cacheSvc  cache.Service
+	emailSvc  email.Service
+	smsSender sms.SmsSender
+	codeTTL   time.Duration
+}
+
+// NewService creates the passwordless service. codeTTL is the validity of the codes and links sent.
+func NewService(cacheSvc cache.Service, emailSvc email.Service, smsSender sms.SmsSender, codeTTL time.Duration) Service {
+	return service{
+		cacheSvc:  cacheSvc,
+		emailSvc:  emailSvc,
+		smsSender: smsSender,
+		codeTTL:   codeTTL,
+	}
+}
+
+func (s service) Start(ctx context.Context, provider sdk.AuthProvider, state, address, ip string) error {
+	switch provider.Provider {
+	case sdk.AuthProviderTypePasswordless:
+		return s.startEmail(ctx, provider, state, address, ip)
+	case sdk.AuthProviderTypeSms:
+		return s.startSms(ctx, provider, state, address, ip)
+	}
+	return sdk.ErrNotPasswordlessProvider
+}
+
+func (s service) startEmail(ctx context.Context, provider sdk.AuthProvider, state, emailId, ip string) error {
+	/*
+	 * validate the email address
+	 * check the rate limits of the email address and the ip address
+	 * cache a challenge with a code keyed by the state, or with a magic link token
+	 * email the code or the link using the templates of the provider
+	 */
+	addr, err := mail.ParseAddress(emailId)
+	if err != nil {
+		return fmt.Errorf("%w: %w", sdk.ErrInvalidEmail, err)
+	}
+	emailId = normalizeEmail(addr.Address)
+
+	err = s.checkRateLimits(ctx, fmt.Sprintf("passwordless-rate-email-%s-%s", provider.ProjectId, hashToken(emailId)), ip)
+	if err != nil {
+		return err
+	}
+
+	method := provider.GetParam(sdk.PasswordlessParamMethod)
+	if len(method) == 0 {
+		method = sdk.PasswordlessMethodCode
+	}
+	ch := challenge{Address: emailId, State: state, ExpiresAt: time.Now().Add(s.codeTTL)}
+	data := messageData{Email: emailId, ExpiresInMinutes: int(s.codeTTL.Minutes())}
+	var key string
+	switch method {
+	case sdk.PasswordlessMethodCode:
+		data.Code, err = randomOtp()
+		if err != nil {
+			return err
+		}
+		ch.OtpHash = hashToken(data.Code)
+		key = otpKey(state)
+	case sdk.PasswordlessMethodLink:
+		pageUrl := provider.GetParam(sdk.PasswordlessParamLinkUrl)
+		if len(pageUrl) == 0 {
+			return fmt.Errorf("the magic link page url is not configured for the auth provider")
+		}
+		token, err := randomToken()
+		if err != nil {
+			return err
+		}
+		data.Link, err = buildLink(pageUrl, token)
+		if err != nil {
+			return err
+		}
+		key = linkKey(token)
+	default:
+		return fmt.Errorf("unknown passwordless method %s", method)
+	}
+
+	subject, body, err := renderEmail(provider, method, data)
+	if err != nil {
+		return err
+	}
+	err = s.saveChallenge(ctx, key, ch)
+	if err != nil {
+		return err
+	}
+	err = s.emailSvc.Send(ctx, emailId, subject, body)
+	if err != nil {
+		return fmt.Errorf("error sending the login email %w", err)
+	}
+	return nil
+}
+
+func (s service) startSms(ctx context.Context, provider sdk.AuthProvider, state, phone, ip string) error {
+	/*
+	 * normalize the phone number to E.164, using the default country code of the provider
+	 * a number that was texted a code within the cooldown has to wait before asking again
+	 * check the rate limits of the phone number and the ip address
+	 * cache a challenge with a code keyed by the state
+	 * text the code using the template of the provider, the cooldown starts once it is sent
+	 */
+	phone, err := sdk.NormalizePhone(phone, provider.GetParam(sdk.SmsParamDefaultCountryCode))
+	if err != nil {
+		return err
+	}
+	cooldownKey := fmt.Sprintf("passwordless-cooldown-%s-%s", provider.ProjectId, hashToken(phone))
+	if val, err := s.cacheSvc.Get(ctx, cooldownKey); err == nil && len(val) > 0 {
+		return sdk.ErrOtpResendCooldown
+	}
+	err = s.checkRateLimits(ctx, fmt.Sprintf("passwordless-rate-phone-%s-%s", provider.ProjectId, hashToken(phone)), ip)
+	if err != nil {
+		return err
+	}
+
+	data := messageData{Phone: phone, ExpiresInMinutes: int(s.codeTTL.Minutes())}
+	data.Code, err = randomOtp()
+	if err != nil {
+		return err
+	}
+	message, err := renderSms(provider, data)
+	if err != nil {
+		return err
+	}
+	ch := challenge{Address: phone, State: state, OtpHash: hashToken(data.Code), ExpiresAt: time.Now().Add(s.codeTTL)}
+	err = s.saveChallenge(ctx, otpKey(state), ch)
+	if err != nil {
+		return err
+	}
+	err = s.smsSender.Send(ctx, phone, message)
+	if err != nil {
+		return fmt.Errorf("error sending the login sms %w", err)
+	}
+	err = s.cacheSvc.Set(ctx, cooldownKey, "1", smsResendCooldown)
+	if err != nil {
+		return fmt.Errorf("error saving the resend cooldown %w", err)
+	}
+	return nil
+}
+
+func (s service) VerifyOtp(ctx context.Context, state, otp string) (string, error) {
+	/*
+	 * get the challenge of the state
+	 * a wrong code counts as an attempt, the challenge is dropped after too many of them
+	 * the right code drops the challenge and gives a login code for the email address or phone number
+	 */
+	key := otpKey(state)
+	ch, err := s.getChallenge(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if subtle.ConstantTimeCompare([]byte(ch.OtpHash), []byte(hashToken(otp))) != 1 {
+		ch.Attempts++
+		if ch.Attempts >= maxOtpAttempts {
+			err = s.cacheSvc.Delete(ctx, key)
+			if err != nil {
+				return "", fmt.Errorf("error invalidating the login code %w", err)
+			}
+			return "", sdk.ErrOtpAttemptsExceeded
+		}
+		err = s.saveChallenge(ctx, key, *ch)
+		if err != nil {
+			return "", err
+		}
+		return "", sdk.ErrInvalidOtp
+	}
+
+	err = s.cacheSvc.Delete(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("error invalidating the login code %w", err)
+	}
+	return s.issueLoginCode(ctx, ch.Address)
+}
+
+func (s service) VerifyLink(ctx context.Context, token string) (string, string, error) {
+	/*
+	 * the magic link is single use, its challenge is dropped before the login code is issued
+	 */
+	key := linkKey(token)
+	ch, err := s.getChallenge(ctx, key)
+	if err != nil {
+		return "", "", err
+	}
+	err = s.cacheSvc.Delete(ctx, key)
+	if err != nil {
+		return "", "", fmt.Errorf("error invalidating the magic link %w", err)
+	}
+	code, err := s.issueLoginCode(ctx, ch.Address)
+	if err != nil {
+		return "", "", err
+	}
+	return code, ch.State, nil
+}
+
+func (s service) ExchangeCode(ctx context.Context, code string) (string, error) {
+	key := fmt.Sprintf("passwordless-login-%s", hashToken(code))
+	address, err := s.cacheSvc.Get(ctx, key)
+	if err != nil || len(address) == 0 {
+		return "", sdk.ErrInvalidOtp
+	}
+	err = s.cacheSvc.Delete(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("error invalidating the login code %w", err)
+	}
+	return address, nil
+}
+
+func (s service) issueLoginCode(ctx context.Context, address string) (string, error) {
+	code, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.cacheSvc.Set(ctx, fmt.Sprintf("passwordless-login-%s", hashToken(code)), address, loginCodeTTL)
+	if err != nil {
+		return "", fmt.Errorf("error caching the login code %w", err)
+	}
+	return code, nil
+}
+
+// checkRateLimits counts the request against the limits of the address and of the ip address when it is known
+func (s service) checkRateLimits(ctx context.Context, addressKey, ip string) error {
+	err := s.checkRateLimit(ctx, addressKey, maxRequestsPerAddress)
+	if err != nil {
+		return err
+	}
+	if len(ip) > 0 {
+		return s.checkRateLimit(ctx, fmt.Sprintf("passwordless-rate-ip-%s", ip), maxRequestsPerIp)
+	}
+	return nil
+}
+
+// checkRateLimit counts a request against the key and fails once the limit of the window is reached
+func (s service) checkRateLimit(ctx context.Context, key string, limit int) error {
+	now := time.Now()
+	window := rateWindow{ResetAt: now.Add(rateLimitWindow)}
+	val, err := s.cacheSvc.Get(ctx, key)
+	if err == nil {
+		var cached rateWindow
+		if json.Unmarshal([]byte(val), &cached) == nil && cached.ResetAt.After(now) {
+			window = cached
+		}
+	}
+	if window.Count >= limit {
+		return sdk.ErrTooManyOtpRequests
+	}
+	window.Count++
+	b, err := json.Marshal(window)
+	if err != nil {
+		return fmt.Errorf("error encoding the rate limit %w", err)
+	}
+	err = s.cacheSvc.Set(ctx, key, string(b), window.ResetAt.Sub(now))
+	if err != nil {
+		return fmt.Errorf("error saving the rate limit %w", err)
+	}
+	return nil
+}
+
+func (s service) saveChallenge(ctx context.Context, key string, ch challenge) error {
+	ttl := time.Until(ch.ExpiresAt)
+	if ttl <= 0 {
+		return sdk.ErrInvalidOtp
+	}
+	b, err := json.Marshal(ch)
+	if err != nil {
+		return fmt.Errorf("error encoding the login challenge %w", err)
+	}
+	err = s.cacheSvc.Set(ctx, key, string(b), ttl)
+	if err != nil {
+		return fmt.Errorf("error caching the login challenge %w", err)
+	}
+	return nil
+}
+
+func (s service) getChallenge(ctx context.Context, key string) (*challenge, error) {
+	val, err := s.cacheSvc.Get(ctx, key)
+	if err != nil || len(val) == 0 {
+		return nil, sdk.ErrInvalidOtp
+	}
+	ch := challenge{}
+	err = json.Unmarshal([]byte(val), &ch)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the login challenge %w", err)
+	}
+	if time.Now().After(ch.ExpiresAt) {
+		return nil, sdk.ErrInvalidOtp
+	}
+	return &ch, nil
+}
+
+func otpKey(state string) string {
+	return fmt.Sprintf("passwordless-otp-%s", hashToken(state))
+}
+
+func linkKey(token string) string {
+	return fmt.Sprintf("passwordless-link-%s", hashToken(token))
+}
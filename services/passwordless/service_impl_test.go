@@ -0,0 +1,366 @@
+package passwordless
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingEmailService keeps the emails instead of sending them
+type recordingEmailService struct {
+	to, subject, body string
+	sent              int
+	err               error
+}
+
+func (r *recordingEmailService) Send(ctx context.Context, to, subject, body string) error {
+	r.to, r.subject, r.body = to, subject, body
+	r.sent++
+	return r.err
+}
+
+var (
+	otpInBody   = regexp.MustCompile(`\b(\d{6})\b`)
+	tokenInLink = regexp.MustCompile(`token=(\S+)`)
+)
+
+// otp returns the code in the last email
+func (r *recordingEmailService) otp(t *testing.T) string {
+	m := otpInBody.FindStringSubmatch(r.body)
+	require.Len(t, m, 2)
+	return m[1]
+}
+
+// linkToken returns the token of the magic link in the last email
+func (r *recordingEmailService) linkToken(t *testing.T) string {
+	m := tokenInLink.FindStringSubmatch(r.body)
+	require.Len(t, m, 2)
+	token, err := url.QueryUnescape(m[1])
+	require.NoError(t, err)
+	return token
+}
+
+var codeProvider = sdk.AuthProvider{
+	Id:        "provider-1",
+	Provider:  sdk.AuthProviderTypePasswordless,
+	ProjectId: "project-1",
+}
+
+var linkProvider = sdk.AuthProvider{
+	Id:        "provider-2",
+	Provider:  sdk.AuthProviderTypePasswordless,
+	ProjectId: "project-1",
+	Params: []sdk.AuthProviderParam{
+		{Key: sdk.PasswordlessParamMethod, Value: sdk.PasswordlessMethodLink},
+		{Key: sdk.PasswordlessParamLinkUrl, Value: "https://app.example.com/magic"},
+	},
+}
+
+// recordingSmsSender keeps the text messages instead of sending them
+type recordingSmsSender struct {
+	to, message string
+	sent        int
+	err         error
+}
+
+func (r *recordingSmsSender) Send(ctx context.Context, to, message string) error {
+	r.to, r.message = to, message
+	r.sent++
+	return r.err
+}
+
+// otp returns the code in the last text message
+func (r *recordingSmsSender) otp(t *testing.T) string {
+	m := otpInBody.FindStringSubmatch(r.message)
+	require.Len(t, m, 2)
+	return m[1]
+}
+
+var smsProvider = sdk.AuthProvider{
+	Id:        "provider-3",
+	Provider:  sdk.AuthProviderTypeSms,
+	ProjectId: "project-1",
+	Params: []sdk.AuthProviderParam{
+		{Key: sdk.SmsParamDefaultCountryCode, Value: "+91"},
+	},
+}
+
+func setupTestService() (service, *recordingEmailService) {
+	emailSvc := &recordingEmailService{}
+	svc := NewService(cache.NewMockService(), emailSvc, &recordingSmsSender{}, time.Minute*10).(service)
+	return svc, emailSvc
+}
+
+func setupSmsTestService() (service, *recordingSmsSender) {
+	sender := &recordingSmsSender{}
+	svc := NewService(cache.NewMockService(), &recordingEmailService{}, sender, time.Minute*10).(service)
+	return svc, sender
+}
+
+func TestService_LoginWithCode(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("the emailed code logs the user in once", func(t *testing.T) {
+		svc, emailSvc := setupTestService()
+
+		err := svc.Start(ctx, codeProvider, "state-1", "User@Example.com", "10.0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", emailSvc.to)
+		assert.Equal(t, defaultCodeSubject, emailSvc.subject)
+		assert.Contains(t, emailSvc.body, "expires in 10 minutes")
+
+		code, err := svc.VerifyOtp(ctx, "state-1", emailSvc.otp(t))
+		require.NoError(t, err)
+		emailId, err := svc.ExchangeCode(ctx, code)
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", emailId)
+
+		_, err = svc.ExchangeCode(ctx, code)
+		assert.ErrorIs(t, err, sdk.ErrInvalidOtp)
+		_, err = svc.VerifyOtp(ctx, "state-1", emailSvc.otp(t))
+		assert.ErrorIs(t, err, sdk.ErrInvalidOtp)
+	})
+
+	t.Run("the code only works for its own login", func(t *testing.T) {
+		svc, emailSvc := setupTestService()
+		require.NoError(t, svc.Start(ctx, codeProvider, "state-1", "user@example.com", ""))
+
+		_, err := svc.VerifyOtp(ctx, "state-2", emailSvc.otp(t))
+		assert.ErrorIs(t, err, sdk.ErrInvalidOtp)
+	})
+
+	t.Run("the code stops working after too many wrong attempts", func(t *testing.T) {
+		svc, emailSvc := setupTestService()
+		require.NoError(t, svc.Start(ctx, codeProvider, "state-1", "user@example.com", ""))
+		otp := emailSvc.otp(t)
+		wrong := "000000"
+		if otp == wrong {
+			wrong = "111111"
+		}
+
+		for i := 1; i < maxOtpAttempts; i++ {
+			_, err := svc.VerifyOtp(ctx, "state-1", wrong)
+			assert.ErrorIs(t, err, sdk.ErrInvalidOtp)
+		}
+		_, err := svc.VerifyOtp(ctx, "state-1", wrong)
+		assert.ErrorIs(t, err, sdk.ErrOtpAttemptsExceeded)
+
+		_, err = svc.VerifyOtp(ctx, "state-1", otp)
+		assert.ErrorIs(t, err, sdk.ErrInvalidOtp)
+	})
+
+	t.Run("invalid email", func(t *testing.T) {
+		svc, emailSvc := setupTestService()
+
+		err := svc.Start(ctx, codeProvider, "state-1", "not an email", "")
+		assert.ErrorIs(t, err, sdk.ErrInvalidEmail)
+		assert.Zero(t, emailSvc.sent)
+	})
+
+	t.Run("provider of another type", func(t *testing.T) {
+		svc, _ := setupTestService()
+
+		err := svc.Start(ctx, sdk.AuthProvider{Provider: sdk.AuthProviderTypePassword}, "state-1", "user@example.com", "")
+		assert.ErrorIs(t, err, sdk.ErrNotPasswordlessProvider)
+	})
+
+	t.Run("sending fails", func(t *testing.T) {
+		svc, emailSvc := setupTestService()
+		emailSvc.err = errors.New("connection refused")
+
+		err := svc.Start(ctx, codeProvider, "state-1", "user@example.com", "")
+		assert.ErrorContains(t, err, "error sending the login email")
+	})
+}
+
+func TestService_LoginWithLink(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("the magic link logs the user in once", func(t *testing.T) {
+		svc, emailSvc := setupTestService()
+
+		err := svc.Start(ctx, linkProvider, "state-1", "user@example.com", "")
+		require.NoError(t, err)
+		assert.Equal(t, defaultLinkSubject, emailSvc.subject)
+		assert.Contains(t, emailSvc.body, "https://app.example.com/magic?token=")
+
+		token := emailSvc.linkToken(t)
+		code, state, err := svc.VerifyLink(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, "state-1", state)
+		emailId, err := svc.ExchangeCode(ctx, code)
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", emailId)
+
+		_, _, err = svc.VerifyLink(ctx, token)
+		assert.ErrorIs(t, err, sdk.ErrInvalidOtp)
+	})
+
+	t.Run("page url is required", func(t *testing.T) {
+		svc, emailSvc := setupTestService()
+		p := linkProvider
+		p.Params = []sdk.AuthProviderParam{{Key: sdk.PasswordlessParamMethod, Value: sdk.PasswordlessMethodLink}}
+
+		err := svc.Start(ctx, p, "state-1", "user@example.com", "")
+		assert.ErrorContains(t, err, "magic link page url is not configured")
+		assert.Zero(t, emailSvc.sent)
+	})
+}
+
+func TestService_RateLimits(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("per email", func(t *testing.T) {
+		svc, _ := setupTestService()
+		for i := 0; i < maxRequestsPerAddress; i++ {
+			require.NoError(t, svc.Start(ctx, codeProvider, "state-1", "user@example.com", ""))
+		}
+
+		err := svc.Start(ctx, codeProvider, "state-1", "USER@example.com", "")
+		assert.ErrorIs(t, err, sdk.ErrTooManyOtpRequests)
+		// other emails are not affected
+		assert.NoError(t, svc.Start(ctx, codeProvider, "state-2", "other@example.com", ""))
+	})
+
+	t.Run("per ip", func(t *testing.T) {
+		svc, _ := setupTestService()
+		for i := 0; i < maxRequestsPerIp; i++ {
+			require.NoError(t, svc.Start(ctx, codeProvider, "state-1", "user"+string(rune('a'+i))+"@example.com", "10.0.0.1"))
+		}
+
+		err := svc.Start(ctx, codeProvider, "state-1", "another@example.com", "10.0.0.1")
+		assert.ErrorIs(t, err, sdk.ErrTooManyOtpRequests)
+		assert.NoError(t, svc.Start(ctx, codeProvider, "state-1", "another@example.com", "10.0.0.2"))
+	})
+}
+
+func TestService_LoginWithSms(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("the texted code logs the user in with the E.164 number", func(t *testing.T) {
+		svc, sender := setupSmsTestService()
+
+		err := svc.Start(ctx, smsProvider, "state-1", "098765 43210", "10.0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, "+919876543210", sender.to)
+		assert.Contains(t, sender.message, "expires in 10 minutes")
+
+		code, err := svc.VerifyOtp(ctx, "state-1", sender.otp(t))
+		require.NoError(t, err)
+		phone, err := svc.ExchangeCode(ctx, code)
+		require.NoError(t, err)
+		assert.Equal(t, "+919876543210", phone)
+	})
+
+	t.Run("message template of the provider", func(t *testing.T) {
+		svc, sender := setupSmsTestService()
+		p := smsProvider
+		p.Params = append(p.Params, sdk.AuthProviderParam{Key: sdk.SmsParamMessageTemplate, Value: "Orders code for {{.Phone}}: {{.Code}}"})
+
+		require.NoError(t, svc.Start(ctx, p, "state-1", "+14155550100", ""))
+		assert.Regexp(t, `^Orders code for \+14155550100: \d{6}$`, sender.message)
+	})
+
+	t.Run("a code cannot be resent during the cooldown", func(t *testing.T) {
+		svc, sender := setupSmsTestService()
+		require.NoError(t, svc.Start(ctx, smsProvider, "state-1", "+919876543210", ""))
+
+		err := svc.Start(ctx, smsProvider, "state-1", "9876543210", "")
+		assert.ErrorIs(t, err, sdk.ErrOtpResendCooldown)
+		assert.Equal(t, 1, sender.sent)
+		// other numbers are not affected
+		assert.NoError(t, svc.Start(ctx, smsProvider, "state-2", "+919876543211", ""))
+	})
+
+	t.Run("the number is rate limited after the cooldowns", func(t *testing.T) {
+		svc, _ := setupSmsTestService()
+		cooldownKey := "passwordless-cooldown-project-1-" + hashToken("+919876543210")
+		for i := 0; i < maxRequestsPerAddress; i++ {
+			require.NoError(t, svc.Start(ctx, smsProvider, "state-1", "+919876543210", ""))
+			require.NoError(t, svc.cacheSvc.Delete(ctx, cooldownKey))
+		}
+
+		err := svc.Start(ctx, smsProvider, "state-1", "+919876543210", "")
+		assert.ErrorIs(t, err, sdk.ErrTooManyOtpRequests)
+	})
+
+	t.Run("the code stops working after too many wrong attempts", func(t *testing.T) {
+		svc, sender := setupSmsTestService()
+		require.NoError(t, svc.Start(ctx, smsProvider, "state-1", "+919876543210", ""))
+		otp := sender.otp(t)
+		wrong := "000000"
+		if otp == wrong {
+			wrong = "111111"
+		}
+
+		for i := 1; i < maxOtpAttempts; i++ {
+			_, err := svc.VerifyOtp(ctx, "state-1", wrong)
+			assert.ErrorIs(t, err, sdk.ErrInvalidOtp)
+		}
+		_, err := svc.VerifyOtp(ctx, "state-1", wrong)
+		assert.ErrorIs(t, err, sdk.ErrOtpAttemptsExceeded)
+		_, err = svc.VerifyOtp(ctx, "state-1", otp)
+		assert.ErrorIs(t, err, sdk.ErrInvalidOtp)
+	})
+
+	t.Run("invalid phone number", func(t *testing.T) {
+		svc, sender := setupSmsTestService()
+
+		err := svc.Start(ctx, smsProvider, "state-1", "call me", "")
+		assert.ErrorIs(t, err, sdk.ErrInvalidPhone)
+		assert.Zero(t, sender.sent)
+	})
+
+	t.Run("a failed send does not start the cooldown", func(t *testing.T) {
+		svc, sender := setupSmsTestService()
+		sender.err = errors.New("gateway timeout")
+
+		err := svc.Start(ctx, smsProvider, "state-1", "+919876543210", "")
+		assert.ErrorContains(t, err, "error sending the login sms")
+
+		sender.err = nil
+		assert.NoError(t, svc.Start(ctx, smsProvider, "state-1", "+919876543210", ""))
+	})
+}
+
+func TestRenderEmail(t *testing.T) {
+	data := messageData{Email: "user@example.com", Code: "123456", ExpiresInMinutes: 10}
+
+	t.Run("templates of the provider", func(t *testing.T) {
+		p := sdk.AuthProvider{Params: []sdk.AuthProviderParam{
+			{Key: sdk.PasswordlessParamEmailSubject, Value: "Sign in to Orders"},
+			{Key: sdk.PasswordlessParamEmailTemplate, Value: "Hi {{.Email}}, use {{.Code}} within {{.ExpiresInMinutes}} minutes"},
+		}}
+
+		subject, body, err := renderEmail(p, sdk.PasswordlessMethodCode, data)
+		require.NoError(t, err)
+		assert.Equal(t, "Sign in to Orders", subject)
+		assert.Equal(t, "Hi user@example.com, use 123456 within 10 minutes", body)
+	})
+
+	t.Run("broken template", func(t *testing.T) {
+		p := sdk.AuthProvider{Params: []sdk.AuthProviderParam{
+			{Key: sdk.PasswordlessParamEmailTemplate, Value: "{{.Code"},
+		}}
+
+		_, _, err := renderEmail(p, sdk.PasswordlessMethodCode, data)
+		assert.ErrorContains(t, err, "error parsing the email template")
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		p := sdk.AuthProvider{Params: []sdk.AuthProviderParam{
+			{Key: sdk.PasswordlessParamEmailTemplate, Value: "{{.Password}}"},
+		}}
+
+		_, _, err := renderEmail(p, sdk.PasswordlessMethodCode, data)
+		assert.ErrorContains(t, err, "error rendering the email template")
+	})
+}
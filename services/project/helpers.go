@@ -6,28 +6,96 @@ import (
 )
 
 func fromSdkToModel(project sdk.Project) models.Project {
+	scopes := []models.ProjectScope{}
+	for _, sc := range project.Scopes {
+		scopes = append(scopes, models.ProjectScope{Name: sc.Name, Description: sc.Description})
+	}
+	var policy *models.PasswordPolicy
+	if project.PasswordPolicy != nil {
+		policy = &models.PasswordPolicy{
+			MinLength:        project.PasswordPolicy.MinLength,
+			RequireUppercase: project.PasswordPolicy.RequireUppercase,
+			RequireLowercase: project.PasswordPolicy.RequireLowercase,
+			RequireNumber:    project.PasswordPolicy.RequireNumber,
+			RequireSymbol:    project.PasswordPolicy.RequireSymbol,
+		}
+	}
+	var mfaPolicy *models.MfaPolicy
+	if project.MfaPolicy != nil {
+		mfaPolicy = &models.MfaPolicy{Mode: project.MfaPolicy.Mode, RoleIds: project.MfaPolicy.RoleIds}
+	}
+	var webAuthn *models.WebAuthnConfig
+	if project.WebAuthn != nil {
+		webAuthn = &models.WebAuthnConfig{RpId: project.WebAuthn.RpId, RpName: project.WebAuthn.RpName, Origins: project.WebAuthn.Origins}
+	}
+	var loginRouting *models.LoginRouting
+	if project.LoginRouting != nil {
+		loginRouting = &models.LoginRouting{Fallback: project.LoginRouting.Fallback}
+		for _, route := range project.LoginRouting.Domains {
+			loginRouting.Domains = append(loginRouting.Domains, models.LoginDomainRoute{Domain: route.Domain, AuthProviderId: route.AuthProviderId})
+		}
+	}
 	return models.Project{
-		Id:          project.Id,
-		Name:        project.Name,
-		Tags:        project.Tags,
-		Description: project.Description,
-		CreatedAt:   project.CreatedAt,
-		CreatedBy:   project.CreatedBy,
-		UpdatedAt:   project.UpdatedAt,
-		UpdatedBy:   project.UpdatedBy,
+		Id:             project.Id,
+		Name:           project.Name,
+		Tags:           project.Tags,
+		Description:    project.Description,
+		Scopes:         scopes,
+		PasswordPolicy: policy,
+		MfaPolicy:      mfaPolicy,
+		WebAuthn:       webAuthn,
+		LoginRouting:   loginRouting,
+		CreatedAt:      project.CreatedAt,
+		CreatedBy:      project.CreatedBy,
+		UpdatedAt:      project.UpdatedAt,
+		UpdatedBy:      project.UpdatedBy,
 	}
 }
 
 func fromModelToSdk(project *models.Project) *sdk.Project {
+	scopes := []sdk.ProjectScope{}
+	for _, sc := range project.Scopes {
+		scopes = append(scopes, sdk.ProjectScope{Name: sc.Name, Description: sc.Description})
+	}
+	var policy *sdk.PasswordPolicy
+	if project.PasswordPolicy != nil {
+		policy = &sdk.PasswordPolicy{
+			MinLength:        project.PasswordPolicy.MinLength,
+			RequireUppercase: project.PasswordPolicy.RequireUppercase,
+			RequireLowercase: project.PasswordPolicy.RequireLowercase,
+			RequireNumber:    project.PasswordPolicy.RequireNumber,
+			RequireSymbol:    project.PasswordPolicy.RequireSymbol,
+		}
+	}
+	var mfaPolicy *sdk.MfaPolicy
+	if project.MfaPolicy != nil {
+		mfaPolicy = &sdk.MfaPolicy{Mode: project.MfaPolicy.Mode, RoleIds: project.MfaPolicy.RoleIds}
+	}
+	var webAuthn *sdk.WebAuthnConfig
+	if project.WebAuthn != nil {
+		webAuthn = &sdk.WebAuthnConfig{RpId: project.WebAuthn.RpId, RpName: project.WebAuthn.RpName, Origins: project.WebAuthn.Origins}
+	}
+	var loginRouting *sdk.LoginRouting
+	if project.LoginRouting != nil {
+		loginRouting = &sdk.LoginRouting{Fallback: project.LoginRouting.Fallback}
+		for _, route := range project.LoginRouting.Domains {
+			loginRouting.Domains = append(loginRouting.Domains, sdk.LoginDomainRoute{Domain: route.Domain, AuthProviderId: route.AuthProviderId})
+		}
+	}
 	return &sdk.Project{
-		Id:          project.Id,
-		Name:        project.Name,
-		Tags:        project.Tags,
-		Description: project.Description,
-		CreatedAt:   project.CreatedAt,
-		CreatedBy:   project.CreatedBy,
-		UpdatedAt:   project.UpdatedAt,
-		UpdatedBy:   project.UpdatedBy,
+		Id:             project.Id,
+		Name:           project.Name,
+		Tags:           project.Tags,
+		Description:    project.Description,
+		Scopes:         scopes,
+		PasswordPolicy: policy,
+		MfaPolicy:      mfaPolicy,
+		WebAuthn:       webAuthn,
+		LoginRouting:   loginRouting,
+		CreatedAt:      project.CreatedAt,
+		CreatedBy:      project.CreatedBy,
+		UpdatedAt:      project.UpdatedAt,
+		UpdatedBy:      project.UpdatedBy,
 	}
 }
 
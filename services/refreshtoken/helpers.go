@@ -0,0 +1,68 @@
+package refreshtoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+// tokenSize is the number of random bytes in a raw refresh token
+const tokenSize = 32
+
+func newRawToken() (string, error) {
+	b := make([]byte, tokenSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("error generating refresh token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(raw string) string {
+	h := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(h[:])
+}
+
+func fromSdkToModel(token sdk.RefreshToken) (*models.RefreshToken, error) {
+	authToken, err := json.Marshal(token.AuthToken)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding refresh token session: %w", err)
+	}
+	return &models.RefreshToken{
+		Id:        token.Id,
+		FamilyId:  token.FamilyId,
+		ClientId:  token.ClientId,
+		UserId:    token.UserId,
+		TokenHash: token.TokenHash,
+		AuthToken: string(authToken),
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+		UsedAt:    token.UsedAt,
+		RevokedAt: token.RevokedAt,
+	}, nil
+}
+
+func fromModelToSdk(token models.RefreshToken) (*sdk.RefreshToken, error) {
+	result := sdk.RefreshToken{
+		Id:        token.Id,
+		FamilyId:  token.FamilyId,
+		ClientId:  token.ClientId,
+		UserId:    token.UserId,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+		UsedAt:    token.UsedAt,
+		RevokedAt: token.RevokedAt,
+	}
+	err := json.Unmarshal([]byte(token.AuthToken), &result.AuthToken)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding refresh token session: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,29 @@
+package refreshtoken
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+type Service interface {
+	// Create issues a refresh token for the session and starts a new token family.
+	// The session id of the token is used as the family id when present.
+	// The raw token is returned and only its hash is stored.
+	Create(ctx context.Context, userId string, token sdk.AuthToken) (string, error)
+	// Rotate consumes the refresh token and issues its successor in the same family.
+	// It returns the successor along with its raw value. Presenting an already used
+	// token revokes the whole family and returns sdk.ErrRefreshTokenReused.
+	// Tokens issued to a client that authenticated with its secret are only rotated when
+	// clientAuthenticated is set, otherwise sdk.ErrClientAuthenticationRequired is returned.
+	Rotate(ctx context.Context, refreshToken, clientId string, clientAuthenticated bool) (*sdk.RefreshToken, string, error)
+	// Revoke revokes the family of the given refresh token. It returns sdk.ErrRefreshTokenNotFound
+	// for unknown tokens and sdk.ErrTokenClientMismatch if the token belongs to another client.
+	Revoke(ctx context.Context, refreshToken, clientId string) error
+	// RevokeFamily revokes every token rotated from the same login.
+	RevokeFamily(ctx context.Context, familyId string) error
+	// RevokeUser revokes every refresh token issued for the user.
+	RevokeUser(ctx context.Context, userId string) error
+	// RevokeUserClient revokes every refresh token issued to the client for the user.
+	RevokeUserClient(ctx context.Context, userId, clientId string) error
+}
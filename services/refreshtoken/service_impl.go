@@ -0,0 +1,151 @@
+package refreshtoken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+type service struct {
+	store Store
+	ttl   time.Duration
+}
+
+// NewService creates the refresh token service.
+// ttl is the validity of every issued token. It slides on each rotation.
+func NewService(store Store, ttl time.Duration) Service {
+	return service{store: store, ttl: ttl}
+}
+
+func (s service) Create(ctx context.Context, userId string, token sdk.AuthToken) (string, error) {
+	familyId := token.SessionId
+	if len(familyId) == 0 {
+		familyId = uuid.NewString()
+	}
+	raw, _, err := s.issue(ctx, familyId, userId, token)
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+func (s service) Rotate(ctx context.Context, refreshToken, clientId string, clientAuthenticated bool) (*sdk.RefreshToken, string, error) {
+	/*
+	 * find the token by its hash
+	 * reject tokens of other clients, revoked or expired tokens
+	 * tokens of confidential clients need the client secret, checked before the reuse detection
+	 * so that a leaked token alone can neither be rotated nor revoke the family
+	 * a token that was already used means it leaked. revoke the family
+	 * mark the token as used and issue the successor in the same family
+	 */
+	current, err := s.store.GetByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, sdk.ErrRefreshTokenNotFound) {
+		return nil, "", sdk.ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error fetching the refresh token: %w", err)
+	}
+	if current.ClientId != clientId || current.RevokedAt != nil {
+		return nil, "", sdk.ErrRefreshTokenInvalid
+	}
+	if !current.AuthToken.PublicClient && !clientAuthenticated {
+		return nil, "", sdk.ErrClientAuthenticationRequired
+	}
+	now := time.Now()
+	if current.UsedAt != nil {
+		return nil, "", s.revokeReused(ctx, *current)
+	}
+	if current.ExpiresAt != nil && current.ExpiresAt.Before(now) {
+		return nil, "", sdk.ErrRefreshTokenInvalid
+	}
+
+	marked, err := s.store.MarkUsed(ctx, current.Id, now)
+	if err != nil {
+		return nil, "", fmt.Errorf("error rotating the refresh token: %w", err)
+	}
+	if !marked {
+		return nil, "", s.revokeReused(ctx, *current)
+	}
+
+	raw, next, err := s.issue(ctx, current.FamilyId, current.UserId, current.AuthToken)
+	if err != nil {
+		return nil, "", err
+	}
+	return next, raw, nil
+}
+
+func (s service) Revoke(ctx context.Context, refreshToken, clientId string) error {
+	token, err := s.store.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if token.ClientId != clientId {
+		return sdk.ErrTokenClientMismatch
+	}
+	return s.RevokeFamily(ctx, token.FamilyId)
+}
+
+func (s service) RevokeFamily(ctx context.Context, familyId string) error {
+	err := s.store.RevokeFamily(ctx, familyId, time.Now())
+	if err != nil {
+		return fmt.Errorf("error revoking the refresh token family: %w", err)
+	}
+	return nil
+}
+
+func (s service) RevokeUser(ctx context.Context, userId string) error {
+	err := s.store.RevokeUser(ctx, userId, time.Now())
+	if err != nil {
+		return fmt.Errorf("error revoking the refresh tokens of the user: %w", err)
+	}
+	return nil
+}
+
+func (s service) RevokeUserClient(ctx context.Context, userId, clientId string) error {
+	err := s.store.RevokeUserClient(ctx, userId, clientId, time.Now())
+	if err != nil {
+		return fmt.Errorf("error revoking the refresh tokens of the user for the client: %w", err)
+	}
+	return nil
+}
+
+func (s service) revokeReused(ctx context.Context, token sdk.RefreshToken) error {
+	log.Warnw("refresh token reuse detected, revoking the token family",
+		"family_id", token.FamilyId,
+		"client_id", token.ClientId,
+		"user_id", token.UserId)
+	err := s.RevokeFamily(ctx, token.FamilyId)
+	if err != nil {
+		return fmt.Errorf("%w: %w", sdk.ErrRefreshTokenReused, err)
+	}
+	return sdk.ErrRefreshTokenReused
+}
+
+func (s service) issue(ctx context.Context, familyId, userId string, token sdk.AuthToken) (string, *sdk.RefreshToken, error) {
+	raw, err := newRawToken()
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	rt := &sdk.RefreshToken{
+		Id:        uuid.NewString(),
+		FamilyId:  familyId,
+		ClientId:  token.ClientId,
+		UserId:    userId,
+		TokenHash: hashToken(raw),
+		AuthToken: token,
+		ExpiresAt: &expiresAt,
+		CreatedAt: &now,
+	}
+	err = s.store.Create(ctx, rt)
+	if err != nil {
+		return "", nil, fmt.Errorf("error saving the refresh token: %w", err)
+	}
+	return raw, rt, nil
+}
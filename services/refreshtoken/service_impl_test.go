@@ -0,0 +1,355 @@
+package refreshtoken
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStore implements Store interface for testing
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) GetByHash(ctx context.Context, hash string) (*sdk.RefreshToken, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.RefreshToken), args.Error(1)
+}
+
+func (m *MockStore) Create(ctx context.Context, token *sdk.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockStore) MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error) {
+	args := m.Called(ctx, id, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockStore) RevokeFamily(ctx context.Context, familyId string, revokedAt time.Time) error {
+	args := m.Called(ctx, familyId, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockStore) RevokeUser(ctx context.Context, userId string, revokedAt time.Time) error {
+	args := m.Called(ctx, userId, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockStore) RevokeUserClient(ctx context.Context, userId, clientId string, revokedAt time.Time) error {
+	args := m.Called(ctx, userId, clientId, revokedAt)
+	return args.Error(0)
+}
+
+func TestNewService(t *testing.T) {
+	svc := NewService(&MockStore{}, time.Hour)
+
+	assert.NotNil(t, svc)
+	assert.Implements(t, (*Service)(nil), svc)
+}
+
+func TestService_Create(t *testing.T) {
+	ctx := context.Background()
+	authToken := sdk.AuthToken{ClientId: "client-1", AuthProviderID: "provider-1"}
+
+	t.Run("success", func(t *testing.T) {
+		mockStore := &MockStore{}
+		var created *sdk.RefreshToken
+		mockStore.On("Create", ctx, mock.AnythingOfType("*sdk.RefreshToken")).Run(func(args mock.Arguments) {
+			created = args.Get(1).(*sdk.RefreshToken)
+		}).Return(nil)
+
+		raw, err := NewService(mockStore, time.Hour).Create(ctx, "user-1", authToken)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, raw)
+		assert.NotEqual(t, raw, created.TokenHash)
+		assert.Equal(t, hashToken(raw), created.TokenHash)
+		assert.NotEmpty(t, created.FamilyId)
+		assert.Equal(t, "client-1", created.ClientId)
+		assert.Equal(t, "user-1", created.UserId)
+		assert.Equal(t, authToken, created.AuthToken)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *created.ExpiresAt, time.Minute)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("session id is used as the family id", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("Create", ctx, mock.MatchedBy(func(rt *sdk.RefreshToken) bool {
+			return rt.FamilyId == "session-1"
+		})).Return(nil)
+
+		_, err := NewService(mockStore, time.Hour).Create(ctx, "user-1", sdk.AuthToken{ClientId: "client-1", SessionId: "session-1"})
+		assert.NoError(t, err)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("Create", ctx, mock.Anything).Return(errors.New("database error"))
+
+		raw, err := NewService(mockStore, time.Hour).Create(ctx, "user-1", authToken)
+		assert.Error(t, err)
+		assert.Empty(t, raw)
+		assert.Contains(t, err.Error(), "error saving the refresh token")
+	})
+}
+
+func TestService_Rotate(t *testing.T) {
+	ctx := context.Background()
+	raw := "raw-refresh-token"
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	stored := func() *sdk.RefreshToken {
+		return &sdk.RefreshToken{
+			Id:        "token-1",
+			FamilyId:  "family-1",
+			ClientId:  "client-1",
+			UserId:    "user-1",
+			TokenHash: hashToken(raw),
+			AuthToken: sdk.AuthToken{ClientId: "client-1"},
+			ExpiresAt: &future,
+		}
+	}
+
+	t.Run("success issues the successor in the same family", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(stored(), nil)
+		mockStore.On("MarkUsed", ctx, "token-1", mock.AnythingOfType("time.Time")).Return(true, nil)
+		mockStore.On("Create", ctx, mock.AnythingOfType("*sdk.RefreshToken")).Return(nil)
+
+		next, newRaw, err := NewService(mockStore, time.Hour).Rotate(ctx, raw, "client-1", true)
+		assert.NoError(t, err)
+		assert.NotEqual(t, raw, newRaw)
+		assert.Equal(t, hashToken(newRaw), next.TokenHash)
+		assert.Equal(t, "family-1", next.FamilyId)
+		assert.Equal(t, "user-1", next.UserId)
+		assert.NotEqual(t, "token-1", next.Id)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("public client token rotates without the client secret", func(t *testing.T) {
+		token := stored()
+		token.AuthToken.PublicClient = true
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(token, nil)
+		mockStore.On("MarkUsed", ctx, "token-1", mock.AnythingOfType("time.Time")).Return(true, nil)
+		mockStore.On("Create", ctx, mock.AnythingOfType("*sdk.RefreshToken")).Return(nil)
+
+		next, _, err := NewService(mockStore, time.Hour).Rotate(ctx, raw, "client-1", false)
+		assert.NoError(t, err)
+		assert.True(t, next.AuthToken.PublicClient)
+	})
+
+	t.Run("confidential client token needs the client secret", func(t *testing.T) {
+		token := stored()
+		token.UsedAt = &past
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(token, nil)
+
+		_, _, err := NewService(mockStore, time.Hour).Rotate(ctx, raw, "client-1", false)
+		assert.ErrorIs(t, err, sdk.ErrClientAuthenticationRequired)
+		mockStore.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything, mock.Anything)
+		mockStore.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(nil, sdk.ErrRefreshTokenNotFound)
+
+		_, _, err := NewService(mockStore, time.Hour).Rotate(ctx, raw, "client-1", true)
+		assert.ErrorIs(t, err, sdk.ErrRefreshTokenInvalid)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(nil, errors.New("database error"))
+
+		_, _, err := NewService(mockStore, time.Hour).Rotate(ctx, raw, "client-1", true)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, sdk.ErrRefreshTokenInvalid)
+		assert.Contains(t, err.Error(), "error fetching the refresh token")
+	})
+
+	t.Run("token of another client", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(stored(), nil)
+
+		_, _, err := NewService(mockStore, time.Hour).Rotate(ctx, raw, "client-2", true)
+		assert.ErrorIs(t, err, sdk.ErrRefreshTokenInvalid)
+		mockStore.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		token := stored()
+		token.RevokedAt = &past
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(token, nil)
+
+		_, _, err := NewService(mockStore, time.Hour).Rotate(ctx, raw, "client-1", true)
+		assert.ErrorIs(t, err, sdk.ErrRefreshTokenInvalid)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		token := stored()
+		token.ExpiresAt = &past
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(token, nil)
+
+		_, _, err := NewService(mockStore, time.Hour).Rotate(ctx, raw, "client-1", true)
+		assert.ErrorIs(t, err, sdk.ErrRefreshTokenInvalid)
+		mockStore.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("reused token revokes the family", func(t *testing.T) {
+		token := stored()
+		token.UsedAt = &past
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(token, nil)
+		mockStore.On("RevokeFamily", ctx, "family-1", mock.AnythingOfType("time.Time")).Return(nil)
+
+		_, _, err := NewService(mockStore, time.Hour).Rotate(ctx, raw, "client-1", true)
+		assert.ErrorIs(t, err, sdk.ErrRefreshTokenReused)
+		mockStore.AssertExpectations(t)
+		mockStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("concurrent rotation is treated as reuse", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(stored(), nil)
+		mockStore.On("MarkUsed", ctx, "token-1", mock.AnythingOfType("time.Time")).Return(false, nil)
+		mockStore.On("RevokeFamily", ctx, "family-1", mock.AnythingOfType("time.Time")).Return(nil)
+
+		_, _, err := NewService(mockStore, time.Hour).Rotate(ctx, raw, "client-1", true)
+		assert.ErrorIs(t, err, sdk.ErrRefreshTokenReused)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("reuse is reported even if revoking fails", func(t *testing.T) {
+		token := stored()
+		token.UsedAt = &past
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(token, nil)
+		mockStore.On("RevokeFamily", ctx, "family-1", mock.Anything).Return(errors.New("database error"))
+
+		_, _, err := NewService(mockStore, time.Hour).Rotate(ctx, raw, "client-1", true)
+		assert.ErrorIs(t, err, sdk.ErrRefreshTokenReused)
+		assert.Contains(t, err.Error(), "database error")
+	})
+
+	t.Run("mark used error", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(stored(), nil)
+		mockStore.On("MarkUsed", ctx, "token-1", mock.Anything).Return(false, errors.New("database error"))
+
+		_, _, err := NewService(mockStore, time.Hour).Rotate(ctx, raw, "client-1", true)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error rotating the refresh token")
+	})
+}
+
+func TestService_RevokeFamily(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("RevokeFamily", ctx, "family-1", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := NewService(mockStore, time.Hour).RevokeFamily(ctx, "family-1")
+		assert.NoError(t, err)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("RevokeFamily", ctx, "family-1", mock.Anything).Return(errors.New("database error"))
+
+		err := NewService(mockStore, time.Hour).RevokeFamily(ctx, "family-1")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error revoking the refresh token family")
+	})
+}
+
+func TestService_Revoke(t *testing.T) {
+	ctx := context.Background()
+	raw := "raw-refresh-token"
+	stored := &sdk.RefreshToken{Id: "token-1", FamilyId: "family-1", ClientId: "client-1"}
+
+	t.Run("success revokes the family", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(stored, nil)
+		mockStore.On("RevokeFamily", ctx, "family-1", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := NewService(mockStore, time.Hour).Revoke(ctx, raw, "client-1")
+		assert.NoError(t, err)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(nil, sdk.ErrRefreshTokenNotFound)
+
+		err := NewService(mockStore, time.Hour).Revoke(ctx, raw, "client-1")
+		assert.ErrorIs(t, err, sdk.ErrRefreshTokenNotFound)
+	})
+
+	t.Run("token of another client", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("GetByHash", ctx, hashToken(raw)).Return(stored, nil)
+
+		err := NewService(mockStore, time.Hour).Revoke(ctx, raw, "client-2")
+		assert.ErrorIs(t, err, sdk.ErrTokenClientMismatch)
+		mockStore.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_RevokeUser(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("RevokeUser", ctx, "user-1", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := NewService(mockStore, time.Hour).RevokeUser(ctx, "user-1")
+		assert.NoError(t, err)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("RevokeUser", ctx, "user-1", mock.Anything).Return(errors.New("database error"))
+
+		err := NewService(mockStore, time.Hour).RevokeUser(ctx, "user-1")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error revoking the refresh tokens of the user")
+	})
+}
+
+func TestService_RevokeUserClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("RevokeUserClient", ctx, "user-1", "client-1", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := NewService(mockStore, time.Hour).RevokeUserClient(ctx, "user-1", "client-1")
+		assert.NoError(t, err)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("RevokeUserClient", ctx, "user-1", "client-1", mock.Anything).Return(errors.New("database error"))
+
+		err := NewService(mockStore, time.Hour).RevokeUserClient(ctx, "user-1", "client-1")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error revoking the refresh tokens of the user for the client")
+	})
+}
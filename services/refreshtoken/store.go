@@ -0,0 +1,18 @@
+package refreshtoken
+
+import (
+	"context"
+	"time"
+
+	"github.com/melvinodsa/go-iam/sdk"
+)
+
+type Store interface {
+	GetByHash(ctx context.Context, hash string) (*sdk.RefreshToken, error)
+	Create(ctx context.Context, token *sdk.RefreshToken) error
+	// MarkUsed flags the token as used. It reports false if the token was already used.
+	MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyId string, revokedAt time.Time) error
+	RevokeUser(ctx context.Context, userId string, revokedAt time.Time) error
+	RevokeUserClient(ctx context.Context, userId, clientId string, revokedAt time.Time) error
+}
@@ -0,0 +1,103 @@
+package refreshtoken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/melvinodsa/go-iam/db"
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/services/encrypt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type store struct {
+	enc encrypt.Service
+	db  db.DB
+}
+
+// NewStore creates a refresh token store backed by mongo.
+// The session carried by a refresh token is encrypted before it is written to the database.
+func NewStore(enc encrypt.Service, db db.DB) Store {
+	return store{enc: enc, db: db}
+}
+
+func (s store) GetByHash(ctx context.Context, hash string) (*sdk.RefreshToken, error) {
+	md := models.GetRefreshTokenModel()
+	var token models.RefreshToken
+	err := s.db.FindOne(ctx, md, bson.D{{Key: md.TokenHashKey, Value: hash}}).Decode(&token)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, sdk.ErrRefreshTokenNotFound
+		}
+		return nil, fmt.Errorf("error finding refresh token: %w", err)
+	}
+	authToken, err := s.enc.Decrypt(token.AuthToken)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting refresh token session: %w", err)
+	}
+	token.AuthToken = authToken
+	return fromModelToSdk(token)
+}
+
+func (s store) Create(ctx context.Context, token *sdk.RefreshToken) error {
+	d, err := fromSdkToModel(*token)
+	if err != nil {
+		return err
+	}
+	d.AuthToken, err = s.enc.Encrypt(d.AuthToken)
+	if err != nil {
+		return fmt.Errorf("error encrypting refresh token session: %w", err)
+	}
+	md := models.GetRefreshTokenModel()
+	_, err = s.db.InsertOne(ctx, md, d)
+	if err != nil {
+		return fmt.Errorf("error creating refresh token: %w", err)
+	}
+	return nil
+}
+
+func (s store) MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error) {
+	md := models.GetRefreshTokenModel()
+	// the used_at filter makes the rotation atomic. a concurrent request
+	// with the same token will not match and is treated as a reuse.
+	filter := bson.D{{Key: md.IdKey, Value: id}, {Key: md.UsedAtKey, Value: nil}}
+	res, err := s.db.UpdateOne(ctx, md, filter, bson.D{{Key: "$set", Value: bson.D{{Key: md.UsedAtKey, Value: usedAt}}}})
+	if err != nil {
+		return false, fmt.Errorf("error marking refresh token as used: %w", err)
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (s store) RevokeFamily(ctx context.Context, familyId string, revokedAt time.Time) error {
+	md := models.GetRefreshTokenModel()
+	filter := bson.D{{Key: md.FamilyIdKey, Value: familyId}, {Key: md.RevokedAtKey, Value: nil}}
+	_, err := s.db.UpdateMany(ctx, md, filter, bson.D{{Key: "$set", Value: bson.D{{Key: md.RevokedAtKey, Value: revokedAt}}}})
+	if err != nil {
+		return fmt.Errorf("error revoking refresh token family: %w", err)
+	}
+	return nil
+}
+
+func (s store) RevokeUser(ctx context.Context, userId string, revokedAt time.Time) error {
+	md := models.GetRefreshTokenModel()
+	filter := bson.D{{Key: md.UserIdKey, Value: userId}, {Key: md.RevokedAtKey, Value: nil}}
+	_, err := s.db.UpdateMany(ctx, md, filter, bson.D{{Key: "$set", Value: bson.D{{Key: md.RevokedAtKey, Value: revokedAt}}}})
+	if err != nil {
+		return fmt.Errorf("error revoking refresh tokens of the user: %w", err)
+	}
+	return nil
+}
+
+func (s store) RevokeUserClient(ctx context.Context, userId, clientId string, revokedAt time.Time) error {
+	md := models.GetRefreshTokenModel()
+	filter := bson.D{{Key: md.UserIdKey, Value: userId}, {Key: md.ClientIdKey, Value: clientId}, {Key: md.RevokedAtKey, Value: nil}}
+	_, err := s.db.UpdateMany(ctx, md, filter, bson.D{{Key: "$set", Value: bson.D{{Key: md.RevokedAtKey, Value: revokedAt}}}})
+	if err != nil {
+		return fmt.Errorf("error revoking refresh tokens of the user for the client: %w", err)
+	}
+	return nil
+}
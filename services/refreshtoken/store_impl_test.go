@@ -0,0 +1,230 @@
+package refreshtoken
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/melvinodsa/go-iam/db/models"
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/melvinodsa/go-iam/utils/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MockEncryptService implements encrypt.Service interface for testing
+type MockEncryptService struct {
+	mock.Mock
+}
+
+func (m *MockEncryptService) Encrypt(rawMessage string) (string, error) {
+	args := m.Called(rawMessage)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockEncryptService) Decrypt(encryptedMessage string) (string, error) {
+	args := m.Called(encryptedMessage)
+	return args.String(0), args.Error(1)
+}
+
+func TestNewStore(t *testing.T) {
+	store := NewStore(&MockEncryptService{}, test.SetupMockDB())
+
+	assert.NotNil(t, store)
+	assert.Implements(t, (*Store)(nil), store)
+}
+
+func TestStore_GetByHash(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetRefreshTokenModel()
+	filter := bson.D{{Key: md.TokenHashKey, Value: "hash-1"}}
+	document := bson.D{
+		{Key: md.IdKey, Value: "token-1"},
+		{Key: md.FamilyIdKey, Value: "family-1"},
+		{Key: "client_id", Value: "client-1"},
+		{Key: md.UserIdKey, Value: "user-1"},
+		{Key: md.TokenHashKey, Value: "hash-1"},
+		{Key: "auth_token", Value: "encrypted-session"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(document, nil, nil))
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Decrypt", "encrypted-session").Return(`{"client_id":"client-1","auth_provider_id":"provider-1"}`, nil)
+
+		token, err := NewStore(mockEnc, mockDB).GetByHash(ctx, "hash-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "token-1", token.Id)
+		assert.Equal(t, "family-1", token.FamilyId)
+		assert.Equal(t, "user-1", token.UserId)
+		assert.Equal(t, "provider-1", token.AuthToken.AuthProviderID)
+		assert.Nil(t, token.UsedAt)
+		mockDB.AssertExpectations(t)
+		mockEnc.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil))
+
+		token, err := NewStore(&MockEncryptService{}, mockDB).GetByHash(ctx, "hash-1")
+		assert.ErrorIs(t, err, sdk.ErrRefreshTokenNotFound)
+		assert.Nil(t, token)
+	})
+
+	t.Run("decrypt error", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("FindOne", ctx, md, filter, mock.Anything).Return(mongo.NewSingleResultFromDocument(document, nil, nil))
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Decrypt", "encrypted-session").Return("", errors.New("decrypt error"))
+
+		token, err := NewStore(mockEnc, mockDB).GetByHash(ctx, "hash-1")
+		assert.Error(t, err)
+		assert.Nil(t, token)
+		assert.Contains(t, err.Error(), "error decrypting refresh token session")
+	})
+}
+
+func TestStore_Create(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetRefreshTokenModel()
+	token := &sdk.RefreshToken{Id: "token-1", FamilyId: "family-1", TokenHash: "hash-1", AuthToken: sdk.AuthToken{ClientId: "client-1"}}
+
+	t.Run("success stores the encrypted session", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("InsertOne", ctx, md, mock.MatchedBy(func(d *models.RefreshToken) bool {
+			return d.Id == "token-1" && d.TokenHash == "hash-1" && d.AuthToken == "encrypted-session"
+		}), mock.Anything).Return(&mongo.InsertOneResult{}, nil)
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Encrypt", mock.AnythingOfType("string")).Return("encrypted-session", nil)
+
+		err := NewStore(mockEnc, mockDB).Create(ctx, token)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("encrypt error", func(t *testing.T) {
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Encrypt", mock.Anything).Return("", errors.New("encrypt error"))
+
+		err := NewStore(mockEnc, test.SetupMockDB()).Create(ctx, token)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error encrypting refresh token session")
+	})
+
+	t.Run("insert error", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("InsertOne", ctx, md, mock.Anything, mock.Anything).Return((*mongo.InsertOneResult)(nil), errors.New("database error"))
+		mockEnc := &MockEncryptService{}
+		mockEnc.On("Encrypt", mock.Anything).Return("encrypted-session", nil)
+
+		err := NewStore(mockEnc, mockDB).Create(ctx, token)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error creating refresh token")
+	})
+}
+
+func TestStore_MarkUsed(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetRefreshTokenModel()
+	usedAt := time.Now()
+	filter := bson.D{{Key: md.IdKey, Value: "token-1"}, {Key: md.UsedAtKey, Value: nil}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: md.UsedAtKey, Value: usedAt}}}}
+
+	tests := []struct {
+		name     string
+		result   *mongo.UpdateResult
+		err      error
+		expected bool
+		wantErr  bool
+	}{
+		{name: "marked", result: &mongo.UpdateResult{ModifiedCount: 1}, expected: true},
+		{name: "already used", result: &mongo.UpdateResult{ModifiedCount: 0}, expected: false},
+		{name: "update error", result: nil, err: errors.New("database error"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := test.SetupMockDB()
+			mockDB.On("UpdateOne", ctx, md, filter, update, mock.Anything).Return(tt.result, tt.err)
+
+			marked, err := NewStore(&MockEncryptService{}, mockDB).MarkUsed(ctx, "token-1", usedAt)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, marked)
+		})
+	}
+}
+
+func TestStore_RevokeFamily(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetRefreshTokenModel()
+	revokedAt := time.Now()
+	filter := bson.D{{Key: md.FamilyIdKey, Value: "family-1"}, {Key: md.RevokedAtKey, Value: nil}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: md.RevokedAtKey, Value: revokedAt}}}}
+
+	t.Run("success", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("UpdateMany", ctx, md, filter, update, mock.Anything).Return(&mongo.UpdateResult{ModifiedCount: 2}, nil)
+
+		err := NewStore(&MockEncryptService{}, mockDB).RevokeFamily(ctx, "family-1", revokedAt)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("update error", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("UpdateMany", ctx, md, filter, update, mock.Anything).Return((*mongo.UpdateResult)(nil), errors.New("database error"))
+
+		err := NewStore(&MockEncryptService{}, mockDB).RevokeFamily(ctx, "family-1", revokedAt)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error revoking refresh token family")
+	})
+}
+
+func TestStore_RevokeUser(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetRefreshTokenModel()
+	revokedAt := time.Now()
+	filter := bson.D{{Key: md.UserIdKey, Value: "user-1"}, {Key: md.RevokedAtKey, Value: nil}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: md.RevokedAtKey, Value: revokedAt}}}}
+
+	t.Run("success", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("UpdateMany", ctx, md, filter, update, mock.Anything).Return(&mongo.UpdateResult{ModifiedCount: 3}, nil)
+
+		err := NewStore(&MockEncryptService{}, mockDB).RevokeUser(ctx, "user-1", revokedAt)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("update error", func(t *testing.T) {
+		mockDB := test.SetupMockDB()
+		mockDB.On("UpdateMany", ctx, md, filter, update, mock.Anything).Return((*mongo.UpdateResult)(nil), errors.New("database error"))
+
+		err := NewStore(&MockEncryptService{}, mockDB).RevokeUser(ctx, "user-1", revokedAt)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "error revoking refresh tokens of the user")
+	})
+}
+
+func TestStore_RevokeUserClient(t *testing.T) {
+	ctx := context.Background()
+	md := models.GetRefreshTokenModel()
+	revokedAt := time.Now()
+	filter := bson.D{{Key: md.UserIdKey, Value: "user-1"}, {Key: md.ClientIdKey, Value: "client-1"}, {Key: md.RevokedAtKey, Value: nil}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: md.RevokedAtKey, Value: revokedAt}}}}
+
+	mockDB := test.SetupMockDB()
+	mockDB.On("UpdateMany", ctx, md, filter, update, mock.Anything).Return(&mongo.UpdateResult{ModifiedCount: 1}, nil)
+
+	err := NewStore(&MockEncryptService{}, mockDB).RevokeUserClient(ctx, "user-1", "client-1", revokedAt)
+	assert.NoError(t, err)
+	mockDB.AssertExpectations(t)
+}
@@ -0,0 +1,8 @@
+package sms
+
+import "context"
+
+// SmsSender sends text messages to phone numbers in the E.164 format.
+type SmsSender interface {
+	Send(ctx context.Context, to, message string) error
+}
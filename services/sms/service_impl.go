@@ -0,0 +1,75 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// webhookTimeout caps the time a webhook call can take
+const webhookTimeout = time.Second * 10
+
+type webhookSender struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// webhookPayload is the body posted to the webhook
+type webhookPayload struct {
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
+
+// NewWebhookSender returns a sender posting the messages as json to the given url,
+// which relays them to the sms gateway. The token is sent as a bearer token when given.
+func NewWebhookSender(url, token string) SmsSender {
+	return webhookSender{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (w webhookSender) Send(ctx context.Context, to, message string) error {
+	body, err := json.Marshal(webhookPayload{To: to, Message: message})
+	if err != nil {
+		return fmt.Errorf("error encoding the sms %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating the sms webhook request %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(w.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling the sms webhook %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sms webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type logSender struct{}
+
+// NewLogSender returns a sender that only logs the messages.
+// It is used when no sms webhook is configured, so that local setups keep working.
+func NewLogSender() SmsSender {
+	return logSender{}
+}
+
+func (l logSender) Send(ctx context.Context, to, message string) error {
+	log.Warnw("sms is not configured, logging the sms instead of sending it", "to", to)
+	log.Debugw("sms message", "to", to, "message", message)
+	return nil
+}
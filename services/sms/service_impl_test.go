@@ -0,0 +1,57 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSender(t *testing.T) {
+	t.Run("posts the message", func(t *testing.T) {
+		var got webhookPayload
+		var auth string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth = r.Header.Get("Authorization")
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		err := NewWebhookSender(server.URL, "secret").Send(context.Background(), "+919876543210", "123456 is your login code")
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer secret", auth)
+		assert.Equal(t, webhookPayload{To: "+919876543210", Message: "123456 is your login code"}, got)
+	})
+
+	t.Run("no token", func(t *testing.T) {
+		var auth string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth = r.Header.Get("Authorization")
+		}))
+		defer server.Close()
+
+		err := NewWebhookSender(server.URL, "").Send(context.Background(), "+919876543210", "hi")
+		require.NoError(t, err)
+		assert.Empty(t, auth)
+	})
+
+	t.Run("gateway error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		err := NewWebhookSender(server.URL, "").Send(context.Background(), "+919876543210", "hi")
+		assert.ErrorContains(t, err, "status 502")
+	})
+}
+
+func TestLogSender(t *testing.T) {
+	err := NewLogSender().Send(context.Background(), "+919876543210", "hi")
+	assert.NoError(t, err)
+}
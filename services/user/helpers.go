@@ -7,43 +7,49 @@ import (
 
 func fromSdkToModel(user sdk.User) models.User {
 	return models.User{
-		Id:             user.Id,
-		Email:          user.Email,
-		Phone:          user.Phone,
-		Name:           user.Name,
-		ProjectId:      user.ProjectId,
-		Enabled:        user.Enabled,
-		Expiry:         user.Expiry,
-		ProfilePic:     user.ProfilePic,
-		LinkedClientId: user.LinkedClientId,
-		Roles:          fromSdkUserRoleMapToModel(user.Roles),
-		Resources:      fromSdkUserResourceMapToModel(user.Resources),
-		Policies:       fromSdkUserPoliciesToModel(user.Policies),
-		CreatedAt:      user.CreatedAt,
-		CreatedBy:      user.CreatedBy,
-		UpdatedAt:      user.UpdatedAt,
-		UpdatedBy:      user.UpdatedBy,
+		Id:              user.Id,
+		Email:           user.Email,
+		EmailVerified:   user.EmailVerified,
+		Phone:           user.Phone,
+		Name:            user.Name,
+		ProjectId:       user.ProjectId,
+		Enabled:         user.Enabled,
+		PendingApproval: user.PendingApproval,
+		Expiry:          user.Expiry,
+		ProfilePic:      user.ProfilePic,
+		LinkedClientId:  user.LinkedClientId,
+		Roles:           fromSdkUserRoleMapToModel(user.Roles),
+		Resources:       fromSdkUserResourceMapToModel(user.Resources),
+		Policies:        fromSdkUserPoliciesToModel(user.Policies),
+		GroupRoles:      user.GroupRoles,
+		CreatedAt:       user.CreatedAt,
+		CreatedBy:       user.CreatedBy,
+		UpdatedAt:       user.UpdatedAt,
+		UpdatedBy:       user.UpdatedBy,
 	}
 }
 
 func fromModelToSdk(user *models.User) *sdk.User {
 	return &sdk.User{
-		Id:             user.Id,
-		Email:          user.Email,
-		Phone:          user.Phone,
-		Name:           user.Name,
-		ProfilePic:     user.ProfilePic,
-		ProjectId:      user.ProjectId,
-		Expiry:         user.Expiry,
-		Enabled:        user.Enabled,
-		LinkedClientId: user.LinkedClientId,
-		Roles:          fromModelUserRoleMapToSdk(user.Roles),
-		Resources:      fromModelUserResourceMapToSdk(user.Resources),
-		Policies:       fromModelUserPoliciesToSdk(user.Policies),
-		CreatedAt:      user.CreatedAt,
-		CreatedBy:      user.CreatedBy,
-		UpdatedAt:      user.UpdatedAt,
-		UpdatedBy:      user.UpdatedBy,
+		Id:              user.Id,
+		Email:           user.Email,
+		EmailVerified:   user.EmailVerified,
+		Phone:           user.Phone,
+		Name:            user.Name,
+		ProfilePic:      user.ProfilePic,
+		ProjectId:       user.ProjectId,
+		Expiry:          user.Expiry,
+		Enabled:         user.Enabled,
+		PendingApproval: user.PendingApproval,
+		LinkedClientId:  user.LinkedClientId,
+		Roles:           fromModelUserRoleMapToSdk(user.Roles),
+		Resources:       fromModelUserResourceMapToSdk(user.Resources),
+		Policies:        fromModelUserPoliciesToSdk(user.Policies),
+		GroupRoles:      user.GroupRoles,
+		CreatedAt:       user.CreatedAt,
+		CreatedBy:       user.CreatedBy,
+		UpdatedAt:       user.UpdatedAt,
+		UpdatedBy:       user.UpdatedBy,
 	}
 }
 
@@ -150,6 +156,21 @@ func fromModelListToSdk(users []models.User) []sdk.User {
 	return result
 }
 
+// heldGroupRoles returns the sources of the roles granted through groups that the user still has
+func heldGroupRoles(groupRoles map[string][]string, roles map[string]sdk.UserRole) map[string][]string {
+	var held map[string][]string
+	for roleId, identityIds := range groupRoles {
+		if _, ok := roles[roleId]; !ok {
+			continue
+		}
+		if held == nil {
+			held = map[string][]string{}
+		}
+		held[roleId] = identityIds
+	}
+	return held
+}
+
 func removeRoleFromUserObj(user *sdk.User, role sdk.Role) {
 	// Ensure user's fields are initialized
 	if user.Roles == nil {
@@ -16,6 +16,7 @@ type Service interface {
 	GetAll(ctx context.Context, query sdk.UserQuery) (*sdk.UserList, error)
 	AddRoleToUser(ctx context.Context, userId, roleId string) error
 	RemoveRoleFromUser(ctx context.Context, userId, roleId string) error
+	SyncGroupRoles(ctx context.Context, userId, identityId string, roleIds []string) (*sdk.User, error)
 	AddResourceToUser(ctx context.Context, userId string, request sdk.AddUserResourceRequest) error
 	AddPolicyToUser(ctx context.Context, userId string, policies map[string]sdk.UserPolicy) error
 	RemovePolicyFromUser(ctx context.Context, userId string, policyIds []string) error
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/melvinodsa/go-iam/middlewares"
@@ -77,9 +79,14 @@ func (s *service) AddRoleToUser(ctx context.Context, userId, roleId string) erro
 		return err
 	}
 
-	// Skip if role already exists
+	// Skip if role already exists, one granted through groups is taken over so that leaving the groups keeps it
 	if _, exists := user.Roles[role.Id]; exists {
-		return nil
+		if len(user.GroupRoles[role.Id]) == 0 {
+			return nil
+		}
+		groupRoles := maps.Clone(user.GroupRoles)
+		delete(groupRoles, role.Id)
+		return s.store.UpdateGroupRoles(ctx, userId, groupRoles)
 	}
 
 	addRoleToUserObj(user, *role)
@@ -127,6 +134,90 @@ func (s *service) RemoveRoleFromUser(ctx context.Context, userId, roleId string)
 	return nil
 }
 
+// SyncGroupRoles sets the roles the groups of the identity at its auth provider grant to the user. The ids of the
+// identities granting a role are kept on the user and the role is removed once none of them grants it any more.
+func (s *service) SyncGroupRoles(ctx context.Context, userId, identityId string, roleIds []string) (*sdk.User, error) {
+	/*
+	 * record the identity as granting the roles, adding the roles the user doesn't have yet
+	 * the roles the user has that no identity grants were assigned by an admin and are not taken over
+	 * drop the identity from the roles it no longer grants
+	 * remove the roles no identity grants any more
+	 * save the identities granting the roles before the roles, the update keeps them as they are
+	 */
+	if userId == "" || identityId == "" {
+		return nil, errors.New("user ID and identity ID are required")
+	}
+	user, err := s.GetById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	groupRoles := map[string][]string{}
+	for roleId, identityIds := range user.GroupRoles {
+		groupRoles[roleId] = slices.Clone(identityIds)
+	}
+	granted := map[string]bool{}
+	rolesChanged := false
+	for _, roleId := range roleIds {
+		if granted[roleId] {
+			continue
+		}
+		granted[roleId] = true
+		_, has := user.Roles[roleId]
+		if has && len(groupRoles[roleId]) == 0 {
+			continue
+		}
+		if !has {
+			role, err := s.roleSvc.GetById(ctx, roleId)
+			if err != nil {
+				return nil, err
+			}
+			addRoleToUserObj(user, *role)
+			rolesChanged = true
+		}
+		if !slices.Contains(groupRoles[roleId], identityId) {
+			groupRoles[roleId] = append(groupRoles[roleId], identityId)
+		}
+	}
+	for roleId, identityIds := range groupRoles {
+		if granted[roleId] || !slices.Contains(identityIds, identityId) {
+			continue
+		}
+		identityIds = slices.DeleteFunc(identityIds, func(id string) bool { return id == identityId })
+		if len(identityIds) > 0 {
+			groupRoles[roleId] = identityIds
+			continue
+		}
+		delete(groupRoles, roleId)
+		if _, has := user.Roles[roleId]; !has {
+			continue
+		}
+		role, err := s.roleSvc.GetById(ctx, roleId)
+		if err != nil {
+			return nil, err
+		}
+		removeRoleFromUserObj(user, *role)
+		rolesChanged = true
+	}
+
+	if !maps.EqualFunc(groupRoles, user.GroupRoles, slices.Equal) {
+		err = s.store.UpdateGroupRoles(ctx, userId, groupRoles)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save the group roles of the user: %w", err)
+		}
+		user.GroupRoles = groupRoles
+	}
+	if !rolesChanged {
+		return user, nil
+	}
+	err = s.store.Update(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update the roles of the user: %w", err)
+	}
+	s.Emit(newEvent(ctx, goiamuniverse.EventUserUpdated, *user, middlewares.GetMetadata(ctx)))
+	return user, nil
+}
+
 func (s *service) AddResourceToUser(ctx context.Context, userId string, request sdk.AddUserResourceRequest) error {
 	usr, err := s.store.GetById(ctx, userId)
 	if err != nil {
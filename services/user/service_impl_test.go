@@ -3,6 +3,7 @@ package user
 import (
 	"context"
 	"errors"
+	"maps"
 	"testing"
 	"time"
 
@@ -57,6 +58,11 @@ func (m *MockStore) RemoveResourceFromAll(ctx context.Context, resourceKey strin
 	return args.Error(0)
 }
 
+func (m *MockStore) UpdateGroupRoles(ctx context.Context, userId string, groupRoles map[string][]string) error {
+	args := m.Called(ctx, userId, groupRoles)
+	return args.Error(0)
+}
+
 // Test helper to create a test user
 func createTestUser() *sdk.User {
 	now := time.Now()
@@ -548,6 +554,19 @@ func TestAddRoleToUser(t *testing.T) {
 				// No Update call expected since role already exists
 			},
 		},
+		{
+			name:   "success - role granted through groups is taken over",
+			userId: "user-123",
+			roleId: "role-123",
+			setupMocks: func() {
+				userWithRole := createTestUser()
+				userWithRole.Roles["role-123"] = sdk.UserRole{Id: "role-123", Name: "Test Role"}
+				userWithRole.GroupRoles = map[string][]string{"role-123": {"identity-1"}, "role-456": {"identity-1"}}
+				mockStore.On("GetById", ctx, "user-123").Return(userWithRole, nil)
+				mockRoleService.On("GetById", ctx, "role-123").Return(testRole, nil)
+				mockStore.On("UpdateGroupRoles", ctx, "user-123", map[string][]string{"role-456": {"identity-1"}}).Return(nil)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -573,6 +592,123 @@ func TestAddRoleToUser(t *testing.T) {
 	}
 }
 
+// groupRolesStore keeps the user it saves, so that the group role syncs see the changes of the previous ones
+type groupRolesStore struct {
+	MockStore
+	user   *sdk.User
+	writes int
+}
+
+func (s *groupRolesStore) GetById(ctx context.Context, id string) (*sdk.User, error) {
+	usr := *s.user
+	usr.Roles = maps.Clone(s.user.Roles)
+	usr.Resources = maps.Clone(s.user.Resources)
+	usr.GroupRoles = maps.Clone(s.user.GroupRoles)
+	return &usr, nil
+}
+
+func (s *groupRolesStore) Update(ctx context.Context, user *sdk.User) error {
+	s.writes++
+	s.user.Roles = maps.Clone(user.Roles)
+	s.user.Resources = maps.Clone(user.Resources)
+	return nil
+}
+
+func (s *groupRolesStore) UpdateGroupRoles(ctx context.Context, userId string, groupRoles map[string][]string) error {
+	s.writes++
+	s.user.GroupRoles = groupRoles
+	return nil
+}
+
+// TestSyncGroupRoles tests that the roles granted through groups follow the identities granting them
+func TestSyncGroupRoles(t *testing.T) {
+	ctx := createContextWithMetadata()
+
+	setup := func(usr *sdk.User) (*service, *groupRolesStore, *services.MockRoleService) {
+		svc, _, mockRoleService := setupUserService()
+		store := &groupRolesStore{user: usr}
+		svc.store = store
+		for _, roleId := range []string{"role-ops", "role-admin", "role-manual"} {
+			mockRoleService.On("GetById", ctx, roleId).Return(&sdk.Role{Id: roleId, Name: roleId}, nil).Maybe()
+		}
+		return svc, store, mockRoleService
+	}
+
+	t.Run("granted roles are added and recorded", func(t *testing.T) {
+		usr := &sdk.User{Id: "user-1", Roles: map[string]sdk.UserRole{"role-manual": {Id: "role-manual"}}}
+		svc, _, _ := setup(usr)
+
+		result, err := svc.SyncGroupRoles(ctx, "user-1", "identity-1", []string{"role-ops", "role-manual", "role-ops"})
+		require.NoError(t, err)
+		assert.Contains(t, result.Roles, "role-ops")
+		assert.Contains(t, result.Roles, "role-manual")
+		// the role assigned by an admin is not taken over
+		assert.Equal(t, map[string][]string{"role-ops": {"identity-1"}}, usr.GroupRoles)
+	})
+
+	t.Run("a role stays while another identity grants it", func(t *testing.T) {
+		usr := &sdk.User{Id: "user-1"}
+		svc, _, _ := setup(usr)
+
+		_, err := svc.SyncGroupRoles(ctx, "user-1", "identity-1", []string{"role-ops", "role-admin"})
+		require.NoError(t, err)
+		_, err = svc.SyncGroupRoles(ctx, "user-1", "identity-2", []string{"role-ops"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{"role-ops": {"identity-1", "identity-2"}, "role-admin": {"identity-1"}}, usr.GroupRoles)
+
+		// identity-1 left the groups
+		result, err := svc.SyncGroupRoles(ctx, "user-1", "identity-1", []string{})
+		require.NoError(t, err)
+		assert.Contains(t, result.Roles, "role-ops")
+		assert.NotContains(t, result.Roles, "role-admin")
+		assert.Equal(t, map[string][]string{"role-ops": {"identity-2"}}, usr.GroupRoles)
+	})
+
+	t.Run("unlinking the identities after a group sync removes their roles", func(t *testing.T) {
+		usr := &sdk.User{Id: "user-1", Roles: map[string]sdk.UserRole{"role-manual": {Id: "role-manual"}}}
+		svc, _, _ := setup(usr)
+
+		_, err := svc.SyncGroupRoles(ctx, "user-1", "identity-1", []string{"role-ops"})
+		require.NoError(t, err)
+		_, err = svc.SyncGroupRoles(ctx, "user-1", "identity-2", []string{"role-ops", "role-admin"})
+		require.NoError(t, err)
+
+		// unlinking syncs the identity without roles
+		result, err := svc.SyncGroupRoles(ctx, "user-1", "identity-2", nil)
+		require.NoError(t, err)
+		assert.Contains(t, result.Roles, "role-ops")
+		assert.NotContains(t, result.Roles, "role-admin")
+
+		result, err = svc.SyncGroupRoles(ctx, "user-1", "identity-1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]sdk.UserRole{"role-manual": {Id: "role-manual"}}, result.Roles)
+		assert.Empty(t, usr.GroupRoles)
+	})
+
+	t.Run("nothing is saved when the roles are in sync", func(t *testing.T) {
+		usr := &sdk.User{
+			Id:         "user-1",
+			Roles:      map[string]sdk.UserRole{"role-ops": {Id: "role-ops"}},
+			GroupRoles: map[string][]string{"role-ops": {"identity-1"}},
+		}
+		svc, store, _ := setup(usr)
+
+		_, err := svc.SyncGroupRoles(ctx, "user-1", "identity-1", []string{"role-ops"})
+		require.NoError(t, err)
+		assert.Zero(t, store.writes)
+	})
+
+	t.Run("error - role not found", func(t *testing.T) {
+		svc, mockStore, mockRoleService := setupUserService()
+		mockStore.On("GetById", ctx, "user-1").Return(&sdk.User{Id: "user-1"}, nil)
+		mockRoleService.On("GetById", ctx, "role-999").Return((*sdk.Role)(nil), errors.New("role not found"))
+
+		_, err := svc.SyncGroupRoles(ctx, "user-1", "identity-1", []string{"role-999"})
+		assert.ErrorContains(t, err, "role not found")
+		mockStore.AssertNotCalled(t, "UpdateGroupRoles", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
 // TestRemoveRoleFromUser tests the RemoveRoleFromUser method
 func TestRemoveRoleFromUser(t *testing.T) {
 	ctx := createContextWithMetadata()
@@ -14,4 +14,7 @@ type Store interface {
 	GetByPhone(ctx context.Context, phone string, projectId string) (*sdk.User, error)
 	GetAll(ctx context.Context, query sdk.UserQuery) (*sdk.UserList, error)
 	RemoveResourceFromAll(ctx context.Context, resourceKey string) error
+	// UpdateGroupRoles saves the ids of the identities granting the user each role through groups.
+	// Update keeps them as they are, dropping those of the roles the user no longer has.
+	UpdateGroupRoles(ctx context.Context, userId string, groupRoles map[string][]string) error
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2/log"
@@ -13,6 +15,7 @@ import (
 	"github.com/melvinodsa/go-iam/middlewares"
 	"github.com/melvinodsa/go-iam/sdk"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -31,7 +34,8 @@ func (s *store) Create(ctx context.Context, user *sdk.User) error {
 	id := uuid.New().String()
 	user.Id = id
 	t := time.Now()
-	user.Enabled = true
+	// users pending approval stay disabled until an admin enables them
+	user.Enabled = !user.PendingApproval
 	user.CreatedAt = &t
 	d := fromSdkToModel(*user)
 
@@ -55,6 +59,15 @@ func (s *store) Update(ctx context.Context, user *sdk.User) error {
 	}
 	user.CreatedAt = o.CreatedAt
 	user.CreatedBy = o.CreatedBy
+	user.GroupRoles = heldGroupRoles(o.GroupRoles, user.Roles)
+	// enabling a user approves them
+	if user.Enabled {
+		user.PendingApproval = false
+	}
+	// the verification of the email address is kept until the address changes
+	if o.EmailVerified && strings.EqualFold(o.Email, user.Email) {
+		user.EmailVerified = true
+	}
 	d := fromSdkToModel(*user)
 	md := models.GetUserModel()
 	_, err = s.db.UpdateOne(ctx, md, bson.D{{Key: md.IdKey, Value: user.Id}}, bson.D{{Key: "$set", Value: d}})
@@ -67,7 +80,9 @@ func (s *store) Update(ctx context.Context, user *sdk.User) error {
 func (s *store) GetByEmail(ctx context.Context, email string, projectId string) (*sdk.User, error) {
 	md := models.GetUserModel()
 	var usr models.User
-	err := s.db.FindOne(ctx, md, bson.D{{Key: md.EmailKey, Value: email}, {Key: md.ProjectIDKey, Value: projectId}}).Decode(&usr)
+	// emails are matched case insensitively, the users may have been saved with the case the admin or the provider used
+	emailFilter := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(strings.TrimSpace(email)) + "$", Options: "i"}
+	err := s.db.FindOne(ctx, md, bson.D{{Key: md.EmailKey, Value: emailFilter}, {Key: md.ProjectIDKey, Value: projectId}}).Decode(&usr)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, ErrorUserNotFound
@@ -163,6 +178,16 @@ func (s *store) GetAll(ctx context.Context, query sdk.UserQuery) (*sdk.UserList,
 	}, nil
 }
 
+func (s *store) UpdateGroupRoles(ctx context.Context, userId string, groupRoles map[string][]string) error {
+	md := models.GetUserModel()
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: md.GroupRolesKey, Value: groupRoles}}}}
+	_, err := s.db.UpdateOne(ctx, md, bson.D{{Key: md.IdKey, Value: userId}}, update)
+	if err != nil {
+		return fmt.Errorf("error updating the group roles of the user: %w", err)
+	}
+	return nil
+}
+
 func (s *store) RemoveResourceFromAll(ctx context.Context, resourceKey string) error {
 	md := models.GetUserModel()
 	filter := bson.D{{Key: fmt.Sprintf("%s.%s", md.ResourcesKey, resourceKey), Value: bson.D{{Key: "$exists", Value: true}}}}
@@ -3,12 +3,14 @@ package user
 import (
 	"context"
 	"errors"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
@@ -117,9 +119,21 @@ func TestStoreCreate(t *testing.T) {
 		err := s.Create(ctx, user)
 
 		assert.NoError(t, err)
+		assert.True(t, user.Enabled)
 		mockDB.AssertExpectations(t)
 	})
 
+	t.Run("pending_approval_stays_disabled", func(t *testing.T) {
+		mockDB.ExpectedCalls = nil
+		user := &sdk.User{ProjectId: "project-123", Email: "test@example.com", PendingApproval: true}
+		mockDB.On("InsertOne", ctx, mock.Anything, mock.Anything).Return(&mongo.InsertOneResult{}, nil)
+
+		err := s.Create(ctx, user)
+
+		assert.NoError(t, err)
+		assert.False(t, user.Enabled)
+	})
+
 	t.Run("error", func(t *testing.T) {
 		mockDB.ExpectedCalls = nil
 		user := &sdk.User{
@@ -201,6 +215,51 @@ func TestStoreUpdate(t *testing.T) {
 		mockDB.AssertExpectations(t)
 	})
 
+	t.Run("enabling_approves_the_user", func(t *testing.T) {
+		mockDB.ExpectedCalls = nil
+		userDoc, _ := bson.Marshal(models.User{Id: "user-123", ProjectId: "project-123", PendingApproval: true})
+		mockDB.On("FindOne", ctx, mock.Anything, mock.Anything).Return(mongo.NewSingleResultFromDocument(userDoc, nil, nil))
+		mockDB.On("UpdateOne", ctx, mock.Anything, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		user := &sdk.User{Id: "user-123", ProjectId: "project-123", Enabled: true, PendingApproval: true}
+
+		err := s.Update(ctx, user)
+
+		assert.NoError(t, err)
+		assert.False(t, user.PendingApproval)
+	})
+
+	t.Run("email_verification_kept_until_the_email_changes", func(t *testing.T) {
+		existingUser := models.User{Id: "user-123", ProjectId: "project-123", Email: "john@example.com", EmailVerified: true}
+		userDoc, _ := bson.Marshal(existingUser)
+		for email, verified := range map[string]bool{"John@example.com": true, "new@example.com": false} {
+			mockDB.ExpectedCalls = nil
+			mockDB.On("FindOne", ctx, mock.Anything, mock.Anything).Return(mongo.NewSingleResultFromDocument(userDoc, nil, nil))
+			mockDB.On("UpdateOne", ctx, mock.Anything, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+			user := &sdk.User{Id: "user-123", ProjectId: "project-123", Email: email}
+
+			err := s.Update(ctx, user)
+
+			assert.NoError(t, err)
+			assert.Equal(t, verified, user.EmailVerified, email)
+		}
+	})
+
+	t.Run("group_roles_kept_for_the_roles_the_user_has", func(t *testing.T) {
+		mockDB.ExpectedCalls = nil
+		userDoc, _ := bson.Marshal(models.User{Id: "user-123", ProjectId: "project-123", GroupRoles: map[string][]string{
+			"role-ops":   {"identity-1"},
+			"role-admin": {"identity-1", "identity-2"},
+		}})
+		mockDB.On("FindOne", ctx, mock.Anything, mock.Anything).Return(mongo.NewSingleResultFromDocument(userDoc, nil, nil))
+		mockDB.On("UpdateOne", ctx, mock.Anything, mock.Anything, mock.Anything).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+		user := &sdk.User{Id: "user-123", ProjectId: "project-123", Roles: map[string]sdk.UserRole{"role-admin": {Id: "role-admin"}}}
+
+		err := s.Update(ctx, user)
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]string{"role-admin": {"identity-1", "identity-2"}}, user.GroupRoles)
+	})
+
 	t.Run("update_database_error", func(t *testing.T) {
 		mockDB.ExpectedCalls = nil
 		now := time.Now()
@@ -234,6 +293,23 @@ func TestStoreUpdate(t *testing.T) {
 	})
 }
 
+// TestStoreUpdateGroupRoles tests the UpdateGroupRoles method
+func TestStoreUpdateGroupRoles(t *testing.T) {
+	ctx := createContextWithProjects()
+	mockDB := &MockDB{}
+	s := NewStore(mockDB)
+	md := models.GetUserModel()
+	groupRoles := map[string][]string{"role-ops": {"identity-1"}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: md.GroupRolesKey, Value: groupRoles}}}}
+
+	mockDB.On("UpdateOne", ctx, md, bson.D{{Key: md.IdKey, Value: "user-123"}}, update).Return(&mongo.UpdateResult{MatchedCount: 1}, nil).Once()
+	assert.NoError(t, s.UpdateGroupRoles(ctx, "user-123", groupRoles))
+
+	mockDB.On("UpdateOne", ctx, md, bson.D{{Key: md.IdKey, Value: "user-123"}}, update).Return((*mongo.UpdateResult)(nil), errors.New("database error")).Once()
+	assert.ErrorContains(t, s.UpdateGroupRoles(ctx, "user-123", groupRoles), "error updating the group roles of the user")
+	mockDB.AssertExpectations(t)
+}
+
 // TestStoreGetById tests the GetById method
 func TestStoreGetById(t *testing.T) {
 	ctx := createContextWithProjects()
@@ -322,6 +398,21 @@ func TestStoreGetByEmail(t *testing.T) {
 		mockDB.AssertExpectations(t)
 	})
 
+	t.Run("matches_the_email_case_insensitively", func(t *testing.T) {
+		mockDB.ExpectedCalls = nil
+		userDoc, _ := bson.Marshal(models.User{Id: "user-123", ProjectId: "project-123", Email: "Test.User@Example.com"})
+		mockDB.On("FindOne", ctx, mock.Anything, mock.MatchedBy(func(filter bson.D) bool {
+			re, ok := filter[0].Value.(primitive.Regex)
+			return ok && re.Options == "i" && regexp.MustCompile("(?i)"+re.Pattern).MatchString("Test.User@Example.com") && !regexp.MustCompile("(?i)"+re.Pattern).MatchString("testxuser@example.com")
+		})).Return(mongo.NewSingleResultFromDocument(userDoc, nil, nil))
+
+		result, err := s.GetByEmail(ctx, " test.user@example.com ", "project-123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user-123", result.Id)
+		mockDB.AssertExpectations(t)
+	})
+
 	t.Run("not_found", func(t *testing.T) {
 		mockDB.ExpectedCalls = nil
 		email := "nonexistent@example.com"
@@ -22,6 +22,7 @@ type ApiWrapper struct {
 	Parameters           []ApiParameter  `json:"parameters,omitempty"`
 	UnAuthenticated      bool            `json:"unauthenticated,omitempty"`
 	ProjectIDNotRequired bool            `json:"projectIdNotRequired,omitempty"`
+	Deprecated           bool            `json:"deprecated,omitempty"`
 }
 
 type ApiParameter struct {
@@ -117,6 +118,7 @@ func generateDocsForApi(apiss []ApiWrapper) (*openapi3.PathItem, error) {
 			Summary:     api.Name,
 			Description: api.Description,
 			Tags:        api.Tags,
+			Deprecated:  api.Deprecated,
 		}
 
 		// Add request body if provided
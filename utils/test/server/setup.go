@@ -30,7 +30,7 @@ func GetServices(cnf config.AppConfig, cS cache.Service, d db.DB) (*providers.Se
 
 	jwtSvc := jwt.NewService(cnf.Jwt.Secret())
 
-	svcs := providers.NewServices(d, cS, enc, jwtSvc, cnf.Server.TokenCacheTTLInMinutes, cnf.Server.AuthProviderRefetchIntervalInMinutes)
+	svcs := providers.NewServices(d, cS, enc, jwtSvc, cnf)
 
 	mockClientSvc := services.MockClientService{}
 	mockProjectSvc := services.MockProjectService{}
@@ -13,8 +13,13 @@ type MockAuthService struct {
 	mock.Mock
 }
 
-func (m *MockAuthService) GetLoginUrl(ctx context.Context, clientId, authProviderId, state, redirectUrl, codeChallengeMethod, codeChallenge string) (string, error) {
-	args := m.Called(ctx, clientId, authProviderId, state, redirectUrl, codeChallengeMethod, codeChallenge)
+func (m *MockAuthService) GetLoginUrl(ctx context.Context, params sdk.AuthLoginParams) (string, error) {
+	args := m.Called(ctx, params)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) StartIdentityLink(ctx context.Context, usr sdk.User, req sdk.UserIdentityLinkRequest) (string, error) {
+	args := m.Called(ctx, usr, req)
 	return args.String(0), args.Error(1)
 }
 
@@ -26,8 +31,145 @@ func (m *MockAuthService) Redirect(ctx context.Context, code, state string) (*sd
 	return args.Get(0).(*sdk.AuthRedirectResponse), args.Error(1)
 }
 
-func (m *MockAuthService) ClientCallback(ctx context.Context, code, codeChallenge, clientId, clietSecret string) (*sdk.AuthVerifyCodeResponse, error) {
-	args := m.Called(ctx, code, codeChallenge, clientId, clietSecret)
+func (m *MockAuthService) GetConsentPrompt(ctx context.Context, consentChallenge string) (*sdk.ConsentPrompt, error) {
+	args := m.Called(ctx, consentChallenge)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.ConsentPrompt), args.Error(1)
+}
+
+func (m *MockAuthService) GetMfaPrompt(ctx context.Context, mfaChallenge string) (*sdk.MfaPrompt, error) {
+	args := m.Called(ctx, mfaChallenge)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.MfaPrompt), args.Error(1)
+}
+
+func (m *MockAuthService) VerifyMfa(ctx context.Context, req sdk.MfaVerifyRequest) (*sdk.MfaVerifyResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.MfaVerifyResponse), args.Error(1)
+}
+
+func (m *MockAuthService) StartMfaPasskey(ctx context.Context, req sdk.MfaPasskeyStartRequest) (*sdk.WebAuthnRequestOptions, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.WebAuthnRequestOptions), args.Error(1)
+}
+
+func (m *MockAuthService) VerifyMfaPasskey(ctx context.Context, req sdk.MfaPasskeyVerifyRequest) (*sdk.MfaVerifyResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.MfaVerifyResponse), args.Error(1)
+}
+
+func (m *MockAuthService) DiscoverLogin(ctx context.Context, req sdk.LoginDiscoveryRequest) (*sdk.LoginDiscoveryResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.LoginDiscoveryResponse), args.Error(1)
+}
+
+func (m *MockAuthService) PasskeyLoginStart(ctx context.Context, req sdk.PasskeyLoginStartRequest) (*sdk.WebAuthnRequestOptions, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.WebAuthnRequestOptions), args.Error(1)
+}
+
+func (m *MockAuthService) PasskeyLoginVerify(ctx context.Context, req sdk.PasskeyLoginVerifyRequest) (*sdk.AuthRedirectResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.AuthRedirectResponse), args.Error(1)
+}
+
+func (m *MockAuthService) SamlAcs(ctx context.Context, req sdk.SamlAcsRequest) (*sdk.AuthRedirectResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.AuthRedirectResponse), args.Error(1)
+}
+
+func (m *MockAuthService) SamlMetadata(ctx context.Context, authProviderId string) ([]byte, error) {
+	args := m.Called(ctx, authProviderId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockAuthService) LdapLogin(ctx context.Context, req sdk.LdapLoginRequest, ip string) (*sdk.AuthRedirectResponse, error) {
+	args := m.Called(ctx, req, ip)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.AuthRedirectResponse), args.Error(1)
+}
+
+func (m *MockAuthService) DecideConsent(ctx context.Context, decision sdk.ConsentDecision) (*sdk.AuthRedirectResponse, error) {
+	args := m.Called(ctx, decision)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.AuthRedirectResponse), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeConsent(ctx context.Context, userId, clientId string) error {
+	args := m.Called(ctx, userId, clientId)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) PasswordLogin(ctx context.Context, req sdk.PasswordLoginRequest, ip string) (*sdk.AuthRedirectResponse, error) {
+	args := m.Called(ctx, req, ip)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.AuthRedirectResponse), args.Error(1)
+}
+
+func (m *MockAuthService) PasswordSignup(ctx context.Context, req sdk.PasswordSignupRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ForgotPassword(ctx context.Context, req sdk.PasswordForgotRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) StartPasswordless(ctx context.Context, req sdk.PasswordlessStartRequest, ip string) error {
+	args := m.Called(ctx, req, ip)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) StartSmsOtp(ctx context.Context, req sdk.SmsOtpStartRequest, ip string) error {
+	args := m.Called(ctx, req, ip)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) PasswordlessVerify(ctx context.Context, req sdk.PasswordlessVerifyRequest) (*sdk.AuthRedirectResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.AuthRedirectResponse), args.Error(1)
+}
+
+func (m *MockAuthService) ClientCallback(ctx context.Context, code, codeVerifier, clientId, clietSecret string) (*sdk.AuthVerifyCodeResponse, error) {
+	args := m.Called(ctx, code, codeVerifier, clientId, clietSecret)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -58,3 +200,52 @@ func (m *MockAuthService) ClientCredentials(ctx context.Context, clientId, clien
 	}
 	return args.Get(0).(*sdk.AuthVerifyCodeResponse), args.Error(1)
 }
+
+func (m *MockAuthService) GetJwks() sdk.Jwks {
+	args := m.Called()
+	return args.Get(0).(sdk.Jwks)
+}
+
+func (m *MockAuthService) GetOpenIdConfiguration() sdk.OpenIdConfiguration {
+	args := m.Called()
+	return args.Get(0).(sdk.OpenIdConfiguration)
+}
+
+func (m *MockAuthService) RefreshToken(ctx context.Context, refreshToken, clientId, clientSecret string) (*sdk.AuthVerifyCodeResponse, error) {
+	args := m.Called(ctx, refreshToken, clientId, clientSecret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.AuthVerifyCodeResponse), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeToken(ctx context.Context, token, tokenTypeHint, clientId, clientSecret string) error {
+	args := m.Called(ctx, token, tokenTypeHint, clientId, clientSecret)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) Logout(ctx context.Context, accessToken, clientId, postLogoutRedirectUrl, state string) (string, error) {
+	args := m.Called(ctx, accessToken, clientId, postLogoutRedirectUrl, state)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeUserTokens(ctx context.Context, userId string) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) IntrospectToken(ctx context.Context, token, clientId, clientSecret string, includeRoles bool) (*sdk.TokenIntrospectionResponse, error) {
+	args := m.Called(ctx, token, clientId, clientSecret, includeRoles)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.TokenIntrospectionResponse), args.Error(1)
+}
+
+func (m *MockAuthService) Token(ctx context.Context, req sdk.TokenRequest) (*sdk.AuthVerifyCodeResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.AuthVerifyCodeResponse), args.Error(1)
+}
@@ -36,6 +36,11 @@ func (m *MockAuthProviderService) GetProvider(ctx context.Context, v sdk.AuthPro
 	return args.Get(0).(sdk.ServiceProvider), args.Error(1)
 }
 
+func (m *MockAuthProviderService) GetTypes(ctx context.Context) []sdk.AuthProviderTypeInfo {
+	args := m.Called(ctx)
+	return args.Get(0).([]sdk.AuthProviderTypeInfo)
+}
+
 // MockServiceProvider implements sdk.ServiceProvider interface for testing
 type MockServiceProvider struct {
 	mock.Mock
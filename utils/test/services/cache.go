@@ -22,6 +22,11 @@ func (m *MockCacheService) Get(ctx context.Context, key string) (string, error)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockCacheService) GetDel(ctx context.Context, key string) (string, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockCacheService) Delete(ctx context.Context, key string) error {
 	args := m.Called(ctx, key)
 	return args.Error(0)
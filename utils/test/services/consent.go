@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockConsentService implements consent.Service interface for testing
+type MockConsentService struct {
+	mock.Mock
+}
+
+func (m *MockConsentService) Get(ctx context.Context, userId, clientId string) (*sdk.Consent, error) {
+	args := m.Called(ctx, userId, clientId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.Consent), args.Error(1)
+}
+
+func (m *MockConsentService) List(ctx context.Context, userId string) ([]sdk.Consent, error) {
+	args := m.Called(ctx, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]sdk.Consent), args.Error(1)
+}
+
+func (m *MockConsentService) Grant(ctx context.Context, userId string, client sdk.Client, scopes []string) (*sdk.Consent, error) {
+	args := m.Called(ctx, userId, client, scopes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.Consent), args.Error(1)
+}
+
+func (m *MockConsentService) Revoke(ctx context.Context, userId, clientId string) error {
+	args := m.Called(ctx, userId, clientId)
+	return args.Error(0)
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockIdentityService implements identity.Service interface for testing
+type MockIdentityService struct {
+	mock.Mock
+}
+
+func (m *MockIdentityService) Get(ctx context.Context, authProviderId, subject string) (*sdk.UserIdentity, error) {
+	args := m.Called(ctx, authProviderId, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.UserIdentity), args.Error(1)
+}
+
+func (m *MockIdentityService) List(ctx context.Context, userId string) ([]sdk.UserIdentity, error) {
+	args := m.Called(ctx, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]sdk.UserIdentity), args.Error(1)
+}
+
+func (m *MockIdentityService) Link(ctx context.Context, identity *sdk.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockIdentityService) RecordLogin(ctx context.Context, identity *sdk.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockIdentityService) Unlink(ctx context.Context, userId, id string) error {
+	args := m.Called(ctx, userId, id)
+	return args.Error(0)
+}
@@ -1,6 +1,9 @@
 package services
 
 import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -18,3 +21,41 @@ func (m *MockJWTService) ValidateToken(token string) (map[string]interface{}, er
 	args := m.Called(token)
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
+
+func (m *MockJWTService) GenerateIdToken(claims map[string]interface{}, expiryTimeInSeconds int64) (string, error) {
+	args := m.Called(claims, expiryTimeInSeconds)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockJWTService) Jwks() sdk.Jwks {
+	args := m.Called()
+	return args.Get(0).(sdk.Jwks)
+}
+
+func (m *MockJWTService) GetKeys(ctx context.Context) ([]sdk.SigningKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]sdk.SigningKey), args.Error(1)
+}
+
+func (m *MockJWTService) RotateKey(ctx context.Context) (*sdk.SigningKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.SigningKey), args.Error(1)
+}
+
+func (m *MockJWTService) RetireKey(ctx context.Context, id string) (*sdk.SigningKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.SigningKey), args.Error(1)
+}
+
+func (m *MockJWTService) RunKeyRotation(ctx context.Context) {
+	m.Called(ctx)
+}
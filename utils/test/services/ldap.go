@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLdapService implements ldap.Service interface for testing
+type MockLdapService struct {
+	mock.Mock
+}
+
+func (m *MockLdapService) Login(ctx context.Context, provider sdk.AuthProvider, username, password, ip string) (string, error) {
+	args := m.Called(ctx, provider, username, password, ip)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockLdapService) ExchangeCode(ctx context.Context, code string) (*sdk.LdapIdentity, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.LdapIdentity), args.Error(1)
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMfaService implements mfa.Service interface for testing
+type MockMfaService struct {
+	mock.Mock
+}
+
+func (m *MockMfaService) Status(ctx context.Context, usr sdk.User) (*sdk.MfaStatus, error) {
+	args := m.Called(ctx, usr)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.MfaStatus), args.Error(1)
+}
+
+func (m *MockMfaService) Enroll(ctx context.Context, usr sdk.User) (*sdk.MfaEnrollment, error) {
+	args := m.Called(ctx, usr)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.MfaEnrollment), args.Error(1)
+}
+
+func (m *MockMfaService) Confirm(ctx context.Context, usr sdk.User, code string) ([]string, error) {
+	args := m.Called(ctx, usr, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMfaService) Verify(ctx context.Context, usr sdk.User, code string) error {
+	args := m.Called(ctx, usr, code)
+	return args.Error(0)
+}
+
+func (m *MockMfaService) RegenerateRecoveryCodes(ctx context.Context, usr sdk.User, code string) ([]string, error) {
+	args := m.Called(ctx, usr, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMfaService) Disable(ctx context.Context, usr sdk.User, code string) error {
+	args := m.Called(ctx, usr, code)
+	return args.Error(0)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPasskeyService implements passkey.Service interface for testing
+type MockPasskeyService struct {
+	mock.Mock
+}
+
+func (m *MockPasskeyService) BeginRegistration(ctx context.Context, usr sdk.User) (*sdk.WebAuthnCreationOptions, error) {
+	args := m.Called(ctx, usr)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.WebAuthnCreationOptions), args.Error(1)
+}
+
+func (m *MockPasskeyService) FinishRegistration(ctx context.Context, usr sdk.User, name string, credential sdk.WebAuthnAttestation) (*sdk.Passkey, error) {
+	args := m.Called(ctx, usr, name, credential)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.Passkey), args.Error(1)
+}
+
+func (m *MockPasskeyService) BeginLogin(ctx context.Context, projectId, session string, usr *sdk.User) (*sdk.WebAuthnRequestOptions, error) {
+	args := m.Called(ctx, projectId, session, usr)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.WebAuthnRequestOptions), args.Error(1)
+}
+
+func (m *MockPasskeyService) FinishLogin(ctx context.Context, projectId, session string, credential sdk.WebAuthnAssertion) (*sdk.Passkey, error) {
+	args := m.Called(ctx, projectId, session, credential)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.Passkey), args.Error(1)
+}
+
+func (m *MockPasskeyService) List(ctx context.Context, userId string) ([]sdk.Passkey, error) {
+	args := m.Called(ctx, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]sdk.Passkey), args.Error(1)
+}
+
+func (m *MockPasskeyService) Delete(ctx context.Context, userId, id string) error {
+	args := m.Called(ctx, userId, id)
+	return args.Error(0)
+}
+
+func (m *MockPasskeyService) IssueLoginCode(ctx context.Context, address string) (string, error) {
+	args := m.Called(ctx, address)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPasskeyService) ExchangeCode(ctx context.Context, code string) (string, error) {
+	args := m.Called(ctx, code)
+	return args.String(0), args.Error(1)
+}
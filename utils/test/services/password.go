@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPasswordService implements password.Service interface for testing
+type MockPasswordService struct {
+	mock.Mock
+}
+
+func (m *MockPasswordService) Login(ctx context.Context, provider sdk.AuthProvider, email, password, ip string) (string, error) {
+	args := m.Called(ctx, provider, email, password, ip)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPasswordService) Signup(ctx context.Context, provider sdk.AuthProvider, req sdk.PasswordSignupRequest) error {
+	args := m.Called(ctx, provider, req)
+	return args.Error(0)
+}
+
+func (m *MockPasswordService) Verify(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordService) ExchangeCode(ctx context.Context, code string) (*sdk.PasswordCredential, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.PasswordCredential), args.Error(1)
+}
+
+func (m *MockPasswordService) Get(ctx context.Context, id string) (*sdk.PasswordCredential, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.PasswordCredential), args.Error(1)
+}
+
+func (m *MockPasswordService) Change(ctx context.Context, projectId, email string, req sdk.PasswordChangeRequest) error {
+	args := m.Called(ctx, projectId, email, req)
+	return args.Error(0)
+}
+
+func (m *MockPasswordService) Forgot(ctx context.Context, provider sdk.AuthProvider, email string) error {
+	args := m.Called(ctx, provider, email)
+	return args.Error(0)
+}
+
+func (m *MockPasswordService) Reset(ctx context.Context, req sdk.PasswordResetRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
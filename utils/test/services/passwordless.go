@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPasswordlessService implements passwordless.Service interface for testing
+type MockPasswordlessService struct {
+	mock.Mock
+}
+
+func (m *MockPasswordlessService) Start(ctx context.Context, provider sdk.AuthProvider, state, email, ip string) error {
+	args := m.Called(ctx, provider, state, email, ip)
+	return args.Error(0)
+}
+
+func (m *MockPasswordlessService) VerifyOtp(ctx context.Context, state, otp string) (string, error) {
+	args := m.Called(ctx, state, otp)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPasswordlessService) VerifyLink(ctx context.Context, token string) (string, string, error) {
+	args := m.Called(ctx, token)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockPasswordlessService) ExchangeCode(ctx context.Context, code string) (string, error) {
+	args := m.Called(ctx, code)
+	return args.String(0), args.Error(1)
+}
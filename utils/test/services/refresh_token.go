@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+
+	"github.com/melvinodsa/go-iam/sdk"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRefreshTokenService implements refreshtoken.Service interface for testing
+type MockRefreshTokenService struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenService) Create(ctx context.Context, userId string, token sdk.AuthToken) (string, error) {
+	args := m.Called(ctx, userId, token)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenService) Rotate(ctx context.Context, refreshToken, clientId string, clientAuthenticated bool) (*sdk.RefreshToken, string, error) {
+	args := m.Called(ctx, refreshToken, clientId, clientAuthenticated)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).(*sdk.RefreshToken), args.String(1), args.Error(2)
+}
+
+func (m *MockRefreshTokenService) RevokeFamily(ctx context.Context, familyId string) error {
+	args := m.Called(ctx, familyId)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenService) Revoke(ctx context.Context, refreshToken, clientId string) error {
+	args := m.Called(ctx, refreshToken, clientId)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenService) RevokeUser(ctx context.Context, userId string) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenService) RevokeUserClient(ctx context.Context, userId, clientId string) error {
+	args := m.Called(ctx, userId, clientId)
+	return args.Error(0)
+}
@@ -56,6 +56,14 @@ func (m *MockUserService) RemoveRoleFromUser(ctx context.Context, userId, roleId
 	return args.Error(0)
 }
 
+func (m *MockUserService) SyncGroupRoles(ctx context.Context, userId, identityId string, roleIds []string) (*sdk.User, error) {
+	args := m.Called(ctx, userId, identityId, roleIds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sdk.User), args.Error(1)
+}
+
 func (m *MockUserService) AddResourceToUser(ctx context.Context, userId string, request sdk.AddUserResourceRequest) error {
 	args := m.Called(ctx, userId, request)
 	return args.Error(0)